@@ -66,9 +66,22 @@ func main() {
 	mc := metric.NewDummyCollector()
 	if conf.EnableMetrics {
 		// TODO: Ingress class is not a part of dataplane anymore
-		mc, err = metric.NewCollector(conf.MetricsPerHost, conf.MetricsPerUndefinedHost, conf.ReportStatusClasses, reg, conf.IngressClassConfiguration.Controller, *conf.MetricsBuckets, conf.MetricsBucketFactor, conf.MetricsMaxBuckets, conf.ExcludeSocketMetrics)
+		mc, err = metric.NewCollectorByName(conf.MetricsCollector, &metric.Options{
+			MetricsPerHost:              conf.MetricsPerHost,
+			MetricsPerUndefinedHost:     conf.MetricsPerUndefinedHost,
+			ReportStatusClasses:         conf.ReportStatusClasses,
+			MetricsPerEndpoint:          conf.MetricsPerEndpoint,
+			MetricsPerEndpointMaxSeries: conf.MetricsPerEndpointMaxSeries,
+			Registry:                    reg,
+			IngressClass:                conf.IngressClassConfiguration.Controller,
+			Buckets:                     *conf.MetricsBuckets,
+			BucketFactor:                conf.MetricsBucketFactor,
+			MaxBuckets:                  conf.MetricsMaxBuckets,
+			ExcludedSocketMetrics:       conf.ExcludeSocketMetrics,
+			MetricsLabels:               conf.MetricsLabels,
+		})
 		if err != nil {
-			klog.Fatalf("Error creating prometheus collector:  %v", err)
+			klog.Fatalf("Error creating metric collector:  %v", err)
 		}
 	}
 	// Pass the ValidationWebhook status to determine if we need to start the collector
@@ -20,6 +20,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 
 	"github.com/spf13/cobra"
@@ -30,6 +33,8 @@ const (
 	backendsPath = "/configuration/backends"
 	generalPath  = "/configuration/general"
 	certsPath    = "/configuration/certs"
+	explainPath  = "/debug/explain"
+	logLevelPath = "/debug/log-level"
 )
 
 func main() {
@@ -108,6 +113,32 @@ func main() {
 	}
 	rootCmd.AddCommand(confCmd)
 
+	explainCmd := &cobra.Command{
+		Use:   "explain [namespace/name]",
+		Short: "Output the server and location blocks rendered for an Ingress and the annotations that influenced them",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			explain(args[0])
+		},
+	}
+	rootCmd.AddCommand(explainCmd)
+
+	var verbosity int
+	var errorLogLevel, duration string
+	var revert bool
+	logLevelCmd := &cobra.Command{
+		Use:   "log-level",
+		Short: "Output the log levels of the controller and NGINX, or change them for a limited duration",
+		Run: func(_ *cobra.Command, _ []string) {
+			logLevel(verbosity, errorLogLevel, duration, revert)
+		},
+	}
+	logLevelCmd.Flags().IntVar(&verbosity, "verbosity", -1, "Verbosity of the logs of the controller")
+	logLevelCmd.Flags().StringVar(&errorLogLevel, "error-log-level", "", "Level of the error log of NGINX")
+	logLevelCmd.Flags().StringVar(&duration, "duration", "", "Duration of the change, after which the log levels are reverted (default --log-level-duration of the controller)")
+	logLevelCmd.Flags().BoolVar(&revert, "revert", false, "Revert the log levels changed at runtime")
+	rootCmd.AddCommand(logLevelCmd)
+
 	rootCmd.PersistentFlags().IntVar(&nginx.StatusPort, "status-port", 10246, `Port to use for the lua HTTP endpoint configuration.`)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -240,6 +271,60 @@ func general() {
 	fmt.Println(prettyBuffer.String())
 }
 
+func logLevel(verbosity int, errorLogLevel, duration string, revert bool) {
+	method := http.MethodGet
+	query := url.Values{}
+	switch {
+	case revert:
+		method = http.MethodDelete
+	case verbosity >= 0 || errorLogLevel != "":
+		method = http.MethodPut
+		if verbosity >= 0 {
+			query.Set("v", fmt.Sprint(verbosity))
+		}
+		if errorLogLevel != "" {
+			query.Set("error-log-level", errorLogLevel)
+		}
+		if duration != "" {
+			query.Set("duration", duration)
+		}
+	}
+
+	u := fmt.Sprintf("http://%v:%v%v?%v", nginx.ProfilerAddress, nginx.ProfilerPort, logLevelPath, query.Encode())
+	req, err := http.NewRequest(method, u, http.NoBody)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	client := http.Client{}
+	res, err := client.Do(req)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		fmt.Printf("Controller returned code %v: %s\n", res.StatusCode, body)
+		return
+	}
+
+	var prettyBuffer bytes.Buffer
+	indentErr := json.Indent(&prettyBuffer, body, "", "  ")
+	if indentErr != nil {
+		fmt.Println(indentErr)
+		return
+	}
+
+	fmt.Println(prettyBuffer.String())
+}
+
 func readNginxConf() {
 	conf, err := nginx.ReadNginxConf()
 	if err != nil {
@@ -249,3 +334,34 @@ func readNginxConf() {
 
 	fmt.Println(conf)
 }
+
+func explain(ing string) {
+	u := fmt.Sprintf("http://%v:%v%v?ingress=%v", nginx.ProfilerAddress, nginx.ProfilerPort, explainPath, url.QueryEscape(ing))
+
+	client := http.Client{}
+	res, err := client.Get(u)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		fmt.Printf("Controller returned code %v: %s\n", res.StatusCode, body)
+		return
+	}
+
+	var prettyBuffer bytes.Buffer
+	indentErr := json.Indent(&prettyBuffer, body, "", "  ")
+	if indentErr != nil {
+		fmt.Println(indentErr)
+		return
+	}
+
+	fmt.Println(prettyBuffer.String())
+}
@@ -31,6 +31,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 	discovery "k8s.io/apimachinery/pkg/version"
+	"k8s.io/apiserver/pkg/server/healthz"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -71,55 +72,29 @@ func main() {
 
 	kubeClient, err := createApiserverClient(conf.APIServerHost, conf.RootCAFile, conf.KubeConfigFile)
 	if err != nil {
-		handleFatalInitError(err)
-	}
-
-	if conf.DefaultService != "" {
-		err := checkService(conf.DefaultService, kubeClient)
-		if err != nil {
-			klog.Fatal(err)
+		if !controller.ConfigurationSnapshotExists(conf.ConfigurationSnapshot) {
+			handleFatalInitError(err)
 		}
 
-		klog.InfoS("Valid default backend", "service", conf.DefaultService)
-	}
-
-	if conf.PublishService != "" {
-		err := checkService(conf.PublishService, kubeClient)
+		// the controller starts serving the configuration snapshot and
+		// waits until the API server is available to synchronize it
+		klog.Warningf("Unable to connect to the Kubernetes API server, starting with the configuration snapshot %v: %v", conf.ConfigurationSnapshot, err)
+		kubeClient, err = newApiserverClient(conf.APIServerHost, conf.RootCAFile, conf.KubeConfigFile)
 		if err != nil {
-			klog.Fatal(err)
+			handleFatalInitError(err)
 		}
-	}
-
-	if conf.Namespace != "" {
-		_, err = kubeClient.CoreV1().Namespaces().Get(context.TODO(), conf.Namespace, metav1.GetOptions{})
-		if err != nil {
-			klog.Fatalf("No namespace with name %v found: %v", conf.Namespace, err)
+		conf.CheckCluster = func() {
+			checkClusterConfiguration(conf, kubeClient)
 		}
+	} else {
+		checkClusterConfiguration(conf, kubeClient)
 	}
 
 	conf.FakeCertificate = ssl.GetFakeSSLCert()
 	klog.InfoS("SSL fake certificate created", "file", conf.FakeCertificate.PemFileName)
 
-	if !k8s.NetworkingIngressAvailable(kubeClient) {
-		klog.Fatalf("ingress-nginx requires Kubernetes v1.19.0 or higher")
-	}
-
-	_, err = kubeClient.NetworkingV1().IngressClasses().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		if !errors.IsNotFound(err) {
-			if errors.IsForbidden(err) {
-				klog.Warningf("No permissions to list and get Ingress Classes: %v, IngressClass feature will be disabled", err)
-				conf.IngressClassConfiguration.IgnoreIngressClass = true
-			}
-		}
-	}
 	conf.Client = kubeClient
 
-	err = k8s.GetIngressPod(kubeClient)
-	if err != nil {
-		klog.Fatalf("Unexpected error obtaining ingress-nginx pod: %v", err)
-	}
-
 	reg := prometheus.NewRegistry()
 
 	reg.MustRegister(collectors.NewGoCollector())
@@ -130,24 +105,50 @@ func main() {
 
 	mc := metric.NewDummyCollector()
 	if conf.EnableMetrics {
-		mc, err = metric.NewCollector(conf.MetricsPerHost, conf.MetricsPerUndefinedHost, conf.ReportStatusClasses, reg, conf.IngressClassConfiguration.Controller, *conf.MetricsBuckets, conf.MetricsBucketFactor, conf.MetricsMaxBuckets, conf.ExcludeSocketMetrics)
+		mc, err = metric.NewCollectorByName(conf.MetricsCollector, &metric.Options{
+			MetricsPerHost:              conf.MetricsPerHost,
+			MetricsPerUndefinedHost:     conf.MetricsPerUndefinedHost,
+			ReportStatusClasses:         conf.ReportStatusClasses,
+			MetricsPerEndpoint:          conf.MetricsPerEndpoint,
+			MetricsPerEndpointMaxSeries: conf.MetricsPerEndpointMaxSeries,
+			Registry:                    reg,
+			IngressClass:                conf.IngressClassConfiguration.Controller,
+			Buckets:                     *conf.MetricsBuckets,
+			BucketFactor:                conf.MetricsBucketFactor,
+			MaxBuckets:                  conf.MetricsMaxBuckets,
+			ExcludedSocketMetrics:       conf.ExcludeSocketMetrics,
+			MetricsLabels:               conf.MetricsLabels,
+		})
 		if err != nil {
-			klog.Fatalf("Error creating prometheus collector:  %v", err)
+			klog.Fatalf("Error creating metric collector:  %v", err)
 		}
 	}
 	// Pass the ValidationWebhook status to determine if we need to start the collector
 	// for the admissionWebhook
 	mc.Start(conf.ValidationWebhook)
 
+	ngx := controller.NewNGINXController(conf, mc)
+
 	if conf.EnableProfiling {
-		go metrics.RegisterProfiler(nginx.ProfilerAddress, nginx.ProfilerPort)
+		go metrics.RegisterProfiler(nginx.ProfilerAddress, nginx.ProfilerPort, metrics.DebugHandler{
+			Path:    controller.ExplainPath,
+			Handler: ngx.ExplainHandler,
+		}, metrics.DebugHandler{
+			Path:    controller.LogLevelPath,
+			Handler: ngx.LogLevelHandler,
+		})
 	}
 
-	ngx := controller.NewNGINXController(conf, mc)
-
 	mux := http.NewServeMux()
-	metrics.RegisterHealthz(nginx.HealthPath, mux, ngx)
+	// the checks of the components are only executed in the verbose health check
+	healthChecks := append([]healthz.HealthChecker{ngx}, ngx.HealthCheckers()...)
+	metrics.RegisterHealthz(nginx.HealthPath, mux, healthChecks...)
 	metrics.RegisterMetrics(reg, mux)
+	mux.HandleFunc(controller.ConfigurationPath, ngx.ConfigurationHandler)
+	mux.HandleFunc(controller.ConfigurationPath+"/", ngx.ConfigurationHandler)
+	mux.HandleFunc(controller.AnnotationUsagePath, ngx.AnnotationUsageHandler)
+	mux.HandleFunc(controller.IgnoredIngressesPath, ngx.IgnoredIngressesHandler)
+	mux.HandleFunc(controller.StreamRoutesPath, ngx.StreamRoutesHandler)
 
 	_, errExists := os.Stat("/chroot")
 	if errExists == nil {
@@ -163,48 +164,62 @@ func main() {
 	})
 }
 
-// createApiserverClient creates a new Kubernetes REST client. apiserverHost is
-// the URL of the API server in the format protocol://address:port/pathPrefix,
-// kubeConfig is the location of a kubeconfig file. If defined, the kubeconfig
-// file is loaded first, the URL of the API server read from the file is then
-// optionally overridden by the value of apiserverHost.
-// If neither apiserverHost nor kubeConfig is passed in, we assume the
-// controller runs inside Kubernetes and fallback to the in-cluster config. If
-// the in-cluster config is missing or fails, we fallback to the default config.
-func createApiserverClient(apiserverHost, rootCAFile, kubeConfig string) (*kubernetes.Clientset, error) {
-	cfg, err := clientcmd.BuildConfigFromFlags(apiserverHost, kubeConfig)
-	if err != nil {
-		return nil, err
+// checkClusterConfiguration checks the resources referenced in the configuration
+// exist in the cluster and loads the information of the ingress-nginx pod
+func checkClusterConfiguration(conf *controller.Configuration, kubeClient *kubernetes.Clientset) {
+	if conf.DefaultService != "" {
+		err := checkService(conf.DefaultService, kubeClient)
+		if err != nil {
+			klog.Fatal(err)
+		}
+
+		klog.InfoS("Valid default backend", "service", conf.DefaultService)
 	}
 
-	// TODO: remove after k8s v1.22
-	cfg.WarningHandler = rest.NoWarnings{}
+	if conf.PublishService != "" {
+		err := checkService(conf.PublishService, kubeClient)
+		if err != nil {
+			klog.Fatal(err)
+		}
+	}
 
-	// Configure the User-Agent used for the HTTP requests made to the API server.
-	cfg.UserAgent = fmt.Sprintf(
-		"%s/%s (%s/%s) ingress-nginx/%s",
-		filepath.Base(os.Args[0]),
-		version.RELEASE,
-		runtime.GOOS,
-		runtime.GOARCH,
-		version.COMMIT,
-	)
+	for _, namespace := range k8s.ParseNamespaces(conf.Namespace) {
+		_, err := kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+		if err != nil {
+			klog.Fatalf("No namespace with name %v found: %v", namespace, err)
+		}
+	}
 
-	if apiserverHost != "" && rootCAFile != "" {
-		tlsClientConfig := rest.TLSClientConfig{}
+	if !k8s.NetworkingIngressAvailable(kubeClient) {
+		klog.Fatalf("ingress-nginx requires Kubernetes v1.19.0 or higher")
+	}
 
-		if _, err := certutil.NewPool(rootCAFile); err != nil {
-			klog.ErrorS(err, "Loading CA config", "file", rootCAFile)
-		} else {
-			tlsClientConfig.CAFile = rootCAFile
+	_, err := kubeClient.NetworkingV1().IngressClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			if errors.IsForbidden(err) {
+				klog.Warningf("No permissions to list and get Ingress Classes: %v, IngressClass feature will be disabled", err)
+				conf.IngressClassConfiguration.IgnoreIngressClass = true
+			}
 		}
-
-		cfg.TLSClientConfig = tlsClientConfig
 	}
 
-	klog.InfoS("Creating API client", "host", cfg.Host)
+	err = k8s.GetIngressPod(kubeClient)
+	if err != nil {
+		klog.Fatalf("Unexpected error obtaining ingress-nginx pod: %v", err)
+	}
+}
 
-	client, err := kubernetes.NewForConfig(cfg)
+// createApiserverClient creates a new Kubernetes REST client. apiserverHost is
+// the URL of the API server in the format protocol://address:port/pathPrefix,
+// kubeConfig is the location of a kubeconfig file. If defined, the kubeconfig
+// file is loaded first, the URL of the API server read from the file is then
+// optionally overridden by the value of apiserverHost.
+// If neither apiserverHost nor kubeConfig is passed in, we assume the
+// controller runs inside Kubernetes and fallback to the in-cluster config. If
+// the in-cluster config is missing or fails, we fallback to the default config.
+func createApiserverClient(apiserverHost, rootCAFile, kubeConfig string) (*kubernetes.Clientset, error) {
+	client, err := newApiserverClient(apiserverHost, rootCAFile, kubeConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -257,6 +272,44 @@ func createApiserverClient(apiserverHost, rootCAFile, kubeConfig string) (*kuber
 	return client, nil
 }
 
+// newApiserverClient creates a new Kubernetes REST client without checking
+// the connection to the API server
+func newApiserverClient(apiserverHost, rootCAFile, kubeConfig string) (*kubernetes.Clientset, error) {
+	cfg, err := clientcmd.BuildConfigFromFlags(apiserverHost, kubeConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	// TODO: remove after k8s v1.22
+	cfg.WarningHandler = rest.NoWarnings{}
+
+	// Configure the User-Agent used for the HTTP requests made to the API server.
+	cfg.UserAgent = fmt.Sprintf(
+		"%s/%s (%s/%s) ingress-nginx/%s",
+		filepath.Base(os.Args[0]),
+		version.RELEASE,
+		runtime.GOOS,
+		runtime.GOARCH,
+		version.COMMIT,
+	)
+
+	if apiserverHost != "" && rootCAFile != "" {
+		tlsClientConfig := rest.TLSClientConfig{}
+
+		if _, err := certutil.NewPool(rootCAFile); err != nil {
+			klog.ErrorS(err, "Loading CA config", "file", rootCAFile)
+		} else {
+			tlsClientConfig.CAFile = rootCAFile
+		}
+
+		cfg.TLSClientConfig = tlsClientConfig
+	}
+
+	klog.InfoS("Creating API client", "host", cfg.Host)
+
+	return kubernetes.NewForConfig(cfg)
+}
+
 // Handler for fatal init errors. Prints a verbose error message and exits.
 func handleFatalInitError(err error) {
 	klog.Fatalf("Error while initiating a connection to the Kubernetes API server. "+
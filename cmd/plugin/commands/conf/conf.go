@@ -42,11 +42,28 @@ func CreateCommand(flags *genericclioptions.ConfigFlags) *cobra.Command {
 				return err
 			}
 
+			asJSON, err := cmd.Flags().GetBool("json")
+			if err != nil {
+				return err
+			}
+
+			if asJSON {
+				port, err := cmd.Flags().GetInt("healthz-port")
+				if err != nil {
+					return err
+				}
+
+				util.PrintError(runningConf(flags, host, *pod, *deployment, *selector, port))
+				return nil
+			}
+
 			util.PrintError(conf(flags, host, *pod, *deployment, *selector, *container))
 			return nil
 		},
 	}
 	cmd.Flags().String("host", "", "Print just the server block with this hostname")
+	cmd.Flags().Bool("json", false, "Print the running configuration in JSON instead of nginx.conf")
+	cmd.Flags().Int("healthz-port", 10254, "Port of the status endpoints of the ingress controller, used with --json")
 	pod = util.AddPodFlag(cmd)
 	deployment = util.AddDeploymentFlag(cmd)
 	selector = util.AddSelectorFlag(cmd)
@@ -79,3 +96,25 @@ func conf(flags *genericclioptions.ConfigFlags, host, podName, deployment, selec
 
 	return nil
 }
+
+// runningConf prints the running configuration returned by the configuration
+// endpoint of the pod, through the proxy of the API server
+func runningConf(flags *genericclioptions.ConfigFlags, host, podName, deployment, selector string, port int) error {
+	pod, err := request.ChoosePod(flags, podName, deployment, selector)
+	if err != nil {
+		return err
+	}
+
+	path := fmt.Sprintf("/api/v1/namespaces/%v/pods/%v:%v/proxy/configuration", pod.Namespace, pod.Name, port)
+	if host != "" {
+		path = fmt.Sprintf("%v/servers/%v", path, host)
+	}
+
+	out, err := kubectl.ExecToString(flags, []string{"get", "--raw", path})
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+	return nil
+}
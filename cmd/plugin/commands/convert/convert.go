@@ -0,0 +1,213 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package convert
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/ingress-nginx/cmd/plugin/conversions"
+	"k8s.io/ingress-nginx/cmd/plugin/request"
+	"k8s.io/ingress-nginx/cmd/plugin/util"
+)
+
+// lastAppliedAnnotation is the annotation containing the configuration applied with kubectl
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+type convertOptions struct {
+	flags         *genericclioptions.ConfigFlags
+	filename      string
+	allNamespaces bool
+	ingressClass  string
+	reportOnly    bool
+}
+
+// CreateCommand creates and returns this cobra subcommand
+func CreateCommand(flags *genericclioptions.ConfigFlags) *cobra.Command {
+	opts := convertOptions{
+		flags: flags,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "Convert the annotations of other ingress controllers to ingress-nginx annotations",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			util.PrintError(convert(opts, cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr()))
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.filename, "filename", "f", "", "File containing the Ingresses to convert, - for the standard input. The Ingresses of the cluster are converted if empty")
+	cmd.Flags().BoolVar(&opts.allNamespaces, "all-namespaces", false, "Convert the Ingresses of all namespaces")
+	cmd.Flags().StringVar(&opts.ingressClass, "ingress-class", "", "Replace the class of the converted Ingresses")
+	cmd.Flags().BoolVar(&opts.reportOnly, "report-only", false, "Only print the report of the conversion")
+
+	return cmd
+}
+
+func convert(opts convertOptions, stdin io.Reader, stdout, stderr io.Writer) error {
+	ings, err := readIngresses(opts, stdin)
+	if err != nil {
+		return err
+	}
+
+	convertOpts := conversions.Options{
+		Dialects:     conversions.Dialects(),
+		IngressClass: opts.ingressClass,
+	}
+
+	for i := range ings {
+		converted, results := conversions.Convert(&ings[i], convertOpts)
+		printReport(stderr, &ings[i], results)
+
+		if opts.reportOnly {
+			continue
+		}
+
+		data, err := yaml.Marshal(cleanIngress(converted))
+		if err != nil {
+			return fmt.Errorf("unexpected error encoding Ingress %v/%v: %w", ings[i].Namespace, ings[i].Name, err)
+		}
+
+		fmt.Fprintf(stdout, "---\n%s", data)
+	}
+
+	return nil
+}
+
+func readIngresses(opts convertOptions, stdin io.Reader) ([]networking.Ingress, error) {
+	switch opts.filename {
+	case "":
+		namespace := util.GetNamespace(opts.flags)
+		if opts.allNamespaces {
+			namespace = ""
+		}
+
+		return request.GetIngressDefinitions(opts.flags, namespace)
+	case "-":
+		return decodeIngresses(stdin)
+	}
+
+	f, err := os.Open(opts.filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return decodeIngresses(f)
+}
+
+// decodeIngresses returns the Ingresses of a YAML or JSON stream, ignoring
+// the documents containing other kinds of objects
+func decodeIngresses(r io.Reader) ([]networking.Ingress, error) {
+	ings := []networking.Ingress{}
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(r))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return ings, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var typeMeta metav1.TypeMeta
+		if err := yaml.Unmarshal(doc, &typeMeta); err != nil {
+			return nil, err
+		}
+
+		if typeMeta.Kind != "Ingress" {
+			continue
+		}
+
+		var ing networking.Ingress
+		if err := yaml.Unmarshal(doc, &ing); err != nil {
+			return nil, err
+		}
+
+		ings = append(ings, ing)
+	}
+}
+
+// cleanIngress removes the fields of the Ingress managed by the API server
+func cleanIngress(ing *networking.Ingress) *networking.Ingress {
+	ing.APIVersion = networking.SchemeGroupVersion.String()
+	ing.Kind = "Ingress"
+
+	ing.ObjectMeta = metav1.ObjectMeta{
+		Name:        ing.Name,
+		Namespace:   ing.Namespace,
+		Labels:      ing.Labels,
+		Annotations: ing.Annotations,
+	}
+	delete(ing.Annotations, lastAppliedAnnotation)
+
+	ing.Status = networking.IngressStatus{}
+
+	return ing
+}
+
+func printReport(w io.Writer, ing *networking.Ingress, results []conversions.Result) {
+	if len(results) == 0 {
+		fmt.Fprintf(w, "✓ %v/%v\n", ing.Namespace, ing.Name)
+		return
+	}
+
+	status := "✓"
+	for i := range results {
+		if results[i].Status == conversions.Unsupported {
+			status = "✗"
+		}
+	}
+
+	fmt.Fprintf(w, "%v %v/%v\n", status, ing.Namespace, ing.Name)
+	for i := range results {
+		r := &results[i]
+		fmt.Fprintf(w, "  - %v: %q %v", r.Annotation, r.Value, r.Status)
+
+		if len(r.Annotations) > 0 {
+			converted := make([]string, 0, len(r.Annotations))
+			for name, value := range r.Annotations {
+				converted = append(converted, fmt.Sprintf("%v/%v: %q", conversions.AnnotationsPrefix, name, value))
+			}
+			sort.Strings(converted)
+
+			fmt.Fprintf(w, " to %v", strings.Join(converted, ", "))
+		}
+
+		fmt.Fprintln(w)
+
+		if r.Note != "" {
+			fmt.Fprintf(w, "      %v\n", r.Note)
+		}
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loglevel
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"k8s.io/ingress-nginx/cmd/plugin/kubectl"
+	"k8s.io/ingress-nginx/cmd/plugin/request"
+	"k8s.io/ingress-nginx/cmd/plugin/util"
+)
+
+// CreateCommand creates and returns this cobra subcommand
+func CreateCommand(flags *genericclioptions.ConfigFlags) *cobra.Command {
+	var pod, deployment, selector, container *string
+	cmd := &cobra.Command{
+		Use:   "log-level",
+		Short: "Inspect or temporarily change the log levels of an ingress-nginx instance",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			verbosity, err := cmd.Flags().GetInt("verbosity")
+			if err != nil {
+				return err
+			}
+			errorLogLevel, err := cmd.Flags().GetString("error-log-level")
+			if err != nil {
+				return err
+			}
+			duration, err := cmd.Flags().GetString("duration")
+			if err != nil {
+				return err
+			}
+			revert, err := cmd.Flags().GetBool("revert")
+			if err != nil {
+				return err
+			}
+			if revert && (verbosity >= 0 || errorLogLevel != "") {
+				return fmt.Errorf("--revert cannot be specified with --verbosity or --error-log-level")
+			}
+
+			util.PrintError(logLevel(flags, *pod, *deployment, *selector, *container, verbosity, errorLogLevel, duration, revert))
+			return nil
+		},
+	}
+
+	pod = util.AddPodFlag(cmd)
+	deployment = util.AddDeploymentFlag(cmd)
+	selector = util.AddSelectorFlag(cmd)
+	container = util.AddContainerFlag(cmd)
+
+	cmd.Flags().Int("verbosity", -1, "Change the verbosity of the logs of the controller")
+	cmd.Flags().String("error-log-level", "", "Change the level of the error log of NGINX, which reloads NGINX")
+	cmd.Flags().String("duration", "", "Duration of the change, after which the log levels are reverted (default --log-level-duration of the controller)")
+	cmd.Flags().Bool("revert", false, "Revert the log levels changed at runtime")
+
+	return cmd
+}
+
+func logLevel(flags *genericclioptions.ConfigFlags, podName, deployment, selector, container string, verbosity int, errorLogLevel, duration string, revert bool) error {
+	command := []string{"/dbg", "log-level"}
+	switch {
+	case revert:
+		command = append(command, "--revert")
+	case verbosity >= 0 || errorLogLevel != "":
+		if verbosity >= 0 {
+			command = append(command, fmt.Sprintf("--verbosity=%v", verbosity))
+		}
+		if errorLogLevel != "" {
+			command = append(command, "--error-log-level="+errorLogLevel)
+		}
+		if duration != "" {
+			command = append(command, "--duration="+duration)
+		}
+	}
+
+	pod, err := request.ChoosePod(flags, podName, deployment, selector)
+	if err != nil {
+		return err
+	}
+
+	out, err := kubectl.PodExecString(flags, &pod, container, command)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(out)
+	return nil
+}
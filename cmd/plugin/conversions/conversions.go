@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+)
+
+// AnnotationsPrefix is the prefix of the annotations of ingress-nginx
+const AnnotationsPrefix = "nginx.ingress.kubernetes.io"
+
+// ingressClassAnnotation is the deprecated annotation defining the class of an Ingress
+const ingressClassAnnotation = "kubernetes.io/ingress.class"
+
+// Status is the result of the conversion of an annotation
+type Status string
+
+const (
+	// Converted annotations are replaced by annotations of ingress-nginx
+	Converted Status = "converted"
+	// Unnecessary annotations configure a behavior ingress-nginx provides
+	// without configuration, they are removed
+	Unnecessary Status = "unnecessary"
+	// Unsupported annotations have no equivalent in the annotations of
+	// ingress-nginx, they are kept unchanged
+	Unsupported Status = "unsupported"
+)
+
+// Result is the result of the conversion of an annotation of an Ingress
+type Result struct {
+	Ingress    string
+	Annotation string
+	Value      string
+	Status     Status
+	// Annotations are the annotations of ingress-nginx replacing the annotation
+	Annotations map[string]string
+	// Note explains why the annotation is not converted, or the differences
+	// of behavior to review when it is converted
+	Note string
+}
+
+// Options configures the conversion of the Ingresses
+type Options struct {
+	// Dialects are the annotations of the other ingress controllers to convert
+	Dialects []Dialect
+	// IngressClass, if not empty, replaces the class of the Ingresses
+	IngressClass string
+}
+
+// Convert returns a copy of the Ingress using the annotations of ingress-nginx
+// instead of the annotations of the dialects, and the result of the
+// conversion of each annotation of the dialects, sorted by annotation
+func Convert(ing *networking.Ingress, opts Options) (*networking.Ingress, []Result) {
+	converted := ing.DeepCopy()
+	results := []Result{}
+
+	names := make([]string, 0, len(ing.Annotations))
+	for name := range ing.Annotations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		dialect, ok := findDialect(opts.Dialects, name)
+		if !ok {
+			continue
+		}
+
+		result := dialect.convert(strings.TrimPrefix(name, dialect.Prefix+"/"), ing.Annotations[name], ing.Annotations)
+		result.Ingress = ing.Namespace + "/" + ing.Name
+		result.Annotation = name
+
+		if result.Status == Converted {
+			if err := addAnnotations(converted, ing, result.Annotations); err != nil {
+				result.Status = Unsupported
+				result.Annotations = nil
+				result.Note = err.Error()
+			}
+		}
+
+		if result.Status != Unsupported {
+			delete(converted.Annotations, name)
+		}
+
+		results = append(results, result)
+	}
+
+	if opts.IngressClass != "" {
+		class := opts.IngressClass
+		converted.Spec.IngressClassName = &class
+		delete(converted.Annotations, ingressClassAnnotation)
+	}
+
+	return converted, results
+}
+
+// addAnnotations adds the annotations of ingress-nginx to the converted
+// Ingress, unless they conflict with annotations of the original Ingress or
+// added by the conversion of other annotations
+func addAnnotations(converted, original *networking.Ingress, annotations map[string]string) error {
+	for name, value := range annotations {
+		current, ok := converted.Annotations[AnnotationsPrefix+"/"+name]
+		if ok && current != value {
+			source := "the Ingress"
+			if _, defined := original.Annotations[AnnotationsPrefix+"/"+name]; !defined {
+				source = "the conversion of another annotation"
+			}
+
+			return fmt.Errorf("the annotation %v/%v is already defined with the value %q by %v", AnnotationsPrefix, name, current, source)
+		}
+	}
+
+	for name, value := range annotations {
+		converted.Annotations[AnnotationsPrefix+"/"+name] = value
+	}
+
+	return nil
+}
+
+func findDialect(dialects []Dialect, annotation string) (Dialect, bool) {
+	for _, dialect := range dialects {
+		if strings.HasPrefix(annotation, dialect.Prefix+"/") {
+			return dialect, true
+		}
+	}
+
+	return Dialect{}, false
+}
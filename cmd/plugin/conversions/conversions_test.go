@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversions
+
+import (
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConvert(t *testing.T) {
+	testcases := map[string]struct {
+		annotations     map[string]string
+		wantAnnotations map[string]string
+		wantStatus      map[string]Status
+	}{
+		"nginx.org annotations": {
+			annotations: map[string]string{
+				"nginx.org/proxy-read-timeout":   "1m",
+				"nginx.org/lb-method":            "ip_hash",
+				"nginx.org/limit-req-rate":       "600r/m",
+				"nginx.org/websocket-services":   "ws",
+				"nginx.org/rewrites":             "serviceName=app rewrite=/",
+				"nginx.org/unknown":              "value",
+				"kubernetes.io/ingress.class":    "nginx-inc",
+				"example.com/unrelated":          "kept",
+				"nginx.org/basic-auth-secret":    "users",
+				"nginx.org/client-max-body-size": "10m",
+			},
+			wantAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/proxy-read-timeout": "60",
+				"nginx.ingress.kubernetes.io/upstream-hash-by":   "$binary_remote_addr",
+				"nginx.ingress.kubernetes.io/limit-rpm":          "600",
+				"nginx.ingress.kubernetes.io/auth-type":          "basic",
+				"nginx.ingress.kubernetes.io/auth-secret":        "users",
+				"nginx.ingress.kubernetes.io/proxy-body-size":    "10m",
+				"nginx.org/rewrites":                             "serviceName=app rewrite=/",
+				"nginx.org/unknown":                              "value",
+				"example.com/unrelated":                          "kept",
+			},
+			wantStatus: map[string]Status{
+				"nginx.org/basic-auth-secret":    Converted,
+				"nginx.org/client-max-body-size": Converted,
+				"nginx.org/lb-method":            Converted,
+				"nginx.org/limit-req-rate":       Converted,
+				"nginx.org/proxy-read-timeout":   Converted,
+				"nginx.org/rewrites":             Unsupported,
+				"nginx.org/unknown":              Unsupported,
+				"nginx.org/websocket-services":   Unnecessary,
+			},
+		},
+		"haproxy annotations": {
+			annotations: map[string]string{
+				"haproxy.org/rate-limit-requests":            "10",
+				"haproxy.org/rate-limit-period":              "1m",
+				"haproxy.org/load-balance":                   "leastconn",
+				"haproxy-ingress.github.io/cors-enable":      "true",
+				"haproxy-ingress.github.io/backend-protocol": "h1-ssl",
+			},
+			wantAnnotations: map[string]string{
+				"nginx.ingress.kubernetes.io/limit-rpm":        "10",
+				"nginx.ingress.kubernetes.io/enable-cors":      "true",
+				"nginx.ingress.kubernetes.io/backend-protocol": "HTTPS",
+				"haproxy.org/load-balance":                     "leastconn",
+			},
+			wantStatus: map[string]Status{
+				"haproxy-ingress.github.io/backend-protocol": Converted,
+				"haproxy-ingress.github.io/cors-enable":      Converted,
+				"haproxy.org/load-balance":                   Unsupported,
+				"haproxy.org/rate-limit-period":              Unnecessary,
+				"haproxy.org/rate-limit-requests":            Converted,
+			},
+		},
+		"conflicting annotations": {
+			annotations: map[string]string{
+				"nginx.org/proxy-read-timeout":                   "30s",
+				"nginx.ingress.kubernetes.io/proxy-read-timeout": "60",
+			},
+			wantAnnotations: map[string]string{
+				"nginx.org/proxy-read-timeout":                   "30s",
+				"nginx.ingress.kubernetes.io/proxy-read-timeout": "60",
+			},
+			wantStatus: map[string]Status{
+				"nginx.org/proxy-read-timeout": Unsupported,
+			},
+		},
+	}
+
+	for title, tc := range testcases {
+		t.Run(title, func(t *testing.T) {
+			ing := &networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   "default",
+					Name:        "app",
+					Annotations: tc.annotations,
+				},
+			}
+
+			converted, results := Convert(ing, Options{Dialects: Dialects(), IngressClass: "nginx"})
+
+			if _, ok := tc.annotations[ingressClassAnnotation]; ok {
+				if _, ok := converted.Annotations[ingressClassAnnotation]; ok {
+					t.Errorf("expected the ingress class annotation to be removed")
+				}
+			}
+
+			if !reflect.DeepEqual(converted.Annotations, tc.wantAnnotations) {
+				t.Errorf("expected annotations %v but returned %v", tc.wantAnnotations, converted.Annotations)
+			}
+
+			if converted.Spec.IngressClassName == nil || *converted.Spec.IngressClassName != "nginx" {
+				t.Errorf("expected the ingress class nginx but returned %v", converted.Spec.IngressClassName)
+			}
+
+			status := map[string]Status{}
+			for _, r := range results {
+				if r.Ingress != "default/app" {
+					t.Errorf("expected the Ingress default/app but returned %v", r.Ingress)
+				}
+				status[r.Annotation] = r.Status
+			}
+
+			if !reflect.DeepEqual(status, tc.wantStatus) {
+				t.Errorf("expected status %v but returned %v", tc.wantStatus, status)
+			}
+
+			if _, ok := ing.Annotations["nginx.ingress.kubernetes.io/limit-rpm"]; ok {
+				t.Errorf("expected the original Ingress to be unchanged")
+			}
+		})
+	}
+}
+
+func TestParseSeconds(t *testing.T) {
+	testcases := map[string]struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		"without unit":     {value: "30", want: 30},
+		"seconds":          {value: "60s", want: 60},
+		"minutes":          {value: "2m", want: 120},
+		"milliseconds":     {value: "1500ms", wantErr: true},
+		"invalid duration": {value: "abc", wantErr: true},
+	}
+
+	for title, tc := range testcases {
+		t.Run(title, func(t *testing.T) {
+			got, err := parseSeconds(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v but returned %v", tc.wantErr, err)
+			}
+
+			if got != tc.want {
+				t.Errorf("expected %v but returned %v", tc.want, got)
+			}
+		})
+	}
+}
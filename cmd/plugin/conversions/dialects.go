@@ -0,0 +1,434 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conversions
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect is the set of annotations of another ingress controller
+type Dialect struct {
+	// Name is the name of the ingress controller using the annotations
+	Name string
+	// Prefix is the prefix of the annotations
+	Prefix string
+	rules  map[string]rule
+}
+
+// rule converts an annotation of a dialect to annotations of ingress-nginx
+type rule struct {
+	// convert returns the annotations of ingress-nginx, without prefix,
+	// equivalent to the value of the annotation. The annotations of the
+	// Ingress are provided to convert annotations depending on others.
+	// A nil convert function means the annotation is not supported.
+	convert func(value string, annotations map[string]string) (map[string]string, error)
+	// note explains the differences of behavior of the converted
+	// annotations, or why the annotation is not converted
+	note string
+}
+
+func (d Dialect) convert(name, value string, annotations map[string]string) Result {
+	r, ok := d.rules[name]
+	if !ok {
+		return Result{Value: value, Status: Unsupported, Note: fmt.Sprintf("unknown annotation of %v", d.Name)}
+	}
+
+	if r.convert == nil {
+		return Result{Value: value, Status: Unsupported, Note: r.note}
+	}
+
+	converted, err := r.convert(value, annotations)
+	if err != nil {
+		return Result{Value: value, Status: Unsupported, Note: err.Error()}
+	}
+
+	if len(converted) == 0 {
+		return Result{Value: value, Status: Unnecessary, Note: r.note}
+	}
+
+	return Result{Value: value, Status: Converted, Annotations: converted, Note: r.note}
+}
+
+// Dialects returns the annotations of the ingress controllers that can be converted
+func Dialects() []Dialect {
+	return []Dialect{
+		{
+			Name:   "NGINX Ingress Controller",
+			Prefix: "nginx.org",
+			rules:  nginxIncRules(),
+		},
+		{
+			Name:   "NGINX Ingress Controller with NGINX Plus",
+			Prefix: "nginx.com",
+			rules:  map[string]rule{},
+		},
+		{
+			Name:   "HAProxy Kubernetes Ingress Controller",
+			Prefix: "haproxy.org",
+			rules:  haproxyRules(),
+		},
+		{
+			Name:   "HAProxy Ingress",
+			Prefix: "haproxy-ingress.github.io",
+			rules:  haproxyIngressRules(),
+		},
+	}
+}
+
+func nginxIncRules() map[string]rule {
+	perServiceNote := "the annotation applies to the services listed in the value, the converted annotation applies to all the services of the Ingress"
+	configMapNote := "configured in the ConfigMap of ingress-nginx"
+
+	return map[string]rule{
+		"ssl-redirect":          {convert: boolean("ssl-redirect")},
+		"redirect-to-https":     {convert: boolean("force-ssl-redirect")},
+		"proxy-connect-timeout": {convert: seconds("proxy-connect-timeout")},
+		"proxy-read-timeout":    {convert: seconds("proxy-read-timeout")},
+		"proxy-send-timeout":    {convert: seconds("proxy-send-timeout")},
+		"client-max-body-size":  {convert: rename("proxy-body-size")},
+		"proxy-buffering":       {convert: onOff("proxy-buffering")},
+		"proxy-buffer-size":     {convert: rename("proxy-buffer-size")},
+		"proxy-buffers": {
+			convert: proxyBuffers,
+			note:    "the size of the buffers is defined by proxy-buffer-size",
+		},
+		"proxy-max-temp-file-size": {convert: rename("proxy-max-temp-file-size")},
+		"server-snippets": {
+			convert: rename("server-snippet"),
+			note:    "snippets require allow-snippet-annotations in the ConfigMap of ingress-nginx",
+		},
+		"location-snippets": {
+			convert: rename("configuration-snippet"),
+			note:    "snippets require allow-snippet-annotations in the ConfigMap of ingress-nginx",
+		},
+		"lb-method":      {convert: nginxLoadBalancing},
+		"ssl-services":   {convert: fixed(map[string]string{"backend-protocol": "HTTPS"}), note: perServiceNote},
+		"grpc-services":  {convert: fixed(map[string]string{"backend-protocol": "GRPC"}), note: perServiceNote},
+		"use-cluster-ip": {convert: boolean("service-upstream")},
+		"websocket-services": {
+			convert: fixed(nil),
+			note:    "ingress-nginx supports WebSocket without configuration",
+		},
+		"mergeable-ingress-type": {
+			convert: fixed(nil),
+			note:    "ingress-nginx merges the Ingresses of the same host without master and minion Ingresses",
+		},
+		"basic-auth-secret": {
+			convert: func(value string, _ map[string]string) (map[string]string, error) {
+				return map[string]string{"auth-type": "basic", "auth-secret": value}, nil
+			},
+			note: "the htpasswd content must be stored in the auth key of the Secret",
+		},
+		"basic-auth-realm": {convert: rename("auth-realm")},
+		"limit-req-rate":   {convert: nginxRateLimit},
+		"rewrites": {
+			note: "the rewrites are defined per service, use an Ingress per service with the rewrite-target annotation",
+		},
+		"hsts":                    {note: "HSTS is " + configMapNote},
+		"hsts-max-age":            {note: "HSTS is " + configMapNote},
+		"hsts-include-subdomains": {note: "HSTS is " + configMapNote},
+		"hsts-behind-proxy":       {note: "HSTS is " + configMapNote},
+		"server-tokens":           {note: "the server tokens are " + configMapNote},
+		"keepalive":               {note: "the keepalive connections to the upstreams are " + configMapNote},
+		"proxy-hide-headers":      {note: "hiding the headers of the responses is " + configMapNote},
+		"proxy-pass-headers":      {note: "ingress-nginx has no equivalent annotation"},
+		"listen-ports":            {note: "the ports are configured with the flags of the controller"},
+		"listen-ports-ssl":        {note: "the ports are configured with the flags of the controller"},
+		"max-fails":               {note: "ingress-nginx has no passive health checks"},
+		"max-conns":               {note: "ingress-nginx has no equivalent annotation"},
+		"fail-timeout":            {note: "ingress-nginx has no passive health checks"},
+	}
+}
+
+func haproxyRules() map[string]rule {
+	return map[string]rule{
+		"ssl-redirect":           {convert: boolean("ssl-redirect")},
+		"ssl-passthrough":        {convert: boolean("ssl-passthrough")},
+		"timeout-connect":        {convert: seconds("proxy-connect-timeout")},
+		"timeout-server":         {convert: seconds("proxy-read-timeout")},
+		"load-balance":           {convert: haproxyLoadBalancing},
+		"cors-enable":            {convert: boolean("enable-cors")},
+		"cors-allow-origin":      {convert: rename("cors-allow-origin")},
+		"cors-allow-methods":     {convert: rename("cors-allow-methods")},
+		"cors-allow-headers":     {convert: rename("cors-allow-headers")},
+		"cors-allow-credentials": {convert: boolean("cors-allow-credentials")},
+		"cors-max-age":           {convert: seconds("cors-max-age")},
+		"allow-list":             {convert: rename("allowlist-source-range")},
+		"whitelist":              {convert: rename("allowlist-source-range")},
+		"deny-list":              {convert: rename("denylist-source-range")},
+		"blacklist":              {convert: rename("denylist-source-range")},
+		"auth-type": {
+			convert: values("auth-type", map[string]string{"basic-auth": "basic"}),
+			note:    "the users must be stored in the auth key of the Secret in htpasswd format",
+		},
+		"auth-secret":       {convert: rename("auth-secret")},
+		"auth-realm":        {convert: rename("auth-realm")},
+		"server-ssl":        {convert: booleanValue("backend-protocol", "HTTPS")},
+		"server-proto":      {convert: values("backend-protocol", map[string]string{"h2": "GRPC"}), note: "HTTP/2 is only supported for gRPC upstreams"},
+		"rate-limit-period": {convert: fixed(nil), note: "converted with rate-limit-requests"},
+		"rate-limit-requests": {
+			convert: func(value string, annotations map[string]string) (map[string]string, error) {
+				return requestsPerPeriod(value, annotations["haproxy.org/rate-limit-period"])
+			},
+		},
+		"path-rewrite":           {note: "the regular expressions of HAProxy are not compatible with rewrite-target"},
+		"backend-config-snippet": {note: "the snippets of HAProxy are not compatible with NGINX"},
+		"frontend-config-snippet": {
+			note: "the snippets of HAProxy are not compatible with NGINX",
+		},
+		"request-set-header":  {note: "the headers sent to the upstreams are configured in the ConfigMap of ingress-nginx"},
+		"response-set-header": {note: "ingress-nginx has no equivalent annotation"},
+		"check":               {note: "ingress-nginx has no active health checks"},
+	}
+}
+
+func haproxyIngressRules() map[string]rule {
+	return map[string]rule{
+		"ssl-redirect":           {convert: boolean("ssl-redirect")},
+		"ssl-passthrough":        {convert: boolean("ssl-passthrough")},
+		"app-root":               {convert: rename("app-root")},
+		"timeout-connect":        {convert: seconds("proxy-connect-timeout")},
+		"timeout-server":         {convert: seconds("proxy-read-timeout")},
+		"balance-algorithm":      {convert: haproxyLoadBalancing},
+		"cors-enable":            {convert: boolean("enable-cors")},
+		"cors-allow-origin":      {convert: rename("cors-allow-origin")},
+		"cors-allow-methods":     {convert: rename("cors-allow-methods")},
+		"cors-allow-headers":     {convert: rename("cors-allow-headers")},
+		"cors-allow-credentials": {convert: boolean("cors-allow-credentials")},
+		"cors-expose-headers":    {convert: rename("cors-expose-headers")},
+		"cors-max-age":           {convert: seconds("cors-max-age")},
+		"allowlist-source-range": {convert: rename("allowlist-source-range")},
+		"whitelist-source-range": {convert: rename("allowlist-source-range")},
+		"denylist-source-range":  {convert: rename("denylist-source-range")},
+		"auth-secret": {
+			convert: func(value string, _ map[string]string) (map[string]string, error) {
+				return map[string]string{"auth-type": "basic", "auth-secret": value}, nil
+			},
+			note: "the users must be stored in the auth key of the Secret in htpasswd format",
+		},
+		"auth-realm": {convert: rename("auth-realm")},
+		"backend-protocol": {
+			convert: values("backend-protocol", map[string]string{"h1": "HTTP", "h1-ssl": "HTTPS", "h2": "GRPC", "h2-ssl": "GRPCS"}),
+			note:    "HTTP/2 is only supported for gRPC upstreams",
+		},
+		"proxy-body-size":     {convert: rename("proxy-body-size")},
+		"limit-rps":           {convert: rename("limit-rps")},
+		"limit-connections":   {convert: rename("limit-connections")},
+		"affinity":            {convert: values("affinity", map[string]string{"cookie": "cookie"})},
+		"session-cookie-name": {convert: rename("session-cookie-name")},
+		"rewrite-target":      {convert: rename("rewrite-target"), note: "the rewrite-target of ingress-nginx replaces the whole path, use a capture group to keep the rest of the path"},
+		"config-backend":      {note: "the snippets of HAProxy are not compatible with NGINX"},
+		"config-frontend":     {note: "the snippets of HAProxy are not compatible with NGINX"},
+		"headers":             {note: "the headers sent to the upstreams are configured in the ConfigMap of ingress-nginx"},
+		"blue-green-deploy":   {note: "use the canary annotations of ingress-nginx in a second Ingress"},
+		"blue-green-balance":  {note: "use the canary annotations of ingress-nginx in a second Ingress"},
+		"backend-check-interval": {
+			note: "ingress-nginx has no active health checks",
+		},
+	}
+}
+
+// rename returns a rule keeping the value of the annotation
+func rename(name string) func(string, map[string]string) (map[string]string, error) {
+	return func(value string, _ map[string]string) (map[string]string, error) {
+		return map[string]string{name: value}, nil
+	}
+}
+
+// fixed returns a rule ignoring the value of the annotation
+func fixed(annotations map[string]string) func(string, map[string]string) (map[string]string, error) {
+	return func(string, map[string]string) (map[string]string, error) {
+		return annotations, nil
+	}
+}
+
+// values returns a rule converting the values of the annotation
+func values(name string, mapping map[string]string) func(string, map[string]string) (map[string]string, error) {
+	return func(value string, _ map[string]string) (map[string]string, error) {
+		converted, ok := mapping[strings.TrimSpace(value)]
+		if !ok {
+			return nil, fmt.Errorf("the value %q has no equivalent in ingress-nginx", value)
+		}
+
+		return map[string]string{name: converted}, nil
+	}
+}
+
+// boolean returns a rule converting a boolean value
+func boolean(name string) func(string, map[string]string) (map[string]string, error) {
+	return func(value string, _ map[string]string) (map[string]string, error) {
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean value %q", value)
+		}
+
+		return map[string]string{name: strconv.FormatBool(b)}, nil
+	}
+}
+
+// booleanValue returns a rule setting an annotation when the value is true
+func booleanValue(name, converted string) func(string, map[string]string) (map[string]string, error) {
+	return func(value string, _ map[string]string) (map[string]string, error) {
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean value %q", value)
+		}
+
+		if !b {
+			return nil, nil
+		}
+
+		return map[string]string{name: converted}, nil
+	}
+}
+
+// onOff returns a rule converting a boolean value to on or off
+func onOff(name string) func(string, map[string]string) (map[string]string, error) {
+	return func(value string, _ map[string]string) (map[string]string, error) {
+		b, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("invalid boolean value %q", value)
+		}
+
+		if b {
+			return map[string]string{name: "on"}, nil
+		}
+
+		return map[string]string{name: "off"}, nil
+	}
+}
+
+// seconds returns a rule converting a duration to a number of seconds
+func seconds(name string) func(string, map[string]string) (map[string]string, error) {
+	return func(value string, _ map[string]string) (map[string]string, error) {
+		s, err := parseSeconds(value)
+		if err != nil {
+			return nil, err
+		}
+
+		return map[string]string{name: strconv.Itoa(s)}, nil
+	}
+}
+
+// parseSeconds parses a duration like 60s, 1m or 1000ms. Values without
+// unit are seconds.
+func parseSeconds(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if s, err := strconv.Atoi(value); err == nil {
+		return s, nil
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+
+	if d%time.Second != 0 {
+		return 0, fmt.Errorf("the duration %q is not a number of seconds", value)
+	}
+
+	return int(d / time.Second), nil
+}
+
+func proxyBuffers(value string, _ map[string]string) (map[string]string, error) {
+	parts := strings.Fields(value)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid value %q, expected the number and the size of the buffers", value)
+	}
+
+	if _, err := strconv.Atoi(parts[0]); err != nil {
+		return nil, fmt.Errorf("invalid number of buffers %q", parts[0])
+	}
+
+	return map[string]string{"proxy-buffers-number": parts[0]}, nil
+}
+
+func nginxLoadBalancing(value string, _ map[string]string) (map[string]string, error) {
+	parts := strings.Fields(value)
+	if len(parts) == 0 {
+		return nil, fmt.Errorf("empty load balancing method")
+	}
+
+	switch {
+	case parts[0] == "round_robin" && len(parts) == 1:
+		return map[string]string{"load-balance": "round_robin"}, nil
+	case parts[0] == "ip_hash" && len(parts) == 1:
+		return map[string]string{"upstream-hash-by": "$binary_remote_addr"}, nil
+	case parts[0] == "hash" && len(parts) > 1:
+		return map[string]string{"upstream-hash-by": parts[1]}, nil
+	}
+
+	return nil, fmt.Errorf("the load balancing method %q has no equivalent in ingress-nginx", value)
+}
+
+func haproxyLoadBalancing(value string, _ map[string]string) (map[string]string, error) {
+	switch strings.TrimSpace(value) {
+	case "roundrobin", "static-rr":
+		return map[string]string{"load-balance": "round_robin"}, nil
+	case "source":
+		return map[string]string{"upstream-hash-by": "$binary_remote_addr"}, nil
+	case "uri":
+		return map[string]string{"upstream-hash-by": "$request_uri"}, nil
+	}
+
+	return nil, fmt.Errorf("the load balancing algorithm %q has no equivalent in ingress-nginx", value)
+}
+
+var nginxRateRegex = regexp.MustCompile(`^(\d+)r/(s|m)$`)
+
+func nginxRateLimit(value string, _ map[string]string) (map[string]string, error) {
+	parts := nginxRateRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid rate %q", value)
+	}
+
+	if parts[2] == "s" {
+		return map[string]string{"limit-rps": parts[1]}, nil
+	}
+
+	return map[string]string{"limit-rpm": parts[1]}, nil
+}
+
+// requestsPerPeriod converts a number of requests per period to the rate
+// limits of ingress-nginx, the default period is one second
+func requestsPerPeriod(requests, period string) (map[string]string, error) {
+	if _, err := strconv.Atoi(strings.TrimSpace(requests)); err != nil {
+		return nil, fmt.Errorf("invalid number of requests %q", requests)
+	}
+
+	if period == "" {
+		period = "1s"
+	}
+
+	s, err := parseSeconds(period)
+	if err != nil {
+		return nil, err
+	}
+
+	switch s {
+	case 1:
+		return map[string]string{"limit-rps": strings.TrimSpace(requests)}, nil
+	case 60:
+		return map[string]string{"limit-rpm": strings.TrimSpace(requests)}, nil
+	}
+
+	return nil, fmt.Errorf("the period %q has no equivalent in ingress-nginx, only one second and one minute are supported", period)
+}
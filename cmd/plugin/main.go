@@ -31,11 +31,13 @@ import (
 	"k8s.io/ingress-nginx/cmd/plugin/commands/backends"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/certs"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/conf"
+	"k8s.io/ingress-nginx/cmd/plugin/commands/convert"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/exec"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/general"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/info"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/ingresses"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/lint"
+	"k8s.io/ingress-nginx/cmd/plugin/commands/loglevel"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/logs"
 	"k8s.io/ingress-nginx/cmd/plugin/commands/ssh"
 )
@@ -60,6 +62,8 @@ func main() {
 	rootCmd.AddCommand(exec.CreateCommand(flags))
 	rootCmd.AddCommand(ssh.CreateCommand(flags))
 	rootCmd.AddCommand(lint.CreateCommand(flags))
+	rootCmd.AddCommand(convert.CreateCommand(flags))
+	rootCmd.AddCommand(loglevel.CreateCommand(flags))
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -43,6 +43,7 @@ require (
 	pault.ag/go/sniff v0.0.0-20200207005214-cf7e4d167732
 	sigs.k8s.io/controller-runtime v0.19.0
 	sigs.k8s.io/mdtoc v1.1.0
+	sigs.k8s.io/yaml v1.4.0
 )
 
 require (
@@ -136,5 +137,4 @@ require (
 	sigs.k8s.io/kustomize/api v0.16.0 // indirect
 	sigs.k8s.io/kustomize/kyaml v0.16.0 // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.4.1 // indirect
-	sigs.k8s.io/yaml v1.4.0 // indirect
 )
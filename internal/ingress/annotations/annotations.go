@@ -26,44 +26,68 @@ import (
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/alias"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authoidc"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreqglobal"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backupservice"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/bodyinspection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/clientbodybuffersize"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/conditionalheader"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/csp"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/customheaders"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/customhttperrors"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/defaultbackend"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/disableproxyintercepterrors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/errorpage"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/externalname"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/extrasecrets"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/grpcweb"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/hsts"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/http2pushpreload"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/httpversion"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipallowlist"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipdenylist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/largefiledelivery"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/loadbalancetuning"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/loadbalancing"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/luakv"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/metricslabels"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentelemetry"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/portinredirect"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/precompressed"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/satisfy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/scope"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serversnippet"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/serviceupstream"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/serving"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sessionaffinity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/snippet"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/splithorizon"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/sslalternate"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslcipher"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sslpassthrough"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/streamsnippet"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/trustedproxies"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamhashby"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamproxyprotocol"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamvhost"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/warmup"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/xforwardedprefix"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
@@ -78,42 +102,66 @@ type Ingress struct {
 	BackendProtocol             string
 	Aliases                     []string
 	BasicDigestAuth             auth.Config
+	Backup                      backupservice.Config
+	OIDCAuth                    authoidc.Config
+	BodyInspection              bodyinspection.Config
 	Canary                      canary.Config
 	CertificateAuth             authtls.Config
 	ClientBodyBufferSize        string
+	ConditionalHeader           conditionalheader.Config
 	CustomHeaders               customheaders.Config
 	ConfigurationSnippet        string
 	Connection                  connection.Config
 	CorsConfig                  cors.Config
+	ContentSecurityPolicy       csp.Config
 	CustomHTTPErrors            []int
 	DisableProxyInterceptErrors bool
 	DefaultBackend              *apiv1.Service
+	ErrorPage                   errorpage.Config
+	ExtraSecrets                []extrasecrets.Secret
 	FastCGI                     fastcgi.Config
 	Denied                      *string
 	ExternalAuth                authreq.Config
+	ExternalName                externalname.Config
 	EnableGlobalAuth            bool
+	HSTS                        hsts.Config
+	GRPCWeb                     bool
 	HTTP2PushPreload            bool
+	HTTPVersion                 httpversion.Config
 	Opentelemetry               opentelemetry.Config
+	Paused                      bool
+	Precompressed               precompressed.Config
 	Proxy                       proxy.Config
+	ProxyCache                  proxycache.Config
+	LargeFileDelivery           largefiledelivery.Config
 	ProxySSL                    proxyssl.Config
 	RateLimit                   ratelimit.Config
 	Redirect                    redirect.Config
 	Rewrite                     rewrite.Config
 	Satisfy                     string
+	Scope                       string
 	ServerSnippet               string
 	ServiceUpstream             bool
 	SessionAffinity             sessionaffinity.Config
 	SSLPassthrough              bool
+	TrustedProxies              []string
 	UsePortInRedirects          bool
 	UpstreamHashBy              upstreamhashby.Config
 	LoadBalancing               string
+	LoadBalanceTuning           loadbalancetuning.Config
 	UpstreamVhost               string
+	UpstreamProxyProtocol       upstreamproxyprotocol.Config
+	Warmup                      warmup.Config
 	Denylist                    ipdenylist.SourceRange
 	XForwardedPrefix            string
 	SSLCipher                   sslcipher.Config
+	SSLAlternateSecret          string
 	Logs                        log.Config
+	LuaKV                       luakv.Config
+	MetricsLabels               map[string]string
 	ModSecurity                 modsecurity.Config
 	Mirror                      mirror.Config
+	SplitHorizon                splithorizon.Config
 	StreamSnippet               string
 	Allowlist                   ipallowlist.SourceRange
 }
@@ -127,43 +175,67 @@ func NewAnnotationFactory(cfg resolver.Resolver) map[string]parser.IngressAnnota
 	return map[string]parser.IngressAnnotation{
 		"Aliases":                     alias.NewParser(cfg),
 		"BasicDigestAuth":             auth.NewParser(auth.AuthDirectory, cfg),
+		"Backup":                      backupservice.NewParser(cfg),
+		"OIDCAuth":                    authoidc.NewParser(cfg),
+		"BodyInspection":              bodyinspection.NewParser(cfg),
 		"Canary":                      canary.NewParser(cfg),
 		"CertificateAuth":             authtls.NewParser(cfg),
 		"ClientBodyBufferSize":        clientbodybuffersize.NewParser(cfg),
+		"ConditionalHeader":           conditionalheader.NewParser(cfg),
 		"CustomHeaders":               customheaders.NewParser(cfg),
 		"ConfigurationSnippet":        snippet.NewParser(cfg),
 		"Connection":                  connection.NewParser(cfg),
 		"CorsConfig":                  cors.NewParser(cfg),
+		"ContentSecurityPolicy":       csp.NewParser(cfg),
 		"CustomHTTPErrors":            customhttperrors.NewParser(cfg),
 		"DisableProxyInterceptErrors": disableproxyintercepterrors.NewParser(cfg),
 		"DefaultBackend":              defaultbackend.NewParser(cfg),
+		"ErrorPage":                   errorpage.NewParser(cfg),
+		"ExtraSecrets":                extrasecrets.NewParser(cfg),
 		"FastCGI":                     fastcgi.NewParser(cfg),
 		"ExternalAuth":                authreq.NewParser(cfg),
+		"ExternalName":                externalname.NewParser(cfg),
 		"EnableGlobalAuth":            authreqglobal.NewParser(cfg),
+		"HSTS":                        hsts.NewParser(cfg),
+		"GRPCWeb":                     grpcweb.NewParser(cfg),
 		"HTTP2PushPreload":            http2pushpreload.NewParser(cfg),
+		"HTTPVersion":                 httpversion.NewParser(cfg),
 		"Opentelemetry":               opentelemetry.NewParser(cfg),
+		"Paused":                      serving.NewParser(cfg),
+		"Precompressed":               precompressed.NewParser(cfg),
 		"Proxy":                       proxy.NewParser(cfg),
+		"ProxyCache":                  proxycache.NewParser(cfg),
+		"LargeFileDelivery":           largefiledelivery.NewParser(cfg),
 		"ProxySSL":                    proxyssl.NewParser(cfg),
 		"RateLimit":                   ratelimit.NewParser(cfg),
 		"Redirect":                    redirect.NewParser(cfg),
 		"Rewrite":                     rewrite.NewParser(cfg),
 		"Satisfy":                     satisfy.NewParser(cfg),
+		"Scope":                       scope.NewParser(cfg),
 		"ServerSnippet":               serversnippet.NewParser(cfg),
 		"ServiceUpstream":             serviceupstream.NewParser(cfg),
 		"SessionAffinity":             sessionaffinity.NewParser(cfg),
 		"SSLPassthrough":              sslpassthrough.NewParser(cfg),
+		"TrustedProxies":              trustedproxies.NewParser(cfg),
 		"UsePortInRedirects":          portinredirect.NewParser(cfg),
 		"UpstreamHashBy":              upstreamhashby.NewParser(cfg),
 		"LoadBalancing":               loadbalancing.NewParser(cfg),
+		"LoadBalanceTuning":           loadbalancetuning.NewParser(cfg),
 		"UpstreamVhost":               upstreamvhost.NewParser(cfg),
+		"UpstreamProxyProtocol":       upstreamproxyprotocol.NewParser(cfg),
+		"Warmup":                      warmup.NewParser(cfg),
 		"Allowlist":                   ipallowlist.NewParser(cfg),
 		"Denylist":                    ipdenylist.NewParser(cfg),
 		"XForwardedPrefix":            xforwardedprefix.NewParser(cfg),
 		"SSLCipher":                   sslcipher.NewParser(cfg),
+		"SSLAlternateSecret":          sslalternate.NewParser(cfg),
 		"Logs":                        log.NewParser(cfg),
+		"LuaKV":                       luakv.NewParser(cfg),
+		"MetricsLabels":               metricslabels.NewParser(cfg),
 		"BackendProtocol":             backendprotocol.NewParser(cfg),
 		"ModSecurity":                 modsecurity.NewParser(cfg),
 		"Mirror":                      mirror.NewParser(cfg),
+		"SplitHorizon":                splithorizon.NewParser(cfg),
 		"StreamSnippet":               streamsnippet.NewParser(cfg),
 	}
 }
@@ -17,9 +17,12 @@ limitations under the License.
 package auth
 
 import (
+	"crypto/sha1" // #nosec
+	"encoding/hex"
 	"fmt"
 	"os"
 	"regexp"
+	"sort"
 	"strings"
 
 	api "k8s.io/api/core/v1"
@@ -96,6 +99,14 @@ type Config struct {
 	FileSHA    string `json:"fileSha"`
 	Secret     string `json:"secret"`
 	SecretType string `json:"secretType"`
+	// InMemory indicates the credentials are verified by Lua instead of a file
+	InMemory bool `json:"inMemory"`
+	// Key identifies the credentials of the Ingress in the Lua shared dictionary
+	Key string `json:"key"`
+	// Credentials contains the hash of the password of each user when the
+	// credentials are kept in memory. It is not serialized to not expose the
+	// credentials in the dumps of the configuration.
+	Credentials map[string]string `json:"-"`
 }
 
 // Equal tests for equality between two Config types
@@ -124,6 +135,12 @@ func (bd1 *Config) Equal(bd2 *Config) bool {
 	if bd1.Secret != bd2.Secret {
 		return false
 	}
+	if bd1.InMemory != bd2.InMemory {
+		return false
+	}
+	if bd1.Key != bd2.Key {
+		return false
+	}
 	return true
 }
 
@@ -203,7 +220,39 @@ func (a auth) Parse(ing *networking.Ingress) (interface{}, error) {
 		return nil, err
 	}
 
-	passFilename := fmt.Sprintf("%v/%v-%v-%v.passwd", a.authDirectory, ing.GetNamespace(), ing.UID, secret.UID)
+	key := fmt.Sprintf("%v-%v-%v", ing.GetNamespace(), ing.UID, secret.UID)
+
+	// the digest authentication requires the file of ngx_http_auth_digest_module
+	if secCfg.AuthCredentialsInMemory && at == "basic" {
+		var credentials map[string]string
+		switch secretType {
+		case fileAuth:
+			credentials, err = secretAuthFileCredentials(secret)
+		case mapAuth:
+			credentials = secretAuthMapCredentials(secret)
+		default:
+			err = ing_errors.LocationDeniedError{
+				Reason: fmt.Errorf("invalid auth-secret-type in annotation, must be 'auth-file' or 'auth-map'"),
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return &Config{
+			Type:        at,
+			Realm:       realm,
+			Secured:     true,
+			FileSHA:     credentialsSHA(credentials),
+			Secret:      name,
+			SecretType:  secretType,
+			InMemory:    true,
+			Key:         key,
+			Credentials: credentials,
+		}, nil
+	}
+
+	passFilename := fmt.Sprintf("%v/%v.passwd", a.authDirectory, key)
 
 	switch secretType {
 	case fileAuth:
@@ -272,6 +321,66 @@ func dumpSecretAuthMap(filename string, secret *api.Secret) error {
 	return nil
 }
 
+// secretAuthFileCredentials returns the hash of the password of each user in
+// the htpasswd content of a secret. Like auth_basic_user_file, the empty lines,
+// the comments and the lines without a password are ignored, and the fields
+// after the password are discarded.
+func secretAuthFileCredentials(secret *api.Secret) (map[string]string, error) {
+	val, ok := secret.Data["auth"]
+	if !ok {
+		return nil, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("the secret %s does not contain a key with value auth", secret.Name),
+		}
+	}
+
+	credentials := map[string]string{}
+	for _, line := range strings.Split(string(val), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		user, rest, ok := strings.Cut(line, ":")
+		if !ok || user == "" {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(rest, ":")
+
+		credentials[user] = hash
+	}
+
+	return credentials, nil
+}
+
+// secretAuthMapCredentials returns the hash of the password of each user
+// defined in the keys of a secret
+func secretAuthMapCredentials(secret *api.Secret) map[string]string {
+	credentials := make(map[string]string, len(secret.Data))
+	for user, pass := range secret.Data {
+		credentials[user] = string(pass)
+	}
+
+	return credentials
+}
+
+// credentialsSHA returns the SHA1 hash of the credentials, used to detect
+// their changes like the hash of the password files
+func credentialsSHA(credentials map[string]string) string {
+	users := make([]string, 0, len(credentials))
+	for user := range credentials {
+		users = append(users, user)
+	}
+	sort.Strings(users)
+
+	hasher := sha1.New() // #nosec
+	for _, user := range users {
+		fmt.Fprintf(hasher, "%v:%v\n", user, credentials[user])
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
 func (a auth) GetDocumentation() parser.AnnotationFields {
 	return a.annotationConfig.Annotations
 }
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -345,3 +346,82 @@ func TestDumpSecretAuthMap(t *testing.T) {
 		t.Errorf("Unexpected error creating htpasswd file %v: %v", tmpfile, err)
 	}
 }
+
+func TestIngressAuthInMemory(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(authTypeAnnotation)] = authType
+	data[parser.GetAnnotationWithPrefix(AuthSecretAnnotation)] = demoSecret
+	data[parser.GetAnnotationWithPrefix(authRealmAnnotation)] = authRealm
+	ing.SetAnnotations(data)
+
+	_, dir, _ := dummySecretContent(t)
+	defer os.RemoveAll(dir)
+
+	i, err := NewParser(dir, &mockSecret{resolver.Mock{AuthInMemory: true}}).Parse(ing)
+	if err != nil {
+		t.Fatalf("Unexpected error with ingress: %v", err)
+	}
+	auth, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a BasicDigest type")
+	}
+	if !auth.InMemory || auth.File != "" {
+		t.Errorf("Expected the credentials in memory but returned the file %q", auth.File)
+	}
+	if auth.Key != "default--" {
+		t.Errorf("Expected default-- as key but returned %s", auth.Key)
+	}
+	if auth.Credentials["foo"] != "$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0" {
+		t.Errorf("Expected the credentials of foo but returned %v", auth.Credentials)
+	}
+	if auth.FileSHA == "" {
+		t.Errorf("Expected the hash of the credentials")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Unexpected error reading %v: %v", dir, err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected no password file but %v were written", len(entries))
+	}
+
+	data[parser.GetAnnotationWithPrefix(authTypeAnnotation)] = "digest"
+	i, err = NewParser(dir, &mockSecret{resolver.Mock{AuthInMemory: true}}).Parse(ing)
+	if err != nil {
+		t.Fatalf("Unexpected error with ingress: %v", err)
+	}
+	if auth := i.(*Config); auth.InMemory || auth.File == "" {
+		t.Errorf("Expected a password file for the digest authentication")
+	}
+}
+
+func TestSecretAuthFileCredentials(t *testing.T) {
+	s := &api.Secret{
+		Data: map[string][]byte{"auth": []byte("# comment\r\nfoo:{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00=\r\n\r\nbar\nbaz:$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0:extra\n")},
+	}
+
+	credentials, err := secretAuthFileCredentials(s)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := map[string]string{
+		"foo": "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00=",
+		"baz": "$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0",
+	}
+	if !reflect.DeepEqual(credentials, expected) {
+		t.Errorf("Expected %v but returned %v", expected, credentials)
+	}
+
+	if credentialsSHA(credentials) == credentialsSHA(map[string]string{"foo": "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00="}) {
+		t.Errorf("Expected the hash to change with the credentials")
+	}
+
+	s.Data = nil
+	if _, err := secretAuthFileCredentials(s); err == nil {
+		t.Errorf("Expected error with secret without auth")
+	}
+}
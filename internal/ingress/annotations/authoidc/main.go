@@ -0,0 +1,355 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authoidc
+
+import (
+	"crypto/sha1" // #nosec
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	oidcIssuerAnnotation          = "auth-oidc-issuer"
+	oidcSecretAnnotation          = "auth-oidc-secret" //#nosec G101
+	oidcScopesAnnotation          = "auth-oidc-scopes"
+	oidcRedirectPathAnnotation    = "auth-oidc-redirect-path"
+	oidcCookieNameAnnotation      = "auth-oidc-cookie-name"
+	oidcCookieDomainAnnotation    = "auth-oidc-cookie-domain"
+	oidcCookieSameSiteAnnotation  = "auth-oidc-cookie-samesite"
+	oidcSessionDurationAnnotation = "auth-oidc-session-duration"
+)
+
+const (
+	defaultScopes          = "openid profile email"
+	defaultRedirectPath    = "/oauth2/callback"
+	defaultCookieName      = "_oidc_session"
+	defaultCookieSameSite  = "Lax"
+	defaultSessionDuration = 8 * time.Hour
+
+	// keys of the Secret containing the client credentials
+	clientIDKey     = "client-id"
+	clientSecretKey = "client-secret" //#nosec G101
+	cookieSecretKey = "cookie-secret" //#nosec G101
+)
+
+var (
+	issuerRegex       = regexp.MustCompile(`^https://[A-Za-z0-9.\-]+(:[0-9]+)?(/[A-Za-z0-9._~\-/]*)?$`)
+	scopesRegex       = regexp.MustCompile(`^[A-Za-z0-9_:.\-/]+(\s+[A-Za-z0-9_:.\-/]+)*$`)
+	redirectPathRegex = regexp.MustCompile(`^/[A-Za-z0-9._~\-/]*$`)
+	cookieNameRegex   = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`)
+	cookieDomainRegex = regexp.MustCompile(`^\.?[A-Za-z0-9\-]+(\.[A-Za-z0-9\-]+)*$`)
+)
+
+var oidcAnnotations = parser.Annotation{
+	Group: "authentication",
+	Annotations: parser.AnnotationFields{
+		oidcIssuerAnnotation: {
+			Validator: parser.ValidateRegex(issuerRegex, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskHigh, // High, as the controller trusts the identities returned by this URL
+			Documentation: `This annotation defines the HTTPS URL of the OpenID Connect issuer authenticating the users.
+			The endpoints are discovered in <issuer>/.well-known/openid-configuration.`,
+		},
+		oidcSecretAnnotation: {
+			Validator: parser.ValidateRegex(parser.BasicCharsRegex, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskMedium, // Medium as it allows a subset of chars
+			Documentation: `This annotation defines the name of the Secret that contains the client-id and client-secret of the OpenID Connect client,
+			and optionally the cookie-secret used to encrypt the session cookie.`,
+		},
+		oidcScopesAnnotation: {
+			Validator:     parser.ValidateRegex(scopesRegex, false),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the space separated scopes requested to the issuer. The openid scope is always requested. Defaults to "openid profile email".`,
+		},
+		oidcRedirectPathAnnotation: {
+			Validator:     parser.ValidateRegex(redirectPathRegex, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the path the issuer redirects the users to after the authentication. Defaults to "/oauth2/callback".`,
+		},
+		oidcCookieNameAnnotation: {
+			Validator:     parser.ValidateRegex(cookieNameRegex, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the name of the session cookie. Defaults to "_oidc_session".`,
+		},
+		oidcCookieDomainAnnotation: {
+			Validator:     parser.ValidateRegex(cookieDomainRegex, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the domain of the session cookie. Defaults to the host of the request.`,
+		},
+		oidcCookieSameSiteAnnotation: {
+			Validator:     parser.ValidateOptions([]string{"Lax", "Strict", "None"}, true, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the SameSite attribute of the session cookie: Lax, Strict or None. Defaults to Lax.`,
+		},
+		oidcSessionDurationAnnotation: {
+			Validator:     parser.ValidateDuration,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the duration of the sessions, like 8h, after which the users are authenticated again. Defaults to 8h.`,
+		},
+	},
+}
+
+// Config contains the OpenID Connect authentication of a location
+type Config struct {
+	Issuer string `json:"issuer"`
+	Secret string `json:"secret"`
+	// Key identifies the client of the Ingress in the Lua shared dictionary
+	Key          string `json:"key"`
+	Scopes       string `json:"scopes"`
+	RedirectPath string `json:"redirectPath"`
+	CookieName   string `json:"cookieName"`
+	CookieDomain string `json:"cookieDomain"`
+	// CookieSameSite is the SameSite attribute of the session cookie
+	CookieSameSite string `json:"cookieSameSite"`
+	// SessionDuration is the duration of the sessions in seconds
+	SessionDuration int `json:"sessionDuration"`
+	// ClientSHA is the hash of the client credentials, used to detect their changes
+	ClientSHA string `json:"clientSha"`
+	// Client contains the credentials of the client. It is not serialized to
+	// not expose the credentials in the dumps of the configuration.
+	Client Client `json:"-"`
+}
+
+// Client contains the credentials of an OpenID Connect client, configured in Lua
+type Client struct {
+	ID     string `json:"clientId"`
+	Secret string `json:"clientSecret"`
+	// CookieSecret is the key of the encryption of the session cookie
+	CookieSecret string `json:"cookieSecret"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Issuer != c2.Issuer {
+		return false
+	}
+	if c1.Secret != c2.Secret {
+		return false
+	}
+	if c1.Key != c2.Key {
+		return false
+	}
+	if c1.Scopes != c2.Scopes {
+		return false
+	}
+	if c1.RedirectPath != c2.RedirectPath {
+		return false
+	}
+	if c1.CookieName != c2.CookieName {
+		return false
+	}
+	if c1.CookieDomain != c2.CookieDomain {
+		return false
+	}
+	if c1.CookieSameSite != c2.CookieSameSite {
+		return false
+	}
+	if c1.SessionDuration != c2.SessionDuration {
+		return false
+	}
+	if c1.ClientSHA != c2.ClientSHA {
+		return false
+	}
+	return true
+}
+
+type authOIDC struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new OpenID Connect authentication annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return authOIDC{
+		r:                r,
+		annotationConfig: oidcAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to authenticate the users with an OpenID Connect issuer
+func (a authOIDC) Parse(ing *networking.Ingress) (interface{}, error) {
+	issuer, err := parser.GetStringAnnotation(oidcIssuerAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := parser.GetStringAnnotation(oidcSecretAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		return nil, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("error reading secret name from annotation: %w", err),
+		}
+	}
+
+	sns, sname, err := cache.SplitMetaNamespaceKey(s)
+	if err != nil {
+		return nil, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("error reading secret name from annotation: %w", err),
+		}
+	}
+
+	if sns == "" {
+		sns = ing.Namespace
+	}
+	// We don't accept different namespaces for secrets.
+	if !a.r.GetSecurityConfiguration().AllowCrossNamespaceResources && sns != ing.Namespace {
+		return nil, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("cross namespace usage of secrets is not allowed"),
+		}
+	}
+
+	name := fmt.Sprintf("%v/%v", sns, sname)
+	secret, err := a.r.GetSecret(name)
+	if err != nil {
+		return nil, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("unexpected error reading secret %s: %w", name, err),
+		}
+	}
+
+	client := Client{
+		ID:           string(secret.Data[clientIDKey]),
+		Secret:       string(secret.Data[clientSecretKey]),
+		CookieSecret: string(secret.Data[cookieSecretKey]),
+	}
+	if client.ID == "" || client.Secret == "" {
+		return nil, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("the secret %s does not contain the keys %v and %v", name, clientIDKey, clientSecretKey),
+		}
+	}
+
+	config := &Config{
+		Issuer:          issuer,
+		Secret:          name,
+		Key:             fmt.Sprintf("%v-%v-%v", ing.GetNamespace(), ing.UID, secret.UID),
+		Scopes:          defaultScopes,
+		RedirectPath:    defaultRedirectPath,
+		CookieName:      defaultCookieName,
+		CookieSameSite:  defaultCookieSameSite,
+		SessionDuration: int(defaultSessionDuration.Seconds()),
+		ClientSHA:       clientSHA(&client),
+		Client:          client,
+	}
+
+	scopes, err := parser.GetStringAnnotation(oidcScopesAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.Scopes = normalizeScopes(scopes)
+	case !ing_errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	redirectPath, err := parser.GetStringAnnotation(oidcRedirectPathAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.RedirectPath = redirectPath
+	case !ing_errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	cookieName, err := parser.GetStringAnnotation(oidcCookieNameAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.CookieName = cookieName
+	case !ing_errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	cookieDomain, err := parser.GetStringAnnotation(oidcCookieDomainAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.CookieDomain = cookieDomain
+	case !ing_errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	sameSite, err := parser.GetStringAnnotation(oidcCookieSameSiteAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.CookieSameSite = sameSite
+	case !ing_errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	duration, err := parser.GetStringAnnotation(oidcSessionDurationAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		d, err := time.ParseDuration(duration)
+		if err != nil || d < time.Second {
+			return nil, ing_errors.NewInvalidAnnotationContent(oidcSessionDurationAnnotation, duration)
+		}
+		config.SessionDuration = int(d.Seconds())
+	case !ing_errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// normalizeScopes returns the space separated scopes without duplicates,
+// starting with the openid scope required by OpenID Connect
+func normalizeScopes(value string) string {
+	scopes := []string{"openid"}
+	for _, scope := range strings.Fields(value) {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return strings.Join(scopes, " ")
+}
+
+// clientSHA returns the SHA1 hash of the client credentials, used to detect
+// their changes like the hash of the password files
+func clientSHA(client *Client) string {
+	hasher := sha1.New() // #nosec
+	fmt.Fprintf(hasher, "%v\n%v\n%v\n", client.ID, client.Secret, client.CookieSecret)
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func (a authOIDC) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a authOIDC) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, oidcAnnotations.Annotations)
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package authoidc
+
+import (
+	"fmt"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockSecret struct {
+	resolver.Mock
+	data map[string][]byte
+}
+
+func (m mockSecret) GetSecret(name string) (*api.Secret, error) {
+	if name != "default/oidc" && name != "otherns/oidc" {
+		return nil, fmt.Errorf("there is no secret with name %v", name)
+	}
+
+	return &api.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{Name: "oidc", UID: "secret-uid"},
+		Data:       m.data,
+	}, nil
+}
+
+func buildIngress(annotations map[string]string) *networking.Ingress {
+	data := map[string]string{}
+	for name, value := range annotations {
+		data[parser.GetAnnotationWithPrefix(name)] = value
+	}
+
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   api.NamespaceDefault,
+			UID:         "ingress-uid",
+			Annotations: data,
+		},
+	}
+}
+
+func newMockSecret() mockSecret {
+	return mockSecret{data: map[string][]byte{
+		clientIDKey:     []byte("dashboard"),
+		clientSecretKey: []byte("client-secret"),
+	}}
+}
+
+func TestParse(t *testing.T) {
+	ing := buildIngress(map[string]string{
+		oidcIssuerAnnotation:          "https://accounts.example.com/realms/main",
+		oidcSecretAnnotation:          "oidc",
+		oidcScopesAnnotation:          "email groups email",
+		oidcCookieDomainAnnotation:    ".example.com",
+		oidcCookieSameSiteAnnotation:  "Strict",
+		oidcSessionDurationAnnotation: "30m",
+	})
+
+	i, err := NewParser(newMockSecret()).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	config, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a Config type but returned %T", i)
+	}
+
+	expected := &Config{
+		Issuer:          "https://accounts.example.com/realms/main",
+		Secret:          "default/oidc",
+		Key:             "default-ingress-uid-secret-uid",
+		Scopes:          "openid email groups",
+		RedirectPath:    defaultRedirectPath,
+		CookieName:      defaultCookieName,
+		CookieDomain:    ".example.com",
+		CookieSameSite:  "Strict",
+		SessionDuration: 1800,
+		ClientSHA:       clientSHA(&Client{ID: "dashboard", Secret: "client-secret"}),
+	}
+	if !config.Equal(expected) {
+		t.Errorf("expected %+v but returned %+v", expected, config)
+	}
+	if config.Client.ID != "dashboard" || config.Client.Secret != "client-secret" {
+		t.Errorf("expected the client credentials of the Secret but returned %+v", config.Client)
+	}
+}
+
+func TestParseWithoutIssuer(t *testing.T) {
+	_, err := NewParser(newMockSecret()).Parse(buildIngress(map[string]string{}))
+	if !ing_errors.IsMissingAnnotations(err) {
+		t.Errorf("expected a missing annotation error but returned %v", err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		title       string
+		annotations map[string]string
+		secret      mockSecret
+	}{
+		{"plain HTTP issuer", map[string]string{oidcIssuerAnnotation: "http://accounts.example.com", oidcSecretAnnotation: "oidc"}, newMockSecret()},
+		{"issuer with a query", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com/?a=b", oidcSecretAnnotation: "oidc"}, newMockSecret()},
+		{"without secret", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com"}, newMockSecret()},
+		{"missing secret", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com", oidcSecretAnnotation: "missing"}, newMockSecret()},
+		{"cross namespace secret", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com", oidcSecretAnnotation: "otherns/oidc"}, newMockSecret()},
+		{"secret without client secret", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com", oidcSecretAnnotation: "oidc"}, mockSecret{data: map[string][]byte{clientIDKey: []byte("dashboard")}}},
+		{"invalid same site", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com", oidcSecretAnnotation: "oidc", oidcCookieSameSiteAnnotation: "Always"}, newMockSecret()},
+		{"invalid redirect path", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com", oidcSecretAnnotation: "oidc", oidcRedirectPathAnnotation: "callback"}, newMockSecret()},
+		{"too short session", map[string]string{oidcIssuerAnnotation: "https://accounts.example.com", oidcSecretAnnotation: "oidc", oidcSessionDurationAnnotation: "10ms"}, newMockSecret()},
+	}
+
+	for _, test := range tests {
+		t.Run(test.title, func(t *testing.T) {
+			if _, err := NewParser(test.secret).Parse(buildIngress(test.annotations)); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestParseCrossNamespace(t *testing.T) {
+	secret := newMockSecret()
+	secret.AllowCrossNamespace = true
+
+	ing := buildIngress(map[string]string{
+		oidcIssuerAnnotation: "https://accounts.example.com",
+		oidcSecretAnnotation: "otherns/oidc",
+	})
+
+	i, err := NewParser(secret).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if config, ok := i.(*Config); !ok || config.Secret != "otherns/oidc" {
+		t.Errorf("expected the Secret otherns/oidc but returned %+v", i)
+	}
+}
+
+func TestNormalizeScopes(t *testing.T) {
+	tests := map[string]string{
+		"openid":                 "openid",
+		"profile":                "openid profile",
+		"profile  openid groups": "openid profile groups",
+	}
+
+	for value, expected := range tests {
+		if scopes := normalizeScopes(value); scopes != expected {
+			t.Errorf("expected %q for %q but returned %q", expected, value, scopes)
+		}
+	}
+}
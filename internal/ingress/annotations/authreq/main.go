@@ -48,6 +48,8 @@ const (
 	authReqProxySetHeadersAnnotation    = "auth-proxy-set-headers"
 	authReqRequestRedirectAnnotation    = "auth-request-redirect"
 	authReqAlwaysSetCookieAnnotation    = "auth-always-set-cookie"
+	authReqFailureModeAnnotation        = "auth-failure-mode"
+	authReqFailureCacheTTLAnnotation    = "auth-failure-cache-ttl"
 
 	// This should be exported as it is imported by other packages
 	AuthSecretAnnotation = "auth-secret"
@@ -148,6 +150,19 @@ var authReqAnnotations = parser.Annotation{
 			Documentation: `This annotation enables setting a cookie returned by auth request. 
 			By default, the cookie will be set only if an upstream reports with the code 200, 201, 204, 206, 301, 302, 303, 304, 307, or 308`,
 		},
+		authReqFailureModeAnnotation: {
+			Validator: parser.ValidateRegex(failureModeRegex, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskMedium, // Medium, as "allow" lets the requests in while the auth service fails
+			Documentation: `This annotation defines the response to the requests when the auth service fails. Can be "deny" (default), "allow" or "cached".
+			"cached" replays the last decision of the auth service for the same credentials, if not older than auth-failure-cache-ttl`,
+		},
+		authReqFailureCacheTTLAnnotation: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the duration in seconds the decisions of the auth service are replayed by the "cached" auth-failure-mode`,
+		},
 	},
 }
 
@@ -170,8 +185,21 @@ type Config struct {
 	KeepaliveTimeout       int               `json:"keepaliveTimeout"`
 	ProxySetHeaders        map[string]string `json:"proxySetHeaders,omitempty"`
 	AlwaysSetCookie        bool              `json:"alwaysSetCookie,omitempty"`
+	FailureMode            string            `json:"failureMode,omitempty"`
+	FailureCacheTTL        int               `json:"failureCacheTTL,omitempty"`
 }
 
+// Responses to the requests when the auth service fails
+const (
+	// FailureModeDeny rejects the requests
+	FailureModeDeny = "deny"
+	// FailureModeAllow sends the requests to the backend
+	FailureModeAllow = "allow"
+	// FailureModeCached replays the last decision of the auth service for
+	// the same credentials, or else rejects the requests
+	FailureModeCached = "cached"
+)
+
 // DefaultCacheDuration is the fallback value if no cache duration is provided
 const DefaultCacheDuration = "200 202 401 5m"
 
@@ -183,6 +211,10 @@ const (
 	defaultKeepaliveTimeout     = 60
 )
 
+// defaultFailureCacheTTL is the duration in seconds the decisions of the auth
+// service are replayed when no auth-failure-cache-ttl is set
+const defaultFailureCacheTTL = 300
+
 // Equal tests for equality between two Config types
 func (e1 *Config) Equal(e2 *Config) bool {
 	if e1 == e2 {
@@ -243,14 +275,23 @@ func (e1 *Config) Equal(e2 *Config) bool {
 		return false
 	}
 
+	if e1.FailureMode != e2.FailureMode {
+		return false
+	}
+
+	if e1.FailureCacheTTL != e2.FailureCacheTTL {
+		return false
+	}
+
 	return sets.StringElementsMatch(e1.AuthCacheDuration, e2.AuthCacheDuration)
 }
 
 var (
-	methodsRegex    = regexp.MustCompile("(GET|HEAD|POST|PUT|PATCH|DELETE|CONNECT|OPTIONS|TRACE)")
-	headerRegexp    = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
-	statusCodeRegex = regexp.MustCompile(`^\d{3}$`)
-	durationRegex   = regexp.MustCompile(`^\d+(ms|s|m|h|d|w|M|y)$`) // see http://nginx.org/en/docs/syntax.html
+	methodsRegex     = regexp.MustCompile("(GET|HEAD|POST|PUT|PATCH|DELETE|CONNECT|OPTIONS|TRACE)")
+	headerRegexp     = regexp.MustCompile(`^[a-zA-Z\d\-_]+$`)
+	statusCodeRegex  = regexp.MustCompile(`^\d{3}$`)
+	durationRegex    = regexp.MustCompile(`^\d+(ms|s|m|h|d|w|M|y)$`) // see http://nginx.org/en/docs/syntax.html
+	failureModeRegex = regexp.MustCompile(`^(deny|allow|cached)$`)
 )
 
 // ValidMethod checks is the provided string a valid HTTP method
@@ -476,6 +517,23 @@ func (a authReq) Parse(ing *networking.Ingress) (interface{}, error) {
 		return nil, fmt.Errorf("%s is invalid: %w", authReqAlwaysSetCookieAnnotation, err)
 	}
 
+	failureMode, err := parser.GetStringAnnotation(authReqFailureModeAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return nil, fmt.Errorf("%s is invalid: %w", authReqFailureModeAnnotation, err)
+		}
+		failureMode = FailureModeDeny
+	}
+
+	failureCacheTTL := 0
+	if failureMode == FailureModeCached {
+		failureCacheTTL, err = parser.GetIntAnnotation(authReqFailureCacheTTLAnnotation, ing, a.annotationConfig.Annotations)
+		if err != nil || failureCacheTTL <= 0 {
+			klog.V(3).InfoS("auth-failure-cache-ttl annotation is undefined or invalid and will be set to its default value")
+			failureCacheTTL = defaultFailureCacheTTL
+		}
+	}
+
 	return &Config{
 		URL:                    urlString,
 		Host:                   authURL.Hostname(),
@@ -493,6 +551,8 @@ func (a authReq) Parse(ing *networking.Ingress) (interface{}, error) {
 		KeepaliveTimeout:       keepaliveTimeout,
 		ProxySetHeaders:        proxySetHeaders,
 		AlwaysSetCookie:        alwaysSetCookie,
+		FailureMode:            failureMode,
+		FailureCacheTTL:        failureCacheTTL,
 	}, nil
 }
 
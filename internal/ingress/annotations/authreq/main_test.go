@@ -329,6 +329,62 @@ func TestKeepaliveAnnotations(t *testing.T) {
 	}
 }
 
+func TestFailureModeAnnotations(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	ing.SetAnnotations(data)
+
+	tests := []struct {
+		title        string
+		mode         string
+		ttl          string
+		expectedErr  bool
+		expectedMode string
+		expectedTTL  int
+	}{
+		{"no annotation", "", "", false, FailureModeDeny, 0},
+		{"deny", "deny", "60", false, FailureModeDeny, 0},
+		{"allow", "allow", "", false, FailureModeAllow, 0},
+		{"cached", "cached", "60", false, FailureModeCached, 60},
+		{"cached without ttl", "cached", "", false, FailureModeCached, defaultFailureCacheTTL},
+		{"cached with invalid ttl", "cached", "-1", false, FailureModeCached, defaultFailureCacheTTL},
+		{"invalid mode", "ignore", "", true, "", 0},
+	}
+
+	for _, test := range tests {
+		data[parser.GetAnnotationWithPrefix("auth-url")] = "http://goog.url"
+		data[parser.GetAnnotationWithPrefix("auth-failure-mode")] = test.mode
+		data[parser.GetAnnotationWithPrefix("auth-failure-cache-ttl")] = test.ttl
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expectedErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", test.title, err)
+			continue
+		}
+
+		u, ok := i.(*Config)
+		if !ok {
+			t.Errorf("%v: expected an External type", test.title)
+			continue
+		}
+
+		if u.FailureMode != test.expectedMode {
+			t.Errorf("%v: expected \"%v\" but \"%v\" was returned", test.title, test.expectedMode, u.FailureMode)
+		}
+
+		if u.FailureCacheTTL != test.expectedTTL {
+			t.Errorf("%v: expected \"%v\" but \"%v\" was returned", test.title, test.expectedTTL, u.FailureCacheTTL)
+		}
+	}
+}
+
 func TestParseStringToCacheDurations(t *testing.T) {
 	tests := []struct {
 		title             string
@@ -0,0 +1,191 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupservice
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	backupServiceAnnotation             = "backup-service"
+	backupServiceFailureCodesAnnotation = "backup-service-failure-codes"
+)
+
+// failureCodes are the status codes of the responses of the backend retried
+// by NGINX, see http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_next_upstream
+var failureCodes = map[int]bool{
+	403: true,
+	404: true,
+	429: true,
+	500: true,
+	502: true,
+	503: true,
+	504: true,
+}
+
+var backupServiceAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		backupServiceAnnotation: {
+			Validator: parser.ValidateServiceName,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the service of the namespace of the Ingress serving the requests when the service of the rule has no ready endpoint,
+			or responds with one of the backup-service-failure-codes.`,
+		},
+		backupServiceFailureCodesAnnotation: {
+			Validator:     validateFailureCodes,
+			Scope:         parser.AnnotationScopeIngress,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the comma separated status codes of the responses of the service of the rule retried with the backup-service: 403, 404, 429, 500, 502, 503 or 504.`,
+		},
+	},
+}
+
+// Config describes the service serving the requests when the service of an
+// Ingress fails
+type Config struct {
+	// Service is the backup service, nil when no backup service is defined
+	Service *apiv1.Service `json:"-"`
+	// FailureCodes are the sorted status codes of the responses retried with
+	// the backup service
+	FailureCodes []int `json:"failureCodes,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if (c1.Service == nil) != (c2.Service == nil) {
+		return false
+	}
+	if c1.Service != nil && c1.Service.UID != c2.Service.UID {
+		return false
+	}
+	if len(c1.FailureCodes) != len(c2.FailureCodes) {
+		return false
+	}
+	for i := range c1.FailureCodes {
+		if c1.FailureCodes[i] != c2.FailureCodes[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type backupService struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new backup service annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return backupService{
+		r:                r,
+		annotationConfig: backupServiceAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// define the service serving the requests when the service of the rule fails
+func (a backupService) Parse(ing *networking.Ingress) (interface{}, error) {
+	name, err := parser.GetStringAnnotation(backupServiceAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return &Config{}, err
+		}
+		return &Config{}, nil
+	}
+
+	key := fmt.Sprintf("%v/%v", ing.Namespace, name)
+	svc, err := a.r.GetService(key)
+	if err != nil {
+		return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("unexpected error reading service %s: %v", key, err))
+	}
+
+	config := &Config{
+		Service: svc,
+	}
+
+	val, err := parser.GetStringAnnotation(backupServiceFailureCodesAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return &Config{}, err
+		}
+		return config, nil
+	}
+
+	config.FailureCodes, err = parseFailureCodes(val)
+	if err != nil {
+		return &Config{}, ing_errors.NewLocationDenied(err.Error())
+	}
+
+	return config, nil
+}
+
+// parseFailureCodes returns the sorted status codes of a comma separated list
+func parseFailureCodes(value string) ([]int, error) {
+	codes := []int{}
+	found := map[int]bool{}
+	for _, s := range strings.Split(value, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+
+		code, err := strconv.Atoi(s)
+		if err != nil || !failureCodes[code] {
+			return nil, fmt.Errorf("%q is not a status code retried by NGINX", s)
+		}
+		if !found[code] {
+			found[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Ints(codes)
+
+	return codes, nil
+}
+
+func validateFailureCodes(value string) error {
+	_, err := parseFailureCodes(value)
+	return err
+}
+
+func (a backupService) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a backupService) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, backupServiceAnnotations.Annotations)
+}
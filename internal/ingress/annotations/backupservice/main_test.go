@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backupservice
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockService struct {
+	resolver.Mock
+}
+
+func (m mockService) GetService(name string) (*api.Service, error) {
+	if name != "default/backup" {
+		return nil, errors.New("no service")
+	}
+
+	return &api.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "backup",
+			Namespace: api.NamespaceDefault,
+		},
+	}, nil
+}
+
+func TestParse(t *testing.T) {
+	service := parser.GetAnnotationWithPrefix(backupServiceAnnotation)
+	codes := parser.GetAnnotationWithPrefix(backupServiceFailureCodesAnnotation)
+	ap := NewParser(mockService{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		service     string
+		codes       []int
+		expErr      bool
+	}{
+		{map[string]string{service: "backup"}, "backup", nil, false},
+		{map[string]string{service: "backup", codes: "503"}, "backup", []int{503}, false},
+		{map[string]string{service: "backup", codes: "504, 502,503,502"}, "backup", []int{502, 503, 504}, false},
+		{map[string]string{service: "backup", codes: "501"}, "", nil, true},
+		{map[string]string{service: "backup", codes: "5xx"}, "", nil, true},
+		{map[string]string{service: "missing"}, "", nil, true},
+		{map[string]string{service: "Invalid_Name"}, "", nil, true},
+		{map[string]string{codes: "503"}, "", nil, false},
+		{map[string]string{}, "", nil, false},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expErr {
+			t.Errorf("expected error %v but %v returned for annotations %v", testCase.expErr, err, testCase.annotations)
+		}
+
+		config, ok := result.(*Config)
+		if !ok {
+			t.Fatalf("expected a Config type but %T returned", result)
+		}
+
+		service := ""
+		if config.Service != nil {
+			service = config.Service.Name
+		}
+		if service != testCase.service {
+			t.Errorf("expected the service %q but %q returned for annotations %v", testCase.service, service, testCase.annotations)
+		}
+		if len(config.FailureCodes) > 0 || len(testCase.codes) > 0 {
+			if !reflect.DeepEqual(config.FailureCodes, testCase.codes) {
+				t.Errorf("expected the failure codes %v but %v returned for annotations %v", testCase.codes, config.FailureCodes, testCase.annotations)
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bodyinspection
+
+import (
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	xmlMaxDepthAnnotation            = "body-inspection-xml-max-depth"
+	xmlMaxEntityExpansionsAnnotation = "body-inspection-xml-max-entity-expansions"
+	jsonMaxDepthAnnotation           = "body-inspection-json-max-depth"
+	jsonMaxArraySizeAnnotation       = "body-inspection-json-max-array-size"
+)
+
+var bodyInspectionAnnotations = parser.Annotation{
+	Group: "body-inspection",
+	Annotations: parser.AnnotationFields{
+		xmlMaxDepthAnnotation: {
+			Validator: parser.ValidateInt,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the maximum nesting depth of the elements of the XML request bodies.
+			The requests with a deeper body are rejected with the status code 400.`,
+		},
+		xmlMaxEntityExpansionsAnnotation: {
+			Validator: parser.ValidateInt,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the maximum number of expansions of the entities declared in the DTD of the XML request bodies,
+			counting the entities referenced by the entities. The requests with more expansions are rejected with the status code 400, recursive entities exceeding any limit.`,
+		},
+		jsonMaxDepthAnnotation: {
+			Validator: parser.ValidateInt,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the maximum nesting depth of the objects and arrays of the JSON request bodies.
+			The requests with a deeper body are rejected with the status code 400.`,
+		},
+		jsonMaxArraySizeAnnotation: {
+			Validator: parser.ValidateInt,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the maximum number of elements of the arrays of the JSON request bodies.
+			The requests with a larger array are rejected with the status code 400.`,
+		},
+	},
+}
+
+// Config contains the limits of the inspection of the request bodies.
+// A limit of 0 is not checked.
+type Config struct {
+	// XMLMaxDepth is the maximum nesting depth of the XML elements
+	XMLMaxDepth int `json:"xmlMaxDepth,omitempty"`
+	// XMLMaxEntityExpansions is the maximum number of expansions of the
+	// XML entities
+	XMLMaxEntityExpansions int `json:"xmlMaxEntityExpansions,omitempty"`
+	// JSONMaxDepth is the maximum nesting depth of the JSON objects and arrays
+	JSONMaxDepth int `json:"jsonMaxDepth,omitempty"`
+	// JSONMaxArraySize is the maximum number of elements of the JSON arrays
+	JSONMaxArraySize int `json:"jsonMaxArraySize,omitempty"`
+}
+
+// Enabled returns true when the request bodies are inspected
+func (c Config) Enabled() bool {
+	return c != Config{}
+}
+
+type bodyInspection struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new request body inspection annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return bodyInspection{
+		r:                r,
+		annotationConfig: bodyInspectionAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to limit the structure of the request bodies
+func (a bodyInspection) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	limits := []struct {
+		annotation string
+		value      *int
+	}{
+		{xmlMaxDepthAnnotation, &config.XMLMaxDepth},
+		{xmlMaxEntityExpansionsAnnotation, &config.XMLMaxEntityExpansions},
+		{jsonMaxDepthAnnotation, &config.JSONMaxDepth},
+		{jsonMaxArraySizeAnnotation, &config.JSONMaxArraySize},
+	}
+
+	for _, limit := range limits {
+		value, err := parser.GetIntAnnotation(limit.annotation, ing, a.annotationConfig.Annotations)
+		switch {
+		case err == nil:
+			if value < 1 {
+				return nil, errors.NewInvalidAnnotationContent(limit.annotation, value)
+			}
+			*limit.value = value
+		case !errors.IsMissingAnnotations(err):
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+func (a bodyInspection) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a bodyInspection) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, bodyInspectionAnnotations.Annotations)
+}
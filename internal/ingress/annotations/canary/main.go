@@ -17,11 +17,14 @@ limitations under the License.
 package canary
 
 import (
+	"regexp"
+
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/expression"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
@@ -33,8 +36,13 @@ const (
 	canaryByHeaderValueAnnotation   = "canary-by-header-value"
 	canaryByHeaderPatternAnnotation = "canary-by-header-pattern"
 	canaryByCookieAnnotation        = "canary-by-cookie"
+	canaryDecisionHeaderAnnotation  = "canary-decision-header"
+	canaryByExpressionAnnotation    = "canary-by-expression"
 )
 
+// validHeaderName validates the name of the response header returning the routing decision
+var validHeaderName = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
 var CanaryAnnotations = parser.Annotation{
 	Group: "canary",
 	Annotations: parser.AnnotationFields{
@@ -88,6 +96,21 @@ var CanaryAnnotations = parser.Annotation{
 			Documentation: `This annotation defines the cookie that should be used for notifying the Ingress to route the request to the service specified in the Canary Ingress.
 			When the cookie is set to 'always', it will be routed to the canary. When the cookie is set to 'never', it will never be routed to the canary`,
 		},
+		canaryByExpressionAnnotation: {
+			Validator: parser.ValidateExpression,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium,
+			Documentation: `This annotation defines an expression over the attributes of the request, in a subset of the Common Expression Language (CEL).
+			When the expression is true, the request will be routed to the canary. Otherwise the request is compared against the canary weight.
+			e.g. 'request.headers["x-tenant"] in ["acme", "globex"] && request.method == "GET"'`,
+		},
+		canaryDecisionHeaderAnnotation: {
+			Validator: parser.ValidateRegex(validHeaderName, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the response header returning the backend, canary or stable, and the canary rule that routed the request.
+			The value of the header is like 'canary; reason=weight'`,
+		},
 	},
 }
 
@@ -105,6 +128,10 @@ type Config struct {
 	HeaderValue   string
 	HeaderPattern string
 	Cookie        string
+	// Expression is the compiled expression routing the requests to the canary when true
+	Expression *expression.Node
+	// DecisionHeader is the name of the response header returning the routing decision
+	DecisionHeader string
 }
 
 // NewParser parses the ingress for canary related annotations
@@ -177,8 +204,28 @@ func (c canary) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.Cookie = ""
 	}
 
+	exp, err := parser.GetStringAnnotation(canaryByExpressionAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid: %v, ignoring it", canaryByExpressionAnnotation, err)
+		}
+	} else {
+		config.Expression, err = expression.Compile(exp)
+		if err != nil {
+			klog.Warningf("%s is invalid: %v, ignoring it", canaryByExpressionAnnotation, err)
+		}
+	}
+
+	config.DecisionHeader, err = parser.GetStringAnnotation(canaryDecisionHeaderAnnotation, ing, c.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to ''", canaryDecisionHeaderAnnotation)
+		}
+		config.DecisionHeader = ""
+	}
+
 	if !config.Enabled && (config.Weight > 0 || config.Header != "" || config.HeaderValue != "" || config.Cookie != "" ||
-		config.HeaderPattern != "") {
+		config.HeaderPattern != "" || config.DecisionHeader != "" || config.Expression != nil) {
 		return nil, errors.NewInvalidAnnotationConfiguration(canaryAnnotation, "configured but not enabled")
 	}
 
@@ -154,3 +154,83 @@ func TestAnnotations(t *testing.T) {
 		}
 	}
 }
+
+func TestDecisionHeader(t *testing.T) {
+	ing := buildIngress()
+
+	tests := []struct {
+		title          string
+		canaryEnabled  bool
+		decisionHeader string
+		expected       string
+		expErr         bool
+	}{
+		{"canary enabled with a decision header", true, "X-Canary-Decision", "X-Canary-Decision", false},
+		{"canary enabled with an invalid decision header", true, "X-Canary: Decision", "", false},
+		{"canary disabled with a decision header", false, "X-Canary-Decision", "", true},
+	}
+
+	for _, test := range tests {
+		ing.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("canary"):                 strconv.FormatBool(test.canaryEnabled),
+			parser.GetAnnotationWithPrefix("canary-decision-header"): test.decisionHeader,
+		})
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: expected nil but returned error %v", test.title, err)
+			continue
+		}
+
+		if decisionHeader := i.(*Config).DecisionHeader; decisionHeader != test.expected {
+			t.Errorf("%v: expected \"%v\", but \"%v\" was returned", test.title, test.expected, decisionHeader)
+		}
+	}
+}
+
+func TestExpression(t *testing.T) {
+	ing := buildIngress()
+
+	tests := []struct {
+		title         string
+		canaryEnabled bool
+		expression    string
+		expected      bool
+		expErr        bool
+	}{
+		{"canary enabled with an expression", true, `request.headers["x-tenant"] == "acme"`, true, false},
+		{"canary enabled with an invalid expression", true, `request.headers["x-tenant"]`, false, false},
+		{"canary disabled with an expression", false, `request.method == "GET"`, false, true},
+	}
+
+	for _, test := range tests {
+		ing.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("canary"):               strconv.FormatBool(test.canaryEnabled),
+			parser.GetAnnotationWithPrefix("canary-by-expression"): test.expression,
+		})
+
+		i, err := NewParser(&resolver.Mock{}).Parse(ing)
+		if test.expErr {
+			if err == nil {
+				t.Errorf("%v: expected error but returned nil", test.title)
+			}
+
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: expected nil but returned error %v", test.title, err)
+			continue
+		}
+
+		if compiled := i.(*Config).Expression != nil; compiled != test.expected {
+			t.Errorf("%v: expected a compiled expression %v, but %v was returned", test.title, test.expected, compiled)
+		}
+	}
+}
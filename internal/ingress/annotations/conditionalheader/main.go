@@ -0,0 +1,138 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditionalheader
+
+import (
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/expression"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	conditionalRequestHeaderAnnotation           = "conditional-request-header"
+	conditionalRequestHeaderExpressionAnnotation = "conditional-request-header-expression"
+)
+
+// validHeader validates the name and the value of the header, the value cannot
+// reference NGINX variables
+var validHeader = regexp.MustCompile(`^[a-zA-Z0-9-]+:\s*[^$"'\\{};\r\n]+$`)
+
+var conditionalHeaderAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		conditionalRequestHeaderAnnotation: {
+			Validator:     parser.ValidateRegex(validHeader, false),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskMedium, // The backend may trust the header
+			Documentation: `This annotation defines the header, like "X-Beta: on", sent to the backend with the requests for which conditional-request-header-expression is true.`,
+		},
+		conditionalRequestHeaderExpressionAnnotation: {
+			Validator: parser.ValidateExpression,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines an expression over the attributes of the request, in a subset of the Common Expression Language (CEL),
+			e.g. 'request.cookies["beta"] == "1" && request.method == "GET"'.`,
+		},
+	},
+}
+
+// Config contains the header sent to the backend with the requests matching an expression
+type Config struct {
+	Name       string           `json:"name,omitempty"`
+	Value      string           `json:"value,omitempty"`
+	Expression *expression.Node `json:"expression,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Name != c2.Name {
+		return false
+	}
+	if c1.Value != c2.Value {
+		return false
+	}
+
+	return c1.Expression.Equal(c2.Expression)
+}
+
+type conditionalHeader struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new conditional header annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return conditionalHeader{
+		r:                r,
+		annotationConfig: conditionalHeaderAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to send a header to the backend when an expression is true
+func (a conditionalHeader) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	header, err := parser.GetStringAnnotation(conditionalRequestHeaderAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return config, err
+		}
+		return config, nil
+	}
+
+	exp, err := parser.GetStringAnnotation(conditionalRequestHeaderExpressionAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return config, err
+		}
+		return config, ing_errors.NewLocationDenied("conditional-request-header requires conditional-request-header-expression")
+	}
+
+	compiled, err := expression.Compile(exp)
+	if err != nil {
+		return config, ing_errors.NewLocationDenied(err.Error())
+	}
+
+	name, value, _ := strings.Cut(header, ":")
+	config.Name = name
+	config.Value = strings.TrimSpace(value)
+	config.Expression = compiled
+
+	return config, nil
+}
+
+func (a conditionalHeader) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a conditionalHeader) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, conditionalHeaderAnnotations.Annotations)
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package conditionalheader
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	header := parser.GetAnnotationWithPrefix(conditionalRequestHeaderAnnotation)
+	exp := parser.GetAnnotationWithPrefix(conditionalRequestHeaderExpressionAnnotation)
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		title       string
+		annotations map[string]string
+		name        string
+		value       string
+		expErr      bool
+	}{
+		{"header and expression", map[string]string{header: "X-Beta: on", exp: `request.cookies["beta"] == "1"`}, "X-Beta", "on", false},
+		{"header without space", map[string]string{header: "X-Tenant:acme corp", exp: `true`}, "X-Tenant", "acme corp", false},
+		{"header without expression", map[string]string{header: "X-Beta: on"}, "", "", true},
+		{"header with a variable", map[string]string{header: "X-Beta: $host", exp: `true`}, "", "", true},
+		{"header without value", map[string]string{header: "X-Beta:", exp: `true`}, "", "", true},
+		{"invalid expression", map[string]string{header: "X-Beta: on", exp: `request.method`}, "", "", true},
+		{"expression without header", map[string]string{exp: `true`}, "", "", false},
+		{"no annotations", map[string]string{}, "", "", false},
+	}
+
+	for _, tc := range testCases {
+		ing.SetAnnotations(tc.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != tc.expErr {
+			t.Errorf("%s: expected error %v but %v returned", tc.title, tc.expErr, err)
+		}
+
+		config, ok := result.(*Config)
+		if !ok {
+			t.Fatalf("%s: expected a Config type but %T returned", tc.title, result)
+		}
+		if config.Name != tc.name || config.Value != tc.value {
+			t.Errorf("%s: expected the header %q: %q but %q: %q returned", tc.title, tc.name, tc.value, config.Name, config.Value)
+		}
+		if (config.Expression != nil) != (tc.name != "") {
+			t.Errorf("%s: unexpected expression %v", tc.title, config.Expression)
+		}
+	}
+}
@@ -22,11 +22,13 @@ import (
 	networking "k8s.io/api/networking/v1"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 )
 
 const (
 	connectionProxyHeaderAnnotation = "connection-proxy-header"
+	upstreamKeepaliveAnnotation     = "upstream-keepalive"
 )
 
 var validConnectionHeaderValue = regexp.MustCompile(`^(close|keep-alive)$`)
@@ -40,6 +42,13 @@ var connectionHeadersAnnotations = parser.Annotation{
 			Risk:          parser.AnnotationRiskLow,
 			Documentation: `This annotation allows setting a specific value for "proxy_set_header Connection" directive. Right now it is restricted to "close" or "keep-alive"`,
 		},
+		upstreamKeepaliveAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation enables or disables the reuse of the connections to the upstream servers of the location. 
+			When disabled, the "Connection" header sent to the upstream servers is "close", except for the upgrade requests like WebSocket. (default: true)`,
+		},
 	},
 }
 
@@ -47,6 +56,8 @@ var connectionHeadersAnnotations = parser.Annotation{
 type Config struct {
 	Header  string `json:"header"`
 	Enabled bool   `json:"enabled"`
+	// KeepaliveDisabled disables the reuse of the connections to the upstream servers
+	KeepaliveDisabled bool `json:"keepaliveDisabled"`
 }
 
 type connection struct {
@@ -65,16 +76,26 @@ func NewParser(r resolver.Resolver) parser.IngressAnnotation {
 // Parse parses the annotations contained in the ingress
 // rule used to indicate if the connection header should be overridden.
 func (a connection) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	keepalive, err := parser.GetBoolAnnotation(upstreamKeepaliveAnnotation, ing, a.annotationConfig.Annotations)
+	hasKeepalive := err == nil
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return config, err
+	}
+	config.KeepaliveDisabled = hasKeepalive && !keepalive
+
 	cp, err := parser.GetStringAnnotation(connectionProxyHeaderAnnotation, ing, a.annotationConfig.Annotations)
 	if err != nil {
-		return &Config{
-			Enabled: false,
-		}, err
+		if hasKeepalive && errors.IsMissingAnnotations(err) {
+			return config, nil
+		}
+		return config, err
 	}
-	return &Config{
-		Enabled: true,
-		Header:  cp,
-	}, nil
+
+	config.Enabled = true
+	config.Header = cp
+	return config, nil
 }
 
 // Equal tests for equality between two Connection types
@@ -91,6 +112,9 @@ func (r1 *Config) Equal(r2 *Config) bool {
 	if r1.Header != r2.Header {
 		return false
 	}
+	if r1.KeepaliveDisabled != r2.KeepaliveDisabled {
+		return false
+	}
 
 	return true
 }
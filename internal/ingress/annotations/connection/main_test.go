@@ -28,6 +28,7 @@ import (
 
 func TestParse(t *testing.T) {
 	annotation := parser.GetAnnotationWithPrefix("connection-proxy-header")
+	keepaliveAnnotation := parser.GetAnnotationWithPrefix("upstream-keepalive")
 
 	ap := NewParser(&resolver.Mock{})
 	if ap == nil {
@@ -41,6 +42,10 @@ func TestParse(t *testing.T) {
 	}{
 		{map[string]string{annotation: "keep-alive"}, &Config{Enabled: true, Header: "keep-alive"}, false},
 		{map[string]string{annotation: "not-allowed-value"}, &Config{Enabled: false}, true},
+		{map[string]string{keepaliveAnnotation: "false"}, &Config{KeepaliveDisabled: true}, false},
+		{map[string]string{keepaliveAnnotation: "true"}, &Config{}, false},
+		{map[string]string{keepaliveAnnotation: "false", annotation: "close"}, &Config{Enabled: true, Header: "close", KeepaliveDisabled: true}, false},
+		{map[string]string{keepaliveAnnotation: "invalid"}, &Config{}, true},
 		{map[string]string{}, &Config{Enabled: false}, true},
 		{nil, &Config{Enabled: false}, true},
 	}
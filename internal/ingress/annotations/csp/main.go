@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csp
+
+import (
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	policyAnnotation     = "content-security-policy"
+	reportOnlyAnnotation = "content-security-policy-report-only"
+	reportAnnotation     = "content-security-policy-report"
+)
+
+// ReportEndpoint is the name of the reporting endpoint of the violation
+// reports in the Reporting-Endpoints header and the report-to directive
+const ReportEndpoint = "ingress-nginx-csp"
+
+// policyRegex rejects the characters breaking the header in the NGINX
+// configuration, like quotes, variables and control characters
+var policyRegex = regexp.MustCompile(`^[^"\\${}\x00-\x1f\x7f]+$`)
+
+// reportDirectiveRegex matches the directives sending the violation reports
+var reportDirectiveRegex = regexp.MustCompile(`(^|;)\s*(report-uri|report-to)(\s|;|$)`)
+
+var cspAnnotations = parser.Annotation{
+	Group: "csp",
+	Annotations: parser.AnnotationFields{
+		policyAnnotation: {
+			Validator: parser.ValidateRegex(policyRegex, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskMedium, // Medium, as it defines a response header
+			Documentation: `This annotation defines the Content Security Policy of the responses of the location, sent in the Content-Security-Policy header.
+			Double quotes, backslashes, dollar signs, braces and control characters are not allowed.`,
+		},
+		reportOnlyAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sends the Content Security Policy in the Content-Security-Policy-Report-Only header,
+			the browsers report the violations of the policy without enforcing it.`,
+		},
+		reportAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sends the violation reports of the Content Security Policy to an endpoint served by the controller in the server of the location,
+			counting the violations by directive in metrics and logging them. The policy cannot define the report-uri and report-to directives.`,
+		},
+	},
+}
+
+// Config contains the Content Security Policy of a location
+type Config struct {
+	// Policy is the Content Security Policy, empty when the header is not sent
+	Policy string `json:"policy,omitempty"`
+	// ReportOnly sends the policy in the Content-Security-Policy-Report-Only
+	// header
+	ReportOnly bool `json:"reportOnly,omitempty"`
+	// Report sends the violation reports to the endpoint served by the
+	// controller
+	Report bool `json:"report,omitempty"`
+}
+
+// Header returns the name of the header of the policy
+func (c Config) Header() string {
+	if c.ReportOnly {
+		return "Content-Security-Policy-Report-Only"
+	}
+	return "Content-Security-Policy"
+}
+
+type csp struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new Content Security Policy annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return csp{
+		r:                r,
+		annotationConfig: cspAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to send a Content Security Policy and collect its violation reports
+func (a csp) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	policy, err := parser.GetStringAnnotation(policyAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	config.Policy = strings.TrimSpace(policy)
+
+	config.ReportOnly, err = parser.GetBoolAnnotation(reportOnlyAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	config.Report, err = parser.GetBoolAnnotation(reportAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+
+	if config.Report && reportDirectiveRegex.MatchString(config.Policy) {
+		return nil, errors.NewInvalidAnnotationConfiguration(policyAnnotation,
+			"the policy cannot define the report-uri and report-to directives with the reports sent to the controller")
+	}
+
+	return config, nil
+}
+
+func (a csp) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a csp) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, cspAnnotations.Annotations)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csp
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	policy := parser.GetAnnotationWithPrefix(policyAnnotation)
+	reportOnly := parser.GetAnnotationWithPrefix(reportOnlyAnnotation)
+	report := parser.GetAnnotationWithPrefix(reportAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{reportOnly: "true", report: "true"}, &Config{}, false},
+		{
+			map[string]string{policy: " default-src 'self'; img-src * ", reportOnly: "true", report: "true"},
+			&Config{Policy: "default-src 'self'; img-src *", ReportOnly: true, Report: true},
+			false,
+		},
+		{
+			map[string]string{policy: "default-src 'self'; report-uri https://reports.example.com"},
+			&Config{Policy: "default-src 'self'; report-uri https://reports.example.com"},
+			false,
+		},
+		{map[string]string{policy: "default-src 'self'; report-uri /csp", report: "true"}, nil, true},
+		{map[string]string{policy: "report-to csp; default-src 'self'", report: "true"}, nil, true},
+		{map[string]string{policy: "default-src 'self'", reportOnly: "yes"}, nil, true},
+		{map[string]string{policy: `default-src "self"`}, nil, true},
+		{map[string]string{policy: "default-src $host"}, nil, true},
+		{map[string]string{policy: "default-src 'self';\nadd_header X-Foo bar"}, nil, true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Fatalf("expected error: %t got error: %t err value: %s. %+v", testCase.expectErr, err != nil, err, testCase.annotations)
+		}
+		if !testCase.expectErr && !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %+v but returned %+v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
+
+func TestHeader(t *testing.T) {
+	if header := (Config{Policy: "default-src 'self'"}).Header(); header != "Content-Security-Policy" {
+		t.Errorf("expected the Content-Security-Policy header but returned %v", header)
+	}
+	if header := (Config{Policy: "default-src 'self'", ReportOnly: true}).Header(); header != "Content-Security-Policy-Report-Only" {
+		t.Errorf("expected the Content-Security-Policy-Report-Only header but returned %v", header)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorpage
+
+import (
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	preserveURIAnnotation    = "default-backend-preserve-uri"
+	preserveMethodAnnotation = "default-backend-preserve-method"
+	customHeadersAnnotation  = "default-backend-custom-headers"
+)
+
+var errorPageAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		preserveURIAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sends the original URI of the request to the default backend handling the error codes defined
+			in the custom-http-errors annotation, instead of /. By default the URI is not preserved.`,
+		},
+		preserveMethodAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines if the original method of the request is sent to the default backend handling the error codes
+			defined in the custom-http-errors annotation. When it is false, the default backend receives a GET request without body. By default the method is preserved.`,
+		},
+		customHeadersAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sends the custom headers defined in the proxy-set-headers ConfigMap to the default backend handling the
+			error codes defined in the custom-http-errors annotation. By default the custom headers are only sent to the services of the Ingress.`,
+		},
+	},
+}
+
+// Config contains how the requests are sent to the default backend
+// serving the error pages of a location. The zero value sends the
+// original method to / without custom headers.
+type Config struct {
+	// PreserveURI sends the original URI instead of /
+	PreserveURI bool `json:"preserveURI"`
+	// DiscardMethod sends a GET request without body instead of the original method
+	DiscardMethod bool `json:"discardMethod"`
+	// CustomHeaders sends the headers defined in the proxy-set-headers ConfigMap
+	CustomHeaders bool `json:"customHeaders"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+// Suffix returns the suffix of the name of the error locations using the
+// configuration, to distinguish them from the error locations using the
+// default configuration
+func (c Config) Suffix() string {
+	var suffix []string
+	if c.PreserveURI {
+		suffix = append(suffix, "uri")
+	}
+	if c.DiscardMethod {
+		suffix = append(suffix, "get")
+	}
+	if c.CustomHeaders {
+		suffix = append(suffix, "headers")
+	}
+
+	if len(suffix) == 0 {
+		return ""
+	}
+
+	return "_" + strings.Join(suffix, "_")
+}
+
+type errorPage struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new error page annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return errorPage{
+		r:                r,
+		annotationConfig: errorPageAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress to configure
+// the requests sent to the default backend serving the error pages
+func (e errorPage) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := Config{}
+
+	preserveMethod := true
+	for annotation, value := range map[string]*bool{
+		preserveURIAnnotation:    &config.PreserveURI,
+		preserveMethodAnnotation: &preserveMethod,
+		customHeadersAnnotation:  &config.CustomHeaders,
+	} {
+		val, err := parser.GetBoolAnnotation(annotation, ing, e.annotationConfig.Annotations)
+		if err != nil {
+			if errors.IsValidationError(err) {
+				return nil, err
+			}
+
+			continue
+		}
+
+		*value = val
+	}
+
+	config.DiscardMethod = !preserveMethod
+
+	return config, nil
+}
+
+func (e errorPage) GetDocumentation() parser.AnnotationFields {
+	return e.annotationConfig.Annotations
+}
+
+func (e errorPage) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(e.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, errorPageAnnotations.Annotations)
+}
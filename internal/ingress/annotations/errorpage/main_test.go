@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package errorpage
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	preserveURI := parser.GetAnnotationWithPrefix(preserveURIAnnotation)
+	preserveMethod := parser.GetAnnotationWithPrefix(preserveMethodAnnotation)
+	customHeaders := parser.GetAnnotationWithPrefix(customHeadersAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    Config
+		suffix      string
+		expectErr   bool
+	}{
+		{nil, Config{}, "", false},
+		{map[string]string{preserveURI: "true"}, Config{PreserveURI: true}, "_uri", false},
+		{map[string]string{preserveMethod: "true"}, Config{}, "", false},
+		{map[string]string{preserveMethod: "false"}, Config{DiscardMethod: true}, "_get", false},
+		{
+			map[string]string{preserveURI: "true", preserveMethod: "false", customHeaders: "true"},
+			Config{PreserveURI: true, DiscardMethod: true, CustomHeaders: true},
+			"_uri_get_headers",
+			false,
+		},
+		{map[string]string{customHeaders: "yes"}, Config{}, "", true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("expected error: %t but got error: %v for annotations %v", testCase.expectErr, err, testCase.annotations)
+			continue
+		}
+
+		if testCase.expectErr {
+			continue
+		}
+
+		config, ok := result.(Config)
+		if !ok {
+			t.Fatalf("expected a Config type but %T was returned", result)
+		}
+
+		if config != testCase.expected {
+			t.Errorf("expected %+v but returned %+v, annotations: %v", testCase.expected, config, testCase.annotations)
+		}
+
+		if config.Suffix() != testCase.suffix {
+			t.Errorf("expected suffix %q but returned %q, annotations: %v", testCase.suffix, config.Suffix(), testCase.annotations)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalname
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	externalNameResolverAnnotation   = "external-name-resolver"
+	externalNameDNSTTLAnnotation     = "external-name-dns-ttl"
+	externalNameDNSRefreshAnnotation = "external-name-dns-refresh"
+)
+
+var externalNameAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		externalNameResolverAnnotation: {
+			Validator: validateNameservers,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium, // Medium, as it allows sending DNS queries to any nameserver
+			Documentation: `This annotation defines a comma separated list of the nameservers used to resolve the name of the Services of type ExternalName.
+			If not defined, the nameservers of the NGINX resolver are used.`,
+		},
+		externalNameDNSTTLAnnotation: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeIngress,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation overrides the TTL, in seconds, of the addresses of the Services of type ExternalName. The TTL of the DNS answers is used if 0.`,
+		},
+		externalNameDNSRefreshAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation enables or disables the resolution of the name of the Services of type ExternalName when the TTL of their addresses expires.
+			When disabled, the name is only resolved when the backends are configured.`,
+		},
+	},
+}
+
+// Config contains the DNS resolution of the Services of type ExternalName
+type Config struct {
+	// Nameservers used to resolve the name of the Services
+	Nameservers []string `json:"nameservers"`
+	// TTL in seconds of the resolved addresses, 0 to use the TTL of the DNS answers
+	TTL int `json:"ttl"`
+	// Static indicates that the name is not resolved again when the TTL expires
+	Static bool `json:"static"`
+}
+
+type externalName struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new ExternalName DNS resolution annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return externalName{
+		r:                r,
+		annotationConfig: externalNameAnnotations,
+	}
+}
+
+// validateNameservers validates if the specified value is a comma separated list of IP addresses
+func validateNameservers(value string) error {
+	for _, ns := range strings.Split(value, ",") {
+		if net.ParseIP(strings.TrimSpace(ns)) == nil {
+			return fmt.Errorf("%q is not a valid IP address", ns)
+		}
+	}
+	return nil
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to configure the DNS resolution of the Services of type ExternalName
+func (a externalName) Parse(ing *networking.Ingress) (interface{}, error) {
+	defBackend := a.r.GetDefaultBackend()
+	config := &Config{
+		Nameservers: defBackend.ExternalNameResolver,
+		TTL:         defBackend.ExternalNameDNSTTL,
+		Static:      !defBackend.ExternalNameDNSRefresh,
+	}
+
+	nameservers, err := parser.GetStringAnnotation(externalNameResolverAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.Nameservers = []string{}
+		for _, ns := range strings.Split(nameservers, ",") {
+			config.Nameservers = append(config.Nameservers, strings.TrimSpace(ns))
+		}
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	ttl, err := parser.GetIntAnnotation(externalNameDNSTTLAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		if ttl < 0 {
+			return nil, errors.NewInvalidAnnotationContent(externalNameDNSTTLAnnotation, ttl)
+		}
+		config.TTL = ttl
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	refresh, err := parser.GetBoolAnnotation(externalNameDNSRefreshAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.Static = !refresh
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (a externalName) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a externalName) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, externalNameAnnotations.Annotations)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package externalname
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockBackend struct {
+	resolver.Mock
+}
+
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{
+		ExternalNameResolver:   []string{"10.0.0.10"},
+		ExternalNameDNSTTL:     30,
+		ExternalNameDNSRefresh: true,
+	}
+}
+
+func TestParse(t *testing.T) {
+	resolverAnnotation := parser.GetAnnotationWithPrefix(externalNameResolverAnnotation)
+	ttlAnnotation := parser.GetAnnotationWithPrefix(externalNameDNSTTLAnnotation)
+	refreshAnnotation := parser.GetAnnotationWithPrefix(externalNameDNSRefreshAnnotation)
+
+	ap := NewParser(mockBackend{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{Nameservers: []string{"10.0.0.10"}, TTL: 30}, false},
+		{
+			map[string]string{resolverAnnotation: "10.0.0.53, 2001:db8::53", ttlAnnotation: "0", refreshAnnotation: "false"},
+			&Config{Nameservers: []string{"10.0.0.53", "2001:db8::53"}, TTL: 0, Static: true},
+			false,
+		},
+		{map[string]string{ttlAnnotation: "300"}, &Config{Nameservers: []string{"10.0.0.10"}, TTL: 300}, false},
+		{map[string]string{resolverAnnotation: "dns.example.com"}, nil, true},
+		{map[string]string{resolverAnnotation: "10.0.0.53,"}, nil, true},
+		{map[string]string{ttlAnnotation: "-1"}, nil, true},
+		{map[string]string{refreshAnnotation: "sometimes"}, nil, true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Fatalf("expected error: %t got error: %t err value: %s. %+v", testCase.expectErr, err != nil, err, testCase.annotations)
+		}
+		if !testCase.expectErr && !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %+v but returned %+v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
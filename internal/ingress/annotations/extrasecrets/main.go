@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extrasecrets
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	extraSecretsAnnotation = "extra-secrets"
+)
+
+// secretsRegex validates a list of Secrets like "client-ca,monitoring/token"
+var secretsRegex = regexp.MustCompile(`^\s*[a-z0-9][a-z0-9.\-]*(/[a-z0-9][a-z0-9.\-]*)?(\s*,\s*[a-z0-9][a-z0-9.\-]*(/[a-z0-9][a-z0-9.\-]*)?)*\s*$`)
+
+var extraSecretsAnnotations = parser.Annotation{
+	Group: "snippets",
+	Annotations: parser.AnnotationFields{
+		extraSecretsAnnotation: {
+			Validator: parser.ValidateRegex(secretsRegex, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium,
+			Documentation: `This annotation defines a comma separated list of Secrets (name or namespace/name) referenced in the snippets of the Ingress.
+			The Secrets are watched like the Secrets referenced by other annotations, so their changes reload the configuration`,
+		},
+	},
+}
+
+// Secret is a Secret referenced in the snippets of an Ingress
+type Secret struct {
+	// Name is the key (namespace/name) of the Secret
+	Name string `json:"name"`
+	// ResourceVersion is the version of the Secret, empty when the Secret does not exist
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type extraSecrets struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new extra secrets annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return extraSecrets{
+		r:                r,
+		annotationConfig: extraSecretsAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress to return the
+// Secrets referenced in the snippets with their current version
+func (e extraSecrets) Parse(ing *networking.Ingress) (interface{}, error) {
+	keys, err := secretKeys(ing, e.annotationConfig.Annotations, e.r.GetSecurityConfiguration().AllowCrossNamespaceResources)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := make([]Secret, 0, len(keys))
+	for _, key := range keys {
+		secret, err := e.r.GetSecret(key)
+		if err != nil || secret == nil {
+			klog.Warningf("Secret %v referenced in annotation %v of Ingress %v/%v not found", key, extraSecretsAnnotation, ing.Namespace, ing.Name)
+			secrets = append(secrets, Secret{Name: key})
+			continue
+		}
+
+		secrets = append(secrets, Secret{Name: key, ResourceVersion: secret.ResourceVersion})
+	}
+
+	return secrets, nil
+}
+
+// SecretKeys returns the keys (namespace/name) of the Secrets listed in the
+// extra-secrets annotation of an Ingress
+func SecretKeys(ing *networking.Ingress, allowCrossNamespace bool) ([]string, error) {
+	return secretKeys(ing, nil, allowCrossNamespace)
+}
+
+func secretKeys(ing *networking.Ingress, fields parser.AnnotationFields, allowCrossNamespace bool) ([]string, error) {
+	value, err := parser.GetStringAnnotation(extraSecretsAnnotation, ing, fields)
+	if err != nil {
+		return nil, err
+	}
+
+	if !secretsRegex.MatchString(value) {
+		return nil, ing_errors.NewInvalidAnnotationContent(extraSecretsAnnotation, value)
+	}
+
+	keys := make([]string, 0)
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+
+		ns, _, found := strings.Cut(name, "/")
+		if !found {
+			keys = append(keys, fmt.Sprintf("%v/%v", ing.Namespace, name))
+			continue
+		}
+
+		if !allowCrossNamespace && ns != ing.Namespace {
+			return nil, ing_errors.NewInvalidAnnotationConfiguration(extraSecretsAnnotation, "cross namespace secrets are not supported")
+		}
+
+		keys = append(keys, name)
+	}
+
+	return keys, nil
+}
+
+func (e extraSecrets) GetDocumentation() parser.AnnotationFields {
+	return e.annotationConfig.Annotations
+}
+
+func (e extraSecrets) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(e.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, extraSecretsAnnotations.Annotations)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extrasecrets
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockSecret struct {
+	resolver.Mock
+}
+
+func (m mockSecret) GetSecret(name string) (*api.Secret, error) {
+	if name != "default/client-ca" && name != "other/token" {
+		return nil, errors.New("secret not found")
+	}
+
+	return &api.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:            name,
+			ResourceVersion: "42",
+		},
+	}, nil
+}
+
+func TestParse(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix(extraSecretsAnnotation)
+
+	testCases := []struct {
+		annotations         map[string]string
+		allowCrossNamespace bool
+		expected            []Secret
+		expectErr           bool
+	}{
+		{map[string]string{annotation: "client-ca"}, false, []Secret{{Name: "default/client-ca", ResourceVersion: "42"}}, false},
+		{map[string]string{annotation: "client-ca, default/missing"}, false, []Secret{{Name: "default/client-ca", ResourceVersion: "42"}, {Name: "default/missing"}}, false},
+		{map[string]string{annotation: "client-ca,other/token"}, true, []Secret{{Name: "default/client-ca", ResourceVersion: "42"}, {Name: "other/token", ResourceVersion: "42"}}, false},
+		{map[string]string{annotation: "other/token"}, false, nil, true},
+		{map[string]string{annotation: "ns/name/garbage"}, true, nil, true},
+		{map[string]string{annotation: "client-ca;"}, false, nil, true},
+		{map[string]string{annotation: ""}, false, nil, true},
+		{map[string]string{}, false, nil, true},
+		{nil, false, nil, true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ap := NewParser(mockSecret{resolver.Mock{AllowCrossNamespace: testCase.allowCrossNamespace}})
+
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("expected error: %t but got error: %v for annotations %v", testCase.expectErr, err, testCase.annotations)
+			continue
+		}
+
+		if testCase.expectErr {
+			continue
+		}
+
+		if !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %v but returned %v, annotations: %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
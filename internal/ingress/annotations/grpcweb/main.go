@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package grpcweb
+
+import (
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	grpcWebAnnotation = "grpc-web"
+)
+
+var grpcWebAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		grpcWebAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `Translates the gRPC-Web requests of the browsers to gRPC for the backend, and the responses of the backend to gRPC-Web.
+			Requires the backend-protocol GRPC or GRPCS.`,
+		},
+	},
+}
+
+type grpcWeb struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new gRPC-Web annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return grpcWeb{
+		r:                r,
+		annotationConfig: grpcWebAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to translate gRPC-Web to gRPC
+func (g grpcWeb) Parse(ing *networking.Ingress) (interface{}, error) {
+	return parser.GetBoolAnnotation(grpcWebAnnotation, ing, g.annotationConfig.Annotations)
+}
+
+func (g grpcWeb) GetDocumentation() parser.AnnotationFields {
+	return g.annotationConfig.Annotations
+}
+
+func (g grpcWeb) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(g.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, grpcWebAnnotations.Annotations)
+}
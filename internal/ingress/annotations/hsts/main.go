@@ -0,0 +1,181 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsts
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	hstsMaxAgeAnnotation            = "hsts-max-age"
+	hstsIncludeSubdomainsAnnotation = "hsts-include-subdomains"
+	hstsPreloadAnnotation           = "hsts-preload"
+	hstsExcludeHostsAnnotation      = "hsts-exclude-hosts"
+)
+
+var (
+	maxAgeRegex = regexp.MustCompile(`^[0-9]+$`)
+	hostsRegex  = regexp.MustCompile(`^(\*\.)?[A-Za-z0-9\-.]+(,(\*\.)?[A-Za-z0-9\-.]+)*$`)
+)
+
+var hstsAnnotations = parser.Annotation{
+	Group: "hsts",
+	Annotations: parser.AnnotationFields{
+		hstsMaxAgeAnnotation: {
+			Validator: parser.ValidateRegex(maxAgeRegex, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation overrides the max-age, in seconds, of the HSTS header of the hosts of the Ingress.
+			It cannot be lower than the hsts-min-max-age of the ConfigMap.`,
+		},
+		hstsIncludeSubdomainsAnnotation: {
+			Validator:     parser.ValidateBool,
+			Scope:         parser.AnnotationScopeIngress,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation overrides the includeSubDomains directive of the HSTS header of the hosts of the Ingress.`,
+		},
+		hstsPreloadAnnotation: {
+			Validator:     parser.ValidateBool,
+			Scope:         parser.AnnotationScopeIngress,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation overrides the preload directive of the HSTS header of the hosts of the Ingress.`,
+		},
+		hstsExcludeHostsAnnotation: {
+			Validator: parser.ValidateRegex(hostsRegex, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium, // Medium, as it removes the HSTS protection of the hosts
+			Documentation: `This annotation defines a comma separated list of the hosts of the Ingress without the HSTS header, like development domains.
+			Wildcards like *.dev.example.com are supported. It is not allowed when the ConfigMap defines a hsts-min-max-age.`,
+		},
+	},
+}
+
+// Config overrides the HSTS header of the hosts of an Ingress
+type Config struct {
+	// MaxAge is the max-age of the header in seconds, empty to use the global value
+	MaxAge               string   `json:"maxAge"`
+	IncludeSubdomains    bool     `json:"includeSubdomains"`
+	IncludeSubdomainsSet bool     `json:"includeSubdomainsSet"`
+	Preload              bool     `json:"preload"`
+	PreloadSet           bool     `json:"preloadSet"`
+	ExcludeHosts         []string `json:"excludeHosts"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.MaxAge != c2.MaxAge {
+		return false
+	}
+	if c1.IncludeSubdomains != c2.IncludeSubdomains || c1.IncludeSubdomainsSet != c2.IncludeSubdomainsSet {
+		return false
+	}
+	if c1.Preload != c2.Preload || c1.PreloadSet != c2.PreloadSet {
+		return false
+	}
+	return slices.Equal(c1.ExcludeHosts, c2.ExcludeHosts)
+}
+
+type hsts struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new HSTS annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return hsts{
+		r:                r,
+		annotationConfig: hstsAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to override the HSTS header of the hosts of the Ingress
+func (h hsts) Parse(ing *networking.Ingress) (interface{}, error) {
+	minMaxAge := h.r.GetDefaultBackend().HSTSMinMaxAge
+	config := &Config{}
+
+	maxAge, err := parser.GetIntAnnotation(hstsMaxAgeAnnotation, ing, h.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		if maxAge < minMaxAge {
+			return nil, errors.NewInvalidAnnotationConfiguration(hstsMaxAgeAnnotation,
+				fmt.Sprintf("the max-age %v is lower than the minimum %v", maxAge, minMaxAge))
+		}
+		config.MaxAge = fmt.Sprintf("%v", maxAge)
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	config.IncludeSubdomains, err = parser.GetBoolAnnotation(hstsIncludeSubdomainsAnnotation, ing, h.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.IncludeSubdomainsSet = true
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	config.Preload, err = parser.GetBoolAnnotation(hstsPreloadAnnotation, ing, h.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.PreloadSet = true
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	excludeHosts, err := parser.GetStringAnnotation(hstsExcludeHostsAnnotation, ing, h.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		if minMaxAge > 0 {
+			return nil, errors.NewInvalidAnnotationConfiguration(hstsExcludeHostsAnnotation,
+				fmt.Sprintf("the hosts cannot be excluded with the minimum max-age %v", minMaxAge))
+		}
+		for _, host := range strings.Split(excludeHosts, ",") {
+			host = strings.ToLower(strings.TrimSpace(host))
+			if host != "" && !slices.Contains(config.ExcludeHosts, host) {
+				config.ExcludeHosts = append(config.ExcludeHosts, host)
+			}
+		}
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (h hsts) GetDocumentation() parser.AnnotationFields {
+	return h.annotationConfig.Annotations
+}
+
+func (h hsts) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(h.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, hstsAnnotations.Annotations)
+}
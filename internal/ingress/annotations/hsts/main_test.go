@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package hsts
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockBackend struct {
+	resolver.Mock
+	minMaxAge int
+}
+
+func (m mockBackend) GetDefaultBackend() defaults.Backend {
+	return defaults.Backend{HSTSMinMaxAge: m.minMaxAge}
+}
+
+func buildIngress(annotations map[string]string) *networking.Ingress {
+	data := map[string]string{}
+	for name, value := range annotations {
+		data[parser.GetAnnotationWithPrefix(name)] = value
+	}
+
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   api.NamespaceDefault,
+			Annotations: data,
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		title       string
+		minMaxAge   int
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{"without annotations", 0, nil, &Config{}, false},
+		{
+			"all the annotations", 0,
+			map[string]string{
+				hstsMaxAgeAnnotation:            "63072000",
+				hstsIncludeSubdomainsAnnotation: "false",
+				hstsPreloadAnnotation:           "true",
+				hstsExcludeHostsAnnotation:      "dev.example.com, *.Dev.example.com,dev.example.com",
+			},
+			&Config{
+				MaxAge:               "63072000",
+				IncludeSubdomainsSet: true,
+				Preload:              true,
+				PreloadSet:           true,
+				ExcludeHosts:         []string{"dev.example.com", "*.dev.example.com"},
+			},
+			false,
+		},
+		{"max-age above the minimum", 31536000, map[string]string{hstsMaxAgeAnnotation: "31536000"}, &Config{MaxAge: "31536000"}, false},
+		{"max-age below the minimum", 31536000, map[string]string{hstsMaxAgeAnnotation: "300"}, nil, true},
+		{"excluded hosts with a minimum", 31536000, map[string]string{hstsExcludeHostsAnnotation: "dev.example.com"}, nil, true},
+		{"invalid max-age", 0, map[string]string{hstsMaxAgeAnnotation: "-1"}, nil, true},
+		{"invalid excluded hosts", 0, map[string]string{hstsExcludeHostsAnnotation: "$host"}, nil, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			i, err := NewParser(mockBackend{minMaxAge: tc.minMaxAge}).Parse(buildIngress(tc.annotations))
+			if tc.expectErr {
+				if err == nil {
+					t.Errorf("expected an error but returned %v", i)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			config, ok := i.(*Config)
+			if !ok {
+				t.Fatalf("expected a Config type but returned %T", i)
+			}
+			if !config.Equal(tc.expected) {
+				t.Errorf("expected %+v but returned %+v", tc.expected, config)
+			}
+		})
+	}
+}
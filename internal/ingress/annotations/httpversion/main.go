@@ -0,0 +1,136 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpversion
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	enableHTTP2Annotation  = "enable-http2"
+	httpVersionsAnnotation = "http-versions"
+)
+
+// Should cover something like "1.1,2.0"
+var regexValidHTTPVersions = regexp.MustCompile(`^(?:1\.0|1\.1|2\.0|3\.0)(?:,(?:1\.0|1\.1|2\.0|3\.0))*$`)
+
+var httpVersionAnnotations = parser.Annotation{
+	Group: "protocol",
+	Annotations: parser.AnnotationFields{
+		enableHTTP2Annotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the http2 directive at the server level, enabling or disabling HTTP/2 for the hosts of the Ingress
+			regardless of the use-http2 ConfigMap key.`,
+		},
+		httpVersionsAnnotation: {
+			Validator:     parser.ValidateRegex(regexValidHTTPVersions, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the comma separated HTTP versions of the clients allowed in the location, like "1.1,2.0". The requests of other versions are rejected with the status code 505.`,
+		},
+	},
+}
+
+// Config contains the protocol versions of the clients allowed by an Ingress
+type Config struct {
+	// HTTP2 is the value of the http2 directive of the server, "on", "off"
+	// or empty to use the configuration
+	HTTP2 string `json:"http2,omitempty"`
+	// Versions are the sorted HTTP versions of the clients allowed in the
+	// location, or empty to allow all the versions
+	Versions []string `json:"versions,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.HTTP2 != c2.HTTP2 {
+		return false
+	}
+
+	return slices.Equal(c1.Versions, c2.Versions)
+}
+
+type httpVersion struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new HTTP version annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return httpVersion{
+		r:                r,
+		annotationConfig: httpVersionAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to limit the protocol versions of the clients
+func (a httpVersion) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	http2, err := parser.GetBoolAnnotation(enableHTTP2Annotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil && http2:
+		config.HTTP2 = "on"
+	case err == nil:
+		config.HTTP2 = "off"
+	case ing_errors.IsValidationError(err):
+		return config, err
+	}
+
+	versions, err := parser.GetStringAnnotation(httpVersionsAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return config, err
+		}
+		return config, nil
+	}
+
+	for _, version := range strings.Split(strings.ReplaceAll(versions, " ", ""), ",") {
+		if !slices.Contains(config.Versions, version) {
+			config.Versions = append(config.Versions, version)
+		}
+	}
+	slices.Sort(config.Versions)
+
+	return config, nil
+}
+
+func (a httpVersion) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a httpVersion) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, httpVersionAnnotations.Annotations)
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package httpversion
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	http2 := parser.GetAnnotationWithPrefix(enableHTTP2Annotation)
+	versions := parser.GetAnnotationWithPrefix(httpVersionsAnnotation)
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expErr      bool
+	}{
+		{map[string]string{http2: "true"}, &Config{HTTP2: "on"}, false},
+		{map[string]string{http2: "false"}, &Config{HTTP2: "off"}, false},
+		{map[string]string{http2: "maybe"}, &Config{}, true},
+		{map[string]string{versions: "2.0, 1.1,2.0"}, &Config{Versions: []string{"1.1", "2.0"}}, false},
+		{map[string]string{http2: "false", versions: "1.0,1.1"}, &Config{HTTP2: "off", Versions: []string{"1.0", "1.1"}}, false},
+		{map[string]string{versions: "HTTP/2.0"}, &Config{}, true},
+		{map[string]string{versions: "1.1,"}, &Config{}, true},
+		{map[string]string{}, &Config{}, false},
+		{nil, &Config{}, false},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expErr {
+			t.Errorf("expected error %v but %v returned for annotations %v", testCase.expErr, err, testCase.annotations)
+		}
+		if config, ok := result.(*Config); !ok || !config.Equal(testCase.expected) {
+			t.Errorf("expected %v but %v returned for annotations %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
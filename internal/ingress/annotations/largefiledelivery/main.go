@@ -0,0 +1,200 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package largefiledelivery
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	largeFileDeliveryAnnotation          = "large-file-delivery"
+	largeFileDeliverySliceSizeAnnotation = "large-file-delivery-slice-size"
+
+	// proxyCacheValidAnnotation enables the cache of the responses, keyed
+	// without the byte ranges of the slices
+	proxyCacheValidAnnotation = "proxy-cache-valid"
+)
+
+// the settings of the preset
+const (
+	defaultSliceSize = "1m"
+	outputBuffers    = "2 1m"
+	sendTimeout      = 300
+	cacheValid       = "1h"
+
+	minSliceSize = 256 * 1024
+	maxSliceSize = 64 * 1024 * 1024
+)
+
+var largeFileDeliveryAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		largeFileDeliveryAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation tunes the location for the delivery of large files: the responses are sent with sendfile, and fetched from the backend
+			and cached by byte ranges with the slice module. Cannot be used with the annotation proxy-cache-valid.`,
+		},
+		largeFileDeliverySliceSizeAnnotation: {
+			Validator:     parser.ValidateRegex(parser.SizeRegex, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the size of the byte ranges fetched from the backend and cached by the large-file-delivery preset, between 256k and 64m. Defaults to 1m.`,
+		},
+	},
+}
+
+// Config contains the settings of the delivery of large files
+type Config struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// SliceSize is the size of the byte ranges fetched from the backend
+	// and cached
+	SliceSize string `json:"sliceSize,omitempty"`
+	// OutputBuffers are the number and size of the buffers reading the
+	// responses from the disk
+	OutputBuffers string `json:"outputBuffers,omitempty"`
+	// SendTimeout is the timeout in seconds between two writes to the client
+	SendTimeout int `json:"sendTimeout,omitempty"`
+	// CacheValid is the caching time of the byte ranges
+	CacheValid string `json:"cacheValid,omitempty"`
+}
+
+type largeFileDelivery struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new large file delivery annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return largeFileDelivery{
+		r:                r,
+		annotationConfig: largeFileDeliveryAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to tune the delivery of large files
+func (a largeFileDelivery) Parse(ing *networking.Ingress) (interface{}, error) {
+	enabled, err := parser.GetBoolAnnotation(largeFileDeliveryAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+	if !enabled {
+		return &Config{}, nil
+	}
+
+	if _, ok := ing.GetAnnotations()[parser.GetAnnotationWithPrefix(proxyCacheValidAnnotation)]; ok {
+		return nil, errors.NewLocationDenied(fmt.Sprintf("annotation %v cannot be used with the annotation %v", largeFileDeliveryAnnotation, proxyCacheValidAnnotation))
+	}
+
+	config := &Config{
+		Enabled:       true,
+		SliceSize:     defaultSliceSize,
+		OutputBuffers: outputBuffers,
+		SendTimeout:   sendTimeout,
+		CacheValid:    cacheValid,
+	}
+
+	sliceSize, err := parser.GetStringAnnotation(largeFileDeliverySliceSizeAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.SliceSize = sliceSize
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// validate checks the settings of the preset understood by NGINX
+func (c *Config) validate() error {
+	size, err := parseSize(c.SliceSize)
+	if err != nil || size < minSliceSize || size > maxSliceSize {
+		return errors.NewInvalidAnnotationContent(largeFileDeliverySliceSizeAnnotation, c.SliceSize)
+	}
+
+	buffers := strings.Fields(c.OutputBuffers)
+	if len(buffers) != 2 {
+		return fmt.Errorf("invalid output buffers %q", c.OutputBuffers)
+	}
+	if _, err := strconv.Atoi(buffers[0]); err != nil {
+		return fmt.Errorf("invalid number of output buffers %q", c.OutputBuffers)
+	}
+	if _, err := parseSize(buffers[1]); err != nil {
+		return fmt.Errorf("invalid size of output buffers %q", c.OutputBuffers)
+	}
+
+	if c.SendTimeout < 1 {
+		return fmt.Errorf("invalid send timeout %v", c.SendTimeout)
+	}
+
+	return nil
+}
+
+// parseSize returns the number of bytes of a size understood by NGINX,
+// like 512k or 1m
+func parseSize(s string) (int, error) {
+	if !parser.SizeRegex.MatchString(s) {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	unit := 1
+	switch strings.ToLower(s[len(s)-1:]) {
+	case "b":
+		s = s[:len(s)-1]
+	case "k":
+		unit = 1024
+		s = s[:len(s)-1]
+	case "m":
+		unit = 1024 * 1024
+		s = s[:len(s)-1]
+	case "g":
+		unit = 1024 * 1024 * 1024
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	return n * unit, nil
+}
+
+func (a largeFileDelivery) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a largeFileDelivery) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, largeFileDeliveryAnnotations.Annotations)
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package largefiledelivery
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	enabledAnnotation := parser.GetAnnotationWithPrefix(largeFileDeliveryAnnotation)
+	sliceSizeAnnotation := parser.GetAnnotationWithPrefix(largeFileDeliverySliceSizeAnnotation)
+	cacheValidAnnotation := parser.GetAnnotationWithPrefix(proxyCacheValidAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	preset := func(sliceSize string) *Config {
+		return &Config{
+			Enabled:       true,
+			SliceSize:     sliceSize,
+			OutputBuffers: "2 1m",
+			SendTimeout:   300,
+			CacheValid:    "1h",
+		}
+	}
+
+	testCases := []struct {
+		annotations  map[string]string
+		expected     *Config
+		expectErr    bool
+		expectDenied bool
+	}{
+		{nil, &Config{}, false, false},
+		{map[string]string{enabledAnnotation: "false", sliceSizeAnnotation: "4m"}, &Config{}, false, false},
+		{map[string]string{enabledAnnotation: "true"}, preset("1m"), false, false},
+		{map[string]string{enabledAnnotation: "true", sliceSizeAnnotation: "4m"}, preset("4m"), false, false},
+		{map[string]string{enabledAnnotation: "true", sliceSizeAnnotation: "512K"}, preset("512K"), false, false},
+		{map[string]string{enabledAnnotation: "true", sliceSizeAnnotation: "64k"}, nil, true, false},
+		{map[string]string{enabledAnnotation: "true", sliceSizeAnnotation: "1g"}, nil, true, false},
+		{map[string]string{enabledAnnotation: "true", sliceSizeAnnotation: "1 m"}, nil, true, false},
+		{map[string]string{enabledAnnotation: "yes"}, nil, true, false},
+		{map[string]string{enabledAnnotation: "true", cacheValidAnnotation: "200 10m"}, nil, true, true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Fatalf("expected error: %t got error: %t err value: %s. %+v", testCase.expectErr, err != nil, err, testCase.annotations)
+		}
+		if testCase.expectDenied && !errors.IsLocationDenied(err) {
+			t.Errorf("expected a location denied error but returned %v, annotations: %s", err, testCase.annotations)
+		}
+		if !testCase.expectErr && !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %+v but returned %+v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	testCases := map[string]int{
+		"1024": 1024,
+		"10b":  10,
+		"256k": 256 * 1024,
+		"2M":   2 * 1024 * 1024,
+		"1g":   1024 * 1024 * 1024,
+	}
+
+	for size, expected := range testCases {
+		n, err := parseSize(size)
+		if err != nil {
+			t.Errorf("unexpected error parsing %v: %v", size, err)
+		}
+		if n != expected {
+			t.Errorf("expected %v bytes for %v but returned %v", expected, size, n)
+		}
+	}
+
+	if _, err := parseSize("1t"); err == nil {
+		t.Errorf("expected an error parsing an invalid size")
+	}
+}
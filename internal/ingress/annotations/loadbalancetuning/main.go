@@ -0,0 +1,111 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package loadbalancetuning
+
+import (
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	p2cChoicesAnnotation        = "load-balance-p2c-choices"
+	leastLatencyDecayAnnotation = "load-balance-least-latency-decay"
+)
+
+var loadBalanceTuningAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		p2cChoicesAnnotation: {
+			Validator: parser.ValidateInt,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the number of endpoints picked at random and compared by the p2c load balancing algorithm.
+			The endpoint with the fewest requests in progress is selected. Defaults to 2.`,
+		},
+		leastLatencyDecayAnnotation: {
+			Validator: parser.ValidateInt,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the decay time, in seconds, of the average latency of the endpoints measured by the least_latency load balancing algorithm.
+			Defaults to 10.`,
+		},
+	},
+}
+
+// Config contains the settings of the load balancing algorithms
+type Config struct {
+	// P2CChoices is the number of endpoints compared by the p2c algorithm
+	P2CChoices int `json:"p2c-choices"`
+	// LeastLatencyDecay is the decay time in seconds of the latency measured
+	// by the least_latency algorithm
+	LeastLatencyDecay int `json:"least-latency-decay"`
+}
+
+type loadBalanceTuning struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new load balancing tuning annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return loadBalanceTuning{
+		r:                r,
+		annotationConfig: loadBalanceTuningAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to tune the load balancing algorithms
+func (a loadBalanceTuning) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	choices, err := parser.GetIntAnnotation(p2cChoicesAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		if choices < 2 {
+			return nil, errors.NewInvalidAnnotationContent(p2cChoicesAnnotation, choices)
+		}
+		config.P2CChoices = choices
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	decay, err := parser.GetIntAnnotation(leastLatencyDecayAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		if decay < 1 {
+			return nil, errors.NewInvalidAnnotationContent(leastLatencyDecayAnnotation, decay)
+		}
+		config.LeastLatencyDecay = decay
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (a loadBalanceTuning) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a loadBalanceTuning) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, loadBalanceTuningAnnotations.Annotations)
+}
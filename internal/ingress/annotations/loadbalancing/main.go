@@ -29,7 +29,7 @@ const (
 	loadBalanceAlgorithmAnnotation = "load-balance"
 )
 
-var loadBalanceAlgorithms = []string{"round_robin", "chash", "chashsubset", "sticky_balanced", "sticky_persistent", "ewma"}
+var loadBalanceAlgorithms = []string{"round_robin", "chash", "chashsubset", "sticky_balanced", "sticky_persistent", "ewma", "p2c", "least_latency"}
 
 var loadBalanceAnnotations = parser.Annotation{
 	Group: "backend",
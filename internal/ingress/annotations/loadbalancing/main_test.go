@@ -39,6 +39,8 @@ func TestParse(t *testing.T) {
 		expected    string
 	}{
 		{map[string]string{annotation: "ewma"}, "ewma"},
+		{map[string]string{annotation: "p2c"}, "p2c"},
+		{map[string]string{annotation: "least_latency"}, "least_latency"},
 		{map[string]string{annotation: "ip_hash"}, ""}, // This is invalid and should not return anything
 		{map[string]string{}, ""},
 		{nil, ""},
@@ -0,0 +1,182 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package luakv
+
+import (
+	"crypto/sha1" // #nosec
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const luaKVConfigMapAnnotation = "lua-kv-configmap"
+
+// MaxDataSize is the maximum size in bytes of the keys and values of the
+// ConfigMap of an Ingress
+const MaxDataSize = 64 * 1024
+
+// the keys of a ConfigMap are validated by the API server, the values cannot
+// contain control characters to keep the logs of the Lua code readable
+var validValue = regexp.MustCompile(`^[^\x00-\x08\x0b-\x1f\x7f]*$`)
+
+var luaKVAnnotations = parser.Annotation{
+	Group: "lua",
+	Annotations: parser.AnnotationFields{
+		luaKVConfigMapAnnotation: {
+			Validator: parser.ValidateRegex(parser.BasicCharsRegex, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium,
+			Documentation: `This annotation sets the name of a ConfigMap whose data is readable by the Lua code of the locations of the Ingress
+			with the kv module. Only ConfigMaps on the same namespace of the Ingress can be used.`,
+		},
+	},
+}
+
+// Config contains the key/value store of the Lua code of an Ingress
+type Config struct {
+	// ConfigMap is the namespace and name of the ConfigMap of the store
+	ConfigMap string `json:"configMap,omitempty"`
+	// DataSHA is the hash of the data, updated dynamically
+	DataSHA string `json:"dataSha,omitempty"`
+	// Data contains the keys and values of the store
+	Data map[string]string `json:"-"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.ConfigMap != c2.ConfigMap {
+		return false
+	}
+
+	return c1.DataSHA == c2.DataSHA
+}
+
+type luaKV struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new Lua key/value store annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return luaKV{
+		r:                r,
+		annotationConfig: luaKVAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to read the key/value store of the Lua code from a ConfigMap
+func (a luaKV) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	cm, err := parser.GetStringAnnotation(luaKVConfigMapAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return config, err
+		}
+		return config, nil
+	}
+
+	cmns, cmn, err := cache.SplitMetaNamespaceKey(cm)
+	if err != nil {
+		return config, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("error reading configmap name from annotation: %w", err),
+		}
+	}
+
+	// We don't accept different namespaces for the store.
+	if cmns != "" && !a.r.GetSecurityConfiguration().AllowCrossNamespaceResources && cmns != ing.Namespace {
+		return config, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("different namespace is not supported on the lua kv configmap"),
+		}
+	}
+	if cmns == "" {
+		cmns = ing.Namespace
+	}
+
+	cm = fmt.Sprintf("%v/%v", cmns, cmn)
+	cmap, err := a.r.GetConfigMap(cm)
+	if err != nil {
+		return config, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("unexpected error reading configmap %s: %w", cm, err),
+		}
+	}
+
+	size := 0
+	for k, v := range cmap.Data {
+		if !validValue.MatchString(v) {
+			return config, ing_errors.LocationDeniedError{
+				Reason: fmt.Errorf("the value of the key %q of the configmap %s contains control characters", k, cm),
+			}
+		}
+		size += len(k) + len(v)
+	}
+
+	if size > MaxDataSize {
+		return config, ing_errors.LocationDeniedError{
+			Reason: fmt.Errorf("the data of the configmap %s is larger than %v bytes", cm, MaxDataSize),
+		}
+	}
+
+	config.ConfigMap = cm
+	config.Data = cmap.Data
+	if config.Data == nil {
+		config.Data = map[string]string{}
+	}
+	config.DataSHA = dataSHA(config.Data)
+
+	return config, nil
+}
+
+// dataSHA returns the hash of the keys and values of a store
+func dataSHA(data map[string]string) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	hasher := sha1.New() // #nosec
+	for _, key := range keys {
+		fmt.Fprintf(hasher, "%q:%q\n", key, data[key])
+	}
+
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func (a luaKV) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a luaKV) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, luaKVAnnotations.Annotations)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package luakv
+
+import (
+	"strings"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func configMap(namespace, name string, data map[string]string) *api.ConfigMap {
+	return &api.ConfigMap{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+		Data: data,
+	}
+}
+
+func TestParse(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix(luaKVConfigMapAnnotation)
+	data := map[string]string{"limit": "10", "message": "hello\nworld"}
+
+	r := &resolver.Mock{
+		ConfigMaps: map[string]*api.ConfigMap{
+			"default/kv":      configMap("default", "kv", data),
+			"default/empty":   configMap("default", "empty", nil),
+			"default/invalid": configMap("default", "invalid", map[string]string{"key": "a\x1bb"}),
+			"default/large":   configMap("default", "large", map[string]string{"key": strings.Repeat("x", MaxDataSize)}),
+			"other/kv":        configMap("other", "kv", data),
+		},
+	}
+	ap := NewParser(r)
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expErr      bool
+	}{
+		{map[string]string{annotation: "kv"}, &Config{ConfigMap: "default/kv", DataSHA: dataSHA(data), Data: data}, false},
+		{map[string]string{annotation: "default/kv"}, &Config{ConfigMap: "default/kv", DataSHA: dataSHA(data), Data: data}, false},
+		{map[string]string{annotation: "empty"}, &Config{ConfigMap: "default/empty", DataSHA: dataSHA(nil), Data: map[string]string{}}, false},
+		{map[string]string{annotation: "other/kv"}, &Config{}, true},
+		{map[string]string{annotation: "missing"}, &Config{}, true},
+		{map[string]string{annotation: "invalid"}, &Config{}, true},
+		{map[string]string{annotation: "large"}, &Config{}, true},
+		{map[string]string{}, &Config{}, false},
+		{nil, &Config{}, false},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expErr {
+			t.Errorf("expected error %v but %v returned for annotations %v", testCase.expErr, err, testCase.annotations)
+		}
+		config, ok := result.(*Config)
+		if !ok || !config.Equal(testCase.expected) || len(config.Data) != len(testCase.expected.Data) {
+			t.Errorf("expected %v but %v returned for annotations %v", testCase.expected, result, testCase.annotations)
+		}
+	}
+
+	r.AllowCrossNamespace = true
+	ing.SetAnnotations(map[string]string{annotation: "other/kv"})
+	result, err := NewParser(r).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error reading the configmap of another namespace: %v", err)
+	}
+	if config := result.(*Config); config.ConfigMap != "other/kv" {
+		t.Errorf("expected the configmap other/kv but %v returned", config.ConfigMap)
+	}
+}
+
+func TestDataSHA(t *testing.T) {
+	if dataSHA(map[string]string{"a": "b:c"}) == dataSHA(map[string]string{"a:b": "c"}) {
+		t.Errorf("expected different hashes for different data")
+	}
+	if dataSHA(nil) != dataSHA(map[string]string{}) {
+		t.Errorf("expected the same hash for empty data")
+	}
+}
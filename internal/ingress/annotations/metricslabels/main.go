@@ -0,0 +1,107 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metricslabels
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	metricsLabelsAnnotation = "metrics-labels"
+)
+
+var (
+	// labelsRegex validates a list of labels like "team=payments,tier=gold"
+	labelsRegex = regexp.MustCompile(`^[a-zA-Z0-9_.\-=, ]*$`)
+	nameRegex   = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+	valueRegex  = regexp.MustCompile(`^[a-zA-Z0-9_.\-]{0,63}$`)
+)
+
+var metricsLabelsAnnotations = parser.Annotation{
+	Group: "metrics",
+	Annotations: parser.AnnotationFields{
+		metricsLabelsAnnotation: {
+			Validator: parser.ValidateRegex(labelsRegex, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation adds static labels to the request metrics of the Ingress, like "team=payments,tier=gold".
+			Only the labels defined using the flag --metrics-labels are added`,
+		},
+	},
+}
+
+type metricsLabels struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new metrics labels annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return metricsLabels{
+		r:                r,
+		annotationConfig: metricsLabelsAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress to add labels to the request metrics
+func (a metricsLabels) Parse(ing *networking.Ingress) (interface{}, error) {
+	value, err := parser.GetStringAnnotation(metricsLabelsAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := map[string]string{}
+	for _, label := range strings.Split(value, ",") {
+		label = strings.TrimSpace(label)
+		if label == "" {
+			continue
+		}
+
+		name, val, found := strings.Cut(label, "=")
+		name = strings.TrimSpace(name)
+		val = strings.TrimSpace(val)
+		if !found || !nameRegex.MatchString(name) || strings.HasPrefix(name, "__") {
+			return nil, ing_errors.NewInvalidAnnotationContent(metricsLabelsAnnotation, value)
+		}
+
+		if !valueRegex.MatchString(val) {
+			return nil, ing_errors.NewInvalidAnnotationConfiguration(metricsLabelsAnnotation,
+				fmt.Sprintf("invalid value of label %v", name))
+		}
+
+		labels[name] = val
+	}
+
+	return labels, nil
+}
+
+func (a metricsLabels) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a metricsLabels) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, metricsLabelsAnnotations.Annotations)
+}
@@ -237,17 +237,29 @@ func normalizeString(input string) string {
 var configmapAnnotations = sets.NewString(
 	"auth-proxy-set-header",
 	"fastcgi-params-configmap",
+	"lua-kv-configmap",
 )
 
 // AnnotationsReferencesConfigmap checks if at least one annotation in the Ingress rule
-// references a configmap.
-func AnnotationsReferencesConfigmap(ing *networking.Ingress) bool {
+// references the configmap with the key namespace/name. The configmaps referenced
+// without a namespace are in the namespace of the Ingress.
+func AnnotationsReferencesConfigmap(ing *networking.Ingress, key string) bool {
 	if ing == nil || len(ing.GetAnnotations()) == 0 {
 		return false
 	}
 
-	for name := range ing.GetAnnotations() {
-		if configmapAnnotations.Has(name) {
+	annotations := ing.GetAnnotations()
+	for name := range configmapAnnotations {
+		cm, ok := annotations[GetAnnotationWithPrefix(name)]
+		if !ok {
+			continue
+		}
+
+		if !strings.Contains(cm, "/") {
+			cm = fmt.Sprintf("%v/%v", ing.Namespace, cm)
+		}
+
+		if cm == key {
 			return true
 		}
 	}
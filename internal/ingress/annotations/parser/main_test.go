@@ -252,3 +252,30 @@ func TestStringToURL(t *testing.T) {
 		}
 	}
 }
+
+func TestAnnotationsReferencesConfigmap(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		key         string
+		exp         bool
+	}{
+		{"no annotations", nil, "default/flags", false},
+		{"other annotation", map[string]string{GetAnnotationWithPrefix("rewrite-target"): "/"}, "default/flags", false},
+		{"fastcgi params", map[string]string{GetAnnotationWithPrefix("fastcgi-params-configmap"): "params"}, "default/params", true},
+		{"lua key/value store", map[string]string{GetAnnotationWithPrefix("lua-kv-configmap"): "flags"}, "default/flags", true},
+		{"lua key/value store in another namespace", map[string]string{GetAnnotationWithPrefix("lua-kv-configmap"): "other/flags"}, "other/flags", true},
+		{"other configmap", map[string]string{GetAnnotationWithPrefix("lua-kv-configmap"): "flags"}, "default/params", false},
+		{"same name in another namespace", map[string]string{GetAnnotationWithPrefix("lua-kv-configmap"): "flags"}, "other/flags", false},
+		{"unprefixed annotation", map[string]string{"lua-kv-configmap": "flags"}, "default/flags", false},
+	}
+
+	for _, test := range tests {
+		ing := buildIngress()
+		ing.SetAnnotations(test.annotations)
+
+		if r := AnnotationsReferencesConfigmap(ing, test.key); r != test.exp {
+			t.Errorf("%v: expected %v but %v was returned", test.name, test.exp, r)
+		}
+	}
+}
@@ -27,6 +27,7 @@ import (
 	networking "k8s.io/api/networking/v1"
 	machineryvalidation "k8s.io/apimachinery/pkg/api/validation"
 	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/expression"
 	"k8s.io/ingress-nginx/internal/net"
 	"k8s.io/klog/v2"
 )
@@ -168,6 +169,13 @@ func ValidateCIDRs(value string) error {
 	return err
 }
 
+// ValidateExpression validates if the specified value is a valid expression
+// evaluated per request
+func ValidateExpression(value string) error {
+	_, err := expression.Compile(value)
+	return err
+}
+
 // ValidateDuration validates if the specified value is a valid time
 func ValidateDuration(value string) error {
 	_, err := time.ParseDuration(value)
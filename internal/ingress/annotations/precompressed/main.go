@@ -0,0 +1,114 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package precompressed
+
+import (
+	"regexp"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	precompressedResponsesAnnotation = "precompressed-responses"
+
+	gzipEncoding   = "gzip"
+	brotliEncoding = "br"
+)
+
+var validEncodings = regexp.MustCompile(`^(gzip|br)(\s*,\s*(gzip|br))*$`)
+
+var precompressedAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		precompressedResponsesAnnotation: {
+			Validator: parser.ValidateRegex(validEncodings, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines a comma separated list of the encodings, "gzip" and "br", of the precompressed responses served by the location.
+			With "gzip" the gzip responses of the upstream servers are decompressed for the clients not supporting gzip, and the ".gz" files are served.
+			With "br" the ".br" files are served, when the Brotli module is available.`,
+		},
+	},
+}
+
+// Config contains the encodings of the precompressed responses of a location
+type Config struct {
+	// Gzip enables the gzip precompressed responses
+	Gzip bool `json:"gzip"`
+	// Brotli enables the Brotli precompressed responses
+	Brotli bool `json:"brotli"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+
+	return *c1 == *c2
+}
+
+type precompressed struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new precompressed responses annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return precompressed{
+		r:                r,
+		annotationConfig: precompressedAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to serve precompressed responses in the location
+func (a precompressed) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	encodings, err := parser.GetStringAnnotation(precompressedResponsesAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		return config, err
+	}
+
+	for _, encoding := range strings.Split(encodings, ",") {
+		switch strings.TrimSpace(encoding) {
+		case gzipEncoding:
+			config.Gzip = true
+		case brotliEncoding:
+			config.Brotli = true
+		}
+	}
+
+	return config, nil
+}
+
+func (a precompressed) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a precompressed) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, precompressedAnnotations.Annotations)
+}
@@ -18,8 +18,12 @@ package proxy
 
 import (
 	"regexp"
+	"strings"
+	"sync"
 
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
@@ -43,10 +47,17 @@ const (
 	proxyBufferingAnnotation           = "proxy-buffering"
 	proxyHTTPVersionAnnotation         = "proxy-http-version"
 	proxyMaxTempFileSizeAnnotation     = "proxy-max-temp-file-size" //#nosec G101
+	proxyTimeoutBudgetHeaderAnnotation = "proxy-timeout-budget-header"
 )
 
+// nonIdempotent is the case of proxy-next-upstream retrying the non-idempotent requests
+const nonIdempotent = "non_idempotent"
+
 var validUpstreamAnnotation = regexp.MustCompile(`^((error|timeout|invalid_header|http_500|http_502|http_503|http_504|http_403|http_404|http_429|non_idempotent|off)\s?)+$`)
 
+// validHeaderName validates the name of the header containing the timeout budget of a request
+var validHeaderName = regexp.MustCompile(`^[a-zA-Z0-9-]+$`)
+
 var proxyAnnotations = parser.Annotation{
 	Group: "backend",
 	Annotations: parser.AnnotationFields{
@@ -106,7 +117,8 @@ var proxyAnnotations = parser.Annotation{
 			Risk:      parser.AnnotationRiskMedium,
 			Documentation: `This annotation defines when the next upstream should be used. 
 			This annotation reflect the directive https://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_next_upstream 
-			and only the allowed values on upstream are allowed here.`,
+			and only the allowed values on upstream are allowed here.
+			The non-idempotent requests (POST, LOCK, PATCH) are only retried when it contains non_idempotent, regardless of retry-non-idempotent.`,
 		},
 		proxyNextUpstreamTimeoutAnnotation: {
 			Validator:     parser.ValidateInt,
@@ -156,6 +168,13 @@ var proxyAnnotations = parser.Annotation{
 			Risk:          parser.AnnotationRiskLow,
 			Documentation: `This annotation defines the maximum size of a temporary file when buffering responses.`,
 		},
+		proxyTimeoutBudgetHeaderAnnotation: {
+			Validator: parser.ValidateRegex(validHeaderName, false),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the request header containing the time the client is willing to wait for the response, like "grpc-timeout".
+			The read timeout to the backend is reduced to the remaining budget of the request, capped by proxy-read-timeout.`,
+		},
 	},
 }
 
@@ -170,6 +189,7 @@ type Config struct {
 	CookieDomain         string `json:"cookieDomain"`
 	CookiePath           string `json:"cookiePath"`
 	NextUpstream         string `json:"nextUpstream"`
+	RetryNonIdempotent   bool   `json:"retryNonIdempotent"`
 	NextUpstreamTimeout  int    `json:"nextUpstreamTimeout"`
 	NextUpstreamTries    int    `json:"nextUpstreamTries"`
 	ProxyRedirectFrom    string `json:"proxyRedirectFrom"`
@@ -178,6 +198,7 @@ type Config struct {
 	ProxyBuffering       string `json:"proxyBuffering"`
 	ProxyHTTPVersion     string `json:"proxyHTTPVersion"`
 	ProxyMaxTempFileSize string `json:"proxyMaxTempFileSize"`
+	TimeoutBudgetHeader  string `json:"timeoutBudgetHeader"`
 }
 
 // Equal tests for equality between two Configuration types
@@ -215,6 +236,9 @@ func (l1 *Config) Equal(l2 *Config) bool {
 	if l1.NextUpstream != l2.NextUpstream {
 		return false
 	}
+	if l1.RetryNonIdempotent != l2.RetryNonIdempotent {
+		return false
+	}
 	if l1.NextUpstreamTimeout != l2.NextUpstreamTimeout {
 		return false
 	}
@@ -241,6 +265,10 @@ func (l1 *Config) Equal(l2 *Config) bool {
 		return false
 	}
 
+	if l1.TimeoutBudgetHeader != l2.TimeoutBudgetHeader {
+		return false
+	}
+
 	return true
 }
 
@@ -308,6 +336,12 @@ func (a proxy) Parse(ing *networking.Ingress) (interface{}, error) {
 	config.NextUpstream, err = parser.GetStringAnnotation(proxyNextUpstreamAnnotation, ing, a.annotationConfig.Annotations)
 	if err != nil {
 		config.NextUpstream = defBackend.ProxyNextUpstream
+		config.RetryNonIdempotent = defBackend.RetryNonIdempotent
+		warnNextUpstream(ing, "")
+	} else {
+		// the non-idempotent requests are only retried when the annotation says so,
+		// regardless of retry-non-idempotent
+		warnNextUpstream(ing, config.NextUpstream)
 	}
 
 	config.NextUpstreamTimeout, err = parser.GetIntAnnotation(proxyNextUpstreamTimeoutAnnotation, ing, a.annotationConfig.Annotations)
@@ -350,9 +384,69 @@ func (a proxy) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.ProxyMaxTempFileSize = defBackend.ProxyMaxTempFileSize
 	}
 
+	config.TimeoutBudgetHeader, err = parser.GetStringAnnotation(proxyTimeoutBudgetHeaderAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		config.TimeoutBudgetHeader = defBackend.ProxyTimeoutBudgetHeader
+	}
+
 	return config, nil
 }
 
+// nextUpstreamWarnings contains the proxy-next-upstream value of each Ingress
+// already warned about. The annotations are parsed in every synchronization
+// and admission review, the warnings are only logged when the value changes.
+type nextUpstreamWarnings struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+var warnedNextUpstream = &nextUpstreamWarnings{values: map[string]string{}}
+
+// changed records the value of an Ingress with a warning, or forgets the
+// Ingress when value is empty, and checks if the value was not warned about
+func (w *nextUpstreamWarnings) changed(key, value string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if value == "" {
+		delete(w.values, key)
+		return false
+	}
+
+	if w.values[key] == value {
+		return false
+	}
+
+	w.values[key] = value
+	return true
+}
+
+// warnNextUpstream logs the proxy-next-upstream values that are probably mistakes:
+// off combined with other cases, and the retries of the non-idempotent requests
+// after a timeout, which may have been processed by the backend already
+func warnNextUpstream(ing *networking.Ingress, nextUpstream string) {
+	var warning string
+	cases := sets.New(strings.Fields(nextUpstream)...)
+	switch {
+	case cases.Has("off") && cases.Len() > 1:
+		warning = "combines off with other cases, the requests are never passed to the next server"
+	case cases.Has(nonIdempotent) && cases.Has("timeout"):
+		warning = "retries the non-idempotent requests (POST, LOCK, PATCH) after a timeout, the backend may process them more than once"
+	}
+
+	key := ing.Namespace + "/" + ing.Name
+	if warning == "" {
+		warnedNextUpstream.changed(key, "")
+		return
+	}
+
+	if !warnedNextUpstream.changed(key, nextUpstream) {
+		return
+	}
+
+	klog.Warningf("ingress %s: %s %q %s", key, proxyNextUpstreamAnnotation, nextUpstream, warning)
+}
+
 func (a proxy) GetDocumentation() parser.AnnotationFields {
 	return a.annotationConfig.Annotations
 }
@@ -286,3 +286,105 @@ func TestProxyWithNoAnnotation(t *testing.T) {
 		t.Errorf("expected 1024m as proxy-max-temp-file-size but returned %v", p.ProxyMaxTempFileSize)
 	}
 }
+
+func TestProxyTimeoutBudgetHeader(t *testing.T) {
+	ing := buildIngress()
+
+	testCases := []struct {
+		value    string
+		expected string
+	}{
+		{"grpc-timeout", "grpc-timeout"},
+		{"X-Request-Timeout", "X-Request-Timeout"},
+		{"X-Request Timeout", ""},
+		{"", ""},
+	}
+
+	for _, tc := range testCases {
+		data := map[string]string{}
+		if tc.value != "" {
+			data[parser.GetAnnotationWithPrefix("proxy-timeout-budget-header")] = tc.value
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Fatalf("unexpected error parsing a valid")
+		}
+		p, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("expected a Config type")
+		}
+		if p.TimeoutBudgetHeader != tc.expected {
+			t.Errorf("expected %q as proxy-timeout-budget-header but returned %q", tc.expected, p.TimeoutBudgetHeader)
+		}
+	}
+}
+
+type mockRetryNonIdempotentBackend struct {
+	mockBackend
+}
+
+func (m mockRetryNonIdempotentBackend) GetDefaultBackend() defaults.Backend {
+	def := m.mockBackend.GetDefaultBackend()
+	def.RetryNonIdempotent = true
+	return def
+}
+
+func TestProxyRetryNonIdempotent(t *testing.T) {
+	ing := buildIngress()
+
+	testCases := []struct {
+		name         string
+		nextUpstream string
+		expected     bool
+	}{
+		{"without annotation", "", true},
+		{"annotation without non_idempotent", "error timeout", false},
+		{"annotation with non_idempotent", "error non_idempotent", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := map[string]string{}
+			if tc.nextUpstream != "" {
+				data[parser.GetAnnotationWithPrefix("proxy-next-upstream")] = tc.nextUpstream
+			}
+			ing.SetAnnotations(data)
+
+			i, err := NewParser(mockRetryNonIdempotentBackend{}).Parse(ing)
+			if err != nil {
+				t.Fatalf("unexpected error parsing a valid")
+			}
+			p, ok := i.(*Config)
+			if !ok {
+				t.Fatalf("expected a Config type")
+			}
+			if p.RetryNonIdempotent != tc.expected {
+				t.Errorf("expected %v as retry-non-idempotent but returned %v", tc.expected, p.RetryNonIdempotent)
+			}
+		})
+	}
+}
+
+func TestNextUpstreamWarningsChanged(t *testing.T) {
+	warnings := &nextUpstreamWarnings{values: map[string]string{}}
+
+	if !warnings.changed("default/foo", "error timeout non_idempotent") {
+		t.Errorf("expected the first warning of the value to be logged")
+	}
+	if warnings.changed("default/foo", "error timeout non_idempotent") {
+		t.Errorf("expected the warning of the same value to be logged once")
+	}
+	if !warnings.changed("default/bar", "error timeout non_idempotent") {
+		t.Errorf("expected the warning of another Ingress to be logged")
+	}
+	if !warnings.changed("default/foo", "off error") {
+		t.Errorf("expected the warning of a changed value to be logged")
+	}
+
+	warnings.changed("default/foo", "")
+	if !warnings.changed("default/foo", "off error") {
+		t.Errorf("expected the warning to be logged again after the value was fixed")
+	}
+}
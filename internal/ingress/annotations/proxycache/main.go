@@ -0,0 +1,167 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	proxyCacheValidAnnotation       = "proxy-cache-valid"
+	proxyCacheLockAnnotation        = "proxy-cache-lock"
+	proxyCacheLockTimeoutAnnotation = "proxy-cache-lock-timeout"
+
+	// defaultLockTimeout is the default time in seconds the requests wait
+	// for the response of the request filling a cache entry
+	defaultLockTimeout = 5
+)
+
+var proxyCacheAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		proxyCacheValidAnnotation: {
+			Validator: parser.ValidateRegex(parser.ExtendedCharsRegex, false),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskMedium,
+			Documentation: `This annotation enables the cache of the responses of the location, and sets their caching time based on their response codes, e.g. 200 301 10m.
+			Multiple comma-separated values can be set: 200 10m, 404 1m.`,
+		},
+		proxyCacheLockAnnotation: {
+			Validator: parser.ValidateBool,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation coalesces the concurrent requests of a missing cache entry: only one request is sent to the backend,
+			the other requests waiting for its response to be cached. Requires the annotation proxy-cache-valid.`,
+		},
+		proxyCacheLockTimeoutAnnotation: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation sets the time in seconds the coalesced requests wait for the cache entry before being sent to the backend. Defaults to 5.`,
+		},
+	},
+}
+
+// Config contains the cache of the responses of a location
+type Config struct {
+	// Valid contains the caching times of the responses by status code.
+	// The responses are not cached when empty.
+	Valid []string `json:"valid,omitempty"`
+	// Lock coalesces the concurrent requests of a missing cache entry
+	Lock bool `json:"lock,omitempty"`
+	// LockTimeout is the time in seconds the coalesced requests wait for
+	// the cache entry
+	LockTimeout int `json:"lockTimeout,omitempty"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Lock != c2.Lock || c1.LockTimeout != c2.LockTimeout {
+		return false
+	}
+	return slices.Equal(c1.Valid, c2.Valid)
+}
+
+type proxyCache struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new response cache annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return proxyCache{
+		r:                r,
+		annotationConfig: proxyCacheAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to cache the responses of the backends
+func (a proxyCache) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	valid, err := parser.GetStringAnnotation(proxyCacheValidAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		for _, duration := range strings.Split(valid, ",") {
+			duration = strings.TrimSpace(duration)
+			if duration == "" {
+				continue
+			}
+			if !authreq.ValidCacheDuration(duration) {
+				return nil, errors.NewInvalidAnnotationContent(proxyCacheValidAnnotation, duration)
+			}
+			config.Valid = append(config.Valid, duration)
+		}
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	lock, err := parser.GetBoolAnnotation(proxyCacheLockAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		config.Lock = lock
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	if config.Lock && len(config.Valid) == 0 {
+		return nil, errors.NewLocationDenied(fmt.Sprintf("annotation %v requires the annotation %v", proxyCacheLockAnnotation, proxyCacheValidAnnotation))
+	}
+
+	if !config.Lock {
+		return config, nil
+	}
+
+	config.LockTimeout = defaultLockTimeout
+	timeout, err := parser.GetIntAnnotation(proxyCacheLockTimeoutAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		if timeout < 1 {
+			return nil, errors.NewInvalidAnnotationContent(proxyCacheLockTimeoutAnnotation, timeout)
+		}
+		config.LockTimeout = timeout
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func (a proxyCache) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a proxyCache) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, proxyCacheAnnotations.Annotations)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxycache
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	validAnnotation := parser.GetAnnotationWithPrefix(proxyCacheValidAnnotation)
+	lockAnnotation := parser.GetAnnotationWithPrefix(proxyCacheLockAnnotation)
+	lockTimeoutAnnotation := parser.GetAnnotationWithPrefix(proxyCacheLockTimeoutAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{}, false},
+		{map[string]string{validAnnotation: "200 301 10m, 404 1m"}, &Config{Valid: []string{"200 301 10m", "404 1m"}}, false},
+		{map[string]string{validAnnotation: "10m", lockAnnotation: "true"}, &Config{Valid: []string{"10m"}, Lock: true, LockTimeout: 5}, false},
+		{map[string]string{validAnnotation: "10m", lockAnnotation: "true", lockTimeoutAnnotation: "2"}, &Config{Valid: []string{"10m"}, Lock: true, LockTimeout: 2}, false},
+		{map[string]string{validAnnotation: "10m", lockAnnotation: "false", lockTimeoutAnnotation: "2"}, &Config{Valid: []string{"10m"}}, false},
+		{map[string]string{validAnnotation: "200"}, nil, true},
+		{map[string]string{validAnnotation: "10m 200"}, nil, true},
+		{map[string]string{lockAnnotation: "true"}, nil, true},
+		{map[string]string{validAnnotation: "10m", lockAnnotation: "true", lockTimeoutAnnotation: "0"}, nil, true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Fatalf("expected error: %t got error: %t err value: %s. %+v", testCase.expectErr, err != nil, err, testCase.annotations)
+		}
+		if !testCase.expectErr && !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %+v but returned %+v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
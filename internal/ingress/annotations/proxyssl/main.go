@@ -42,6 +42,8 @@ var (
 	proxySSLOnOffRegex    = regexp.MustCompile(`^(on|off)$`)
 	proxySSLProtocolRegex = regexp.MustCompile(`^(TLSv1\.2|TLSv1\.3| )*$`)
 	proxySSLCiphersRegex  = regexp.MustCompile(`^[A-Za-z0-9\+:\_\-!]*$`)
+	// proxySSLPinRegex matches a comma separated list of SHA-256 hashes encoded in base64
+	proxySSLPinRegex = regexp.MustCompile(`^[A-Za-z0-9+/]{43}=(\s*,\s*[A-Za-z0-9+/]{43}=)*$`)
 )
 
 const (
@@ -52,6 +54,7 @@ const (
 	proxySSLVerifyAnnotation      = "proxy-ssl-verify"
 	proxySSLVerifyDepthAnnotation = "proxy-ssl-verify-depth"
 	proxySSLServerNameAnnotation  = "proxy-ssl-server-name"
+	proxySSLPinSHA256Annotation   = "proxy-ssl-pin-sha256"
 )
 
 var proxySSLAnnotation = parser.Annotation{
@@ -104,6 +107,13 @@ var proxySSLAnnotation = parser.Annotation{
 			Risk:          parser.AnnotationRiskLow,
 			Documentation: `This annotation enables passing of the server name through TLS Server Name Indication extension (SNI, RFC 6066) when establishing a connection with the proxied HTTPS server.`,
 		},
+		proxySSLPinSHA256Annotation: {
+			Validator: parser.ValidateRegex(proxySSLPinRegex, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines a comma separated list of SHA-256 hashes, encoded in base64, of the SubjectPublicKeyInfo of the CA certificates trusted to verify the proxied HTTPS server. 
+			Only the certificates of ca.crt in the proxy-ssl-secret matching one of the hashes are trusted, and the verification is enabled.`,
+		},
 	},
 }
 
@@ -117,6 +127,8 @@ type Config struct {
 	Verify             string `json:"verify"`
 	VerifyDepth        int    `json:"verifyDepth"`
 	ProxySSLServerName string `json:"proxySSLServerName"`
+	// PinSHA256 contains the sorted SPKI pins of the trusted CA certificates
+	PinSHA256 string `json:"pinSHA256,omitempty"`
 }
 
 // Equal tests for equality between two Config types
@@ -142,9 +154,15 @@ func (pssl1 *Config) Equal(pssl2 *Config) bool {
 	if pssl1.VerifyDepth != pssl2.VerifyDepth {
 		return false
 	}
+	if pssl1.ProxySSLName != pssl2.ProxySSLName {
+		return false
+	}
 	if pssl1.ProxySSLServerName != pssl2.ProxySSLServerName {
 		return false
 	}
+	if pssl1.PinSHA256 != pssl2.PinSHA256 {
+		return false
+	}
 	return true
 }
 
@@ -253,6 +271,27 @@ func (p proxySSL) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.ProxySSLServerName = defaultProxySSLServerName
 	}
 
+	pins, err := parser.GetStringAnnotation(proxySSLPinSHA256Annotation, ing, p.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return &Config{}, err
+		}
+		return config, nil
+	}
+
+	if config.CAFileName == "" {
+		return &Config{}, ing_errors.NewLocationDenied("proxy-ssl-pin-sha256 requires a CA certificate (ca.crt) in the proxy-ssl-secret")
+	}
+
+	pinList := parsePins(pins)
+	config.CAFileName, config.CASHA, err = writePinnedCAFile(config.CAFileName, pinList)
+	if err != nil {
+		e := fmt.Errorf("error pinning the CA certificates: %w", err)
+		return &Config{}, ing_errors.LocationDeniedError{Reason: e}
+	}
+	config.PinSHA256 = strings.Join(pinList, ",")
+	config.Verify = "on"
+
 	return config, nil
 }
 
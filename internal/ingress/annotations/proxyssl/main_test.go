@@ -17,6 +17,8 @@ limitations under the License.
 package proxyssl
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -209,6 +211,68 @@ func TestInvalidAnnotations(t *testing.T) {
 	}
 }
 
+// mockPinnedSecret mocks a Secret whose ca.crt contains two CA certificates
+type mockPinnedSecret struct {
+	resolver.Mock
+	caFileName string
+}
+
+func (m mockPinnedSecret) GetAuthCertificate(name string) (*resolver.AuthSSLCert, error) {
+	return &resolver.AuthSSLCert{
+		Secret:     name,
+		CAFileName: m.caFileName,
+		CASHA:      "abc",
+	}, nil
+}
+
+func TestPinAnnotation(t *testing.T) {
+	ca1 := newCACertificate(t, "ca-1")
+	ca2 := newCACertificate(t, "ca-2")
+	caFileName := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFileName, encodeCertificates(ca1, ca2), 0o600); err != nil {
+		t.Fatalf("unexpected error writing the CA file: %v", err)
+	}
+
+	ing := buildIngress()
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(proxySSLSecretAnnotation)] = defaultDemoSecret
+	data[parser.GetAnnotationWithPrefix(proxySSLPinSHA256Annotation)] = spkiPin(ca2)
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockPinnedSecret{caFileName: caFileName}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	u := i.(*Config)
+	if u.CAFileName == caFileName || u.CASHA == "abc" {
+		t.Errorf("expected the pinned CA file but got %v, %v", u.CAFileName, u.CASHA)
+	}
+	if u.Verify != "on" {
+		t.Errorf("expected the verification to be enabled but got %v", u.Verify)
+	}
+	if u.PinSHA256 != spkiPin(ca2) {
+		t.Errorf("expected %v but got %v", spkiPin(ca2), u.PinSHA256)
+	}
+
+	// no CA certificate matches the pins
+	data[parser.GetAnnotationWithPrefix(proxySSLPinSHA256Annotation)] = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	if _, err := NewParser(mockPinnedSecret{caFileName: caFileName}).Parse(ing); !errors.IsLocationDenied(err) {
+		t.Errorf("expected a location denied error but got %v", err)
+	}
+
+	// the Secret does not contain a CA certificate
+	if _, err := NewParser(mockPinnedSecret{}).Parse(ing); !errors.IsLocationDenied(err) {
+		t.Errorf("expected a location denied error but got %v", err)
+	}
+
+	// invalid pins
+	data[parser.GetAnnotationWithPrefix(proxySSLPinSHA256Annotation)] = "not-a-pin"
+	if _, err := NewParser(mockPinnedSecret{caFileName: caFileName}).Parse(ing); !errors.IsValidationError(err) {
+		t.Errorf("expected a validation error but got %v", err)
+	}
+}
+
 func TestEquals(t *testing.T) {
 	cfg1 := &Config{}
 	cfg2 := &Config{}
@@ -283,6 +347,23 @@ func TestEquals(t *testing.T) {
 	}
 	cfg2.ProxySSLServerName = off
 
+	// Different ProxySSLName
+	cfg1.ProxySSLName = sslServerName
+	cfg2.ProxySSLName = "$host"
+	result = cfg1.Equal(cfg2)
+	if result != false {
+		t.Errorf("Expected false")
+	}
+	cfg2.ProxySSLName = sslServerName
+
+	// Different PinSHA256
+	cfg1.PinSHA256 = "47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="
+	result = cfg1.Equal(cfg2)
+	if result != false {
+		t.Errorf("Expected false")
+	}
+	cfg2.PinSHA256 = cfg1.PinSHA256
+
 	// Equal Configs
 	result = cfg1.Equal(cfg2)
 	if result != true {
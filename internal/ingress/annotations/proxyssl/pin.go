@@ -0,0 +1,106 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxyssl
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"k8s.io/ingress-nginx/pkg/util/file"
+)
+
+// parsePins returns the sorted list of the SPKI pins of a comma separated value
+func parsePins(value string) []string {
+	pins := []string{}
+	for _, pin := range strings.Split(value, ",") {
+		pin = strings.TrimSpace(pin)
+		if pin != "" && !slices.Contains(pins, pin) {
+			pins = append(pins, pin)
+		}
+	}
+
+	slices.Sort(pins)
+	return pins
+}
+
+// spkiPin returns the SHA-256 hash of the SubjectPublicKeyInfo of a certificate,
+// encoded in base64
+func spkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// pinnedCACertificates returns the certificates of a PEM bundle with one of
+// the SPKI pins
+func pinnedCACertificates(data []byte, pins []string) ([]byte, error) {
+	pinned := []byte{}
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if slices.Contains(pins, spkiPin(cert)) {
+			pinned = append(pinned, pem.EncodeToMemory(block)...)
+		}
+	}
+
+	if len(pinned) == 0 {
+		return nil, fmt.Errorf("no CA certificate matches the pins %v", strings.Join(pins, ","))
+	}
+
+	return pinned, nil
+}
+
+// writePinnedCAFile writes the CA certificates of caFileName with one of the
+// SPKI pins in a file next to it, and returns the name of the file and its SHA1
+func writePinnedCAFile(caFileName string, pins []string) (fileName, sha string, err error) {
+	data, err := os.ReadFile(caFileName)
+	if err != nil {
+		return "", "", err
+	}
+
+	pinned, err := pinnedCACertificates(data, pins)
+	if err != nil {
+		return "", "", err
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(pins, ",")))
+	fileName = fmt.Sprintf("%v-pinned-%v.pem", strings.TrimSuffix(caFileName, ".pem"), hex.EncodeToString(sum[:8]))
+	if err := os.WriteFile(fileName, pinned, file.ReadWriteByUser); err != nil {
+		return "", "", err
+	}
+
+	return fileName, file.SHA1(fileName), nil
+}
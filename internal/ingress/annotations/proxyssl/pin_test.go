@@ -0,0 +1,119 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package proxyssl
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newCACertificate(t *testing.T, name string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating a key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("unexpected error creating a certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a certificate: %v", err)
+	}
+
+	return cert
+}
+
+func encodeCertificates(certs ...*x509.Certificate) []byte {
+	data := []byte{}
+	for _, cert := range certs {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return data
+}
+
+func TestParsePins(t *testing.T) {
+	expected := []string{"a", "b"}
+	if pins := parsePins(" b, a ,b,"); !reflect.DeepEqual(pins, expected) {
+		t.Errorf("expected %v but returned %v", expected, pins)
+	}
+}
+
+func TestPinnedCACertificates(t *testing.T) {
+	ca1 := newCACertificate(t, "ca-1")
+	ca2 := newCACertificate(t, "ca-2")
+	bundle := encodeCertificates(ca1, ca2)
+
+	pinned, err := pinnedCACertificates(bundle, []string{spkiPin(ca2)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := encodeCertificates(ca2); string(pinned) != string(expected) {
+		t.Errorf("expected only the pinned certificate but returned %s", pinned)
+	}
+
+	if _, err := pinnedCACertificates(bundle, []string{"47DEQpj8HBSa+/TImW+5JCeuQeRkm5NMpJWZG3hSuFU="}); err == nil {
+		t.Errorf("expected an error without a pinned certificate")
+	}
+}
+
+func TestWritePinnedCAFile(t *testing.T) {
+	ca1 := newCACertificate(t, "ca-1")
+	ca2 := newCACertificate(t, "ca-2")
+
+	caFileName := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFileName, encodeCertificates(ca1, ca2), 0o600); err != nil {
+		t.Fatalf("unexpected error writing the CA file: %v", err)
+	}
+
+	fileName, sha, err := writePinnedCAFile(caFileName, []string{spkiPin(ca1)})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if fileName == caFileName || sha == "" {
+		t.Errorf("expected a new pinned file but returned %v, %v", fileName, sha)
+	}
+
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		t.Fatalf("unexpected error reading the pinned file: %v", err)
+	}
+	if expected := encodeCertificates(ca1); string(data) != string(expected) {
+		t.Errorf("expected only the pinned certificate but the file contains %s", data)
+	}
+}
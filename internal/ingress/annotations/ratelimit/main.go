@@ -19,6 +19,8 @@ package ratelimit
 import (
 	"encoding/base64"
 	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 
 	networking "k8s.io/api/networking/v1"
@@ -60,6 +62,35 @@ type Config struct {
 	ID string `json:"id"`
 
 	Allowlist []string `json:"allowlist"`
+
+	// ConnectionsKey indicates the key of the connection limit, like
+	// "header:X-API-Key". Empty means the IP address of the client
+	ConnectionsKey string `json:"connections-key"`
+
+	// Exemptions are the header values and the JWT claims of the requests
+	// that are not rate limited
+	Exemptions []Exemption `json:"exemptions,omitempty"`
+
+	// AuthenticatedRPS indicates a limit of the authenticated requests per second
+	AuthenticatedRPS Zone `json:"authenticated-rps"`
+
+	// AuthenticatedRPM indicates a limit of the authenticated requests per minute
+	AuthenticatedRPM Zone `json:"authenticated-rpm"`
+
+	// AuthenticatedKey indicates the identity of the authenticated requests,
+	// like "header:Authorization", limited by the authenticated zones instead
+	// of the zones of the IP address of the client
+	AuthenticatedKey string `json:"authenticated-key,omitempty"`
+}
+
+// Exemption is a header value or a JWT claim of the requests that are not rate limited
+type Exemption struct {
+	// Source is header or jwt
+	Source string `json:"source"`
+	// Name is the name of the header or of the claim
+	Name string `json:"name"`
+	// Value is the value of the header or of the claim
+	Value string `json:"value"`
 }
 
 // Equal tests for equality between two RateLimit types
@@ -91,6 +122,21 @@ func (rt1 *Config) Equal(rt2 *Config) bool {
 	if rt1.Name != rt2.Name {
 		return false
 	}
+	if rt1.ConnectionsKey != rt2.ConnectionsKey {
+		return false
+	}
+	if !slices.Equal(rt1.Exemptions, rt2.Exemptions) {
+		return false
+	}
+	if !(&rt1.AuthenticatedRPS).Equal(&rt2.AuthenticatedRPS) {
+		return false
+	}
+	if !(&rt1.AuthenticatedRPM).Equal(&rt2.AuthenticatedRPM) {
+		return false
+	}
+	if rt1.AuthenticatedKey != rt2.AuthenticatedKey {
+		return false
+	}
 	if len(rt1.Allowlist) != len(rt2.Allowlist) {
 		return false
 	}
@@ -141,8 +187,65 @@ const (
 	limitRateBurstMultiplierAnnotation = "limit-burst-multiplier"
 	limitWhitelistAnnotation           = "limit-whitelist" // This annotation is an alias for limit-allowlist
 	limitAllowlistAnnotation           = "limit-allowlist"
+	limitConnectionsKeyAnnotation      = "limit-connections-key"
+	limitExemptionsAnnotation          = "limit-exemptions"
+	limitAuthenticatedRPSAnnotation    = "limit-authenticated-rps"
+	limitAuthenticatedRPMAnnotation    = "limit-authenticated-rpm"
+	limitAuthenticatedKeyAnnotation    = "limit-authenticated-key"
 )
 
+// defAuthenticatedKey is the identity of the authenticated requests when
+// limit-authenticated-key is not defined
+const defAuthenticatedKey = "header:Authorization"
+
+// Sources of the key of the connection limit
+const (
+	// HeaderKey uses the value of a request header
+	HeaderKey = "header"
+	// CookieKey uses the value of a cookie
+	CookieKey = "cookie"
+	// JWTClaimKey uses the value of a claim of the JWT sent as bearer token
+	JWTClaimKey = "jwt"
+)
+
+// limitConnectionsKeyRegex validates keys like "header:X-API-Key", "cookie:session" or "jwt:sub"
+var limitConnectionsKeyRegex = regexp.MustCompile(`^(?:header:[A-Za-z0-9-]+|cookie:[A-Za-z0-9_]+|jwt:[A-Za-z0-9_]+)$`)
+
+// limitExemptionRegex validates exemptions like "header:X-Internal=true" or "jwt:role=admin",
+// the values being written in quoted NGINX strings
+var limitExemptionRegex = regexp.MustCompile(`^(?:header:[A-Za-z0-9-]+|jwt:[A-Za-z0-9_]+)=[A-Za-z0-9._+/=:@-]+$`)
+
+// validateExemptions validates a comma separated list of CIDRs, header values and JWT claims
+func validateExemptions(value string) error {
+	_, _, err := parseExemptions(value)
+	return err
+}
+
+// parseExemptions returns the CIDRs and the other exemptions of a comma separated list
+func parseExemptions(value string) (cidrs []string, exemptions []Exemption, err error) {
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if limitExemptionRegex.MatchString(entry) {
+			source, rest, _ := strings.Cut(entry, ":")
+			name, val, _ := strings.Cut(rest, "=")
+			exemptions = append(exemptions, Exemption{Source: source, Name: name, Value: val})
+			continue
+		}
+
+		parsed, err := net.ParseCIDRs(entry)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid exemption %v", entry)
+		}
+		cidrs = append(cidrs, parsed...)
+	}
+
+	return cidrs, exemptions, nil
+}
+
 var rateLimitAnnotations = parser.Annotation{
 	Group: "rate-limit",
 	Annotations: parser.AnnotationFields{
@@ -191,6 +294,39 @@ var rateLimitAnnotations = parser.Annotation{
 			Documentation:     `List of CIDR/IP addresses that will not be rate-limited.`,
 			AnnotationAliases: []string{limitWhitelistAnnotation},
 		},
+		limitConnectionsKeyAnnotation: {
+			Validator: parser.ValidateRegex(limitConnectionsKeyRegex, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow, // Low, as it allows just a set of options
+			Documentation: `Key of the connection limit instead of the IP address of the client, like "header:X-API-Key", "cookie:session" or "jwt:sub".
+			The signature of the JWT is not verified. Requests without the key are not limited.`,
+		},
+		limitExemptionsAnnotation: {
+			Validator: validateExemptions,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow, // Low, as it allows just a set of options
+			Documentation: `List of CIDR/IP addresses, header values like "header:X-Internal=true" and JWT claims like "jwt:role=admin" of the requests that will not be rate-limited.
+			The signature of the JWT is not verified.`,
+		},
+		limitAuthenticatedRPSAnnotation: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow, // Low, as it allows just a set of options
+			Documentation: `Requests per second that will be allowed for each identity of the authenticated requests.`,
+		},
+		limitAuthenticatedRPMAnnotation: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow, // Low, as it allows just a set of options
+			Documentation: `Requests per minute that will be allowed for each identity of the authenticated requests.`,
+		},
+		limitAuthenticatedKeyAnnotation: {
+			Validator: parser.ValidateRegex(limitConnectionsKeyRegex, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow, // Low, as it allows just a set of options
+			Documentation: `Identity of the authenticated requests, like "header:X-API-Key", "cookie:session" or "jwt:sub". Defaults to "header:Authorization".
+			The requests with the identity are limited by limit-authenticated-rps and limit-authenticated-rpm instead of the limits of the IP address of the client.`,
+		},
 	},
 }
 
@@ -247,7 +383,54 @@ func (a ratelimit) Parse(ing *networking.Ingress) (interface{}, error) {
 		return nil, errCidr
 	}
 
-	if rpm == 0 && rps == 0 && conn == 0 {
+	connKey, err := parser.GetStringAnnotation(limitConnectionsKeyAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil && errors.IsValidationError(err) {
+		return nil, err
+	}
+	// the key is part of the configuration even when the validation of annotations is disabled
+	if connKey != "" && !limitConnectionsKeyRegex.MatchString(connKey) {
+		return nil, errors.NewInvalidAnnotationContent(limitConnectionsKeyAnnotation, connKey)
+	}
+
+	exemptionsValue, err := parser.GetStringAnnotation(limitExemptionsAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil && errors.IsValidationError(err) {
+		return nil, err
+	}
+	// the exemptions are part of the configuration even when the validation of annotations is disabled
+	exemptCIDRs, exemptions, err := parseExemptions(exemptionsValue)
+	if err != nil {
+		return nil, errors.NewInvalidAnnotationContent(limitExemptionsAnnotation, exemptionsValue)
+	}
+	for _, cidr := range exemptCIDRs {
+		if !slices.Contains(cidrs, cidr) {
+			cidrs = append(cidrs, cidr)
+		}
+	}
+
+	authRPS, err := parser.GetIntAnnotation(limitAuthenticatedRPSAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil && errors.IsValidationError(err) {
+		return nil, err
+	}
+	authRPM, err := parser.GetIntAnnotation(limitAuthenticatedRPMAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil && errors.IsValidationError(err) {
+		return nil, err
+	}
+
+	authKey := ""
+	if authRPS > 0 || authRPM > 0 {
+		authKey, err = parser.GetStringAnnotation(limitAuthenticatedKeyAnnotation, ing, a.annotationConfig.Annotations)
+		if err != nil && errors.IsValidationError(err) {
+			return nil, err
+		}
+		if authKey == "" {
+			authKey = defAuthenticatedKey
+		}
+		if !limitConnectionsKeyRegex.MatchString(authKey) {
+			return nil, errors.NewInvalidAnnotationContent(limitAuthenticatedKeyAnnotation, authKey)
+		}
+	}
+
+	if rpm == 0 && rps == 0 && conn == 0 && authRPS == 0 && authRPM == 0 {
 		return &Config{
 			Connections:    Zone{},
 			RPS:            Zone{},
@@ -278,14 +461,35 @@ func (a ratelimit) Parse(ing *networking.Ingress) (interface{}, error) {
 			Burst:      rpm * burstMultiplier,
 			SharedSize: defSharedSize,
 		},
-		LimitRate:      lr,
-		LimitRateAfter: lra,
-		Name:           zoneName,
-		ID:             encode(zoneName),
-		Allowlist:      cidrs,
+		AuthenticatedRPS: Zone{
+			Name:       fmt.Sprintf("%v_authenticated_rps", zoneName),
+			Limit:      authRPS,
+			Burst:      authRPS * burstMultiplier,
+			SharedSize: defSharedSize,
+		},
+		AuthenticatedRPM: Zone{
+			Name:       fmt.Sprintf("%v_authenticated_rpm", zoneName),
+			Limit:      authRPM,
+			Burst:      authRPM * burstMultiplier,
+			SharedSize: defSharedSize,
+		},
+		LimitRate:        lr,
+		LimitRateAfter:   lra,
+		Name:             zoneName,
+		ID:               encode(zoneName),
+		Allowlist:        cidrs,
+		ConnectionsKey:   connKey,
+		Exemptions:       exemptions,
+		AuthenticatedKey: authKey,
 	}, nil
 }
 
+// ParseConnectionsKey returns the source and the name of the key of the connection limit
+func ParseConnectionsKey(key string) (source, name string) {
+	source, name, _ = strings.Cut(key, ":")
+	return source, name
+}
+
 func encode(s string) string {
 	str := base64.URLEncoding.EncodeToString([]byte(s))
 	return strings.ReplaceAll(str, "=", "")
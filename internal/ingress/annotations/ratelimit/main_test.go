@@ -17,6 +17,7 @@ limitations under the License.
 package ratelimit
 
 import (
+	"slices"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -248,3 +249,151 @@ func TestAnnotationCIDR(t *testing.T) {
 		t.Errorf("expected 1 cidrs in limit by ip but %v was returned", len(rateLimit.Allowlist))
 	}
 }
+
+func TestAnnotationConnectionsKey(t *testing.T) {
+	ing := buildIngress()
+
+	testCases := []struct {
+		key       string
+		expected  string
+		expectErr bool
+	}{
+		{"header:X-API-Key", "header:X-API-Key", false},
+		{"cookie:session_id", "cookie:session_id", false},
+		{"jwt:sub", "jwt:sub", false},
+		{"", "", false},
+		{"header:X-API-Key;", "", true},
+		{"cookie:session-id", "", true},
+		{"query:key", "", true},
+	}
+
+	for _, tc := range testCases {
+		data := map[string]string{}
+		data[parser.GetAnnotationWithPrefix(limitRateConnectionsAnnotation)] = "5"
+		if tc.key != "" {
+			data[parser.GetAnnotationWithPrefix(limitConnectionsKeyAnnotation)] = tc.key
+		}
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if (err != nil) != tc.expectErr {
+			t.Errorf("expected error: %t but got error: %v for key %v", tc.expectErr, err, tc.key)
+			continue
+		}
+
+		if tc.expectErr {
+			continue
+		}
+
+		rateLimit, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("expected a RateLimit type")
+		}
+		if rateLimit.ConnectionsKey != tc.expected {
+			t.Errorf("expected key %v but %v was returned", tc.expected, rateLimit.ConnectionsKey)
+		}
+	}
+}
+
+func TestAnnotationExemptions(t *testing.T) {
+	ing := buildIngress()
+
+	testCases := []struct {
+		title      string
+		exemptions string
+		cidrs      int
+		expected   []Exemption
+		expectErr  bool
+	}{
+		{"cidrs", "10.0.0.0/8, 2001:db8::/32", 3, nil, false},
+		{
+			"header and claim",
+			"header:X-Internal=true,jwt:role=admin",
+			1,
+			[]Exemption{{Source: HeaderKey, Name: "X-Internal", Value: "true"}, {Source: JWTClaimKey, Name: "role", Value: "admin"}},
+			false,
+		},
+		{"duplicated cidr", "192.168.0.5", 1, nil, false},
+		{"invalid value", `header:X-Internal=a"b`, 0, nil, true},
+		{"cookie", "cookie:session=1", 0, nil, true},
+		{"invalid cidr", "10.0.0.0/33", 0, nil, true},
+	}
+
+	for _, tc := range testCases {
+		data := map[string]string{}
+		data[parser.GetAnnotationWithPrefix(limitRateRPSAnnotation)] = "5"
+		data[parser.GetAnnotationWithPrefix(limitAllowlistAnnotation)] = "192.168.0.5"
+		data[parser.GetAnnotationWithPrefix(limitExemptionsAnnotation)] = tc.exemptions
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if (err != nil) != tc.expectErr {
+			t.Errorf("%s: expected error: %t but got error: %v", tc.title, tc.expectErr, err)
+			continue
+		}
+		if tc.expectErr {
+			continue
+		}
+
+		rateLimit, ok := i.(*Config)
+		if !ok {
+			t.Fatalf("expected a RateLimit type")
+		}
+		if len(rateLimit.Allowlist) != tc.cidrs {
+			t.Errorf("%s: expected %v cidrs but %v was returned", tc.title, tc.cidrs, rateLimit.Allowlist)
+		}
+		if !slices.Equal(rateLimit.Exemptions, tc.expected) {
+			t.Errorf("%s: expected exemptions %v but %v was returned", tc.title, tc.expected, rateLimit.Exemptions)
+		}
+	}
+}
+
+func TestAnnotationAuthenticatedLimits(t *testing.T) {
+	ing := buildIngress()
+
+	data := map[string]string{}
+	data[parser.GetAnnotationWithPrefix(limitAuthenticatedRPSAnnotation)] = "50"
+	ing.SetAnnotations(data)
+
+	i, err := NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	rateLimit, ok := i.(*Config)
+	if !ok {
+		t.Fatalf("expected a RateLimit type")
+	}
+	if rateLimit.ID == "" {
+		t.Errorf("expected a rate limit with only an authenticated limit")
+	}
+	if rateLimit.AuthenticatedRPS.Limit != 50 || rateLimit.AuthenticatedRPS.Burst != 250 {
+		t.Errorf("expected an authenticated limit of 50 with a burst of 250 but %+v was returned", rateLimit.AuthenticatedRPS)
+	}
+	if rateLimit.AuthenticatedRPS.Name != "default_foo__authenticated_rps" {
+		t.Errorf("unexpected zone name %v", rateLimit.AuthenticatedRPS.Name)
+	}
+	if rateLimit.AuthenticatedKey != defAuthenticatedKey {
+		t.Errorf("expected the default identity %v but %v was returned", defAuthenticatedKey, rateLimit.AuthenticatedKey)
+	}
+
+	data[parser.GetAnnotationWithPrefix(limitAuthenticatedKeyAnnotation)] = "jwt:sub"
+	i, err = NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key := i.(*Config).AuthenticatedKey; key != "jwt:sub" {
+		t.Errorf("expected the identity jwt:sub but %v was returned", key)
+	}
+
+	data = map[string]string{}
+	data[parser.GetAnnotationWithPrefix(limitRateRPSAnnotation)] = "5"
+	data[parser.GetAnnotationWithPrefix(limitAuthenticatedKeyAnnotation)] = "jwt:sub"
+	ing.SetAnnotations(data)
+	i, err = NewParser(mockBackend{}).Parse(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if key := i.(*Config).AuthenticatedKey; key != "" {
+		t.Errorf("expected no identity without authenticated limit but %v was returned", key)
+	}
+}
@@ -17,7 +17,11 @@ limitations under the License.
 package rewrite
 
 import (
+	"fmt"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 
 	networking "k8s.io/api/networking/v1"
 	"k8s.io/klog/v2"
@@ -28,14 +32,38 @@ import (
 )
 
 const (
-	rewriteTargetAnnotation         = "rewrite-target"
-	sslRedirectAnnotation           = "ssl-redirect"
-	preserveTrailingSlashAnnotation = "preserve-trailing-slash"
-	forceSSLRedirectAnnotation      = "force-ssl-redirect"
-	useRegexAnnotation              = "use-regex"
-	appRootAnnotation               = "app-root"
+	rewriteTargetAnnotation            = "rewrite-target"
+	sslRedirectAnnotation              = "ssl-redirect"
+	preserveTrailingSlashAnnotation    = "preserve-trailing-slash"
+	forceSSLRedirectAnnotation         = "force-ssl-redirect"
+	useRegexAnnotation                 = "use-regex"
+	appRootAnnotation                  = "app-root"
+	rewriteRulesAnnotation             = "rewrite-rules"
+	sslRedirectCodeAnnotation          = "ssl-redirect-code"
+	sslRedirectPreserveQueryAnnotation = "ssl-redirect-preserve-query"
+	sslRedirectExemptPathsAnnotation   = "ssl-redirect-exempt-paths"
 )
 
+const (
+	// LowercaseTransform converts the rewritten URI to lowercase
+	LowercaseTransform = "lowercase"
+	// UppercaseTransform converts the rewritten URI to uppercase
+	UppercaseTransform = "uppercase"
+)
+
+var (
+	// rewriteReplacementRegex allows replacements starting with "/" containing
+	// alphanumeric characters and references to capture groups like $1, $name or ${name}
+	rewriteReplacementRegex = regexp.MustCompile(`^/[\-\.\_\~a-zA-Z0-9\/:$\{\}?=&%]*$`)
+	// captureReferenceRegex matches the references to capture groups of a replacement
+	captureReferenceRegex = regexp.MustCompile(`\$(?:\{([A-Za-z0-9_]+)\}|([0-9]+|[A-Za-z_][A-Za-z0-9_]*))`)
+	// exemptPathsRegex allows a comma separated list of path prefixes
+	exemptPathsRegex = regexp.MustCompile(`^/[\-\.\_\~a-zA-Z0-9\/]*(,/[\-\.\_\~a-zA-Z0-9\/]*)*$`)
+)
+
+// sslRedirectCodes are the status codes allowed in the redirects to HTTPS
+var sslRedirectCodes = []string{"301", "302", "307", "308"}
+
 var rewriteAnnotations = parser.Annotation{
 	Group: "rewrite",
 	Annotations: parser.AnnotationFields{
@@ -77,9 +105,166 @@ var rewriteAnnotations = parser.Annotation{
 			Risk:          parser.AnnotationRiskMedium,
 			Documentation: `This annotation defines the Application Root that the Controller must redirect if it's in / context`,
 		},
+		rewriteRulesAnnotation: {
+			Validator: validateRules,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium,
+			Documentation: `This annotation defines an ordered list of rules, one per line, rewriting the URI before proxying the request.
+			Each rule contains a regular expression, a replacement that can reference numbered and named capture groups like '$1' or '$name',
+			and optionally the transformation 'lowercase' or 'uppercase' applied to the rewritten URI.`,
+		},
+		sslRedirectCodeAnnotation: {
+			Validator:     parser.ValidateOptions(sslRedirectCodes, true, true),
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the status code of the redirects to HTTPS, 301, 302, 307 or 308. It overrides http-redirect-code of the ConfigMap`,
+		},
+		sslRedirectPreserveQueryAnnotation: {
+			Validator:     parser.ValidateBool,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines if the query string is kept in the redirects to HTTPS. Defaults to true`,
+		},
+		sslRedirectExemptPathsAnnotation: {
+			Validator: parser.ValidateRegex(exemptPathsRegex, true),
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines a comma separated list of path prefixes not redirected to HTTPS by ssl-redirect and force-ssl-redirect,
+			like /.well-known/acme-challenge`,
+		},
 	},
 }
 
+// Rule describes a rewrite of the URI of the requests of a location
+type Rule struct {
+	// Regex is the regular expression matching the URI
+	Regex string `json:"regex"`
+	// Replacement is the new URI, that can reference the capture groups of the regular expression
+	Replacement string `json:"replacement"`
+	// Transform is the case conversion applied to the rewritten URI
+	Transform string `json:"transform,omitempty"`
+}
+
+// parseRules parses the rules of the rewrite-rules annotation, one per line
+// with the format "<regex> <replacement> [lowercase|uppercase]"
+func parseRules(value string) ([]Rule, error) {
+	rules := []Rule{}
+	for _, line := range strings.Split(value, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if len(fields) < 2 || len(fields) > 3 {
+			return nil, fmt.Errorf("invalid rule %q, expected \"<regex> <replacement> [lowercase|uppercase]\"", line)
+		}
+
+		rule := Rule{
+			Regex:       fields[0],
+			Replacement: fields[1],
+		}
+
+		if len(fields) == 3 {
+			rule.Transform = fields[2]
+			if rule.Transform != LowercaseTransform && rule.Transform != UppercaseTransform {
+				return nil, fmt.Errorf("invalid transformation %q in rule %q", rule.Transform, line)
+			}
+		}
+
+		if err := validateRule(rule); err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rule)
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("no rewrite rules defined")
+	}
+
+	return rules, nil
+}
+
+// validateRule checks the regular expression of a rule compiles and the
+// replacement only references capture groups defined in it
+func validateRule(rule Rule) error {
+	if strings.ContainsAny(rule.Regex, "\"'`") {
+		return fmt.Errorf("regular expression %q contains quotes", rule.Regex)
+	}
+
+	re, err := regexp.Compile(rule.Regex)
+	if err != nil {
+		return fmt.Errorf("invalid regular expression %q: %w", rule.Regex, err)
+	}
+
+	if !rewriteReplacementRegex.MatchString(rule.Replacement) {
+		return fmt.Errorf("invalid replacement %q", rule.Replacement)
+	}
+
+	references := captureReferenceRegex.FindAllStringSubmatch(rule.Replacement, -1)
+	if len(references) != strings.Count(rule.Replacement, "$") {
+		return fmt.Errorf("replacement %q contains an invalid reference to a capture group", rule.Replacement)
+	}
+
+	for _, reference := range references {
+		name := reference[1] + reference[2]
+		if n, err := strconv.Atoi(name); err == nil {
+			if n < 1 || n > re.NumSubexp() {
+				return fmt.Errorf("replacement %q references the capture group %v not defined in %q", rule.Replacement, n, rule.Regex)
+			}
+			continue
+		}
+
+		if re.SubexpIndex(name) == -1 {
+			return fmt.Errorf("replacement %q references the capture group %q not defined in %q", rule.Replacement, name, rule.Regex)
+		}
+	}
+
+	return nil
+}
+
+func validateRules(value string) error {
+	_, err := parseRules(value)
+	return err
+}
+
+// SSLRedirectPolicy customizes the redirects of the requests to HTTPS
+type SSLRedirectPolicy struct {
+	// Code is the status code of the redirects. The http-redirect-code of
+	// the configuration is used if zero.
+	Code int `json:"code,omitempty"`
+	// StripQuery removes the query string from the redirects
+	StripQuery bool `json:"stripQuery,omitempty"`
+	// ExemptPaths are the prefixes of the paths not redirected
+	ExemptPaths []string `json:"exemptPaths,omitempty"`
+}
+
+// Equal tests for equality between two SSLRedirectPolicy types
+func (p1 *SSLRedirectPolicy) Equal(p2 *SSLRedirectPolicy) bool {
+	if p1 == p2 {
+		return true
+	}
+	if p1 == nil || p2 == nil {
+		return false
+	}
+	if p1.Code != p2.Code {
+		return false
+	}
+	if p1.StripQuery != p2.StripQuery {
+		return false
+	}
+	if len(p1.ExemptPaths) != len(p2.ExemptPaths) {
+		return false
+	}
+	for i := range p1.ExemptPaths {
+		if p1.ExemptPaths[i] != p2.ExemptPaths[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
 // Config describes the per location redirect config
 type Config struct {
 	// Target URI where the traffic must be redirected
@@ -94,6 +279,10 @@ type Config struct {
 	AppRoot string `json:"appRoot"`
 	// UseRegex indicates whether or not the locations use regex paths
 	UseRegex bool `json:"useRegex"`
+	// Rules defines an ordered list of rewrites of the URI
+	Rules []Rule `json:"rules,omitempty"`
+	// SSLRedirectPolicy customizes the redirects to HTTPS
+	SSLRedirectPolicy SSLRedirectPolicy `json:"sslRedirectPolicy"`
 }
 
 // Equal tests for equality between two Redirect types
@@ -119,6 +308,17 @@ func (r1 *Config) Equal(r2 *Config) bool {
 	if r1.UseRegex != r2.UseRegex {
 		return false
 	}
+	if len(r1.Rules) != len(r2.Rules) {
+		return false
+	}
+	for i := range r1.Rules {
+		if r1.Rules[i] != r2.Rules[i] {
+			return false
+		}
+	}
+	if !(&r1.SSLRedirectPolicy).Equal(&r2.SSLRedirectPolicy) {
+		return false
+	}
 
 	return true
 }
@@ -180,6 +380,20 @@ func (a rewrite) Parse(ing *networking.Ingress) (interface{}, error) {
 		config.UseRegex = false
 	}
 
+	rules, err := parser.GetStringAnnotation(rewriteRulesAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, ignoring: %v", rewriteRulesAnnotation, err)
+		}
+	} else {
+		config.Rules, err = parseRules(rules)
+		if err != nil {
+			klog.Warningf("%s is invalid, ignoring: %v", rewriteRulesAnnotation, err)
+		}
+	}
+
+	config.SSLRedirectPolicy = a.parseSSLRedirectPolicy(ing)
+
 	config.AppRoot, err = parser.GetStringAnnotation(appRootAnnotation, ing, a.annotationConfig.Annotations)
 	if err != nil {
 		if !errors.IsMissingAnnotations(err) && !errors.IsInvalidContent(err) {
@@ -205,6 +419,44 @@ func (a rewrite) Parse(ing *networking.Ingress) (interface{}, error) {
 	return config, nil
 }
 
+// parseSSLRedirectPolicy parses the annotations customizing the redirects to HTTPS
+func (a rewrite) parseSSLRedirectPolicy(ing *networking.Ingress) SSLRedirectPolicy {
+	policy := SSLRedirectPolicy{}
+
+	code, err := parser.GetIntAnnotation(sslRedirectCodeAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to http-redirect-code", sslRedirectCodeAnnotation)
+		}
+	} else {
+		policy.Code = code
+	}
+
+	preserveQuery, err := parser.GetBoolAnnotation(sslRedirectPreserveQueryAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, defaulting to 'true'", sslRedirectPreserveQueryAnnotation)
+		}
+		preserveQuery = true
+	}
+	policy.StripQuery = !preserveQuery
+
+	paths, err := parser.GetStringAnnotation(sslRedirectExemptPathsAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsValidationError(err) {
+			klog.Warningf("%s is invalid, ignoring: %v", sslRedirectExemptPathsAnnotation, err)
+		}
+	} else {
+		for _, path := range strings.Split(paths, ",") {
+			if path = strings.TrimSpace(path); path != "" {
+				policy.ExemptPaths = append(policy.ExemptPaths, path)
+			}
+		}
+	}
+
+	return policy
+}
+
 func (a rewrite) GetDocumentation() parser.AnnotationFields {
 	return a.annotationConfig.Annotations
 }
@@ -17,6 +17,7 @@ limitations under the License.
 package rewrite
 
 import (
+	"reflect"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -272,3 +273,101 @@ func TestUseRegex(t *testing.T) {
 		t.Errorf("Unexpected value got in UseRegex")
 	}
 }
+
+func TestRewriteRules(t *testing.T) {
+	testCases := []struct {
+		value    string
+		expected []Rule
+	}{
+		{
+			"^/users/(?<id>[0-9]+)$ /api/users/$id",
+			[]Rule{{Regex: "^/users/(?<id>[0-9]+)$", Replacement: "/api/users/$id"}},
+		},
+		{
+			`^/Docs/(.*) /docs/$1 lowercase
+			^/(?P<section>[a-z]+)/(.*)$ /${section}/v2/$2`,
+			[]Rule{
+				{Regex: "^/Docs/(.*)", Replacement: "/docs/$1", Transform: LowercaseTransform},
+				{Regex: "^/(?P<section>[a-z]+)/(.*)$", Replacement: "/${section}/v2/$2"},
+			},
+		},
+		{"^/(.*) /$1 titlecase", nil},
+		{"^/(.*) /$2", nil},
+		{"^/(?<id>.*) /$name", nil},
+		{"^/(.*) /$1$", nil},
+		{"^/(.*) /$host", nil},
+		{`^/("*) /$1`, nil},
+		{"^/(.* /$1", nil},
+		{"^/(.*) http://example.com/$1", nil},
+		{"^/(.*) /$1;return", nil},
+		{"^/(.*)", nil},
+	}
+
+	for _, testCase := range testCases {
+		ing := buildIngress()
+		ing.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("rewrite-rules"): testCase.value,
+		})
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error with ingress: %v", err)
+		}
+		config, ok := i.(*Config)
+		if !ok {
+			t.Errorf("expected a Config type")
+		}
+
+		if !reflect.DeepEqual(config.Rules, testCase.expected) {
+			t.Errorf("expected rules %v for %q but %v returned", testCase.expected, testCase.value, config.Rules)
+		}
+	}
+}
+
+func TestSSLRedirectPolicy(t *testing.T) {
+	testCases := []struct {
+		annotations map[string]string
+		expected    SSLRedirectPolicy
+	}{
+		{map[string]string{}, SSLRedirectPolicy{}},
+		{
+			map[string]string{
+				"ssl-redirect-code":           "308",
+				"ssl-redirect-preserve-query": "false",
+				"ssl-redirect-exempt-paths":   "/.well-known/acme-challenge, /healthz",
+			},
+			SSLRedirectPolicy{
+				Code:        308,
+				StripQuery:  true,
+				ExemptPaths: []string{"/.well-known/acme-challenge", "/healthz"},
+			},
+		},
+		{map[string]string{"ssl-redirect-code": "303"}, SSLRedirectPolicy{}},
+		{map[string]string{"ssl-redirect-preserve-query": "true"}, SSLRedirectPolicy{}},
+		{map[string]string{"ssl-redirect-exempt-paths": "healthz"}, SSLRedirectPolicy{}},
+		{map[string]string{"ssl-redirect-exempt-paths": "/healthz;return"}, SSLRedirectPolicy{}},
+	}
+
+	for _, testCase := range testCases {
+		data := map[string]string{}
+		for name, value := range testCase.annotations {
+			data[parser.GetAnnotationWithPrefix(name)] = value
+		}
+
+		ing := buildIngress()
+		ing.SetAnnotations(data)
+
+		i, err := NewParser(mockBackend{}).Parse(ing)
+		if err != nil {
+			t.Errorf("unexpected error with ingress: %v", err)
+		}
+		config, ok := i.(*Config)
+		if !ok {
+			t.Errorf("expected a Config type")
+		}
+
+		if !reflect.DeepEqual(config.SSLRedirectPolicy, testCase.expected) {
+			t.Errorf("expected policy %v for %v but %v returned", testCase.expected, testCase.annotations, config.SSLRedirectPolicy)
+		}
+	}
+}
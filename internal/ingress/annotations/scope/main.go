@@ -0,0 +1,88 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scope
+
+import (
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	scopeAnnotation = "scope"
+
+	// Public is the scope of the Ingresses served in the listeners defined
+	// by --http-port and --https-port
+	Public = "public"
+
+	// Internal is the scope of the Ingresses only served in the listeners
+	// defined by --internal-http-port and --internal-https-port
+	Internal = "internal"
+)
+
+var scopeAnnotations = parser.Annotation{
+	Group: "listen",
+	Annotations: parser.AnnotationFields{
+		scopeAnnotation: {
+			Validator: parser.ValidateOptions([]string{Public, Internal}, true, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the listeners serving the hosts of the Ingress. public serves them in the listeners
+			defined by --http-port and --https-port, internal only in the listeners defined by --internal-http-port and --internal-https-port.`,
+		},
+	},
+}
+
+type scope struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new scope annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return scope{
+		r:                r,
+		annotationConfig: scopeAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to define the listeners serving the hosts of the Ingress
+func (a scope) Parse(ing *networking.Ingress) (interface{}, error) {
+	s, err := parser.GetStringAnnotation(scopeAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return Public, err
+		}
+		return Public, nil
+	}
+
+	return strings.TrimSpace(s), nil
+}
+
+func (a scope) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a scope) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, scopeAnnotations.Annotations)
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backendprotocol"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+// ServiceAnnotations contains the annotations (without prefix) that can also be
+// defined in the backing Service of an Ingress. Their values are applied to all
+// the locations that use the Service unless the Ingress defines the same annotation.
+var ServiceAnnotations = []string{
+	"backend-protocol",
+	"connection-proxy-header",
+	"upstream-keepalive",
+	"proxy-http-version",
+	"proxy-connect-timeout",
+	"proxy-send-timeout",
+	"proxy-read-timeout",
+	"proxy-next-upstream",
+	"proxy-next-upstream-timeout",
+	"proxy-next-upstream-tries",
+	"proxy-ssl-secret",
+	"proxy-ssl-ciphers",
+	"proxy-ssl-protocols",
+	"proxy-ssl-name",
+	"proxy-ssl-verify",
+	"proxy-ssl-verify-depth",
+	"proxy-ssl-server-name",
+	"proxy-ssl-pin-sha256",
+}
+
+// NewServiceAnnotationExtractor creates a new annotations extractor that only
+// parses the annotations affected by ServiceAnnotations
+func NewServiceAnnotationExtractor(cfg resolver.Resolver) Extractor {
+	return Extractor{
+		map[string]parser.IngressAnnotation{
+			"BackendProtocol": backendprotocol.NewParser(cfg),
+			"Connection":      connection.NewParser(cfg),
+			"Proxy":           proxy.NewParser(cfg),
+			"ProxySSL":        proxyssl.NewParser(cfg),
+		},
+	}
+}
+
+// MergeServiceAnnotations returns a copy of the Ingress containing the
+// ServiceAnnotations defined in the Service that are not already present in
+// the Ingress. The returned boolean is false when there is nothing to merge.
+func MergeServiceAnnotations(ing *networking.Ingress, svc *apiv1.Service) (*networking.Ingress, bool) {
+	if ing == nil || svc == nil || len(svc.GetAnnotations()) == 0 {
+		return ing, false
+	}
+
+	merged := map[string]string{}
+	for k, v := range ing.GetAnnotations() {
+		merged[k] = v
+	}
+
+	found := false
+	for _, name := range ServiceAnnotations {
+		key := parser.GetAnnotationWithPrefix(name)
+		val, ok := svc.GetAnnotations()[key]
+		if !ok {
+			continue
+		}
+
+		if _, ok := merged[key]; ok {
+			continue
+		}
+
+		merged[key] = val
+		found = true
+	}
+
+	if !found {
+		return ing, false
+	}
+
+	copyIng := ing.DeepCopy()
+	copyIng.SetAnnotations(merged)
+	return copyIng, true
+}
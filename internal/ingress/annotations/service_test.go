@@ -0,0 +1,185 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package annotations
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+func TestMergeServiceAnnotations(t *testing.T) {
+	annotationBackendProtocol := parser.GetAnnotationWithPrefix("backend-protocol")
+	annotationReadTimeout := parser.GetAnnotationWithPrefix("proxy-read-timeout")
+	annotationSendTimeout := parser.GetAnnotationWithPrefix("proxy-send-timeout")
+
+	svc := &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "svc",
+			Namespace: apiv1.NamespaceDefault,
+			Annotations: map[string]string{
+				annotationBackendProtocol:                         "GRPC",
+				annotationReadTimeout:                             "120",
+				annotationSendTimeout:                             "90",
+				annotationCustomHeaders:                           "default/custom-headers",
+				parser.GetAnnotationWithPrefix("ssl-passthrough"): "true",
+			},
+		},
+	}
+
+	ing := buildIngress()
+	data := map[string]string{}
+	data[annotationSendTimeout] = "30"
+	ing.SetAnnotations(data)
+
+	merged, ok := MergeServiceAnnotations(ing, svc)
+	if !ok {
+		t.Fatalf("expected Service annotations to be merged")
+	}
+
+	expected := map[string]string{
+		annotationBackendProtocol: "GRPC",
+		annotationReadTimeout:     "120",
+		annotationSendTimeout:     "30",
+	}
+	if len(merged.GetAnnotations()) != len(expected) {
+		t.Errorf("expected %v annotations but %v returned", len(expected), len(merged.GetAnnotations()))
+	}
+	for k, v := range expected {
+		if merged.GetAnnotations()[k] != v {
+			t.Errorf("expected %v for annotation %v but %v returned", v, k, merged.GetAnnotations()[k])
+		}
+	}
+
+	if len(ing.GetAnnotations()) != 1 {
+		t.Errorf("expected the original Ingress to be unmodified")
+	}
+
+	ec := NewServiceAnnotationExtractor(mockCfg{})
+	anns, err := ec.Extract(merged)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if anns.BackendProtocol != "GRPC" {
+		t.Errorf("expected GRPC as backend protocol but %v returned", anns.BackendProtocol)
+	}
+	if anns.Proxy.ReadTimeout != 120 || anns.Proxy.SendTimeout != 30 {
+		t.Errorf("unexpected proxy timeouts: %+v", anns.Proxy)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		annotationBackendProtocol: "HTTPS",
+		annotationReadTimeout:     "10",
+		annotationSendTimeout:     "10",
+	})
+	if _, ok := MergeServiceAnnotations(ing, svc); ok {
+		t.Errorf("expected no merge when the Ingress already defines the annotations")
+	}
+
+	if _, ok := MergeServiceAnnotations(ing, nil); ok {
+		t.Errorf("expected no merge without Service")
+	}
+}
+
+func TestMergeServiceProxySSLAnnotations(t *testing.T) {
+	svc := &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "external",
+			Namespace: apiv1.NamespaceDefault,
+			Annotations: map[string]string{
+				parser.GetAnnotationWithPrefix("proxy-ssl-secret"):      "default/external-ca",
+				parser.GetAnnotationWithPrefix("proxy-ssl-verify"):      "on",
+				parser.GetAnnotationWithPrefix("proxy-ssl-name"):        "api.example.com",
+				parser.GetAnnotationWithPrefix("proxy-ssl-server-name"): "on",
+			},
+		},
+		Spec: apiv1.ServiceSpec{
+			Type:         apiv1.ServiceTypeExternalName,
+			ExternalName: "api.example.com",
+		},
+	}
+
+	ing := buildIngress()
+	merged, ok := MergeServiceAnnotations(ing, svc)
+	if !ok {
+		t.Fatalf("expected Service annotations to be merged")
+	}
+
+	ec := NewServiceAnnotationExtractor(mockCfg{
+		MockSecrets: map[string]*apiv1.Secret{
+			"default/external-ca": {},
+		},
+	})
+	anns, err := ec.Extract(merged)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if anns.ProxySSL.Secret != "default/external-ca" || anns.ProxySSL.CAFileName == "" {
+		t.Errorf("expected the CA of the Secret default/external-ca but %+v returned", anns.ProxySSL.AuthSSLCert)
+	}
+	if anns.ProxySSL.Verify != "on" || anns.ProxySSL.ProxySSLName != "api.example.com" || anns.ProxySSL.ProxySSLServerName != "on" {
+		t.Errorf("unexpected proxy SSL configuration: %+v", anns.ProxySSL)
+	}
+}
+
+func TestMergeServiceKeepaliveAnnotations(t *testing.T) {
+	annotationKeepalive := parser.GetAnnotationWithPrefix("upstream-keepalive")
+
+	svc := &apiv1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "legacy",
+			Namespace: apiv1.NamespaceDefault,
+			Annotations: map[string]string{
+				annotationKeepalive: "false",
+			},
+		},
+	}
+
+	ing := buildIngress()
+	merged, ok := MergeServiceAnnotations(ing, svc)
+	if !ok {
+		t.Fatalf("expected Service annotations to be merged")
+	}
+
+	ec := NewServiceAnnotationExtractor(mockCfg{})
+	anns, err := ec.Extract(merged)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !anns.Connection.KeepaliveDisabled {
+		t.Errorf("expected the reuse of the upstream connections to be disabled but %+v returned", anns.Connection)
+	}
+
+	ing.SetAnnotations(map[string]string{
+		annotationKeepalive: "true",
+	})
+	if _, ok := MergeServiceAnnotations(ing, svc); ok {
+		t.Errorf("expected no merge when the Ingress already defines the annotation")
+	}
+
+	anns, err = ec.Extract(ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if anns.Connection.KeepaliveDisabled {
+		t.Errorf("expected the reuse of the upstream connections defined in the Ingress but %+v returned", anns.Connection)
+	}
+}
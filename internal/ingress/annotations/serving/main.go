@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"regexp"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	servingAnnotation = "serving"
+
+	// servingActive serves the requests to the Ingress
+	servingActive = "active"
+	// servingPaused returns a status code of 503 to the requests to the Ingress
+	servingPaused = "paused"
+)
+
+var servingAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		servingAnnotation: {
+			Validator: parser.ValidateRegex(regexp.MustCompile(`^(active|paused)$`), true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation pauses the Ingress when set to 'paused': the requests to its paths return a status code of 503
+			instead of being sent to its services, until the annotation is removed or set to 'active'. By default the Ingress is active.`,
+		},
+	},
+}
+
+type serving struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new serving annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return serving{
+		r:                r,
+		annotationConfig: servingAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress to indicate
+// if the requests to the Ingress are paused
+func (s serving) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(servingAnnotation, ing, s.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return false, nil
+		}
+
+		return false, err
+	}
+
+	return val == servingPaused, nil
+}
+
+func (s serving) GetDocumentation() parser.AnnotationFields {
+	return s.annotationConfig.Annotations
+}
+
+func (s serving) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(s.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, servingAnnotations.Annotations)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package serving
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func buildIngress(annotations map[string]string) *networking.Ingress {
+	data := map[string]string{}
+	for name, value := range annotations {
+		data[parser.GetAnnotationWithPrefix(name)] = value
+	}
+
+	return &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:        "foo",
+			Namespace:   api.NamespaceDefault,
+			Annotations: data,
+		},
+	}
+}
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		title       string
+		annotations map[string]string
+		expected    bool
+		expErr      bool
+	}{
+		{"without the annotation", map[string]string{}, false, false},
+		{"active", map[string]string{servingAnnotation: "active"}, false, false},
+		{"paused", map[string]string{servingAnnotation: "paused"}, true, false},
+		{"invalid value", map[string]string{servingAnnotation: "stopped"}, false, true},
+	}
+
+	for _, tc := range testCases {
+		paused, err := NewParser(&resolver.Mock{}).Parse(buildIngress(tc.annotations))
+		if tc.expErr {
+			if err == nil {
+				t.Errorf("%v: expected an error but returned nil", tc.title)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%v: unexpected error: %v", tc.title, err)
+			continue
+		}
+
+		if paused != tc.expected {
+			t.Errorf("%v: expected %v but returned %v", tc.title, tc.expected, paused)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splithorizon
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/net"
+)
+
+const (
+	splitHorizonInternalCIDRsAnnotation   = "split-horizon-internal-cidrs"
+	splitHorizonInternalBackendAnnotation = "split-horizon-internal-backend"
+)
+
+var splitHorizonAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		splitHorizonInternalCIDRsAnnotation: {
+			Validator: parser.ValidateCIDRs,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium, // The internal clients may be served a richer API
+			Documentation: `This annotation defines the IPs and networks of the internal clients of the Ingress.
+			The backend receives the header X-Client-Network with the value "internal" or "external".`,
+		},
+		splitHorizonInternalBackendAnnotation: {
+			Validator:     parser.ValidateServiceName,
+			Scope:         parser.AnnotationScopeIngress,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the service of the namespace of the Ingress serving the internal clients instead of the service of the rule.`,
+		},
+	},
+}
+
+// Config describes the internal clients of an Ingress and the service serving them
+type Config struct {
+	// ID identifies the internal clients of the Ingress in the NGINX variables
+	ID string `json:"id,omitempty"`
+	// InternalCIDRs are the sorted IPs and networks of the internal clients
+	InternalCIDRs []string `json:"internalCIDRs,omitempty"`
+	// InternalService is the service serving the internal clients, nil when
+	// all the clients are served by the service of the rule
+	InternalService *apiv1.Service `json:"-"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.ID != c2.ID {
+		return false
+	}
+	if len(c1.InternalCIDRs) != len(c2.InternalCIDRs) {
+		return false
+	}
+	for i := range c1.InternalCIDRs {
+		if c1.InternalCIDRs[i] != c2.InternalCIDRs[i] {
+			return false
+		}
+	}
+	if (c1.InternalService == nil) != (c2.InternalService == nil) {
+		return false
+	}
+	if c1.InternalService != nil && c1.InternalService.UID != c2.InternalService.UID {
+		return false
+	}
+
+	return true
+}
+
+type splitHorizon struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new split-horizon annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return splitHorizon{
+		r:                r,
+		annotationConfig: splitHorizonAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// select the backend of the clients of the internal networks
+func (a splitHorizon) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(splitHorizonInternalCIDRsAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return &Config{}, err
+		}
+		return &Config{}, nil
+	}
+
+	ipnets, ips, err := net.ParseIPNets(strings.Split(val, ",")...)
+	if err != nil {
+		return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("the annotation does not contain valid IP addresses or networks: %v", err))
+	}
+
+	cidrs := make([]string, 0, len(ipnets)+len(ips))
+	for k := range ipnets {
+		cidrs = append(cidrs, k)
+	}
+	for k := range ips {
+		cidrs = append(cidrs, k)
+	}
+	sort.Strings(cidrs)
+
+	config := &Config{
+		ID:            id(ing),
+		InternalCIDRs: cidrs,
+	}
+
+	name, err := parser.GetStringAnnotation(splitHorizonInternalBackendAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return &Config{}, err
+		}
+		return config, nil
+	}
+
+	key := fmt.Sprintf("%v/%v", ing.Namespace, name)
+	svc, err := a.r.GetService(key)
+	if err != nil {
+		return &Config{}, ing_errors.NewLocationDenied(fmt.Sprintf("unexpected error reading service %s: %v", key, err))
+	}
+	config.InternalService = svc
+
+	return config, nil
+}
+
+// id returns the identifier of an Ingress usable in the names of the NGINX variables
+func id(ing *networking.Ingress) string {
+	h := fnv.New64a()
+	h.Write([]byte(ing.Namespace + "/" + ing.Name))
+	return fmt.Sprintf("%x", h.Sum64())
+}
+
+func (a splitHorizon) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a splitHorizon) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, splitHorizonAnnotations.Annotations)
+}
@@ -0,0 +1,118 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package splithorizon
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+type mockService struct {
+	resolver.Mock
+}
+
+func (m mockService) GetService(name string) (*api.Service, error) {
+	if name != "default/internal" {
+		return nil, errors.New("no service")
+	}
+
+	return &api.Service{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "internal",
+			Namespace: api.NamespaceDefault,
+		},
+	}, nil
+}
+
+func TestParse(t *testing.T) {
+	cidrs := parser.GetAnnotationWithPrefix(splitHorizonInternalCIDRsAnnotation)
+	backend := parser.GetAnnotationWithPrefix(splitHorizonInternalBackendAnnotation)
+	ap := NewParser(mockService{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	testCases := []struct {
+		annotations map[string]string
+		cidrs       []string
+		service     string
+		expErr      bool
+	}{
+		{map[string]string{cidrs: "10.0.0.0/8"}, []string{"10.0.0.0/8"}, "", false},
+		{map[string]string{cidrs: "192.168.0.1, 10.0.0.0/8,2001:db8::/32"}, []string{"10.0.0.0/8", "192.168.0.1", "2001:db8::/32"}, "", false},
+		{map[string]string{cidrs: "10.0.0.0/8", backend: "internal"}, []string{"10.0.0.0/8"}, "internal", false},
+		{map[string]string{cidrs: "10.0.0.0/8", backend: "missing"}, nil, "", true},
+		{map[string]string{cidrs: "10.0.0.0/33"}, nil, "", true},
+		{map[string]string{backend: "internal"}, nil, "", false},
+		{map[string]string{}, nil, "", false},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expErr {
+			t.Errorf("expected error %v but %v returned for annotations %v", testCase.expErr, err, testCase.annotations)
+		}
+
+		config, ok := result.(*Config)
+		if !ok {
+			t.Fatalf("expected a Config type but %T returned", result)
+		}
+		if !reflect.DeepEqual(config.InternalCIDRs, testCase.cidrs) {
+			t.Errorf("expected %v but %v returned for annotations %v", testCase.cidrs, config.InternalCIDRs, testCase.annotations)
+		}
+		if len(testCase.cidrs) > 0 && config.ID == "" {
+			t.Errorf("expected an ID for annotations %v", testCase.annotations)
+		}
+
+		service := ""
+		if config.InternalService != nil {
+			service = config.InternalService.Name
+		}
+		if service != testCase.service {
+			t.Errorf("expected the service %q but %q returned for annotations %v", testCase.service, service, testCase.annotations)
+		}
+	}
+}
+
+func TestID(t *testing.T) {
+	foo := &networking.Ingress{ObjectMeta: meta_v1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	bar := &networking.Ingress{ObjectMeta: meta_v1.ObjectMeta{Name: "bar", Namespace: "default"}}
+
+	if id(foo) != id(foo.DeepCopy()) {
+		t.Errorf("expected the same ID for the same Ingress")
+	}
+	if id(foo) == id(bar) {
+		t.Errorf("expected different IDs for different Ingresses")
+	}
+}
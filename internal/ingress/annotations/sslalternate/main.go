@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sslalternate
+
+import (
+	"fmt"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	sslAlternateSecretAnnotation = "ssl-alternate-secret" //#nosec G101
+)
+
+var sslAlternateAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		sslAlternateSecretAnnotation: {
+			Validator: parser.ValidateRegex(parser.BasicCharsRegex, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium, // Medium as it allows a subset of chars
+			Documentation: `This annotation defines the Secret (name or namespace/name) containing an alternate certificate and key served with the certificate of the TLS section.
+			The alternate certificate must use a different key type, like ECDSA for an RSA certificate, and NGINX serves the certificate supported by each client`,
+		},
+	},
+}
+
+type sslAlternate struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new alternate certificate annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return sslAlternate{
+		r:                r,
+		annotationConfig: sslAlternateAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress to return the key
+// (namespace/name) of the Secret containing the alternate certificate
+func (a sslAlternate) Parse(ing *networking.Ingress) (interface{}, error) {
+	secret, err := parser.GetStringAnnotation(sslAlternateSecretAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		return "", err
+	}
+
+	ns, name, found := strings.Cut(secret, "/")
+	if !found {
+		return fmt.Sprintf("%v/%v", ing.Namespace, secret), nil
+	}
+
+	if ns == "" || name == "" || strings.Contains(name, "/") {
+		return "", ing_errors.NewInvalidAnnotationContent(sslAlternateSecretAnnotation, secret)
+	}
+
+	if !a.r.GetSecurityConfiguration().AllowCrossNamespaceResources && ns != ing.Namespace {
+		return "", ing_errors.NewInvalidAnnotationConfiguration(sslAlternateSecretAnnotation, "cross namespace secrets are not supported")
+	}
+
+	return secret, nil
+}
+
+func (a sslAlternate) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a sslAlternate) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, sslAlternateAnnotations.Annotations)
+}
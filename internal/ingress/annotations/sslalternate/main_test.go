@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sslalternate
+
+import (
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	annotation := parser.GetAnnotationWithPrefix(sslAlternateSecretAnnotation)
+
+	testCases := []struct {
+		annotations         map[string]string
+		allowCrossNamespace bool
+		expected            string
+		expectErr           bool
+	}{
+		{map[string]string{annotation: "ecdsa"}, false, "default/ecdsa", false},
+		{map[string]string{annotation: "default/ecdsa"}, false, "default/ecdsa", false},
+		{map[string]string{annotation: "other/ecdsa"}, false, "", true},
+		{map[string]string{annotation: "other/ecdsa"}, true, "other/ecdsa", false},
+		{map[string]string{annotation: "/ecdsa"}, false, "", true},
+		{map[string]string{annotation: "default/ecdsa/extra"}, false, "", true},
+		{map[string]string{annotation: "ecdsa;"}, false, "", true},
+		{map[string]string{}, false, "", true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ap := NewParser(&resolver.Mock{AllowCrossNamespace: testCase.allowCrossNamespace})
+
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Errorf("expected error %v but returned %v, annotations: %s", testCase.expectErr, err, testCase.annotations)
+		}
+		if result != testCase.expected {
+			t.Errorf("expected %v but returned %v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
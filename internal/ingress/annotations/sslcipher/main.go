@@ -17,9 +17,14 @@ limitations under the License.
 package sslcipher
 
 import (
+	"fmt"
+	"os/exec"
 	"regexp"
+	"strings"
+	"sync"
 
 	networking "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
@@ -29,12 +34,60 @@ import (
 const (
 	sslPreferServerCipherAnnotation = "ssl-prefer-server-ciphers"
 	sslCipherAnnotation             = "ssl-ciphers"
+	sslProtocolsAnnotation          = "ssl-protocols"
 )
 
 // Should cover something like "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP"
 // (?:@STRENGTH) is included twice so it can appear before or after @SECLEVEL=n
 var regexValidSSLCipher = regexp.MustCompile(`^(?:(?:[A-Za-z0-9!:+\-])*(?:@STRENGTH)*(?:@SECLEVEL=[0-5])*(?:@STRENGTH)*)*$`)
 
+// Should cover something like "TLSv1.2 TLSv1.3"
+var regexValidSSLProtocols = regexp.MustCompile(`^(?:(?:SSLv2|SSLv3|TLSv1|TLSv1\.1|TLSv1\.2|TLSv1\.3)\s*)+$`)
+
+// opensslBinary is the OpenSSL command line tool used to validate the ciphers
+const opensslBinary = "openssl"
+
+// knownCiphers caches if the elements of the ciphers selects any cipher
+var knownCiphers sync.Map
+
+// selectsCiphers checks if an element of a list of ciphers selects any cipher
+// of the OpenSSL library present in the image
+var selectsCiphers = func(element string) bool {
+	if known, ok := knownCiphers.Load(element); ok {
+		return known.(bool)
+	}
+
+	// #nosec G204
+	err := exec.Command(opensslBinary, "ciphers", element).Run()
+	knownCiphers.Store(element, err == nil)
+	return err == nil
+}
+
+// validateCiphers checks that each element of the ciphers adding ciphers to the
+// list is known by OpenSSL. Unknown elements are silently ignored by OpenSSL
+// and the handshakes fail when no cipher of the list is supported.
+func validateCiphers(ciphers string) error {
+	if _, err := exec.LookPath(opensslBinary); err != nil {
+		klog.V(2).InfoS("Skipping the validation of the SSL ciphers", "error", err)
+		return nil
+	}
+
+	for _, element := range strings.Split(ciphers, ":") {
+		element, _, _ = strings.Cut(element, "@")
+		element = strings.TrimSpace(element)
+		// elements removing or moving ciphers cannot break the handshakes
+		if element == "" || strings.ContainsAny(element[:1], "!-+") {
+			continue
+		}
+
+		if !selectsCiphers(element) {
+			return fmt.Errorf("the SSL cipher %q is not supported by OpenSSL", element)
+		}
+	}
+
+	return nil
+}
+
 var sslCipherAnnotations = parser.Annotation{
 	Group: "backend",
 	Annotations: parser.AnnotationFields{
@@ -46,10 +99,17 @@ var sslCipherAnnotations = parser.Annotation{
 			This configuration specifies that server ciphers should be preferred over client ciphers when using the TLS protocols.`,
 		},
 		sslCipherAnnotation: {
-			Validator:     parser.ValidateRegex(regexValidSSLCipher, true),
+			Validator: parser.ValidateRegex(regexValidSSLCipher, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `Using this annotation will set the ssl_ciphers directive at the server level. This configuration is active for all the paths in the host.
+			The ciphers are validated against the OpenSSL library of the controller.`,
+		},
+		sslProtocolsAnnotation: {
+			Validator:     parser.ValidateRegex(regexValidSSLProtocols, false),
 			Scope:         parser.AnnotationScopeIngress,
 			Risk:          parser.AnnotationRiskLow,
-			Documentation: `Using this annotation will set the ssl_ciphers directive at the server level. This configuration is active for all the paths in the host.`,
+			Documentation: `Using this annotation will set the ssl_protocols directive at the server level, like "TLSv1.2 TLSv1.3". This configuration is active for all the paths in the host.`,
 		},
 	},
 }
@@ -63,6 +123,7 @@ type sslCipher struct {
 type Config struct {
 	SSLCiphers             string
 	SSLPreferServerCiphers string
+	SSLProtocols           string
 }
 
 // NewParser creates a new sslCipher annotation parser
@@ -96,6 +157,19 @@ func (sc sslCipher) Parse(ing *networking.Ingress) (interface{}, error) {
 		return config, err
 	}
 
+	if config.SSLCiphers != "" {
+		if err := validateCiphers(config.SSLCiphers); err != nil {
+			return config, errors.ValidationError{
+				Reason: fmt.Errorf("annotation %s contains invalid value: %w", sslCipherAnnotation, err),
+			}
+		}
+	}
+
+	config.SSLProtocols, err = parser.GetStringAnnotation(sslProtocolsAnnotation, ing, sc.annotationConfig.Annotations)
+	if err != nil && !errors.IsInvalidContent(err) && !errors.IsMissingAnnotations(err) {
+		return config, err
+	}
+
 	return config, nil
 }
 
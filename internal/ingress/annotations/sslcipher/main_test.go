@@ -17,6 +17,7 @@ limitations under the License.
 package sslcipher
 
 import (
+	"os/exec"
 	"reflect"
 	"testing"
 
@@ -35,29 +36,41 @@ func TestParse(t *testing.T) {
 
 	annotationSSLCiphers := parser.GetAnnotationWithPrefix(sslCipherAnnotation)
 	annotationSSLPreferServerCiphers := parser.GetAnnotationWithPrefix(sslPreferServerCipherAnnotation)
+	annotationSSLProtocols := parser.GetAnnotationWithPrefix(sslProtocolsAnnotation)
+
+	defer func(f func(string) bool) {
+		selectsCiphers = f
+	}(selectsCiphers)
+	selectsCiphers = func(string) bool {
+		return true
+	}
 
 	testCases := []struct {
 		annotations map[string]string
 		expected    Config
 		expectErr   bool
 	}{
-		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", ""}, false},
-		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56@SECLEVEL=2:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP"}, Config{"ALL:!aNULL:!EXPORT56@SECLEVEL=2:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", ""}, false},
-		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH", ""}, false},
-		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH@SECLEVEL=3"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH@SECLEVEL=3", ""}, false},
-		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA@STRENGTH:+HIGH@SECLEVEL=5:+MEDIUM:+LOW:+SSLv2:+EXP"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA@STRENGTH:+HIGH@SECLEVEL=5:+MEDIUM:+LOW:+SSLv2:+EXP", ""}, false},
+		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", "", ""}, false},
+		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56@SECLEVEL=2:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP"}, Config{"ALL:!aNULL:!EXPORT56@SECLEVEL=2:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", "", ""}, false},
+		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH", "", ""}, false},
+		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH@SECLEVEL=3"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP@STRENGTH@SECLEVEL=3", "", ""}, false},
+		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA@STRENGTH:+HIGH@SECLEVEL=5:+MEDIUM:+LOW:+SSLv2:+EXP"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA@STRENGTH:+HIGH@SECLEVEL=5:+MEDIUM:+LOW:+SSLv2:+EXP", "", ""}, false},
 		{
 			map[string]string{annotationSSLCiphers: "ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256"},
-			Config{"ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256", ""},
+			Config{"ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-CHACHA20-POLY1305:ECDHE-RSA-CHACHA20-POLY1305:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256:ECDHE-ECDSA-AES256-SHA384:ECDHE-RSA-AES256-SHA384:ECDHE-ECDSA-AES128-SHA256:ECDHE-RSA-AES128-SHA256", "", ""},
 			false,
 		},
-		{map[string]string{annotationSSLCiphers: ""}, Config{"", ""}, false},
-		{map[string]string{annotationSSLPreferServerCiphers: "true"}, Config{"", "on"}, false},
-		{map[string]string{annotationSSLPreferServerCiphers: "false"}, Config{"", "off"}, false},
-		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", annotationSSLPreferServerCiphers: "true"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", "on"}, false},
-		{map[string]string{annotationSSLCiphers: "ALL:SOMETHING:;locationXPTO"}, Config{"", ""}, true},
-		{map[string]string{}, Config{"", ""}, false},
-		{nil, Config{"", ""}, false},
+		{map[string]string{annotationSSLCiphers: ""}, Config{"", "", ""}, false},
+		{map[string]string{annotationSSLPreferServerCiphers: "true"}, Config{"", "on", ""}, false},
+		{map[string]string{annotationSSLPreferServerCiphers: "false"}, Config{"", "off", ""}, false},
+		{map[string]string{annotationSSLCiphers: "ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", annotationSSLPreferServerCiphers: "true"}, Config{"ALL:!aNULL:!EXPORT56:RC4+RSA:+HIGH:+MEDIUM:+LOW:+SSLv2:+EXP", "on", ""}, false},
+		{map[string]string{annotationSSLCiphers: "ALL:SOMETHING:;locationXPTO"}, Config{"", "", ""}, true},
+		{map[string]string{annotationSSLProtocols: "TLSv1.2 TLSv1.3"}, Config{"", "", "TLSv1.2 TLSv1.3"}, false},
+		{map[string]string{annotationSSLProtocols: "TLSv1.3"}, Config{"", "", "TLSv1.3"}, false},
+		{map[string]string{annotationSSLProtocols: "TLSv1.4"}, Config{"", "", ""}, true},
+		{map[string]string{annotationSSLProtocols: "TLSv1.3; return 200"}, Config{"", "", ""}, true},
+		{map[string]string{}, Config{"", "", ""}, false},
+		{nil, Config{"", "", ""}, false},
 	}
 
 	ing := &networking.Ingress{
@@ -79,3 +92,32 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateCiphers(t *testing.T) {
+	if _, err := exec.LookPath(opensslBinary); err != nil {
+		t.Skipf("%v is not available", opensslBinary)
+	}
+
+	defer func(f func(string) bool) {
+		selectsCiphers = f
+	}(selectsCiphers)
+	selectsCiphers = func(element string) bool {
+		return element != "UNKNOWN-CIPHER"
+	}
+
+	testCases := []struct {
+		ciphers   string
+		expectErr bool
+	}{
+		{"ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256", false},
+		{"HIGH:!aNULL:!UNKNOWN-CIPHER:+MEDIUM@STRENGTH", false},
+		{"ECDHE-RSA-AES128-GCM-SHA256:UNKNOWN-CIPHER", true},
+		{"UNKNOWN-CIPHER@SECLEVEL=2", true},
+	}
+
+	for _, testCase := range testCases {
+		if err := validateCiphers(testCase.ciphers); (err != nil) != testCase.expectErr {
+			t.Errorf("expected error: %t but got error: %v for ciphers %v", testCase.expectErr, err, testCase.ciphers)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package trustedproxies
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ing_errors "k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+	"k8s.io/ingress-nginx/internal/net"
+)
+
+const trustedProxiesAnnotation = "trusted-proxies"
+
+var trustedProxiesAnnotations = parser.Annotation{
+	Group: "forwarded-headers",
+	Annotations: parser.AnnotationFields{
+		trustedProxiesAnnotation: {
+			Validator: parser.ValidateCIDRs,
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium, // Trusting the wrong networks allows spoofing the client addresses
+			Documentation: `This annotation defines the IPs and networks of the proxies whose Forwarded and X-Forwarded-* headers are honored
+			by the hosts of the Ingress, overriding the trusted-proxies ConfigMap key. The headers sent by other clients are replaced.`,
+		},
+	},
+}
+
+type trustedProxies struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new trusted proxies annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return trustedProxies{
+		r:                r,
+		annotationConfig: trustedProxiesAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule used to
+// define the sorted IPs and networks of the trusted proxies
+// e.g. `10.0.0.0/8,192.168.0.1`
+func (a trustedProxies) Parse(ing *networking.Ingress) (interface{}, error) {
+	val, err := parser.GetStringAnnotation(trustedProxiesAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if ing_errors.IsValidationError(err) {
+			return []string{}, err
+		}
+		return []string{}, nil
+	}
+
+	ipnets, ips, err := net.ParseIPNets(strings.Split(val, ",")...)
+	if err != nil {
+		return []string{}, ing_errors.NewLocationDenied(fmt.Sprintf("the annotation does not contain valid IP addresses or networks: %v", err))
+	}
+
+	cidrs := make([]string, 0, len(ipnets)+len(ips))
+	for k := range ipnets {
+		cidrs = append(cidrs, k)
+	}
+	for k := range ips {
+		cidrs = append(cidrs, k)
+	}
+	sort.Strings(cidrs)
+
+	return cidrs, nil
+}
+
+func (a trustedProxies) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a trustedProxies) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, trustedProxiesAnnotations.Annotations)
+}
@@ -30,10 +30,12 @@ const (
 	upstreamHashByAnnotation       = "upstream-hash-by"
 	upstreamHashBySubsetAnnotation = "upstream-hash-by-subset"
 	upstreamHashBySubsetSize       = "upstream-hash-by-subset-size"
+	upstreamHashByBalanceFactor    = "upstream-hash-by-balance-factor"
+	upstreamHashByReplication      = "upstream-hash-by-replication-factor"
 )
 
 var (
-	specialChars = regexp.QuoteMeta("_${}")
+	specialChars = regexp.QuoteMeta("_${},")
 	hashByRegex  = regexp.MustCompilePOSIX(`^[A-Za-z0-9\-` + specialChars + `]*$`)
 )
 
@@ -45,7 +47,8 @@ var upstreamHashByAnnotations = parser.Annotation{
 			Scope:     parser.AnnotationScopeLocation,
 			Risk:      parser.AnnotationRiskHigh, // High, this annotation allows accessing NGINX variables
 			Documentation: `This annotation defines the nginx variable, text value or any combination thereof to use for consistent hashing. 
-			For example: nginx.ingress.kubernetes.io/upstream-hash-by: "$request_uri" or nginx.ingress.kubernetes.io/upstream-hash-by: "$request_uri$host" or nginx.ingress.kubernetes.io/upstream-hash-by: "${request_uri}-text-value" to consistently hash upstream requests by the current request URI.`,
+			For example: nginx.ingress.kubernetes.io/upstream-hash-by: "$request_uri" or nginx.ingress.kubernetes.io/upstream-hash-by: "$request_uri$host" or nginx.ingress.kubernetes.io/upstream-hash-by: "${request_uri}-text-value" to consistently hash upstream requests by the current request URI.
+			Several keys can be separated by commas, for example "$remote_addr,$http_x_tenant" hashes by the client address and a header.`,
 		},
 		upstreamHashBySubsetAnnotation: {
 			Validator:     parser.ValidateBool,
@@ -59,6 +62,19 @@ var upstreamHashByAnnotations = parser.Annotation{
 			Risk:          parser.AnnotationRiskLow,
 			Documentation: `This annotation determines the size of each subset (default 3)`,
 		},
+		upstreamHashByBalanceFactor: {
+			Validator: parser.ValidateInt,
+			Scope:     parser.AnnotationScopeLocation,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation bounds the load of the endpoints selected by consistent hashing, in percent of the average requests in progress, at least 100.
+			The requests of an endpoint above the bound go to the next endpoint of the ring. Disabled by default.`,
+		},
+		upstreamHashByReplication: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeLocation,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation multiplies the number of points of each endpoint on the consistent hashing ring, spreading the keys more evenly (default 1)`,
+		},
 	},
 }
 
@@ -69,9 +85,11 @@ type upstreamhashby struct {
 
 // Config contains the Consistent hash configuration to be used in the Ingress
 type Config struct {
-	UpstreamHashBy           string `json:"upstream-hash-by,omitempty"`
-	UpstreamHashBySubset     bool   `json:"upstream-hash-by-subset,omitempty"`
-	UpstreamHashBySubsetSize int    `json:"upstream-hash-by-subset-size,omitempty"`
+	UpstreamHashBy                  string `json:"upstream-hash-by,omitempty"`
+	UpstreamHashBySubset            bool   `json:"upstream-hash-by-subset,omitempty"`
+	UpstreamHashBySubsetSize        int    `json:"upstream-hash-by-subset-size,omitempty"`
+	UpstreamHashByBalanceFactor     int    `json:"upstream-hash-by-balance-factor,omitempty"`
+	UpstreamHashByReplicationFactor int    `json:"upstream-hash-by-replication-factor,omitempty"`
 }
 
 // NewParser creates a new UpstreamHashBy annotation parser
@@ -101,7 +119,32 @@ func (a upstreamhashby) Parse(ing *networking.Ingress) (interface{}, error) {
 		upstreamHashbySubsetSize = 3
 	}
 
-	return &Config{upstreamHashBy, upstreamHashBySubset, upstreamHashbySubsetSize}, nil
+	balanceFactor, err := parser.GetIntAnnotation(upstreamHashByBalanceFactor, ing, a.annotationConfig.Annotations)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if balanceFactor != 0 && balanceFactor < 100 {
+		return nil, errors.NewInvalidAnnotationContent(upstreamHashByBalanceFactor, balanceFactor)
+	}
+
+	replicationFactor, err := parser.GetIntAnnotation(upstreamHashByReplication, ing, a.annotationConfig.Annotations)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		return nil, err
+	}
+	if replicationFactor < 0 {
+		return nil, errors.NewInvalidAnnotationContent(upstreamHashByReplication, replicationFactor)
+	}
+	if replicationFactor == 0 {
+		replicationFactor = 1
+	}
+
+	return &Config{
+		UpstreamHashBy:                  upstreamHashBy,
+		UpstreamHashBySubset:            upstreamHashBySubset,
+		UpstreamHashBySubsetSize:        upstreamHashbySubsetSize,
+		UpstreamHashByBalanceFactor:     balanceFactor,
+		UpstreamHashByReplicationFactor: replicationFactor,
+	}, nil
 }
 
 func (a upstreamhashby) GetDocumentation() parser.AnnotationFields {
@@ -17,6 +17,7 @@ limitations under the License.
 package upstreamhashby
 
 import (
+	"reflect"
 	"testing"
 
 	api "k8s.io/api/core/v1"
@@ -43,6 +44,7 @@ func TestParse(t *testing.T) {
 		{map[string]string{annotation: "$request_uri$scheme"}, "$request_uri$scheme", false},
 		{map[string]string{annotation: "xpto;[]"}, "", true},
 		{map[string]string{annotation: "lalal${scheme_test}"}, "lalal${scheme_test}", false},
+		{map[string]string{annotation: "$remote_addr,$http_x_tenant"}, "$remote_addr,$http_x_tenant", false},
 		{map[string]string{annotation: "false"}, "false", false},
 		{map[string]string{}, "", false},
 		{nil, "", false},
@@ -74,3 +76,44 @@ func TestParse(t *testing.T) {
 		}
 	}
 }
+
+func TestParseBoundedLoad(t *testing.T) {
+	balanceFactorAnnotation := parser.GetAnnotationWithPrefix(upstreamHashByBalanceFactor)
+	replicationAnnotation := parser.GetAnnotationWithPrefix(upstreamHashByReplication)
+
+	ap := NewParser(&resolver.Mock{})
+
+	testCases := []struct {
+		annotations map[string]string
+		expected    *Config
+		expectErr   bool
+	}{
+		{nil, &Config{UpstreamHashBySubsetSize: 3, UpstreamHashByReplicationFactor: 1}, false},
+		{
+			map[string]string{balanceFactorAnnotation: "125", replicationAnnotation: "4"},
+			&Config{UpstreamHashBySubsetSize: 3, UpstreamHashByBalanceFactor: 125, UpstreamHashByReplicationFactor: 4},
+			false,
+		},
+		{map[string]string{balanceFactorAnnotation: "50"}, nil, true},
+		{map[string]string{replicationAnnotation: "-1"}, nil, true},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Fatalf("expected error: %t got error: %t err value: %s. %+v", testCase.expectErr, err != nil, err, testCase.annotations)
+		}
+		if !testCase.expectErr && !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %+v but returned %+v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
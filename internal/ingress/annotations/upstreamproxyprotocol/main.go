@@ -0,0 +1,196 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upstreamproxyprotocol
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	upstreamProxyProtocolAnnotation     = "upstream-proxy-protocol"
+	upstreamProxyProtocolTLVsAnnotation = "upstream-proxy-protocol-tlvs"
+
+	// the PROXY protocol header is only sent by the SSL passthrough proxy,
+	// which does not buffer the connections
+	sslPassthroughAnnotation        = "ssl-passthrough"
+	proxyBufferingAnnotation        = "proxy-buffering"
+	proxyRequestBufferingAnnotation = "proxy-request-buffering"
+)
+
+// tlvsRegex matches a comma separated list of TLVs like 0xE0=value, with a
+// type in the range reserved to custom applications
+var tlvsRegex = regexp.MustCompile(`^0[xX][eE][0-9a-fA-F]=[A-Za-z0-9._:/-]{1,255}(,0[xX][eE][0-9a-fA-F]=[A-Za-z0-9._:/-]{1,255})*$`)
+
+var upstreamProxyProtocolAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		upstreamProxyProtocolAnnotation: {
+			Validator: parser.ValidateOptions([]string{"v1", "v2"}, true, true),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation sends a PROXY protocol header with the address of the client to the backend of a SSL passthrough Ingress.
+			It accepts v1 or v2, and requires the annotation ssl-passthrough.`,
+		},
+		upstreamProxyProtocolTLVsAnnotation: {
+			Validator: parser.ValidateRegex(tlvsRegex, false),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskMedium,
+			Documentation: `This annotation appends TLVs to the PROXY protocol v2 header sent to the backend, as a comma separated list of type=value
+			with a type between 0xE0 and 0xEF, like 0xE0=cluster-a,0xE1=zone-b.`,
+		},
+	},
+}
+
+// Config contains the PROXY protocol header sent to the backend
+type Config struct {
+	// Version is the version of the PROXY protocol header, 0 when no
+	// header is sent
+	Version int `json:"version,omitempty"`
+	// TLVs are appended to the version 2 header
+	TLVs []TLV `json:"tlvs,omitempty"`
+}
+
+// TLV is a custom Type-Length-Value vector of the PROXY protocol v2 header
+type TLV struct {
+	Type  byte   `json:"type"`
+	Value string `json:"value"`
+}
+
+// Equal tests for equality between two Config types
+func (c1 *Config) Equal(c2 *Config) bool {
+	if c1 == c2 {
+		return true
+	}
+	if c1 == nil || c2 == nil {
+		return false
+	}
+	if c1.Version != c2.Version {
+		return false
+	}
+	if len(c1.TLVs) != len(c2.TLVs) {
+		return false
+	}
+	for i := range c1.TLVs {
+		if c1.TLVs[i] != c2.TLVs[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+type upstreamProxyProtocol struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new upstream PROXY protocol annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return upstreamProxyProtocol{
+		r:                r,
+		annotationConfig: upstreamProxyProtocolAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to send a PROXY protocol header to the backend
+func (a upstreamProxyProtocol) Parse(ing *networking.Ingress) (interface{}, error) {
+	version, err := parser.GetStringAnnotation(upstreamProxyProtocolAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+
+	anns := ing.GetAnnotations()
+	if anns[parser.GetAnnotationWithPrefix(sslPassthroughAnnotation)] != "true" {
+		return nil, errors.ValidationError{
+			Reason: fmt.Errorf("annotation %v requires the annotation %v", upstreamProxyProtocolAnnotation, sslPassthroughAnnotation),
+		}
+	}
+	for _, buffering := range []string{proxyBufferingAnnotation, proxyRequestBufferingAnnotation} {
+		if anns[parser.GetAnnotationWithPrefix(buffering)] == "on" {
+			return nil, errors.ValidationError{
+				Reason: fmt.Errorf("annotation %v cannot be used with the annotation %v, the connections are not buffered", upstreamProxyProtocolAnnotation, buffering),
+			}
+		}
+	}
+
+	config := &Config{Version: 1}
+	if version == "v2" {
+		config.Version = 2
+	}
+
+	tlvs, err := parser.GetStringAnnotation(upstreamProxyProtocolTLVsAnnotation, ing, a.annotationConfig.Annotations)
+	switch {
+	case err == nil:
+		if config.Version != 2 {
+			return nil, errors.ValidationError{
+				Reason: fmt.Errorf("annotation %v requires the version v2 of the PROXY protocol", upstreamProxyProtocolTLVsAnnotation),
+			}
+		}
+		config.TLVs, err = parseTLVs(tlvs)
+		if err != nil {
+			return nil, errors.ValidationError{
+				Reason: fmt.Errorf("annotation %v contains invalid value: %w", upstreamProxyProtocolTLVsAnnotation, err),
+			}
+		}
+	case !errors.IsMissingAnnotations(err):
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// parseTLVs returns the TLVs of a list validated by tlvsRegex, each type
+// being used once
+func parseTLVs(s string) ([]TLV, error) {
+	var tlvs []TLV
+	seen := map[byte]bool{}
+	for _, field := range strings.Split(s, ",") {
+		typ, value, _ := strings.Cut(field, "=")
+		t, err := strconv.ParseUint(typ[2:], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid type %q", typ)
+		}
+		if seen[byte(t)] {
+			return nil, fmt.Errorf("duplicate type %q", typ)
+		}
+		seen[byte(t)] = true
+		tlvs = append(tlvs, TLV{Type: byte(t), Value: value})
+	}
+	return tlvs, nil
+}
+
+func (a upstreamProxyProtocol) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a upstreamProxyProtocol) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, upstreamProxyProtocolAnnotations.Annotations)
+}
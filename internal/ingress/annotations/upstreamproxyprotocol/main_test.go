@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package upstreamproxyprotocol
+
+import (
+	"reflect"
+	"testing"
+
+	api "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+func TestParse(t *testing.T) {
+	versionAnnotation := parser.GetAnnotationWithPrefix(upstreamProxyProtocolAnnotation)
+	tlvsAnnotation := parser.GetAnnotationWithPrefix(upstreamProxyProtocolTLVsAnnotation)
+	passthroughAnnotation := parser.GetAnnotationWithPrefix(sslPassthroughAnnotation)
+	bufferingAnnotation := parser.GetAnnotationWithPrefix(proxyBufferingAnnotation)
+	requestBufferingAnnotation := parser.GetAnnotationWithPrefix(proxyRequestBufferingAnnotation)
+
+	ap := NewParser(&resolver.Mock{})
+	if ap == nil {
+		t.Fatalf("expected a parser.IngressAnnotation but returned nil")
+	}
+
+	testCases := []struct {
+		annotations      map[string]string
+		expected         *Config
+		expectErr        bool
+		expectValidation bool
+	}{
+		{nil, &Config{}, false, false},
+		{map[string]string{passthroughAnnotation: "true"}, &Config{}, false, false},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v1"}, &Config{Version: 1}, false, false},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v2"}, &Config{Version: 2}, false, false},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v2", bufferingAnnotation: "off"}, &Config{Version: 2}, false, false},
+		{
+			map[string]string{passthroughAnnotation: "true", versionAnnotation: "v2", tlvsAnnotation: "0xE0=cluster-a,0xef=zone.b"},
+			&Config{Version: 2, TLVs: []TLV{{Type: 0xE0, Value: "cluster-a"}, {Type: 0xEF, Value: "zone.b"}}},
+			false, false,
+		},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v3"}, nil, true, false},
+		{map[string]string{versionAnnotation: "v1"}, nil, true, true},
+		{map[string]string{passthroughAnnotation: "false", versionAnnotation: "v1"}, nil, true, true},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v1", bufferingAnnotation: "on"}, nil, true, true},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v2", requestBufferingAnnotation: "on"}, nil, true, true},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v1", tlvsAnnotation: "0xE0=a"}, nil, true, true},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v2", tlvsAnnotation: "0xE0=a,0xe0=b"}, nil, true, true},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v2", tlvsAnnotation: "0x02=example.com"}, nil, true, false},
+		{map[string]string{passthroughAnnotation: "true", versionAnnotation: "v2", tlvsAnnotation: "0xE0=a b"}, nil, true, false},
+	}
+
+	ing := &networking.Ingress{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      "foo",
+			Namespace: api.NamespaceDefault,
+		},
+		Spec: networking.IngressSpec{},
+	}
+
+	for _, testCase := range testCases {
+		ing.SetAnnotations(testCase.annotations)
+		result, err := ap.Parse(ing)
+		if (err != nil) != testCase.expectErr {
+			t.Fatalf("expected error: %t got error: %t err value: %s. %+v", testCase.expectErr, err != nil, err, testCase.annotations)
+		}
+		if testCase.expectValidation && !errors.IsValidationError(err) {
+			t.Errorf("expected a validation error but returned %v, annotations: %s", err, testCase.annotations)
+		}
+		if !testCase.expectErr && !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("expected %+v but returned %+v, annotations: %s", testCase.expected, result, testCase.annotations)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	c1 := &Config{Version: 2, TLVs: []TLV{{Type: 0xE0, Value: "a"}}}
+	c2 := &Config{Version: 2, TLVs: []TLV{{Type: 0xE0, Value: "a"}}}
+	if !c1.Equal(c2) {
+		t.Errorf("expected %+v to be equal to %+v", c1, c2)
+	}
+
+	c2.TLVs[0].Value = "b"
+	if c1.Equal(c2) {
+		t.Errorf("expected %+v to differ from %+v", c1, c2)
+	}
+	if c1.Equal(&Config{Version: 2}) {
+		t.Errorf("expected %+v to differ from a config without TLVs", c1)
+	}
+}
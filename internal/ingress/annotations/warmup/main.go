@@ -0,0 +1,142 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package warmup
+
+import (
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/resolver"
+)
+
+const (
+	warmupPathAnnotation     = "warmup-path"
+	warmupRequestsAnnotation = "warmup-requests"
+	warmupTimeoutAnnotation  = "warmup-timeout"
+)
+
+const (
+	defaultRequests = 1
+	maxRequests     = 100
+	defaultTimeout  = 5
+	maxTimeout      = 60
+)
+
+var warmupAnnotations = parser.Annotation{
+	Group: "backend",
+	Annotations: parser.AnnotationFields{
+		warmupPathAnnotation: {
+			Validator: parser.ValidateRegex(parser.URLIsValidRegex, false),
+			Scope:     parser.AnnotationScopeIngress,
+			Risk:      parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the path, with an optional query string, requested on the new endpoints of the service before they receive traffic.
+			The endpoints are added to the load balancing once the warm-up requests are completed.`,
+		},
+		warmupRequestsAnnotation: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeIngress,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the number of warm-up requests sent to a new endpoint, from 1 to 100. Defaults to 1.`,
+		},
+		warmupTimeoutAnnotation: {
+			Validator:     parser.ValidateInt,
+			Scope:         parser.AnnotationScopeIngress,
+			Risk:          parser.AnnotationRiskLow,
+			Documentation: `This annotation defines the timeout, in seconds, of each warm-up request, from 1 to 60. Defaults to 5.`,
+		},
+	},
+}
+
+// Config describes the requests warming up the new endpoints of a backend
+type Config struct {
+	// Path is the path requested on the new endpoints, empty when the
+	// endpoints are not warmed up
+	Path string `json:"path,omitempty"`
+	// Requests is the number of requests sent to a new endpoint
+	Requests int `json:"requests,omitempty"`
+	// Timeout is the timeout in seconds of each request
+	Timeout int `json:"timeout,omitempty"`
+}
+
+type warmup struct {
+	r                resolver.Resolver
+	annotationConfig parser.Annotation
+}
+
+// NewParser creates a new warm-up annotation parser
+func NewParser(r resolver.Resolver) parser.IngressAnnotation {
+	return warmup{
+		r:                r,
+		annotationConfig: warmupAnnotations,
+	}
+}
+
+// Parse parses the annotations contained in the ingress rule
+// used to warm up the new endpoints of the backends
+func (a warmup) Parse(ing *networking.Ingress) (interface{}, error) {
+	config := &Config{}
+
+	path, err := parser.GetStringAnnotation(warmupPathAnnotation, ing, a.annotationConfig.Annotations)
+	if err != nil {
+		if errors.IsMissingAnnotations(err) {
+			return config, nil
+		}
+		return nil, err
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, errors.NewInvalidAnnotationContent(warmupPathAnnotation, path)
+	}
+
+	config.Path = path
+	config.Requests, err = intAnnotation(warmupRequestsAnnotation, ing, a.annotationConfig.Annotations, defaultRequests, maxRequests)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout, err = intAnnotation(warmupTimeoutAnnotation, ing, a.annotationConfig.Annotations, defaultTimeout, maxTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// intAnnotation returns the value of an annotation from 1 to maxValue, or
+// the default value when the annotation is missing
+func intAnnotation(name string, ing *networking.Ingress, fields parser.AnnotationFields, defaultValue, maxValue int) (int, error) {
+	value, err := parser.GetIntAnnotation(name, ing, fields)
+	switch {
+	case errors.IsMissingAnnotations(err):
+		return defaultValue, nil
+	case err != nil:
+		return 0, err
+	case value < 1 || value > maxValue:
+		return 0, errors.NewInvalidAnnotationContent(name, value)
+	}
+	return value, nil
+}
+
+func (a warmup) GetDocumentation() parser.AnnotationFields {
+	return a.annotationConfig.Annotations
+}
+
+func (a warmup) Validate(anns map[string]string) error {
+	maxrisk := parser.StringRiskToRisk(a.r.GetSecurityConfiguration().AnnotationsRiskLevel)
+	return parser.CheckAnnotationRisk(anns, maxrisk, warmupAnnotations.Annotations)
+}
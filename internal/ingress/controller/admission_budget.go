@@ -0,0 +1,113 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// fullValidationInterval is the interval after which the admission webhook
+// measures again the duration of the full validation, once the budget of
+// the rendering was exceeded
+const fullValidationInterval = 10 * time.Minute
+
+// admissionBudget selects the incremental validation of the Ingresses in the
+// admission webhook when the full validation is too slow
+type admissionBudget struct {
+	mu sync.Mutex
+
+	// maxRender is the duration of the full validation above which the
+	// Ingresses are validated incrementally. 0 disables the budget.
+	maxRender time.Duration
+	// maxIngresses is the number of Ingresses above which the Ingresses are
+	// validated incrementally. 0 disables the budget.
+	maxIngresses int
+
+	lastFullValidation         time.Time
+	lastFullValidationDuration time.Duration
+}
+
+// incremental returns true when the checked Ingress must be validated only
+// with the Ingresses sharing its servers
+func (b *admissionBudget) incremental(ingresses int, now time.Time) bool {
+	if b.maxIngresses > 0 && ingresses > b.maxIngresses {
+		return true
+	}
+
+	if b.maxRender == 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.lastFullValidationDuration > b.maxRender &&
+		now.Sub(b.lastFullValidation) < fullValidationInterval
+}
+
+// recordFullValidation records the duration of a full validation
+func (b *admissionBudget) recordFullValidation(start time.Time, duration time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.lastFullValidation = start
+	b.lastFullValidationDuration = duration
+}
+
+// ingressHosts returns the names of the servers an Ingress adds locations to
+func ingressHosts(ing *ingress.Ingress) sets.Set[string] {
+	hosts := sets.New[string]()
+	if ing.Spec.DefaultBackend != nil {
+		hosts.Insert(defServerName)
+	}
+
+	for i := range ing.Spec.Rules {
+		host := ing.Spec.Rules[i].Host
+		if host == "" {
+			host = defServerName
+		}
+		hosts.Insert(host)
+	}
+
+	if ing.ParsedAnnotations != nil {
+		hosts.Insert(ing.ParsedAnnotations.Aliases...)
+	}
+
+	return hosts
+}
+
+// affectedIngresses returns the Ingresses sharing a server with the checked
+// Ingress, the last one of the list, so that only the affected servers are
+// rendered
+func affectedIngresses(ings []*ingress.Ingress) []*ingress.Ingress {
+	checked := ings[len(ings)-1]
+	hosts := ingressHosts(checked).UnsortedList()
+
+	affected := []*ingress.Ingress{}
+	for _, ing := range ings[:len(ings)-1] {
+		if ingressHosts(ing).HasAny(hosts...) {
+			affected = append(affected, ing)
+		}
+	}
+
+	return append(affected, checked)
+}
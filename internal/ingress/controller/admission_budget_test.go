@@ -0,0 +1,98 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestAdmissionBudgetIncremental(t *testing.T) {
+	now := time.Now()
+
+	budget := &admissionBudget{}
+	if budget.incremental(10000, now) {
+		t.Errorf("expected a full validation without a budget")
+	}
+
+	budget = &admissionBudget{maxIngresses: 100}
+	if budget.incremental(100, now) {
+		t.Errorf("expected a full validation below the maximum number of ingresses")
+	}
+	if !budget.incremental(101, now) {
+		t.Errorf("expected an incremental validation above the maximum number of ingresses")
+	}
+
+	budget = &admissionBudget{maxRender: time.Second}
+	budget.recordFullValidation(now, 500*time.Millisecond)
+	if budget.incremental(10000, now) {
+		t.Errorf("expected a full validation within the render budget")
+	}
+
+	budget.recordFullValidation(now, 2*time.Second)
+	if !budget.incremental(10000, now.Add(time.Minute)) {
+		t.Errorf("expected an incremental validation after exceeding the render budget")
+	}
+	if budget.incremental(10000, now.Add(fullValidationInterval)) {
+		t.Errorf("expected a full validation after %v", fullValidationInterval)
+	}
+}
+
+func TestAffectedIngresses(t *testing.T) {
+	newIngress := func(name string, hosts []string, aliases ...string) *ingress.Ingress {
+		ing := &ingress.Ingress{
+			Ingress: networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			},
+			ParsedAnnotations: &annotations.Ingress{Aliases: aliases},
+		}
+		for _, host := range hosts {
+			ing.Spec.Rules = append(ing.Spec.Rules, networking.IngressRule{Host: host})
+		}
+		return ing
+	}
+
+	sameHost := newIngress("same-host", []string{"example.com"})
+	otherHost := newIngress("other-host", []string{"other.com"})
+	alias := newIngress("alias", []string{"alias.com"}, "www.example.com")
+	catchAll := newIngress("catch-all", []string{""})
+	checked := newIngress("checked", []string{"example.com", "www.example.com"})
+
+	affected := affectedIngresses([]*ingress.Ingress{sameHost, otherHost, alias, catchAll, checked})
+
+	expected := []string{"same-host", "alias", "checked"}
+	if len(affected) != len(expected) {
+		t.Fatalf("expected %v affected ingresses but returned %v", len(expected), len(affected))
+	}
+	for i, name := range expected {
+		if affected[i].Name != name {
+			t.Errorf("expected ingress %v at index %v but returned %v", name, i, affected[i].Name)
+		}
+	}
+
+	defaultBackend := newIngress("default-backend", nil)
+	defaultBackend.Spec.DefaultBackend = &networking.IngressBackend{}
+	if affected := affectedIngresses([]*ingress.Ingress{catchAll, sameHost, defaultBackend}); len(affected) != 2 || affected[0] != catchAll {
+		t.Errorf("expected the catch-all ingress to share the default server")
+	}
+}
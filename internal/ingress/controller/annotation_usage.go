@@ -0,0 +1,179 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/deprecation"
+	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// AnnotationUsagePath is the path of the endpoint returning the usage of
+// the annotations, served in the status port
+const AnnotationUsagePath = "/annotations/usage"
+
+// annotationUsagePeriod is the interval between the aggregations of the
+// usage of the annotations
+const annotationUsagePeriod = 1 * time.Minute
+
+// annotationInfo describes a known annotation
+type annotationInfo struct {
+	group string
+	risk  string
+	// alias is the name of the annotation when this is one of its aliases
+	alias string
+}
+
+// knownAnnotations returns the annotations parsed by the controller, and
+// their aliases, without the prefix
+var knownAnnotations = sync.OnceValue(func() map[string]annotationInfo {
+	known := map[string]annotationInfo{}
+	for group, annotationParser := range annotations.NewAnnotationFactory(nil) {
+		for name, config := range annotationParser.GetDocumentation() {
+			known[name] = annotationInfo{group: group, risk: config.Risk.ToString()}
+			for _, alias := range config.AnnotationAliases {
+				known[alias] = annotationInfo{group: group, risk: config.Risk.ToString(), alias: name}
+			}
+		}
+	}
+
+	return known
+})
+
+// AnnotationUsage is the usage of an annotation in the Ingresses
+type AnnotationUsage struct {
+	// Name is the name of the annotation without the prefix
+	Name string `json:"name"`
+	// Group is the feature the annotation belongs to
+	Group string `json:"group,omitempty"`
+	// Risk is the risk level of the annotation, like Critical for the snippets
+	Risk string `json:"risk,omitempty"`
+	// AliasOf is the name of the annotation when this is one of its aliases
+	AliasOf    string `json:"aliasOf,omitempty"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	// Unknown is set for the annotations with the prefix not parsed by the controller
+	Unknown bool `json:"unknown,omitempty"`
+	// Ingresses is the number of Ingresses using the annotation
+	Ingresses int `json:"ingresses"`
+}
+
+// AnnotationUsageReport is the document returned by the annotation usage endpoint
+type AnnotationUsageReport struct {
+	// Time is the time of the aggregation
+	Time time.Time `json:"time"`
+	// Ingresses is the number of Ingresses handled by the controller
+	Ingresses   int               `json:"ingresses"`
+	Annotations []AnnotationUsage `json:"annotations"`
+}
+
+// annotationUsage aggregates the usage of the annotations with the prefix in
+// the Ingresses, sorted by name
+func annotationUsage(ings []*ingress.Ingress) []AnnotationUsage {
+	counts := map[string]int{}
+	for _, ing := range ings {
+		for key := range ing.GetAnnotations() {
+			if !strings.HasPrefix(key, parser.AnnotationsPrefix+"/") {
+				continue
+			}
+
+			counts[parser.TrimAnnotationPrefix(key)]++
+		}
+	}
+
+	known := knownAnnotations()
+	usage := make([]AnnotationUsage, 0, len(counts))
+	for name, count := range counts {
+		info, ok := known[name]
+		deprecated := deprecation.IsDeprecated(deprecation.Annotation, name)
+		usage = append(usage, AnnotationUsage{
+			Name:       name,
+			Group:      info.group,
+			Risk:       info.risk,
+			AliasOf:    info.alias,
+			Deprecated: deprecated,
+			Unknown:    !ok && !deprecated,
+			Ingresses:  count,
+		})
+	}
+
+	sort.Slice(usage, func(i, j int) bool {
+		return usage[i].Name < usage[j].Name
+	})
+
+	return usage
+}
+
+// aggregateAnnotationUsage updates the usage of the annotations reported in
+// the metrics and the annotation usage endpoint. The unknown annotations
+// are only reported in the endpoint to bound the cardinality of the metrics.
+func (n *NGINXController) aggregateAnnotationUsage() {
+	ings := n.store.ListIngresses()
+	report := &AnnotationUsageReport{
+		Time:        time.Now(),
+		Ingresses:   len(ings),
+		Annotations: annotationUsage(ings),
+	}
+
+	metrics := make([]collectors.AnnotationUsage, 0, len(report.Annotations))
+	for _, u := range report.Annotations {
+		if u.Unknown {
+			continue
+		}
+
+		metrics = append(metrics, collectors.AnnotationUsage{
+			Annotation: u.Name,
+			Group:      u.Group,
+			Risk:       u.Risk,
+			Deprecated: u.Deprecated,
+			Ingresses:  u.Ingresses,
+		})
+	}
+	n.metricCollector.SetAnnotationUsage(metrics)
+
+	n.annotationUsageLock.Lock()
+	defer n.annotationUsageLock.Unlock()
+
+	n.annotationUsageReport = report
+}
+
+// AnnotationUsageHandler returns the usage of the annotations in the
+// Ingresses of the last aggregation
+func (n *NGINXController) AnnotationUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	n.annotationUsageLock.RLock()
+	report := n.annotationUsageReport
+	n.annotationUsageLock.RUnlock()
+
+	if report == nil {
+		http.Error(w, "the usage of the annotations is not aggregated yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	writeJSON(w, report)
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func newIngressWithAnnotations(name string, anns map[string]string) *ingress.Ingress {
+	return &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Namespace:   "default",
+				Annotations: anns,
+			},
+		},
+	}
+}
+
+func TestAnnotationUsage(t *testing.T) {
+	ings := []*ingress.Ingress{
+		newIngressWithAnnotations("one", map[string]string{
+			"nginx.ingress.kubernetes.io/configuration-snippet": "more_set_headers \"foo: bar\";",
+			"nginx.ingress.kubernetes.io/rewrite-target":        "/",
+			"kubernetes.io/ingress.class":                       "nginx",
+		}),
+		newIngressWithAnnotations("two", map[string]string{
+			"nginx.ingress.kubernetes.io/configuration-snippet":  "return 200;",
+			"nginx.ingress.kubernetes.io/whitelist-source-range": "10.0.0.0/8",
+			"nginx.ingress.kubernetes.io/enable-influxdb":        "true",
+			"nginx.ingress.kubernetes.io/not-an-annotation":      "true",
+		}),
+		newIngressWithAnnotations("three", nil),
+	}
+
+	expected := []AnnotationUsage{
+		{Name: "configuration-snippet", Group: "ConfigurationSnippet", Risk: "Critical", Ingresses: 2},
+		{Name: "enable-influxdb", Deprecated: true, Ingresses: 1},
+		{Name: "not-an-annotation", Unknown: true, Ingresses: 1},
+		{Name: "rewrite-target", Group: "Rewrite", Risk: "Medium", Ingresses: 1},
+		{Name: "whitelist-source-range", Group: "Allowlist", Risk: "Medium", AliasOf: "allowlist-source-range", Ingresses: 1},
+	}
+
+	if usage := annotationUsage(ings); !reflect.DeepEqual(usage, expected) {
+		t.Errorf("expected %+v but returned %+v", expected, usage)
+	}
+}
+
+func TestAnnotationUsageHandler(t *testing.T) {
+	n := &NGINXController{}
+
+	w := httptest.NewRecorder()
+	n.AnnotationUsageHandler(w, httptest.NewRequest(http.MethodGet, AnnotationUsagePath, http.NoBody))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status %v before the first aggregation but returned %v", http.StatusServiceUnavailable, w.Code)
+	}
+
+	n.annotationUsageReport = &AnnotationUsageReport{
+		Ingresses:   1,
+		Annotations: []AnnotationUsage{{Name: "rewrite-target", Group: "Rewrite", Risk: "Medium", Ingresses: 1}},
+	}
+
+	w = httptest.NewRecorder()
+	n.AnnotationUsageHandler(w, httptest.NewRequest(http.MethodGet, AnnotationUsagePath, http.NoBody))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status %v but returned %v", http.StatusOK, w.Code)
+	}
+
+	var report AnnotationUsageReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("unexpected error decoding %v: %v", w.Body.String(), err)
+	}
+	if !reflect.DeepEqual(report.Annotations, n.annotationUsageReport.Annotations) {
+		t.Errorf("expected %+v but returned %+v", n.annotationUsageReport.Annotations, report.Annotations)
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"slices"
+
+	apiv1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// handlesIngress checks if the Ingress belongs to the IngressClass and the
+// namespaces watched by the controller
+func (n *NGINXController) handlesIngress(ing *networking.Ingress) bool {
+	if ingressClass, _ := n.store.GetIngressClass(ing, n.cfg.IngressClassConfiguration); ingressClass == "" {
+		return false
+	}
+
+	namespaces := k8s.ParseNamespaces(n.cfg.Namespace)
+	return len(namespaces) == 0 || slices.Contains(namespaces, ing.Namespace)
+}
+
+// backendServices returns the Service backends referenced by the Ingress,
+// without duplicates
+func backendServices(ing *networking.Ingress) []*networking.IngressServiceBackend {
+	backends := []*networking.IngressServiceBackend{}
+	add := func(backend *networking.IngressBackend) {
+		if backend == nil || backend.Service == nil {
+			return
+		}
+
+		for _, b := range backends {
+			if b.Name == backend.Service.Name && b.Port == backend.Service.Port {
+				return
+			}
+		}
+
+		backends = append(backends, backend.Service)
+	}
+
+	add(ing.Spec.DefaultBackend)
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+
+		for i := range rule.HTTP.Paths {
+			add(&rule.HTTP.Paths[i].Backend)
+		}
+	}
+
+	return backends
+}
+
+// unreachableBackends returns the reasons why the Service backends of the
+// Ingress cannot serve requests: a missing Service, a missing port, or no
+// ready endpoint
+func (n *NGINXController) unreachableBackends(ing *networking.Ingress) []string {
+	reasons := []string{}
+	for _, backend := range backendServices(ing) {
+		key := fmt.Sprintf("%v/%v", ing.Namespace, backend.Name)
+
+		svc, err := n.store.GetService(key)
+		if err != nil {
+			reasons = append(reasons, fmt.Sprintf("the backend service %v does not exist", key))
+			continue
+		}
+
+		// the endpoints of ExternalName services are resolved by NGINX
+		if svc.Spec.Type == apiv1.ServiceTypeExternalName {
+			continue
+		}
+
+		if !serviceHasPort(svc, backend.Port) {
+			reasons = append(reasons, fmt.Sprintf("the backend service %v does not have the port %v", key, servicePortString(backend.Port)))
+			continue
+		}
+
+		endpointSlices, err := n.store.GetServiceEndpointsSlices(key)
+		if err != nil || !hasReadyEndpoint(endpointSlices) {
+			reasons = append(reasons, fmt.Sprintf("the backend service %v does not have a ready endpoint", key))
+		}
+	}
+
+	return reasons
+}
+
+// serviceHasPort checks if the Service defines the port of a backend, by name or number
+func serviceHasPort(svc *apiv1.Service, port networking.ServiceBackendPort) bool {
+	for _, p := range svc.Spec.Ports {
+		if (port.Name != "" && p.Name == port.Name) || (port.Name == "" && p.Port == port.Number) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func servicePortString(port networking.ServiceBackendPort) string {
+	if port.Name != "" {
+		return port.Name
+	}
+
+	return fmt.Sprintf("%v", port.Number)
+}
+
+// hasReadyEndpoint checks if an EndpointSlice contains a ready endpoint
+func hasReadyEndpoint(endpointSlices []*discoveryv1.EndpointSlice) bool {
+	for _, eps := range endpointSlices {
+		for _, ep := range eps.Endpoints {
+			// a nil ready condition means the state is unknown and should be interpreted as ready
+			if ep.Conditions.Ready == nil || *ep.Conditions.Ready {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,175 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
+)
+
+type fakeBackendStore struct {
+	*fakeIngressStore
+	services       map[string]*corev1.Service
+	endpointSlices map[string][]*discoveryv1.EndpointSlice
+}
+
+func (fbs *fakeBackendStore) GetService(key string) (*corev1.Service, error) {
+	svc, ok := fbs.services[key]
+	if !ok {
+		return nil, fmt.Errorf("service %v not found", key)
+	}
+	return svc, nil
+}
+
+func (fbs *fakeBackendStore) GetServiceEndpointsSlices(key string) ([]*discoveryv1.EndpointSlice, error) {
+	return fbs.endpointSlices[key], nil
+}
+
+func newBackendCheckController(policy string) *NGINXController {
+	ready, notReady := true, false
+	newService := func(name string, svcType corev1.ServiceType) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: corev1.ServiceSpec{
+				Type:  svcType,
+				Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+			},
+		}
+	}
+
+	return &NGINXController{
+		cfg: &Configuration{},
+		store: &fakeBackendStore{
+			fakeIngressStore: &fakeIngressStore{
+				configuration: ngx_config.Configuration{AdmissionBackendCheck: policy},
+			},
+			services: map[string]*corev1.Service{
+				"default/ready":     newService("ready", corev1.ServiceTypeClusterIP),
+				"default/not-ready": newService("not-ready", corev1.ServiceTypeClusterIP),
+				"default/external":  newService("external", corev1.ServiceTypeExternalName),
+			},
+			endpointSlices: map[string][]*discoveryv1.EndpointSlice{
+				"default/ready": {{Endpoints: []discoveryv1.Endpoint{
+					{Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+					{Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+				}}},
+				"default/not-ready": {{Endpoints: []discoveryv1.Endpoint{
+					{Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+				}}},
+			},
+		},
+		metricCollector: metric.DummyCollector{},
+	}
+}
+
+func newBackendCheckIngress(backends ...networking.IngressServiceBackend) *networking.Ingress {
+	paths := []networking.HTTPIngressPath{}
+	for i := range backends {
+		paths = append(paths, networking.HTTPIngressPath{
+			Path:     fmt.Sprintf("/%v", i),
+			PathType: &pathTypePrefix,
+			Backend:  networking.IngressBackend{Service: &backends[i]},
+		})
+	}
+
+	return &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{Name: "backend-check", Namespace: "default"},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{{
+				Host: "example.com",
+				IngressRuleValue: networking.IngressRuleValue{
+					HTTP: &networking.HTTPIngressRuleValue{Paths: paths},
+				},
+			}},
+		},
+	}
+}
+
+func TestUnreachableBackends(t *testing.T) {
+	n := newBackendCheckController(ngx_config.AdmissionBackendCheckWarn)
+
+	ing := newBackendCheckIngress(
+		networking.IngressServiceBackend{Name: "ready", Port: networking.ServiceBackendPort{Name: "http"}},
+		networking.IngressServiceBackend{Name: "ready", Port: networking.ServiceBackendPort{Number: 80}},
+		networking.IngressServiceBackend{Name: "ready", Port: networking.ServiceBackendPort{Number: 8080}},
+		networking.IngressServiceBackend{Name: "not-ready", Port: networking.ServiceBackendPort{Number: 80}},
+		networking.IngressServiceBackend{Name: "external", Port: networking.ServiceBackendPort{Number: 80}},
+		networking.IngressServiceBackend{Name: "typo", Port: networking.ServiceBackendPort{Number: 80}},
+		networking.IngressServiceBackend{Name: "typo", Port: networking.ServiceBackendPort{Number: 80}},
+	)
+
+	expected := []string{
+		"the backend service default/ready does not have the port 8080",
+		"the backend service default/not-ready does not have a ready endpoint",
+		"the backend service default/typo does not exist",
+	}
+	if reasons := n.unreachableBackends(ing); !reflect.DeepEqual(reasons, expected) {
+		t.Errorf("expected %v but returned %v", expected, reasons)
+	}
+}
+
+func TestAdmissionBackendCheck(t *testing.T) {
+	ing := newBackendCheckIngress(networking.IngressServiceBackend{Name: "typo", Port: networking.ServiceBackendPort{Number: 80}})
+
+	t.Run("the warn policy returns a warning", func(t *testing.T) {
+		n := newBackendCheckController(ngx_config.AdmissionBackendCheckWarn)
+
+		warnings, err := n.CheckWarning(ing)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(warnings) != 1 || !strings.Contains(warnings[0], "default/typo does not exist") {
+			t.Errorf("expected a warning about the missing service but returned %v", warnings)
+		}
+	})
+
+	t.Run("the disabled policy does not check the backends", func(t *testing.T) {
+		n := newBackendCheckController(ngx_config.AdmissionBackendCheckDisabled)
+
+		warnings, err := n.CheckWarning(ing)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(warnings) != 0 {
+			t.Errorf("expected no warning but returned %v", warnings)
+		}
+	})
+
+	t.Run("the reject policy rejects the Ingress", func(t *testing.T) {
+		n := newBackendCheckController(ngx_config.AdmissionBackendCheckReject)
+
+		warnings, err := n.CheckWarning(ing)
+		if err != nil || len(warnings) != 0 {
+			t.Errorf("expected no warning but returned %v, %v", warnings, err)
+		}
+
+		err = n.CheckIngress(ing)
+		if err == nil || !strings.Contains(err.Error(), "default/typo does not exist") {
+			t.Errorf("expected the Ingress to be rejected but returned %v", err)
+		}
+	})
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/process"
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+// binaryUpgradeSettleDelay is the time given to the workers of the previous
+// NGINX master process to close their listening sockets after a binary
+// upgrade, before the dynamic configuration is sent to the new workers
+const binaryUpgradeSettleDelay = time.Second
+
+// setupBinaryUpgrade makes the controller adopt the NGINX master processes
+// started by binary upgrades, or else falls back to reloads
+func (n *NGINXController) setupBinaryUpgrade() {
+	if !n.cfg.BinaryUpgradeReload {
+		return
+	}
+
+	if err := process.SetChildSubreaper(); err != nil {
+		klog.Warningf("Error adopting the NGINX master processes started by binary upgrades, reloading NGINX instead (--binary-upgrade-reload): %v", err)
+		return
+	}
+	n.binaryUpgrade = true
+}
+
+// upgradeNGINX replaces the NGINX master process with a binary upgrade
+// loading the new configuration. The previous master process and its workers
+// gracefully stop after the new master process has started, and the dynamic
+// configuration is sent again to the Lua shared dictionaries of the new one.
+func (n *NGINXController) upgradeNGINX(ctx context.Context) error {
+	oldPID, newPID, err := process.UpgradeBinary(ctx, nginx.PID)
+	if err != nil {
+		return err
+	}
+
+	// the new master process is waited for once the previous one exits
+	n.ngxMasterPID.Store(int64(newPID))
+	n.resetDynamicConfiguration.Store(true)
+
+	if err := process.QuitMaster(oldPID); err != nil {
+		return fmt.Errorf("stopping the previous NGINX master process %v: %w", oldPID, err)
+	}
+	klog.InfoS("NGINX binary upgraded", "previousMasterPID", oldPID, "masterPID", newPID)
+
+	time.Sleep(binaryUpgradeSettleDelay)
+	return nil
+}
+
+// waitNGINX returns the error of the exit of the NGINX master process, or of
+// the master processes which replaced it with binary upgrades. pid is the
+// master process started by the controller, which exited with err.
+func (n *NGINXController) waitNGINX(pid int, err error) error {
+	for {
+		master := int(n.ngxMasterPID.Load())
+		if master == pid {
+			return err
+		}
+
+		// the master process started by the binary upgrade was adopted by
+		// the controller when the previous master process exited
+		p, findErr := os.FindProcess(master)
+		if findErr != nil {
+			return findErr
+		}
+		state, waitErr := p.Wait()
+		if waitErr != nil {
+			return waitErr
+		}
+
+		pid, err = master, nil
+		if !state.Success() {
+			err = &exec.ExitError{ProcessState: state}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/process"
+)
+
+func TestWaitNGINX(t *testing.T) {
+	if err := process.SetChildSubreaper(); err != nil {
+		t.Skipf("child subreapers are not supported: %v", err)
+	}
+
+	for _, code := range []int{0, 3} {
+		n := &NGINXController{}
+
+		// the shell exits after starting a process, like a master process
+		// exiting after a binary upgrade
+		cmd := exec.Command("/bin/sh", "-c", "(sleep 0.2; exit "+strconv.Itoa(code)+") & echo $!")
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		upgraded, err := strconv.Atoi(strings.TrimSpace(string(out)))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		n.ngxMasterPID.Store(int64(upgraded))
+
+		err = n.waitNGINX(cmd.Process.Pid, nil)
+		exit, ok := process.ParseExit(err)
+		switch {
+		case code == 0 && err != nil:
+			t.Errorf("expected no error but got %v", err)
+		case code != 0 && (!ok || exit.ExitCode != code):
+			t.Errorf("expected the exit code %v but got %v", code, err)
+		}
+	}
+}
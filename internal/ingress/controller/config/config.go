@@ -24,6 +24,7 @@ import (
 	"k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/nginx"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
 	"k8s.io/ingress-nginx/pkg/util/runtime"
 )
@@ -86,6 +87,80 @@ const (
 	defaultLimitConnZoneVariable = "$binary_remote_addr"
 )
 
+const (
+	// TLSMissingSecretDefaultCertificate uses the default SSL certificate in the
+	// hosts of the TLS section of an Ingress without a secretName
+	TLSMissingSecretDefaultCertificate = "default-certificate"
+
+	// TLSMissingSecretWildcard uses a wildcard SSL certificate of the namespace of
+	// the Ingress matching the host, or the default SSL certificate if none matches
+	TLSMissingSecretWildcard = "wildcard"
+
+	// TLSMissingSecretReject rejects Ingresses with entries in the TLS section
+	// without a secretName
+	TLSMissingSecretReject = "reject"
+)
+
+const (
+	// DuplicatePathOldestWins configures a path defined by several Ingresses
+	// in the same host using the Ingress created first
+	DuplicatePathOldestWins = "oldest-wins"
+
+	// DuplicatePathNewestWins configures a path defined by several Ingresses
+	// in the same host using the Ingress created last
+	DuplicatePathNewestWins = "newest-wins"
+
+	// DuplicatePathReject does not configure a path defined by several
+	// Ingresses in the same host
+	DuplicatePathReject = "reject"
+)
+
+const (
+	// AdmissionBackendCheckDisabled does not check the backends of the
+	// Ingresses in the validating webhook
+	AdmissionBackendCheckDisabled = "disabled"
+
+	// AdmissionBackendCheckWarn returns a warning in the validating webhook
+	// when a Service referenced by an Ingress is missing, does not have the
+	// port, or has no ready endpoint
+	AdmissionBackendCheckWarn = "warn"
+
+	// AdmissionBackendCheckReject rejects the Ingresses with such a backend
+	// in the validating webhook
+	AdmissionBackendCheckReject = "reject"
+)
+
+const (
+	// ForwardedHeadersTrust honors the X-Forwarded-Host, X-Forwarded-Proto and
+	// X-Forwarded-Port headers sent by the trusted proxies, or by all the peers
+	// when no trusted proxy is defined
+	ForwardedHeadersTrust = "trust"
+
+	// ForwardedHeadersOverwrite replaces the forwarded headers by the values
+	// of the connection
+	ForwardedHeadersOverwrite = "overwrite"
+
+	// ForwardedHeadersAppend honors the forwarded headers like
+	// ForwardedHeadersTrust and appends the values of the connection to the
+	// headers sent to the backend
+	ForwardedHeadersAppend = "append"
+)
+
+// IsValidForwardedHeadersMode checks if mode is a mode of the forwarded headers
+func IsValidForwardedHeadersMode(mode string) bool {
+	switch mode {
+	case ForwardedHeadersTrust, ForwardedHeadersOverwrite, ForwardedHeadersAppend:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsValidForwardedScheme checks if scheme is a scheme of the clients
+func IsValidForwardedScheme(scheme string) bool {
+	return scheme == "http" || scheme == "https"
+}
+
 // Configuration represents the content of nginx.conf file
 type Configuration struct {
 	defaults.Backend `json:",squash"` //nolint:staticcheck // Ignore unknown JSON option "squash" error
@@ -105,6 +180,12 @@ type Configuration struct {
 	// Default Risk is Critical by default, but this may be changed in future releases
 	AnnotationsRiskLevel string `json:"annotations-risk-level"`
 
+	// AuthCredentialsInMemory keeps the credentials of the basic authentication
+	// in a Lua shared dictionary instead of the files of auth_basic_user_file.
+	// The credentials are verified by Lua and updated without a reload when the
+	// Secret changes.
+	AuthCredentialsInMemory bool `json:"auth-credentials-in-memory"`
+
 	// AnnotationValueWordBlocklist defines words that should not be part of an user annotation value
 	// (can be used to run arbitrary code or configs, for example) and that should be dropped.
 	// This list should be separated by "," character
@@ -124,6 +205,12 @@ type Configuration struct {
 	// By default it's empty
 	AccessLogParams string `json:"access-log-params,omitempty"`
 
+	// ClientAbortLogSampleRate is the ratio, between 0 and 1, of the requests
+	// closed by the client before the response (status code 499) written in
+	// the access log. The other requests are always written.
+	// Default: 1
+	ClientAbortLogSampleRate float32 `json:"client-abort-log-sample-rate"`
+
 	// EnableAccessLogForDefaultBackend enable access_log for default backend
 	// By default this is disabled
 	EnableAccessLogForDefaultBackend bool `json:"enable-access-log-for-default-backend"`
@@ -163,6 +250,12 @@ type Configuration struct {
 	// By default this is disabled
 	EnableOCSP bool `json:"enable-ocsp"`
 
+	// OCSPPrefetch makes the controller fetch the OCSP responses of the
+	// certificates and push them to NGINX, instead of letting each NGINX
+	// worker fetch them on the first TLS handshakes.
+	// Only used when EnableOCSP is enabled. By default this is enabled
+	OCSPPrefetch bool `json:"ocsp-prefetch"`
+
 	// EnableOWASPCoreRules enables the OWASP ModSecurity Core Rule Set (CRS)
 	// By default this is disabled
 	EnableOWASPCoreRules bool `json:"enable-owasp-modsecurity-crs"`
@@ -216,10 +309,6 @@ type Configuration struct {
 	// By default this is enabled
 	IgnoreInvalidHeaders bool `json:"ignore-invalid-headers"`
 
-	// RetryNonIdempotent since 1.9.13 NGINX will not retry non-idempotent requests (POST, LOCK, PATCH)
-	// in case of an error. The previous behavior can be restored using the value true
-	RetryNonIdempotent bool `json:"retry-non-idempotent"`
-
 	// http://nginx.org/en/docs/ngx_core_module.html#error_log
 	// Configures logging level [debug | info | notice | warn | error | crit | alert | emerg]
 	// Log levels above are listed in the order of increasing severity
@@ -322,6 +411,12 @@ type Configuration struct {
 	// of your external load balancer
 	ProxyRealIPCIDR []string `json:"proxy-real-ip-cidr,omitempty"`
 
+	// TrustedProxies defines the IP/network addresses of the proxies whose
+	// Forwarded and X-Forwarded-* headers are honored, the headers sent by
+	// other clients being replaced.
+	// Takes precedence over UseForwardedHeaders and ProxyRealIPCIDR when set.
+	TrustedProxies []string `json:"trusted-proxies,omitempty"`
+
 	// Sets the name of the configmap that contains the headers to pass to the backend
 	ProxySetHeaders string `json:"proxy-set-headers,omitempty"`
 
@@ -346,6 +441,11 @@ type Configuration struct {
 	// https://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_headers_hash_bucket_size
 	ProxyHeadersHashBucketSize int `json:"proxy-headers-hash-bucket-size,omitempty"`
 
+	// Maximum size of the cache of the responses of the locations
+	// with the annotations proxy-cache-valid and large-file-delivery
+	// https://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_cache_path
+	ProxyCacheMaxSize string `json:"proxy-cache-max-size,omitempty"`
+
 	// Enables or disables emitting nginx version in error messages and in the “Server” response header field.
 	// http://nginx.org/en/docs/http/ngx_http_core_module.html#server_tokens
 	// Default: false
@@ -407,6 +507,33 @@ type Configuration struct {
 	// Default: false
 	SSLRejectHandshake bool `json:"ssl-reject-handshake"`
 
+	// TLSMissingSecretPolicy defines the SSL certificate used in the hosts of the TLS
+	// section of an Ingress without a secretName: "default-certificate", "wildcard" or "reject"
+	// Default: default-certificate
+	TLSMissingSecretPolicy string `json:"tls-missing-secret-policy"`
+
+	// DuplicatePathPolicy defines the Ingress configuring a path defined by
+	// several Ingresses in the same host: "oldest-wins", "newest-wins" or "reject"
+	// Default: oldest-wins
+	DuplicatePathPolicy string `json:"duplicate-path-policy"`
+
+	// AdmissionBackendCheck defines how the validating webhook handles the
+	// Ingresses referencing a Service that is missing, does not have the port,
+	// or has no ready endpoint: "disabled", "warn" or "reject"
+	// Default: warn
+	AdmissionBackendCheck string `json:"admission-backend-check"`
+
+	// EnableDynamicServerAliases allows adding server aliases without reloading NGINX.
+	// Until the next reload, requests for the new aliases reach the catch-all server and
+	// are proxied internally to the server that contains the alias.
+	// By default this is disabled
+	EnableDynamicServerAliases bool `json:"enable-dynamic-server-aliases"`
+
+	// EnableProvenanceComments adds a comment to each server and location block
+	// naming the Ingresses from which it was generated and their annotations.
+	// By default this is disabled
+	EnableProvenanceComments bool `json:"enable-provenance-comments"`
+
 	// Enables or disables the use of the PROXY protocol to receive client connection
 	// (real IP address) information passed through proxy servers and load balancers
 	// such as HAproxy and Amazon Elastic Load Balancer (ELB).
@@ -472,6 +599,11 @@ type Configuration struct {
 	// http://nginx.org/en/docs/ngx_core_module.html#worker_processes
 	WorkerProcesses string `json:"worker-processes,omitempty"`
 
+	// Defines how the number of worker processes is decided when worker-processes is auto:
+	// cgroup uses the CPU limit of the container, node the CPUs of the node, and fixed
+	// requires a number in worker-processes
+	WorkerProcessesPolicy string `json:"worker-processes-policy,omitempty"`
+
 	// Defines whether multiple concurrent reloads of worker processes should occur.
 	// Set this to false to prevent more than n x 2 workers to exist at any time, to avoid potential OOM situations and high CPU load
 	// With this setting on false, configuration changes in the queue will be re-queued with an exponential backoff, until the number of worker process is the expected value.
@@ -483,6 +615,15 @@ type Configuration struct {
 	// http://nginx.org/en/docs/ngx_core_module.html#worker_shutdown_timeout
 	WorkerShutdownTimeout string `json:"worker-shutdown-timeout,omitempty"`
 
+	// Defines the time after which the controller kills the worker processes
+	// of previous configurations still shutting down. Disabled when empty
+	OldWorkersMaxAge string `json:"old-workers-max-age,omitempty"`
+
+	// Defines the number of worker processes of previous configurations
+	// shutting down above which the controller kills the oldest ones.
+	// Disabled when 0
+	OldWorkersMaxCount int `json:"old-workers-max-count,omitempty"`
+
 	// Sets the bucket size for the variables hash table.
 	// http://nginx.org/en/docs/http/ngx_http_map_module.html#variables_hash_bucket_size
 	VariablesHashBucketSize int `json:"variables-hash-bucket-size,omitempty"`
@@ -558,6 +699,20 @@ type Configuration struct {
 	// Sets whether to use incoming X-Forwarded headers.
 	UseForwardedHeaders bool `json:"use-forwarded-headers"`
 
+	// ForwardedHeadersMode defines how the X-Forwarded-Host, X-Forwarded-Proto
+	// and X-Forwarded-Port headers are computed: "trust" honors the headers of
+	// the trusted peers, "overwrite" replaces them and "append" appends the
+	// values of the connection to them. Takes precedence over UseForwardedHeaders
+	// for these headers when set.
+	// Default: ""
+	ForwardedHeadersMode string `json:"forwarded-headers-mode,omitempty"`
+
+	// ForwardedSchemeOverride is the scheme, "http" or "https", of the connections
+	// of the clients, like when the TLS connections are terminated by a load
+	// balancer in front of NGINX.
+	// Default: ""
+	ForwardedSchemeOverride string `json:"forwarded-scheme-override,omitempty"`
+
 	// Sets whether to enable the real ip module
 	EnableRealIP bool `json:"enable-real-ip"`
 
@@ -689,6 +844,29 @@ type Configuration struct {
 	// +optional
 	GlobalExternalAuth GlobalExternalAuth `json:"global-external-auth"`
 
+	// DNSCacheAuthURL enables the resolution of the host of the auth-url
+	// annotation by the DNS cache of Lua instead of the NGINX resolver
+	// Default: false
+	DNSCacheAuthURL bool `json:"dns-cache-auth-url"`
+
+	// DNSCacheStaleTTL is the number of seconds the expired addresses of a host
+	// are used by the DNS cache of Lua when its resolution fails. 0 disables it.
+	// Default: 0
+	DNSCacheStaleTTL int `json:"dns-cache-stale-ttl"`
+
+	// UpstreamIPFamilyPreference is the family of the endpoints tried first
+	// when a backend has IPv4 and IPv6 endpoints, "ipv4" or "ipv6". The
+	// endpoints of the other family are tried when the connection fails.
+	// Empty balances the requests between all the endpoints.
+	// Default: ""
+	UpstreamIPFamilyPreference string `json:"upstream-ip-family-preference"`
+
+	// UpstreamIPFamilyFallbackTimeout is the connect timeout in milliseconds
+	// of the first try to the preferred family, after which the endpoints of
+	// the other family are tried. 0 uses proxy-connect-timeout.
+	// Default: 250
+	UpstreamIPFamilyFallbackTimeout int `json:"upstream-ip-family-fallback-timeout"`
+
 	// Checksum contains a checksum of the configmap configuration
 	Checksum string `json:"-"`
 
@@ -704,6 +882,16 @@ type Configuration struct {
 	// Lua shared dict configuration data / certificate data
 	LuaSharedDicts map[string]int `json:"lua-shared-dicts"`
 
+	// LuaSharedDictsAutosize enables the sizing of the Lua shared dictionaries
+	// from the number of backends, endpoints and certificates, bounded by the
+	// memory limit of the container. The dictionaries defined in lua-shared-dicts
+	// keep the defined size.
+	LuaSharedDictsAutosize bool `json:"lua-shared-dicts-autosize"`
+
+	// LuaSharedDictsOverrides contains the names of the Lua shared dictionaries
+	// defined in lua-shared-dicts
+	LuaSharedDictsOverrides []string `json:"-"`
+
 	// DefaultSSLCertificate holds the default SSL certificate to use in the configuration
 	// It can be the fake certificate or the one behind the flag --default-ssl-certificate
 	DefaultSSLCertificate *ingress.SSLCert `json:"-"`
@@ -723,6 +911,11 @@ type Configuration struct {
 	// Default: ""
 	DebugConnections []string `json:"debug-connections"`
 
+	// DisableModules defines the optional dynamic modules of NGINX that are never loaded:
+	// brotli, geoip2, modsecurity and opentelemetry. The features requiring them are disabled.
+	// Default: ""
+	DisableModules []string `json:"disable-modules"`
+
 	// StrictValidatePathType enable the strict validation of Ingress Paths
 	// It enforces that pathType of type Exact or Prefix should start with / and contain only
 	// alphanumeric chars, "-", "_", "/".In case of additional characters,
@@ -755,8 +948,10 @@ func NewDefault() Configuration {
 		AllowBackendServerHeader:         false,
 		AnnotationValueWordBlocklist:     "",
 		AnnotationsRiskLevel:             "High",
+		AuthCredentialsInMemory:          false,
 		AccessLogPath:                    "/var/log/nginx/access.log",
 		AccessLogParams:                  "",
+		ClientAbortLogSampleRate:         1,
 		EnableAccessLogForDefaultBackend: false,
 		EnableAuthAccessLog:              false,
 		WorkerCPUAffinity:                "",
@@ -772,6 +967,7 @@ func NewDefault() Configuration {
 		ClientBodyBufferSize:             "8k",
 		ClientBodyTimeout:                60,
 		EnableUnderscoresInHeaders:       false,
+		OCSPPrefetch:                     true,
 		ErrorLogLevel:                    errorLevel,
 		UseForwardedHeaders:              false,
 		EnableRealIP:                     false,
@@ -809,6 +1005,7 @@ func NewDefault() Configuration {
 		ServerNameHashMaxSize:            1024,
 		ProxyHeadersHashMaxSize:          512,
 		ProxyHeadersHashBucketSize:       64,
+		ProxyCacheMaxSize:                "512m",
 		ProxyStreamResponses:             1,
 		ReusePort:                        true,
 		ShowServerTokens:                 false,
@@ -818,6 +1015,9 @@ func NewDefault() Configuration {
 		SSLProtocols:                     sslProtocols,
 		SSLEarlyData:                     sslEarlyData,
 		SSLRejectHandshake:               false,
+		TLSMissingSecretPolicy:           TLSMissingSecretDefaultCertificate,
+		DuplicatePathPolicy:              DuplicatePathOldestWins,
+		AdmissionBackendCheck:            AdmissionBackendCheckWarn,
 		SSLSessionCache:                  true,
 		SSLSessionCacheSize:              sslSessionCacheSize,
 		SSLSessionTickets:                false,
@@ -828,6 +1028,7 @@ func NewDefault() Configuration {
 		UseGeoIP2:                        false,
 		GeoIP2AutoReloadMinutes:          0,
 		WorkerProcesses:                  strconv.Itoa(runtime.NumCPU()),
+		WorkerProcessesPolicy:            WorkerProcessesPolicyCgroup,
 		WorkerSerialReloads:              false,
 		WorkerShutdownTimeout:            "240s",
 		VariablesHashBucketSize:          256,
@@ -838,6 +1039,7 @@ func NewDefault() Configuration {
 		ProxyStreamNextUpstream:          true,
 		ProxyStreamNextUpstreamTimeout:   "600s",
 		ProxyStreamNextUpstreamTries:     3,
+		UpstreamIPFamilyFallbackTimeout:  250,
 		Backend: defaults.Backend{
 			ProxyBodySize:               bodySize,
 			ProxyConnectTimeout:         5,
@@ -867,6 +1069,8 @@ func NewDefault() Configuration {
 			ProxyMaxTempFileSize:        "1024m",
 			ServiceUpstream:             false,
 			AllowedResponseHeaders:      []string{},
+			ExternalNameResolver:        []string{},
+			ExternalNameDNSRefresh:      true,
 		},
 		UpstreamKeepaliveConnections:   320,
 		UpstreamKeepaliveTime:          "1h",
@@ -894,6 +1098,7 @@ func NewDefault() Configuration {
 		ProxySSLLocationOnly:           false,
 		DefaultType:                    "text/html",
 		DebugConnections:               []string{},
+		DisableModules:                 []string{},
 		StrictValidatePathType:         true,
 		GRPCBufferSizeKb:               0,
 	}
@@ -932,6 +1137,15 @@ type TemplateConfig struct {
 	StatusPort               int                              `json:"StatusPort"`
 	StreamPort               int                              `json:"StreamPort"`
 	StreamSnippets           []string                         `json:"StreamSnippets"`
+	EnableCustomDomains      bool                             `json:"EnableCustomDomains"`
+	ServerAliasSockets       nginx.ServerAliasSocketPaths     `json:"ServerAliasSockets"`
+	Dataplane                *nginx.Dataplane                 `json:"Dataplane"`
+	// RenderedServers contains the server blocks rendered before the execution
+	// of the template, indexed by hostname
+	RenderedServers map[string]string `json:"-"`
+	// Admission indicates the configuration is rendered to validate an
+	// Ingress, and must not replace the cached server blocks
+	Admission bool `json:"-"`
 }
 
 // ListenPorts describe the ports required to run the
@@ -942,6 +1156,11 @@ type ListenPorts struct {
 	Health   int `json:"Health"`
 	Default  int `json:"Default"`
 	SSLProxy int `json:"SSLProxy"`
+
+	// InternalHTTP and InternalHTTPS are the ports serving the Ingresses
+	// with the scope internal, 0 when disabled
+	InternalHTTP  int `json:"InternalHTTP,omitempty"`
+	InternalHTTPS int `json:"InternalHTTPS,omitempty"`
 }
 
 // GlobalExternalAuth describe external authentication configuration for the
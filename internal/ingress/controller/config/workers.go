@@ -0,0 +1,72 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	goruntime "runtime"
+	"strconv"
+
+	"k8s.io/ingress-nginx/pkg/util/runtime"
+)
+
+const (
+	// WorkerProcessesPolicyCgroup starts a worker process for each CPU of the
+	// CPU limit of the cgroup of the container, or each CPU available without limit
+	WorkerProcessesPolicyCgroup = "cgroup"
+
+	// WorkerProcessesPolicyNode starts a worker process for each CPU of the node
+	// available to the controller, ignoring the CPU limit
+	WorkerProcessesPolicyNode = "node"
+
+	// WorkerProcessesPolicyFixed starts the number of worker processes defined
+	// in worker-processes
+	WorkerProcessesPolicyFixed = "fixed"
+)
+
+var (
+	// cgroupCPUs returns the number of CPUs of the CPU limit of the cgroup
+	cgroupCPUs = runtime.NumCPU
+	// nodeCPUs returns the number of CPUs of the node usable by the process
+	nodeCPUs = goruntime.NumCPU
+)
+
+// WorkerProcessesCount returns the number of worker processes for the value of
+// worker-processes, a number or auto, and the policy worker-processes-policy.
+// A number is used with any policy. The policy decides the number of worker
+// processes when the value is auto or empty, and requires a number when fixed.
+func WorkerProcessesCount(workerProcesses, policy string) (string, error) {
+	if workerProcesses != "" && workerProcesses != "auto" {
+		count, err := strconv.Atoi(workerProcesses)
+		if err != nil || count < 1 {
+			return "", fmt.Errorf("invalid number of worker processes %q", workerProcesses)
+		}
+
+		return workerProcesses, nil
+	}
+
+	switch policy {
+	case "", WorkerProcessesPolicyCgroup:
+		return strconv.Itoa(cgroupCPUs()), nil
+	case WorkerProcessesPolicyNode:
+		return strconv.Itoa(nodeCPUs()), nil
+	case WorkerProcessesPolicyFixed:
+		return "", fmt.Errorf("the worker processes policy %v requires a number of worker processes", policy)
+	default:
+		return "", fmt.Errorf("invalid worker processes policy %q", policy)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import "testing"
+
+func TestWorkerProcessesCount(t *testing.T) {
+	defer func(cgroup, node func() int) {
+		cgroupCPUs = cgroup
+		nodeCPUs = node
+	}(cgroupCPUs, nodeCPUs)
+
+	cgroupCPUs = func() int { return 2 }
+	nodeCPUs = func() int { return 64 }
+
+	tests := []struct {
+		name            string
+		workerProcesses string
+		policy          string
+		expected        string
+		expectedErr     bool
+	}{
+		{"default", "", "", "2", false},
+		{"auto with the cgroup policy", "auto", WorkerProcessesPolicyCgroup, "2", false},
+		{"auto with the node policy", "auto", WorkerProcessesPolicyNode, "64", false},
+		{"number with the cgroup policy", "4", WorkerProcessesPolicyCgroup, "4", false},
+		{"number with the fixed policy", "8", WorkerProcessesPolicyFixed, "8", false},
+		{"auto with the fixed policy", "auto", WorkerProcessesPolicyFixed, "", true},
+		{"empty with the fixed policy", "", WorkerProcessesPolicyFixed, "", true},
+		{"invalid number", "many", WorkerProcessesPolicyCgroup, "", true},
+		{"zero", "0", WorkerProcessesPolicyFixed, "", true},
+		{"invalid policy", "auto", "cores", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := WorkerProcessesCount(tt.workerProcesses, tt.policy)
+			if (err != nil) != tt.expectedErr {
+				t.Fatalf("expected error %v but returned %v", tt.expectedErr, err)
+			}
+			if count != tt.expected {
+				t.Errorf("expected %v worker processes but returned %v", tt.expected, count)
+			}
+		})
+	}
+}
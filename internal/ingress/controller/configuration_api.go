@@ -0,0 +1,195 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+const (
+	// ConfigurationPath is the path of the endpoint returning the running
+	// configuration, served in the status port
+	ConfigurationPath = "/configuration"
+
+	// ConfigurationAPIVersion is the version of the documents returned by the
+	// configuration endpoint. It changes with incompatible changes of the format.
+	ConfigurationAPIVersion = "ingress-nginx.kubernetes.io/v1alpha1"
+
+	configurationServersPath = ConfigurationPath + "/servers/"
+	configurationSchemaPath  = ConfigurationPath + "/schema"
+)
+
+// redactedFields are the fields of the running configuration removed from
+// the documents because they contain private keys
+var redactedFields = []string{"pemCertKey"}
+
+// RunningConfiguration is the document returned by the configuration
+// endpoint. Configuration is set in the documents of kind Configuration and
+// Server in the documents of kind Server.
+type RunningConfiguration struct {
+	APIVersion    string                 `json:"apiVersion"`
+	Kind          string                 `json:"kind"`
+	Checksum      string                 `json:"checksum,omitempty"`
+	Configuration *ingress.Configuration `json:"configuration,omitempty"`
+	Server        *ingress.Server        `json:"server,omitempty"`
+}
+
+// ConfigurationHandler returns the running configuration, the server with
+// the hostname or alias in the path /configuration/servers/<host>, or the
+// JSON schema of the documents in the path /configuration/schema
+func (n *NGINXController) ConfigurationHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if r.URL.Path == configurationSchemaPath {
+		writeJSON(w, configurationSchema())
+		return
+	}
+
+	pcfg := n.getRunningConfig()
+	doc := RunningConfiguration{
+		APIVersion: ConfigurationAPIVersion,
+		Kind:       "Configuration",
+		Checksum:   pcfg.ConfigurationChecksum,
+	}
+
+	switch {
+	case r.URL.Path == ConfigurationPath || r.URL.Path == ConfigurationPath+"/":
+		doc.Configuration = pcfg
+	case strings.HasPrefix(r.URL.Path, configurationServersPath):
+		host := strings.TrimPrefix(r.URL.Path, configurationServersPath)
+		server := findServer(pcfg.Servers, host)
+		if server == nil {
+			http.Error(w, fmt.Sprintf("server %v not found", host), http.StatusNotFound)
+			return
+		}
+
+		doc.Kind = "Server"
+		doc.Server = server
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	data, err := redact(doc)
+	if err != nil {
+		klog.Errorf("Error encoding running configuration: %v", err)
+		http.Error(w, "error encoding running configuration", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, data)
+}
+
+// setRunningConfig replaces the running configuration. It is only called
+// from the synchronization loop.
+func (n *NGINXController) setRunningConfig(pcfg *ingress.Configuration) {
+	n.runningConfigLock.Lock()
+	defer n.runningConfigLock.Unlock()
+
+	n.runningConfig = pcfg
+}
+
+// getRunningConfig returns the running configuration outside of the
+// synchronization loop
+func (n *NGINXController) getRunningConfig() *ingress.Configuration {
+	n.runningConfigLock.RLock()
+	defer n.runningConfigLock.RUnlock()
+
+	return n.runningConfig
+}
+
+// findServer returns the server with the hostname or alias host
+func findServer(servers []*ingress.Server, host string) *ingress.Server {
+	for _, server := range servers {
+		if server.Hostname == host {
+			return server
+		}
+	}
+
+	for _, server := range servers {
+		for _, alias := range server.Aliases {
+			if alias == host {
+				return server
+			}
+		}
+	}
+
+	return nil
+}
+
+// redact returns the JSON encoding of v, as generic values, without the
+// redacted fields
+func redact(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	removeFields(doc, redactedFields)
+	return doc, nil
+}
+
+func removeFields(v interface{}, fields []string) {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for _, field := range fields {
+			delete(value, field)
+		}
+		for _, child := range value {
+			removeFields(child, fields)
+		}
+	case []interface{}:
+		for _, child := range value {
+			removeFields(child, fields)
+		}
+	}
+}
+
+// configurationSchema returns the JSON schema of the documents returned by
+// the configuration endpoint
+func configurationSchema() map[string]interface{} {
+	s := newJSONSchema(redactedFields...)
+	schema := s.structSchema(reflect.TypeOf(RunningConfiguration{}))
+
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = ConfigurationAPIVersion
+	schema["$defs"] = s.defs
+	return schema
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.Errorf("Error writing JSON response: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestConfigurationHandler(t *testing.T) {
+	n := &NGINXController{
+		runningConfig: &ingress.Configuration{
+			ConfigurationChecksum: "42",
+			Servers: []*ingress.Server{
+				{
+					Hostname: "foo.bar",
+					Aliases:  []string{"www.foo.bar"},
+					SSLCert:  &ingress.SSLCert{Name: "foo-tls", PemCertKey: "private key"},
+				},
+				{Hostname: "other.bar"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		path         string
+		expectedCode int
+		expectedKind string
+		expectedHost string
+	}{
+		{"/configuration", http.StatusOK, "Configuration", ""},
+		{"/configuration/servers/foo.bar", http.StatusOK, "Server", "foo.bar"},
+		{"/configuration/servers/www.foo.bar", http.StatusOK, "Server", "foo.bar"},
+		{"/configuration/servers/other.bar", http.StatusOK, "Server", "other.bar"},
+		{"/configuration/servers/missing.bar", http.StatusNotFound, "", ""},
+		{"/configuration/unknown", http.StatusNotFound, "", ""},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		n.ConfigurationHandler(w, httptest.NewRequest(http.MethodGet, tc.path, http.NoBody))
+
+		if w.Code != tc.expectedCode {
+			t.Errorf("%v: expected status %v but returned %v", tc.path, tc.expectedCode, w.Code)
+			continue
+		}
+		if tc.expectedCode != http.StatusOK {
+			continue
+		}
+
+		if strings.Contains(w.Body.String(), "private key") {
+			t.Errorf("%v: expected the private keys to be redacted but returned %v", tc.path, w.Body.String())
+		}
+
+		var doc RunningConfiguration
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%v: unexpected error decoding %v: %v", tc.path, w.Body.String(), err)
+		}
+
+		if doc.APIVersion != ConfigurationAPIVersion || doc.Kind != tc.expectedKind || doc.Checksum != "42" {
+			t.Errorf("%v: unexpected document header %v %v %v", tc.path, doc.APIVersion, doc.Kind, doc.Checksum)
+		}
+
+		switch tc.expectedKind {
+		case "Configuration":
+			if doc.Configuration == nil || len(doc.Configuration.Servers) != 2 {
+				t.Errorf("%v: expected the configuration with 2 servers but returned %v", tc.path, w.Body.String())
+			}
+		case "Server":
+			if doc.Server == nil || doc.Server.Hostname != tc.expectedHost {
+				t.Errorf("%v: expected the server %v but returned %v", tc.path, tc.expectedHost, w.Body.String())
+			}
+		}
+	}
+
+	w := httptest.NewRecorder()
+	n.ConfigurationHandler(w, httptest.NewRequest(http.MethodPost, "/configuration", http.NoBody))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %v but returned %v", http.StatusMethodNotAllowed, w.Code)
+	}
+}
+
+func TestConfigurationSchema(t *testing.T) {
+	schema := configurationSchema()
+
+	defs, ok := schema["$defs"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the definitions in the schema")
+	}
+
+	server, ok := defs[definitionName(reflect.TypeOf(ingress.Server{}))].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the definition of ingress.Server in %v", defs)
+	}
+	properties := server["properties"].(map[string]interface{})
+	for _, name := range []string{"hostname", "aliases", "locations", "sslCert"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("expected the property %v in the definition of ingress.Server", name)
+		}
+	}
+
+	cert, ok := defs[definitionName(reflect.TypeOf(ingress.SSLCert{}))].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected the definition of ingress.SSLCert in %v", defs)
+	}
+	if _, ok := cert["properties"].(map[string]interface{})["pemCertKey"]; ok {
+		t.Errorf("expected the redacted property pemCertKey to be omitted")
+	}
+
+	// the recursive and embedded types are encoded
+	if _, err := json.Marshal(schema); err != nil {
+		t.Errorf("unexpected error encoding the schema: %v", err)
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -34,17 +37,25 @@ import (
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/externalname"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/opentelemetry"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/scope"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
+	"k8s.io/ingress-nginx/internal/ingress/controller/shard"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/ingress/deprecation"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
 	"k8s.io/ingress-nginx/internal/ingress/inspector"
 	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
 	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/net/ssl"
 	"k8s.io/ingress-nginx/internal/nginx"
+	"k8s.io/ingress-nginx/internal/task"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
 	utilingress "k8s.io/ingress-nginx/pkg/util/ingress"
 	"k8s.io/klog/v2"
@@ -81,6 +92,8 @@ type Configuration struct {
 	TCPConfigMapName string
 	// +optional
 	UDPConfigMapName string
+	// +optional
+	CustomDomainsConfigMapName string
 
 	DefaultSSLCertificate string
 
@@ -94,6 +107,14 @@ type Configuration struct {
 	ElectionTTL            time.Duration
 	UpdateStatusOnShutdown bool
 
+	StatusRemovalHoldTime     time.Duration
+	StatusRemovalObservations int
+
+	// ConfigDriftThreshold is the minimum time a replica must run a
+	// configuration different from the other replicas before it is reported.
+	// 0 disables the detection.
+	ConfigDriftThreshold time.Duration
+
 	HealthCheckHost string
 	ListenPorts     *ngx_config.ListenPorts
 
@@ -105,14 +126,19 @@ type Configuration struct {
 
 	EnableProfiling bool
 
-	EnableMetrics           bool
-	MetricsPerHost          bool
-	MetricsPerUndefinedHost bool
-	MetricsBuckets          *collectors.HistogramBuckets
-	MetricsBucketFactor     float64
-	MetricsMaxBuckets       uint32
-	ReportStatusClasses     bool
-	ExcludeSocketMetrics    []string
+	EnableMetrics               bool
+	MetricsCollector            string
+	MetricsPerHost              bool
+	MetricsPerUndefinedHost     bool
+	MetricsBuckets              *collectors.HistogramBuckets
+	MetricsBucketFactor         float64
+	MetricsMaxBuckets           uint32
+	ReportStatusClasses         bool
+	ExcludeSocketMetrics        []string
+	MetricsPerEndpoint          bool
+	MetricsPerEndpointMaxSeries int
+	MetricsLabels               []string
+	ObservabilityLabels         []string
 
 	FakeCertificate *ingress.SSLCert
 
@@ -122,10 +148,48 @@ type Configuration struct {
 
 	IngressClassConfiguration *ingressclass.Configuration
 
+	// Shard defines the hostnames owned by the controller when the
+	// Ingresses are split between several controller deployments
+	Shard *shard.Configuration
+
 	ValidationWebhook         string
 	ValidationWebhookCertPath string
 	ValidationWebhookKeyPath  string
 	DisableFullValidationTest bool
+	SyntaxOnlyValidationTest  bool
+	// AdmissionMaxRenderSeconds is the duration of the full validation above
+	// which the admission webhook validates the Ingresses incrementally
+	AdmissionMaxRenderSeconds float64
+	// AdmissionMaxIngressesRendered is the number of Ingresses above which
+	// the admission webhook validates the Ingresses incrementally
+	AdmissionMaxIngressesRendered int
+
+	// OptimizeConfiguration moves the blocks of directives repeated in the
+	// locations of the NGINX configuration to shared files
+	OptimizeConfiguration bool
+
+	// ConfigSizeBudget is the size in bytes of the NGINX configuration
+	// above which the servers are moved to their own file
+	ConfigSizeBudget int
+
+	// SplitConfiguration moves each server of the NGINX configuration to
+	// its own file, whatever the size of the configuration
+	SplitConfiguration bool
+
+	// ReloadTimeout is the maximum duration of the commands reloading and
+	// testing the NGINX configuration
+	ReloadTimeout time.Duration
+
+	// BinaryUpgradeReload loads the configuration with binary upgrades of the
+	// NGINX master process instead of reloads
+	BinaryUpgradeReload bool
+
+	// LogLevelDuration is the default duration of the changes of the log
+	// levels made at runtime
+	LogLevelDuration time.Duration
+
+	// Offline disables the outbound calls of the controller
+	Offline bool
 
 	GlobalExternalAuth  *ngx_config.GlobalExternalAuth
 	MaxmindEditionFiles *[]string
@@ -141,9 +205,23 @@ type Configuration struct {
 
 	DynamicConfigurationRetries int
 
+	// ConfigurationSnapshot is the path of the file containing the last configuration applied successfully
+	ConfigurationSnapshot string
+	// ConfigurationSnapshotTLSKeys writes the private keys of the certificates in the configuration snapshot
+	ConfigurationSnapshotTLSKeys bool
+	// CheckCluster runs the checks of the cluster skipped when the controller
+	// starts from the configuration snapshot, once the API server is available
+	CheckCluster func()
+
+	// ConfigurationExport is the path of the file containing the logical configuration in YAML
+	ConfigurationExport string
+
 	DisableSyncEvents bool
 
 	EnableTopologyAwareRouting bool
+
+	// Dataplane describes the flavor of NGINX driven by the controller
+	Dataplane *nginx.Dataplane
 }
 
 func getIngressPodZone(svc *apiv1.Service) string {
@@ -171,29 +249,62 @@ func (n *NGINXController) GetPublishService() *apiv1.Service {
 
 // syncIngress collects all the pieces required to assemble the NGINX
 // configuration file and passes the resulting data structures to the backend
-// (OnUpdate) when a reload is deemed necessary.
-func (n *NGINXController) syncIngress(interface{}) error {
+// (OnUpdate) when a reload is deemed necessary. The synchronization is aborted
+// when the context is canceled, because a newer one supersedes it or the
+// controller shuts down.
+func (n *NGINXController) syncIngress(ctx context.Context, item interface{}) error {
 	n.syncRateLimiter.Accept()
 
 	if n.syncQueue.IsShuttingDown() {
 		return nil
 	}
 
-	ings := n.store.ListIngresses()
-	hosts, servers, pcfg := n.getConfiguration(ings)
+	// the changes of the certificates received before the start
+	// of the synchronization are configured when it succeeds
+	start := time.Now()
+
+	if element, ok := item.(task.Element); ok && element.IsPartial {
+		if key, ok := element.Key.(string); ok && n.syncCertificate(key) {
+			n.health.certificatesSynced(start)
+			return nil
+		}
+	}
+
+	ings := n.scopeIngresses(n.shardIngresses(n.store.ListIngresses()))
+	hosts, servers, pcfg := n.getConfiguration(ctx, ings, n.decisionEvents)
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	pcfg.CustomDomains = n.getCustomDomains(pcfg.Servers)
 
 	n.metricCollector.SetSSLExpireTime(servers)
 	n.metricCollector.SetSSLInfo(servers)
+	n.metricCollector.SetIngressLabels(n.ingressMetricsLabels(ings))
+	n.metricCollector.SetPathConflicts(n.reportPathConflicts(ings))
+	n.decisionEvents.synced()
 
-	if n.runningConfig.Equal(pcfg) {
+	if n.runningConfig.Equal(pcfg) && !n.resetDynamicConfiguration.Load() {
 		klog.V(3).Infof("No configuration change detected, skipping backend reload")
+		n.health.certificatesSynced(start)
 		return nil
 	}
 
 	n.metricCollector.SetHosts(hosts)
+	n.metricCollector.SetEndpoints(pcfg.Backends)
+
+	cfg := n.store.GetBackendConfiguration()
+	dynamicServerAliases := cfg.EnableDynamicServerAliases &&
+		utilingress.IsServerAliasesAddition(pcfg, n.runningConfig)
+
+	// the Lua shared dictionaries can only be resized by a reload
+	luaSharedDicts := luaSharedDictSizes(&cfg, pcfg)
+	luaSharedDictsResized := n.runningLuaSharedDicts != nil && !maps.Equal(luaSharedDicts, n.runningLuaSharedDicts)
 
-	if !utilingress.IsDynamicConfigurationEnough(pcfg, n.runningConfig) {
+	if luaSharedDictsResized || (!dynamicServerAliases && !utilingress.IsDynamicConfigurationEnough(pcfg, n.runningConfig)) {
 		klog.InfoS("Configuration changes detected, backend reload required")
+		if luaSharedDictsResized {
+			klog.InfoS("Lua shared dictionaries resized", "sizes", luaSharedDicts)
+		}
 
 		hash, err := hashstructure.Hash(pcfg, hashstructure.FormatV1, &hashstructure.HashOptions{
 			TagName: "json",
@@ -204,7 +315,13 @@ func (n *NGINXController) syncIngress(interface{}) error {
 
 		pcfg.ConfigurationChecksum = fmt.Sprintf("%v", hash)
 
-		err = n.OnUpdate(*pcfg)
+		err = n.OnUpdate(ctx, *pcfg)
+		if err != nil && ctx.Err() != nil {
+			// superseded before the configuration was written
+			return ctx.Err()
+		}
+
+		n.health.reloaded(err)
 		if err != nil {
 			n.metricCollector.IncReloadErrorCount()
 			n.metricCollector.ConfigSuccess(hash, false)
@@ -214,6 +331,7 @@ func (n *NGINXController) syncIngress(interface{}) error {
 		}
 
 		klog.InfoS("Backend successfully reloaded")
+		n.runningLuaSharedDicts = luaSharedDicts
 		n.metricCollector.ConfigSuccess(hash, true)
 		n.metricCollector.IncReloadCount()
 
@@ -250,6 +368,7 @@ func (n *NGINXController) syncIngress(interface{}) error {
 		klog.Warningf("Dynamic reconfiguration failed: %v", err)
 		return false, err
 	})
+	n.health.dynamicallyConfigured(err)
 	if err != nil {
 		klog.Errorf("Unexpected failure reconfiguring NGINX:\n%v", err)
 		return err
@@ -259,11 +378,90 @@ func (n *NGINXController) syncIngress(interface{}) error {
 	rc := utilingress.GetRemovedCertificateSerialNumbers(n.runningConfig, pcfg)
 	n.metricCollector.RemoveMetrics(ri, rc)
 
-	n.runningConfig = pcfg
+	n.setRunningConfig(pcfg)
+	n.health.certificatesSynced(start)
+
+	if n.cfg.ConfigurationSnapshot != "" {
+		if err := n.writeConfigurationSnapshot(pcfg); err != nil {
+			klog.Warningf("Error writing configuration snapshot: %v", err)
+		}
+	}
+
+	if n.cfg.ConfigurationExport != "" {
+		if err := writeConfigurationExport(n.cfg.ConfigurationExport, pcfg); err != nil {
+			klog.Warningf("Error writing configuration export: %v", err)
+		}
+	}
 
 	return nil
 }
 
+// syncCertificate configures the certificate contained in the Secret matching
+// key in the servers using it, without building the complete configuration.
+// It returns false when a full synchronization is required instead.
+func (n *NGINXController) syncCertificate(key string) bool {
+	hosts := n.store.GetSecretHostnames(key)
+	if len(hosts) == 0 {
+		return false
+	}
+
+	cert, err := n.store.GetLocalSSLCert(key)
+	if err != nil || cert.Certificate == nil {
+		return false
+	}
+
+	servers := make([]*ingress.Server, len(n.runningConfig.Servers))
+	copy(servers, n.runningConfig.Servers)
+
+	updated := make([]*ingress.Server, 0, len(hosts))
+	for _, host := range hosts {
+		idx := -1
+		for i, server := range servers {
+			if server.Hostname == host && server.SSLCert != nil &&
+				fmt.Sprintf("%v/%v", server.SSLCert.Namespace, server.SSLCert.Name) == key {
+				idx = i
+				break
+			}
+		}
+
+		if idx == -1 {
+			klog.V(3).InfoS("Server does not use the certificate yet, full synchronization required", "host", host, "secret", key)
+			return false
+		}
+
+		if cert.Certificate.VerifyHostname(host) != nil && verifyHostname(host, cert.Certificate) != nil {
+			klog.V(3).InfoS("Certificate is not valid for server, full synchronization required", "host", host, "secret", key)
+			return false
+		}
+
+		if alternate := servers[idx].AlternateSSLCert; alternate != nil && ssl.CheckAlternateCertificate(cert.Certificate, alternate.Certificate) != nil {
+			klog.V(3).InfoS("Certificate cannot be served with the alternate certificate of server, full synchronization required", "host", host, "secret", key)
+			return false
+		}
+
+		server := *servers[idx]
+		server.SSLCert = cert
+		servers[idx] = &server
+		updated = append(updated, &server)
+	}
+
+	if err := postCertificates(updated, certificateRedirects(servers, hosts)); err != nil {
+		klog.Warningf("Dynamic certificate update failed, falling back to full synchronization: %v", err)
+		return false
+	}
+
+	klog.InfoS("Certificate updated without reloading the backend", "secret", key, "hosts", hosts)
+	n.metricCollector.IncReloadAvoidedCount()
+	n.metricCollector.SetSSLExpireTime(updated)
+	n.metricCollector.SetSSLInfo(updated)
+
+	pcfg := *n.runningConfig
+	pcfg.Servers = servers
+	n.setRunningConfig(&pcfg)
+
+	return true
+}
+
 // GetWarnings returns a list of warnings an Ingress gets when being created.
 // The warnings are going to be used in an admission webhook, and they represent
 // a list of messages that users need to be aware (like deprecation notices)
@@ -271,27 +469,17 @@ func (n *NGINXController) syncIngress(interface{}) error {
 func (n *NGINXController) CheckWarning(ing *networking.Ingress) ([]string, error) {
 	warnings := make([]string, 0)
 
-	deprecatedAnnotations := sets.NewString()
-	deprecatedAnnotations.Insert(
-		"enable-influxdb",
-		"influxdb-measurement",
-		"influxdb-port",
-		"influxdb-host",
-		"influxdb-server-name",
-		"secure-verify-ca-secret",
-	)
-
 	// Skip checks if the ingress is marked as deleted
 	if !ing.DeletionTimestamp.IsZero() {
 		return warnings, nil
 	}
 
-	anns := ing.GetAnnotations()
-	for k := range anns {
-		trimmedkey := strings.TrimPrefix(k, parser.AnnotationsPrefix+"/")
-		if deprecatedAnnotations.Has(trimmedkey) {
-			warnings = append(warnings, fmt.Sprintf("annotation %s is deprecated", k))
-		}
+	for _, d := range deprecation.Annotations(ing.GetAnnotations()) {
+		warnings = append(warnings, d.Message())
+	}
+
+	if n.store.GetBackendConfiguration().AdmissionBackendCheck == ngx_config.AdmissionBackendCheckWarn && n.handlesIngress(ing) {
+		warnings = append(warnings, n.unreachableBackends(ing)...)
 	}
 
 	// Add each validation as a single warning
@@ -310,6 +498,94 @@ func (n *NGINXController) CheckWarning(ing *networking.Ingress) ([]string, error
 	return warnings, nil
 }
 
+// ingressMetricsLabels returns the labels of the request metrics defined in
+// the Ingresses using the annotation metrics-labels, or copied from the labels
+// allowed by the flag --observability-labels, indexed by namespace/name.
+// The annotation overrides the labels of the Ingresses.
+func (n *NGINXController) ingressMetricsLabels(ings []*ingress.Ingress) map[string]map[string]string {
+	labels := make(map[string]map[string]string)
+	for _, ing := range ings {
+		ingLabels := n.observabilityLabels(&ing.Ingress)
+		if ing.ParsedAnnotations != nil {
+			for name, value := range ing.ParsedAnnotations.MetricsLabels {
+				if ingLabels == nil {
+					ingLabels = make(map[string]string)
+				}
+				ingLabels[name] = value
+			}
+		}
+
+		if len(ingLabels) == 0 {
+			continue
+		}
+
+		labels[k8s.MetaNamespaceKey(ing)] = ingLabels
+	}
+
+	return labels
+}
+
+// shardIngresses returns the Ingresses restricted to the rules of the
+// hostnames owned by the shard of the controller
+func (n *NGINXController) shardIngresses(ings []*ingress.Ingress) []*ingress.Ingress {
+	if !n.cfg.Shard.Enabled() {
+		return ings
+	}
+
+	sharded := make([]*ingress.Ingress, 0, len(ings))
+	for _, ing := range ings {
+		shardedIng := *ing
+		shardedIng.Spec = n.cfg.Shard.Spec(&ing.Ingress)
+		if len(shardedIng.Spec.Rules) == 0 && shardedIng.Spec.DefaultBackend == nil {
+			continue
+		}
+		sharded = append(sharded, &shardedIng)
+	}
+
+	return sharded
+}
+
+// scopeIngresses returns the Ingresses without the Ingresses with the scope
+// internal that cannot be served only in the internal listeners. An Event
+// explains why they are not configured.
+func (n *NGINXController) scopeIngresses(ings []*ingress.Ingress) []*ingress.Ingress {
+	scoped := make([]*ingress.Ingress, 0, len(ings))
+	for _, ing := range ings {
+		if err := n.checkScope(&ing.Ingress, ing.ParsedAnnotations); err != nil {
+			klog.Warningf("Ingress %v/%v is not configured: %v", ing.Namespace, ing.Name, err)
+			n.decisionEvents.Eventf(ing, "", apiv1.EventTypeWarning, "Scope", "Ingress is not configured: %v", err)
+			continue
+		}
+		scoped = append(scoped, ing)
+	}
+
+	return scoped
+}
+
+// checkScope returns an error when an Ingress with the scope internal would
+// be served in the public listeners
+func (n *NGINXController) checkScope(ing *networking.Ingress, anns *annotations.Ingress) error {
+	if anns == nil || anns.Scope != scope.Internal {
+		return nil
+	}
+
+	if n.cfg.ListenPorts == nil || n.cfg.ListenPorts.InternalHTTP == 0 {
+		return fmt.Errorf("the scope is internal and the internal listeners are disabled (flag --internal-http-port)")
+	}
+
+	if ing.Spec.DefaultBackend != nil && len(ing.Spec.Rules) == 0 {
+		return fmt.Errorf("the scope is internal and the Ingress configures the catch-all server, which is served in all the listeners")
+	}
+
+	for i, rule := range ing.Spec.Rules {
+		if rule.Host == "" {
+			return fmt.Errorf("the scope is internal and spec.rules[%d] does not define a host. The catch-all server is served in all the listeners", i)
+		}
+	}
+
+	return nil
+}
+
 // CheckIngress returns an error in case the provided ingress, when added
 // to the current configuration, generates an invalid configuration
 func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
@@ -337,8 +613,8 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 		return nil
 	}
 
-	if n.cfg.Namespace != "" && ing.ObjectMeta.Namespace != n.cfg.Namespace {
-		klog.Warningf("ignoring ingress %v in namespace %v different from the namespace watched %s", ing.Name, ing.ObjectMeta.Namespace, n.cfg.Namespace)
+	if namespaces := k8s.ParseNamespaces(n.cfg.Namespace); len(namespaces) > 0 && !slices.Contains(namespaces, ing.ObjectMeta.Namespace) {
+		klog.Warningf("ignoring ingress %v in namespace %v different from the namespaces watched %s", ing.Name, ing.ObjectMeta.Namespace, n.cfg.Namespace)
 		return nil
 	}
 
@@ -356,14 +632,37 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 		}
 	}
 
+	if cfg.TLSMissingSecretPolicy == ngx_config.TLSMissingSecretReject {
+		for i, tls := range ing.Spec.TLS {
+			if tls.SecretName == "" {
+				return fmt.Errorf("spec.tls[%d] does not define a secretName. TLS entries without a secretName are rejected by the Ingress administrator", i)
+			}
+		}
+	}
+
+	if cfg.AdmissionBackendCheck == ngx_config.AdmissionBackendCheckReject {
+		if reasons := n.unreachableBackends(ing); len(reasons) > 0 {
+			return fmt.Errorf("%v. Ingresses with unreachable backends are rejected by the Ingress administrator", strings.Join(reasons, ", "))
+		}
+	}
+
 	var arrayBadWords []string
 
 	if cfg.AnnotationValueWordBlocklist != "" {
 		arrayBadWords = strings.Split(strings.TrimSpace(cfg.AnnotationValueWordBlocklist), ",")
 	}
 
-	for key, value := range ing.ObjectMeta.GetAnnotations() {
-		if parser.AnnotationsPrefix != parser.DefaultAnnotationsPrefix {
+	// annotations using the prefixes accepted by the IngressClass are validated
+	// as annotations of the controller, like in the synchronization
+	aliasedIng := *ing
+	aliasedIng.Annotations = n.store.GetIngressAnnotations(ing)
+
+	// the default prefix is valid with a custom prefix when the IngressClass accepts it
+	rejectDefaultPrefix := parser.AnnotationsPrefix != parser.DefaultAnnotationsPrefix &&
+		!slices.Contains(n.store.GetAnnotationPrefixes(ing), parser.DefaultAnnotationsPrefix)
+
+	for key, value := range aliasedIng.Annotations {
+		if rejectDefaultPrefix {
 			if strings.HasPrefix(key, fmt.Sprintf("%s/", parser.DefaultAnnotationsPrefix)) {
 				return fmt.Errorf("this deployment has a custom annotation prefix defined. Use '%s' instead of '%s'", parser.AnnotationsPrefix, parser.DefaultAnnotationsPrefix)
 			}
@@ -391,7 +690,21 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 			toCheck.ObjectMeta.Name == ing.ObjectMeta.Name
 	}
 	ings := store.FilterIngresses(allIngresses, filter)
-	parsed, err := annotations.NewAnnotationExtractor(n.store).Extract(ing)
+	parsed, err := annotations.NewAnnotationExtractor(n.store).Extract(&aliasedIng)
+	if err != nil {
+		n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
+		return err
+	}
+	if err := n.checkScope(ing, parsed); err != nil {
+		n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
+		return err
+	}
+	err = store.ValidateAnnotationSchedules(aliasedIng.Annotations, func(scheduled map[string]string) error {
+		scheduledIng := *ing
+		scheduledIng.Annotations = scheduled
+		_, err := annotations.NewAnnotationExtractor(n.store).Extract(&scheduledIng)
+		return err
+	})
 	if err != nil {
 		n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
 		return err
@@ -401,7 +714,17 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 		ParsedAnnotations: parsed,
 	})
 	startTest := time.Now().UnixNano() / 1000000
-	_, servers, pcfg := n.getConfiguration(ings)
+
+	// on large clusters only the servers of the checked Ingress are rendered
+	incremental := n.admissionBudget.incremental(len(ings), time.Now())
+	if incremental {
+		klog.V(2).InfoS("Validating the ingress incrementally", "ingress", klog.KObj(ing), "ingresses", len(ings))
+		ings = affectedIngresses(ings)
+	}
+
+	// the admission of an Ingress does not emit the Events of the decisions
+	// taken building the configuration, which is not applied
+	_, servers, pcfg := n.getConfiguration(context.TODO(), ings, nil)
 
 	err = checkOverlap(ing, servers)
 	if err != nil {
@@ -410,23 +733,26 @@ func (n *NGINXController) CheckIngress(ing *networking.Ingress) error {
 	}
 	testedSize := len(ings)
 	if n.cfg.DisableFullValidationTest {
-		_, _, pcfg = n.getConfiguration(ings[len(ings)-1:])
+		_, _, pcfg = n.getConfiguration(context.TODO(), ings[len(ings)-1:], nil)
 		testedSize = 1
 	}
 
-	content, err := n.generateTemplate(cfg, *pcfg)
+	content, err := n.generateTemplate(cfg, *pcfg, true)
 	if err != nil {
 		n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
 		return err
 	}
 
-	err = n.testTemplate(content)
+	err = n.validateTemplate(content)
 	if err != nil {
 		n.metricCollector.IncCheckErrorCount(ing.ObjectMeta.Namespace, ing.Name)
 		return err
 	}
 	n.metricCollector.IncCheckCount(ing.ObjectMeta.Namespace, ing.Name)
 	endCheck := time.Now().UnixNano() / 1000000
+	if !incremental {
+		n.admissionBudget.recordFullValidation(time.UnixMilli(startTest), time.Duration(endCheck-startTest)*time.Millisecond)
+	}
 	n.metricCollector.SetAdmissionMetrics(
 		float64(testedSize),
 		float64(endCheck-startTest)/1000,
@@ -463,6 +789,8 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 		n.cfg.ListenPorts.SSLProxy,
 		n.cfg.ListenPorts.Health,
 		n.cfg.ListenPorts.Default,
+		n.cfg.ListenPorts.InternalHTTP,
+		n.cfg.ListenPorts.InternalHTTPS,
 		nginx.ProfilerPort,
 		nginx.StatusPort,
 		nginx.StreamPort,
@@ -470,6 +798,7 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 
 	reservedPorts := sets.NewInt(rp...)
 	// svcRef format: <(str)namespace>/<(str)service>:<(intstr)port>[:<("PROXY")decode>:<("PROXY")encode>]
+	// UDP svcRef format: <(str)namespace>/<(str)service>:<(intstr)port>[:<(str)option>=<(str)value>...]
 	for port, svcRef := range configmap.Data {
 		externalPort, err := strconv.Atoi(port) // #nosec
 		if err != nil {
@@ -498,6 +827,10 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 				svcProxyProtocol.Encode = true
 			}
 		}
+		var udpConfig ingress.UDPConfig
+		if len(nsSvcPort) >= 3 && proto == apiv1.ProtocolUDP {
+			udpConfig = parseUDPConfig(svcRef, nsSvcPort[2:])
+		}
 		svcNs, svcName, err := k8s.ParseNameNS(nsName)
 		if err != nil {
 			klog.Warningf("%v", err)
@@ -557,6 +890,7 @@ func (n *NGINXController) getStreamServices(configmapName string, proto apiv1.Pr
 				Port:          intstr.FromString(svcPort),
 				Protocol:      proto,
 				ProxyProtocol: svcProxyProtocol,
+				UDP:           udpConfig,
 			},
 			Endpoints: endps,
 			Service:   svc,
@@ -606,10 +940,12 @@ func (n *NGINXController) getDefaultUpstream() *ingress.Backend {
 }
 
 // getConfiguration returns the configuration matching the standard kubernetes ingress
-func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.Set[string], []*ingress.Server, *ingress.Configuration) {
-	upstreams, servers := n.getBackendServers(ingresses)
+func (n *NGINXController) getConfiguration(ctx context.Context, ingresses []*ingress.Ingress, events *decisionEvents) (sets.Set[string], []*ingress.Server, *ingress.Configuration) {
+	upstreams, servers := n.getBackendServers(ctx, ingresses, events)
 	var passUpstreams []*ingress.SSLPassthroughBackend
 
+	disableUnavailableModuleLocations(servers, nginx.UnavailableModules(n.store.GetBackendConfiguration().DisableModules))
+
 	hosts := sets.New[string]()
 
 	for _, server := range servers {
@@ -647,10 +983,11 @@ func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.S
 				continue
 			}
 			passUpstreams = append(passUpstreams, &ingress.SSLPassthroughBackend{
-				Backend:  loc.Backend,
-				Hostname: server.Hostname,
-				Service:  loc.Service,
-				Port:     loc.Port,
+				Backend:       loc.Backend,
+				Hostname:      server.Hostname,
+				Service:       loc.Service,
+				Port:          loc.Port,
+				ProxyProtocol: loc.UpstreamProxyProtocol,
 			})
 			break
 		}
@@ -663,6 +1000,7 @@ func (n *NGINXController) getConfiguration(ingresses []*ingress.Ingress) (sets.S
 		UDPEndpoints:          n.getStreamServices(n.cfg.UDPConfigMapName, apiv1.ProtocolUDP),
 		PassthroughBackends:   passUpstreams,
 		BackendConfigChecksum: n.store.GetBackendConfiguration().Checksum,
+		ErrorLogLevel:         n.logLevels.getErrorLogLevel(),
 		DefaultSSLCertificate: n.getDefaultSSLCertificate(),
 		StreamSnippets:        n.getStreamSnippets(ingresses),
 	}
@@ -698,17 +1036,24 @@ func dropSnippetDirectives(anns *annotations.Ingress, ingKey string) {
 
 // getBackendServers returns a list of Upstream and Server to be used by the
 // backend.  An upstream can be used in multiple servers if the namespace,
-// service name and port are the same.
+// service name and port are the same. The returned configuration is incomplete
+// when the context is canceled.
 //
 //nolint:gocyclo // Ignore function complexity error
-func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*ingress.Backend, []*ingress.Server) {
+func (n *NGINXController) getBackendServers(ctx context.Context, ingresses []*ingress.Ingress, events *decisionEvents) ([]*ingress.Backend, []*ingress.Server) {
 	du := n.getDefaultUpstream()
 	upstreams := n.createUpstreams(ingresses, du)
-	servers := n.createServers(ingresses, upstreams, du)
+	servers := n.createServers(ingresses, upstreams, du, events)
+	conflicts := resolvePathConflicts(ingresses, n.store.GetBackendConfiguration().DuplicatePathPolicy)
 
 	var canaryIngresses []*ingress.Ingress
 
 	for _, ing := range ingresses {
+		if ctx.Err() != nil {
+			klog.V(2).InfoS("Aborting the build of the configuration (context canceled)")
+			break
+		}
+
 		ingKey := k8s.MetaNamespaceKey(ing)
 		anns := ing.ParsedAnnotations
 
@@ -787,6 +1132,12 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 					nginxPath = path.Path
 				}
 
+				if conflicts.excludes(host, nginxPath, path.PathType, ingKey) {
+					klog.V(3).Infof("Location %q for server %q is defined by several Ingresses and the policy for duplicate paths excludes Ingress %q",
+						nginxPath, server.Hostname, ingKey)
+					continue
+				}
+
 				addLoc := true
 				for _, loc := range server.Locations {
 					if loc.Path != nginxPath {
@@ -817,6 +1168,9 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 					loc.Ingress = ing
 
 					locationApplyAnnotations(loc, anns)
+					n.locationApplyServiceAnnotations(loc, ing)
+					loc.ObservabilityLabels = n.observabilityLabels(&ing.Ingress)
+					loc.ForwardedHeaders = ing.ForwardedHeaders
 
 					if loc.Redirect.FromToWWW {
 						server.RedirectFromToWWW = true
@@ -839,6 +1193,9 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 						Ingress:      ing,
 					}
 					locationApplyAnnotations(loc, anns)
+					n.locationApplyServiceAnnotations(loc, ing)
+					loc.ObservabilityLabels = n.observabilityLabels(&ing.Ingress)
+					loc.ForwardedHeaders = ing.ForwardedHeaders
 
 					if loc.Redirect.FromToWWW {
 						server.RedirectFromToWWW = true
@@ -966,6 +1323,9 @@ func (n *NGINXController) getBackendServers(ingresses []*ingress.Ingress) ([]*in
 		}
 	}
 
+	aUpstreams = append(aUpstreams, n.createSplitHorizonUpstreams(upstreams, servers)...)
+	aUpstreams = append(aUpstreams, n.createBackupUpstreams(upstreams, servers)...)
+
 	aServers := make([]*ingress.Server, 0, len(servers))
 	for _, value := range servers {
 		sort.SliceStable(value.Locations, func(i, j int) bool {
@@ -1013,11 +1373,17 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 			upstreams[defBackend].UpstreamHashBy.UpstreamHashBy = anns.UpstreamHashBy.UpstreamHashBy
 			upstreams[defBackend].UpstreamHashBy.UpstreamHashBySubset = anns.UpstreamHashBy.UpstreamHashBySubset
 			upstreams[defBackend].UpstreamHashBy.UpstreamHashBySubsetSize = anns.UpstreamHashBy.UpstreamHashBySubsetSize
+			upstreams[defBackend].UpstreamHashBy.UpstreamHashByBalanceFactor = anns.UpstreamHashBy.UpstreamHashByBalanceFactor
+			upstreams[defBackend].UpstreamHashBy.UpstreamHashByReplicationFactor = anns.UpstreamHashBy.UpstreamHashByReplicationFactor
+			upstreams[defBackend].ExternalNameResolution = newExternalNameResolution(&anns.ExternalName)
 
 			upstreams[defBackend].LoadBalancing = anns.LoadBalancing
 			if upstreams[defBackend].LoadBalancing == "" {
 				upstreams[defBackend].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
 			}
+			upstreams[defBackend].LoadBalanceTuning.P2CChoices = anns.LoadBalanceTuning.P2CChoices
+			upstreams[defBackend].LoadBalanceTuning.LeastLatencyDecay = anns.LoadBalanceTuning.LeastLatencyDecay
+			upstreams[defBackend].Warmup = ingress.Warmup(anns.Warmup)
 
 			svcKey := fmt.Sprintf("%v/%v", ing.Namespace, ing.Spec.DefaultBackend.Service.Name)
 
@@ -1078,11 +1444,17 @@ func (n *NGINXController) createUpstreams(data []*ingress.Ingress, du *ingress.B
 				upstreams[name].UpstreamHashBy.UpstreamHashBy = anns.UpstreamHashBy.UpstreamHashBy
 				upstreams[name].UpstreamHashBy.UpstreamHashBySubset = anns.UpstreamHashBy.UpstreamHashBySubset
 				upstreams[name].UpstreamHashBy.UpstreamHashBySubsetSize = anns.UpstreamHashBy.UpstreamHashBySubsetSize
+				upstreams[name].UpstreamHashBy.UpstreamHashByBalanceFactor = anns.UpstreamHashBy.UpstreamHashByBalanceFactor
+				upstreams[name].UpstreamHashBy.UpstreamHashByReplicationFactor = anns.UpstreamHashBy.UpstreamHashByReplicationFactor
+				upstreams[name].ExternalNameResolution = newExternalNameResolution(&anns.ExternalName)
 
 				upstreams[name].LoadBalancing = anns.LoadBalancing
 				if upstreams[name].LoadBalancing == "" {
 					upstreams[name].LoadBalancing = n.store.GetBackendConfiguration().LoadBalancing
 				}
+				upstreams[name].LoadBalanceTuning.P2CChoices = anns.LoadBalanceTuning.P2CChoices
+				upstreams[name].LoadBalanceTuning.LeastLatencyDecay = anns.LoadBalanceTuning.LeastLatencyDecay
+				upstreams[name].Warmup = ingress.Warmup(anns.Warmup)
 
 				svcKey := fmt.Sprintf("%v/%v", ing.Namespace, svcName)
 
@@ -1237,12 +1609,103 @@ func (n *NGINXController) getDefaultSSLCertificate() *ingress.SSLCert {
 	return n.cfg.FakeCertificate
 }
 
+// getMissingSecretSSLCertificate returns the SSL certificate of a host listed in
+// the TLS section of an Ingress without a secretName, following the policy defined
+// in tls-missing-secret-policy. An Event explains the decision in the Ingress.
+func (n *NGINXController) getMissingSecretSSLCertificate(host string, ing *ingress.Ingress, events *decisionEvents) *ingress.SSLCert {
+	switch n.store.GetBackendConfiguration().TLSMissingSecretPolicy {
+	case ngx_config.TLSMissingSecretReject:
+		klog.Warningf("Host %q is listed in the TLS section of Ingress %v/%v but secretName is empty. TLS is not configured", host, ing.Namespace, ing.Name)
+		events.Eventf(ing, host, apiv1.EventTypeWarning, "TLS",
+			"Host %q has no secretName in the TLS section and the policy for missing secrets is %q. TLS is not configured", host, ngx_config.TLSMissingSecretReject)
+		return nil
+	case ngx_config.TLSMissingSecretWildcard:
+		cert := findWildcardSSLCertificate(host, ing.Namespace, n.store.ListLocalSSLCerts())
+		if cert != nil {
+			klog.V(3).Infof("Host %q is listed in the TLS section but secretName is empty. Using wildcard certificate %v/%v", host, cert.Namespace, cert.Name)
+			events.Eventf(ing, host, apiv1.EventTypeNormal, "TLS",
+				"Host %q has no secretName in the TLS section. Using the wildcard certificate of Secret %v/%v", host, cert.Namespace, cert.Name)
+			return cert
+		}
+
+		events.Eventf(ing, host, apiv1.EventTypeNormal, "TLS",
+			"Host %q has no secretName in the TLS section and no wildcard certificate matches it. Using the default certificate", host)
+	default:
+		events.Eventf(ing, host, apiv1.EventTypeNormal, "TLS",
+			"Host %q has no secretName in the TLS section. Using the default certificate", host)
+	}
+
+	klog.V(3).Infof("Host %q is listed in the TLS section but secretName is empty. Using default certificate", host)
+	return n.getDefaultSSLCertificate()
+}
+
+// getAlternateSSLCertificate returns the SSL certificate of the Secret defined in
+// the ssl-alternate-secret annotation of an Ingress, served in addition to the
+// certificate of a host to the clients supporting its key type. An Event explains
+// why the certificate is ignored in the Ingress.
+func (n *NGINXController) getAlternateSSLCertificate(host string, cert *ingress.SSLCert, ing *ingress.Ingress, events *decisionEvents) *ingress.SSLCert {
+	key := ing.ParsedAnnotations.SSLAlternateSecret
+	if key == "" {
+		return nil
+	}
+
+	alternate, err := n.store.GetLocalSSLCert(key)
+	if err != nil || alternate.Certificate == nil {
+		klog.Warningf("Alternate SSL certificate %q of server %q not found or invalid. Serving only the certificate %v/%v", key, host, cert.Namespace, cert.Name)
+		events.Eventf(ing, "alternate "+host, apiv1.EventTypeWarning, "TLS",
+			"Alternate SSL certificate %q of host %q is missing or invalid", key, host)
+		return nil
+	}
+
+	if alternate.Certificate.VerifyHostname(host) != nil && verifyHostname(host, alternate.Certificate) != nil {
+		klog.Warningf("Alternate SSL certificate %q is not valid for server %q. Serving only the certificate %v/%v", key, host, cert.Namespace, cert.Name)
+		events.Eventf(ing, "alternate "+host, apiv1.EventTypeWarning, "TLS",
+			"Alternate SSL certificate %q is not valid for host %q", key, host)
+		return nil
+	}
+
+	if err := ssl.CheckAlternateCertificate(cert.Certificate, alternate.Certificate); err != nil {
+		klog.Warningf("Alternate SSL certificate %q cannot be served with the certificate %v/%v of server %q: %v", key, cert.Namespace, cert.Name, host, err)
+		events.Eventf(ing, "alternate "+host, apiv1.EventTypeWarning, "TLS",
+			"Alternate SSL certificate %q of host %q cannot be served: %v", key, host, err)
+		return nil
+	}
+
+	return alternate
+}
+
+// findWildcardSSLCertificate returns the SSL certificate of the namespace with a
+// wildcard name matching the host, choosing the first one by name when several match
+func findWildcardSSLCertificate(host, namespace string, certs []*ingress.SSLCert) *ingress.SSLCert {
+	var found *ingress.SSLCert
+	for _, cert := range certs {
+		if cert == nil || cert.Certificate == nil || cert.Namespace != namespace {
+			continue
+		}
+
+		if !slices.ContainsFunc(cert.CN, func(cn string) bool { return strings.HasPrefix(cn, "*.") }) {
+			continue
+		}
+
+		if cert.Certificate.VerifyHostname(host) != nil {
+			continue
+		}
+
+		if found == nil || cert.Name < found.Name {
+			found = cert
+		}
+	}
+
+	return found
+}
+
 // createServers builds a map of host name to Server structs from a map of
 // already computed Upstream structs. Each Server is configured with at least
 // one root location, which uses a default backend if left unspecified.
 func (n *NGINXController) createServers(data []*ingress.Ingress,
 	upstreams map[string]*ingress.Backend,
 	du *ingress.Backend,
+	events *decisionEvents,
 ) map[string]*ingress.Server {
 	servers := make(map[string]*ingress.Server, len(data))
 	allAliases := make(map[string][]string, len(data))
@@ -1258,6 +1721,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 		CookieDomain:         bdef.ProxyCookieDomain,
 		CookiePath:           bdef.ProxyCookiePath,
 		NextUpstream:         bdef.ProxyNextUpstream,
+		RetryNonIdempotent:   bdef.RetryNonIdempotent,
 		NextUpstreamTimeout:  bdef.ProxyNextUpstreamTimeout,
 		NextUpstreamTries:    bdef.ProxyNextUpstreamTries,
 		RequestBuffering:     bdef.ProxyRequestBuffering,
@@ -1265,6 +1729,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 		ProxyBuffering:       bdef.ProxyBuffering,
 		ProxyHTTPVersion:     bdef.ProxyHTTPVersion,
 		ProxyMaxTempFileSize: bdef.ProxyMaxTempFileSize,
+		TimeoutBudgetHeader:  bdef.ProxyTimeoutBudgetHeader,
 	}
 
 	// initialize default server and root location
@@ -1325,6 +1790,8 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 					originalRedirect := defLoc.Redirect
 					originalRewrite := defLoc.Rewrite
 					locationApplyAnnotations(defLoc, anns)
+					defLoc.ObservabilityLabels = n.observabilityLabels(&ing.Ingress)
+					defLoc.ForwardedHeaders = ing.ForwardedHeaders
 					defLoc.Redirect = originalRedirect
 					defLoc.Rewrite = originalRewrite
 				} else {
@@ -1353,6 +1820,8 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 				Service:      &apiv1.Service{},
 			}
 			locationApplyAnnotations(loc, anns)
+			loc.ObservabilityLabels = n.observabilityLabels(&ing.Ingress)
+			loc.ForwardedHeaders = ing.ForwardedHeaders
 
 			servers[host] = &ingress.Server{
 				Hostname: host,
@@ -1362,10 +1831,14 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 				SSLPassthrough:         anns.SSLPassthrough,
 				SSLCiphers:             anns.SSLCipher.SSLCiphers,
 				SSLPreferServerCiphers: anns.SSLCipher.SSLPreferServerCiphers,
+				HTTP2:                  anns.HTTPVersion.HTTP2,
 			}
 		}
 	}
 
+	// hosts of the Ingresses with the scope public
+	publicHosts := sets.New[string]()
+
 	// configure default location, alias, and SSL
 	for _, ing := range data {
 		ingKey := k8s.MetaNamespaceKey(ing)
@@ -1386,6 +1859,22 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 				host = defServerName
 			}
 
+			// a host defined by Ingresses with both scopes is internal,
+			// to never serve the internal paths in the public listeners
+			if anns.Scope == scope.Internal && host != defServerName {
+				servers[host].Internal = true
+			} else {
+				publicHosts.Insert(host)
+			}
+
+			if len(anns.TrustedProxies) > 0 {
+				if len(servers[host].TrustedProxies) == 0 {
+					servers[host].TrustedProxies = anns.TrustedProxies
+				} else if !slices.Equal(servers[host].TrustedProxies, anns.TrustedProxies) {
+					klog.Warningf("Trusted proxies already configured for server %q, skipping (Ingress %q)", host, ingKey)
+				}
+			}
+
 			if len(servers[host].Aliases) == 0 {
 				servers[host].Aliases = anns.Aliases
 				if aliases := allAliases[host]; len(aliases) == 0 {
@@ -1414,6 +1903,16 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 				servers[host].SSLPreferServerCiphers = anns.SSLCipher.SSLPreferServerCiphers
 			}
 
+			// only add the http2 directive if the server does not have it previously configured
+			if servers[host].HTTP2 == "" && anns.HTTPVersion.HTTP2 != "" {
+				servers[host].HTTP2 = anns.HTTPVersion.HTTP2
+			}
+
+			// only add SSL protocols if the server does not have them previously configured
+			if servers[host].SSLProtocols == "" && anns.SSLCipher.SSLProtocols != "" {
+				servers[host].SSLProtocols = anns.SSLCipher.SSLProtocols
+			}
+
 			// only add a certificate if the server does not have one previously configured
 			if servers[host].SSLCert != nil {
 				continue
@@ -1426,8 +1925,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 
 			tlsSecretName := extractTLSSecretName(host, ing, n.store.GetLocalSSLCert)
 			if tlsSecretName == "" {
-				klog.V(3).Infof("Host %q is listed in the TLS section but secretName is empty. Using default certificate", host)
-				servers[host].SSLCert = n.getDefaultSSLCertificate()
+				servers[host].SSLCert = n.getMissingSecretSSLCertificate(host, ing, events)
 				continue
 			}
 
@@ -1462,6 +1960,7 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 			}
 
 			servers[host].SSLCert = cert
+			servers[host].AlternateSSLCert = n.getAlternateSSLCertificate(host, cert, ing, events)
 
 			now := time.Now()
 			if cert.ExpireTime.Before(now) {
@@ -1472,6 +1971,12 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 		}
 	}
 
+	for host := range publicHosts {
+		if servers[host].Internal {
+			klog.Warningf("Server %q is defined by Ingresses with the scope internal and public. It is only served in the internal listeners", host)
+		}
+	}
+
 	for host, hostAliases := range allAliases {
 		if _, ok := servers[host]; !ok {
 			continue
@@ -1500,41 +2005,256 @@ func (n *NGINXController) createServers(data []*ingress.Ingress,
 	return servers
 }
 
+// disableUnavailableModuleLocations disables the features of the locations
+// requiring an optional NGINX module that is disabled or not present in the image
+func disableUnavailableModuleLocations(servers []*ingress.Server, unavailable []string) {
+	disableModSecurity := slices.Contains(unavailable, nginx.ModSecurityModule)
+	disableOpentelemetry := slices.Contains(unavailable, nginx.OpentelemetryModule)
+	disableBrotli := slices.Contains(unavailable, nginx.BrotliModule)
+	if !disableModSecurity && !disableOpentelemetry && !disableBrotli {
+		return
+	}
+
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if disableModSecurity && loc.ModSecurity.EnableSet {
+				klog.Warningf("Ignoring ModSecurity configuration of location %q in server %q, the %v module is not available", loc.Path, server.Hostname, nginx.ModSecurityModule)
+				loc.ModSecurity = modsecurity.Config{}
+			}
+
+			if disableOpentelemetry && loc.Opentelemetry.Set {
+				klog.Warningf("Ignoring OpenTelemetry configuration of location %q in server %q, the %v module is not available", loc.Path, server.Hostname, nginx.OpentelemetryModule)
+				loc.Opentelemetry = opentelemetry.Config{}
+			}
+
+			if disableBrotli && loc.Precompressed.Brotli {
+				klog.Warningf("Ignoring Brotli precompressed responses of location %q in server %q, the %v module is not available", loc.Path, server.Hostname, nginx.BrotliModule)
+				loc.Precompressed.Brotli = false
+			}
+		}
+	}
+}
+
 func locationApplyAnnotations(loc *ingress.Location, anns *annotations.Ingress) {
 	loc.BasicDigestAuth = anns.BasicDigestAuth
+	loc.OIDCAuth = anns.OIDCAuth
 	loc.ClientBodyBufferSize = anns.ClientBodyBufferSize
 	loc.CustomHeaders = anns.CustomHeaders
 	loc.ConfigurationSnippet = anns.ConfigurationSnippet
 	loc.CorsConfig = anns.CorsConfig
+	loc.ConditionalHeader = anns.ConditionalHeader
 	loc.ExternalAuth = anns.ExternalAuth
 	loc.EnableGlobalAuth = anns.EnableGlobalAuth
+	loc.HSTS = anns.HSTS
+	loc.GRPCWeb = anns.GRPCWeb
+	loc.BodyInspection = anns.BodyInspection
+	loc.ContentSecurityPolicy = anns.ContentSecurityPolicy
 	loc.HTTP2PushPreload = anns.HTTP2PushPreload
+	loc.HTTPVersions = anns.HTTPVersion.Versions
 	loc.Opentelemetry = anns.Opentelemetry
 	loc.Proxy = anns.Proxy
+	loc.ProxyCache = anns.ProxyCache
+	loc.LargeFileDelivery = anns.LargeFileDelivery
 	loc.ProxySSL = anns.ProxySSL
 	loc.RateLimit = anns.RateLimit
 	loc.Redirect = anns.Redirect
 	loc.Rewrite = anns.Rewrite
 	loc.UpstreamVhost = anns.UpstreamVhost
+	loc.UpstreamProxyProtocol = anns.UpstreamProxyProtocol
 	loc.Denylist = anns.Denylist
 	loc.Allowlist = anns.Allowlist
 	loc.Denied = anns.Denied
 	loc.XForwardedPrefix = anns.XForwardedPrefix
 	loc.UsePortInRedirects = anns.UsePortInRedirects
+	loc.Paused = anns.Paused
 	loc.Connection = anns.Connection
 	loc.Logs = anns.Logs
+	loc.LuaKV = anns.LuaKV
 	loc.DefaultBackend = anns.DefaultBackend
 	loc.BackendProtocol = anns.BackendProtocol
 	loc.FastCGI = anns.FastCGI
 	loc.CustomHTTPErrors = anns.CustomHTTPErrors
 	loc.DisableProxyInterceptErrors = anns.DisableProxyInterceptErrors
+	loc.ErrorPage = anns.ErrorPage
 	loc.ModSecurity = anns.ModSecurity
 	loc.Satisfy = anns.Satisfy
 	loc.Mirror = anns.Mirror
+	loc.SplitHorizon = anns.SplitHorizon
+	loc.Backup = anns.Backup
+	loc.ExtraSecrets = anns.ExtraSecrets
+	loc.Precompressed = anns.Precompressed
 
 	loc.DefaultBackendUpstreamName = defUpstreamName
 }
 
+// locationApplyServiceAnnotations applies to the location the annotations.ServiceAnnotations
+// defined in the Service used as backend. Annotations present in the Ingress take precedence.
+func (n *NGINXController) locationApplyServiceAnnotations(loc *ingress.Location, ing *ingress.Ingress) {
+	defer locationApplyExternalNameProxySSL(loc)
+
+	merged, ok := annotations.MergeServiceAnnotations(&ing.Ingress, loc.Service)
+	if !ok {
+		return
+	}
+
+	anns, err := annotations.NewServiceAnnotationExtractor(n.store).Extract(merged)
+	if err != nil {
+		klog.Warningf("Ignoring annotations from Service %q used in Ingress %q: %v",
+			k8s.MetaNamespaceKey(loc.Service), k8s.MetaNamespaceKey(ing), err)
+		return
+	}
+
+	klog.V(3).Infof("Applying annotations from Service %q to location %q (Ingress %q)",
+		k8s.MetaNamespaceKey(loc.Service), loc.Path, k8s.MetaNamespaceKey(ing))
+
+	loc.Proxy = anns.Proxy
+	loc.Connection = anns.Connection
+	loc.BackendProtocol = anns.BackendProtocol
+	loc.ProxySSL = anns.ProxySSL
+}
+
+// locationApplyExternalNameProxySSL verifies the certificate of an ExternalName
+// Service against its external name, sent with SNI, when the verification is
+// enabled without proxy-ssl-name. Otherwise NGINX would verify the name of the
+// upstream, which is the same for all the backends.
+func locationApplyExternalNameProxySSL(loc *ingress.Location) {
+	if loc.Service == nil || loc.Service.Spec.Type != apiv1.ServiceTypeExternalName {
+		return
+	}
+
+	if loc.ProxySSL.Verify != "on" || loc.ProxySSL.ProxySSLName != "" {
+		return
+	}
+
+	loc.ProxySSL.ProxySSLName = strings.TrimSuffix(loc.Service.Spec.ExternalName, ".")
+	loc.ProxySSL.ProxySSLServerName = "on"
+}
+
+// pathConflictKey identifies a path of a host
+type pathConflictKey struct {
+	host     string
+	path     string
+	pathType networking.PathType
+}
+
+// pathConflict is a path of a host defined by several Ingresses
+type pathConflict struct {
+	// winner is the namespace/name of the Ingress configuring the path,
+	// empty when the path is rejected
+	winner string
+	// losers are the namespace/name of the Ingresses whose path is not configured
+	losers []string
+}
+
+// pathConflicts are the paths of the hosts defined by several Ingresses
+type pathConflicts map[pathConflictKey]*pathConflict
+
+// resolvePathConflicts returns the paths of the hosts defined by several
+// Ingresses and the Ingress configuring each one, following the policy defined
+// in duplicate-path-policy. The Ingresses are sorted by creation timestamp.
+// Canary Ingresses are merged into the other Ingresses and never conflict.
+func resolvePathConflicts(ingresses []*ingress.Ingress, policy string) pathConflicts {
+	defined := map[pathConflictKey][]string{}
+	for _, ing := range ingresses {
+		if ing.ParsedAnnotations != nil && ing.ParsedAnnotations.Canary.Enabled {
+			continue
+		}
+
+		ingKey := k8s.MetaNamespaceKey(ing)
+		for _, rule := range ing.Spec.Rules {
+			if rule.HTTP == nil {
+				continue
+			}
+
+			host := rule.Host
+			if host == "" {
+				host = defServerName
+			}
+
+			for _, path := range rule.HTTP.Paths {
+				if path.Backend.Service == nil {
+					continue
+				}
+
+				key := newPathConflictKey(host, path.Path, path.PathType)
+				if !slices.Contains(defined[key], ingKey) {
+					defined[key] = append(defined[key], ingKey)
+				}
+			}
+		}
+	}
+
+	conflicts := pathConflicts{}
+	for key, ingKeys := range defined {
+		if len(ingKeys) < 2 {
+			continue
+		}
+
+		switch policy {
+		case ngx_config.DuplicatePathReject:
+			conflicts[key] = &pathConflict{losers: ingKeys}
+		case ngx_config.DuplicatePathNewestWins:
+			last := len(ingKeys) - 1
+			conflicts[key] = &pathConflict{winner: ingKeys[last], losers: ingKeys[:last]}
+		default:
+			conflicts[key] = &pathConflict{winner: ingKeys[0], losers: ingKeys[1:]}
+		}
+	}
+
+	return conflicts
+}
+
+func newPathConflictKey(host, path string, pathType *networking.PathType) pathConflictKey {
+	if path == "" {
+		path = rootLocation
+	}
+
+	key := pathConflictKey{host: host, path: path}
+	if pathType != nil {
+		key.pathType = *pathType
+	}
+
+	return key
+}
+
+// excludes returns true if the path of the host is not configured for the Ingress
+func (pc pathConflicts) excludes(host, path string, pathType *networking.PathType, ingKey string) bool {
+	conflict, ok := pc[newPathConflictKey(host, path, pathType)]
+	return ok && slices.Contains(conflict.losers, ingKey)
+}
+
+// reportPathConflicts emits an Event in the Ingresses whose paths are not
+// configured because other Ingresses define them, and returns the number of
+// such paths of each Ingress, by namespace/name
+func (n *NGINXController) reportPathConflicts(ingresses []*ingress.Ingress) map[string]int {
+	policy := n.store.GetBackendConfiguration().DuplicatePathPolicy
+	conflicts := resolvePathConflicts(ingresses, policy)
+
+	byKey := make(map[string]*ingress.Ingress, len(ingresses))
+	for _, ing := range ingresses {
+		byKey[k8s.MetaNamespaceKey(ing)] = ing
+	}
+
+	counts := map[string]int{}
+	for key, conflict := range conflicts {
+		for _, loser := range conflict.losers {
+			counts[loser]++
+
+			ing := byKey[loser]
+			if conflict.winner == "" {
+				n.decisionEvents.Eventf(ing, key.host+key.path, apiv1.EventTypeWarning, "PathConflict",
+					"Path %q of host %q is defined by several Ingresses and the policy for duplicate paths is %q. The path is not configured", key.path, key.host, policy)
+				continue
+			}
+
+			n.decisionEvents.Eventf(ing, key.host+key.path, apiv1.EventTypeWarning, "PathConflict",
+				"Path %q of host %q is also defined by Ingress %v, which configures it", key.path, key.host, conflict.winner)
+		}
+	}
+
+	return counts
+}
+
 // OK to merge canary ingresses iff there exists one or more ingresses to potentially merge into
 func nonCanaryIngressExists(ingresses, canaryIngresses []*ingress.Ingress) bool {
 	return len(ingresses)-len(canaryIngresses) > 0
@@ -1732,6 +2452,150 @@ func extractTLSSecretName(host string, ing *ingress.Ingress,
 	return ""
 }
 
+// createSplitHorizonUpstreams creates the upstreams of the services serving the internal
+// clients of the locations, setting the name of the upstream in the locations.
+// The locations whose internal service has no active endpoint serve all the clients
+// with the service of the rule.
+func (n *NGINXController) createSplitHorizonUpstreams(upstreams map[string]*ingress.Backend, servers map[string]*ingress.Server) []*ingress.Backend {
+	created := make(map[string]*ingress.Backend)
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			svc := location.SplitHorizon.InternalService
+			if svc == nil || location.Backend == defUpstreamName {
+				continue
+			}
+
+			upstream, ok := upstreams[location.Backend]
+			if !ok {
+				continue
+			}
+
+			sp := locationServicePort(svc, location.Port)
+			if sp == nil {
+				klog.Errorf("Split-horizon service %v/%v has no ports. Ignoring", svc.Namespace, svc.Name)
+				continue
+			}
+
+			name := fmt.Sprintf("split-horizon-%v-%v-%v", svc.Namespace, svc.Name, sp.Port)
+			if _, ok := created[name]; !ok {
+				var zone string
+				if n.cfg.EnableTopologyAwareRouting {
+					zone = getIngressPodZone(svc)
+				} else {
+					zone = emptyZone
+				}
+
+				var nb *ingress.Backend
+				endps := getEndpointsFromSlices(svc, sp, apiv1.ProtocolTCP, zone, n.store.GetServiceEndpointsSlices)
+				if len(endps) > 0 {
+					klog.V(3).Infof("Creating %q upstream based on split-horizon annotation", name)
+
+					nb = upstream.DeepCopy()
+					nb.Name = name
+					nb.Service = svc
+					nb.Port = intstr.FromInt32(sp.Port)
+					nb.Endpoints = endps
+					nb.AlternativeBackends = nil
+				}
+				created[name] = nb
+			}
+
+			if created[name] == nil {
+				klog.Warningf("Split-horizon service %v/%v has no active Endpoint, so using the service of location %q in server %q for the internal clients",
+					svc.Namespace, svc.Name, location.Path, server.Hostname)
+				continue
+			}
+			location.SplitHorizonUpstreamName = name
+		}
+	}
+
+	aUpstreams := make([]*ingress.Backend, 0, len(created))
+	for _, upstream := range created {
+		if upstream != nil {
+			aUpstreams = append(aUpstreams, upstream)
+		}
+	}
+	return aUpstreams
+}
+
+// createBackupUpstreams creates the upstreams of the backup services of the locations,
+// setting the name of the upstream in the locations. The upstreams are created even
+// without active endpoint: the Lua balancer only fails over to the backup services
+// with endpoints.
+func (n *NGINXController) createBackupUpstreams(upstreams map[string]*ingress.Backend, servers map[string]*ingress.Server) []*ingress.Backend {
+	created := make(map[string]*ingress.Backend)
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			svc := location.Backup.Service
+			if svc == nil || location.Backend == defUpstreamName {
+				continue
+			}
+
+			upstream, ok := upstreams[location.Backend]
+			if !ok {
+				continue
+			}
+
+			sp := locationServicePort(svc, location.Port)
+			if sp == nil {
+				klog.Errorf("Backup service %v/%v has no ports. Ignoring", svc.Namespace, svc.Name)
+				continue
+			}
+
+			name := fmt.Sprintf("backup-%v-%v-%v", svc.Namespace, svc.Name, sp.Port)
+			if _, ok := created[name]; !ok {
+				var zone string
+				if n.cfg.EnableTopologyAwareRouting {
+					zone = getIngressPodZone(svc)
+				} else {
+					zone = emptyZone
+				}
+
+				endps := getEndpointsFromSlices(svc, sp, apiv1.ProtocolTCP, zone, n.store.GetServiceEndpointsSlices)
+				if len(endps) == 0 {
+					klog.Warningf("Backup service %v/%v of location %q in server %q has no active Endpoint",
+						svc.Namespace, svc.Name, location.Path, server.Hostname)
+				}
+
+				klog.V(3).Infof("Creating %q upstream based on backup-service annotation", name)
+
+				nb := upstream.DeepCopy()
+				nb.Name = name
+				nb.Service = svc
+				nb.Port = intstr.FromInt32(sp.Port)
+				nb.Endpoints = endps
+				nb.AlternativeBackends = nil
+				created[name] = nb
+			}
+			location.BackupUpstreamName = name
+		}
+	}
+
+	aUpstreams := make([]*ingress.Backend, 0, len(created))
+	for _, upstream := range created {
+		aUpstreams = append(aUpstreams, upstream)
+	}
+	return aUpstreams
+}
+
+// locationServicePort returns the port of the service serving the requests of a location
+// instead of the service of the rule matching the port of the location, or else its first port
+func locationServicePort(svc *apiv1.Service, port intstr.IntOrString) *apiv1.ServicePort {
+	if len(svc.Spec.Ports) == 0 {
+		return nil
+	}
+
+	for i := range svc.Spec.Ports {
+		sp := &svc.Spec.Ports[i]
+		if (port.Type == intstr.String && sp.Name == port.StrVal) || (port.Type == intstr.Int && sp.Port == port.IntVal) {
+			return sp
+		}
+	}
+	return &svc.Spec.Ports[0]
+}
+
 // checks conditions for whether or not an upstream should be created for a custom default backend
 func shouldCreateUpstreamForLocationDefaultBackend(upstream *ingress.Backend, location *ingress.Location) bool {
 	return (upstream.Name == location.Backend) &&
@@ -1883,11 +2747,22 @@ func (n *NGINXController) getStreamSnippets(ingresses []*ingress.Ingress) []stri
 // newTrafficShapingPolicy creates new ingress.TrafficShapingPolicy instance using canary configuration
 func newTrafficShapingPolicy(cfg *canary.Config) ingress.TrafficShapingPolicy {
 	return ingress.TrafficShapingPolicy{
-		Weight:        cfg.Weight,
-		WeightTotal:   cfg.WeightTotal,
-		Header:        cfg.Header,
-		HeaderValue:   cfg.HeaderValue,
-		HeaderPattern: cfg.HeaderPattern,
-		Cookie:        cfg.Cookie,
+		Weight:         cfg.Weight,
+		WeightTotal:    cfg.WeightTotal,
+		Header:         cfg.Header,
+		HeaderValue:    cfg.HeaderValue,
+		HeaderPattern:  cfg.HeaderPattern,
+		Cookie:         cfg.Cookie,
+		DecisionHeader: cfg.DecisionHeader,
+		Expression:     cfg.Expression,
+	}
+}
+
+// newExternalNameResolution creates new ingress.ExternalNameResolution instance using the external name configuration
+func newExternalNameResolution(cfg *externalname.Config) ingress.ExternalNameResolution {
+	return ingress.ExternalNameResolution{
+		Nameservers: cfg.Nameservers,
+		TTL:         cfg.TTL,
+		Static:      cfg.Static,
 	}
 }
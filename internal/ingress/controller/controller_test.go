@@ -27,6 +27,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -38,14 +39,19 @@ import (
 	networking "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/record"
 
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/canary"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipallowlist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/opentelemetry"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/precompressed"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/sessionaffinity"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
@@ -56,6 +62,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/net/ssl"
+	"k8s.io/ingress-nginx/internal/nginx"
 
 	"k8s.io/ingress-nginx/pkg/util/file"
 )
@@ -66,14 +73,37 @@ const (
 )
 
 type fakeIngressStore struct {
-	ingresses     []*ingress.Ingress
-	configuration ngx_config.Configuration
+	ingresses          []*ingress.Ingress
+	ignored            []store.IgnoredIngress
+	configuration      ngx_config.Configuration
+	annotationPrefixes []string
+}
+
+func (fakeIngressStore) GetNamespaceLabels(_ string) map[string]string {
+	return nil
 }
 
 func (fakeIngressStore) GetIngressClass(_ *networking.Ingress, _ *ingressclass.Configuration) (string, error) {
 	return "nginx", nil
 }
 
+func (fis *fakeIngressStore) GetAnnotationPrefixes(_ *networking.Ingress) []string {
+	return fis.annotationPrefixes
+}
+
+func (fis *fakeIngressStore) GetIngressAnnotations(ing *networking.Ingress) map[string]string {
+	aliased := map[string]string{}
+	for key, value := range ing.GetAnnotations() {
+		aliased[key] = value
+		for _, prefix := range fis.annotationPrefixes {
+			if name, ok := strings.CutPrefix(key, prefix+"/"); ok {
+				aliased[parser.GetAnnotationWithPrefix(name)] = value
+			}
+		}
+	}
+	return aliased
+}
+
 func (fis *fakeIngressStore) GetBackendConfiguration() ngx_config.Configuration {
 	return fis.configuration
 }
@@ -105,6 +135,10 @@ func (fis *fakeIngressStore) ListIngresses() []*ingress.Ingress {
 	return fis.ingresses
 }
 
+func (fis *fakeIngressStore) ListIgnoredIngresses() []store.IgnoredIngress {
+	return fis.ignored
+}
+
 func (fis *fakeIngressStore) FilterIngresses(ingresses []*ingress.Ingress, _ store.IngressFilterFunc) []*ingress.Ingress {
 	return ingresses
 }
@@ -113,6 +147,10 @@ func (fakeIngressStore) GetLocalSSLCert(_ string) (*ingress.SSLCert, error) {
 	return nil, fmt.Errorf("test error")
 }
 
+func (fakeIngressStore) GetSecretHostnames(_ string) []string {
+	return []string{}
+}
+
 func (fakeIngressStore) ListLocalSSLCerts() []*ingress.SSLCert {
 	return nil
 }
@@ -127,6 +165,10 @@ func (fakeIngressStore) GetDefaultBackend() defaults.Backend {
 
 func (fakeIngressStore) Run(_ chan struct{}) {}
 
+func (fakeIngressStore) HasSynced() bool {
+	return true
+}
+
 type testNginxTestCommand struct {
 	t        *testing.T
 	expected string
@@ -138,6 +180,10 @@ func (ntc testNginxTestCommand) ExecCommand(_ ...string) *exec.Cmd {
 	return nil
 }
 
+func (ntc testNginxTestCommand) ExecCommandContext(_ context.Context, _ ...string) *exec.Cmd {
+	return nil
+}
+
 func (ntc testNginxTestCommand) Test(cfg string) ([]byte, error) {
 	fd, err := os.Open(cfg)
 	if err != nil {
@@ -158,14 +204,14 @@ func (ntc testNginxTestCommand) Test(cfg string) ([]byte, error) {
 type fakeTemplate struct{}
 
 func (fakeTemplate) Write(conf *ngx_config.TemplateConfig) ([]byte, error) {
-	r := []byte{}
-	for _, s := range conf.Servers {
-		if len(r) > 0 {
+	r := []byte("server_names ")
+	for i, s := range conf.Servers {
+		if i > 0 {
 			r = append(r, ',')
 		}
 		r = append(r, []byte(s.Hostname)...)
 	}
-	return r, nil
+	return append(r, ';'), nil
 }
 
 func TestCheckIngress(t *testing.T) {
@@ -224,7 +270,7 @@ func TestCheckIngress(t *testing.T) {
 		nginx.command = testNginxTestCommand{
 			t:        t,
 			err:      nil,
-			expected: "_,example.com",
+			expected: "server_names _,example.com;",
 		}
 		if nginx.CheckIngress(ing) != nil {
 			t.Errorf("with a new ingress without error, no error should be returned")
@@ -243,7 +289,7 @@ func TestCheckIngress(t *testing.T) {
 			nginx.command = testNginxTestCommand{
 				t:        t,
 				err:      nil,
-				expected: "_,test.example.com",
+				expected: "server_names _,test.example.com;",
 			}
 			if nginx.CheckIngress(ing) != nil {
 				t.Errorf("with a new ingress without error, no error should be returned")
@@ -255,13 +301,27 @@ func TestCheckIngress(t *testing.T) {
 				t:        t,
 				err:      fmt.Errorf("test error"),
 				out:      []byte("this is the test command output"),
-				expected: "_,test.example.com",
+				expected: "server_names _,test.example.com;",
 			}
 			if nginx.CheckIngress(ing) == nil {
 				t.Errorf("with a new ingress with an error, an error should be returned")
 			}
 		})
 
+		t.Run("When only the syntax is tested", func(t *testing.T) {
+			defer func() {
+				nginx.cfg.SyntaxOnlyValidationTest = false
+			}()
+			nginx.cfg.SyntaxOnlyValidationTest = true
+			nginx.command = testNginxTestCommand{
+				t:   t,
+				err: fmt.Errorf("nginx -t should not run"),
+			}
+			if err := nginx.CheckIngress(ing); err != nil {
+				t.Errorf("with a valid syntax, no error should be returned: %v", err)
+			}
+		})
+
 		t.Run("When the default annotation prefix is used despite an override", func(t *testing.T) {
 			defer func() {
 				parser.AnnotationsPrefix = "nginx.ingress.kubernetes.io"
@@ -277,6 +337,26 @@ func TestCheckIngress(t *testing.T) {
 			}
 		})
 
+		t.Run("When the default annotation prefix is used with an override and accepted by the IngressClass", func(t *testing.T) {
+			defer func() {
+				parser.AnnotationsPrefix = "nginx.ingress.kubernetes.io"
+			}()
+			parser.AnnotationsPrefix = "ingress.kubernetes.io"
+			nginx.store = &fakeIngressStore{
+				ingresses:          []*ingress.Ingress{},
+				annotationPrefixes: []string{"nginx.ingress.kubernetes.io"},
+			}
+			accepted := ing.DeepCopy()
+			accepted.ObjectMeta.Annotations["nginx.ingress.kubernetes.io/backend-protocol"] = "GRPC"
+			nginx.command = testNginxTestCommand{
+				t:   t,
+				err: nil,
+			}
+			if err := nginx.CheckIngress(accepted); err != nil {
+				t.Errorf("with the default annotation prefix accepted by the IngressClass, no error should be returned: %v", err)
+			}
+		})
+
 		t.Run("When snippets are disabled and user tries to use snippet annotation", func(t *testing.T) {
 			nginx.store = &fakeIngressStore{
 				ingresses: []*ingress.Ingress{},
@@ -315,6 +395,28 @@ func TestCheckIngress(t *testing.T) {
 			}
 		})
 
+		t.Run("When invalid directives are used in annotations with a prefix accepted by the IngressClass", func(t *testing.T) {
+			nginx.store = &fakeIngressStore{
+				ingresses: []*ingress.Ingress{},
+				configuration: ngx_config.Configuration{
+					AnnotationValueWordBlocklist: "invalid_directive",
+				},
+				annotationPrefixes: []string{"example.com"},
+			}
+			nginx.command = testNginxTestCommand{
+				t:   t,
+				err: nil,
+			}
+			aliased := ing.DeepCopy()
+			aliased.ObjectMeta.Annotations = map[string]string{
+				"kubernetes.io/ingress.class": "nginx",
+				"example.com/custom-headers":  "invalid_directive",
+			}
+			if err := nginx.CheckIngress(aliased); err == nil {
+				t.Errorf("with an invalid value in an annotation using an accepted prefix the ingress should be rejected")
+			}
+		})
+
 		t.Run("When a new catch-all ingress is being created despite catch-alls being disabled ", func(t *testing.T) {
 			backendBefore := ing.Spec.DefaultBackend
 			disableCatchAllBefore := nginx.cfg.DisableCatchAll
@@ -343,6 +445,30 @@ func TestCheckIngress(t *testing.T) {
 			nginx.cfg.DisableCatchAll = disableCatchAllBefore
 		})
 
+		t.Run("When TLS entries without a secretName are rejected", func(t *testing.T) {
+			defer func() {
+				ing.Spec.TLS = nil
+			}()
+			nginx.store = &fakeIngressStore{
+				ingresses: []*ingress.Ingress{},
+				configuration: ngx_config.Configuration{
+					TLSMissingSecretPolicy: ngx_config.TLSMissingSecretReject,
+				},
+			}
+			nginx.command = testNginxTestCommand{
+				t:   t,
+				err: nil,
+			}
+			ing.Spec.TLS = []networking.IngressTLS{
+				{
+					Hosts: []string{"test.example.com"},
+				},
+			}
+			if nginx.CheckIngress(ing) == nil {
+				t.Errorf("with a TLS entry without secretName, the ingress should be rejected")
+			}
+		})
+
 		t.Run("When the ingress is in a different namespace than the watched one", func(t *testing.T) {
 			defer func() {
 				nginx.cfg.Namespace = "test-namespace"
@@ -1393,6 +1519,232 @@ func TestMergeAlternativeBackends(t *testing.T) {
 	}
 }
 
+func TestDisableUnavailableModuleLocations(t *testing.T) {
+	newServers := func() []*ingress.Server {
+		return []*ingress.Server{
+			{
+				Hostname: "example.com",
+				Locations: []*ingress.Location{
+					{
+						Path:          "/",
+						ModSecurity:   modsecurity.Config{Enable: true, EnableSet: true},
+						Opentelemetry: opentelemetry.Config{Enabled: true, Set: true},
+						Precompressed: precompressed.Config{Gzip: true, Brotli: true},
+					},
+				},
+			},
+		}
+	}
+
+	servers := newServers()
+	disableUnavailableModuleLocations(servers, []string{})
+	if !servers[0].Locations[0].ModSecurity.Enable || !servers[0].Locations[0].Opentelemetry.Enabled {
+		t.Errorf("expected the features of available modules to be enabled")
+	}
+
+	servers = newServers()
+	disableUnavailableModuleLocations(servers, []string{nginx.ModSecurityModule})
+	if servers[0].Locations[0].ModSecurity.EnableSet {
+		t.Errorf("expected ModSecurity to be disabled but %v returned", servers[0].Locations[0].ModSecurity)
+	}
+	if !servers[0].Locations[0].Opentelemetry.Enabled {
+		t.Errorf("expected OpenTelemetry to be enabled")
+	}
+
+	servers = newServers()
+	disableUnavailableModuleLocations(servers, []string{nginx.OpentelemetryModule})
+	if servers[0].Locations[0].Opentelemetry.Set {
+		t.Errorf("expected OpenTelemetry to be disabled but %v returned", servers[0].Locations[0].Opentelemetry)
+	}
+
+	servers = newServers()
+	disableUnavailableModuleLocations(servers, []string{nginx.BrotliModule})
+	if expected := (precompressed.Config{Gzip: true}); servers[0].Locations[0].Precompressed != expected {
+		t.Errorf("expected only the Brotli precompressed responses to be disabled but %v returned", servers[0].Locations[0].Precompressed)
+	}
+}
+
+func TestFindWildcardSSLCertificate(t *testing.T) {
+	certs := []*ingress.SSLCert{
+		{Name: "wildcard-b", Namespace: "user-namespace", CN: []string{"*.example.com"}, Certificate: fakeX509Cert([]string{"*.example.com"})},
+		{Name: "wildcard-a", Namespace: "user-namespace", CN: []string{"*.example.com"}, Certificate: fakeX509Cert([]string{"*.example.com"})},
+		{Name: "exact", Namespace: "user-namespace", CN: []string{"foo.example.org"}, Certificate: fakeX509Cert([]string{"foo.example.org"})},
+		{Name: "other-namespace", Namespace: "other-namespace", CN: []string{"*.example.org"}, Certificate: fakeX509Cert([]string{"*.example.org"})},
+		{Name: "no-certificate", Namespace: "user-namespace", CN: []string{"*.example.org"}},
+	}
+
+	testCases := []struct {
+		host    string
+		expName string
+	}{
+		{"foo.example.com", "wildcard-a"},
+		{"foo.bar.example.com", ""},
+		{"foo.example.org", ""},
+		{"bar.example.org", ""},
+	}
+
+	for _, tc := range testCases {
+		cert := findWildcardSSLCertificate(tc.host, "user-namespace", certs)
+		name := ""
+		if cert != nil {
+			name = cert.Name
+		}
+
+		if name != tc.expName {
+			t.Errorf("expected certificate %q for host %q but %q returned", tc.expName, tc.host, name)
+		}
+	}
+}
+
+func TestLocationApplyExternalNameProxySSL(t *testing.T) {
+	externalName := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type:         corev1.ServiceTypeExternalName,
+			ExternalName: "api.example.com.",
+		},
+	}
+	clusterIP := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeClusterIP,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		service       *corev1.Service
+		proxySSL      proxyssl.Config
+		expName       string
+		expServerName string
+	}{
+		{"ExternalName with verification", externalName, proxyssl.Config{Verify: "on", ProxySSLServerName: "off"}, "api.example.com", "on"},
+		{"ExternalName with proxy-ssl-name", externalName, proxyssl.Config{Verify: "on", ProxySSLName: "other.example.com", ProxySSLServerName: "off"}, "other.example.com", "off"},
+		{"ExternalName without verification", externalName, proxyssl.Config{Verify: "off", ProxySSLServerName: "off"}, "", "off"},
+		{"ClusterIP with verification", clusterIP, proxyssl.Config{Verify: "on", ProxySSLServerName: "off"}, "", "off"},
+		{"without Service", nil, proxyssl.Config{Verify: "on", ProxySSLServerName: "off"}, "", "off"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			loc := &ingress.Location{Service: tc.service, ProxySSL: tc.proxySSL}
+			locationApplyExternalNameProxySSL(loc)
+
+			if loc.ProxySSL.ProxySSLName != tc.expName || loc.ProxySSL.ProxySSLServerName != tc.expServerName {
+				t.Errorf("expected %q and %q but %q and %q returned", tc.expName, tc.expServerName, loc.ProxySSL.ProxySSLName, loc.ProxySSL.ProxySSLServerName)
+			}
+		})
+	}
+}
+
+func TestResolvePathConflicts(t *testing.T) {
+	pathTypePrefix := networking.PathTypePrefix
+	pathTypeExact := networking.PathTypeExact
+
+	newIngress := func(name string, canaryEnabled bool, paths ...networking.HTTPIngressPath) *ingress.Ingress {
+		return &ingress.Ingress{
+			Ingress: networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+				Spec: networking.IngressSpec{
+					Rules: []networking.IngressRule{
+						{
+							Host: "example.com",
+							IngressRuleValue: networking.IngressRuleValue{
+								HTTP: &networking.HTTPIngressRuleValue{Paths: paths},
+							},
+						},
+					},
+				},
+			},
+			ParsedAnnotations: &annotations.Ingress{Canary: canary.Config{Enabled: canaryEnabled}},
+		}
+	}
+	newPath := func(path string, pathType *networking.PathType) networking.HTTPIngressPath {
+		return networking.HTTPIngressPath{
+			Path:     path,
+			PathType: pathType,
+			Backend: networking.IngressBackend{
+				Service: &networking.IngressServiceBackend{Name: "http-svc", Port: networking.ServiceBackendPort{Number: 80}},
+			},
+		}
+	}
+
+	ingresses := []*ingress.Ingress{
+		newIngress("oldest", false, newPath("/api", &pathTypePrefix), newPath("/", &pathTypePrefix)),
+		newIngress("exact", false, newPath("/api", &pathTypeExact)),
+		newIngress("canary", true, newPath("/api", &pathTypePrefix)),
+		newIngress("newest", false, newPath("/api", &pathTypePrefix), newPath("/web", &pathTypePrefix)),
+	}
+
+	testCases := []struct {
+		policy    string
+		expWinner string
+		expLosers []string
+	}{
+		{ngx_config.DuplicatePathOldestWins, "default/oldest", []string{"default/newest"}},
+		{ngx_config.DuplicatePathNewestWins, "default/newest", []string{"default/oldest"}},
+		{ngx_config.DuplicatePathReject, "", []string{"default/oldest", "default/newest"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy, func(t *testing.T) {
+			conflicts := resolvePathConflicts(ingresses, tc.policy)
+			if len(conflicts) != 1 {
+				t.Fatalf("expected one conflicting path but %v returned", len(conflicts))
+			}
+
+			conflict := conflicts[pathConflictKey{host: "example.com", path: "/api", pathType: pathTypePrefix}]
+			if conflict == nil {
+				t.Fatalf("expected a conflict for the path /api of the host example.com")
+			}
+			if conflict.winner != tc.expWinner || !slices.Equal(conflict.losers, tc.expLosers) {
+				t.Errorf("expected winner %q and losers %v but %q and %v returned", tc.expWinner, tc.expLosers, conflict.winner, conflict.losers)
+			}
+
+			for _, loser := range tc.expLosers {
+				if !conflicts.excludes("example.com", "/api", &pathTypePrefix, loser) {
+					t.Errorf("expected the path /api to be excluded for Ingress %v", loser)
+				}
+			}
+			if conflicts.excludes("example.com", "/api", &pathTypeExact, "default/exact") {
+				t.Errorf("expected the path /api of another type to be configured for Ingress default/exact")
+			}
+		})
+	}
+}
+
+func TestLocationServicePort(t *testing.T) {
+	svc := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "http", Port: 80},
+				{Name: "grpc", Port: 9090},
+			},
+		},
+	}
+
+	testCases := map[string]struct {
+		port     intstr.IntOrString
+		expected int32
+	}{
+		"port number":         {intstr.FromInt32(9090), 9090},
+		"port name":           {intstr.FromString("grpc"), 9090},
+		"missing port number": {intstr.FromInt32(8080), 80},
+		"missing port name":   {intstr.FromString("https"), 80},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			sp := locationServicePort(svc, tc.port)
+			if sp == nil || sp.Port != tc.expected {
+				t.Errorf("expected the port %v but %v returned", tc.expected, sp)
+			}
+		})
+	}
+
+	if sp := locationServicePort(&corev1.Service{}, intstr.FromInt32(80)); sp != nil {
+		t.Errorf("expected no port for a service without ports but %v returned", sp)
+	}
+}
+
 func TestExtractTLSSecretName(t *testing.T) {
 	testCases := map[string]struct {
 		host    string
@@ -2540,7 +2892,7 @@ func TestGetBackendServers(t *testing.T) {
 
 	for _, testCase := range testCases {
 		nginxController := newDynamicNginxController(t, testCase.SetConfigMap)
-		upstreams, servers := nginxController.getBackendServers(testCase.Ingresses)
+		upstreams, servers := nginxController.getBackendServers(context.TODO(), testCase.Ingresses, nil)
 		testCase.Validate(testCase.Ingresses, upstreams, servers)
 	}
 }
@@ -2588,6 +2940,7 @@ func newNGINXController(t *testing.T) *NGINXController {
 		fmt.Sprintf("%v/tcp", ns),
 		fmt.Sprintf("%v/udp", ns),
 		"",
+		"",
 		10*time.Minute,
 		clientSet,
 		channels.NewRingChannel(10),
@@ -2597,7 +2950,10 @@ func newNGINXController(t *testing.T) *NGINXController {
 			Controller:      "k8s.io/ingress-nginx",
 			AnnotationValue: "nginx",
 		},
+		nil,
+		false,
 		false,
+		nil,
 	)
 
 	sslCert := ssl.GetFakeSSLCert()
@@ -2609,9 +2965,10 @@ func newNGINXController(t *testing.T) *NGINXController {
 	}
 
 	return &NGINXController{
-		store:   storer,
-		cfg:     config,
-		command: NewNginxCommand(),
+		store:    storer,
+		cfg:      config,
+		command:  NewNginxCommand(),
+		recorder: &record.FakeRecorder{},
 	}
 }
 
@@ -2654,6 +3011,7 @@ func newDynamicNginxController(t *testing.T, setConfigMap func(string) *corev1.C
 		fmt.Sprintf("%v/tcp", ns),
 		fmt.Sprintf("%v/udp", ns),
 		"",
+		"",
 		10*time.Minute,
 		clientSet,
 		channels.NewRingChannel(10),
@@ -2663,7 +3021,10 @@ func newDynamicNginxController(t *testing.T, setConfigMap func(string) *corev1.C
 			Controller:      "k8s.io/ingress-nginx",
 			AnnotationValue: "nginx",
 		},
-		false)
+		nil,
+		false,
+		false,
+		nil)
 
 	sslCert := ssl.GetFakeSSLCert()
 	config := &Configuration{
@@ -2678,5 +3039,6 @@ func newDynamicNginxController(t *testing.T, setConfigMap func(string) *corev1.C
 		cfg:             config,
 		command:         NewNginxCommand(),
 		metricCollector: metric.DummyCollector{},
+		recorder:        &record.FakeRecorder{},
 	}
 }
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/net/ssl"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// customDomainCert is a certificate of a custom domain and the version of
+// the Secret it was created from
+type customDomainCert struct {
+	resourceVersion string
+	cert            *ingress.SSLCert
+}
+
+// getCustomDomains returns the custom domains defined in the ConfigMap
+// configured using the flag --custom-domains-configmap, sorted by hostname
+func (n *NGINXController) getCustomDomains(servers []*ingress.Server) []ingress.CustomDomain {
+	if n.cfg.CustomDomainsConfigMapName == "" {
+		return nil
+	}
+
+	configmap, err := n.store.GetConfigMap(n.cfg.CustomDomainsConfigMapName)
+	if err != nil {
+		klog.Warningf("Error getting ConfigMap %q: %v", n.cfg.CustomDomainsConfigMapName, err)
+		return nil
+	}
+
+	hostnames := make(map[string]bool, len(servers))
+	for _, server := range servers {
+		hostnames[server.Hostname] = true
+		for _, alias := range server.Aliases {
+			hostnames[alias] = true
+		}
+	}
+
+	certs := make(map[string]customDomainCert)
+	domains := make([]ingress.CustomDomain, 0, len(configmap.Data))
+	for hostname, value := range configmap.Data {
+		server, secretKey, err := parseCustomDomain(hostname, value)
+		if err != nil {
+			klog.Warningf("Error parsing custom domain %q: %v", hostname, err)
+			continue
+		}
+
+		if hostnames[hostname] {
+			klog.Warningf("Ignoring custom domain %q: the hostname is already defined in an Ingress", hostname)
+			continue
+		}
+
+		if server == defServerName || !hostnames[server] {
+			klog.Warningf("Ignoring custom domain %q: server %q does not exist", hostname, server)
+			continue
+		}
+
+		domain := ingress.CustomDomain{
+			Hostname: hostname,
+			Server:   server,
+		}
+
+		if secretKey != "" {
+			cert, err := n.getCustomDomainCert(secretKey, certs)
+			if err != nil {
+				klog.Warningf("Error getting certificate of custom domain %q: %v", hostname, err)
+			} else if !ssl.IsValidHostname(hostname, cert.CN) {
+				klog.Warningf("Ignoring certificate of custom domain %q: Secret %q is not valid for the hostname", hostname, secretKey)
+			} else {
+				domain.SSLCert = cert
+			}
+		}
+
+		domains = append(domains, domain)
+	}
+
+	// certificates of Secrets no longer referenced are released
+	n.customDomainCerts = certs
+
+	sort.SliceStable(domains, func(i, j int) bool {
+		return domains[i].Hostname < domains[j].Hostname
+	})
+
+	return domains
+}
+
+// getCustomDomainCert returns the certificate contained in the Secret matching key.
+// Certificates are created only when the Secret changed since the previous sync.
+func (n *NGINXController) getCustomDomainCert(key string, certs map[string]customDomainCert) (*ingress.SSLCert, error) {
+	secret, err := n.store.GetSecret(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, cache := range []map[string]customDomainCert{certs, n.customDomainCerts} {
+		if cached, ok := cache[key]; ok && cached.resourceVersion == secret.ResourceVersion {
+			certs[key] = cached
+			return cached.cert, nil
+		}
+	}
+
+	cert, okcert := secret.Data[apiv1.TLSCertKey]
+	pkey, okkey := secret.Data[apiv1.TLSPrivateKeyKey]
+	if !okcert || !okkey {
+		return nil, fmt.Errorf("secret %q must contain the keys %v and %v", key, apiv1.TLSCertKey, apiv1.TLSPrivateKeyKey)
+	}
+
+	sslCert, err := ssl.CreateSSLCert(cert, pkey, string(secret.UID))
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error creating SSL Cert: %w", err)
+	}
+	sslCert.Name = secret.Name
+	sslCert.Namespace = secret.Namespace
+
+	certs[key] = customDomainCert{
+		resourceVersion: secret.ResourceVersion,
+		cert:            sslCert,
+	}
+
+	return sslCert, nil
+}
+
+// parseCustomDomain parses the value of a custom domain in the form
+// "hostname[,namespace/secret]" and returns the hostname of the server
+// and the key of the TLS Secret
+func parseCustomDomain(hostname, value string) (server, secretKey string, err error) {
+	if errs := validation.IsDNS1123Subdomain(hostname); len(errs) > 0 {
+		return "", "", fmt.Errorf("invalid hostname: %v", strings.Join(errs, ", "))
+	}
+
+	server, secretKey, _ = strings.Cut(strings.TrimSpace(value), ",")
+	server = strings.TrimSpace(server)
+	secretKey = strings.TrimSpace(secretKey)
+
+	if server == "" {
+		return "", "", fmt.Errorf("missing server hostname")
+	}
+
+	if secretKey != "" {
+		if _, _, err := k8s.ParseNameNS(secretKey); err != nil {
+			return "", "", err
+		}
+	}
+
+	return server, secretKey, nil
+}
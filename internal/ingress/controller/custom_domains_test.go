@@ -0,0 +1,82 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestParseCustomDomain(t *testing.T) {
+	testCases := []struct {
+		hostname  string
+		value     string
+		server    string
+		secretKey string
+		expErr    bool
+	}{
+		{"shop.customer.com", "tenant.example.com", "tenant.example.com", "", false},
+		{"shop.customer.com", " tenant.example.com , tenants/shop-tls ", "tenant.example.com", "tenants/shop-tls", false},
+		{"shop.customer.com", "tenant.example.com,shop-tls", "", "", true},
+		{"shop.customer.com", "", "", "", true},
+		{"Invalid_Domain", "tenant.example.com", "", "", true},
+	}
+
+	for _, tc := range testCases {
+		server, secretKey, err := parseCustomDomain(tc.hostname, tc.value)
+		if tc.expErr {
+			if err == nil {
+				t.Errorf("%v=%v: expected error but none returned", tc.hostname, tc.value)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%v=%v: unexpected error: %v", tc.hostname, tc.value, err)
+			continue
+		}
+
+		if server != tc.server || secretKey != tc.secretKey {
+			t.Errorf("%v=%v: expected %v and %v but %v and %v returned", tc.hostname, tc.value, tc.server, tc.secretKey, server, secretKey)
+		}
+	}
+}
+
+func TestBuildServerAliases(t *testing.T) {
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{Hostname: "tenant.example.com", Aliases: []string{"www.tenant.example.com"}},
+		},
+		CustomDomains: []ingress.CustomDomain{
+			{Hostname: "shop.customer.com", Server: "tenant.example.com"},
+		},
+	}
+
+	expected := map[string]string{
+		"shop.customer.com": "tenant.example.com",
+	}
+	if aliases := buildServerAliases(pcfg, false); !reflect.DeepEqual(aliases, expected) {
+		t.Errorf("expected %v but %v returned", expected, aliases)
+	}
+
+	expected["www.tenant.example.com"] = "tenant.example.com"
+	if aliases := buildServerAliases(pcfg, true); !reflect.DeepEqual(aliases, expected) {
+		t.Errorf("expected %v but %v returned", expected, aliases)
+	}
+}
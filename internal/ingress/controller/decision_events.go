@@ -0,0 +1,92 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// decisionEvents emits the Events explaining the decisions taken about the
+// Ingresses while building the configuration. As the configuration is built
+// in every synchronization, an Event is only emitted when the decision about
+// its subject changes. A nil decisionEvents emits no Event, like when the
+// configuration is built to validate an Ingress.
+type decisionEvents struct {
+	recorder record.EventRecorder
+	// emitted contains the message of the decisions of the previous synchronization
+	emitted map[decisionSubject]string
+	// taken contains the message of the decisions of the running synchronization
+	taken map[decisionSubject]string
+}
+
+// decisionSubject identifies what a decision is about in an Ingress,
+// like a host or a path
+type decisionSubject struct {
+	ingress string
+	reason  string
+	subject string
+}
+
+func newDecisionEvents(recorder record.EventRecorder) *decisionEvents {
+	return &decisionEvents{
+		recorder: recorder,
+		emitted:  map[decisionSubject]string{},
+		taken:    map[decisionSubject]string{},
+	}
+}
+
+// Eventf emits an Event in the Ingress unless the same decision about the
+// subject was already emitted
+func (d *decisionEvents) Eventf(ing *ingress.Ingress, subject, eventtype, reason, messageFmt string, args ...interface{}) {
+	if d == nil {
+		return
+	}
+
+	key := decisionSubject{
+		ingress: k8s.MetaNamespaceKey(ing),
+		reason:  reason,
+		subject: subject,
+	}
+	message := fmt.Sprintf(messageFmt, args...)
+
+	if taken, ok := d.taken[key]; ok && taken == message {
+		return
+	}
+	d.taken[key] = message
+
+	if emitted, ok := d.emitted[key]; ok && emitted == message {
+		return
+	}
+
+	d.recorder.Event(&ing.Ingress, eventtype, reason, message)
+}
+
+// synced ends a synchronization. The decisions not taken again are
+// forgotten, to emit their Events when they are taken later.
+func (d *decisionEvents) synced() {
+	if d == nil {
+		return
+	}
+
+	d.emitted = d.taken
+	d.taken = map[decisionSubject]string{}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"testing"
+
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestDecisionEvents(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	events := newDecisionEvents(recorder)
+
+	ing := &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "foo",
+				Namespace: "default",
+			},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		events.Eventf(ing, "foo.bar", apiv1.EventTypeNormal, "TLS", "Host %q uses the default certificate", "foo.bar")
+		events.Eventf(ing, "foo.bar", apiv1.EventTypeNormal, "TLS", "Host %q uses the default certificate", "foo.bar")
+		events.synced()
+	}
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but %v were emitted", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	events.Eventf(ing, "foo.bar", apiv1.EventTypeWarning, "TLS", "Host %q is not configured", "foo.bar")
+	events.synced()
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected a new event after the decision changed but %v were emitted", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	events.synced()
+	events.Eventf(ing, "foo.bar", apiv1.EventTypeWarning, "TLS", "Host %q is not configured", "foo.bar")
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected a new event after the decision was taken again but %v were emitted", len(recorder.Events))
+	}
+
+	var admission *decisionEvents
+	admission.Eventf(ing, "foo.bar", apiv1.EventTypeWarning, "TLS", "Host %q is not configured", "foo.bar")
+	admission.synced()
+}
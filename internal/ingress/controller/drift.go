@@ -0,0 +1,257 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+const (
+	// configurationChecksumsAnnotation is the annotation of the Lease of the
+	// leader election containing the checksum of the configuration running in
+	// each replica of the controller, by pod name
+	configurationChecksumsAnnotation = "ingress-nginx.kubernetes.io/configuration-checksums"
+
+	// configDriftCheckPeriod is the period of the publication of the checksum
+	// of the running configuration and of the comparison of the replicas
+	configDriftCheckPeriod = 30 * time.Second
+)
+
+// configDrift tracks the replicas of the controller running a configuration
+// different from the other replicas
+type configDrift struct {
+	// since contains the time each drifted replica was first seen, by pod name
+	since map[string]time.Time
+	// reported contains the replicas with a drift already reported in an Event
+	reported sets.Set[string]
+}
+
+func newConfigDrift() *configDrift {
+	return &configDrift{
+		since:    map[string]time.Time{},
+		reported: sets.New[string](),
+	}
+}
+
+// referenceChecksum returns the checksum running in most replicas. In case
+// of a tie the checksum of the replica evaluating the drift is preferred.
+func referenceChecksum(checksums map[string]string, own string) string {
+	counts := map[string]int{}
+	for _, checksum := range checksums {
+		counts[checksum]++
+	}
+
+	sorted := make([]string, 0, len(counts))
+	for checksum := range counts {
+		sorted = append(sorted, checksum)
+	}
+	sort.Strings(sorted)
+
+	reference := own
+	for _, checksum := range sorted {
+		if counts[checksum] > counts[reference] {
+			reference = checksum
+		}
+	}
+
+	return reference
+}
+
+// update records the checksums running in the replicas at now and returns
+// the replicas running a configuration different from the reference for
+// longer than threshold, sorted by name
+func (d *configDrift) update(checksums map[string]string, reference string, now time.Time, threshold time.Duration) []string {
+	drifted := []string{}
+	for pod, checksum := range checksums {
+		if checksum == reference {
+			delete(d.since, pod)
+			d.reported.Delete(pod)
+			continue
+		}
+
+		since, ok := d.since[pod]
+		if !ok {
+			since = now
+			d.since[pod] = now
+		}
+
+		if now.Sub(since) >= threshold {
+			drifted = append(drifted, pod)
+		}
+	}
+
+	for pod := range d.since {
+		if _, ok := checksums[pod]; !ok {
+			delete(d.since, pod)
+			d.reported.Delete(pod)
+		}
+	}
+
+	sort.Strings(drifted)
+	return drifted
+}
+
+// replicaLabels returns the labels shared by all the replicas of the
+// controller. As in the status synchronization, the app.kubernetes.io labels
+// identify the deployment; the labels specific to a revision, like
+// pod-template-hash, are never included so the replicas of a rollout in
+// progress are compared too.
+func replicaLabels(podLabels map[string]string) map[string]string {
+	replica := map[string]string{}
+	for k, v := range podLabels {
+		if strings.HasPrefix(k, "app.kubernetes.io/") {
+			replica[k] = v
+		}
+	}
+
+	if len(replica) > 0 {
+		return replica
+	}
+
+	for k, v := range podLabels {
+		if k != "pod-template-hash" && k != "controller-revision-hash" && k != "pod-template-generation" {
+			replica[k] = v
+		}
+	}
+
+	return replica
+}
+
+// updateConfigurationChecksums updates the checksums of the replicas in the
+// Lease of the leader election
+func (n *NGINXController) updateConfigurationChecksums(update func(checksums map[string]string)) error {
+	leases := n.cfg.Client.CoordinationV1().Leases(k8s.IngressPodDetails.Namespace)
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leases.Get(context.TODO(), n.cfg.ElectionID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		checksums := map[string]string{}
+		if data, ok := lease.Annotations[configurationChecksumsAnnotation]; ok {
+			if err := json.Unmarshal([]byte(data), &checksums); err != nil {
+				klog.ErrorS(err, "ignoring invalid configuration checksums", "lease", n.cfg.ElectionID)
+			}
+		}
+
+		update(checksums)
+
+		data, err := json.Marshal(checksums)
+		if err != nil {
+			return err
+		}
+
+		if lease.Annotations[configurationChecksumsAnnotation] == string(data) {
+			return nil
+		}
+
+		if lease.Annotations == nil {
+			lease.Annotations = map[string]string{}
+		}
+		lease.Annotations[configurationChecksumsAnnotation] = string(data)
+
+		_, err = leases.Update(context.TODO(), lease, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// publishConfigurationChecksum stores the checksum of the running
+// configuration in the Lease of the leader election. The Lease is checked on
+// every period, as the entry of the pod can be removed by the leader or lost
+// when the Lease is recreated; it is only updated when the entry differs.
+func (n *NGINXController) publishConfigurationChecksum() {
+	n.runningConfigLock.RLock()
+	checksum := n.runningConfig.ConfigurationChecksum
+	n.runningConfigLock.RUnlock()
+
+	if checksum == "" {
+		return
+	}
+
+	err := n.updateConfigurationChecksums(func(checksums map[string]string) {
+		checksums[k8s.IngressPodDetails.Name] = checksum
+	})
+	if err != nil {
+		klog.V(2).InfoS("Unable to publish the checksum of the configuration", "lease", n.cfg.ElectionID, "err", err)
+	}
+}
+
+// checkConfigurationDrift compares the checksums of the configurations
+// running in the replicas of the controller and emits a warning Event on the
+// pods running a different configuration for longer than the threshold.
+// It runs in the leader, which also removes the checksums of the pods that
+// are not running anymore.
+func (n *NGINXController) checkConfigurationDrift() {
+	pods, err := n.cfg.Client.CoreV1().Pods(k8s.IngressPodDetails.Namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(replicaLabels(k8s.IngressPodDetails.Labels)).String(),
+	})
+	if err != nil {
+		klog.Warningf("Error listing the pods of the controller: %v", err)
+		return
+	}
+
+	running := map[string]*apiv1.Pod{}
+	for i := range pods.Items {
+		if pods.Items[i].Status.Phase == apiv1.PodRunning && pods.Items[i].DeletionTimestamp == nil {
+			running[pods.Items[i].Name] = &pods.Items[i]
+		}
+	}
+
+	checksums := map[string]string{}
+	err = n.updateConfigurationChecksums(func(published map[string]string) {
+		for pod, checksum := range published {
+			if _, ok := running[pod]; !ok {
+				delete(published, pod)
+				continue
+			}
+
+			checksums[pod] = checksum
+		}
+	})
+	if err != nil {
+		klog.Warningf("Error obtaining the checksums of the configuration of the replicas: %v", err)
+		return
+	}
+
+	reference := referenceChecksum(checksums, checksums[k8s.IngressPodDetails.Name])
+	drifted := n.configDrift.update(checksums, reference, time.Now(), n.cfg.ConfigDriftThreshold)
+	n.metricCollector.SetConfigDriftedReplicas(len(drifted))
+
+	for _, pod := range drifted {
+		if n.configDrift.reported.Has(pod) {
+			continue
+		}
+
+		klog.Warningf("Replica %v runs the configuration %v instead of %v for more than %v", pod, checksums[pod], reference, n.cfg.ConfigDriftThreshold)
+		n.recorder.Eventf(running[pod], apiv1.EventTypeWarning, "ConfigurationDrift",
+			"The replica runs the configuration %v instead of %v for more than %v", checksums[pod], reference, n.cfg.ConfigDriftThreshold)
+		n.configDrift.reported.Insert(pod)
+	}
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReferenceChecksum(t *testing.T) {
+	testCases := []struct {
+		title     string
+		checksums map[string]string
+		own       string
+		expected  string
+	}{
+		{"majority", map[string]string{"a": "1", "b": "2", "c": "2"}, "1", "2"},
+		{"tie with the own checksum", map[string]string{"a": "1", "b": "2"}, "1", "1"},
+		{"tie without the own checksum", map[string]string{"a": "2", "b": "1"}, "", "1"},
+		{"empty", map[string]string{}, "", ""},
+	}
+
+	for _, testCase := range testCases {
+		if reference := referenceChecksum(testCase.checksums, testCase.own); reference != testCase.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, testCase.expected, reference)
+		}
+	}
+}
+
+func TestConfigDriftUpdate(t *testing.T) {
+	d := newConfigDrift()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	threshold := 5 * time.Minute
+
+	checksums := map[string]string{"a": "1", "b": "1", "c": "2"}
+	if drifted := d.update(checksums, "1", now, threshold); len(drifted) != 0 {
+		t.Errorf("expected no drifted replica before the threshold but returned %v", drifted)
+	}
+
+	if drifted := d.update(checksums, "1", now.Add(threshold), threshold); !reflect.DeepEqual(drifted, []string{"c"}) {
+		t.Errorf("expected the drifted replica c but returned %v", drifted)
+	}
+
+	d.reported.Insert("c")
+	checksums["c"] = "1"
+	if drifted := d.update(checksums, "1", now.Add(2*threshold), threshold); len(drifted) != 0 {
+		t.Errorf("expected no drifted replica after the convergence but returned %v", drifted)
+	}
+	if len(d.since) != 0 || d.reported.Len() != 0 {
+		t.Errorf("expected the drift of c to be forgotten but returned %v, %v", d.since, d.reported)
+	}
+
+	checksums["c"] = "3"
+	d.update(checksums, "1", now, threshold)
+	delete(checksums, "c")
+	d.update(checksums, "1", now, threshold)
+	if len(d.since) != 0 {
+		t.Errorf("expected the drift of the removed replica to be forgotten but returned %v", d.since)
+	}
+}
+
+func TestReplicaLabels(t *testing.T) {
+	testCases := []struct {
+		title    string
+		labels   map[string]string
+		expected map[string]string
+	}{
+		{
+			"app.kubernetes.io labels",
+			map[string]string{"app.kubernetes.io/name": "ingress-nginx", "app.kubernetes.io/component": "controller", "pod-template-hash": "abc", "team": "a"},
+			map[string]string{"app.kubernetes.io/name": "ingress-nginx", "app.kubernetes.io/component": "controller"},
+		},
+		{
+			"labels without the revision",
+			map[string]string{"app": "ingress-nginx", "pod-template-hash": "abc", "controller-revision-hash": "def", "pod-template-generation": "1"},
+			map[string]string{"app": "ingress-nginx"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		if labels := replicaLabels(testCase.labels); !reflect.DeepEqual(labels, testCase.expected) {
+			t.Errorf("%v: expected %v but returned %v", testCase.title, testCase.expected, labels)
+		}
+	}
+}
@@ -0,0 +1,428 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// ExplainPath is the path of the endpoint used to explain the configuration
+// rendered for an Ingress
+const ExplainPath = "/debug/explain"
+
+// ExplainedIngress contains the configuration rendered in nginx.conf for an Ingress
+type ExplainedIngress struct {
+	Namespace   string            `json:"namespace"`
+	Name        string            `json:"name"`
+	Annotations []string          `json:"annotations,omitempty"`
+	Servers     []ExplainedServer `json:"servers"`
+}
+
+// ExplainedServer contains a server block and the locations created in it by
+// an Ingress. The block does not contain the location blocks.
+type ExplainedServer struct {
+	Hostname   string               `json:"hostname"`
+	Block      string               `json:"block"`
+	Directives []ExplainedDirective `json:"directives,omitempty"`
+	Locations  []ExplainedLocation  `json:"locations"`
+}
+
+// ExplainedLocation contains a rendered location block and the directives
+// configured using annotations
+type ExplainedLocation struct {
+	Path       string               `json:"path"`
+	Block      string               `json:"block"`
+	Directives []ExplainedDirective `json:"directives,omitempty"`
+}
+
+// ExplainedDirective relates a directive of a server or location block with
+// the annotations that influenced its value
+type ExplainedDirective struct {
+	Directive   string   `json:"directive"`
+	Annotations []string `json:"annotations"`
+}
+
+// ExplainHandler returns the server and location blocks rendered for the
+// Ingress defined in the query parameter ingress (namespace/name)
+func (n *NGINXController) ExplainHandler(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("ingress")
+	if key == "" {
+		http.Error(w, "missing ingress query parameter (namespace/name)", http.StatusBadRequest)
+		return
+	}
+
+	var ing *ingress.Ingress
+	others := store.FilterIngresses(n.store.ListIngresses(), func(toCheck *ingress.Ingress) bool {
+		if k8s.MetaNamespaceKey(toCheck) != key {
+			return false
+		}
+		ing = toCheck
+		return true
+	})
+	if ing == nil {
+		http.Error(w, fmt.Sprintf("ingress %v not found", key), http.StatusNotFound)
+		return
+	}
+
+	explained, err := n.explainIngress(ing, others)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("error rendering the NGINX configuration: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(explained); err != nil {
+		klog.Errorf("Error encoding explanation of Ingress %v: %v", key, err)
+	}
+}
+
+// explainIngress renders the servers of an Ingress like the admission webhook,
+// without applying the configuration. The directives are attributed to an
+// annotation when their lines change once the field of the parsed annotations
+// of the Ingress gets the value parsed without the annotation.
+func (n *NGINXController) explainIngress(ing *ingress.Ingress, others []*ingress.Ingress) (*ExplainedIngress, error) {
+	cfg := n.store.GetBackendConfiguration()
+	cfg.Resolver = n.resolver
+
+	render := func(parsed *annotations.Ingress) (*ExplainedIngress, error) {
+		explainedIng := *ing
+		explainedIng.ParsedAnnotations = parsed
+
+		ings := affectedIngresses(append(slices.Clone(others), &explainedIng))
+		_, _, pcfg := n.getConfiguration(context.TODO(), ings, nil)
+		content, err := n.generateTemplate(cfg, *pcfg, true)
+		if err != nil {
+			return nil, err
+		}
+
+		return explainConfiguration(content, ing.Namespace, ing.Name), nil
+	}
+
+	explained, err := render(ing.ParsedAnnotations)
+	if err != nil {
+		return nil, err
+	}
+
+	ingAnnotations := n.store.GetIngressAnnotations(&ing.Ingress)
+	for key := range ingAnnotations {
+		if strings.HasPrefix(key, parser.AnnotationsPrefix+"/") {
+			explained.Annotations = append(explained.Annotations, key)
+		}
+	}
+	sort.Strings(explained.Annotations)
+
+	if ing.ParsedAnnotations == nil {
+		return explained, nil
+	}
+
+	extractor := annotations.NewAnnotationExtractor(n.store)
+	factory := annotations.NewAnnotationFactory(n.store)
+
+	fields := make([]string, 0, len(factory))
+	for field := range factory {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	for _, field := range fields {
+		names := make([]string, 0)
+		for name := range factory[field].GetDocumentation() {
+			if _, ok := ingAnnotations[parser.GetAnnotationWithPrefix(name)]; ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			annotation := parser.GetAnnotationWithPrefix(name)
+
+			without := ing.Ingress
+			without.Annotations = make(map[string]string, len(ingAnnotations))
+			for key, value := range ingAnnotations {
+				if key != annotation {
+					without.Annotations[key] = value
+				}
+			}
+
+			parsedWithout, err := extractor.Extract(&without)
+			if err != nil {
+				klog.V(3).InfoS("Cannot parse the annotations of the Ingress without annotation", "ingress", klog.KObj(ing), "annotation", annotation, "err", err)
+				continue
+			}
+
+			parsed := *ing.ParsedAnnotations
+			value := reflect.ValueOf(&parsed).Elem().FieldByName(field)
+			valueWithout := reflect.ValueOf(parsedWithout).Elem().FieldByName(field)
+			if !value.IsValid() || !valueWithout.IsValid() || reflect.DeepEqual(value.Interface(), valueWithout.Interface()) {
+				continue
+			}
+			value.Set(valueWithout)
+
+			rendered, err := render(&parsed)
+			if err != nil {
+				klog.V(3).InfoS("Cannot render the Ingress without annotation", "ingress", klog.KObj(ing), "annotation", annotation, "err", err)
+				continue
+			}
+
+			attributeDirectives(explained, rendered, annotation)
+		}
+	}
+
+	return explained, nil
+}
+
+// explainConfiguration extracts from the NGINX configuration the server and
+// location blocks generated for the Ingress with the given namespace and name.
+func explainConfiguration(conf []byte, namespace, name string) *ExplainedIngress {
+	explained := &ExplainedIngress{
+		Namespace: namespace,
+		Name:      name,
+		Servers:   []ExplainedServer{},
+	}
+
+	var server *ExplainedServer
+	var serverBlock []string
+	var block []string
+	depth := 0
+
+	scanner := bufio.NewScanner(bytes.NewReader(conf))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if block != nil {
+			block = append(block, line)
+			depth += strings.Count(trimmed, "{") - strings.Count(trimmed, "}")
+			if depth > 0 {
+				continue
+			}
+
+			if server != nil && locationBelongsTo(block, namespace, name) {
+				server.Locations = append(server.Locations, ExplainedLocation{
+					Path:  locationPath(block[0]),
+					Block: strings.Join(block, "\n"),
+				})
+			}
+			block = nil
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(trimmed, "## start server "):
+			server = &ExplainedServer{
+				Hostname:  strings.TrimPrefix(trimmed, "## start server "),
+				Locations: []ExplainedLocation{},
+			}
+			serverBlock = nil
+		case strings.HasPrefix(trimmed, "## end server "):
+			if server != nil && len(server.Locations) > 0 {
+				server.Block = strings.Join(serverBlock, "\n")
+				explained.Servers = append(explained.Servers, *server)
+			}
+			server = nil
+		case strings.HasPrefix(trimmed, "location ") && strings.HasSuffix(trimmed, "{"):
+			block = []string{line}
+			depth = 1
+		case server != nil && trimmed != "":
+			serverBlock = append(serverBlock, line)
+		}
+	}
+
+	return explained
+}
+
+// locationBelongsTo checks if a location block was generated by the Ingress
+// using the variables set in the block
+func locationBelongsTo(block []string, namespace, name string) bool {
+	var ns, ing string
+	for _, line := range block {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "set" {
+			continue
+		}
+
+		value := strings.Trim(fields[2], `";`)
+		switch fields[1] {
+		case "$namespace":
+			ns = value
+		case "$ingress_name":
+			ing = value
+		}
+	}
+
+	return ns == namespace && ing == name
+}
+
+// locationPath returns the path of a location from the first line of the block
+func locationPath(line string) string {
+	path := strings.TrimSpace(line)
+	path = strings.TrimPrefix(path, "location ")
+	path = strings.TrimSuffix(path, "{")
+	return strings.TrimSpace(path)
+}
+
+// attributeDirectives attributes to an annotation the directives of the blocks
+// of explained that differ from the blocks rendered without the annotation
+func attributeDirectives(explained, without *ExplainedIngress, annotation string) {
+	for i := range explained.Servers {
+		server := &explained.Servers[i]
+
+		var withoutServer *ExplainedServer
+		for j := range without.Servers {
+			if without.Servers[j].Hostname == server.Hostname {
+				withoutServer = &without.Servers[j]
+				break
+			}
+		}
+
+		withoutBlock := ""
+		if withoutServer != nil {
+			withoutBlock = withoutServer.Block
+		}
+		server.Directives = addDirectives(server.Directives, changedDirectives(server.Block, withoutBlock), annotation)
+
+		for j := range server.Locations {
+			location := &server.Locations[j]
+
+			withoutBlock := ""
+			if withoutServer != nil {
+				for _, withoutLocation := range withoutServer.Locations {
+					if withoutLocation.Path == location.Path {
+						withoutBlock = withoutLocation.Block
+						break
+					}
+				}
+			}
+			location.Directives = addDirectives(location.Directives, changedDirectives(location.Block, withoutBlock), annotation)
+		}
+	}
+}
+
+// addDirectives adds an annotation to the directives
+func addDirectives(explained []ExplainedDirective, directives []string, annotation string) []ExplainedDirective {
+	for _, directive := range directives {
+		idx := slices.IndexFunc(explained, func(d ExplainedDirective) bool {
+			return d.Directive == directive
+		})
+		if idx == -1 {
+			explained = append(explained, ExplainedDirective{Directive: directive})
+			idx = len(explained) - 1
+		}
+
+		if !slices.Contains(explained[idx].Annotations, annotation) {
+			explained[idx].Annotations = append(explained[idx].Annotations, annotation)
+			sort.Strings(explained[idx].Annotations)
+		}
+	}
+
+	return explained
+}
+
+// changedDirectives returns the directives whose lines are only present in one
+// of the blocks, in the order of the block and then of the other block
+func changedDirectives(block, other string) []string {
+	lines, otherLines := blockLines(block), blockLines(other)
+
+	directives := []string{}
+	for _, changed := range [][]blockLine{unmatchedLines(lines, otherLines), unmatchedLines(otherLines, lines)} {
+		for _, line := range changed {
+			if !slices.Contains(directives, line.directive) {
+				directives = append(directives, line.directive)
+			}
+		}
+	}
+
+	return directives
+}
+
+// blockLine is a line of a block and the directive it belongs to
+type blockLine struct {
+	directive string
+	line      string
+}
+
+// blockLines returns the lines of a block, without the first and last ones.
+// The lines of a nested block belong to the directive opening it.
+func blockLines(block string) []blockLine {
+	lines := strings.Split(block, "\n")
+	if len(lines) < 2 {
+		return nil
+	}
+
+	result := []blockLine{}
+	nested := ""
+	depth := 0
+	for _, line := range lines[1 : len(lines)-1] {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		directive := strings.Fields(trimmed)[0]
+		if depth > 0 {
+			directive = nested
+		} else if strings.HasSuffix(trimmed, "{") {
+			nested = directive
+		}
+
+		depth += strings.Count(trimmed, "{") - strings.Count(trimmed, "}")
+		if depth < 0 {
+			depth = 0
+		}
+
+		result = append(result, blockLine{directive: directive, line: trimmed})
+	}
+
+	return result
+}
+
+// unmatchedLines returns the lines not present in the other lines
+func unmatchedLines(lines, other []blockLine) []blockLine {
+	remaining := map[string]int{}
+	for _, line := range other {
+		remaining[line.line]++
+	}
+
+	unmatched := []blockLine{}
+	for _, line := range lines {
+		if remaining[line.line] > 0 {
+			remaining[line.line]--
+			continue
+		}
+		unmatched = append(unmatched, line)
+	}
+
+	return unmatched
+}
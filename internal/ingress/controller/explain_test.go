@@ -0,0 +1,242 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+const explainConf = `
+http {
+	## start server foo.bar
+	server {
+		server_name foo.bar ;
+
+		ssl_ciphers HIGH;
+
+		location /api/ {
+			set $namespace      "default";
+			set $ingress_name   "api";
+			set $service_name   "api";
+
+			rewrite_by_lua_block {
+				balancer.rewrite()
+			}
+
+			proxy_read_timeout                      120s;
+			proxy_send_timeout                      60s;
+			client_max_body_size                    8m;
+		}
+
+		location / {
+			set $namespace      "default";
+			set $ingress_name   "web";
+			set $service_name   "web";
+
+			proxy_read_timeout                      60s;
+		}
+	}
+	## end server foo.bar
+
+	## start server other.bar
+	server {
+		server_name other.bar ;
+
+		location / {
+			set $namespace      "default";
+			set $ingress_name   "web";
+			set $service_name   "web";
+		}
+	}
+	## end server other.bar
+}
+`
+
+func TestExplainConfiguration(t *testing.T) {
+	explained := explainConfiguration([]byte(explainConf), "default", "api")
+
+	if len(explained.Servers) != 1 {
+		t.Fatalf("expected 1 server but %v returned", len(explained.Servers))
+	}
+
+	server := explained.Servers[0]
+	if server.Hostname != "foo.bar" {
+		t.Errorf("expected hostname foo.bar but %v returned", server.Hostname)
+	}
+
+	expectedBlock := "\tserver {\n\t\tserver_name foo.bar ;\n\t\tssl_ciphers HIGH;\n\t}"
+	if server.Block != expectedBlock {
+		t.Errorf("expected server block %q but %q returned", expectedBlock, server.Block)
+	}
+
+	if len(server.Locations) != 1 {
+		t.Fatalf("expected 1 location but %v returned", len(server.Locations))
+	}
+
+	loc := server.Locations[0]
+	if loc.Path != "/api/" {
+		t.Errorf("expected path /api/ but %v returned", loc.Path)
+	}
+	if !strings.HasSuffix(loc.Block, "client_max_body_size                    8m;\n\t\t}") {
+		t.Errorf("unexpected location block: %v", loc.Block)
+	}
+
+	explained = explainConfiguration([]byte(explainConf), "default", "web")
+	if len(explained.Servers) != 2 {
+		t.Errorf("expected 2 servers but %v returned", len(explained.Servers))
+	}
+
+	explained = explainConfiguration([]byte(explainConf), "default", "missing")
+	if len(explained.Servers) != 0 {
+		t.Errorf("expected no servers but %v returned", len(explained.Servers))
+	}
+}
+
+func TestAttributeDirectives(t *testing.T) {
+	explained := explainConfiguration([]byte(explainConf), "default", "api")
+
+	without := strings.Replace(explainConf, "120s;", "60s;", 1)
+	without = strings.Replace(without, "balancer.rewrite()", "balancer.rewrite(true)", 1)
+	attributeDirectives(explained, explainConfiguration([]byte(without), "default", "api"), "a")
+
+	without = strings.Replace(explainConf, "ssl_ciphers HIGH;", "", 1)
+	without = strings.Replace(without, "120s;", "60s;", 1)
+	attributeDirectives(explained, explainConfiguration([]byte(without), "default", "api"), "b")
+
+	attributeDirectives(explained, explainConfiguration([]byte(explainConf), "default", "api"), "c")
+
+	server := explained.Servers[0]
+	expectedServer := []ExplainedDirective{
+		{Directive: "ssl_ciphers", Annotations: []string{"b"}},
+	}
+	if !reflect.DeepEqual(server.Directives, expectedServer) {
+		t.Errorf("expected server directives %v but %v returned", expectedServer, server.Directives)
+	}
+
+	expectedLocation := []ExplainedDirective{
+		{Directive: "rewrite_by_lua_block", Annotations: []string{"a"}},
+		{Directive: "proxy_read_timeout", Annotations: []string{"a", "b"}},
+	}
+	if !reflect.DeepEqual(server.Locations[0].Directives, expectedLocation) {
+		t.Errorf("expected location directives %v but %v returned", expectedLocation, server.Locations[0].Directives)
+	}
+}
+
+// explainTemplate renders the servers and the locations of the Ingresses with
+// directives configured using annotations
+type explainTemplate struct{}
+
+func (explainTemplate) Write(conf *ngx_config.TemplateConfig) ([]byte, error) {
+	var b strings.Builder
+	for _, server := range conf.Servers {
+		fmt.Fprintf(&b, "## start server %v\nserver {\n\tserver_name %v ;\n", server.Hostname, server.Hostname)
+		if server.ServerSnippet != "" {
+			fmt.Fprintf(&b, "\t%v\n", server.ServerSnippet)
+		}
+
+		for _, location := range server.Locations {
+			if location.Ingress == nil {
+				continue
+			}
+
+			fmt.Fprintf(&b, "\tlocation %v {\n", location.Path)
+			fmt.Fprintf(&b, "\t\tset $namespace \"%v\";\n\t\tset $ingress_name \"%v\";\n", location.Ingress.Namespace, location.Ingress.Name)
+			fmt.Fprintf(&b, "\t\tproxy_read_timeout %vs;\n\t\tclient_max_body_size %v;\n\t}\n", location.Proxy.ReadTimeout, location.Proxy.BodySize)
+		}
+
+		fmt.Fprintf(&b, "}\n## end server %v\n", server.Hostname)
+	}
+
+	return []byte(b.String()), nil
+}
+
+func TestExplainIngress(t *testing.T) {
+	nginx := newNGINXController(t)
+	nginx.t = explainTemplate{}
+	nginx.store = &fakeIngressStore{
+		configuration: ngx_config.Configuration{
+			AllowSnippetAnnotations: true,
+			AnnotationsRiskLevel:    "Critical",
+		},
+	}
+
+	ing := networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "api",
+			Namespace: "default",
+			Annotations: map[string]string{
+				parser.GetAnnotationWithPrefix("proxy-read-timeout"): "120",
+				parser.GetAnnotationWithPrefix("proxy-body-size"):    "8m",
+				parser.GetAnnotationWithPrefix("server-snippet"):     "gzip on;",
+				"kubernetes.io/ingress.class":                        "nginx",
+			},
+		},
+		Spec: networking.IngressSpec{
+			Rules: []networking.IngressRule{{Host: "foo.bar"}},
+		},
+	}
+
+	parsed, err := annotations.NewAnnotationExtractor(nginx.store).Extract(&ing)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	explained, err := nginx.explainIngress(&ingress.Ingress{Ingress: ing, ParsedAnnotations: parsed}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expectedAnnotations := []string{
+		parser.GetAnnotationWithPrefix("proxy-body-size"),
+		parser.GetAnnotationWithPrefix("proxy-read-timeout"),
+		parser.GetAnnotationWithPrefix("server-snippet"),
+	}
+	if !reflect.DeepEqual(explained.Annotations, expectedAnnotations) {
+		t.Errorf("expected annotations %v but %v returned", expectedAnnotations, explained.Annotations)
+	}
+
+	if len(explained.Servers) != 1 || len(explained.Servers[0].Locations) != 1 {
+		t.Fatalf("expected 1 server with 1 location but %v returned", explained.Servers)
+	}
+
+	server := explained.Servers[0]
+	expectedServer := []ExplainedDirective{
+		{Directive: "gzip", Annotations: []string{parser.GetAnnotationWithPrefix("server-snippet")}},
+	}
+	if !reflect.DeepEqual(server.Directives, expectedServer) {
+		t.Errorf("expected server directives %v but %v returned", expectedServer, server.Directives)
+	}
+
+	expectedLocation := []ExplainedDirective{
+		{Directive: "client_max_body_size", Annotations: []string{parser.GetAnnotationWithPrefix("proxy-body-size")}},
+		{Directive: "proxy_read_timeout", Annotations: []string{parser.GetAnnotationWithPrefix("proxy-read-timeout")}},
+	}
+	if !reflect.DeepEqual(server.Locations[0].Directives, expectedLocation) {
+		t.Errorf("expected location directives %v but %v returned", expectedLocation, server.Locations[0].Directives)
+	}
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// ExportedConfiguration is the logical configuration of the controller,
+// without the information changing without a change of the Ingresses,
+// like the endpoints or the checksums, to be tracked in Git.
+type ExportedConfiguration struct {
+	Servers     []ExportedServer  `json:"servers"`
+	Backends    []ExportedBackend `json:"backends"`
+	TCPServices []ExportedStream  `json:"tcpServices,omitempty"`
+	UDPServices []ExportedStream  `json:"udpServices,omitempty"`
+}
+
+// ExportedServer is a server of the logical configuration
+type ExportedServer struct {
+	Hostname       string             `json:"hostname"`
+	Aliases        []string           `json:"aliases,omitempty"`
+	TLSSecret      string             `json:"tlsSecret,omitempty"`
+	SSLPassthrough bool               `json:"sslPassthrough,omitempty"`
+	Locations      []ExportedLocation `json:"locations"`
+}
+
+// ExportedLocation is a location of a server of the logical configuration.
+// The policies are the annotations of the Ingress, without prefix.
+type ExportedLocation struct {
+	Path     string            `json:"path"`
+	PathType string            `json:"pathType,omitempty"`
+	Ingress  string            `json:"ingress,omitempty"`
+	Backend  string            `json:"backend"`
+	Policies map[string]string `json:"policies,omitempty"`
+}
+
+// ExportedBackend is a backend of the logical configuration
+type ExportedBackend struct {
+	Name                string                        `json:"name"`
+	Service             string                        `json:"service,omitempty"`
+	Port                string                        `json:"port,omitempty"`
+	LoadBalancing       string                        `json:"loadBalancing,omitempty"`
+	SessionAffinity     string                        `json:"sessionAffinity,omitempty"`
+	AlternativeBackends []string                      `json:"alternativeBackends,omitempty"`
+	Canary              *ingress.TrafficShapingPolicy `json:"canary,omitempty"`
+}
+
+// ExportedStream is a TCP or UDP service of the logical configuration
+type ExportedStream struct {
+	Port        int    `json:"port"`
+	Service     string `json:"service"`
+	ServicePort string `json:"servicePort"`
+}
+
+// exportConfiguration returns the logical configuration of pcfg with
+// stable ordering
+func exportConfiguration(pcfg *ingress.Configuration) *ExportedConfiguration {
+	exported := &ExportedConfiguration{
+		Servers:     []ExportedServer{},
+		Backends:    []ExportedBackend{},
+		TCPServices: exportStreams(pcfg.TCPEndpoints),
+		UDPServices: exportStreams(pcfg.UDPEndpoints),
+	}
+
+	for _, server := range pcfg.Servers {
+		s := ExportedServer{
+			Hostname:       server.Hostname,
+			Aliases:        sortedCopy(server.Aliases),
+			SSLPassthrough: server.SSLPassthrough,
+			Locations:      []ExportedLocation{},
+		}
+
+		if server.SSLCert != nil {
+			s.TLSSecret = fmt.Sprintf("%v/%v", server.SSLCert.Namespace, server.SSLCert.Name)
+		}
+
+		for _, location := range server.Locations {
+			s.Locations = append(s.Locations, exportLocation(location))
+		}
+
+		sort.SliceStable(s.Locations, func(i, j int) bool {
+			if s.Locations[i].Path != s.Locations[j].Path {
+				return s.Locations[i].Path < s.Locations[j].Path
+			}
+
+			return s.Locations[i].PathType < s.Locations[j].PathType
+		})
+
+		exported.Servers = append(exported.Servers, s)
+	}
+
+	sort.SliceStable(exported.Servers, func(i, j int) bool {
+		return exported.Servers[i].Hostname < exported.Servers[j].Hostname
+	})
+
+	for _, backend := range pcfg.Backends {
+		b := ExportedBackend{
+			Name:                backend.Name,
+			Port:                backend.Port.String(),
+			LoadBalancing:       backend.LoadBalancing,
+			SessionAffinity:     backend.SessionAffinity.AffinityType,
+			AlternativeBackends: sortedCopy(backend.AlternativeBackends),
+		}
+
+		if backend.Service != nil {
+			b.Service = k8s.MetaNamespaceKey(backend.Service)
+		}
+
+		if backend.NoServer {
+			canary := backend.TrafficShapingPolicy
+			b.Canary = &canary
+		}
+
+		exported.Backends = append(exported.Backends, b)
+	}
+
+	sort.SliceStable(exported.Backends, func(i, j int) bool {
+		return exported.Backends[i].Name < exported.Backends[j].Name
+	})
+
+	return exported
+}
+
+func exportLocation(location *ingress.Location) ExportedLocation {
+	l := ExportedLocation{
+		Path:    location.Path,
+		Backend: location.Backend,
+	}
+
+	if location.PathType != nil {
+		l.PathType = string(*location.PathType)
+	}
+
+	if location.Ingress == nil {
+		return l
+	}
+
+	l.Ingress = k8s.MetaNamespaceKey(location.Ingress)
+
+	prefix := parser.AnnotationsPrefix + "/"
+	for name, value := range location.Ingress.GetAnnotations() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+
+		if l.Policies == nil {
+			l.Policies = map[string]string{}
+		}
+
+		l.Policies[strings.TrimPrefix(name, prefix)] = value
+	}
+
+	return l
+}
+
+func exportStreams(services []ingress.L4Service) []ExportedStream {
+	streams := make([]ExportedStream, 0, len(services))
+	for i := range services {
+		streams = append(streams, ExportedStream{
+			Port:        services[i].Port,
+			Service:     fmt.Sprintf("%v/%v", services[i].Backend.Namespace, services[i].Backend.Name),
+			ServicePort: services[i].Backend.Port.String(),
+		})
+	}
+
+	sort.SliceStable(streams, func(i, j int) bool {
+		return streams[i].Port < streams[j].Port
+	})
+
+	return streams
+}
+
+func sortedCopy(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+
+	sorted := make([]string, len(values))
+	copy(sorted, values)
+	sort.Strings(sorted)
+
+	return sorted
+}
+
+// writeConfigurationExport writes the logical configuration of pcfg in YAML
+// to path, unless the file already contains the same configuration
+func writeConfigurationExport(path string, pcfg *ingress.Configuration) error {
+	data, err := yaml.Marshal(exportConfiguration(pcfg))
+	if err != nil {
+		return fmt.Errorf("unexpected error encoding configuration export: %w", err)
+	}
+
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+
+	return writeFileAtomically(path, data)
+}
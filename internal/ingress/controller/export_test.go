@@ -0,0 +1,150 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestWriteConfigurationExport(t *testing.T) {
+	pathTypePrefix := networking.PathTypePrefix
+	ing := &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: "default",
+				Name:      "app",
+				Annotations: map[string]string{
+					"nginx.ingress.kubernetes.io/limit-rps": "10",
+					"kubectl.kubernetes.io/last-applied":    "{}",
+				},
+			},
+		},
+	}
+
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{
+				Hostname: "b.example.com",
+				Locations: []*ingress.Location{
+					{Path: "/", Backend: "upstream-default-backend"},
+				},
+			},
+			{
+				Hostname: "a.example.com",
+				Aliases:  []string{"z.example.com", "y.example.com"},
+				SSLCert:  &ingress.SSLCert{Namespace: "default", Name: "tls", PemSHA: "abc"},
+				Locations: []*ingress.Location{
+					{Path: "/v2", PathType: &pathTypePrefix, Backend: "default-app-80", Ingress: ing},
+					{Path: "/", PathType: &pathTypePrefix, Backend: "default-app-80", Ingress: ing},
+				},
+			},
+		},
+		Backends: []*ingress.Backend{
+			{
+				Name:      "default-app-80",
+				Port:      intstr.FromInt(80),
+				Endpoints: []ingress.Endpoint{{Address: "10.0.0.1", Port: "8080"}},
+			},
+		},
+		TCPEndpoints: []ingress.L4Service{
+			{Port: 9000, Backend: ingress.L4Backend{Namespace: "default", Name: "db", Port: intstr.FromInt(5432)}},
+		},
+		ConfigurationChecksum: "12345",
+	}
+
+	expected := `backends:
+- name: default-app-80
+  port: "80"
+servers:
+- aliases:
+  - y.example.com
+  - z.example.com
+  hostname: a.example.com
+  locations:
+  - backend: default-app-80
+    ingress: default/app
+    path: /
+    pathType: Prefix
+    policies:
+      limit-rps: "10"
+  - backend: default-app-80
+    ingress: default/app
+    path: /v2
+    pathType: Prefix
+    policies:
+      limit-rps: "10"
+  tlsSecret: default/tls
+- hostname: b.example.com
+  locations:
+  - backend: upstream-default-backend
+    path: /
+tcpServices:
+- port: 9000
+  service: default/db
+  servicePort: "5432"
+`
+
+	path := filepath.Join(t.TempDir(), "export", "configuration.yaml")
+	if err := writeConfigurationExport(path, pcfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if string(data) != expected {
+		t.Errorf("expected\n%v\nbut returned\n%v", expected, string(data))
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the endpoints and checksums are not part of the logical configuration
+	pcfg.Backends[0].Endpoints = []ingress.Endpoint{{Address: "10.0.0.2", Port: "8080"}}
+	pcfg.ConfigurationChecksum = "67890"
+
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := writeConfigurationExport(path, pcfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !updated.ModTime().Equal(past) || updated.Size() != info.Size() {
+		t.Errorf("expected the export to not be written again without changes")
+	}
+}
@@ -0,0 +1,174 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+const (
+	// fdCheckPeriod defines the interval between checks of the file descriptors used by the NGINX workers
+	fdCheckPeriod = 30 * time.Second
+
+	// fdWarningThreshold defines the utilization of file descriptors that triggers a warning Event
+	fdWarningThreshold = 0.8
+
+	procRoot = "/proc"
+)
+
+// checkWorkerFileDescriptors updates the file descriptor utilization of the NGINX
+// workers and emits a warning Event when the utilization crosses fdWarningThreshold
+func (n *NGINXController) checkWorkerFileDescriptors() {
+	f, err := os.ReadFile(nginx.PID)
+	if err != nil {
+		klog.V(3).InfoS("Unable to read NGINX PID file", "file", nginx.PID, "err", err)
+		return
+	}
+
+	masterPID, err := strconv.Atoi(strings.TrimSpace(string(f)))
+	if err != nil {
+		klog.Warningf("Error reading NGINX PID from file %v: %v", nginx.PID, err)
+		return
+	}
+
+	ratio, err := workerFDUtilization(procRoot, masterPID)
+	if err != nil {
+		klog.Warningf("Error obtaining file descriptors of NGINX workers: %v", err)
+		return
+	}
+
+	n.metricCollector.SetWorkerFDUtilization(ratio)
+
+	if ratio < fdWarningThreshold {
+		n.fdWarningEmitted = false
+		return
+	}
+
+	if n.fdWarningEmitted {
+		return
+	}
+
+	n.fdWarningEmitted = true
+	msg := fmt.Sprintf("NGINX workers are using %.0f%% of the open files limit (max-worker-open-files). New connections could be reset", ratio*100)
+	klog.Warning(msg)
+	n.recorder.Event(k8s.IngressPodDetails, apiv1.EventTypeWarning, "FileDescriptors", msg)
+}
+
+// workerFDUtilization returns the highest ratio between open file descriptors
+// and the soft limit of open files of the children of the NGINX master process
+func workerFDUtilization(root string, masterPID int) (float64, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return 0, err
+	}
+
+	highest := 0.0
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		ppid, err := readParentPID(filepath.Join(root, entry.Name(), "stat"))
+		if err != nil || ppid != masterPID {
+			// the process could exit while we are reading
+			continue
+		}
+
+		limit, err := readOpenFilesLimit(filepath.Join(root, entry.Name(), "limits"))
+		if err != nil || limit == 0 {
+			continue
+		}
+
+		fds, err := os.ReadDir(filepath.Join(root, entry.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+
+		ratio := float64(len(fds)) / float64(limit)
+		klog.V(5).InfoS("NGINX worker file descriptors", "pid", pid, "open", len(fds), "limit", limit)
+		if ratio > highest {
+			highest = ratio
+		}
+	}
+
+	return highest, nil
+}
+
+// readParentPID returns the parent PID from the content of /proc/<pid>/stat
+func readParentPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// the name of the command can contain spaces and parenthesis
+	stat := string(data)
+	idx := strings.LastIndex(stat, ")")
+	if idx == -1 {
+		return 0, fmt.Errorf("unexpected format of %v", path)
+	}
+
+	fields := strings.Fields(stat[idx+1:])
+	if len(fields) < 2 {
+		return 0, fmt.Errorf("unexpected format of %v", path)
+	}
+
+	return strconv.Atoi(fields[1])
+}
+
+// readOpenFilesLimit returns the soft limit of open files from the content of /proc/<pid>/limits
+func readOpenFilesLimit(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) == 0 || fields[0] == "unlimited" {
+			return 0, nil
+		}
+
+		return strconv.ParseUint(fields[0], 10, 64)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("open files limit not found in %v", path)
+}
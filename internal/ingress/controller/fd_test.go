@@ -0,0 +1,96 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func createFakeProcess(t *testing.T, root string, pid, ppid, limit, fds int) {
+	dir := filepath.Join(root, fmt.Sprintf("%d", pid))
+	if err := os.MkdirAll(filepath.Join(dir, "fd"), 0o755); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	stat := fmt.Sprintf("%d (nginx: worker) S %d %d %d 0 -1", pid, ppid, ppid, ppid)
+	if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	limits := fmt.Sprintf(`Limit                     Soft Limit           Hard Limit           Units
+Max cpu time              unlimited            unlimited            seconds
+Max open files            %d                 %d                 files
+`, limit, limit)
+	if err := os.WriteFile(filepath.Join(dir, "limits"), []byte(limits), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for i := 0; i < fds; i++ {
+		if err := os.WriteFile(filepath.Join(dir, "fd", fmt.Sprintf("%d", i)), nil, 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+}
+
+func TestWorkerFDUtilization(t *testing.T) {
+	root := t.TempDir()
+
+	// master process
+	createFakeProcess(t, root, 10, 1, 100, 90)
+	// workers
+	createFakeProcess(t, root, 11, 10, 100, 20)
+	createFakeProcess(t, root, 12, 10, 100, 40)
+	// unrelated process
+	createFakeProcess(t, root, 20, 1, 10, 10)
+
+	ratio, err := workerFDUtilization(root, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ratio != 0.4 {
+		t.Errorf("expected 0.4 but %v returned", ratio)
+	}
+
+	ratio, err = workerFDUtilization(root, 99)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ratio != 0 {
+		t.Errorf("expected 0 without workers but %v returned", ratio)
+	}
+}
+
+func TestReadParentPID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "stat")
+	if err := os.WriteFile(path, []byte("42 (nginx: worker (x) process) S 7 7 7 0 -1"), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ppid, err := readParentPID(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if ppid != 7 {
+		t.Errorf("expected 7 but %v returned", ppid)
+	}
+}
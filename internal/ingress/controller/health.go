@@ -0,0 +1,278 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apiserver/pkg/server/healthz"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Components of the controller reported in the verbose health check
+const (
+	informersComponent            = "informers"
+	reloadComponent               = "reload"
+	dynamicConfigurationComponent = "dynamic-configuration"
+	certificatesComponent         = "certificates"
+	leaderElectionComponent       = "leader-election"
+)
+
+// maxCertificateSyncLag is the time a change of a certificate can wait to be
+// configured in NGINX before the certificates component is unhealthy
+const maxCertificateSyncLag = 5 * time.Minute
+
+// componentHealthPeriod is the interval between the updates of the
+// metrics reporting the health of the components
+const componentHealthPeriod = 10 * time.Second
+
+// componentHealth tracks the result of the operations of the components of
+// the controller. The zero value is ready to use.
+type componentHealth struct {
+	mu sync.RWMutex
+
+	informersSynced time.Time
+
+	lastReload time.Time
+	reloadErr  error
+
+	lastDynamicConfiguration time.Time
+	dynamicConfigurationErr  error
+
+	lastCertificateSync      time.Time
+	certificatesPendingSince time.Time
+
+	leaderSince time.Time
+	leader      bool
+}
+
+// setInformersSynced records the synchronization of the local caches
+func (h *componentHealth) setInformersSynced() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.informersSynced = time.Now()
+}
+
+// reloaded records the result of a reload of NGINX
+func (h *componentHealth) reloaded(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.reloadErr = err
+	if err == nil {
+		h.lastReload = time.Now()
+	}
+}
+
+// dynamicallyConfigured records the result of a dynamic configuration of NGINX
+func (h *componentHealth) dynamicallyConfigured(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.dynamicConfigurationErr = err
+	if err == nil {
+		h.lastDynamicConfiguration = time.Now()
+	}
+}
+
+// certificateChanged records the reception of a change of a certificate
+// not configured in NGINX yet
+func (h *componentHealth) certificateChanged() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.certificatesPendingSince.IsZero() {
+		h.certificatesPendingSince = time.Now()
+	}
+}
+
+// certificatesSynced records the configuration in NGINX of the changes of
+// the certificates received before since
+func (h *componentHealth) certificatesSynced(since time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastCertificateSync = time.Now()
+	if !h.certificatesPendingSince.After(since) {
+		h.certificatesPendingSince = time.Time{}
+	}
+}
+
+// setLeader records the changes of the leadership of the pod
+func (h *componentHealth) setLeader(leader bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.leader = leader
+	h.leaderSince = time.Now()
+}
+
+// componentCheck checks the health of a component and returns the
+// time of its last successful operation
+type componentCheck func() (time.Time, error)
+
+// componentChecks returns the checks of the components of the controller
+// indexed by name
+func (n *NGINXController) componentChecks() map[string]componentCheck {
+	h := &n.health
+
+	return map[string]componentCheck{
+		informersComponent: func() (time.Time, error) {
+			h.mu.RLock()
+			defer h.mu.RUnlock()
+
+			if n.store == nil || !n.store.HasSynced() {
+				return h.informersSynced, fmt.Errorf("the local caches are not synchronized with the API server")
+			}
+
+			return h.informersSynced, nil
+		},
+		reloadComponent: func() (time.Time, error) {
+			h.mu.RLock()
+			defer h.mu.RUnlock()
+
+			if h.reloadErr != nil {
+				return h.lastReload, fmt.Errorf("the last reload failed (last successful reload: %v): %w", formatTime(h.lastReload), h.reloadErr)
+			}
+
+			return h.lastReload, nil
+		},
+		dynamicConfigurationComponent: func() (time.Time, error) {
+			h.mu.RLock()
+			defer h.mu.RUnlock()
+
+			if h.dynamicConfigurationErr != nil {
+				return h.lastDynamicConfiguration, fmt.Errorf("the last dynamic configuration failed (last success: %v): %w",
+					formatTime(h.lastDynamicConfiguration), h.dynamicConfigurationErr)
+			}
+
+			return h.lastDynamicConfiguration, nil
+		},
+		certificatesComponent: func() (time.Time, error) {
+			h.mu.RLock()
+			defer h.mu.RUnlock()
+
+			if !h.certificatesPendingSince.IsZero() {
+				if lag := time.Since(h.certificatesPendingSince); lag > maxCertificateSyncLag {
+					return h.lastCertificateSync, fmt.Errorf("certificates changed %v ago are not configured yet", lag.Round(time.Second))
+				}
+			}
+
+			return h.lastCertificateSync, nil
+		},
+		// the pods not holding the leadership are healthy, the last success
+		// of the leader election is the time the pod became the leader
+		leaderElectionComponent: func() (time.Time, error) {
+			h.mu.RLock()
+			defer h.mu.RUnlock()
+
+			if !h.leader {
+				return time.Time{}, nil
+			}
+
+			return h.leaderSince, nil
+		},
+	}
+}
+
+// HealthCheckers returns the checks of the components of the controller.
+// The checks are only executed in the verbose health check (/healthz?verbose)
+// or when the component is checked individually (/healthz/<component>),
+// the liveness of the controller is only checked by NGINXController.Check.
+func (n *NGINXController) HealthCheckers() []healthz.HealthChecker {
+	checks := n.componentChecks()
+
+	checkers := make([]healthz.HealthChecker, 0, len(checks))
+	for _, name := range []string{
+		informersComponent,
+		reloadComponent,
+		dynamicConfigurationComponent,
+		certificatesComponent,
+		leaderElectionComponent,
+	} {
+		checkers = append(checkers, &componentChecker{name: name, check: checks[name]})
+	}
+
+	return checkers
+}
+
+// reportComponentHealth updates the metrics reporting the health of the components
+func (n *NGINXController) reportComponentHealth() {
+	for name, check := range n.componentChecks() {
+		lastSuccess, err := check()
+		n.metricCollector.SetComponentHealth(name, err == nil, lastSuccess)
+	}
+}
+
+// componentChecker is a healthz.HealthChecker checking a component of the controller
+type componentChecker struct {
+	name  string
+	check componentCheck
+}
+
+// Name returns the name of the component
+func (c *componentChecker) Name() string {
+	return c.name
+}
+
+// Check returns the health of the component in the verbose health check
+func (c *componentChecker) Check(r *http.Request) error {
+	if !isDeepCheck(r, c.name) {
+		return nil
+	}
+
+	_, err := c.check()
+	return err
+}
+
+// isDeepCheck checks if the request asks for the health of the components,
+// using the verbose parameter or the path of the component
+func isDeepCheck(r *http.Request, component string) bool {
+	if r == nil || r.URL == nil {
+		return false
+	}
+
+	if _, ok := r.URL.Query()["verbose"]; ok {
+		return true
+	}
+
+	return path.Base(r.URL.Path) == component
+}
+
+// isSecret checks if the object of an event is a Secret
+func isSecret(obj interface{}) bool {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	_, ok := obj.(*apiv1.Secret)
+	return ok
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
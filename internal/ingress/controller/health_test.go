@@ -0,0 +1,112 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apiserver/pkg/server/healthz"
+)
+
+func TestComponentHealthCheckers(t *testing.T) {
+	n := &NGINXController{store: &fakeIngressStore{}}
+
+	mux := http.NewServeMux()
+	healthz.InstallPathHandler(mux, "/healthz", n.HealthCheckers()...)
+
+	get := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, http.NoBody)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/healthz?verbose")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status code 200 but %v returned: %v", w.Code, w.Body.String())
+	}
+
+	for _, component := range []string{"informers", "reload", "dynamic-configuration", "certificates", "leader-election"} {
+		if !strings.Contains(w.Body.String(), fmt.Sprintf("[+]%v ok", component)) {
+			t.Errorf("expected component %v in the verbose health check: %v", component, w.Body.String())
+		}
+	}
+
+	n.health.reloaded(fmt.Errorf("invalid configuration"))
+
+	if w := get("/healthz"); w.Code != http.StatusOK {
+		t.Errorf("expected the components to not be checked without the verbose parameter but %v returned", w.Code)
+	}
+
+	w = get("/healthz?verbose")
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code 500 but %v returned", w.Code)
+	}
+
+	if !strings.Contains(w.Body.String(), "[-]reload failed") {
+		t.Errorf("expected the reload component to fail: %v", w.Body.String())
+	}
+
+	if w := get("/healthz/reload"); w.Code != http.StatusInternalServerError {
+		t.Errorf("expected status code 500 checking the reload component but %v returned", w.Code)
+	}
+
+	if w := get("/healthz/certificates"); w.Code != http.StatusOK {
+		t.Errorf("expected status code 200 checking the certificates component but %v returned", w.Code)
+	}
+
+	n.health.reloaded(nil)
+	if w := get("/healthz/reload"); w.Code != http.StatusOK {
+		t.Errorf("expected status code 200 after a successful reload but %v returned", w.Code)
+	}
+}
+
+func TestComponentHealthCertificates(t *testing.T) {
+	n := &NGINXController{store: &fakeIngressStore{}}
+	check := n.componentChecks()[certificatesComponent]
+
+	n.health.certificateChanged()
+	if _, err := check(); err != nil {
+		t.Errorf("unexpected error before the maximum lag: %v", err)
+	}
+
+	n.health.certificatesPendingSince = time.Now().Add(-2 * maxCertificateSyncLag)
+	if _, err := check(); err == nil {
+		t.Errorf("expected an error after the maximum lag")
+	}
+
+	// changes received after the start of the synchronization are still pending
+	n.health.certificatesSynced(n.health.certificatesPendingSince.Add(-time.Second))
+	if _, err := check(); err == nil {
+		t.Errorf("expected an error with changes received during the synchronization")
+	}
+
+	n.health.certificatesSynced(time.Now())
+	lastSync, err := check()
+	if err != nil {
+		t.Errorf("unexpected error after the synchronization: %v", err)
+	}
+
+	if lastSync.IsZero() {
+		t.Errorf("expected the time of the last synchronization")
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+)
+
+// IgnoredIngressesPath is the path of the endpoint returning the Ingresses
+// seen but ignored by the controller, served in the status port
+const IgnoredIngressesPath = "/ingresses/ignored"
+
+// ignoredIngressesPeriod is the interval between the updates of the number
+// of ignored Ingresses reported in the metrics
+const ignoredIngressesPeriod = 30 * time.Second
+
+// IgnoredIngressesReport is the document returned by the ignored Ingresses endpoint
+type IgnoredIngressesReport struct {
+	Ingresses []store.IgnoredIngress `json:"ingresses"`
+}
+
+// ignoredIngressCounts returns the number of ignored Ingresses by reason
+func ignoredIngressCounts(ignored []store.IgnoredIngress) map[string]int {
+	counts := map[string]int{
+		store.IgnoredReasonIngressClass:       0,
+		store.IgnoredReasonCatchAll:           0,
+		store.IgnoredReasonDeepInspection:     0,
+		store.IgnoredReasonInvalidAnnotations: 0,
+		store.IgnoredReasonDeniedLocations:    0,
+	}
+	for _, ing := range ignored {
+		counts[ing.Reason]++
+	}
+
+	return counts
+}
+
+// reportIgnoredIngresses updates the number of ignored Ingresses by reason
+// reported in the metrics
+func (n *NGINXController) reportIgnoredIngresses() {
+	n.metricCollector.SetIgnoredIngresses(ignoredIngressCounts(n.store.ListIgnoredIngresses()))
+}
+
+// IgnoredIngressesHandler returns the Ingresses seen but ignored by the
+// controller, optionally filtered by the reason in the query parameter reason
+func (n *NGINXController) IgnoredIngressesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	reason := r.URL.Query().Get("reason")
+	report := &IgnoredIngressesReport{
+		Ingresses: []store.IgnoredIngress{},
+	}
+	for _, ing := range n.store.ListIgnoredIngresses() {
+		if reason == "" || ing.Reason == reason {
+			report.Ingresses = append(report.Ingresses, ing)
+		}
+	}
+
+	writeJSON(w, report)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/store"
+)
+
+func TestIgnoredIngressCounts(t *testing.T) {
+	counts := ignoredIngressCounts([]store.IgnoredIngress{
+		{Namespace: "default", Name: "one", Reason: store.IgnoredReasonIngressClass},
+		{Namespace: "default", Name: "two", Reason: store.IgnoredReasonIngressClass},
+		{Namespace: "default", Name: "three", Reason: store.IgnoredReasonDeniedLocations},
+	})
+
+	expected := map[string]int{
+		store.IgnoredReasonIngressClass:       2,
+		store.IgnoredReasonCatchAll:           0,
+		store.IgnoredReasonDeepInspection:     0,
+		store.IgnoredReasonInvalidAnnotations: 0,
+		store.IgnoredReasonDeniedLocations:    1,
+	}
+	if !reflect.DeepEqual(counts, expected) {
+		t.Errorf("expected %v but returned %v", expected, counts)
+	}
+}
+
+func TestIgnoredIngressesHandler(t *testing.T) {
+	n := &NGINXController{
+		store: &fakeIngressStore{
+			ignored: []store.IgnoredIngress{
+				{Namespace: "default", Name: "one", Reason: store.IgnoredReasonIngressClass, Message: "ingress does not contain a valid IngressClass"},
+				{Namespace: "default", Name: "two", Reason: store.IgnoredReasonDeniedLocations, Message: "invalid auth-url"},
+			},
+		},
+	}
+
+	testCases := []struct {
+		target   string
+		expected []string
+	}{
+		{IgnoredIngressesPath, []string{"one", "two"}},
+		{IgnoredIngressesPath + "?reason=DeniedLocations", []string{"two"}},
+		{IgnoredIngressesPath + "?reason=CatchAll", []string{}},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		n.IgnoredIngressesHandler(w, httptest.NewRequest(http.MethodGet, tc.target, http.NoBody))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %v but returned %v", http.StatusOK, w.Code)
+		}
+
+		var report IgnoredIngressesReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("unexpected error decoding %v: %v", w.Body.String(), err)
+		}
+
+		names := []string{}
+		for _, ing := range report.Ingresses {
+			names = append(names, ing.Name)
+		}
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("%v: expected %v but returned %v", tc.target, tc.expected, names)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	n.IgnoredIngressesHandler(w, httptest.NewRequest(http.MethodPost, IgnoredIngressesPath, http.NoBody))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected status %v but returned %v", http.StatusMethodNotAllowed, w.Code)
+	}
+}
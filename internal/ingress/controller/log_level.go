@@ -0,0 +1,274 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/task"
+)
+
+const (
+	// LogLevelPath is the path of the endpoint used to change the log levels
+	// at runtime, served in the profiler server only reachable inside the pod
+	LogLevelPath = "/debug/log-level"
+
+	// maxVerbosity is the highest verbosity of the logs of the controller
+	maxVerbosity = 10
+
+	// maxLogLevelDuration is the longest duration of a change of the log levels
+	maxLogLevelDuration = 24 * time.Hour
+)
+
+// errorLogLevels are the levels of the error log of NGINX
+var errorLogLevels = []string{"debug", "info", "notice", "warn", "error", "crit", "alert", "emerg"}
+
+// LogLevels contains the log levels returned by the log level endpoint
+type LogLevels struct {
+	// Verbosity is the verbosity of the logs of the controller
+	Verbosity int `json:"verbosity"`
+	// ErrorLogLevel is the level of the error log of NGINX
+	ErrorLogLevel string `json:"errorLogLevel"`
+	// Expires is the time the levels changed at runtime are reverted
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+// logLevelOverride contains the log levels changed at runtime, reverted
+// after a duration to avoid leaving the debug logs enabled
+type logLevelOverride struct {
+	mu sync.Mutex
+
+	// verbosity is the verbosity of the controller before the change
+	verbosity int
+	// errorLogLevel is the level of the error log of NGINX replacing
+	// error-log-level, empty when not changed
+	errorLogLevel string
+	// expires is the time the levels are reverted, zero when not changed
+	expires time.Time
+	timer   *time.Timer
+
+	// onErrorLogLevelChange is called when the level of the error log of
+	// NGINX changes, to render the configuration again
+	onErrorLogLevelChange func()
+}
+
+// currentVerbosity returns the verbosity of the logs of the controller
+func currentVerbosity() int {
+	v := 0
+	for v < maxVerbosity && klog.V(klog.Level(v+1)).Enabled() {
+		v++
+	}
+
+	return v
+}
+
+// setVerbosity changes the verbosity of the logs of the controller
+func setVerbosity(v int) {
+	var level klog.Level
+	if err := level.Set(strconv.Itoa(v)); err != nil {
+		klog.Errorf("Error setting the verbosity of the logs to %v: %v", v, err)
+	}
+}
+
+// set changes the verbosity of the controller, if not negative, and the
+// level of the error log of NGINX, if not empty, until the duration elapses.
+// A new change extends the duration and keeps the levels before the first change.
+func (o *logLevelOverride) set(verbosity int, errorLogLevel string, duration time.Duration, now time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.expires.IsZero() {
+		o.verbosity = currentVerbosity()
+	}
+	if verbosity >= 0 {
+		setVerbosity(verbosity)
+	}
+
+	errorLogLevelChanged := errorLogLevel != "" && errorLogLevel != o.errorLogLevel
+	if errorLogLevel != "" {
+		o.errorLogLevel = errorLogLevel
+	}
+
+	o.expires = now.Add(duration)
+	if o.timer != nil {
+		o.timer.Stop()
+	}
+	o.timer = time.AfterFunc(duration, o.expire)
+
+	klog.InfoS("Log levels changed", "verbosity", currentVerbosity(), "errorLogLevel", o.errorLogLevel, "expires", o.expires)
+
+	if errorLogLevelChanged && o.onErrorLogLevelChange != nil {
+		o.onErrorLogLevelChange()
+	}
+}
+
+// expire reverts the levels when the duration of the last change elapsed
+func (o *logLevelOverride) expire() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.expires.IsZero() || time.Now().Before(o.expires) {
+		return
+	}
+
+	o.revertLocked()
+}
+
+// revert restores the levels before the change
+func (o *logLevelOverride) revert() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.expires.IsZero() {
+		return
+	}
+
+	o.revertLocked()
+}
+
+func (o *logLevelOverride) revertLocked() {
+	if o.timer != nil {
+		o.timer.Stop()
+		o.timer = nil
+	}
+
+	setVerbosity(o.verbosity)
+	errorLogLevelChanged := o.errorLogLevel != ""
+	o.errorLogLevel = ""
+	o.expires = time.Time{}
+
+	klog.InfoS("Log levels reverted", "verbosity", o.verbosity)
+
+	if errorLogLevelChanged && o.onErrorLogLevelChange != nil {
+		o.onErrorLogLevelChange()
+	}
+}
+
+// getErrorLogLevel returns the level of the error log of NGINX replacing
+// error-log-level, or an empty string
+func (o *logLevelOverride) getErrorLogLevel() string {
+	if o == nil {
+		return ""
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.errorLogLevel
+}
+
+// getExpires returns the time the levels are reverted, or nil
+func (o *logLevelOverride) getExpires() *time.Time {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.expires.IsZero() {
+		return nil
+	}
+
+	expires := o.expires
+	return &expires
+}
+
+func newLogLevelOverride(n *NGINXController) *logLevelOverride {
+	return &logLevelOverride{
+		onErrorLogLevelChange: func() {
+			n.syncQueue.EnqueueTask(task.GetDummyObject("log-level-change"))
+		},
+	}
+}
+
+// LogLevelHandler returns the log levels. PUT requests change them with
+// the query parameters v (verbosity of the controller), error-log-level
+// (level of the error log of NGINX) and duration (time before the levels
+// are reverted, --log-level-duration by default). DELETE requests revert them.
+func (n *NGINXController) LogLevelHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		verbosity, errorLogLevel, duration, err := parseLogLevels(r, n.cfg.LogLevelDuration)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		n.logLevels.set(verbosity, errorLogLevel, duration, time.Now())
+	case http.MethodDelete:
+		n.logLevels.revert()
+	default:
+		http.Error(w, "only GET, PUT and DELETE are allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	levels := LogLevels{
+		Verbosity:     currentVerbosity(),
+		ErrorLogLevel: n.logLevels.getErrorLogLevel(),
+		Expires:       n.logLevels.getExpires(),
+	}
+	if levels.ErrorLogLevel == "" {
+		levels.ErrorLogLevel = n.store.GetBackendConfiguration().ErrorLogLevel
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(levels); err != nil {
+		klog.Errorf("Error encoding log levels: %v", err)
+	}
+}
+
+// parseLogLevels returns the verbosity, -1 when not changed, the level of
+// the error log of NGINX and the duration of a change of the log levels
+func parseLogLevels(r *http.Request, defaultDuration time.Duration) (verbosity int, errorLogLevel string, duration time.Duration, err error) {
+	query := r.URL.Query()
+
+	verbosity = -1
+	if v := query.Get("v"); v != "" {
+		verbosity, err = strconv.Atoi(v)
+		if err != nil || verbosity < 0 || verbosity > maxVerbosity {
+			return 0, "", 0, fmt.Errorf("invalid verbosity %q, it must be between 0 and %v", v, maxVerbosity)
+		}
+	}
+
+	errorLogLevel = query.Get("error-log-level")
+	if errorLogLevel != "" && !slices.Contains(errorLogLevels, errorLogLevel) {
+		return 0, "", 0, fmt.Errorf("invalid error log level %q, it must be one of %v", errorLogLevel, errorLogLevels)
+	}
+
+	if verbosity < 0 && errorLogLevel == "" {
+		return 0, "", 0, fmt.Errorf("missing v or error-log-level query parameter")
+	}
+
+	duration = defaultDuration
+	if d := query.Get("duration"); d != "" {
+		duration, err = time.ParseDuration(d)
+		if err != nil {
+			return 0, "", 0, fmt.Errorf("invalid duration %q: %w", d, err)
+		}
+	}
+	if duration <= 0 || duration > maxLogLevelDuration {
+		return 0, "", 0, fmt.Errorf("invalid duration %v, it must be positive and at most %v", duration, maxLogLevelDuration)
+	}
+
+	return verbosity, errorLogLevel, duration, nil
+}
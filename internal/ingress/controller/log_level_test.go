@@ -0,0 +1,99 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestParseLogLevels(t *testing.T) {
+	testCases := []struct {
+		query            string
+		expVerbosity     int
+		expErrorLogLevel string
+		expDuration      time.Duration
+		expErr           bool
+	}{
+		{"v=5", 5, "", 15 * time.Minute, false},
+		{"error-log-level=debug&duration=5m", -1, "debug", 5 * time.Minute, false},
+		{"v=3&error-log-level=info&duration=1h", 3, "info", time.Hour, false},
+		{"", 0, "", 0, true},
+		{"v=11", 0, "", 0, true},
+		{"v=-1", 0, "", 0, true},
+		{"error-log-level=trace", 0, "", 0, true},
+		{"v=5&duration=25h", 0, "", 0, true},
+		{"v=5&duration=-1m", 0, "", 0, true},
+		{"v=5&duration=soon", 0, "", 0, true},
+	}
+
+	for _, tc := range testCases {
+		r := httptest.NewRequest("PUT", LogLevelPath+"?"+tc.query, nil)
+		verbosity, errorLogLevel, duration, err := parseLogLevels(r, 15*time.Minute)
+		if tc.expErr {
+			if err == nil {
+				t.Errorf("expected an error for the query %q", tc.query)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("unexpected error for the query %q: %v", tc.query, err)
+			continue
+		}
+		if verbosity != tc.expVerbosity || errorLogLevel != tc.expErrorLogLevel || duration != tc.expDuration {
+			t.Errorf("expected %v, %q and %v for the query %q but %v, %q and %v returned",
+				tc.expVerbosity, tc.expErrorLogLevel, tc.expDuration, tc.query, verbosity, errorLogLevel, duration)
+		}
+	}
+}
+
+func TestLogLevelOverride(t *testing.T) {
+	verbosity := currentVerbosity()
+	defer setVerbosity(verbosity)
+
+	changes := 0
+	o := &logLevelOverride{onErrorLogLevelChange: func() { changes++ }}
+
+	now := time.Now()
+	o.set(verbosity+2, "debug", time.Hour, now)
+	if currentVerbosity() != verbosity+2 || o.getErrorLogLevel() != "debug" || changes != 1 {
+		t.Fatalf("expected verbosity %v and error log level debug with one change but %v, %q and %v changes returned",
+			verbosity+2, currentVerbosity(), o.getErrorLogLevel(), changes)
+	}
+
+	o.set(verbosity+3, "", 2*time.Hour, now)
+	if currentVerbosity() != verbosity+3 || o.getErrorLogLevel() != "debug" || changes != 1 {
+		t.Errorf("expected the error log level to be kept when only the verbosity changes")
+	}
+	if expires := o.getExpires(); expires == nil || !expires.Equal(now.Add(2*time.Hour)) {
+		t.Errorf("expected the levels to be reverted at %v but %v returned", now.Add(2*time.Hour), expires)
+	}
+
+	o.revert()
+	if currentVerbosity() != verbosity || o.getErrorLogLevel() != "" || o.getExpires() != nil || changes != 2 {
+		t.Errorf("expected the levels before the first change to be restored but verbosity %v and error log level %q returned",
+			currentVerbosity(), o.getErrorLogLevel())
+	}
+
+	o.set(-1, "info", 10*time.Millisecond, time.Now())
+	time.Sleep(100 * time.Millisecond)
+	if o.getErrorLogLevel() != "" || changes != 4 {
+		t.Errorf("expected the error log level to be reverted after the duration but %q returned", o.getErrorLogLevel())
+	}
+}
@@ -18,6 +18,7 @@ package controller
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"errors"
@@ -29,9 +30,11 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"text/template"
 	"time"
@@ -41,6 +44,8 @@ import (
 	"github.com/eapache/channels"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
@@ -48,15 +53,18 @@ import (
 	"k8s.io/ingress-nginx/pkg/tcpproxy"
 
 	adm_controller "k8s.io/ingress-nginx/internal/admission/controller"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authoidc"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/process"
 	"k8s.io/ingress-nginx/internal/ingress/controller/store"
 	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
 	"k8s.io/ingress-nginx/internal/ingress/metric"
 	"k8s.io/ingress-nginx/internal/ingress/status"
+	"k8s.io/ingress-nginx/internal/k8s"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/internal/net/dns"
 	"k8s.io/ingress-nginx/internal/net/ssl"
+	"k8s.io/ingress-nginx/internal/net/ssl/stapling"
 	"k8s.io/ingress-nginx/internal/nginx"
 	"k8s.io/ingress-nginx/internal/task"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
@@ -77,7 +85,7 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
 	eventBroadcaster.StartRecordingToSink(&v1core.EventSinkImpl{
-		Interface: config.Client.CoreV1().Events(config.Namespace),
+		Interface: config.Client.CoreV1().Events(k8s.SingleNamespace(config.Namespace)),
 	})
 
 	h, err := dns.GetSystemNameServers()
@@ -85,6 +93,9 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		klog.Warningf("Error reading system nameservers: %v", err)
 	}
 
+	command := NewNginxCommand()
+	command.Timeout = config.ReloadTimeout
+
 	n := &NGINXController{
 		isIPV6Enabled: ing_net.IsIPv6Enabled(),
 
@@ -100,19 +111,33 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		stopCh:   make(chan struct{}),
 		updateCh: channels.NewRingChannel(1024),
 
-		ngxErrCh: make(chan error),
+		ngxErrCh:      make(chan error),
+		ngxSupervisor: process.NewSupervisor(),
 
 		stopLock: &sync.Mutex{},
 
 		runningConfig: new(ingress.Configuration),
 
+		luaSharedDictWarnings: sets.New[string](),
+
+		ocspStapler: stapling.NewStapler(ocspRequestTimeout),
+
 		Proxy: &tcpproxy.TCPProxy{},
 
 		metricCollector: mc,
 
-		command: NewNginxCommand(),
+		admissionBudget: admissionBudget{
+			maxRender:    time.Duration(config.AdmissionMaxRenderSeconds * float64(time.Second)),
+			maxIngresses: config.AdmissionMaxIngressesRendered,
+		},
+
+		command: command,
+
+		oldWorkers: process.NewWorkerWatchdog(),
 	}
 
+	n.decisionEvents = newDecisionEvents(n.recorder)
+
 	if n.cfg.ValidationWebhook != "" {
 		n.validationWebhookServer = &http.Server{
 			Addr: config.ValidationWebhook,
@@ -133,6 +158,7 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		config.ConfigMapName,
 		config.TCPConfigMapName,
 		config.UDPConfigMapName,
+		config.CustomDomainsConfigMapName,
 		config.DefaultSSLCertificate,
 		config.ResyncPeriod,
 		config.Client,
@@ -140,11 +166,20 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 		config.DisableCatchAll,
 		config.DeepInspector,
 		config.IngressClassConfiguration,
-		config.DisableSyncEvents)
+		config.Shard,
+		config.DisableSyncEvents,
+		len(config.ObservabilityLabels) > 0,
+		mc)
 
-	n.syncQueue = task.NewTaskQueue(n.syncIngress)
+	n.syncQueue = task.NewContextTaskQueue(n.syncIngress)
+	n.logLevels = newLogLevelOverride(n)
 
 	if config.UpdateStatus {
+		electionID := config.ElectionID
+		if config.DisableLeaderElection {
+			electionID = ""
+		}
+
 		n.syncStatus = status.NewStatusSyncer(status.Config{
 			Client:                 config.Client,
 			PublishService:         config.PublishService,
@@ -152,6 +187,11 @@ func NewNGINXController(config *Configuration, mc metric.Collector) *NGINXContro
 			IngressLister:          n.store,
 			UpdateStatusOnShutdown: config.UpdateStatusOnShutdown,
 			UseNodeInternalIP:      config.UseNodeInternalIP,
+			RemovalHoldTime:        config.StatusRemovalHoldTime,
+			RemovalObservations:    config.StatusRemovalObservations,
+			ElectionID:             electionID,
+			Shard:                  config.Shard,
+			MetricCollector:        mc,
 		})
 	} else {
 		klog.Warning("Update of Ingress status is disabled (flag --update-status)")
@@ -244,8 +284,31 @@ type NGINXController struct {
 	// ngxErrCh is used to detect errors with the NGINX processes
 	ngxErrCh chan error
 
+	// ngxSupervisor decides when the NGINX master process is respawned after an exit
+	ngxSupervisor *process.Supervisor
+	// ngxPgid is the process group of the running NGINX master process and its workers
+	ngxPgid int
+	// ngxMasterPID is the PID of the running NGINX master process, started by
+	// the controller or by a binary upgrade
+	ngxMasterPID atomic.Int64
+
+	// binaryUpgrade is true when the configuration is loaded by binary upgrades
+	// of the NGINX master process instead of reloads
+	binaryUpgrade bool
+	// resetDynamicConfiguration is true when the NGINX master process was
+	// replaced by a binary upgrade or respawned, and the whole dynamic
+	// configuration must be sent to its empty Lua shared dictionaries
+	resetDynamicConfiguration atomic.Bool
+
 	// runningConfig contains the running configuration in the Backend
 	runningConfig *ingress.Configuration
+	// runningConfigLock protects the reads of runningConfig outside of the
+	// synchronization loop, like in the configuration endpoint
+	runningConfigLock sync.RWMutex
+
+	// annotationUsageReport is the last aggregation of the usage of the annotations
+	annotationUsageReport *AnnotationUsageReport
+	annotationUsageLock   sync.RWMutex
 
 	t ngx_template.Writer
 
@@ -255,14 +318,73 @@ type NGINXController struct {
 
 	isShuttingDown bool
 
+	// fdWarningEmitted indicates a warning about the file descriptors
+	// used by the NGINX workers was already emitted
+	fdWarningEmitted bool
+
+	// configSizeWarningEmitted indicates a warning about the size of the
+	// configuration exceeding the budget was already emitted
+	configSizeWarningEmitted bool
+
+	// serverBlocks contains the files of the servers of the last reloaded
+	// configuration, when the servers are moved to their own file
+	serverBlocks map[string][]byte
+
+	// oldWorkers tracks the NGINX workers of previous configurations
+	// shutting down, and kills the ones exceeding the limits
+	oldWorkers *process.WorkerWatchdog
+
+	// stuckReloadWarningEmitted indicates a warning about the NGINX workers
+	// not exiting after a reload was already emitted
+	stuckReloadWarningEmitted bool
+
+	// offlineWarnedOptions contains the options of the configuration
+	// disabled by --offline of the last warning emitted
+	offlineWarnedOptions string
+
+	// luaSharedDictWarnings contains the Lua shared dictionaries with a
+	// warning about their utilization already emitted
+	luaSharedDictWarnings sets.Set[string]
+
+	// decisionEvents emits the Events of the decisions taken about the
+	// Ingresses in the synchronizations when they change
+	decisionEvents *decisionEvents
+
+	// runningLuaSharedDicts contains the sizes of the Lua shared dictionaries
+	// of the running configuration
+	runningLuaSharedDicts map[string]int
+
+	// ocspStapler prefetches the OCSP responses of the certificates
+	ocspStapler *stapling.Stapler
+	// ocspLastSync is the last time all the OCSP responses were configured in NGINX
+	ocspLastSync time.Time
+
+	// configDrift tracks the replicas running a different configuration
+	// while the pod holds the leadership
+	configDrift *configDrift
+
+	// customDomainCerts contains the certificates of the custom domains indexed by Secret
+	customDomainCerts map[string]customDomainCert
+
 	Proxy *tcpproxy.TCPProxy
 
 	store store.Storer
 
 	metricCollector metric.Collector
 
+	// health tracks the result of the operations of the components
+	// reported in the verbose health check
+	health componentHealth
+
 	validationWebhookServer *http.Server
 
+	// admissionBudget selects the incremental validation of the Ingresses
+	// in the admission webhook
+	admissionBudget admissionBudget
+
+	// logLevels contains the log levels changed at runtime
+	logLevels *logLevelOverride
+
 	command NginxExecTester
 }
 
@@ -270,7 +392,19 @@ type NGINXController struct {
 func (n *NGINXController) Start() {
 	klog.InfoS("Starting NGINX Ingress controller")
 
+	startedFromSnapshot := n.startFromConfigurationSnapshot()
+
+	if startedFromSnapshot {
+		// the checks skipped during the start are executed once the API
+		// server is available, before the informers depending on them
+		n.waitForAPIServer()
+		if n.cfg.CheckCluster != nil {
+			n.cfg.CheckCluster()
+		}
+	}
+
 	n.store.Run(n.stopCh)
+	n.health.setInformersSynced()
 
 	// we need to use the defined ingress class to allow multiple leaders
 	// in order to update information about ingress status
@@ -289,7 +423,13 @@ func (n *NGINXController) Start() {
 					go n.syncStatus.Run(stopCh)
 				}
 
+				if n.cfg.ConfigDriftThreshold > 0 {
+					n.configDrift = newConfigDrift()
+					go wait.Until(n.checkConfigurationDrift, configDriftCheckPeriod, stopCh)
+				}
+
 				n.metricCollector.OnStartedLeading(electionID)
+				n.health.setLeader(true)
 				// manually update SSL expiration metrics
 				// (to not wait for a reload)
 				n.metricCollector.SetSSLExpireTime(n.runningConfig.Servers)
@@ -297,27 +437,29 @@ func (n *NGINXController) Start() {
 			},
 			OnStoppedLeading: func() {
 				n.metricCollector.OnStoppedLeading(electionID)
+				n.metricCollector.SetConfigDriftedReplicas(0)
+				n.health.setLeader(false)
 			},
 		})
-	}
-
-	cmd := n.command.ExecCommand()
 
-	// put NGINX in another process group to prevent it
-	// to receive signals meant for the controller
-	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
+		if n.cfg.ConfigDriftThreshold > 0 {
+			go wait.Until(n.publishConfigurationChecksum, configDriftCheckPeriod, n.stopCh)
+		}
 	}
 
-	if n.cfg.EnableSSLPassthrough {
-		n.setupSSLProxy()
+	if !startedFromSnapshot {
+		klog.InfoS("Starting NGINX process")
+		n.startNGINX()
 	}
 
-	klog.InfoS("Starting NGINX process")
-	n.start(cmd)
-
 	go n.syncQueue.Run(time.Second, n.stopCh)
+	go wait.Until(n.checkWorkerFileDescriptors, fdCheckPeriod, n.stopCh)
+	go wait.Until(n.checkStuckReload, stuckReloadCheckPeriod, n.stopCh)
+	go wait.Until(n.checkLuaSharedDicts, luaSharedDictCheckPeriod, n.stopCh)
+	go wait.Until(n.reportComponentHealth, componentHealthPeriod, n.stopCh)
+	go wait.Until(n.aggregateAnnotationUsage, annotationUsagePeriod, n.stopCh)
+	go wait.Until(n.reportIgnoredIngresses, ignoredIngressesPeriod, n.stopCh)
+	go wait.Until(n.refreshOCSPResponses, ocspRefreshPeriod, n.stopCh)
 	// force initial sync
 	n.syncQueue.EnqueueTask(task.GetDummyObject("initial-sync"))
 
@@ -348,9 +490,9 @@ func (n *NGINXController) Start() {
 				return
 			}
 
-			// if the nginx master process dies, the workers continue to process requests
-			// until the failure of the configured livenessProbe and restart of the pod.
-			if process.IsRespawnIfRequired(err) {
+			if !n.respawnNGINX(err) {
+				// the workers continue to process requests until the failure
+				// of the configured livenessProbe and restart of the pod.
 				return
 			}
 
@@ -367,6 +509,15 @@ func (n *NGINXController) Start() {
 					continue
 				}
 
+				if isSecret(evt.Obj) {
+					n.health.certificateChanged()
+				}
+
+				if evt.Type == store.CertificateEvent {
+					n.syncQueue.EnqueuePartialTask(evt.Obj)
+					continue
+				}
+
 				n.syncQueue.EnqueueSkippableTask(evt.Obj)
 			} else {
 				klog.Warningf("Unexpected event type received %T", event)
@@ -407,7 +558,9 @@ func (n *NGINXController) Stop() error {
 
 	// send stop signal to NGINX
 	klog.InfoS("Stopping NGINX process")
-	cmd := n.command.ExecCommand("-s", "quit")
+	quitCtx, cancel := n.commandContext()
+	defer cancel()
+	cmd := n.command.ExecCommandContext(quitCtx, "-s", "quit")
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	err := cmd.Run()
@@ -428,7 +581,88 @@ func (n *NGINXController) Stop() error {
 	return nil
 }
 
+// startNGINX starts the NGINX master process
+func (n *NGINXController) startNGINX() {
+	if n.cfg.EnableSSLPassthrough {
+		n.setupSSLProxy()
+	}
+	n.setupBinaryUpgrade()
+
+	n.start(n.nginxCommand())
+}
+
+// nginxCommand returns the command running the NGINX master process
+func (n *NGINXController) nginxCommand() *exec.Cmd {
+	cmd := n.command.ExecCommand()
+
+	// put NGINX in another process group to prevent it
+	// to receive signals meant for the controller
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+		Pgid:    0,
+	}
+
+	return cmd
+}
+
+// respawnNGINX starts a new NGINX master process after the exit of the
+// running one, after a delay growing with the consecutive exits. It returns
+// false when the process must not be respawned, because the error is not an
+// exit of the process or the process exits too often.
+func (n *NGINXController) respawnNGINX(err error) bool {
+	exit, ok := process.ParseExit(err)
+	if !ok {
+		klog.ErrorS(err, "Unexpected error waiting for the NGINX master process")
+		return false
+	}
+
+	klog.Warningf(`
+-------------------------------------------------------------------------------
+NGINX master process died (%v): %v
+-------------------------------------------------------------------------------
+`, exit, err)
+	n.metricCollector.IncNGINXMasterExitCount(exit.Reason())
+	n.recorder.Eventf(k8s.IngressPodDetails, apiv1.EventTypeWarning, "NGINXExit", "NGINX master process %v", exit)
+
+	delay, respawn := n.ngxSupervisor.Exited(time.Now())
+	if !respawn {
+		msg := fmt.Sprintf("NGINX master process exited %v times in %v, not respawning it",
+			n.ngxSupervisor.CrashLoopExits, n.ngxSupervisor.CrashLoopWindow)
+		klog.Error(msg)
+		n.metricCollector.SetNGINXMasterCrashLoop(true)
+		n.recorder.Event(k8s.IngressPodDetails, apiv1.EventTypeWarning, "NGINXCrashLoop", msg)
+		return false
+	}
+
+	// the workers of the previous master process keep the listening sockets open
+	if err := process.TerminateGroup(n.ngxPgid); err != nil {
+		klog.Warningf("Error stopping the workers of the previous NGINX master process: %v", err)
+	}
+
+	klog.InfoS("Respawning NGINX master process", "delay", delay)
+	select {
+	case <-time.After(delay):
+	case <-n.stopCh:
+		return false
+	}
+
+	n.start(n.nginxCommand())
+
+	// the Lua shared dictionaries of the new master process are empty
+	n.resetDynamicConfiguration.Store(true)
+	n.syncQueue.EnqueueTask(task.GetDummyObject("nginx-respawn"))
+	return true
+}
+
 func (n *NGINXController) start(cmd *exec.Cmd) {
+	// NGINX only removes its UNIX sockets when the master process exits
+	// gracefully, and cannot listen on the sockets left by a crash
+	for _, socket := range []string{nginx.ServerAliasSockets.HTTP, nginx.ServerAliasSockets.HTTPS} {
+		if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+			klog.Warningf("Error removing UNIX socket %v: %v", socket, err)
+		}
+	}
+
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Start(); err != nil {
@@ -437,8 +671,13 @@ func (n *NGINXController) start(cmd *exec.Cmd) {
 		return
 	}
 
+	n.ngxPgid = cmd.Process.Pid
+	n.ngxMasterPID.Store(int64(cmd.Process.Pid))
+	n.ngxSupervisor.Started(time.Now())
+
 	go func() {
-		n.ngxErrCh <- cmd.Wait()
+		err := cmd.Wait()
+		n.ngxErrCh <- n.waitNGINX(cmd.Process.Pid, err)
 	}()
 }
 
@@ -451,11 +690,14 @@ func (n *NGINXController) DefaultEndpoint() ingress.Endpoint {
 	}
 }
 
-// generateTemplate returns the nginx configuration file content
+// generateTemplate returns the nginx configuration file content. The
+// configurations generated for the admission of an Ingress do not replace
+// the cached server blocks nor the SSL passthrough servers of the running
+// configuration.
 //
 //nolint:gocritic // the cfg shouldn't be changed, and shouldn't be mutated by other processes while being rendered.
-func (n *NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressCfg ingress.Configuration) ([]byte, error) {
-	if n.cfg.EnableSSLPassthrough {
+func (n *NGINXController) generateTemplate(cfg ngx_config.Configuration, ingressCfg ingress.Configuration, admission bool) ([]byte, error) {
+	if n.cfg.EnableSSLPassthrough && !admission {
 		servers := []*tcpproxy.TCPServer{}
 		for _, pb := range ingressCfg.PassthroughBackends {
 			svc := pb.Service
@@ -481,16 +723,20 @@ func (n *NGINXController) generateTemplate(cfg ngx_config.Configuration, ingress
 				}
 			}
 
-			// TODO: Allow PassthroughBackends to specify they support proxy-protocol
-			servers = append(servers, &tcpproxy.TCPServer{
-				Hostname:      pb.Hostname,
-				IP:            svc.Spec.ClusterIP,
-				Port:          port,
-				ProxyProtocol: false,
-			})
+			server := &tcpproxy.TCPServer{
+				Hostname:             pb.Hostname,
+				IP:                   svc.Spec.ClusterIP,
+				Port:                 port,
+				ProxyProtocol:        pb.ProxyProtocol.Version > 0,
+				ProxyProtocolVersion: pb.ProxyProtocol.Version,
+			}
+			for _, tlv := range pb.ProxyProtocol.TLVs {
+				server.ProxyProtocolTLVs = append(server.ProxyProtocolTLVs, tcpproxy.TLV{Type: tlv.Type, Value: []byte(tlv.Value)})
+			}
+			servers = append(servers, server)
 		}
 
-		n.Proxy.ServerList = servers
+		n.Proxy.SetServers(servers, terminatedHosts(ingressCfg.Servers))
 	}
 
 	// NGINX cannot resize the hash tables used to store server names. For
@@ -551,6 +797,11 @@ func (n *NGINXController) generateTemplate(cfg ngx_config.Configuration, ingress
 		cfg.MaxWorkerConnections = maxWorkerConnections
 	}
 
+	if cfg.LuaSharedDictsAutosize {
+		cfg.LuaSharedDicts = luaSharedDictSizes(&cfg, &ingressCfg)
+		klog.V(3).InfoS("Adjusting LuaSharedDicts variable", "value", cfg.LuaSharedDicts)
+	}
+
 	setHeaders := map[string]string{}
 	if cfg.ProxySetHeaders != "" {
 		cmap, err := n.store.GetConfigMap(cfg.ProxySetHeaders)
@@ -630,6 +881,10 @@ func (n *NGINXController) generateTemplate(cfg ngx_config.Configuration, ingress
 		StatusPort:               nginx.StatusPort,
 		StreamPort:               nginx.StreamPort,
 		StreamSnippets:           append(ingressCfg.StreamSnippets, cfg.StreamSnippet),
+		EnableCustomDomains:      n.cfg.CustomDomainsConfigMapName != "",
+		ServerAliasSockets:       nginx.ServerAliasSockets,
+		Dataplane:                n.cfg.Dataplane,
+		Admission:                admission,
 	}
 
 	tc.Cfg.Checksum = ingressCfg.ConfigurationChecksum
@@ -670,26 +925,71 @@ Error: %v
 	return nil
 }
 
+// validateTemplate checks the NGINX configuration at the admission stage. The
+// syntax is checked in process first, rejecting invalid configurations without
+// running "nginx -t", which is skipped when only the syntax is tested.
+func (n *NGINXController) validateTemplate(cfg []byte) error {
+	if err := nginx.CheckSyntax(cfg); err != nil {
+		return fmt.Errorf("invalid NGINX configuration: %w", err)
+	}
+
+	if n.cfg.SyntaxOnlyValidationTest {
+		return nil
+	}
+
+	return n.testTemplate(cfg)
+}
+
 // OnUpdate is called by the synchronization loop whenever configuration
 // changes were detected. The received backend Configuration is merged with the
 // configuration ConfigMap before generating the final configuration file.
-// Returns nil in case the backend was successfully reloaded.
+// Returns nil in case the backend was successfully reloaded. The update is
+// aborted when the context is canceled before the configuration is written.
 //
 //nolint:gocritic // the cfg shouldn't be changed, and shouldn't be mutated by other processes while being rendered.
-func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
+func (n *NGINXController) OnUpdate(ctx context.Context, ingressCfg ingress.Configuration) error {
 	cfg := n.store.GetBackendConfiguration()
 	cfg.Resolver = n.resolver
+	if ingressCfg.ErrorLogLevel != "" {
+		cfg.ErrorLogLevel = ingressCfg.ErrorLogLevel
+	}
 
 	workerSerialReloads := cfg.WorkerSerialReloads
 	if workerSerialReloads && n.workersReloading {
 		return errors.New("worker reload already in progress, requeuing reload")
 	}
 
-	content, err := n.generateTemplate(cfg, ingressCfg)
+	content, err := n.generateTemplate(cfg, ingressCfg, false)
 	if err != nil {
 		return err
 	}
 
+	renderedSize := len(content)
+	var sharedBlocks map[string][]byte
+	if n.cfg.OptimizeConfiguration {
+		content, sharedBlocks, err = optimizeConfiguration(content, sharedBlocksPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	var serverBlocks map[string][]byte
+	if n.exceedsConfigSizeBudget(len(content)) || n.cfg.SplitConfiguration {
+		content, serverBlocks, err = splitConfiguration(content, n.serverBlocksDir())
+		if err != nil {
+			return err
+		}
+	}
+
+	n.metricCollector.SetConfigSize(renderedSize, len(content)+sharedBlocksSize(sharedBlocks)+sharedBlocksSize(serverBlocks), len(sharedBlocks))
+	n.metricCollector.SetConfigObjects(configurationObjects(&ingressCfg))
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	n.warnOfflineOptions(&cfg)
+
 	err = n.createLuaConfig(&cfg)
 	if err != nil {
 		return err
@@ -719,26 +1019,31 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 			if err != nil {
 				return err
 			}
-			//nolint:gosec //Ignore G204 error
-			diffOutput, err := exec.Command("diff", "-I", "'# Configuration.*'", "-u", cfgPath, tmpfile.Name()).CombinedOutput()
-			if err != nil {
-				if exitError, ok := err.(*exec.ExitError); ok {
-					ws, ok := exitError.Sys().(syscall.WaitStatus)
-					if !ok {
-						klog.Errorf("unexpected type: %T", exitError.Sys())
-					}
-					if ws.ExitStatus() == 2 {
-						klog.Warningf("Failed to executing diff command: %v", err)
-					}
-				}
-			}
 
-			klog.InfoS("NGINX configuration change", "diff", string(diffOutput))
+			klog.InfoS("NGINX configuration change", "diff", diffFiles(cfgPath, tmpfile.Name()))
 
 			// we do not defer the deletion of temp files in order
 			// to keep them around for inspection in case of error
 			os.Remove(tmpfile.Name())
 		}
+
+		// the files of the previous servers are kept until the reload
+		dir := n.serverBlocksDir()
+		for _, change := range changedServers(n.serverBlocks, serverBlocks) {
+			previous, current := os.DevNull, os.DevNull
+			if change.previous != "" {
+				previous = filepath.Join(dir, change.previous)
+			}
+			if change.current != "" {
+				current = filepath.Join(dir, change.current)
+			}
+			klog.InfoS("NGINX server configuration change", "server", change.server, "diff", diffFiles(previous, current))
+		}
+	}
+
+	// the configuration is tested, the update is not aborted after this point
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	err = os.WriteFile(cfgPath, content, file.ReadWriteByUser)
@@ -746,11 +1051,34 @@ func (n *NGINXController) OnUpdate(ingressCfg ingress.Configuration) error {
 		return err
 	}
 
-	o, err := n.command.ExecCommand("-s", "reload").CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("%v\n%v", err, string(o))
+	reloadCtx, cancel := n.commandContext()
+	defer cancel()
+	if n.binaryUpgrade {
+		if err := n.upgradeNGINX(reloadCtx); err != nil {
+			return fmt.Errorf("NGINX binary upgrade failed: %w", err)
+		}
+	} else {
+		o, err := n.command.ExecCommandContext(reloadCtx, "-s", "reload").CombinedOutput()
+		if reloadCtx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("NGINX reload timed out after %v (--reload-timeout)", n.cfg.ReloadTimeout)
+		}
+		if err != nil {
+			return fmt.Errorf("%v\n%v", err, string(o))
+		}
 	}
 
+	if n.cfg.OptimizeConfiguration {
+		if err := removeUnusedSharedBlocks(sharedBlocksPath, sharedBlocks); err != nil {
+			klog.Warningf("Error removing the unused shared blocks of the NGINX configuration: %v", err)
+		}
+	}
+	if n.cfg.ConfigSizeBudget > 0 || n.cfg.SplitConfiguration {
+		if err := removeUnusedSharedBlocks(n.serverBlocksDir(), serverBlocks); err != nil {
+			klog.Warningf("Error removing the unused servers of the NGINX configuration: %v", err)
+		}
+	}
+	n.serverBlocks = serverBlocks
+
 	// Reload status checking runs in a separate goroutine to avoid blocking the sync queue
 	if workerSerialReloads {
 		go n.awaitWorkersReload()
@@ -860,8 +1188,19 @@ func (n *NGINXController) setupSSLProxy() {
 
 // configureDynamically encodes new Backends in JSON format and POSTs the
 // payload to an internal HTTP endpoint handled by Lua.
-func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) error {
-	backendsChanged := !reflect.DeepEqual(n.runningConfig.Backends, pcfg.Backends)
+func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) (err error) {
+	running := n.runningConfig
+	if n.resetDynamicConfiguration.Swap(false) {
+		running = new(ingress.Configuration)
+		defer func() {
+			if err != nil {
+				// the whole configuration is sent again by the next attempt
+				n.resetDynamicConfiguration.Store(true)
+			}
+		}()
+	}
+
+	backendsChanged := !reflect.DeepEqual(running.Backends, pcfg.Backends)
 	if backendsChanged {
 		err := configureBackends(pcfg.Backends)
 		if err != nil {
@@ -869,7 +1208,7 @@ func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) erro
 		}
 	}
 
-	streamConfigurationChanged := !reflect.DeepEqual(n.runningConfig.TCPEndpoints, pcfg.TCPEndpoints) || !reflect.DeepEqual(n.runningConfig.UDPEndpoints, pcfg.UDPEndpoints)
+	streamConfigurationChanged := !reflect.DeepEqual(running.TCPEndpoints, pcfg.TCPEndpoints) || !reflect.DeepEqual(running.UDPEndpoints, pcfg.UDPEndpoints)
 	if streamConfigurationChanged {
 		err := updateStreamConfiguration(pcfg.TCPEndpoints, pcfg.UDPEndpoints)
 		if err != nil {
@@ -877,7 +1216,7 @@ func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) erro
 		}
 	}
 
-	serversChanged := !reflect.DeepEqual(n.runningConfig.Servers, pcfg.Servers)
+	serversChanged := !reflect.DeepEqual(running.Servers, pcfg.Servers)
 	if serversChanged {
 		err := configureCertificates(pcfg.Servers)
 		if err != nil {
@@ -885,6 +1224,46 @@ func (n *NGINXController) configureDynamically(pcfg *ingress.Configuration) erro
 		}
 	}
 
+	customDomainsChanged := !reflect.DeepEqual(running.CustomDomains, pcfg.CustomDomains)
+	if customDomainsChanged {
+		err := configureCustomDomainCertificates(pcfg.CustomDomains, running.CustomDomains)
+		if err != nil {
+			return err
+		}
+	}
+
+	dynamicServerAliases := n.store.GetBackendConfiguration().EnableDynamicServerAliases
+	if (serversChanged && dynamicServerAliases) || customDomainsChanged {
+		err := configureServerAliases(buildServerAliases(pcfg, dynamicServerAliases))
+		if err != nil {
+			return err
+		}
+	}
+
+	authCredentials := buildAuthCredentials(pcfg)
+	if !reflect.DeepEqual(buildAuthCredentials(running), authCredentials) {
+		err := configureAuthCredentials(authCredentials)
+		if err != nil {
+			return err
+		}
+	}
+
+	oidcClients := buildOIDCClients(pcfg)
+	if !reflect.DeepEqual(buildOIDCClients(running), oidcClients) {
+		err := configureOIDCClients(oidcClients)
+		if err != nil {
+			return err
+		}
+	}
+
+	luaKV := buildLuaKV(pcfg)
+	if !reflect.DeepEqual(buildLuaKV(running), luaKV) {
+		err := configureLuaKV(luaKV)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -913,12 +1292,18 @@ func updateStreamConfiguration(tcpEndpoints, udpEndpoints []ingress.L4Service) e
 		}
 
 		key := fmt.Sprintf("udp-%v-%v-%v", ep.Backend.Namespace, ep.Backend.Name, ep.Backend.Port.String())
-		streams = append(streams, ingress.Backend{
+		stream := ingress.Backend{
 			Name:      key,
 			Endpoints: ep.Endpoints,
 			Port:      intstr.FromInt(ep.Port),
 			Service:   service,
-		})
+		}
+		if ep.Backend.UDP.Affinity == "client-ip" {
+			// consistent hashing returns the same endpoint for a client in every replica
+			stream.LoadBalancing = "chash"
+			stream.UpstreamHashBy.UpstreamHashBy = "$remote_addr"
+		}
+		streams = append(streams, stream)
 	}
 
 	buf, err := json.Marshal(streams)
@@ -954,16 +1339,19 @@ func configureBackends(rawBackends []*ingress.Backend) error {
 			service = &apiv1.Service{Spec: backend.Service.Spec}
 		}
 		luaBackend := &ingress.Backend{
-			Name:                 backend.Name,
-			Port:                 backend.Port,
-			SSLPassthrough:       backend.SSLPassthrough,
-			SessionAffinity:      backend.SessionAffinity,
-			UpstreamHashBy:       backend.UpstreamHashBy,
-			LoadBalancing:        backend.LoadBalancing,
-			Service:              service,
-			NoServer:             backend.NoServer,
-			TrafficShapingPolicy: backend.TrafficShapingPolicy,
-			AlternativeBackends:  backend.AlternativeBackends,
+			Name:                   backend.Name,
+			Port:                   backend.Port,
+			SSLPassthrough:         backend.SSLPassthrough,
+			SessionAffinity:        backend.SessionAffinity,
+			UpstreamHashBy:         backend.UpstreamHashBy,
+			LoadBalancing:          backend.LoadBalancing,
+			LoadBalanceTuning:      backend.LoadBalanceTuning,
+			Warmup:                 backend.Warmup,
+			Service:                service,
+			NoServer:               backend.NoServer,
+			TrafficShapingPolicy:   backend.TrafficShapingPolicy,
+			AlternativeBackends:    backend.AlternativeBackends,
+			ExternalNameResolution: backend.ExternalNameResolution,
 		}
 
 		var endpoints []ingress.Endpoint
@@ -993,17 +1381,227 @@ func configureBackends(rawBackends []*ingress.Backend) error {
 type sslConfiguration struct {
 	Certificates map[string]string `json:"certificates"`
 	Servers      map[string]string `json:"servers"`
+	// AlternateServers contains the UID of the alternate certificate of each
+	// server, served to the clients supporting its key type
+	AlternateServers map[string]string       `json:"alternateServers,omitempty"`
+	OCSPResponses    map[string]ocspResponse `json:"ocspResponses,omitempty"`
+}
+
+// alternateCertificate returns the UID and the PEM of the alternate certificate
+// served with a certificate, from the ssl-alternate-secret annotation or else
+// from the Secret of the certificate. The UID is emptyUID without an alternate
+// certificate.
+func alternateCertificate(sslCert, alternate *ingress.SSLCert) (uid, pem string) {
+	switch {
+	case alternate != nil:
+		return alternate.UID, alternate.PemCertKey
+	case sslCert != nil && sslCert.AlternatePemCertKey != "":
+		return sslCert.UID + "-alternate", sslCert.AlternatePemCertKey
+	default:
+		return emptyUID, ""
+	}
+}
+
+// buildServerAliases returns the hostname of the server that handles each custom domain
+// and, when includeAliases is true, each server alias
+func buildServerAliases(pcfg *ingress.Configuration, includeAliases bool) map[string]string {
+	aliases := make(map[string]string)
+	if includeAliases {
+		for _, rawServer := range pcfg.Servers {
+			for _, alias := range rawServer.Aliases {
+				aliases[alias] = rawServer.Hostname
+			}
+		}
+	}
+
+	for _, domain := range pcfg.CustomDomains {
+		aliases[domain.Hostname] = domain.Server
+	}
+
+	return aliases
+}
+
+// configureServerAliases configures the hostname of the server that handles each alias.
+// Requests for aliases not present in the running NGINX configuration are proxied to that server.
+func configureServerAliases(aliases map[string]string) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/server-aliases", "application/json", aliases)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
+
+// buildAuthCredentials returns the credentials kept in memory of the locations
+// using basic authentication, by key
+func buildAuthCredentials(pcfg *ingress.Configuration) map[string]map[string]string {
+	credentials := make(map[string]map[string]string)
+	for _, server := range pcfg.Servers {
+		for _, location := range server.Locations {
+			if !location.BasicDigestAuth.InMemory {
+				continue
+			}
+
+			credentials[location.BasicDigestAuth.Key] = location.BasicDigestAuth.Credentials
+		}
+	}
+
+	return credentials
+}
+
+// configureAuthCredentials replaces the credentials used by Lua to verify the
+// basic authentication of the locations
+func configureAuthCredentials(credentials map[string]map[string]string) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/auth-credentials", "application/json", credentials)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
+
+// buildOIDCClients returns the credentials of the OpenID Connect clients of
+// the locations, by key
+func buildOIDCClients(pcfg *ingress.Configuration) map[string]authoidc.Client {
+	clients := make(map[string]authoidc.Client)
+	for _, server := range pcfg.Servers {
+		for _, location := range server.Locations {
+			if location.OIDCAuth.Key == "" {
+				continue
+			}
+
+			clients[location.OIDCAuth.Key] = location.OIDCAuth.Client
+		}
+	}
+
+	return clients
+}
+
+// configureOIDCClients replaces the credentials of the OpenID Connect clients
+// used by Lua to authenticate the users of the locations
+func configureOIDCClients(clients map[string]authoidc.Client) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/oidc-clients", "application/json", clients)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
+
+// buildLuaKV returns the key/value stores of the Lua code of the locations,
+// by namespace and name of their Ingress
+func buildLuaKV(pcfg *ingress.Configuration) map[string]map[string]string {
+	stores := make(map[string]map[string]string)
+	for _, server := range pcfg.Servers {
+		for _, location := range server.Locations {
+			if location.LuaKV.ConfigMap == "" || location.Ingress == nil {
+				continue
+			}
+
+			stores[k8s.MetaNamespaceKey(location.Ingress)] = location.LuaKV.Data
+		}
+	}
+
+	return stores
+}
+
+// configureLuaKV replaces the key/value stores read by the Lua code of the
+// locations
+func configureLuaKV(stores map[string]map[string]string) error {
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/lua-kv", "application/json", stores)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
+
+// configureCustomDomainCertificates configures the certificates of the custom domains
+// and removes the certificates of the custom domains that no longer exist
+func configureCustomDomainCertificates(domains, previousDomains []ingress.CustomDomain) error {
+	configuration := &sslConfiguration{
+		Certificates:     map[string]string{},
+		Servers:          map[string]string{},
+		AlternateServers: map[string]string{},
+	}
+
+	for _, domain := range previousDomains {
+		configuration.Servers[domain.Hostname] = emptyUID
+		configuration.AlternateServers[domain.Hostname] = emptyUID
+	}
+
+	for _, domain := range domains {
+		alternateUID, alternatePem := alternateCertificate(domain.SSLCert, nil)
+		configuration.AlternateServers[domain.Hostname] = alternateUID
+		if alternateUID != emptyUID {
+			configuration.Certificates[alternateUID] = alternatePem
+		}
+
+		if domain.SSLCert == nil {
+			configuration.Servers[domain.Hostname] = emptyUID
+			continue
+		}
+
+		configuration.Servers[domain.Hostname] = domain.SSLCert.UID
+		configuration.Certificates[domain.SSLCert.UID] = domain.SSLCert.PemCertKey
+	}
+
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/servers", "application/json", configuration)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
 }
 
 // configureCertificates JSON encodes certificates and POSTs it to an internal HTTP endpoint
 // that is handled by Lua
 func configureCertificates(rawServers []*ingress.Server) error {
+	return postCertificates(rawServers, utilingress.BuildRedirects(rawServers))
+}
+
+// certificateRedirects returns the redirects to the hosts built from all the
+// servers, as a server of the www or apex twin of a host disables its redirect
+func certificateRedirects(servers []*ingress.Server, hosts []string) []*utilingress.Redirect {
+	redirects := make([]*utilingress.Redirect, 0)
+	for _, redirect := range utilingress.BuildRedirects(servers) {
+		if slices.Contains(hosts, redirect.To) {
+			redirects = append(redirects, redirect)
+		}
+	}
+
+	return redirects
+}
+
+// postCertificates POSTs the certificates of the servers and redirects to Lua
+func postCertificates(rawServers []*ingress.Server, redirects []*utilingress.Redirect) error {
 	configuration := &sslConfiguration{
-		Certificates: map[string]string{},
-		Servers:      map[string]string{},
+		Certificates:     map[string]string{},
+		Servers:          map[string]string{},
+		AlternateServers: map[string]string{},
 	}
 
-	configure := func(hostname string, sslCert *ingress.SSLCert) {
+	configure := func(hostname string, sslCert, alternate *ingress.SSLCert) {
 		uid := emptyUID
 
 		if sslCert != nil {
@@ -1015,23 +1613,29 @@ func configureCertificates(rawServers []*ingress.Server) error {
 		}
 
 		configuration.Servers[hostname] = uid
+
+		alternateUID, alternatePem := alternateCertificate(sslCert, alternate)
+		if alternateUID != emptyUID {
+			configuration.Certificates[alternateUID] = alternatePem
+		}
+
+		configuration.AlternateServers[hostname] = alternateUID
 	}
 
 	for _, rawServer := range rawServers {
-		configure(rawServer.Hostname, rawServer.SSLCert)
+		configure(rawServer.Hostname, rawServer.SSLCert, rawServer.AlternateSSLCert)
 
 		for _, alias := range rawServer.Aliases {
 			if rawServer.SSLCert != nil && ssl.IsValidHostname(alias, rawServer.SSLCert.CN) {
-				configuration.Servers[alias] = rawServer.SSLCert.UID
+				configure(alias, rawServer.SSLCert, rawServer.AlternateSSLCert)
 			} else {
-				configuration.Servers[alias] = emptyUID
+				configure(alias, nil, nil)
 			}
 		}
 	}
 
-	redirects := utilingress.BuildRedirects(rawServers)
 	for _, redirect := range redirects {
-		configure(redirect.From, redirect.SSLCert)
+		configure(redirect.From, redirect.SSLCert, nil)
 	}
 
 	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/servers", "application/json", configuration)
@@ -1093,14 +1697,22 @@ func (n *NGINXController) createLuaConfig(cfg *ngx_config.Configuration) error {
 		},
 		UseProxyProtocol:        cfg.UseProxyProtocol,
 		UseForwardedHeaders:     cfg.UseForwardedHeaders,
+		TrustedProxies:          strings.Join(cfg.TrustedProxies, ","),
+		ForwardedHeadersMode:    cfg.ForwardedHeadersMode,
+		ForwardedSchemeOverride: cfg.ForwardedSchemeOverride,
 		IsSSLPassthroughEnabled: n.cfg.EnableSSLPassthrough,
 		HTTPRedirectCode:        cfg.HTTPRedirectCode,
-		EnableOCSP:              cfg.EnableOCSP,
+		EnableOCSP:              cfg.EnableOCSP && !n.cfg.Offline,
+		OCSPPrefetch:            cfg.OCSPPrefetch,
 		MonitorBatchMaxSize:     n.cfg.MonitorMaxBatchSize,
 		HSTS:                    cfg.HSTS,
 		HSTSMaxAge:              cfg.HSTSMaxAge,
 		HSTSIncludeSubdomains:   cfg.HSTSIncludeSubdomains,
 		HSTSPreload:             cfg.HSTSPreload,
+		DNSCacheStaleTTL:        cfg.DNSCacheStaleTTL,
+
+		UpstreamIPFamilyPreference:      cfg.UpstreamIPFamilyPreference,
+		UpstreamIPFamilyFallbackTimeout: cfg.UpstreamIPFamilyFallbackTimeout,
 	}
 	jsonCfg, err := json.Marshal(luaconfigs)
 	if err != nil {
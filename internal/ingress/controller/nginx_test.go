@@ -24,14 +24,20 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	jsoniter "github.com/json-iterator/go"
 	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/wait"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authoidc"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/luakv"
 	"k8s.io/ingress-nginx/internal/nginx"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
 )
@@ -136,6 +142,7 @@ func TestConfigureDynamically(t *testing.T) {
 	n := &NGINXController{
 		runningConfig: &ingress.Configuration{},
 		cfg:           &Configuration{},
+		store:         &fakeIngressStore{},
 	}
 
 	err = n.configureDynamically(commonConfig)
@@ -185,6 +192,67 @@ func TestConfigureDynamically(t *testing.T) {
 	}
 }
 
+func TestConfigureBackends(t *testing.T) {
+	listener, err := tryListen("tcp", fmt.Sprintf(":%v", nginx.StatusPort))
+	if err != nil {
+		t.Fatalf("creating tcp listener: %s", err)
+	}
+	defer listener.Close()
+
+	resolution := ingress.ExternalNameResolution{Nameservers: []string{"10.0.0.10"}, TTL: 30, Static: true}
+	backends := []*ingress.Backend{
+		{
+			Name:                   "default-external-80",
+			Endpoints:              []ingress.Endpoint{{Address: "example.com", Port: "80"}},
+			ExternalNameResolution: resolution,
+		},
+	}
+
+	requests := 0
+	server := &httptest.Server{
+		Listener: listener,
+		//nolint:gosec // Ignore not configured ReadHeaderTimeout in testing
+		Config: &http.Server{
+			Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				requests++
+
+				if r.URL.Path != "/configuration/backends" {
+					t.Errorf("unexpected request to %s", r.URL.Path)
+				}
+
+				b, err := io.ReadAll(r.Body)
+				if err != nil && err != io.EOF {
+					t.Fatal(err)
+				}
+				var posted []*ingress.Backend
+				err = jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(b, &posted)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(posted) != 1 {
+					t.Fatalf("expected 1 backend but %v posted", len(posted))
+				}
+
+				if !posted[0].ExternalNameResolution.Equal(&resolution) {
+					t.Errorf("expected the resolution %v but %v posted", resolution, posted[0].ExternalNameResolution)
+				}
+			}),
+		},
+	}
+	defer server.Close()
+	server.Start()
+
+	if err := configureBackends(backends); err != nil {
+		t.Errorf("unexpected error posting dynamic backend configuration: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request but %v received", requests)
+	}
+}
+
 func TestConfigureCertificates(t *testing.T) {
 	listener, err := tryListen("tcp", fmt.Sprintf(":%v", nginx.StatusPort))
 	if err != nil {
@@ -259,6 +327,153 @@ func TestConfigureCertificates(t *testing.T) {
 	}
 }
 
+func TestCertificateRedirects(t *testing.T) {
+	apex := &ingress.Server{
+		Hostname:          "example.com",
+		RedirectFromToWWW: true,
+		SSLCert:           &ingress.SSLCert{UID: "apex", CN: []string{"example.com", "www.example.com"}},
+	}
+	www := &ingress.Server{
+		Hostname: "www.example.com",
+		SSLCert:  &ingress.SSLCert{UID: "www", CN: []string{"www.example.com"}},
+	}
+	other := &ingress.Server{
+		Hostname:          "other.com",
+		RedirectFromToWWW: true,
+		SSLCert:           &ingress.SSLCert{UID: "other", CN: []string{"other.com", "www.other.com"}},
+	}
+
+	// the redirect of the apex is disabled by the existing www server, so the
+	// certificate of www.example.com must not be replaced by the apex one
+	if redirects := certificateRedirects([]*ingress.Server{apex, www, other}, []string{"example.com"}); len(redirects) != 0 {
+		t.Errorf("expected no redirect but %v returned", redirects)
+	}
+
+	redirects := certificateRedirects([]*ingress.Server{apex, www, other}, []string{"other.com"})
+	if len(redirects) != 1 || redirects[0].From != "www.other.com" || redirects[0].SSLCert.UID != "other" {
+		t.Errorf("expected the redirect from www.other.com but %v returned", redirects)
+	}
+
+	redirects = certificateRedirects([]*ingress.Server{apex, other}, []string{"example.com"})
+	if len(redirects) != 1 || redirects[0].From != "www.example.com" || redirects[0].SSLCert.UID != "apex" {
+		t.Errorf("expected the redirect from www.example.com but %v returned", redirects)
+	}
+}
+
+func TestAlternateCertificate(t *testing.T) {
+	sslCert := &ingress.SSLCert{UID: "uid-1", PemCertKey: "rsa", AlternatePemCertKey: "ecdsa"}
+	alternate := &ingress.SSLCert{UID: "uid-2", PemCertKey: "ecdsa-annotation"}
+
+	testCases := []struct {
+		name        string
+		sslCert     *ingress.SSLCert
+		alternate   *ingress.SSLCert
+		expectedUID string
+		expectedPem string
+	}{
+		{"without certificate", nil, nil, emptyUID, ""},
+		{"without alternate certificate", &ingress.SSLCert{UID: "uid-1", PemCertKey: "rsa"}, nil, emptyUID, ""},
+		{"alternate certificate in the same Secret", sslCert, nil, "uid-1-alternate", "ecdsa"},
+		{"alternate certificate from the annotation", sslCert, alternate, "uid-2", "ecdsa-annotation"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			uid, pem := alternateCertificate(tc.sslCert, tc.alternate)
+			if uid != tc.expectedUID || pem != tc.expectedPem {
+				t.Errorf("expected %v, %v but returned %v, %v", tc.expectedUID, tc.expectedPem, uid, pem)
+			}
+		})
+	}
+}
+
+func TestBuildAuthCredentials(t *testing.T) {
+	credentials := map[string]string{"foo": "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00="}
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{{
+			Hostname: "myapp.fake",
+			Locations: []*ingress.Location{
+				{Path: "/"},
+				{
+					Path: "/file",
+					BasicDigestAuth: auth.Config{
+						Type:    "basic",
+						Secured: true,
+						File:    "/etc/ingress-controller/auth/default-file-secret.passwd",
+					},
+				},
+				{
+					Path: "/memory",
+					BasicDigestAuth: auth.Config{
+						Type:        "basic",
+						Secured:     true,
+						InMemory:    true,
+						Key:         "default-memory-secret",
+						Credentials: credentials,
+					},
+				},
+			},
+		}},
+	}
+
+	expected := map[string]map[string]string{"default-memory-secret": credentials}
+	if actual := buildAuthCredentials(pcfg); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but returned %v", expected, actual)
+	}
+}
+
+func TestBuildOIDCClients(t *testing.T) {
+	client := authoidc.Client{ID: "dashboard", Secret: "client-secret"}
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{{
+			Hostname: "myapp.fake",
+			Locations: []*ingress.Location{
+				{Path: "/"},
+				{
+					Path: "/dashboard",
+					OIDCAuth: authoidc.Config{
+						Issuer: "https://accounts.example.com",
+						Key:    "default-ing-secret",
+						Client: client,
+					},
+				},
+			},
+		}},
+	}
+
+	expected := map[string]authoidc.Client{"default-ing-secret": client}
+	if actual := buildOIDCClients(pcfg); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but returned %v", expected, actual)
+	}
+}
+
+func TestBuildLuaKV(t *testing.T) {
+	data := map[string]string{"limit": "10"}
+	ing := &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"},
+		},
+	}
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{{
+			Hostname: "myapp.fake",
+			Locations: []*ingress.Location{
+				{Path: "/", Ingress: ing},
+				{
+					Path:    "/api",
+					Ingress: ing,
+					LuaKV:   luakv.Config{ConfigMap: "default/kv", DataSHA: "sha", Data: data},
+				},
+			},
+		}},
+	}
+
+	expected := map[string]map[string]string{"default/app": data}
+	if actual := buildLuaKV(pcfg); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but returned %v", expected, actual)
+	}
+}
+
 func TestNginxHashBucketSize(t *testing.T) {
 	tests := []struct {
 		n        int
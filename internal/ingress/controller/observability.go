@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"regexp"
+
+	networking "k8s.io/api/networking/v1"
+)
+
+// invalidLabelNameChars matches the characters not allowed in the names of
+// the labels of the metrics
+var invalidLabelNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// ObservabilityLabelName returns the name of the label of the request metrics
+// and of the attribute of the tracing spans containing the value of a label of
+// the Ingresses, like app_kubernetes_io_name for app.kubernetes.io/name
+func ObservabilityLabelName(key string) string {
+	return invalidLabelNameChars.ReplaceAllString(key, "_")
+}
+
+// observabilityLabels returns the labels of an Ingress and of its namespace
+// allowed by the flag --observability-labels, indexed by the name returned by
+// ObservabilityLabelName. The labels of the Ingress override the labels of
+// its namespace.
+func (n *NGINXController) observabilityLabels(ing *networking.Ingress) map[string]string {
+	if n.cfg == nil || len(n.cfg.ObservabilityLabels) == 0 {
+		return nil
+	}
+
+	nsLabels := n.store.GetNamespaceLabels(ing.Namespace)
+
+	labels := make(map[string]string)
+	for _, key := range n.cfg.ObservabilityLabels {
+		if value, ok := ing.Labels[key]; ok {
+			labels[ObservabilityLabelName(key)] = value
+			continue
+		}
+
+		if value, ok := nsLabels[key]; ok {
+			labels[ObservabilityLabelName(key)] = value
+		}
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	return labels
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+type fakeNamespaceLabelsStore struct {
+	*fakeIngressStore
+	labels map[string]map[string]string
+}
+
+func (s fakeNamespaceLabelsStore) GetNamespaceLabels(name string) map[string]string {
+	return s.labels[name]
+}
+
+func TestObservabilityLabelName(t *testing.T) {
+	testCases := map[string]string{
+		"team":                   "team",
+		"app.kubernetes.io/name": "app_kubernetes_io_name",
+		"cost-center":            "cost_center",
+	}
+
+	for key, expected := range testCases {
+		if actual := ObservabilityLabelName(key); actual != expected {
+			t.Errorf("expected %v for %v but returned %v", expected, key, actual)
+		}
+	}
+}
+
+func TestIngressMetricsLabels(t *testing.T) {
+	n := &NGINXController{
+		cfg: &Configuration{ObservabilityLabels: []string{"team", "app.kubernetes.io/name", "environment"}},
+		store: fakeNamespaceLabelsStore{
+			fakeIngressStore: &fakeIngressStore{},
+			labels: map[string]map[string]string{
+				"shop": {"team": "platform", "environment": "production"},
+			},
+		},
+	}
+
+	ings := []*ingress.Ingress{
+		{
+			Ingress: networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "shop",
+					Name:      "checkout",
+					Labels:    map[string]string{"team": "payments", "app.kubernetes.io/name": "checkout", "tier": "gold"},
+				},
+			},
+			ParsedAnnotations: &annotations.Ingress{
+				MetricsLabels: map[string]string{"environment": "canary"},
+			},
+		},
+		{
+			Ingress: networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "catalog"},
+			},
+			ParsedAnnotations: &annotations.Ingress{},
+		},
+		{
+			Ingress: networking.Ingress{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "other", Name: "unlabeled"},
+			},
+			ParsedAnnotations: &annotations.Ingress{},
+		},
+	}
+
+	expected := map[string]map[string]string{
+		"shop/checkout": {"team": "payments", "app_kubernetes_io_name": "checkout", "environment": "canary"},
+		"shop/catalog":  {"team": "platform", "environment": "production"},
+	}
+
+	if actual := n.ingressMetricsLabels(ings); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v but returned %v", expected, actual)
+	}
+
+	n.cfg.ObservabilityLabels = nil
+	expected = map[string]map[string]string{
+		"shop/checkout": {"environment": "canary"},
+	}
+
+	if actual := n.ingressMetricsLabels(ings); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v without observability labels but returned %v", expected, actual)
+	}
+}
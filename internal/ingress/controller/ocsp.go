@@ -0,0 +1,164 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/net/ssl/stapling"
+	"k8s.io/ingress-nginx/internal/nginx"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+const (
+	// ocspRefreshPeriod defines the interval between refreshes of the OCSP responses
+	ocspRefreshPeriod = 30 * time.Second
+
+	// ocspResyncPeriod defines the interval between configurations of all the
+	// OCSP responses in NGINX, which loses them when it is restarted
+	ocspResyncPeriod = 10 * time.Minute
+
+	// ocspRequestTimeout defines the timeout of the requests to the OCSP responders
+	ocspRequestTimeout = 10 * time.Second
+)
+
+// ocspResponse is an OCSP response configured in NGINX
+type ocspResponse struct {
+	// Response is the OCSP response in DER format, encoded in base64 in JSON
+	Response []byte `json:"response"`
+	// Expiry is the number of seconds before the response expires
+	Expiry int64 `json:"expiry"`
+}
+
+// ocspCertificates returns the certificates of the servers and custom domains
+// of the configuration
+func ocspCertificates(pcfg *ingress.Configuration) []stapling.Certificate {
+	certificates := []stapling.Certificate{}
+	add := func(sslCert *ingress.SSLCert) {
+		if sslCert == nil || sslCert.PemCertKey == "" {
+			return
+		}
+
+		certificates = append(certificates, stapling.Certificate{
+			UID:    sslCert.UID,
+			Secret: fmt.Sprintf("%v/%v", sslCert.Namespace, sslCert.Name),
+			PEM:    sslCert.PemCertKey,
+		})
+	}
+
+	for _, server := range pcfg.Servers {
+		add(server.SSLCert)
+	}
+
+	for _, domain := range pcfg.CustomDomains {
+		add(domain.SSLCert)
+	}
+
+	return certificates
+}
+
+// buildOCSPResponses returns the OCSP responses to configure in NGINX, by UID
+// of certificate
+func buildOCSPResponses(responses map[string]*stapling.Response, now time.Time) map[string]ocspResponse {
+	configuration := make(map[string]ocspResponse, len(responses))
+	for uid, response := range responses {
+		configuration[uid] = ocspResponse{
+			Response: response.Raw,
+			Expiry:   max(int64(response.Expiry().Sub(now).Seconds()), 0),
+		}
+	}
+
+	return configuration
+}
+
+// configureOCSPResponses configures the OCSP responses stapled by NGINX
+func configureOCSPResponses(responses map[string]ocspResponse) error {
+	if len(responses) == 0 {
+		return nil
+	}
+
+	configuration := &sslConfiguration{
+		Certificates:  map[string]string{},
+		Servers:       map[string]string{},
+		OCSPResponses: responses,
+	}
+
+	statusCode, _, err := nginx.NewPostStatusRequest("/configuration/servers", "application/json", configuration)
+	if err != nil {
+		return err
+	}
+
+	if statusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected error code: %d", statusCode)
+	}
+
+	return nil
+}
+
+// refreshOCSPResponses fetches the OCSP responses of the certificates of the
+// running configuration, configures the new ones in NGINX and updates the
+// OCSP metrics. All the responses are configured every ocspResyncPeriod and
+// after an error.
+func (n *NGINXController) refreshOCSPResponses() {
+	cfg := n.store.GetBackendConfiguration()
+	if n.cfg.Offline || !cfg.EnableOCSP || !cfg.OCSPPrefetch {
+		return
+	}
+
+	certificates := ocspCertificates(n.getRunningConfig())
+	secrets := make(map[string]string, len(certificates))
+	for _, certificate := range certificates {
+		secrets[certificate.UID] = certificate.Secret
+	}
+
+	fetched, errs := n.ocspStapler.Refresh(certificates)
+	for uid, err := range errs {
+		reason := stapling.ReasonRequest
+		var stapleErr *stapling.Error
+		if errors.As(err, &stapleErr) {
+			reason = stapleErr.Reason
+		}
+
+		klog.Warningf("Error fetching the OCSP response of the certificate of the Secret %v: %v", secrets[uid], err)
+		n.metricCollector.IncOCSPFetchErrorCount(secrets[uid], reason)
+	}
+
+	now := time.Now()
+	responses := fetched
+	resync := now.Sub(n.ocspLastSync) >= ocspResyncPeriod
+	if resync {
+		responses = n.ocspStapler.Responses()
+	}
+
+	if err := configureOCSPResponses(buildOCSPResponses(responses, now)); err != nil {
+		klog.Warningf("Error configuring the OCSP responses: %v", err)
+		n.ocspLastSync = time.Time{}
+	} else if resync {
+		n.ocspLastSync = now
+	}
+
+	ages := map[string]float64{}
+	for uid, response := range n.ocspStapler.Responses() {
+		ages[secrets[uid]] = now.Sub(response.ThisUpdate).Seconds()
+	}
+	n.metricCollector.SetOCSPResponseAge(ages)
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/net/ssl/stapling"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestOCSPCertificates(t *testing.T) {
+	sslCert := &ingress.SSLCert{Namespace: "default", Name: "example", UID: "uid-1", PemCertKey: "pem-1"}
+
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{Hostname: "_"},
+			{Hostname: "example.com", SSLCert: sslCert},
+			{Hostname: "no-pem.example.com", SSLCert: &ingress.SSLCert{UID: "uid-2"}},
+		},
+		CustomDomains: []ingress.CustomDomain{
+			{Hostname: "custom.example.org", Server: "example.com", SSLCert: &ingress.SSLCert{Namespace: "custom", Name: "tls", UID: "uid-3", PemCertKey: "pem-3"}},
+			{Hostname: "pending.example.org", Server: "example.com"},
+		},
+	}
+
+	expected := []stapling.Certificate{
+		{UID: "uid-1", Secret: "default/example", PEM: "pem-1"},
+		{UID: "uid-3", Secret: "custom/tls", PEM: "pem-3"},
+	}
+	if certificates := ocspCertificates(pcfg); !reflect.DeepEqual(certificates, expected) {
+		t.Errorf("expected %v but returned %v", expected, certificates)
+	}
+}
+
+func TestBuildOCSPResponses(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	responses := map[string]*stapling.Response{
+		"valid":   {Raw: []byte("valid"), ThisUpdate: now.Add(-time.Hour), NextUpdate: now.Add(time.Hour)},
+		"expired": {Raw: []byte("expired"), ThisUpdate: now.Add(-2 * time.Hour), NextUpdate: now.Add(-time.Hour)},
+	}
+
+	expected := map[string]ocspResponse{
+		"valid":   {Response: []byte("valid"), Expiry: 3600},
+		"expired": {Response: []byte("expired"), Expiry: 0},
+	}
+	if configuration := buildOCSPResponses(responses, now); !reflect.DeepEqual(configuration, expected) {
+		t.Errorf("expected %v but returned %v", expected, configuration)
+	}
+}
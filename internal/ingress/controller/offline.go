@@ -0,0 +1,62 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// offlineOptions returns the options of the configuration ConfigMap enabling
+// outbound calls, disabled when the controller runs with --offline
+func offlineOptions(cfg *ngx_config.Configuration) []string {
+	options := []string{}
+	if cfg.EnableOCSP {
+		// the responses are fetched from the OCSP responders of the certificates
+		options = append(options, "enable-ocsp")
+	}
+
+	return options
+}
+
+// warnOfflineOptions emits a Warning Event when the configuration enables
+// options disabled by --offline, once until the options change
+func (n *NGINXController) warnOfflineOptions(cfg *ngx_config.Configuration) {
+	if !n.cfg.Offline {
+		return
+	}
+
+	options := strings.Join(offlineOptions(cfg), ", ")
+	if options == n.offlineWarnedOptions {
+		return
+	}
+
+	n.offlineWarnedOptions = options
+	if options == "" {
+		return
+	}
+
+	msg := fmt.Sprintf("The options %v of the configuration make outbound calls and are disabled by --offline", options)
+	klog.Warning(msg)
+	n.recorder.Event(k8s.IngressPodDetails, apiv1.EventTypeWarning, "Offline", msg)
+}
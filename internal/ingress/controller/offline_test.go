@@ -0,0 +1,64 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/tools/record"
+
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+)
+
+func TestOfflineOptions(t *testing.T) {
+	cfg := ngx_config.NewDefault()
+	if options := offlineOptions(&cfg); len(options) != 0 {
+		t.Errorf("expected no option but returned %v", options)
+	}
+
+	cfg.EnableOCSP = true
+	if options, expected := offlineOptions(&cfg), []string{"enable-ocsp"}; !reflect.DeepEqual(options, expected) {
+		t.Errorf("expected %v but returned %v", expected, options)
+	}
+}
+
+func TestWarnOfflineOptions(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	n := &NGINXController{
+		cfg:      &Configuration{Offline: true},
+		recorder: recorder,
+	}
+
+	cfg := ngx_config.NewDefault()
+	cfg.EnableOCSP = true
+
+	n.warnOfflineOptions(&cfg)
+	n.warnOfflineOptions(&cfg)
+	if len(recorder.Events) != 1 {
+		t.Fatalf("expected one event but %v were emitted", len(recorder.Events))
+	}
+	<-recorder.Events
+
+	cfg.EnableOCSP = false
+	n.warnOfflineOptions(&cfg)
+	cfg.EnableOCSP = true
+	n.warnOfflineOptions(&cfg)
+	if len(recorder.Events) != 1 {
+		t.Errorf("expected a new event after the option was enabled again but %v were emitted", len(recorder.Events))
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"syscall"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/nginx"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+	"k8s.io/ingress-nginx/pkg/util/file"
+)
+
+// minSharedBlockSize is the minimal size of the runs of directives moved to
+// a shared file, smaller runs are cheaper to repeat than to include
+const minSharedBlockSize = 256
+
+// optimizeConfiguration moves the runs of directives repeated in the
+// locations of an NGINX configuration to shared files written in dir, and
+// returns the optimized configuration with the content of the shared files.
+// The shared files are named after their content and are written before the
+// configuration is tested, the files of the previous configuration are kept
+// until the new one is reloaded.
+func optimizeConfiguration(content []byte, dir string) ([]byte, map[string][]byte, error) {
+	optimization, err := nginx.Optimize(content, dir, minSharedBlockSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeSharedBlocks(dir, optimization.SharedBlocks); err != nil {
+		return nil, nil, err
+	}
+
+	return optimization.Config, optimization.SharedBlocks, nil
+}
+
+// splitConfiguration moves each server of an NGINX configuration to its own
+// file written in dir, and returns the configuration including the files
+// with the content of the files. Like the shared files, the files of the
+// servers are named after their content, only the files of the changed
+// servers are written.
+func splitConfiguration(content []byte, dir string) ([]byte, map[string][]byte, error) {
+	split, err := nginx.SplitServers(content, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := writeSharedBlocks(dir, split.SharedBlocks); err != nil {
+		return nil, nil, err
+	}
+
+	return split.Config, split.SharedBlocks, nil
+}
+
+// writeSharedBlocks writes the files of blocks missing in dir
+func writeSharedBlocks(dir string, blocks map[string][]byte) error {
+	if len(blocks) == 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, file.ReadWriteByUser); err != nil {
+		return err
+	}
+
+	for name, block := range blocks {
+		fileName := filepath.Join(dir, name)
+		if _, err := os.Stat(fileName); err == nil {
+			continue
+		}
+
+		if err := os.WriteFile(fileName, block, file.ReadWriteByUser); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeUnusedSharedBlocks removes the shared files of dir not included by
+// the configuration anymore
+func removeUnusedSharedBlocks(dir string, blocks map[string][]byte) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	errs := []error{}
+	for _, entry := range entries {
+		if _, ok := blocks[entry.Name()]; ok || entry.IsDir() {
+			continue
+		}
+
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// serverChange is a server of the NGINX configuration changed between two
+// split configurations
+type serverChange struct {
+	server string
+	// previous and current are the files of the server in the previous and
+	// the current configuration, empty when the server is added or removed
+	previous string
+	current  string
+}
+
+// changedServers returns the servers changed between two split
+// configurations, sorted by name
+func changedServers(previous, current map[string][]byte) []serverChange {
+	changes := map[string]*serverChange{}
+	change := func(name string) *serverChange {
+		server := nginx.ServerFileName(name)
+		if changes[server] == nil {
+			changes[server] = &serverChange{server: server}
+		}
+		return changes[server]
+	}
+
+	for name := range previous {
+		if _, ok := current[name]; !ok {
+			change(name).previous = name
+		}
+	}
+	for name := range current {
+		if _, ok := previous[name]; !ok {
+			change(name).current = name
+		}
+	}
+
+	servers := []serverChange{}
+	for _, c := range changes {
+		servers = append(servers, *c)
+	}
+	sort.Slice(servers, func(i, j int) bool {
+		return servers[i].server < servers[j].server
+	})
+	return servers
+}
+
+// diffFiles returns the unified diff of two files of the NGINX
+// configuration
+func diffFiles(previous, current string) string {
+	//nolint:gosec //Ignore G204 error
+	diffOutput, err := exec.Command("diff", "-I", "'# Configuration.*'", "-u", previous, current).CombinedOutput()
+	if err != nil {
+		if exitError, ok := err.(*exec.ExitError); ok {
+			ws, ok := exitError.Sys().(syscall.WaitStatus)
+			if !ok {
+				klog.Errorf("unexpected type: %T", exitError.Sys())
+			}
+			if ws.ExitStatus() == 2 {
+				klog.Warningf("Failed to executing diff command: %v", err)
+			}
+		}
+	}
+
+	return string(diffOutput)
+}
+
+// sharedBlocksSize returns the size of the content of the shared files
+func sharedBlocksSize(blocks map[string][]byte) int {
+	size := 0
+	for _, block := range blocks {
+		size += len(block)
+	}
+	return size
+}
+
+// configurationObjects returns the number of servers and locations of a
+// configuration
+func configurationObjects(cfg *ingress.Configuration) (servers, locations int) {
+	for _, server := range cfg.Servers {
+		locations += len(server.Locations)
+	}
+	return len(cfg.Servers), locations
+}
+
+// serverBlocksDir returns the directory of the servers moved to their own
+// file
+func (n *NGINXController) serverBlocksDir() string {
+	if n.cfg.SplitConfiguration {
+		return splitServersPath
+	}
+	return serverBlocksPath
+}
+
+// exceedsConfigSizeBudget returns true when the size of the configuration
+// exceeds the size budget, and emits a warning Event when the size crosses
+// the budget
+func (n *NGINXController) exceedsConfigSizeBudget(size int) bool {
+	if n.cfg.ConfigSizeBudget <= 0 || size <= n.cfg.ConfigSizeBudget {
+		n.configSizeWarningEmitted = false
+		return false
+	}
+
+	if n.configSizeWarningEmitted {
+		return true
+	}
+
+	n.configSizeWarningEmitted = true
+	msg := fmt.Sprintf("The NGINX configuration is %v bytes, exceeding the budget of %v bytes (config-size-budget). The servers are moved to their own file, the reloads could time out", size, n.cfg.ConfigSizeBudget)
+	klog.Warning(msg)
+	n.recorder.Event(k8s.IngressPodDetails, apiv1.EventTypeWarning, "ConfigurationSize", msg)
+
+	return true
+}
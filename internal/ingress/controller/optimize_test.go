@@ -0,0 +1,211 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestOptimizeConfiguration(t *testing.T) {
+	proxySettings := strings.Repeat("proxy_set_header X-Forwarded-Host $host;\n", 8)
+	content := []byte(`http {
+	server {
+		server_name example.com;
+		location /a {
+			` + proxySettings + `
+			return 200;
+		}
+		location /b {
+			` + proxySettings + `
+			return 200;
+		}
+	}
+}
+`)
+
+	dir := filepath.Join(t.TempDir(), "shared")
+	optimized, blocks, err := optimizeConfiguration(content, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("expected one shared block but returned %v", len(blocks))
+	}
+	if len(optimized) >= len(content) {
+		t.Errorf("expected an optimized configuration smaller than %v bytes but returned %v bytes", len(content), len(optimized))
+	}
+
+	for name, block := range blocks {
+		if !strings.Contains(string(optimized), "include "+filepath.Join(dir, name)+";") {
+			t.Errorf("expected the configuration to include the shared block %v", name)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unexpected error reading the shared block: %v", err)
+		}
+		if string(data) != string(block) {
+			t.Errorf("expected the shared block %q but the file contains %q", block, data)
+		}
+		if size := sharedBlocksSize(blocks); size != len(block) {
+			t.Errorf("expected a size of %v but returned %v", len(block), size)
+		}
+	}
+}
+
+func TestRemoveUnusedSharedBlocks(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"used.conf", "unused.conf"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("return 200;"), 0o600); err != nil {
+			t.Fatalf("unexpected error writing a shared block: %v", err)
+		}
+	}
+
+	if err := removeUnusedSharedBlocks(dir, map[string][]byte{"used.conf": nil}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("unexpected error reading the directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "used.conf" {
+		t.Errorf("expected only the used shared block but the directory contains %v", entries)
+	}
+
+	if err := removeUnusedSharedBlocks(filepath.Join(dir, "missing"), nil); err != nil {
+		t.Errorf("unexpected error with a missing directory: %v", err)
+	}
+}
+
+func TestSplitConfiguration(t *testing.T) {
+	content := []byte(`http {
+	server {
+		server_name foo.com;
+	}
+	server {
+		server_name bar.com;
+	}
+}
+`)
+
+	dir := filepath.Join(t.TempDir(), "servers")
+	split, blocks, err := splitConfiguration(content, dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("expected two server files but returned %v", len(blocks))
+	}
+	if strings.Contains(string(split), "server_name") {
+		t.Errorf("expected the servers to be moved to their own file but the configuration is %s", split)
+	}
+
+	for name, block := range blocks {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("unexpected error reading the server file: %v", err)
+		}
+		if string(data) != string(block) {
+			t.Errorf("expected the server file %q but the file contains %q", block, data)
+		}
+	}
+}
+
+func TestChangedServers(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "conf.d")
+	_, previous, err := splitConfiguration([]byte(`http {
+	server {
+		server_name foo.com;
+		listen 80;
+	}
+	server {
+		server_name bar.com;
+	}
+	server {
+		server_name baz.com;
+	}
+}
+`), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, current, err := splitConfiguration([]byte(`http {
+	server {
+		server_name foo.com;
+		listen 8080;
+	}
+	server {
+		server_name bar.com;
+	}
+	server {
+		server_name qux.com;
+	}
+}
+`), dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	changes := changedServers(previous, current)
+	servers := []string{}
+	for _, change := range changes {
+		servers = append(servers, change.server)
+	}
+	if expected := []string{"baz.com", "foo.com", "qux.com"}; !reflect.DeepEqual(servers, expected) {
+		t.Fatalf("expected the changed servers %v but returned %v", expected, servers)
+	}
+
+	if changes[0].previous == "" || changes[0].current != "" {
+		t.Errorf("expected the server baz.com to be removed but returned %+v", changes[0])
+	}
+	if changes[1].previous == "" || changes[1].current == "" || changes[1].previous == changes[1].current {
+		t.Errorf("expected the file of the server foo.com to change but returned %+v", changes[1])
+	}
+	if changes[2].previous != "" || changes[2].current == "" {
+		t.Errorf("expected the server qux.com to be added but returned %+v", changes[2])
+	}
+
+	if diff := diffFiles(filepath.Join(dir, changes[1].previous), filepath.Join(dir, changes[1].current)); !strings.Contains(diff, "+\t\tlisten 8080;") {
+		t.Errorf("expected the diff of the server foo.com to contain the new port but returned %v", diff)
+	}
+
+	if changes := changedServers(current, current); len(changes) != 0 {
+		t.Errorf("expected no changed servers but returned %+v", changes)
+	}
+}
+
+func TestConfigurationObjects(t *testing.T) {
+	cfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{Hostname: "_", Locations: []*ingress.Location{{Path: "/"}}},
+			{Hostname: "foo.com", Locations: []*ingress.Location{{Path: "/"}, {Path: "/foo"}}},
+		},
+	}
+
+	servers, locations := configurationObjects(cfg)
+	if servers != 2 || locations != 3 {
+		t.Errorf("expected 2 servers and 3 locations but returned %v and %v", servers, locations)
+	}
+}
@@ -17,27 +17,75 @@ limitations under the License.
 package process
 
 import (
+	"errors"
+	"fmt"
 	"os/exec"
 	"syscall"
+)
 
-	klog "k8s.io/klog/v2"
+const (
+	// ExitReasonExited is the reason of the exits of the NGINX master process with an exit code
+	ExitReasonExited = "exited"
+	// ExitReasonSignaled is the reason of the exits of the NGINX master process killed by a signal
+	ExitReasonSignaled = "signaled"
+	// ExitReasonCoreDumped is the reason of the exits of the NGINX master process killed by a signal with a core dump
+	ExitReasonCoreDumped = "core_dumped"
 )
 
-// IsRespawnIfRequired checks if error type is exec.ExitError or not
-func IsRespawnIfRequired(err error) bool {
-	exitError, ok := err.(*exec.ExitError)
-	if !ok {
-		return false
+// Exit describes the termination of the NGINX master process
+type Exit struct {
+	// ExitCode is the exit code of the process, -1 when killed by a signal
+	ExitCode int
+	// Signal is the signal that killed the process, zero when the process exited
+	Signal syscall.Signal
+	// CoreDumped indicates the process produced a core dump
+	CoreDumped bool
+}
+
+// ParseExit returns the termination of the NGINX master process from the
+// error returned waiting for it. It returns false when err is not the
+// termination of the process, like an error starting it.
+func ParseExit(err error) (Exit, bool) {
+	var exitError *exec.ExitError
+	if !errors.As(err, &exitError) {
+		return Exit{}, false
 	}
 
 	waitStatus, ok := exitError.Sys().(syscall.WaitStatus)
 	if !ok {
-		return false
+		return Exit{}, false
+	}
+
+	exit := Exit{
+		ExitCode: waitStatus.ExitStatus(),
+	}
+	if waitStatus.Signaled() {
+		exit.Signal = waitStatus.Signal()
+		exit.CoreDumped = waitStatus.CoreDump()
+	}
+
+	return exit, true
+}
+
+// Reason returns the reason of the termination, used as label of the metrics
+func (e Exit) Reason() string {
+	switch {
+	case e.CoreDumped:
+		return ExitReasonCoreDumped
+	case e.Signal != 0:
+		return ExitReasonSignaled
+	default:
+		return ExitReasonExited
+	}
+}
+
+func (e Exit) String() string {
+	switch {
+	case e.CoreDumped:
+		return fmt.Sprintf("killed by signal %v (core dumped)", e.Signal)
+	case e.Signal != 0:
+		return fmt.Sprintf("killed by signal %v", e.Signal)
+	default:
+		return fmt.Sprintf("exited with code %v", e.ExitCode)
 	}
-	klog.Warningf(`
--------------------------------------------------------------------------------
-NGINX master process died (%v): %v
--------------------------------------------------------------------------------
-`, waitStatus.ExitStatus(), err)
-	return true
 }
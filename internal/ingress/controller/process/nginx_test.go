@@ -18,27 +18,45 @@ package process
 
 import (
 	"fmt"
-	"os"
 	"os/exec"
+	"syscall"
 	"testing"
 )
 
-func TestIsRespawnIfRequired(t *testing.T) {
+func TestParseExit(t *testing.T) {
 	cases := []struct {
-		err   error
-		isReq bool
+		err      error
+		isExit   bool
+		expected Exit
+		reason   string
 	}{
-		{nil, false},
-		{fmt.Errorf("dummy"), false},
-		{&exec.ExitError{
-			ProcessState: &os.ProcessState{},
-		}, true},
+		{nil, false, Exit{}, ""},
+		{fmt.Errorf("dummy"), false, Exit{}, ""},
+		{exec.Command("sh", "-c", "exit 3").Run(), true, Exit{ExitCode: 3}, ExitReasonExited},
+		{exec.Command("sh", "-c", "kill -TERM $$").Run(), true, Exit{ExitCode: -1, Signal: syscall.SIGTERM}, ExitReasonSignaled},
+		{fmt.Errorf("wrapped: %w", exec.Command("sh", "-c", "exit 1").Run()), true, Exit{ExitCode: 1}, ExitReasonExited},
 	}
 
 	for _, tc := range cases {
-		isReq := IsRespawnIfRequired(tc.err)
-		if tc.isReq != isReq {
-			t.Error("expected an error but none returned")
+		exit, isExit := ParseExit(tc.err)
+		if isExit != tc.isExit {
+			t.Errorf("expected %v to be an exit: %v", tc.err, tc.isExit)
+			continue
 		}
+		if !isExit {
+			continue
+		}
+
+		if exit != tc.expected {
+			t.Errorf("expected %+v but returned %+v", tc.expected, exit)
+		}
+		if exit.Reason() != tc.reason {
+			t.Errorf("expected reason %v but returned %v", tc.reason, exit.Reason())
+		}
+	}
+
+	coreDump := Exit{ExitCode: -1, Signal: syscall.SIGSEGV, CoreDumped: true}
+	if coreDump.Reason() != ExitReasonCoreDumped {
+		t.Errorf("expected reason %v but returned %v", ExitReasonCoreDumped, coreDump.Reason())
 	}
 }
@@ -0,0 +1,38 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"syscall"
+)
+
+// prSetChildSubreaper is the prctl option PR_SET_CHILD_SUBREAPER
+const prSetChildSubreaper = 36
+
+// SetChildSubreaper makes the processes orphaned in the descendants of the
+// current process its children, like the NGINX master process started by a
+// binary upgrade once the previous master process exits, to be waited for.
+func SetChildSubreaper() error {
+	_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSetChildSubreaper(t *testing.T) {
+	if err := SetChildSubreaper(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// the shell exits leaving its child orphaned, like a master process
+	// exiting after a binary upgrade
+	out, err := exec.Command("/bin/sh", "-c", "sleep 0.2 & echo $!").Output()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	state, err := p.Wait()
+	if err != nil {
+		t.Fatalf("expected the orphaned process to be a child but got %v", err)
+	}
+	if !state.Success() {
+		t.Errorf("expected the orphaned process to succeed but got %v", state)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"errors"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultInitialBackoff is the delay before the first respawn of the NGINX master process
+	defaultInitialBackoff = time.Second
+	// defaultMaxBackoff is the maximum delay between respawns
+	defaultMaxBackoff = time.Minute
+	// defaultStablePeriod is the time the process must run to reset the delay between respawns
+	defaultStablePeriod = 10 * time.Minute
+	// defaultCrashLoopExits is the number of exits in defaultCrashLoopWindow considered a crash loop
+	defaultCrashLoopExits = 5
+	// defaultCrashLoopWindow is the period where the exits are counted to detect a crash loop
+	defaultCrashLoopWindow = 5 * time.Minute
+)
+
+// Supervisor tracks the exits of the NGINX master process to decide when
+// it is respawned. The delay between respawns grows exponentially until the
+// process runs for a stable period, and the respawns stop when the process
+// exits too often, to let the liveness probe restart the pod.
+type Supervisor struct {
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+	StablePeriod    time.Duration
+	CrashLoopExits  int
+	CrashLoopWindow time.Duration
+
+	backoff   time.Duration
+	startedAt time.Time
+	exits     []time.Time
+}
+
+// NewSupervisor returns a Supervisor using the default backoff and crash
+// loop detection
+func NewSupervisor() *Supervisor {
+	return &Supervisor{
+		InitialBackoff:  defaultInitialBackoff,
+		MaxBackoff:      defaultMaxBackoff,
+		StablePeriod:    defaultStablePeriod,
+		CrashLoopExits:  defaultCrashLoopExits,
+		CrashLoopWindow: defaultCrashLoopWindow,
+	}
+}
+
+// Started records the start of the NGINX master process
+func (s *Supervisor) Started(now time.Time) {
+	s.startedAt = now
+}
+
+// Exited records an exit of the NGINX master process and returns the delay
+// before the next respawn, or false when the process is in a crash loop
+// and must not be respawned.
+func (s *Supervisor) Exited(now time.Time) (time.Duration, bool) {
+	if !s.startedAt.IsZero() && now.Sub(s.startedAt) >= s.StablePeriod {
+		s.backoff = 0
+	}
+
+	exits := s.exits[:0]
+	for _, exit := range s.exits {
+		if now.Sub(exit) < s.CrashLoopWindow {
+			exits = append(exits, exit)
+		}
+	}
+	s.exits = append(exits, now)
+
+	if len(s.exits) >= s.CrashLoopExits {
+		return 0, false
+	}
+
+	if s.backoff == 0 {
+		s.backoff = s.InitialBackoff
+	} else {
+		s.backoff *= 2
+	}
+	if s.backoff > s.MaxBackoff {
+		s.backoff = s.MaxBackoff
+	}
+
+	return s.backoff, true
+}
+
+// TerminateGroup gracefully stops the processes of the process group pgid,
+// like the workers left running after the exit of the NGINX master process
+func TerminateGroup(pgid int) error {
+	if pgid <= 0 {
+		return nil
+	}
+
+	err := syscall.Kill(-pgid, syscall.SIGQUIT)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSupervisor(t *testing.T) {
+	s := NewSupervisor()
+	now := time.Now()
+
+	// the delay doubles with each exit, up to the maximum
+	s.MaxBackoff = 3 * time.Second
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}
+	for _, e := range expected {
+		s.Started(now)
+		now = now.Add(time.Minute)
+		delay, respawn := s.Exited(now)
+		if !respawn || delay != e {
+			t.Errorf("expected a respawn after %v but returned %v (respawn %v)", e, delay, respawn)
+		}
+	}
+
+	// the delay is reset when the process runs for the stable period
+	s.Started(now)
+	now = now.Add(s.StablePeriod)
+	delay, respawn := s.Exited(now)
+	if !respawn || delay != time.Second {
+		t.Errorf("expected a respawn after %v but returned %v (respawn %v)", time.Second, delay, respawn)
+	}
+
+	// the exits out of the window are not counted
+	for i := 1; i < s.CrashLoopExits-1; i++ {
+		s.Started(now)
+		now = now.Add(time.Second)
+		if _, respawn := s.Exited(now); !respawn {
+			t.Fatalf("unexpected crash loop after %v exits", i+1)
+		}
+	}
+
+	s.Started(now)
+	if _, respawn := s.Exited(now.Add(time.Second)); respawn {
+		t.Errorf("expected a crash loop after %v exits in %v", s.CrashLoopExits, s.CrashLoopWindow)
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// upgradePollPeriod is the interval between the reads of the PID file
+// waiting for the new NGINX master process
+const upgradePollPeriod = 100 * time.Millisecond
+
+// ReadPID returns the PID of the NGINX master process written in a PID file
+func ReadPID(pidFile string) (int, error) {
+	f, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(f)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid PID in file %v: %w", pidFile, err)
+	}
+	return pid, nil
+}
+
+// UpgradeBinary starts a new NGINX master process with the binary upgrade
+// of the master process of the PID file, signaled with SIGUSR2. The new
+// master process inherits the listening sockets and loads the configuration
+// while the previous master process and its workers keep running. It returns
+// the PIDs of the previous and of the new master process, once the new master
+// process has written the PID file.
+func UpgradeBinary(ctx context.Context, pidFile string) (oldPID, newPID int, err error) {
+	oldPID, err = ReadPID(pidFile)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if err := syscall.Kill(oldPID, syscall.SIGUSR2); err != nil {
+		return 0, 0, fmt.Errorf("signaling the NGINX master process %v: %w", oldPID, err)
+	}
+
+	ticker := time.NewTicker(upgradePollPeriod)
+	defer ticker.Stop()
+
+	for {
+		// the previous master process renames the PID file before starting
+		// the new one, which writes the PID file once its configuration is loaded
+		newPID, err = ReadPID(pidFile)
+		if err == nil && newPID != oldPID {
+			return oldPID, newPID, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return 0, 0, fmt.Errorf("the new NGINX master process did not start: %w", ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// QuitMaster gracefully stops an NGINX master process and its workers, like
+// the previous master process of a binary upgrade
+func QuitMaster(pid int) error {
+	return syscall.Kill(pid, syscall.SIGQUIT)
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestReadPID(t *testing.T) {
+	dir := t.TempDir()
+
+	pidFile := filepath.Join(dir, "nginx.pid")
+	if err := os.WriteFile(pidFile, []byte("1234\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	pid, err := ReadPID(pidFile)
+	if err != nil || pid != 1234 {
+		t.Errorf("expected PID 1234 but got %v (%v)", pid, err)
+	}
+
+	if err := os.WriteFile(pidFile, []byte("nginx"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadPID(pidFile); err == nil {
+		t.Errorf("expected an error reading an invalid PID")
+	}
+
+	if _, err := ReadPID(filepath.Join(dir, "missing.pid")); err == nil {
+		t.Errorf("expected an error reading a missing PID file")
+	}
+}
+
+// startMaster starts a process writing the PID of a new process to the PID
+// file when it receives SIGUSR2, like an NGINX master process
+func startMaster(t *testing.T, pidFile string, newPID int) *exec.Cmd {
+	t.Helper()
+
+	script := "trap 'echo " + strconv.Itoa(newPID) + " > " + pidFile + "' USR2; trap 'exit 0' QUIT; " +
+		"echo $$ > " + pidFile + "; while true; do sleep 0.05; done"
+	cmd := exec.Command("/bin/sh", "-c", script)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	for i := 0; i < 100; i++ {
+		if pid, err := ReadPID(pidFile); err == nil && pid == cmd.Process.Pid {
+			return cmd
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the master process did not write the PID file")
+	return nil
+}
+
+func TestUpgradeBinary(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "nginx.pid")
+	cmd := startMaster(t, pidFile, 4321)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	oldPID, newPID, err := UpgradeBinary(ctx, pidFile)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if oldPID != cmd.Process.Pid || newPID != 4321 {
+		t.Errorf("expected PIDs %v and 4321 but got %v and %v", cmd.Process.Pid, oldPID, newPID)
+	}
+
+	if err := QuitMaster(oldPID); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cmd.Wait(); err != nil {
+		t.Errorf("expected the master process to exit but got %v", err)
+	}
+}
+
+func TestUpgradeBinaryTimeout(t *testing.T) {
+	pidFile := filepath.Join(t.TempDir(), "nginx.pid")
+	cmd := exec.Command("/bin/sh", "-c", "trap '' USR2; sleep 5")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = cmd.Process.Signal(syscall.SIGKILL)
+		_ = cmd.Wait()
+	}()
+	if err := os.WriteFile(pidFile, []byte(strconv.Itoa(cmd.Process.Pid)), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	if _, _, err := UpgradeBinary(ctx, pidFile); err == nil {
+		t.Errorf("expected an error when the new master process does not start")
+	}
+}
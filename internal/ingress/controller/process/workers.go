@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// the reasons of the kills of the workers shutting down
+const (
+	KilledByAge   = "age"
+	KilledByCount = "count"
+)
+
+// OldWorker is a NGINX worker process of a previous configuration shutting
+// down after a reload
+type OldWorker struct {
+	PID int
+	// Since is the time the worker was first seen shutting down
+	Since time.Time
+}
+
+// WorkerCheck is the result of a check of the workers shutting down
+type WorkerCheck struct {
+	// Running are the workers left running, the oldest first
+	Running []OldWorker
+	// Killed is the number of workers killed, by reason
+	Killed map[string]int
+}
+
+// WorkerWatchdog tracks the NGINX worker processes shutting down after a
+// reload, which keep their memory until their last connection is closed.
+// It kills the workers shutting down for longer than MaxAge, then the
+// oldest workers when more than MaxCount are shutting down.
+type WorkerWatchdog struct {
+	// MaxAge is the time a worker can take to shut down, unlimited when zero
+	MaxAge time.Duration
+	// MaxCount is the number of workers that can shut down at the same
+	// time, unlimited when zero
+	MaxCount int
+
+	since map[int]time.Time
+	kill  func(pid int) error
+}
+
+// NewWorkerWatchdog returns a WorkerWatchdog without limits
+func NewWorkerWatchdog() *WorkerWatchdog {
+	return &WorkerWatchdog{
+		since: map[int]time.Time{},
+		kill:  killWorker,
+	}
+}
+
+// Check records the PIDs of the workers shutting down seen at now, forgets
+// the workers that exited, and kills the workers exceeding the limits. The
+// workers that cannot be killed are left running and reported in the error.
+func (w *WorkerWatchdog) Check(pids []int, now time.Time) (WorkerCheck, error) {
+	seen := make(map[int]bool, len(pids))
+	workers := make([]OldWorker, 0, len(pids))
+	for _, pid := range pids {
+		seen[pid] = true
+
+		since, ok := w.since[pid]
+		if !ok {
+			since = now
+			w.since[pid] = now
+		}
+		workers = append(workers, OldWorker{PID: pid, Since: since})
+	}
+
+	for pid := range w.since {
+		if !seen[pid] {
+			delete(w.since, pid)
+		}
+	}
+
+	sort.Slice(workers, func(i, j int) bool {
+		if workers[i].Since.Equal(workers[j].Since) {
+			return workers[i].PID < workers[j].PID
+		}
+		return workers[i].Since.Before(workers[j].Since)
+	})
+
+	check := WorkerCheck{Killed: map[string]int{}}
+	var errs []error
+	terminate := func(worker OldWorker, reason string) bool {
+		if err := w.kill(worker.PID); err != nil {
+			errs = append(errs, fmt.Errorf("killing NGINX worker %v: %w", worker.PID, err))
+			return false
+		}
+		delete(w.since, worker.PID)
+		check.Killed[reason]++
+		return true
+	}
+
+	running := make([]OldWorker, 0, len(workers))
+	for _, worker := range workers {
+		if w.MaxAge > 0 && now.Sub(worker.Since) > w.MaxAge && terminate(worker, KilledByAge) {
+			continue
+		}
+		running = append(running, worker)
+	}
+
+	if excess := len(running) - w.MaxCount; w.MaxCount > 0 && excess > 0 {
+		kept := make([]OldWorker, 0, w.MaxCount)
+		for i, worker := range running {
+			if i < excess && terminate(worker, KilledByCount) {
+				continue
+			}
+			kept = append(kept, worker)
+		}
+		running = kept
+	}
+
+	check.Running = running
+	return check, errors.Join(errs...)
+}
+
+// killWorker kills a worker without waiting for its connections to close
+func killWorker(pid int) error {
+	err := syscall.Kill(pid, syscall.SIGKILL)
+	if errors.Is(err, syscall.ESRCH) {
+		return nil
+	}
+
+	return err
+}
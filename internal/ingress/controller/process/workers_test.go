@@ -0,0 +1,110 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWorkerWatchdog(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	killed := []int{}
+	w := NewWorkerWatchdog()
+	w.kill = func(pid int) error {
+		killed = append(killed, pid)
+		return nil
+	}
+
+	// without limits the workers are only tracked
+	check, err := w.Check([]int{12, 11}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []OldWorker{{PID: 11, Since: now}, {PID: 12, Since: now}}
+	if !reflect.DeepEqual(check.Running, expected) {
+		t.Errorf("expected %v but %v returned", expected, check.Running)
+	}
+
+	// the workers that exited are forgotten
+	check, err = w.Check([]int{12, 13, 14, 15}, now.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []OldWorker{
+		{PID: 12, Since: now},
+		{PID: 13, Since: now.Add(time.Minute)},
+		{PID: 14, Since: now.Add(time.Minute)},
+		{PID: 15, Since: now.Add(time.Minute)},
+	}
+	if !reflect.DeepEqual(check.Running, expected) {
+		t.Errorf("expected %v but %v returned", expected, check.Running)
+	}
+	if len(killed) != 0 {
+		t.Errorf("expected no killed workers without limits but %v returned", killed)
+	}
+
+	// the workers older than the maximum age are killed, then the oldest
+	// workers above the maximum count
+	w.MaxAge = 90 * time.Second
+	w.MaxCount = 2
+	check, err = w.Check([]int{12, 13, 14, 15}, now.Add(2*time.Minute))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected = []OldWorker{{PID: 14, Since: now.Add(time.Minute)}, {PID: 15, Since: now.Add(time.Minute)}}
+	if !reflect.DeepEqual(check.Running, expected) {
+		t.Errorf("expected %v but %v returned", expected, check.Running)
+	}
+	if expectedKilled := []int{12, 13}; !reflect.DeepEqual(killed, expectedKilled) {
+		t.Errorf("expected the workers %v killed but %v returned", expectedKilled, killed)
+	}
+	if expectedKills := map[string]int{KilledByAge: 1, KilledByCount: 1}; !reflect.DeepEqual(check.Killed, expectedKills) {
+		t.Errorf("expected %v but %v returned", expectedKills, check.Killed)
+	}
+	if _, ok := w.since[12]; ok {
+		t.Errorf("expected the killed worker 12 to be forgotten")
+	}
+}
+
+func TestWorkerWatchdogKillError(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := NewWorkerWatchdog()
+	w.kill = func(pid int) error {
+		if pid == 11 {
+			return errors.New("operation not permitted")
+		}
+		return nil
+	}
+	w.MaxCount = 1
+
+	check, err := w.Check([]int{11, 12, 13}, now)
+	if err == nil {
+		t.Errorf("expected an error killing the worker 11")
+	}
+
+	// the worker that cannot be killed is left running
+	expected := []OldWorker{{PID: 11, Since: now}, {PID: 13, Since: now}}
+	if !reflect.DeepEqual(check.Running, expected) {
+		t.Errorf("expected %v but %v returned", expected, check.Running)
+	}
+	if check.Killed[KilledByCount] != 1 {
+		t.Errorf("expected 1 worker killed but %v returned", check.Killed[KilledByCount])
+	}
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/process"
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+const (
+	// stuckReloadCheckPeriod defines the interval between checks of the NGINX workers shutting down
+	stuckReloadCheckPeriod = 30 * time.Second
+
+	// stuckReloadMargin defines the time a worker can run after worker-shutdown-timeout
+	// before it is considered stuck, longer than the check period
+	stuckReloadMargin = time.Minute
+)
+
+// commandContext returns the context of a command reloading or stopping NGINX,
+// done after the reload timeout
+func (n *NGINXController) commandContext() (context.Context, context.CancelFunc) {
+	if n.cfg.ReloadTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+
+	return context.WithTimeout(context.Background(), n.cfg.ReloadTimeout)
+}
+
+// checkStuckReload updates the number of NGINX workers of previous configurations
+// shutting down, kills the workers exceeding old-workers-max-age or
+// old-workers-max-count, and emits a warning Event when a worker is running
+// after worker-shutdown-timeout and a margin
+func (n *NGINXController) checkStuckReload() {
+	cfg := n.store.GetBackendConfiguration()
+
+	f, err := os.ReadFile(nginx.PID)
+	if err != nil {
+		klog.V(3).InfoS("Unable to read NGINX PID file", "file", nginx.PID, "err", err)
+		return
+	}
+
+	masterPID, err := strconv.Atoi(strings.TrimSpace(string(f)))
+	if err != nil {
+		klog.Warningf("Error reading NGINX PID from file %v: %v", nginx.PID, err)
+		return
+	}
+
+	pids, err := shuttingDownWorkerPIDs(procRoot, masterPID)
+	if err != nil {
+		klog.Warningf("Error obtaining the NGINX workers shutting down: %v", err)
+		return
+	}
+
+	n.oldWorkers.MaxAge = 0
+	if cfg.OldWorkersMaxAge != "" {
+		n.oldWorkers.MaxAge, err = time.ParseDuration(cfg.OldWorkersMaxAge)
+		if err != nil {
+			klog.Warningf("Ignoring invalid old-workers-max-age %q: %v", cfg.OldWorkersMaxAge, err)
+		}
+	}
+	n.oldWorkers.MaxCount = cfg.OldWorkersMaxCount
+
+	now := time.Now()
+	check, err := n.oldWorkers.Check(pids, now)
+	if err != nil {
+		klog.Warningf("Error killing NGINX workers of previous configurations: %v", err)
+	}
+	for reason, killed := range check.Killed {
+		klog.Warningf("Killed %v NGINX workers of previous configurations exceeding old-workers-max-%v", killed, reason)
+		n.metricCollector.AddOldWorkersKilled(reason, killed)
+	}
+	n.metricCollector.SetOldWorkers(len(check.Running))
+
+	shutdownTimeout, err := time.ParseDuration(cfg.WorkerShutdownTimeout)
+	if err != nil {
+		klog.V(3).InfoS("Unable to parse worker-shutdown-timeout", "err", err)
+		return
+	}
+
+	stuck := stuckWorkers(check.Running, now, shutdownTimeout+stuckReloadMargin)
+	n.metricCollector.SetStuckReloadWorkers(stuck)
+
+	if stuck == 0 {
+		n.stuckReloadWarningEmitted = false
+		return
+	}
+
+	if n.stuckReloadWarningEmitted {
+		return
+	}
+
+	n.stuckReloadWarningEmitted = true
+	msg := fmt.Sprintf("%v NGINX workers of previous configurations are running more than %v after the reload (worker-shutdown-timeout). Long-lived connections could keep the workers running", stuck, shutdownTimeout)
+	klog.Warning(msg)
+	n.recorder.Event(k8s.IngressPodDetails, apiv1.EventTypeWarning, "StuckReload", msg)
+}
+
+// shuttingDownWorkerPIDs returns the PIDs of the children of the NGINX master
+// process shutting down after a reload
+func shuttingDownWorkerPIDs(root string, masterPID int) ([]int, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	pids := []int{}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		ppid, err := readParentPID(filepath.Join(root, entry.Name(), "stat"))
+		if err != nil || ppid != masterPID {
+			// the process could exit while we are reading
+			continue
+		}
+
+		cmdline, err := os.ReadFile(filepath.Join(root, entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+
+		// NGINX changes the title of the processes shutting down
+		if strings.Contains(string(cmdline), "is shutting down") {
+			pids = append(pids, pid)
+		}
+	}
+
+	return pids, nil
+}
+
+// stuckWorkers returns the number of workers shutting down for longer than
+// the timeout
+func stuckWorkers(workers []process.OldWorker, now time.Time, timeout time.Duration) int {
+	stuck := 0
+	for _, worker := range workers {
+		if now.Sub(worker.Since) > timeout {
+			stuck++
+		}
+	}
+
+	return stuck
+}
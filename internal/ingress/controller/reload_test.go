@@ -0,0 +1,91 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/process"
+)
+
+func TestShuttingDownWorkerPIDs(t *testing.T) {
+	root := t.TempDir()
+
+	// master process
+	createFakeProcess(t, root, 10, 1, 100, 0)
+	// workers
+	createFakeProcess(t, root, 11, 10, 100, 0)
+	createFakeProcess(t, root, 12, 10, 100, 0)
+	// unrelated process
+	createFakeProcess(t, root, 20, 1, 100, 0)
+
+	for pid, title := range map[int]string{
+		10: "nginx: master process /usr/bin/nginx -c /etc/nginx/nginx.conf",
+		11: "nginx: worker process",
+		12: "nginx: worker process is shutting down",
+		20: "nginx: worker process is shutting down",
+	} {
+		if err := os.WriteFile(filepath.Join(root, fmt.Sprintf("%d", pid), "cmdline"), []byte(title), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	pids, err := shuttingDownWorkerPIDs(root, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if expected := []int{12}; !reflect.DeepEqual(pids, expected) {
+		t.Errorf("expected %v but %v returned", expected, pids)
+	}
+}
+
+func TestStuckWorkers(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	w := process.NewWorkerWatchdog()
+
+	check, err := w.Check([]int{11, 12}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stuck := stuckWorkers(check.Running, now, time.Minute); stuck != 0 {
+		t.Errorf("expected no stuck workers when first seen but %v returned", stuck)
+	}
+
+	now = now.Add(2 * time.Minute)
+	check, err = w.Check([]int{11, 12, 13}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stuck := stuckWorkers(check.Running, now, time.Minute); stuck != 2 {
+		t.Errorf("expected 2 stuck workers but %v returned", stuck)
+	}
+
+	now = now.Add(time.Minute)
+	check, err = w.Check([]int{13}, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stuck := stuckWorkers(check.Running, now, time.Minute); stuck != 0 {
+		t.Errorf("expected no stuck workers after the exit of the workers but %v returned", stuck)
+	}
+}
@@ -0,0 +1,163 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	timeType          = reflect.TypeOf(time.Time{})
+)
+
+// jsonSchema builds the JSON schema of the JSON encoding of Go types. The
+// named structs are defined once in $defs and referenced with $ref, which
+// supports recursive types.
+type jsonSchema struct {
+	defs map[string]interface{}
+	// omitted contains the names of the fields left out of the schema
+	omitted map[string]bool
+}
+
+func newJSONSchema(omitted ...string) *jsonSchema {
+	s := &jsonSchema{
+		defs:    map[string]interface{}{},
+		omitted: map[string]bool{},
+	}
+	for _, name := range omitted {
+		s.omitted[name] = true
+	}
+
+	return s
+}
+
+// definitionName returns the name of the definition of a named type,
+// qualified by its package to avoid collisions
+func definitionName(t reflect.Type) string {
+	return strings.NewReplacer("/", ".", "-", "_").Replace(t.PkgPath()) + "." + t.Name()
+}
+
+// schemaOf returns the schema of the JSON encoding of t
+func (s *jsonSchema) schemaOf(t reflect.Type) map[string]interface{} {
+	if t.Kind() == reflect.Pointer {
+		// nil pointers are encoded as null
+		return map[string]interface{}{
+			"anyOf": []interface{}{s.schemaOf(t.Elem()), map[string]interface{}{"type": "null"}},
+		}
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		// the encoding is defined by the type, like intstr.IntOrString or metav1.Time
+		return map[string]interface{}{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return map[string]interface{}{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoded in base64
+			return map[string]interface{}{"type": "string"}
+		}
+		return map[string]interface{}{"type": []string{"array", "null"}, "items": s.schemaOf(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": []string{"object", "null"}, "additionalProperties": s.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+
+		name := definitionName(t)
+		if _, ok := s.defs[name]; !ok {
+			// reserve the definition before walking the fields of recursive types
+			s.defs[name] = nil
+			s.defs[name] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+
+	// interfaces, functions and channels
+	return map[string]interface{}{}
+}
+
+// structSchema returns the schema of the fields of a struct encoded in JSON
+func (s *jsonSchema) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	s.addFields(t, properties)
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+func (s *jsonSchema) addFields(t reflect.Type, properties map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				// the fields of embedded structs are promoted
+				s.addFields(ft, properties)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		if s.omitted[name] {
+			continue
+		}
+
+		properties[name] = s.schemaOf(field.Type)
+	}
+}
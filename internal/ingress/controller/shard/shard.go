@@ -0,0 +1,145 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"hash/fnv"
+	"slices"
+	"strconv"
+	"strings"
+
+	networking "k8s.io/api/networking/v1"
+)
+
+// Label assigns an Ingress and all its hostnames to the shard with the
+// index of its value, instead of the shards of the hash of its hostnames
+const Label = "nginx.ingress.kubernetes.io/shard"
+
+// Configuration defines the subset of the hostnames owned by a controller
+// when several controller deployments share the Ingresses of a cluster.
+// Each hostname is owned by the shard of its hash modulo the number of
+// shards. The rules without hostname and the default backends are owned
+// by the shard 0.
+type Configuration struct {
+	// Count is the number of shards. Sharding is disabled below 2 shards.
+	Count int
+	// Index is the shard of the controller, from 0 to Count-1
+	Index int
+}
+
+// Enabled returns true when the hostnames are split between several shards
+func (c *Configuration) Enabled() bool {
+	return c != nil && c.Count > 1
+}
+
+// hostShard returns the shard of a hostname
+func (c *Configuration) hostShard(host string) int {
+	if host == "" {
+		return 0
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(host)))
+	return int(h.Sum32() % uint32(c.Count))
+}
+
+// labelShard returns the shard set by the label of an Ingress, or -1
+// when the label is missing or invalid
+func (c *Configuration) labelShard(ing *networking.Ingress) int {
+	value, ok := ing.Labels[Label]
+	if !ok {
+		return -1
+	}
+
+	index, err := strconv.Atoi(value)
+	if err != nil || index < 0 || index >= c.Count {
+		return -1
+	}
+	return index
+}
+
+// hosts returns the hostnames of the rules of an Ingress, the empty
+// hostname standing for the rules without hostname and the default backend
+func hosts(ing *networking.Ingress) []string {
+	hosts := make([]string, 0, len(ing.Spec.Rules)+1)
+	if ing.Spec.DefaultBackend != nil {
+		hosts = append(hosts, "")
+	}
+	for i := range ing.Spec.Rules {
+		hosts = append(hosts, ing.Spec.Rules[i].Host)
+	}
+	return hosts
+}
+
+// Owns returns true when the controller owns at least one hostname of
+// an Ingress
+func (c *Configuration) Owns(ing *networking.Ingress) bool {
+	if !c.Enabled() {
+		return true
+	}
+
+	if index := c.labelShard(ing); index != -1 {
+		return index == c.Index
+	}
+
+	for _, host := range hosts(ing) {
+		if c.hostShard(host) == c.Index {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnsStatus returns true when the controller updates the status of an
+// Ingress. The status of an Ingress split between several shards is
+// updated by the shard of its lowest hostname only.
+func (c *Configuration) OwnsStatus(ing *networking.Ingress) bool {
+	if !c.Enabled() {
+		return true
+	}
+
+	if index := c.labelShard(ing); index != -1 {
+		return index == c.Index
+	}
+
+	hosts := hosts(ing)
+	if len(hosts) == 0 {
+		return c.Index == 0
+	}
+	return c.hostShard(slices.Min(hosts)) == c.Index
+}
+
+// Spec returns the specification of an Ingress restricted to the rules of
+// the hostnames owned by the controller. The Ingress is not modified.
+func (c *Configuration) Spec(ing *networking.Ingress) networking.IngressSpec {
+	spec := ing.Spec
+	if !c.Enabled() || c.labelShard(ing) != -1 {
+		return spec
+	}
+
+	if spec.DefaultBackend != nil && c.hostShard("") != c.Index {
+		spec.DefaultBackend = nil
+	}
+
+	spec.Rules = make([]networking.IngressRule, 0, len(ing.Spec.Rules))
+	for i := range ing.Spec.Rules {
+		if c.hostShard(ing.Spec.Rules[i].Host) == c.Index {
+			spec.Rules = append(spec.Rules, ing.Spec.Rules[i])
+		}
+	}
+	return spec
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shard
+
+import (
+	"fmt"
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newIngress(labels map[string]string, hosts ...string) *networking.Ingress {
+	ing := &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "default",
+			Labels:    labels,
+		},
+	}
+	for _, host := range hosts {
+		ing.Spec.Rules = append(ing.Spec.Rules, networking.IngressRule{Host: host})
+	}
+	return ing
+}
+
+// hostsOfShards returns a hostname of each shard
+func hostsOfShards(t *testing.T, c *Configuration) []string {
+	t.Helper()
+
+	hosts := make([]string, c.Count)
+	found := 0
+	for i := 0; found < c.Count && i < 1000; i++ {
+		host := fmt.Sprintf("host-%d.example.com", i)
+		if shard := c.hostShard(host); hosts[shard] == "" {
+			hosts[shard] = host
+			found++
+		}
+	}
+	if found < c.Count {
+		t.Fatalf("expected a hostname in each of the %v shards", c.Count)
+	}
+	return hosts
+}
+
+func TestHostShard(t *testing.T) {
+	c := &Configuration{Count: 4}
+
+	if c.hostShard("") != 0 {
+		t.Errorf("expected the empty hostname in the shard 0")
+	}
+	if c.hostShard("Foo.Example.com") != c.hostShard("foo.example.com") {
+		t.Errorf("expected the same shard for the hostnames differing by case")
+	}
+	for i := 0; i < 100; i++ {
+		if shard := c.hostShard(fmt.Sprintf("host-%d.example.com", i)); shard < 0 || shard >= c.Count {
+			t.Errorf("expected a shard between 0 and %v but returned %v", c.Count-1, shard)
+		}
+	}
+}
+
+func TestOwns(t *testing.T) {
+	hosts := hostsOfShards(t, &Configuration{Count: 3})
+
+	testCases := []struct {
+		name     string
+		config   *Configuration
+		ing      *networking.Ingress
+		owns     bool
+		ownsSpec int
+	}{
+		{"disabled sharding", nil, newIngress(nil, hosts[1]), true, 1},
+		{"single shard", &Configuration{Count: 1}, newIngress(nil, hosts[1]), true, 1},
+		{"owned hostname", &Configuration{Count: 3, Index: 1}, newIngress(nil, hosts[1]), true, 1},
+		{"hostname of another shard", &Configuration{Count: 3, Index: 2}, newIngress(nil, hosts[1]), false, 0},
+		{"split hostnames", &Configuration{Count: 3, Index: 2}, newIngress(nil, hosts[0], hosts[2], hosts[1]), true, 1},
+		{"rule without hostname", &Configuration{Count: 3, Index: 0}, newIngress(nil, "", hosts[1]), true, 1},
+		{"label of the shard", &Configuration{Count: 3, Index: 2}, newIngress(map[string]string{Label: "2"}, hosts[0], hosts[1]), true, 2},
+		{"label of another shard", &Configuration{Count: 3, Index: 1}, newIngress(map[string]string{Label: "2"}, hosts[1]), false, 1},
+		{"invalid label", &Configuration{Count: 3, Index: 1}, newIngress(map[string]string{Label: "3"}, hosts[1]), true, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if owns := tc.config.Owns(tc.ing); owns != tc.owns {
+				t.Errorf("expected ownership %v but returned %v", tc.owns, owns)
+			}
+			if rules := tc.config.Spec(tc.ing).Rules; len(rules) != tc.ownsSpec {
+				t.Errorf("expected %v owned rules but returned %v", tc.ownsSpec, len(rules))
+			}
+		})
+	}
+}
+
+func TestOwnsStatus(t *testing.T) {
+	c := &Configuration{Count: 3}
+	hosts := hostsOfShards(t, c)
+
+	// the lowest hostname decides the shard updating the status
+	ing := newIngress(nil, hosts[0], hosts[1], hosts[2])
+	lowest := c.hostShard(min(hosts[0], hosts[1], hosts[2]))
+
+	for index := 0; index < c.Count; index++ {
+		shard := &Configuration{Count: 3, Index: index}
+		if owns := shard.OwnsStatus(ing); owns != (index == lowest) {
+			t.Errorf("expected the status owned by the shard %v only, the shard %v returned %v", lowest, index, owns)
+		}
+	}
+
+	labeled := newIngress(map[string]string{Label: "1"}, hosts[0])
+	if !(&Configuration{Count: 3, Index: 1}).OwnsStatus(labeled) {
+		t.Errorf("expected the status owned by the shard of the label")
+	}
+	if (&Configuration{Count: 3, Index: 0}).OwnsStatus(labeled) {
+		t.Errorf("expected the status not owned by the shard of the hostname")
+	}
+
+	var disabled *Configuration
+	if !disabled.OwnsStatus(ing) {
+		t.Errorf("expected the status owned without sharding")
+	}
+}
+
+func TestSpecDefaultBackend(t *testing.T) {
+	hosts := hostsOfShards(t, &Configuration{Count: 2})
+
+	ing := newIngress(nil, hosts[0], hosts[1])
+	ing.Spec.DefaultBackend = &networking.IngressBackend{
+		Service: &networking.IngressServiceBackend{Name: "default"},
+	}
+
+	spec := (&Configuration{Count: 2, Index: 1}).Spec(ing)
+	if spec.DefaultBackend != nil {
+		t.Errorf("expected the default backend owned by the shard 0 only")
+	}
+	if len(spec.Rules) != 1 || spec.Rules[0].Host != hosts[1] {
+		t.Errorf("expected the rule of %v but returned %v", hosts[1], spec.Rules)
+	}
+	if ing.Spec.DefaultBackend == nil || len(ing.Spec.Rules) != 2 {
+		t.Errorf("expected the Ingress not modified")
+	}
+
+	spec = (&Configuration{Count: 2, Index: 0}).Spec(ing)
+	if spec.DefaultBackend == nil {
+		t.Errorf("expected the default backend owned by the shard 0")
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	klog "k8s.io/klog/v2"
+
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
+	"k8s.io/ingress-nginx/internal/k8s"
+	"k8s.io/ingress-nginx/internal/nginx"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+	"k8s.io/ingress-nginx/pkg/util/runtime"
+)
+
+const (
+	// luaSharedDictsPath is the path of the NGINX status server returning the
+	// capacity and the free space of the Lua shared dictionaries
+	luaSharedDictsPath = "/configuration/shared-dicts"
+
+	// luaSharedDictCheckPeriod defines the interval between checks of the utilization of the Lua shared dictionaries
+	luaSharedDictCheckPeriod = 30 * time.Second
+
+	// luaSharedDictWarningThreshold defines the utilization of a Lua shared dictionary that triggers a warning Event
+	luaSharedDictWarningThreshold = 0.9
+
+	// luaSharedDictsMemoryRatio is the maximum ratio of the memory limit of
+	// the container used by the Lua shared dictionaries when autosized
+	luaSharedDictsMemoryRatio = 0.25
+
+	// luaSharedDictHeadroom multiplies the estimated size of the content of
+	// an autosized Lua shared dictionary, for the fragmentation and the growth
+	// between reloads
+	luaSharedDictHeadroom = 2
+)
+
+// estimated sizes in bytes of the entries of the Lua shared dictionaries
+const (
+	// backendEntryBytes is the size of a backend without endpoints in configuration_data
+	backendEntryBytes = 2048
+	// endpointEntryBytes is the size of an endpoint in configuration_data and in the balancer_ewma dictionaries
+	endpointEntryBytes = 256
+	// certificateEntryBytes is the size of a certificate in certificate_data, without the PEM
+	certificateEntryBytes = 256
+	// hostnameEntryBytes is the size of a hostname in certificate_servers, without the hostname
+	hostnameEntryBytes = 128
+	// ocspResponseEntryBytes is the size of an OCSP response in ocsp_response_cache
+	ocspResponseEntryBytes = 4096
+	// luaKVEntryBytes is the size of a key/value store in lua_kv, without the data
+	luaKVEntryBytes = 128
+)
+
+// memoryLimit returns the memory limit of the container in bytes, or -1
+var memoryLimit = runtime.MemoryLimit
+
+// luaSharedDictEstimates returns the estimated size in bytes of the content
+// of the Lua shared dictionaries used by the controller for a configuration
+func luaSharedDictEstimates(ingressCfg *ingress.Configuration) map[string]int {
+	endpoints := 0
+	for _, backend := range ingressCfg.Backends {
+		endpoints += len(backend.Endpoints)
+	}
+
+	// the certificates are stored once for all the servers using them
+	certificates := map[string]int{}
+	hostnames := 0
+	for _, server := range ingressCfg.Servers {
+		if server.SSLCert == nil {
+			continue
+		}
+
+		certificates[server.SSLCert.UID] = len(server.SSLCert.PemCertKey) + certificateEntryBytes
+		hostnames += len(server.Hostname) + hostnameEntryBytes
+		for _, alias := range server.Aliases {
+			hostnames += len(alias) + hostnameEntryBytes
+		}
+	}
+
+	certificateBytes := 0
+	for _, size := range certificates {
+		certificateBytes += size
+	}
+
+	// the key/value stores are stored once for all the locations of an Ingress
+	stores := map[string]int{}
+	for _, server := range ingressCfg.Servers {
+		for _, location := range server.Locations {
+			if location.LuaKV.ConfigMap == "" || location.Ingress == nil {
+				continue
+			}
+
+			size := luaKVEntryBytes
+			for key, value := range location.LuaKV.Data {
+				size += len(key) + len(value)
+			}
+			stores[k8s.MetaNamespaceKey(location.Ingress)] = size
+		}
+	}
+
+	luaKVBytes := 0
+	for _, size := range stores {
+		luaKVBytes += size
+	}
+
+	return map[string]int{
+		"configuration_data":            len(ingressCfg.Backends)*backendEntryBytes + endpoints*endpointEntryBytes,
+		"certificate_data":              certificateBytes,
+		"certificate_servers":           hostnames,
+		"ocsp_response_cache":           len(certificates) * ocspResponseEntryBytes,
+		"balancer_ewma":                 endpoints * endpointEntryBytes,
+		"balancer_ewma_last_touched_at": endpoints * endpointEntryBytes,
+		"balancer_ewma_locks":           endpoints * endpointEntryBytes,
+		"lua_kv":                        luaKVBytes,
+	}
+}
+
+// autosizeLuaSharedDicts returns the sizes in kilobytes of the Lua shared
+// dictionaries, growing the ones not defined in lua-shared-dicts to hold the
+// content of the configuration. The autosized dictionaries use powers of two
+// megabytes to avoid reloads on small changes of the configuration, never go
+// below the configured size, and are shrunk, the largest first, while the
+// dictionaries use more than luaSharedDictsMemoryRatio of the memory limit.
+func autosizeLuaSharedDicts(cfg *ngx_config.Configuration, ingressCfg *ingress.Configuration, limit int64) map[string]int {
+	sizes := make(map[string]int, len(cfg.LuaSharedDicts))
+	for name, size := range cfg.LuaSharedDicts {
+		sizes[name] = size
+	}
+
+	overrides := sets.New(cfg.LuaSharedDictsOverrides...)
+	autosized := []string{}
+	for name, estimate := range luaSharedDictEstimates(ingressCfg) {
+		configured, ok := sizes[name]
+		if !ok || overrides.Has(name) {
+			continue
+		}
+
+		megabytes := (estimate*luaSharedDictHeadroom + 1024*1024 - 1) / (1024 * 1024)
+		size := min(nextPowerOf2(megabytes)*1024, ngx_template.MaxAllowedLuaDictSize)
+		if size > configured {
+			sizes[name] = size
+			autosized = append(autosized, name)
+		}
+	}
+
+	if limit <= 0 || len(autosized) == 0 {
+		return sizes
+	}
+
+	total := 0
+	for _, size := range sizes {
+		total += size
+	}
+
+	sort.Strings(autosized)
+	budget := int(float64(limit) * luaSharedDictsMemoryRatio / 1024)
+	for total > budget {
+		largest := ""
+		for _, name := range autosized {
+			if sizes[name] > cfg.LuaSharedDicts[name] && (largest == "" || sizes[name] > sizes[largest]) {
+				largest = name
+			}
+		}
+
+		if largest == "" {
+			break
+		}
+
+		size := max(sizes[largest]/2, cfg.LuaSharedDicts[largest])
+		total -= sizes[largest] - size
+		sizes[largest] = size
+	}
+
+	return sizes
+}
+
+// luaSharedDictSizes returns the sizes in kilobytes of the Lua shared
+// dictionaries for a configuration, autosized when lua-shared-dicts-autosize
+// is enabled
+func luaSharedDictSizes(cfg *ngx_config.Configuration, ingressCfg *ingress.Configuration) map[string]int {
+	if !cfg.LuaSharedDictsAutosize {
+		return cfg.LuaSharedDicts
+	}
+
+	return autosizeLuaSharedDicts(cfg, ingressCfg, memoryLimit())
+}
+
+// luaSharedDictStatus is the status of a Lua shared dictionary returned by luaSharedDictsPath
+type luaSharedDictStatus struct {
+	Capacity  int64 `json:"capacity"`
+	FreeSpace int64 `json:"free_space"`
+}
+
+// luaSharedDictUtilization returns the ratio of the memory used in each Lua
+// shared dictionary. The free space only counts the free memory pages, so the
+// utilization is an upper bound.
+func luaSharedDictUtilization(dicts map[string]luaSharedDictStatus) map[string]float64 {
+	utilization := make(map[string]float64, len(dicts))
+	for name, dict := range dicts {
+		if dict.Capacity <= 0 {
+			continue
+		}
+
+		utilization[name] = 1 - float64(dict.FreeSpace)/float64(dict.Capacity)
+	}
+
+	return utilization
+}
+
+// checkLuaSharedDicts updates the utilization of the Lua shared dictionaries
+// and emits a warning Event when the utilization of a dictionary crosses
+// luaSharedDictWarningThreshold
+func (n *NGINXController) checkLuaSharedDicts() {
+	statusCode, data, err := nginx.NewGetStatusRequest(luaSharedDictsPath)
+	if err != nil {
+		klog.V(3).InfoS("Unable to obtain the Lua shared dictionaries", "err", err)
+		return
+	}
+
+	if statusCode != http.StatusOK {
+		klog.Warningf("Unexpected status code %v obtaining the Lua shared dictionaries", statusCode)
+		return
+	}
+
+	var dicts map[string]luaSharedDictStatus
+	if err := json.Unmarshal(data, &dicts); err != nil {
+		klog.Warningf("Error decoding the Lua shared dictionaries: %v", err)
+		return
+	}
+
+	utilization := luaSharedDictUtilization(dicts)
+	n.metricCollector.SetLuaSharedDictUtilization(utilization)
+
+	for name, ratio := range utilization {
+		if ratio < luaSharedDictWarningThreshold {
+			n.luaSharedDictWarnings.Delete(name)
+			continue
+		}
+
+		if n.luaSharedDictWarnings.Has(name) {
+			continue
+		}
+
+		n.luaSharedDictWarnings.Insert(name)
+		msg := fmt.Sprintf("The Lua shared dictionary %v is %.0f%% full. Increase its size with lua-shared-dicts or enable lua-shared-dicts-autosize", name, ratio*100)
+		klog.Warning(msg)
+		n.recorder.Event(k8s.IngressPodDetails, apiv1.EventTypeWarning, "LuaSharedDict", msg)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func newSharedDictsConfiguration(backends, endpoints, certificates int) *ingress.Configuration {
+	cfg := &ingress.Configuration{}
+	for i := 0; i < backends; i++ {
+		backend := &ingress.Backend{Name: fmt.Sprintf("backend-%v", i)}
+		for j := 0; j < endpoints; j++ {
+			backend.Endpoints = append(backend.Endpoints, ingress.Endpoint{Address: fmt.Sprintf("10.0.%v.%v", i%256, j%256), Port: "8080"})
+		}
+		cfg.Backends = append(cfg.Backends, backend)
+	}
+
+	for i := 0; i < certificates; i++ {
+		cfg.Servers = append(cfg.Servers, &ingress.Server{
+			Hostname: fmt.Sprintf("host-%v.example.com", i),
+			SSLCert: &ingress.SSLCert{
+				UID:        fmt.Sprintf("uid-%v", i),
+				PemCertKey: strings.Repeat("x", 4096),
+			},
+		})
+	}
+
+	return cfg
+}
+
+func newSharedDictsBackendConfiguration(overrides ...string) *ngx_config.Configuration {
+	cfg := &ngx_config.Configuration{
+		LuaSharedDicts: map[string]int{
+			"configuration_data":            20480,
+			"certificate_data":              20480,
+			"balancer_ewma":                 10240,
+			"balancer_ewma_last_touched_at": 10240,
+			"balancer_ewma_locks":           1024,
+			"certificate_servers":           5120,
+			"ocsp_response_cache":           5120,
+		},
+		LuaSharedDictsAutosize:  true,
+		LuaSharedDictsOverrides: overrides,
+	}
+
+	return cfg
+}
+
+func TestAutosizeLuaSharedDicts(t *testing.T) {
+	t.Run("a small configuration keeps the default sizes", func(t *testing.T) {
+		cfg := newSharedDictsBackendConfiguration()
+		sizes := autosizeLuaSharedDicts(cfg, newSharedDictsConfiguration(10, 3, 10), -1)
+		if !reflect.DeepEqual(sizes, cfg.LuaSharedDicts) {
+			t.Errorf("expected the default sizes %v but returned %v", cfg.LuaSharedDicts, sizes)
+		}
+	})
+
+	t.Run("a large configuration grows the dictionaries to powers of two megabytes", func(t *testing.T) {
+		cfg := newSharedDictsBackendConfiguration()
+		// 5000 backends of 10 endpoints and 5000 certificates of 4k
+		sizes := autosizeLuaSharedDicts(cfg, newSharedDictsConfiguration(5000, 10, 5000), -1)
+
+		expected := map[string]int{
+			// (5000*2048 + 50000*256) * 2 bytes ~ 44M
+			"configuration_data": 64 * 1024,
+			// 5000*(4096+256) * 2 bytes ~ 41M
+			"certificate_data": 64 * 1024,
+			// 50000*256 * 2 bytes ~ 24M
+			"balancer_ewma":                 32 * 1024,
+			"balancer_ewma_last_touched_at": 32 * 1024,
+			"balancer_ewma_locks":           32 * 1024,
+			"certificate_servers":           5120,
+			// 5000*4096 * 2 bytes ~ 39M
+			"ocsp_response_cache": 64 * 1024,
+		}
+		if !reflect.DeepEqual(sizes, expected) {
+			t.Errorf("expected %v but returned %v", expected, sizes)
+		}
+		if cfg.LuaSharedDicts["configuration_data"] != 20480 {
+			t.Errorf("expected the configuration to not be modified")
+		}
+	})
+
+	t.Run("the dictionaries defined in lua-shared-dicts are not autosized", func(t *testing.T) {
+		cfg := newSharedDictsBackendConfiguration("configuration_data")
+		sizes := autosizeLuaSharedDicts(cfg, newSharedDictsConfiguration(5000, 10, 0), -1)
+		if sizes["configuration_data"] != 20480 {
+			t.Errorf("expected the defined size 20480 but returned %v", sizes["configuration_data"])
+		}
+		if sizes["balancer_ewma"] != 32*1024 {
+			t.Errorf("expected balancer_ewma to be autosized to 32768 but returned %v", sizes["balancer_ewma"])
+		}
+	})
+
+	t.Run("the dictionaries are bounded by the memory limit", func(t *testing.T) {
+		cfg := newSharedDictsBackendConfiguration()
+		// a quarter of 1G, 256M, for 72M of dictionaries with the default sizes
+		sizes := autosizeLuaSharedDicts(cfg, newSharedDictsConfiguration(5000, 10, 5000), 1024*1024*1024)
+
+		total := 0
+		for _, size := range sizes {
+			total += size
+		}
+		if total > 256*1024 {
+			t.Errorf("expected at most 256M of dictionaries but returned %vk: %v", total, sizes)
+		}
+		for name, size := range sizes {
+			if size < cfg.LuaSharedDicts[name] {
+				t.Errorf("expected %v to not be smaller than the configured size %v but returned %v", name, cfg.LuaSharedDicts[name], size)
+			}
+		}
+	})
+
+	t.Run("a low memory limit keeps the default sizes", func(t *testing.T) {
+		cfg := newSharedDictsBackendConfiguration()
+		sizes := autosizeLuaSharedDicts(cfg, newSharedDictsConfiguration(5000, 10, 5000), 128*1024*1024)
+		if !reflect.DeepEqual(sizes, cfg.LuaSharedDicts) {
+			t.Errorf("expected the default sizes %v but returned %v", cfg.LuaSharedDicts, sizes)
+		}
+	})
+}
+
+func TestLuaSharedDictSizes(t *testing.T) {
+	cfg := newSharedDictsBackendConfiguration()
+	cfg.LuaSharedDictsAutosize = false
+
+	sizes := luaSharedDictSizes(cfg, newSharedDictsConfiguration(5000, 10, 5000))
+	if !reflect.DeepEqual(sizes, cfg.LuaSharedDicts) {
+		t.Errorf("expected the configured sizes without autosizing but returned %v", sizes)
+	}
+}
+
+func TestLuaSharedDictUtilization(t *testing.T) {
+	utilization := luaSharedDictUtilization(map[string]luaSharedDictStatus{
+		"configuration_data": {Capacity: 20 * 1024 * 1024, FreeSpace: 5 * 1024 * 1024},
+		"balancer_ewma":      {Capacity: 10 * 1024 * 1024, FreeSpace: 10 * 1024 * 1024},
+		"empty":              {},
+	})
+
+	expected := map[string]float64{
+		"configuration_data": 0.75,
+		"balancer_ewma":      0,
+	}
+	if !reflect.DeepEqual(utilization, expected) {
+		t.Errorf("expected %v but returned %v", expected, utilization)
+	}
+}
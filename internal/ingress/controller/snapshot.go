@@ -0,0 +1,337 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	klog "k8s.io/klog/v2"
+
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+	"k8s.io/ingress-nginx/pkg/util/file"
+)
+
+// apiServerCheckPeriod is the period of the checks of the Kubernetes API
+// server when the controller starts from the configuration snapshot
+const apiServerCheckPeriod = 5 * time.Second
+
+// configurationSnapshot contains the last configuration applied successfully.
+// It is used to serve traffic when the controller starts without access to
+// the Kubernetes API server.
+type configurationSnapshot struct {
+	Backend       ngx_config.Configuration `json:"backend"`
+	Configuration *ingress.Configuration   `json:"configuration"`
+}
+
+// ConfigurationSnapshotExists checks if there is a configuration snapshot in path
+func ConfigurationSnapshotExists(path string) bool {
+	if path == "" {
+		return false
+	}
+
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// snapshotUnsupportedFeature returns the first feature used in the configuration
+// whose data is not persisted in a snapshot, or an empty string. The credentials
+// kept in memory are never written to disk, and the files of the authentication
+// and of the CA certificates do not survive the restart of the container.
+func snapshotUnsupportedFeature(pcfg *ingress.Configuration) string {
+	for _, server := range pcfg.Servers {
+		if server.CertificateAuth.CAFileName != "" {
+			return "auth-tls-secret"
+		}
+		if server.ProxySSL.CAFileName != "" {
+			return "proxy-ssl-secret"
+		}
+
+		for _, location := range server.Locations {
+			switch {
+			case location.BasicDigestAuth.Secured:
+				return "auth-secret"
+			case location.OIDCAuth.Key != "":
+				return "auth-oidc-issuer"
+			case location.LuaKV.ConfigMap != "":
+				return "lua-kv-configmap"
+			case location.ProxySSL.CAFileName != "":
+				return "proxy-ssl-secret"
+			}
+		}
+	}
+
+	return ""
+}
+
+// snapshotConfiguration returns the configuration written in a snapshot. The
+// private keys of the certificates are only written when keepKeys is true,
+// otherwise they are read from the SSL directory when the snapshot is used.
+func snapshotConfiguration(pcfg *ingress.Configuration, keepKeys bool) *ingress.Configuration {
+	if keepKeys {
+		return pcfg
+	}
+
+	snapshot := *pcfg
+
+	snapshot.Servers = make([]*ingress.Server, 0, len(pcfg.Servers))
+	for _, server := range pcfg.Servers {
+		s := *server
+		s.SSLCert = withoutPrivateKeys(server.SSLCert)
+		s.AlternateSSLCert = withoutPrivateKeys(server.AlternateSSLCert)
+		snapshot.Servers = append(snapshot.Servers, &s)
+	}
+
+	snapshot.CustomDomains = make([]ingress.CustomDomain, 0, len(pcfg.CustomDomains))
+	for _, domain := range pcfg.CustomDomains {
+		domain.SSLCert = withoutPrivateKeys(domain.SSLCert)
+		snapshot.CustomDomains = append(snapshot.CustomDomains, domain)
+	}
+
+	return &snapshot
+}
+
+// withoutPrivateKeys returns a copy of the certificate without the PEM encoded
+// certificates and private keys
+func withoutPrivateKeys(cert *ingress.SSLCert) *ingress.SSLCert {
+	if cert == nil {
+		return nil
+	}
+
+	c := *cert
+	c.PemCertKey = ""
+	c.AlternatePemCertKey = ""
+	return &c
+}
+
+// loadSnapshotCertificates reads the certificates and private keys not written
+// in a snapshot from their file in the SSL directory. It returns an error when
+// a file does not exist, like the certificates only configured in Lua.
+func loadSnapshotCertificates(pcfg *ingress.Configuration) error {
+	certs := []*ingress.SSLCert{}
+	for _, server := range pcfg.Servers {
+		certs = append(certs, server.SSLCert, server.AlternateSSLCert)
+	}
+	for _, domain := range pcfg.CustomDomains {
+		certs = append(certs, domain.SSLCert)
+	}
+
+	for _, cert := range certs {
+		if cert == nil {
+			continue
+		}
+
+		if cert.PemCertKey == "" {
+			pem, err := readSnapshotCertificate(cert, cert.PemFileName)
+			if err != nil {
+				return err
+			}
+			cert.PemCertKey = pem
+		}
+
+		if cert.AlternatePemSHA != "" && cert.AlternatePemCertKey == "" {
+			pem, err := readSnapshotCertificate(cert, cert.AlternatePemFileName)
+			if err != nil {
+				return err
+			}
+			cert.AlternatePemCertKey = pem
+		}
+	}
+
+	return nil
+}
+
+func readSnapshotCertificate(cert *ingress.SSLCert, path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("the private key of the certificate %v/%v is not persisted", cert.Namespace, cert.Name)
+	}
+
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading the certificate %v/%v: %w", cert.Namespace, cert.Name, err)
+	}
+
+	return string(pem), nil
+}
+
+// writeConfigurationSnapshot persists the configuration in the file configured
+// using the flag --configuration-snapshot. The snapshot is removed when the
+// configuration uses a feature whose data cannot be persisted.
+func (n *NGINXController) writeConfigurationSnapshot(pcfg *ingress.Configuration) error {
+	if feature := snapshotUnsupportedFeature(pcfg); feature != "" {
+		err := os.Remove(n.cfg.ConfigurationSnapshot)
+		if err == nil {
+			klog.Warningf("Removing configuration snapshot: the configuration uses %v, which cannot be persisted", feature)
+		}
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	snapshot := configurationSnapshot{
+		Backend:       n.store.GetBackendConfiguration(),
+		Configuration: snapshotConfiguration(pcfg, n.cfg.ConfigurationSnapshotTLSKeys),
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("unexpected error encoding configuration snapshot: %w", err)
+	}
+
+	return writeFileAtomically(n.cfg.ConfigurationSnapshot, data)
+}
+
+// writeFileAtomically writes data to a temporal file renamed to path,
+// to never leave a partial file
+func writeFileAtomically(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), file.ReadWriteByUser); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, file.ReadWriteByUser); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+// readConfigurationSnapshot returns the configuration snapshot persisted in path
+func readConfigurationSnapshot(path string) (*configurationSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &configurationSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("unexpected error decoding configuration snapshot: %w", err)
+	}
+
+	if snapshot.Configuration == nil {
+		return nil, fmt.Errorf("configuration snapshot %v does not contain a configuration", path)
+	}
+
+	return snapshot, nil
+}
+
+// startFromConfigurationSnapshot starts NGINX using the configuration snapshot,
+// when it exists, to serve traffic before the local store is synchronized with
+// the Kubernetes API server. It returns true when NGINX was started.
+func (n *NGINXController) startFromConfigurationSnapshot() bool {
+	if n.cfg.ConfigurationSnapshot == "" {
+		return false
+	}
+
+	snapshot, err := readConfigurationSnapshot(n.cfg.ConfigurationSnapshot)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			klog.Warningf("Error reading configuration snapshot: %v", err)
+		}
+		return false
+	}
+
+	if feature := snapshotUnsupportedFeature(snapshot.Configuration); feature != "" {
+		klog.Warningf("Ignoring configuration snapshot: the configuration uses %v, which cannot be persisted", feature)
+		return false
+	}
+
+	if err := loadSnapshotCertificates(snapshot.Configuration); err != nil {
+		klog.Warningf("Ignoring configuration snapshot: %v", err)
+		return false
+	}
+
+	cfg := snapshot.Backend
+	cfg.Resolver = n.resolver
+
+	content, err := n.generateTemplate(cfg, *snapshot.Configuration, false)
+	if err != nil {
+		klog.Warningf("Error rendering configuration snapshot: %v", err)
+		return false
+	}
+
+	if err := n.createLuaConfig(&cfg); err != nil {
+		klog.Warningf("Error creating Lua configuration from snapshot: %v", err)
+		return false
+	}
+
+	if err := createOpentelemetryCfg(&cfg); err != nil {
+		klog.Warningf("Error creating OpenTelemetry configuration from snapshot: %v", err)
+		return false
+	}
+
+	if err := n.testTemplate(content); err != nil {
+		klog.Warningf("Invalid configuration snapshot: %v", err)
+		return false
+	}
+
+	if err := os.WriteFile(cfgPath, content, file.ReadWriteByUser); err != nil {
+		klog.Warningf("Error writing configuration snapshot: %v", err)
+		return false
+	}
+
+	klog.InfoS("Starting NGINX process with the configuration snapshot", "file", n.cfg.ConfigurationSnapshot)
+	n.startNGINX()
+
+	// NGINX needs some time to start listening
+	time.Sleep(1 * time.Second)
+
+	retry := wait.Backoff{
+		Steps:    1 + n.cfg.DynamicConfigurationRetries,
+		Duration: time.Second,
+		Factor:   1.3,
+		Jitter:   0.1,
+	}
+
+	err = wait.ExponentialBackoff(retry, func() (bool, error) {
+		if err := n.configureDynamically(snapshot.Configuration); err != nil {
+			klog.Warningf("Dynamic reconfiguration from snapshot failed (retrying): %v", err)
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		klog.Warningf("Dynamic reconfiguration from snapshot failed: %v", err)
+		return true
+	}
+
+	// the first synchronization only reloads NGINX if the configuration changed
+	n.setRunningConfig(snapshot.Configuration)
+
+	return true
+}
+
+// waitForAPIServer blocks until the Kubernetes API server is available
+func (n *NGINXController) waitForAPIServer() {
+	err := wait.PollUntilContextCancel(wait.ContextForChannel(n.stopCh), apiServerCheckPeriod, true, func(context.Context) (bool, error) {
+		if _, err := n.cfg.Client.Discovery().ServerVersion(); err != nil {
+			klog.V(2).ErrorS(err, "Kubernetes API server not available")
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		klog.Warningf("Stopped waiting for the Kubernetes API server: %v", err)
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/luakv"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestReadConfigurationSnapshot(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "snapshot.json")
+	if ConfigurationSnapshotExists(path) {
+		t.Errorf("expected no snapshot in %v", path)
+	}
+
+	if ConfigurationSnapshotExists("") {
+		t.Errorf("expected no snapshot without path")
+	}
+
+	if _, err := readConfigurationSnapshot(path); !os.IsNotExist(err) {
+		t.Errorf("expected not exist error but %v returned", err)
+	}
+
+	content := `{"backend":{"worker-processes":"4"},"configuration":{"servers":[{"hostname":"example.com"}]}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !ConfigurationSnapshotExists(path) {
+		t.Errorf("expected snapshot in %v", path)
+	}
+
+	snapshot, err := readConfigurationSnapshot(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if snapshot.Backend.WorkerProcesses != "4" {
+		t.Errorf("expected 4 worker processes but %v returned", snapshot.Backend.WorkerProcesses)
+	}
+
+	if len(snapshot.Configuration.Servers) != 1 || snapshot.Configuration.Servers[0].Hostname != "example.com" {
+		t.Errorf("unexpected servers in snapshot: %v", snapshot.Configuration.Servers)
+	}
+
+	for _, invalid := range []string{`{"backend":{}}`, `{"configuration":`} {
+		if err := os.WriteFile(path, []byte(invalid), 0o644); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if _, err := readConfigurationSnapshot(path); err == nil {
+			t.Errorf("expected an error reading %v", invalid)
+		}
+	}
+}
+
+func TestSnapshotUnsupportedFeature(t *testing.T) {
+	testCases := []struct {
+		title    string
+		location *ingress.Location
+		expected string
+	}{
+		{"no authentication", &ingress.Location{Path: "/"}, ""},
+		{"basic authentication", &ingress.Location{Path: "/", BasicDigestAuth: auth.Config{Secured: true, InMemory: true}}, "auth-secret"},
+		{"lua key/value store", &ingress.Location{Path: "/", LuaKV: luakv.Config{ConfigMap: "default/flags"}}, "lua-kv-configmap"},
+	}
+
+	for _, testCase := range testCases {
+		pcfg := &ingress.Configuration{
+			Servers: []*ingress.Server{{Hostname: "example.com", Locations: []*ingress.Location{testCase.location}}},
+		}
+
+		if feature := snapshotUnsupportedFeature(pcfg); feature != testCase.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, testCase.expected, feature)
+		}
+	}
+}
+
+func TestSnapshotConfigurationPrivateKeys(t *testing.T) {
+	cert := &ingress.SSLCert{Name: "example-tls", Namespace: "default", PemFileName: "/ssl/default-example-tls.pem", PemCertKey: "key"}
+	pcfg := &ingress.Configuration{
+		Servers:       []*ingress.Server{{Hostname: "example.com", SSLCert: cert}, {Hostname: "_"}},
+		CustomDomains: []ingress.CustomDomain{{Hostname: "example.org", Server: "example.com", SSLCert: cert}},
+	}
+
+	if snapshot := snapshotConfiguration(pcfg, true); snapshot.Servers[0].SSLCert.PemCertKey != "key" {
+		t.Errorf("expected the private key in the snapshot")
+	}
+
+	snapshot := snapshotConfiguration(pcfg, false)
+	if snapshot.Servers[0].SSLCert.PemCertKey != "" || snapshot.CustomDomains[0].SSLCert.PemCertKey != "" {
+		t.Errorf("expected no private key in the snapshot")
+	}
+
+	if snapshot.Servers[0].SSLCert.PemFileName != cert.PemFileName {
+		t.Errorf("expected the file of the certificate %v but %v returned", cert.PemFileName, snapshot.Servers[0].SSLCert.PemFileName)
+	}
+
+	if snapshot.Servers[1].SSLCert != nil {
+		t.Errorf("expected no certificate in the server without TLS")
+	}
+
+	if cert.PemCertKey != "key" {
+		t.Errorf("expected the certificate of the configuration not to be modified")
+	}
+}
+
+func TestLoadSnapshotCertificates(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "default-example-tls.pem")
+	if err := os.WriteFile(path, []byte("key"), 0o600); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	pcfg := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{Hostname: "example.com", SSLCert: &ingress.SSLCert{Name: "example-tls", Namespace: "default", PemFileName: path}},
+			{Hostname: "_"},
+		},
+	}
+
+	if err := loadSnapshotCertificates(pcfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if pcfg.Servers[0].SSLCert.PemCertKey != "key" {
+		t.Errorf("expected the private key read from %v but '%v' returned", path, pcfg.Servers[0].SSLCert.PemCertKey)
+	}
+
+	missing := &ingress.Configuration{
+		Servers: []*ingress.Server{
+			{Hostname: "example.com", SSLCert: &ingress.SSLCert{Name: "example-tls", Namespace: "default", PemFileName: filepath.Join(dir, "missing.pem")}},
+		},
+	}
+
+	if err := loadSnapshotCertificates(missing); err == nil {
+		t.Errorf("expected an error with a certificate not present in the SSL directory")
+	}
+}
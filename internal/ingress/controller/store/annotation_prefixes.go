@@ -0,0 +1,159 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+// annotationPrefixesKey is the key of the ConfigMap referenced in the parameters
+// of an IngressClass that contains the annotation prefixes accepted in its Ingresses
+const annotationPrefixesKey = "annotation-prefixes"
+
+// ingressClassParametersKey returns the key of the ConfigMap referenced in the
+// parameters of an IngressClass or an empty string
+func ingressClassParametersKey(ic *networkingv1.IngressClass) string {
+	params := ic.Spec.Parameters
+	if params == nil || params.Kind != "ConfigMap" || params.Namespace == nil {
+		return ""
+	}
+
+	if params.APIGroup != nil && *params.APIGroup != "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%v/%v", *params.Namespace, params.Name)
+}
+
+// isIngressClassParameters checks if the ConfigMap matching key is referenced
+// in the parameters of an IngressClass
+func (s *k8sStore) isIngressClassParameters(key string) bool {
+	if s.listers.IngressClass.Store == nil {
+		return false
+	}
+
+	for _, obj := range s.listers.IngressClass.List() {
+		ic, ok := obj.(*networkingv1.IngressClass)
+		if ok && ingressClassParametersKey(ic) == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getIngressClassParameters returns the data of the ConfigMap referenced in the
+// parameters of the IngressClass of an Ingress, or nil
+func (s *k8sStore) getIngressClassParameters(ing *networkingv1.Ingress) map[string]string {
+	if s.listers.IngressClass.Store == nil || ing.Spec.IngressClassName == nil {
+		return nil
+	}
+
+	ic, err := s.listers.IngressClass.ByKey(*ing.Spec.IngressClassName)
+	if err != nil {
+		return nil
+	}
+
+	key := ingressClassParametersKey(ic)
+	if key == "" {
+		return nil
+	}
+
+	cm, err := s.GetConfigMap(key)
+	if err != nil {
+		klog.Warningf("Error getting parameters of IngressClass %v: %v", ic.Name, err)
+		return nil
+	}
+
+	return cm.Data
+}
+
+// GetAnnotationPrefixes returns the annotation prefixes accepted in an Ingress,
+// sorted by precedence, as configured in the parameters of its IngressClass
+func (s *k8sStore) GetAnnotationPrefixes(ing *networkingv1.Ingress) []string {
+	value, ok := s.getIngressClassParameters(ing)[annotationPrefixesKey]
+	if !ok {
+		return nil
+	}
+
+	prefixes := []string{}
+	for _, prefix := range strings.Split(value, ",") {
+		prefix = strings.TrimSuffix(strings.TrimSpace(prefix), "/")
+		if prefix != "" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+
+	return prefixes
+}
+
+// GetIngressAnnotations returns the annotations of an Ingress as parsed by the
+// controller, where the annotations using the prefixes accepted by its
+// IngressClass are also present with the prefix of the controller
+func (s *k8sStore) GetIngressAnnotations(ing *networkingv1.Ingress) map[string]string {
+	return aliasAnnotationPrefixes(ing.Annotations, s.GetAnnotationPrefixes(ing))
+}
+
+// aliasAnnotationPrefixes returns a copy of the annotations where the annotations
+// using any of the accepted prefixes are also present with the prefix of the
+// controller. When an annotation is defined with several prefixes, the value of
+// the first prefix in the list is used. The prefix of the controller has the
+// highest precedence unless it is explicitly included in the list.
+func aliasAnnotationPrefixes(annotations map[string]string, prefixes []string) map[string]string {
+	if len(prefixes) == 0 {
+		return annotations
+	}
+
+	hasControllerPrefix := false
+	for _, prefix := range prefixes {
+		if prefix == parser.AnnotationsPrefix {
+			hasControllerPrefix = true
+			break
+		}
+	}
+
+	if !hasControllerPrefix {
+		prefixes = append([]string{parser.AnnotationsPrefix}, prefixes...)
+	}
+
+	aliased := make(map[string]string, len(annotations))
+	values := map[string]string{}
+	for key, value := range annotations {
+		aliased[key] = value
+	}
+
+	// iterate in reverse order so prefixes with higher precedence override the rest
+	for i := len(prefixes) - 1; i >= 0; i-- {
+		for key, value := range annotations {
+			if name, ok := strings.CutPrefix(key, prefixes[i]+"/"); ok {
+				values[name] = value
+			}
+		}
+	}
+
+	for name, value := range values {
+		aliased[parser.GetAnnotationWithPrefix(name)] = value
+	}
+
+	return aliased
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+func TestAliasAnnotationPrefixes(t *testing.T) {
+	annotations := map[string]string{
+		parser.GetAnnotationWithPrefix("proxy-body-size"): "1m",
+		"acme.com/proxy-body-size":                        "2m",
+		"acme.com/rewrite-target":                         "/",
+		"legacy.acme.com/rewrite-target":                  "/legacy",
+		"legacy.acme.com/ssl-redirect":                    "false",
+		"other.com/enable-cors":                           "true",
+	}
+
+	testCases := []struct {
+		title    string
+		prefixes []string
+		expected map[string]string
+	}{
+		{
+			"without prefixes",
+			nil,
+			map[string]string{
+				parser.GetAnnotationWithPrefix("proxy-body-size"): "1m",
+				"acme.com/rewrite-target":                         "/",
+			},
+		},
+		{
+			"controller prefix has precedence when not listed",
+			[]string{"acme.com", "legacy.acme.com"},
+			map[string]string{
+				parser.GetAnnotationWithPrefix("proxy-body-size"): "1m",
+				parser.GetAnnotationWithPrefix("rewrite-target"):  "/",
+				parser.GetAnnotationWithPrefix("ssl-redirect"):    "false",
+			},
+		},
+		{
+			"order of the list defines the precedence",
+			[]string{"legacy.acme.com", "acme.com", parser.AnnotationsPrefix},
+			map[string]string{
+				parser.GetAnnotationWithPrefix("proxy-body-size"): "2m",
+				parser.GetAnnotationWithPrefix("rewrite-target"):  "/legacy",
+				parser.GetAnnotationWithPrefix("ssl-redirect"):    "false",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			aliased := aliasAnnotationPrefixes(annotations, tc.prefixes)
+			for key, value := range tc.expected {
+				if aliased[key] != value {
+					t.Errorf("expected %v=%v but %v returned", key, value, aliased[key])
+				}
+			}
+
+			if _, ok := aliased[parser.GetAnnotationWithPrefix("enable-cors")]; ok {
+				t.Errorf("expected annotations with prefixes not accepted to be ignored")
+			}
+
+			if len(annotations) != 6 {
+				t.Errorf("expected the original annotations to not change")
+			}
+		})
+	}
+}
+
+func TestIngressClassParametersKey(t *testing.T) {
+	ns := "ingress-nginx"
+	group := "k8s.example.com"
+
+	testCases := []struct {
+		params   *networkingv1.IngressClassParametersReference
+		expected string
+	}{
+		{nil, ""},
+		{&networkingv1.IngressClassParametersReference{Kind: "ConfigMap", Name: "params"}, ""},
+		{&networkingv1.IngressClassParametersReference{Kind: "ConfigMap", Name: "params", Namespace: &ns}, "ingress-nginx/params"},
+		{&networkingv1.IngressClassParametersReference{APIGroup: &group, Kind: "ConfigMap", Name: "params", Namespace: &ns}, ""},
+		{&networkingv1.IngressClassParametersReference{Kind: "Secret", Name: "params", Namespace: &ns}, ""},
+	}
+
+	for _, tc := range testCases {
+		ic := &networkingv1.IngressClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+			Spec:       networkingv1.IngressClassSpec{Parameters: tc.params},
+		}
+
+		if key := ingressClassParametersKey(ic); key != tc.expected {
+			t.Errorf("expected %q but %q returned", tc.expected, key)
+		}
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+// scheduleAnnotationPrefix is the prefix of the name of the annotations that
+// schedule changes of the value of another annotation of an Ingress, like
+// schedule.canary-weight for the annotation canary-weight
+const scheduleAnnotationPrefix = "schedule."
+
+// scheduledValue is a value of an annotation applied from a time
+type scheduledValue struct {
+	annotation string
+	time       time.Time
+	value      string
+}
+
+// parseSchedule returns the values of an annotation scheduled in a list of
+// time=value entries separated by semicolons or new lines, the time being in
+// the RFC3339 format, sorted by time. The invalid entries are ignored.
+func parseSchedule(annotation, schedule string) []scheduledValue {
+	values := []scheduledValue{}
+	for _, entry := range scheduleEntries(schedule) {
+		value, err := parseScheduleEntry(annotation, entry)
+		if err != nil {
+			klog.Warningf("Ignoring the scheduled value %q of the annotation %v: %v", entry, annotation, err)
+			continue
+		}
+
+		values = append(values, value)
+	}
+
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].time.Before(values[j].time)
+	})
+
+	return values
+}
+
+// scheduleEntries returns the entries of a schedule separated by semicolons or new lines
+func scheduleEntries(schedule string) []string {
+	entries := []string{}
+	for _, entry := range strings.FieldsFunc(schedule, func(r rune) bool { return r == ';' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// parseScheduleEntry parses an entry time=value of the schedule of an annotation
+func parseScheduleEntry(annotation, entry string) (scheduledValue, error) {
+	at, value, found := strings.Cut(entry, "=")
+	if !found {
+		return scheduledValue{}, fmt.Errorf("expected time=value")
+	}
+
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(at))
+	if err != nil {
+		return scheduledValue{}, err
+	}
+
+	return scheduledValue{annotation: annotation, time: t, value: strings.TrimSpace(value)}, nil
+}
+
+// ValidateAnnotationSchedules returns an error when an entry of a
+// schedule.<annotation> annotation is invalid, or when validate returns an
+// error for the annotations with one of the scheduled values applied
+func ValidateAnnotationSchedules(annotations map[string]string, validate func(map[string]string) error) error {
+	keys := make([]string, 0, len(annotations))
+	for key := range annotations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name, ok := strings.CutPrefix(key, parser.GetAnnotationWithPrefix(scheduleAnnotationPrefix))
+		if !ok || name == "" {
+			continue
+		}
+
+		for _, entry := range scheduleEntries(annotations[key]) {
+			value, err := parseScheduleEntry(name, entry)
+			if err != nil {
+				return fmt.Errorf("annotation %v contains the invalid scheduled value %q: %w", key, entry, err)
+			}
+
+			scheduled := make(map[string]string, len(annotations))
+			for k, v := range annotations {
+				scheduled[k] = v
+			}
+			scheduled[parser.GetAnnotationWithPrefix(name)] = value.value
+
+			if err := validate(scheduled); err != nil {
+				return fmt.Errorf("annotation %v contains the invalid value %q scheduled at %v: %w",
+					key, value.value, value.time.Format(time.RFC3339), err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyAnnotationSchedules returns a copy of the annotations where the
+// annotations with a schedule have the last value scheduled before now, the
+// applied values and the time of the next scheduled value, or the zero time
+func applyAnnotationSchedules(annotations map[string]string, now time.Time) (map[string]string, []scheduledValue, time.Time) {
+	var applied []scheduledValue
+	var next time.Time
+
+	scheduled := annotations
+	for key, schedule := range annotations {
+		name, ok := strings.CutPrefix(key, parser.GetAnnotationWithPrefix(scheduleAnnotationPrefix))
+		if !ok || name == "" {
+			continue
+		}
+
+		var current *scheduledValue
+		for _, value := range parseSchedule(name, schedule) {
+			if value.time.After(now) {
+				if next.IsZero() || value.time.Before(next) {
+					next = value.time
+				}
+				break
+			}
+			current = &value
+		}
+
+		if current == nil {
+			continue
+		}
+
+		if len(applied) == 0 {
+			scheduled = make(map[string]string, len(annotations))
+			for k, v := range annotations {
+				scheduled[k] = v
+			}
+		}
+
+		scheduled[parser.GetAnnotationWithPrefix(name)] = current.value
+		applied = append(applied, *current)
+	}
+
+	return scheduled, applied, next
+}
+
+// scheduleIngressSync synchronizes an Ingress again at the time of its next
+// scheduled annotation value, replacing the previous schedule
+func (s *k8sStore) scheduleIngressSync(key string, next time.Time) {
+	s.scheduleMu.Lock()
+	defer s.scheduleMu.Unlock()
+
+	if timer, ok := s.scheduleTimers[key]; ok {
+		timer.Stop()
+		delete(s.scheduleTimers, key)
+	}
+
+	if next.IsZero() {
+		return
+	}
+
+	if s.scheduleTimers == nil {
+		s.scheduleTimers = map[string]*time.Timer{}
+	}
+
+	klog.V(3).InfoS("Scheduling the next annotation change", "ingress", key, "time", next)
+	s.scheduleTimers[key] = time.AfterFunc(time.Until(next), func() {
+		s.applyScheduledChanges(key, next)
+	})
+}
+
+// applyScheduledChanges synchronizes an Ingress whose scheduled annotation
+// values apply from a time, recording an event for each applied value
+func (s *k8sStore) applyScheduledChanges(key string, at time.Time) {
+	if _, err := s.listers.IngressWithAnnotation.ByKey(key); err != nil {
+		klog.V(3).InfoS("Ignoring the scheduled annotation change of a removed ingress", "ingress", key)
+		return
+	}
+
+	ing, err := s.listers.Ingress.ByKey(key)
+	if err != nil {
+		klog.V(3).InfoS("Ignoring the scheduled annotation change of a removed ingress", "ingress", key)
+		return
+	}
+
+	_, applied, _ := applyAnnotationSchedules(s.GetIngressAnnotations(ing), time.Now())
+	for _, value := range applied {
+		if value.time.Before(at) {
+			continue
+		}
+
+		klog.InfoS("Applying the scheduled annotation value", "ingress", key, "annotation", value.annotation, "value", value.value)
+		s.recorder.Eventf(ing, corev1.EventTypeNormal, "ScheduledChange", "Annotation %v set to %q as scheduled at %v",
+			value.annotation, value.value, value.time.Format(time.RFC3339))
+	}
+
+	s.syncIngress(ing)
+
+	s.updateCh.In() <- Event{
+		Type: UpdateEvent,
+		Obj:  ing,
+	}
+}
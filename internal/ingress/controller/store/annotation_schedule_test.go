@@ -0,0 +1,155 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+func TestParseSchedule(t *testing.T) {
+	values := parseSchedule("canary-weight", `2026-10-17T03:00:00Z=100; 2026-10-17T02:00:00+02:00 = 50
+invalid;2026-10-17=10`)
+	if len(values) != 2 {
+		t.Fatalf("expected 2 scheduled values but %v returned", len(values))
+	}
+
+	if values[0].value != "50" || !values[0].time.Equal(time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("expected the first value 50 at midnight UTC but %q at %v returned", values[0].value, values[0].time)
+	}
+	if values[1].value != "100" || values[1].annotation != "canary-weight" {
+		t.Errorf("expected the second value 100 of canary-weight but %q of %v returned", values[1].value, values[1].annotation)
+	}
+}
+
+func TestApplyAnnotationSchedules(t *testing.T) {
+	weight := parser.GetAnnotationWithPrefix("canary-weight")
+	backend := parser.GetAnnotationWithPrefix("split-horizon-internal-backend")
+	annotations := map[string]string{
+		weight: "10",
+		parser.GetAnnotationWithPrefix("schedule.canary-weight"):                  "2026-10-17T02:00:00Z=50;2026-10-17T03:00:00Z=100",
+		parser.GetAnnotationWithPrefix("schedule.split-horizon-internal-backend"): "2026-10-18T00:00:00Z=api-v2",
+	}
+
+	testCases := []struct {
+		title   string
+		now     time.Time
+		weight  string
+		backend string
+		applied int
+		next    time.Time
+	}{
+		{
+			"before the schedules",
+			time.Date(2026, 10, 17, 1, 0, 0, 0, time.UTC),
+			"10",
+			"",
+			0,
+			time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC),
+		},
+		{
+			"at a scheduled time",
+			time.Date(2026, 10, 17, 2, 0, 0, 0, time.UTC),
+			"50",
+			"",
+			1,
+			time.Date(2026, 10, 17, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			"between the schedules of several annotations",
+			time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+			"100",
+			"",
+			1,
+			time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			"after the schedules",
+			time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+			"100",
+			"api-v2",
+			2,
+			time.Time{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			scheduled, applied, next := applyAnnotationSchedules(annotations, tc.now)
+			if scheduled[weight] != tc.weight {
+				t.Errorf("expected the weight %q but %q returned", tc.weight, scheduled[weight])
+			}
+			if scheduled[backend] != tc.backend {
+				t.Errorf("expected the backend %q but %q returned", tc.backend, scheduled[backend])
+			}
+			if len(applied) != tc.applied {
+				t.Errorf("expected %v applied values but %v returned", tc.applied, len(applied))
+			}
+			if !next.Equal(tc.next) {
+				t.Errorf("expected the next change at %v but %v returned", tc.next, next)
+			}
+		})
+	}
+
+	if annotations[weight] != "10" {
+		t.Errorf("expected the annotations to be left unchanged but the weight %q returned", annotations[weight])
+	}
+}
+
+func TestValidateAnnotationSchedules(t *testing.T) {
+	weight := parser.GetAnnotationWithPrefix("canary-weight")
+	validate := func(annotations map[string]string) error {
+		if annotations[weight] == "invalid" {
+			return fmt.Errorf("invalid weight")
+		}
+		return nil
+	}
+
+	testCases := []struct {
+		title    string
+		schedule string
+		valid    bool
+	}{
+		{"valid values", "2026-10-17T02:00:00Z=50;2026-10-17T03:00:00Z=100", true},
+		{"entry without value", "2026-10-17T02:00:00Z=50;2026-10-17T03:00:00Z", false},
+		{"invalid time", "2026-10-17=50", false},
+		{"invalid value", "2026-10-17T02:00:00Z=50;2026-10-17T03:00:00Z=invalid", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			annotations := map[string]string{
+				weight: "10",
+				parser.GetAnnotationWithPrefix("schedule.canary-weight"): tc.schedule,
+			}
+
+			err := ValidateAnnotationSchedules(annotations, validate)
+			if tc.valid && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if !tc.valid && err == nil {
+				t.Errorf("expected an error but none returned")
+			}
+			if annotations[weight] != "10" {
+				t.Errorf("expected the annotations to be left unchanged but the weight %q returned", annotations[weight])
+			}
+		})
+	}
+}
@@ -33,9 +33,27 @@ import (
 	"k8s.io/ingress-nginx/pkg/util/file"
 )
 
+const (
+	// alternateCertKey and alternateKeyKey are the keys of the alternate
+	// certificate and key of a TLS Secret, using a different key type
+	alternateCertKey = "tls-alternate.crt"
+	alternateKeyKey  = "tls-alternate.key" //#nosec G101
+)
+
 // syncSecret synchronizes the content of a TLS Secret (certificate(s), secret
 // key) with the filesystem. The resulting files can be used by NGINX.
 func (s *k8sStore) syncSecret(key string) {
+	if s.syncLocalSSLCert(key) {
+		// this update must trigger an update
+		// (like an update event from a change in Ingress)
+		s.sendDummyEvent()
+	}
+}
+
+// syncLocalSSLCert adds or updates the local copy of the certificate
+// contained in the Secret matching key. It returns true when the local
+// store was changed.
+func (s *k8sStore) syncLocalSSLCert(key string) bool {
 	s.syncSecretMu.Lock()
 	defer s.syncSecretMu.Unlock()
 
@@ -46,7 +64,7 @@ func (s *k8sStore) syncSecret(key string) {
 		if !isErrSecretForAuth(err) {
 			klog.Warningf("Error obtaining X.509 certificate: %v", err)
 		}
-		return
+		return false
 	}
 
 	// create certificates and add or update the item in the store
@@ -54,21 +72,16 @@ func (s *k8sStore) syncSecret(key string) {
 	if err == nil {
 		if cur.Equal(cert) {
 			// no need to update
-			return
+			return false
 		}
 		klog.InfoS("Updating secret in local store", "name", key)
 		s.sslStore.Update(key, cert)
-		// this update must trigger an update
-		// (like an update event from a change in Ingress)
-		s.sendDummyEvent()
-		return
+		return true
 	}
 
 	klog.InfoS("Adding secret to local store", "name", key)
 	s.sslStore.Add(key, cert)
-	// this update must trigger an update
-	// (like an update event from a change in Ingress)
-	s.sendDummyEvent()
+	return true
 }
 
 // getPemCertificate receives a secret, and creates a ingress.SSLCert as return.
@@ -106,6 +119,15 @@ func (s *k8sStore) getPemCertificate(secretName string) (*ingress.SSLCert, error
 			return nil, fmt.Errorf("unexpected error creating SSL Cert: %v", err)
 		}
 
+		altCert, okAltCert := secret.Data[alternateCertKey]
+		altKey, okAltKey := secret.Data[alternateKeyKey]
+		if okAltCert || okAltKey {
+			err = ssl.AddAlternateCertificate(sslCert, altCert, altKey)
+			if err != nil {
+				klog.Warningf("Ignoring the alternate certificate of Secret %q: %v", secretName, err)
+			}
+		}
+
 		if len(ca) > 0 {
 			caCert, err := ssl.CheckCACert(ca)
 			if err != nil {
@@ -186,6 +208,15 @@ func (s *k8sStore) getPemCertificate(secretName string) (*ingress.SSLCert, error
 		}
 
 		sslCert.PemFileName = path
+
+		if sslCert.AlternatePemCertKey != "" {
+			path, err := ssl.StoreAlternateSSLCertOnDisk(nsSecName, sslCert)
+			if err != nil {
+				return nil, fmt.Errorf("storing default alternate SSL Certificate: %w", err)
+			}
+
+			sslCert.AlternatePemFileName = path
+		}
 	}
 
 	return sslCert, nil
@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"k8s.io/klog/v2"
+
+	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+const (
+	// forwardedHeadersModeKey is the key of the parameters of an IngressClass
+	// defining how the forwarded headers of its Ingresses are computed
+	forwardedHeadersModeKey = "forwarded-headers-mode"
+
+	// forwardedSchemeOverrideKey is the key of the parameters of an IngressClass
+	// defining the scheme of the connections of the clients of its Ingresses
+	forwardedSchemeOverrideKey = "forwarded-scheme-override"
+)
+
+// parseForwardedHeaders returns the configuration of the forwarded headers
+// defined in the parameters of an IngressClass, ignoring the invalid values
+func parseForwardedHeaders(params map[string]string) ingress.ForwardedHeaders {
+	forwardedHeaders := ingress.ForwardedHeaders{}
+
+	if mode := params[forwardedHeadersModeKey]; mode != "" {
+		if ngx_config.IsValidForwardedHeadersMode(mode) {
+			forwardedHeaders.Mode = mode
+		} else {
+			klog.Warningf("%v is not a valid mode of the forwarded headers, valid values are trust, overwrite or append", mode)
+		}
+	}
+
+	if scheme := params[forwardedSchemeOverrideKey]; scheme != "" {
+		if ngx_config.IsValidForwardedScheme(scheme) {
+			forwardedHeaders.SchemeOverride = scheme
+		} else {
+			klog.Warningf("%v is not a valid scheme, valid values are http or https", scheme)
+		}
+	}
+
+	return forwardedHeaders
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestParseForwardedHeaders(t *testing.T) {
+	testCases := []struct {
+		title    string
+		params   map[string]string
+		expected ingress.ForwardedHeaders
+	}{
+		{"without parameters", nil, ingress.ForwardedHeaders{}},
+		{"without forwarded headers", map[string]string{annotationPrefixesKey: "acme.com"}, ingress.ForwardedHeaders{}},
+		{
+			"mode and scheme",
+			map[string]string{forwardedHeadersModeKey: "append", forwardedSchemeOverrideKey: "https"},
+			ingress.ForwardedHeaders{Mode: "append", SchemeOverride: "https"},
+		},
+		{
+			"invalid values",
+			map[string]string{forwardedHeadersModeKey: "replace", forwardedSchemeOverrideKey: "HTTPS"},
+			ingress.ForwardedHeaders{},
+		},
+	}
+
+	for _, tc := range testCases {
+		if actual := parseForwardedHeaders(tc.params); actual != tc.expected {
+			t.Errorf("%s: expected %+v but %+v returned", tc.title, tc.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	networkingv1 "k8s.io/api/networking/v1"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// Reasons of the Ingresses seen by the controller but not configured, or
+// configured with their locations denied
+const (
+	// IgnoredReasonIngressClass is the reason of the Ingresses of another class
+	IgnoredReasonIngressClass = "IngressClass"
+	// IgnoredReasonCatchAll is the reason of the catch-all Ingresses ignored
+	// with the flag --disable-catch-all
+	IgnoredReasonCatchAll = "CatchAll"
+	// IgnoredReasonDeepInspection is the reason of the Ingresses rejected by
+	// the deep inspection
+	IgnoredReasonDeepInspection = "DeepInspection"
+	// IgnoredReasonInvalidAnnotations is the reason of the Ingresses whose
+	// annotations cannot be parsed
+	IgnoredReasonInvalidAnnotations = "InvalidAnnotations"
+	// IgnoredReasonDeniedLocations is the reason of the Ingresses whose
+	// locations are denied because of an invalid annotation
+	IgnoredReasonDeniedLocations = "DeniedLocations"
+)
+
+// IgnoredIngress is an Ingress seen by the controller but not configured, or
+// configured with its locations denied
+type IgnoredIngress struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Reason is the reason the Ingress is ignored, like IngressClass
+	Reason string `json:"reason"`
+	// Message describes the error that made the controller ignore the Ingress
+	Message string `json:"message"`
+	// Since is the time the Ingress is ignored for the reason
+	Since time.Time `json:"since"`
+}
+
+// ignoredIngresses contains the Ingresses ignored by the controller
+// indexed by namespace/name
+type ignoredIngresses struct {
+	mu        sync.RWMutex
+	ingresses map[string]IgnoredIngress
+}
+
+func newIgnoredIngresses() *ignoredIngresses {
+	return &ignoredIngresses{
+		ingresses: map[string]IgnoredIngress{},
+	}
+}
+
+// ignore records an Ingress ignored for a reason, keeping the time it is
+// ignored since when the reason does not change
+func (ii *ignoredIngresses) ignore(ing *networkingv1.Ingress, reason, message string) {
+	key := k8s.MetaNamespaceKey(ing)
+
+	ii.mu.Lock()
+	defer ii.mu.Unlock()
+
+	if ignored, ok := ii.ingresses[key]; ok && ignored.Reason == reason && ignored.Message == message {
+		return
+	}
+
+	ii.ingresses[key] = IgnoredIngress{
+		Namespace: ing.Namespace,
+		Name:      ing.Name,
+		Reason:    reason,
+		Message:   message,
+		Since:     time.Now(),
+	}
+}
+
+// forget removes an Ingress configured or deleted
+func (ii *ignoredIngresses) forget(ing *networkingv1.Ingress) {
+	key := k8s.MetaNamespaceKey(ing)
+
+	ii.mu.Lock()
+	defer ii.mu.Unlock()
+
+	delete(ii.ingresses, key)
+}
+
+// list returns the ignored Ingresses sorted by namespace and name
+func (ii *ignoredIngresses) list() []IgnoredIngress {
+	ii.mu.RLock()
+	defer ii.mu.RUnlock()
+
+	ignored := make([]IgnoredIngress, 0, len(ii.ingresses))
+	for _, ing := range ii.ingresses {
+		ignored = append(ignored, ing)
+	}
+
+	sort.Slice(ignored, func(i, j int) bool {
+		if ignored[i].Namespace != ignored[j].Namespace {
+			return ignored[i].Namespace < ignored[j].Namespace
+		}
+		return ignored[i].Name < ignored[j].Name
+	})
+
+	return ignored
+}
+
+// ListIgnoredIngresses returns the Ingresses seen by the controller but not
+// configured, or configured with their locations denied
+func (s *k8sStore) ListIgnoredIngresses() []IgnoredIngress {
+	return s.ignoredIngresses.list()
+}
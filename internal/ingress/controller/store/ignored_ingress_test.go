@@ -0,0 +1,58 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestIgnoredIngresses(t *testing.T) {
+	newIngress := func(namespace, name string) *networkingv1.Ingress {
+		return &networkingv1.Ingress{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+
+	ii := newIgnoredIngresses()
+	ii.ignore(newIngress("web", "shop"), IgnoredReasonDeniedLocations, "invalid auth-url")
+	ii.ignore(newIngress("api", "orders"), IgnoredReasonIngressClass, "ingress does not contain a valid IngressClass")
+
+	ignored := ii.list()
+	if len(ignored) != 2 {
+		t.Fatalf("expected 2 ignored Ingresses but %v returned", len(ignored))
+	}
+	if ignored[0].Namespace != "api" || ignored[1].Name != "shop" {
+		t.Errorf("expected the Ingresses sorted by namespace and name but %+v returned", ignored)
+	}
+
+	since := ignored[1].Since
+	ii.ignore(newIngress("web", "shop"), IgnoredReasonDeniedLocations, "invalid auth-url")
+	if again := ii.list()[1]; !again.Since.Equal(since) {
+		t.Errorf("expected the time an Ingress is ignored since to be kept for the same reason but %v returned", again.Since)
+	}
+
+	ii.ignore(newIngress("web", "shop"), IgnoredReasonInvalidAnnotations, "forbidden value")
+	if changed := ii.list()[1]; changed.Reason != IgnoredReasonInvalidAnnotations || changed.Message != "forbidden value" {
+		t.Errorf("expected the reason of the Ingress to be updated but %+v returned", changed)
+	}
+
+	ii.forget(newIngress("api", "orders"))
+	if ignored := ii.list(); len(ignored) != 1 || ignored[0].Name != "shop" {
+		t.Errorf("expected only the Ingress web/shop once the other one is forgotten but %+v returned", ignored)
+	}
+}
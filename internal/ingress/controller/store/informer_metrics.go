@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+	klog "k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/metric"
+)
+
+// resources returns the informers that are enabled indexed by resource type
+func (i *Informer) resources() map[string]cache.SharedIndexInformer {
+	informers := map[string]cache.SharedIndexInformer{
+		"ingresses":      i.Ingress,
+		"ingressclasses": i.IngressClass,
+		"endpointslices": i.EndpointSlice,
+		"services":       i.Service,
+		"secrets":        i.Secret,
+		"configmaps":     i.ConfigMap,
+		"namespaces":     i.Namespace,
+	}
+
+	for resource, informer := range informers {
+		if informer == nil {
+			delete(informers, resource)
+		}
+	}
+
+	return informers
+}
+
+// instrumentInformers configures the informers to report the restarts of
+// watches and the latency of the events received from the API server.
+// It must be called before the informers are started.
+func instrumentInformers(i *Informer, mc metric.Collector) {
+	for resource, informer := range i.resources() {
+		resource := resource
+		mc.SetInformerSynced(resource, false)
+
+		err := informer.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			mc.IncInformerWatchRestartCount(resource)
+			cache.DefaultWatchErrorHandler(r, err)
+		})
+		if err != nil {
+			klog.Errorf("Error setting watch error handler of %v: %v", resource, err)
+		}
+
+		_, err = informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc: func(obj interface{}, isInInitialList bool) {
+				// objects of the initial list were not changed recently
+				if !isInInitialList {
+					observeEventLatency(mc, resource, obj)
+				}
+			},
+			UpdateFunc: func(old, cur interface{}) {
+				if isResync(old, cur) {
+					return
+				}
+
+				observeEventLatency(mc, resource, cur)
+			},
+		})
+		if err != nil {
+			klog.Errorf("Error adding metrics event handler of %v: %v", resource, err)
+		}
+	}
+}
+
+// observeEventLatency records the time elapsed since the last change of obj
+func observeEventLatency(mc metric.Collector, resource string, obj interface{}) {
+	last := lastChangeTime(obj)
+	if last.IsZero() {
+		return
+	}
+
+	// the clocks of the API server and the controller can differ
+	latency := time.Since(last)
+	if latency < 0 {
+		latency = 0
+	}
+
+	mc.ObserveInformerEventLatency(resource, latency)
+}
+
+// lastChangeTime returns the most recent time in the metadata of an object.
+// The time of the managed fields has a precision of one second.
+func lastChangeTime(obj interface{}) time.Time {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return time.Time{}
+	}
+
+	last := accessor.GetCreationTimestamp().Time
+	for _, field := range accessor.GetManagedFields() {
+		if field.Time != nil && field.Time.After(last) {
+			last = field.Time.Time
+		}
+	}
+
+	if deletion := accessor.GetDeletionTimestamp(); deletion != nil && deletion.After(last) {
+		last = deletion.Time
+	}
+
+	return last
+}
+
+// isResync checks if an update event was generated by the periodic resync
+// of the informer instead of a change in the API server
+func isResync(old, cur interface{}) bool {
+	oldAccessor, err := meta.Accessor(old)
+	if err != nil {
+		return false
+	}
+
+	curAccessor, err := meta.Accessor(cur)
+	if err != nil {
+		return false
+	}
+
+	return oldAccessor.GetResourceVersion() == curAccessor.GetResourceVersion()
+}
+
+// HasSynced checks if the informers of all the enabled resources are synchronized
+func (i *Informer) HasSynced() bool {
+	for _, informer := range i.resources() {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// setInformersSynced updates the synchronization status of the informers
+func setInformersSynced(i *Informer, mc metric.Collector) {
+	for resource, informer := range i.resources() {
+		mc.SetInformerSynced(resource, informer.HasSynced())
+	}
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLastChangeTime(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	deleted := updated.Add(time.Hour)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			CreationTimestamp: metav1.NewTime(created),
+		},
+	}
+
+	if last := lastChangeTime(cm); !last.Equal(created) {
+		t.Errorf("expected %v but %v returned", created, last)
+	}
+
+	cm.ManagedFields = []metav1.ManagedFieldsEntry{
+		{Manager: "kubectl", Time: &metav1.Time{Time: updated}},
+		{Manager: "kube-controller-manager", Time: &metav1.Time{Time: created}},
+		{Manager: "unknown"},
+	}
+
+	if last := lastChangeTime(cm); !last.Equal(updated) {
+		t.Errorf("expected %v but %v returned", updated, last)
+	}
+
+	cm.DeletionTimestamp = &metav1.Time{Time: deleted}
+	if last := lastChangeTime(cm); !last.Equal(deleted) {
+		t.Errorf("expected %v but %v returned", deleted, last)
+	}
+
+	if last := lastChangeTime("invalid"); !last.IsZero() {
+		t.Errorf("expected zero time but %v returned", last)
+	}
+}
+
+func TestIsResync(t *testing.T) {
+	old := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"}}
+	cur := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "1"}}
+
+	if !isResync(old, cur) {
+		t.Errorf("expected resync with the same resource version")
+	}
+
+	cur.ResourceVersion = "2"
+	if isResync(old, cur) {
+		t.Errorf("expected update with a different resource version")
+	}
+}
@@ -0,0 +1,333 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newNamespacedInformer returns the informer created by newInformer when at
+// most one namespace is watched, or an informer aggregating the informers of
+// each namespace otherwise
+func newNamespacedInformer(namespaces []string, newInformer func(namespace string) cache.SharedIndexInformer) cache.SharedIndexInformer {
+	if len(namespaces) == 0 {
+		return newInformer("")
+	}
+
+	if len(namespaces) == 1 {
+		return newInformer(namespaces[0])
+	}
+
+	informer := &multiNamespaceInformer{
+		namespaces: namespaces,
+		informers:  make(map[string]cache.SharedIndexInformer, len(namespaces)),
+	}
+
+	stores := make(map[string]cache.Store, len(namespaces))
+	for _, namespace := range namespaces {
+		informer.informers[namespace] = newInformer(namespace)
+		stores[namespace] = informer.informers[namespace].GetStore()
+	}
+
+	informer.store = &multiNamespaceStore{
+		namespaces: namespaces,
+		stores:     stores,
+	}
+
+	// the methods not defined in multiNamespaceInformer, like GetIndexer,
+	// return the information of the informer of the first namespace
+	informer.SharedIndexInformer = informer.informers[namespaces[0]]
+
+	return informer
+}
+
+// multiNamespaceInformer is a cache.SharedIndexInformer that aggregates
+// the informers of a resource in several namespaces
+type multiNamespaceInformer struct {
+	cache.SharedIndexInformer
+
+	namespaces []string
+	informers  map[string]cache.SharedIndexInformer
+	store      *multiNamespaceStore
+}
+
+// multiNamespaceRegistration contains the registrations of an event handler
+// in the informers of each namespace
+type multiNamespaceRegistration map[string]cache.ResourceEventHandlerRegistration
+
+// HasSynced implements cache.ResourceEventHandlerRegistration
+func (r multiNamespaceRegistration) HasSynced() bool {
+	for _, registration := range r {
+		if !registration.HasSynced() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// AddEventHandler adds the handler to the informers of all the namespaces
+func (i *multiNamespaceInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	return i.addEventHandler(func(informer cache.SharedIndexInformer) (cache.ResourceEventHandlerRegistration, error) {
+		return informer.AddEventHandler(handler)
+	})
+}
+
+// AddEventHandlerWithResyncPeriod adds the handler to the informers of all the namespaces
+func (i *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, resyncPeriod time.Duration) (cache.ResourceEventHandlerRegistration, error) {
+	return i.addEventHandler(func(informer cache.SharedIndexInformer) (cache.ResourceEventHandlerRegistration, error) {
+		return informer.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	})
+}
+
+func (i *multiNamespaceInformer) addEventHandler(add func(cache.SharedIndexInformer) (cache.ResourceEventHandlerRegistration, error)) (cache.ResourceEventHandlerRegistration, error) {
+	registration := make(multiNamespaceRegistration, len(i.informers))
+	for namespace, informer := range i.informers {
+		r, err := add(informer)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %v: %w", namespace, err)
+		}
+
+		registration[namespace] = r
+	}
+
+	return registration, nil
+}
+
+// RemoveEventHandler removes the handler from the informers of all the namespaces
+func (i *multiNamespaceInformer) RemoveEventHandler(handle cache.ResourceEventHandlerRegistration) error {
+	registration, ok := handle.(multiNamespaceRegistration)
+	if !ok {
+		return fmt.Errorf("unexpected event handler registration %T", handle)
+	}
+
+	var errs []error
+	for namespace, r := range registration {
+		if err := i.informers[namespace].RemoveEventHandler(r); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// GetStore returns a store containing the objects of all the namespaces
+func (i *multiNamespaceInformer) GetStore() cache.Store {
+	return i.store
+}
+
+// Run starts the informers of all the namespaces
+func (i *multiNamespaceInformer) Run(stopCh <-chan struct{}) {
+	for _, informer := range i.informers {
+		go informer.Run(stopCh)
+	}
+
+	<-stopCh
+}
+
+// HasSynced checks if the informers of all the namespaces are synced
+func (i *multiNamespaceInformer) HasSynced() bool {
+	for _, informer := range i.informers {
+		if !informer.HasSynced() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsStopped checks if the informers of all the namespaces are stopped
+func (i *multiNamespaceInformer) IsStopped() bool {
+	for _, informer := range i.informers {
+		if !informer.IsStopped() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// SetWatchErrorHandler sets the handler in the informers of all the namespaces
+func (i *multiNamespaceInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	for _, informer := range i.informers {
+		if err := informer.SetWatchErrorHandler(handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetTransform sets the transform function in the informers of all the namespaces
+func (i *multiNamespaceInformer) SetTransform(handler cache.TransformFunc) error {
+	for _, informer := range i.informers {
+		if err := informer.SetTransform(handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddIndexers adds the indexers to the informers of all the namespaces
+func (i *multiNamespaceInformer) AddIndexers(indexers cache.Indexers) error {
+	for _, informer := range i.informers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// multiNamespaceStore is a cache.Store that aggregates the stores of the
+// informers of a resource in several namespaces
+type multiNamespaceStore struct {
+	namespaces []string
+	stores     map[string]cache.Store
+}
+
+// storeOf returns the store of the namespace of an object
+func (s *multiNamespaceStore) storeOf(obj interface{}) (cache.Store, error) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	store, ok := s.stores[accessor.GetNamespace()]
+	if !ok {
+		return nil, fmt.Errorf("namespace %q is not watched", accessor.GetNamespace())
+	}
+
+	return store, nil
+}
+
+// Add adds the object to the store of its namespace
+func (s *multiNamespaceStore) Add(obj interface{}) error {
+	store, err := s.storeOf(obj)
+	if err != nil {
+		return err
+	}
+
+	return store.Add(obj)
+}
+
+// Update updates the object in the store of its namespace
+func (s *multiNamespaceStore) Update(obj interface{}) error {
+	store, err := s.storeOf(obj)
+	if err != nil {
+		return err
+	}
+
+	return store.Update(obj)
+}
+
+// Delete deletes the object from the store of its namespace
+func (s *multiNamespaceStore) Delete(obj interface{}) error {
+	store, err := s.storeOf(obj)
+	if err != nil {
+		return err
+	}
+
+	return store.Delete(obj)
+}
+
+// List returns the objects of all the namespaces
+func (s *multiNamespaceStore) List() []interface{} {
+	list := []interface{}{}
+	for _, namespace := range s.namespaces {
+		list = append(list, s.stores[namespace].List()...)
+	}
+
+	return list
+}
+
+// ListKeys returns the keys of the objects of all the namespaces
+func (s *multiNamespaceStore) ListKeys() []string {
+	keys := []string{}
+	for _, namespace := range s.namespaces {
+		keys = append(keys, s.stores[namespace].ListKeys()...)
+	}
+
+	return keys
+}
+
+// Get returns the object from the store of its namespace
+func (s *multiNamespaceStore) Get(obj interface{}) (item interface{}, exists bool, err error) {
+	store, err := s.storeOf(obj)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return store.Get(obj)
+}
+
+// GetByKey returns the object matching the key from the store of its namespace
+func (s *multiNamespaceStore) GetByKey(key string) (item interface{}, exists bool, err error) {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	store, ok := s.stores[namespace]
+	if !ok {
+		return nil, false, nil
+	}
+
+	return store.GetByKey(key)
+}
+
+// Replace replaces the content of the store of each namespace
+func (s *multiNamespaceStore) Replace(list []interface{}, resourceVersion string) error {
+	byNamespace := make(map[string][]interface{}, len(s.namespaces))
+	for _, obj := range list {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return err
+		}
+
+		byNamespace[accessor.GetNamespace()] = append(byNamespace[accessor.GetNamespace()], obj)
+	}
+
+	for _, namespace := range s.namespaces {
+		if err := s.stores[namespace].Replace(byNamespace[namespace], resourceVersion); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Resync resyncs the store of each namespace
+func (s *multiNamespaceStore) Resync() error {
+	for _, namespace := range s.namespaces {
+		if err := s.stores[namespace].Resync(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
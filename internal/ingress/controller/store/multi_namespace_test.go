@@ -0,0 +1,85 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package store
+
+import (
+	"sort"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestMultiNamespaceStore(t *testing.T) {
+	namespaces := []string{"team-a", "team-b"}
+	s := &multiNamespaceStore{
+		namespaces: namespaces,
+		stores: map[string]cache.Store{
+			"team-a": cache.NewStore(cache.MetaNamespaceKeyFunc),
+			"team-b": cache.NewStore(cache.MetaNamespaceKeyFunc),
+		},
+	}
+
+	newConfigMap := func(namespace, name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name}}
+	}
+
+	for _, cm := range []*corev1.ConfigMap{newConfigMap("team-a", "a"), newConfigMap("team-b", "b")} {
+		if err := s.Add(cm); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if err := s.Add(newConfigMap("team-c", "c")); err == nil {
+		t.Errorf("expected an error adding an object of a namespace not watched")
+	}
+
+	keys := s.ListKeys()
+	sort.Strings(keys)
+	if len(keys) != 2 || keys[0] != "team-a/a" || keys[1] != "team-b/b" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+
+	if len(s.stores["team-b"].List()) != 1 {
+		t.Errorf("expected the object in the store of its namespace")
+	}
+
+	if _, exists, err := s.GetByKey("team-b/b"); err != nil || !exists {
+		t.Errorf("expected team-b/b to exist (err: %v)", err)
+	}
+
+	if _, exists, err := s.GetByKey("team-c/c"); err != nil || exists {
+		t.Errorf("expected team-c/c to not exist (err: %v)", err)
+	}
+
+	if err := s.Delete(newConfigMap("team-a", "a")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, exists, _ := s.Get(newConfigMap("team-a", "a")); exists {
+		t.Errorf("expected team-a/a to be deleted")
+	}
+
+	if err := s.Replace([]interface{}{newConfigMap("team-a", "x")}, "1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(s.List()) != 1 {
+		t.Errorf("expected only one object after replace but %v returned", len(s.List()))
+	}
+}
@@ -36,6 +36,7 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -49,12 +50,16 @@ import (
 	klog "k8s.io/klog/v2"
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/extrasecrets"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
+	"k8s.io/ingress-nginx/internal/ingress/controller/shard"
 	ngx_template "k8s.io/ingress-nginx/internal/ingress/controller/template"
 	"k8s.io/ingress-nginx/internal/ingress/defaults"
+	"k8s.io/ingress-nginx/internal/ingress/deprecation"
 	"k8s.io/ingress-nginx/internal/ingress/errors"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
 	"k8s.io/ingress-nginx/internal/ingress/resolver"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
@@ -87,9 +92,18 @@ type Storer interface {
 	// ListIngresses returns a list of all Ingresses in the store.
 	ListIngresses() []*ingress.Ingress
 
+	// ListIgnoredIngresses returns the Ingresses seen by the controller but
+	// not configured, or configured with their locations denied.
+	ListIgnoredIngresses() []IgnoredIngress
+
 	// GetLocalSSLCert returns the local copy of a SSLCert
 	GetLocalSSLCert(name string) (*ingress.SSLCert, error)
 
+	// GetSecretHostnames returns the hostnames of the servers using the Secret
+	// matching key as TLS certificate. It returns an empty list if the Secret
+	// is also used in any other way.
+	GetSecretHostnames(key string) []string
+
 	// ListLocalSSLCerts returns the list of local SSLCerts
 	ListLocalSSLCerts() []*ingress.SSLCert
 
@@ -104,8 +118,23 @@ type Storer interface {
 	// Run initiates the synchronization of the controllers
 	Run(stopCh chan struct{})
 
+	// HasSynced checks if the local caches are synchronized with the API server
+	HasSynced() bool
+
+	// GetNamespaceLabels returns the labels of a Namespace. It returns nil
+	// if the Namespaces are not watched by the store.
+	GetNamespaceLabels(name string) map[string]string
+
 	// GetIngressClass validates given ingress against ingress class configuration and returns the ingress class.
 	GetIngressClass(ing *networkingv1.Ingress, icConfig *ingressclass.Configuration) (string, error)
+
+	// GetIngressAnnotations returns the annotations of an Ingress as parsed by the
+	// controller, including the annotations using the prefixes accepted by its IngressClass.
+	GetIngressAnnotations(ing *networkingv1.Ingress) map[string]string
+
+	// GetAnnotationPrefixes returns the annotation prefixes accepted in an Ingress
+	// by its IngressClass, in addition to the prefix of the controller.
+	GetAnnotationPrefixes(ing *networkingv1.Ingress) []string
 }
 
 // EventType type of event associated with an informer
@@ -120,6 +149,8 @@ const (
 	DeleteEvent EventType = "DELETE"
 	// ConfigurationEvent event associated when a controller configuration object is created or updated
 	ConfigurationEvent EventType = "CONFIGURATION"
+	// CertificateEvent event associated when a Secret only used as TLS certificate is updated
+	CertificateEvent EventType = "CERTIFICATE"
 )
 
 // Event holds the context of an event.
@@ -219,6 +250,9 @@ type k8sStore struct {
 	// listers contains the cache.Store interfaces used in the ingress controller
 	listers *Lister
 
+	// icConfig contains the configuration of the IngressClasses watched
+	icConfig *ingressclass.Configuration
+
 	// sslStore local store of SSL certificates (certificates used in ingress)
 	// this is required because the certificates must be present in the
 	// container filesystem
@@ -230,6 +264,10 @@ type k8sStore struct {
 	// secret in the annotations.
 	secretIngressMap ObjectRefMap
 
+	// annotationSecretIngressMap contains the subset of secretIngressMap
+	// referenced from annotations instead of the TLS section.
+	annotationSecretIngressMap ObjectRefMap
+
 	// updateCh
 	updateCh *channels.RingChannel
 
@@ -240,6 +278,27 @@ type k8sStore struct {
 	backendConfigMu *sync.RWMutex
 
 	defaultSSLCertificate string
+
+	// customDomainsConfigMap is the ConfigMap of the custom domains, whose
+	// values reference the Secrets of their certificates
+	customDomainsConfigMap string
+
+	metricCollector metric.Collector
+
+	// recorder emits the events of the synchronized objects
+	recorder record.EventRecorder
+
+	// deprecationLimiter limits the warnings of the deprecated settings to
+	// one per object and setting each day
+	deprecationLimiter *deprecation.Limiter
+
+	// scheduleTimers synchronize the Ingresses at the time of their next
+	// scheduled annotation value, indexed by namespace/name
+	scheduleTimers map[string]*time.Timer
+	scheduleMu     sync.Mutex
+
+	// ignoredIngresses contains the Ingresses seen but not configured
+	ignoredIngresses *ignoredIngresses
 }
 
 // New creates a new object store to be used in the ingress controller.
@@ -248,18 +307,26 @@ type k8sStore struct {
 func New(
 	namespace string,
 	namespaceSelector labels.Selector,
-	configmap, tcp, udp, defaultSSLCertificate string,
+	configmap, tcp, udp, customDomains, defaultSSLCertificate string,
 	resyncPeriod time.Duration,
 	client clientset.Interface,
 	updateCh *channels.RingChannel,
 	disableCatchAll bool,
 	deepInspector bool,
 	icConfig *ingressclass.Configuration,
+	shardConfig *shard.Configuration,
 	disableSyncEvents bool,
+	watchNamespaceLabels bool,
+	mc metric.Collector,
 ) Storer {
+	if mc == nil {
+		mc = metric.NewDummyCollector()
+	}
+
 	store := &k8sStore{
 		informers:             &Informer{},
 		listers:               &Lister{},
+		icConfig:              icConfig,
 		sslStore:              NewSSLCertTracker(),
 		updateCh:              updateCh,
 		backendConfig:         ngx_config.NewDefault(),
@@ -267,18 +334,25 @@ func New(
 		backendConfigMu:       &sync.RWMutex{},
 		secretIngressMap:      NewObjectRefMap(),
 		defaultSSLCertificate: defaultSSLCertificate,
+		metricCollector:       mc,
+
+		annotationSecretIngressMap: NewObjectRefMap(),
+		customDomainsConfigMap:     customDomains,
+		deprecationLimiter:         deprecation.NewLimiter(deprecationWarningInterval),
+		ignoredIngresses:           newIgnoredIngresses(),
 	}
 
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(klog.Infof)
 	if !disableSyncEvents {
 		eventBroadcaster.StartRecordingToSink(&clientcorev1.EventSinkImpl{
-			Interface: client.CoreV1().Events(namespace),
+			Interface: client.CoreV1().Events(k8s.SingleNamespace(namespace)),
 		})
 	}
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{
 		Component: "nginx-ingress-controller",
 	})
+	store.recorder = recorder
 
 	// k8sStore fulfills resolver.Resolver interface
 	store.annotations = annotations.NewAnnotationExtractor(store)
@@ -312,45 +386,49 @@ func New(
 		}
 	}
 
-	// create informers factory, enable and assign required informers
-	infFactory := informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod,
-		informers.WithNamespace(namespace),
-	)
-
-	// create informers factory for configmaps
-	infFactoryConfigmaps := informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod,
-		informers.WithNamespace(namespace),
-		informers.WithTweakListOptions(labelsTweakListOptionsFunc),
-	)
-
-	// create informers factory for secrets
-	infFactorySecrets := informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod,
-		informers.WithNamespace(namespace),
-		informers.WithTweakListOptions(secretsTweakListOptionsFunc),
-	)
+	// create informers factories, enable and assign required informers.
+	// When several namespaces are watched, the informers of each namespace
+	// are aggregated so the listers contain the objects of all of them.
+	namespaces := k8s.ParseNamespaces(namespace)
+	newFactory := func(namespace string, options ...informers.SharedInformerOption) informers.SharedInformerFactory {
+		options = append(options, informers.WithNamespace(namespace))
+		return informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod, options...)
+	}
 
-	store.informers.Ingress = infFactory.Networking().V1().Ingresses().Informer()
+	store.informers.Ingress = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newFactory(namespace).Networking().V1().Ingresses().Informer()
+	})
 	store.listers.Ingress.Store = store.informers.Ingress.GetStore()
 
 	if !icConfig.IgnoreIngressClass {
-		store.informers.IngressClass = infFactory.Networking().V1().IngressClasses().Informer()
+		// IngressClasses are cluster-scoped
+		store.informers.IngressClass = newFactory(corev1.NamespaceAll).Networking().V1().IngressClasses().Informer()
 		store.listers.IngressClass.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
 	}
 
-	store.informers.EndpointSlice = infFactory.Discovery().V1().EndpointSlices().Informer()
+	store.informers.EndpointSlice = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newFactory(namespace).Discovery().V1().EndpointSlices().Informer()
+	})
 	store.listers.EndpointSlice.Store = store.informers.EndpointSlice.GetStore()
 
-	store.informers.Secret = infFactorySecrets.Core().V1().Secrets().Informer()
+	store.informers.Secret = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newFactory(namespace, informers.WithTweakListOptions(secretsTweakListOptionsFunc)).Core().V1().Secrets().Informer()
+	})
 	store.listers.Secret.Store = store.informers.Secret.GetStore()
 
-	store.informers.ConfigMap = infFactoryConfigmaps.Core().V1().ConfigMaps().Informer()
+	store.informers.ConfigMap = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newFactory(namespace, informers.WithTweakListOptions(labelsTweakListOptionsFunc)).Core().V1().ConfigMaps().Informer()
+	})
 	store.listers.ConfigMap.Store = store.informers.ConfigMap.GetStore()
 
-	store.informers.Service = infFactory.Core().V1().Services().Informer()
+	store.informers.Service = newNamespacedInformer(namespaces, func(namespace string) cache.SharedIndexInformer {
+		return newFactory(namespace).Core().V1().Services().Informer()
+	})
 	store.listers.Service.Store = store.informers.Service.GetStore()
 
-	// avoid caching namespaces at cluster scope when watching single namespace
-	if namespaceSelector != nil && !namespaceSelector.Empty() {
+	// avoid caching namespaces at cluster scope when watching single namespace,
+	// unless the labels of the namespaces are used by the configuration
+	if (namespaceSelector != nil && !namespaceSelector.Empty()) || watchNamespaceLabels {
 		// cache informers factory for namespaces
 		infFactoryNamespaces := informers.NewSharedInformerFactoryWithOptions(client, resyncPeriod,
 			informers.WithTweakListOptions(labelsTweakListOptionsFunc),
@@ -394,10 +472,16 @@ func New(
 			}
 		}
 
+		store.ignoredIngresses.forget(ing)
+
 		if !watchedNamespace(ing.Namespace) {
 			return
 		}
 
+		if !shardConfig.Owns(ing) {
+			return
+		}
+
 		_, err := store.GetIngressClass(ing, icConfig)
 		if err != nil {
 			klog.InfoS("Ignoring ingress because of error while validating ingress class", "ingress", klog.KObj(ing), "error", err)
@@ -416,6 +500,7 @@ func New(
 
 		key := k8s.MetaNamespaceKey(ing)
 		store.secretIngressMap.Delete(key)
+		store.annotationSecretIngressMap.Delete(key)
 
 		updateCh.In() <- Event{
 			Type: DeleteEvent,
@@ -431,9 +516,15 @@ func New(
 				return
 			}
 
+			if !shardConfig.Owns(ing) {
+				klog.V(3).InfoS("Ignoring ingress because its hostnames are owned by other shards", "ingress", klog.KObj(ing))
+				return
+			}
+
 			ic, err := store.GetIngressClass(ing, icConfig)
 			if err != nil {
 				klog.InfoS("Ignoring ingress because of error while validating ingress class", "ingress", klog.KObj(ing), "error", err)
+				store.ignoredIngresses.ignore(ing, IgnoredReasonIngressClass, err.Error())
 				return
 			}
 
@@ -442,11 +533,13 @@ func New(
 			if deepInspector {
 				if err := inspector.DeepInspect(ing); err != nil {
 					klog.ErrorS(err, "received invalid ingress", "ingress", klog.KObj(ing))
+					store.ignoredIngresses.ignore(ing, IgnoredReasonDeepInspection, err.Error())
 					return
 				}
 			}
 			if hasCatchAllIngressRule(ing.Spec) && disableCatchAll {
 				klog.InfoS("Ignoring add for catch-all ingress because of --disable-catch-all", "ingress", klog.KObj(ing))
+				store.ignoredIngresses.ignore(ing, IgnoredReasonCatchAll, "catch-all Ingresses are disabled by --disable-catch-all")
 				return
 			}
 
@@ -470,6 +563,14 @@ func New(
 				return
 			}
 
+			if !shardConfig.Owns(curIng) {
+				if shardConfig.Owns(oldIng) {
+					klog.InfoS("removing ingress because its hostnames are owned by other shards", "ingress", klog.KObj(curIng))
+					ingDeleteHandler(old)
+				}
+				return
+			}
+
 			var errOld, errCur error
 			var classCur string
 			if !icConfig.IgnoreIngressClass {
@@ -480,6 +581,7 @@ func New(
 			case errOld != nil && errCur == nil:
 				if hasCatchAllIngressRule(curIng.Spec) && disableCatchAll {
 					klog.InfoS("ignoring update for catch-all ingress because of --disable-catch-all", "ingress", klog.KObj(curIng))
+					store.ignoredIngresses.ignore(curIng, IgnoredReasonCatchAll, "catch-all Ingresses are disabled by --disable-catch-all")
 					return
 				}
 
@@ -488,15 +590,20 @@ func New(
 			case errOld == nil && errCur != nil:
 				klog.InfoS("removing ingress because of unknown ingressclass", "ingress", klog.KObj(curIng))
 				ingDeleteHandler(old)
+				store.ignoredIngresses.ignore(curIng, IgnoredReasonIngressClass, errCur.Error())
 				return
 			case errCur == nil && !reflect.DeepEqual(old, cur):
 				if hasCatchAllIngressRule(curIng.Spec) && disableCatchAll {
 					klog.InfoS("ignoring update for catch-all ingress and delete old one because of --disable-catch-all", "ingress", klog.KObj(curIng))
 					ingDeleteHandler(old)
+					store.ignoredIngresses.ignore(curIng, IgnoredReasonCatchAll, "catch-all Ingresses are disabled by --disable-catch-all")
 					return
 				}
 
 				recorder.Eventf(curIng, corev1.EventTypeNormal, "Sync", "Scheduled for sync")
+			case errCur != nil:
+				store.ignoredIngresses.ignore(curIng, IgnoredReasonIngressClass, errCur.Error())
+				return
 			default:
 				klog.V(3).InfoS("No changes on ingress. Skipping update", "ingress", klog.KObj(curIng))
 				return
@@ -505,6 +612,7 @@ func New(
 			if deepInspector {
 				if err := inspector.DeepInspect(curIng); err != nil {
 					klog.ErrorS(err, "received invalid ingress", "ingress", klog.KObj(curIng))
+					store.ignoredIngresses.ignore(curIng, IgnoredReasonDeepInspection, err.Error())
 					return
 				}
 			}
@@ -586,6 +694,20 @@ func New(
 					klog.InfoS("error updating ingressclass in store", "ingressclass", klog.KObj(cic), "error", err)
 					return
 				}
+
+				// the parameters can change the annotations accepted in the Ingresses
+				for _, ing := range store.listers.IngressWithAnnotation.List() {
+					ingKey := k8s.MetaNamespaceKey(ing)
+					curIng, err := store.getIngress(ingKey)
+					if err != nil {
+						klog.Errorf("could not find Ingress %v in local store: %v", ingKey, err)
+						continue
+					}
+
+					if curIng.Spec.IngressClassName != nil && *curIng.Spec.IngressClassName == cic.Name {
+						store.syncIngress(curIng)
+					}
+				}
 				updateCh.In() <- Event{
 					Type: UpdateEvent,
 					Obj:  cur,
@@ -606,6 +728,14 @@ func New(
 				store.syncSecret(store.defaultSSLCertificate)
 			}
 
+			if store.isCustomDomainSecret(key) {
+				klog.InfoS("Secret was added and it is used in custom domains", "secret", key)
+				updateCh.In() <- Event{
+					Type: CreateEvent,
+					Obj:  obj,
+				}
+			}
+
 			// find references in ingresses and update local ssl certs
 			if ings := store.secretIngressMap.Reference(key); len(ings) > 0 {
 				klog.InfoS("Secret was added and it is used in ingress annotations. Parsing", "secret", key)
@@ -640,6 +770,28 @@ func New(
 					store.syncSecret(store.defaultSSLCertificate)
 				}
 
+				// the certificates of the custom domains are created during the sync
+				if store.isCustomDomainSecret(key) {
+					klog.InfoS("secret was updated and it is used in custom domains", "secret", key)
+					updateCh.In() <- Event{
+						Type: UpdateEvent,
+						Obj:  cur,
+					}
+				}
+
+				// the certificate of Secrets only referenced in TLS sections
+				// can be updated without parsing the ingresses again
+				if hosts := store.GetSecretHostnames(key); len(hosts) > 0 {
+					klog.InfoS("secret was updated and it is only used as TLS certificate", "secret", key, "hosts", hosts)
+					if store.syncLocalSSLCert(key) {
+						updateCh.In() <- Event{
+							Type: CertificateEvent,
+							Obj:  cur,
+						}
+					}
+					return
+				}
+
 				// find references in ingresses and update local ssl certs
 				if ings := store.secretIngressMap.Reference(key); len(ings) > 0 {
 					klog.InfoS("secret was updated and it is used in ingress annotations. Parsing", "secret", key)
@@ -682,6 +834,14 @@ func New(
 
 			key := k8s.MetaNamespaceKey(sec)
 
+			if store.isCustomDomainSecret(key) {
+				klog.InfoS("secret was deleted and it is used in custom domains", "secret", key)
+				updateCh.In() <- Event{
+					Type: DeleteEvent,
+					Obj:  obj,
+				}
+			}
+
 			// find references in ingresses
 			if ings := store.secretIngressMap.Reference(key); len(ings) > 0 {
 				klog.InfoS("secret was deleted and it is used in ingress annotations. Parsing", "secret", key)
@@ -734,7 +894,8 @@ func New(
 	}
 
 	changeTriggerUpdate := func(name string) bool {
-		return name == configmap || name == tcp || name == udp
+		return name == configmap || name == tcp || name == udp || name == customDomains ||
+			store.isIngressClassParameters(name)
 	}
 
 	handleCfgMapEvent := func(key string, cfgMap *corev1.ConfigMap, eventName string) {
@@ -748,17 +909,21 @@ func New(
 			}
 		}
 
+		// the Ingresses referencing the ConfigMap are updated, the data of the
+		// key/value stores of the Lua code is applied without a reload
+		referenced := false
 		ings := store.listers.IngressWithAnnotation.List()
-		for _, ingKey := range ings {
-			key := k8s.MetaNamespaceKey(ingKey)
-			ing, err := store.getIngress(key)
+		for _, ingress := range ings {
+			ingKey := k8s.MetaNamespaceKey(ingress)
+			ing, err := store.getIngress(ingKey)
 			if err != nil {
-				klog.Errorf("could not find Ingress %v in local store: %v", key, err)
+				klog.Errorf("could not find Ingress %v in local store: %v", ingKey, err)
 				continue
 			}
 
-			if parser.AnnotationsReferencesConfigmap(ing) {
+			if parser.AnnotationsReferencesConfigmap(ing, key) {
 				store.syncIngress(ing)
+				referenced = true
 				continue
 			}
 
@@ -767,7 +932,7 @@ func New(
 			}
 		}
 
-		if triggerUpdate {
+		if triggerUpdate || referenced {
 			updateCh.In() <- Event{
 				Type: ConfigurationEvent,
 				Obj:  cfgMap,
@@ -844,6 +1009,31 @@ func New(
 		},
 	}
 
+	// the labels of the namespaces are part of the configuration of the Ingresses
+	namespaceLabelsHandler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, cur interface{}) {
+			oldNs, ok := old.(*corev1.Namespace)
+			if !ok {
+				klog.Errorf("unexpected type: %T", old)
+				return
+			}
+			curNs, ok := cur.(*corev1.Namespace)
+			if !ok {
+				klog.Errorf("unexpected type: %T", cur)
+				return
+			}
+
+			if reflect.DeepEqual(oldNs.Labels, curNs.Labels) {
+				return
+			}
+
+			updateCh.In() <- Event{
+				Type: UpdateEvent,
+				Obj:  cur,
+			}
+		},
+	}
+
 	if _, err := store.informers.Ingress.AddEventHandler(ingEventHandler); err != nil {
 		klog.Errorf("Error adding ingress event handler: %v", err)
 	}
@@ -864,6 +1054,13 @@ func New(
 	if _, err := store.informers.Service.AddEventHandler(serviceHandler); err != nil {
 		klog.Errorf("Error adding service event handler: %v", err)
 	}
+	if watchNamespaceLabels {
+		if _, err := store.informers.Namespace.AddEventHandler(namespaceLabelsHandler); err != nil {
+			klog.Errorf("Error adding namespace event handler: %v", err)
+		}
+	}
+
+	instrumentInformers(store.informers, mc)
 
 	// do not wait for informers to read the configmap configuration
 	ns, name, err := k8s.ParseNameNS(configmap)
@@ -909,9 +1106,14 @@ func (s *k8sStore) syncIngress(ing *networkingv1.Ingress) {
 	copyIng := &networkingv1.Ingress{}
 	ing.ObjectMeta.DeepCopyInto(&copyIng.ObjectMeta)
 
+	// annotations using the prefixes accepted by the IngressClass are parsed as
+	// annotations of the controller. The stored Ingress keeps the original annotations.
+	annotations := aliasAnnotationPrefixes(copyIng.Annotations, s.GetAnnotationPrefixes(ing))
+
 	if s.backendConfig.AnnotationValueWordBlocklist != "" {
-		if err := checkBadAnnotationValue(copyIng.Annotations, s.backendConfig.AnnotationValueWordBlocklist); err != nil {
+		if err := checkBadAnnotationValue(annotations, s.backendConfig.AnnotationValueWordBlocklist); err != nil {
 			klog.Warningf("skipping ingress %s: %s", key, err)
+			s.ignoredIngresses.ignore(ing, IgnoredReasonInvalidAnnotations, err.Error())
 			return
 		}
 	}
@@ -933,14 +1135,30 @@ func (s *k8sStore) syncIngress(ing *networkingv1.Ingress) {
 
 	k8s.SetDefaultNGINXPathType(copyIng)
 
-	parsed, err := s.annotations.Extract(ing)
+	s.warnDeprecations(ing, "Ingress/"+key, deprecation.Annotations(annotations))
+
+	annotations, _, next := applyAnnotationSchedules(annotations, time.Now())
+	s.scheduleIngressSync(key, next)
+
+	annotatedIng := *ing
+	annotatedIng.Annotations = annotations
+
+	parsed, err := s.annotations.Extract(&annotatedIng)
 	if err != nil {
 		klog.Error(err)
+		s.ignoredIngresses.ignore(ing, IgnoredReasonInvalidAnnotations, err.Error())
 		return
 	}
+
+	if parsed.Denied != nil {
+		s.ignoredIngresses.ignore(ing, IgnoredReasonDeniedLocations, *parsed.Denied)
+	} else {
+		s.ignoredIngresses.forget(ing)
+	}
 	err = s.listers.IngressWithAnnotation.Update(&ingress.Ingress{
 		Ingress:           *copyIng,
 		ParsedAnnotations: parsed,
+		ForwardedHeaders:  parseForwardedHeaders(s.getIngressClassParameters(ing)),
 	})
 	if err != nil {
 		klog.Error(err)
@@ -955,8 +1173,10 @@ func (s *k8sStore) updateSecretIngressMap(ing *networkingv1.Ingress) {
 
 	// delete all existing references first
 	s.secretIngressMap.Delete(key)
+	s.annotationSecretIngressMap.Delete(key)
 
 	var refSecrets []string
+	var annotationRefSecrets []string
 
 	for _, tls := range ing.Spec.TLS {
 		secrName := tls.SecretName
@@ -972,25 +1192,40 @@ func (s *k8sStore) updateSecretIngressMap(ing *networkingv1.Ingress) {
 	// references it would not trigger a resync of that secret.
 	secretAnnotations := []string{
 		"auth-secret",
+		"auth-oidc-secret",
 		"auth-tls-secret",
 		"proxy-ssl-secret",
 		"secure-verify-ca-secret",
+		"ssl-alternate-secret",
 	}
 
+	annotatedIng := *ing
+	annotatedIng.Annotations = s.GetIngressAnnotations(ing)
+
 	secConfig := s.GetSecurityConfiguration().AllowCrossNamespaceResources
 	for _, ann := range secretAnnotations {
-		secrKey, err := objectRefAnnotationNsKey(ann, ing, secConfig)
+		secrKey, err := objectRefAnnotationNsKey(ann, &annotatedIng, secConfig)
 		if err != nil && !errors.IsMissingAnnotations(err) {
 			klog.Errorf("error reading secret reference in annotation %q: %s", ann, err)
 			continue
 		}
 		if secrKey != "" {
 			refSecrets = append(refSecrets, secrKey)
+			annotationRefSecrets = append(annotationRefSecrets, secrKey)
 		}
 	}
 
+	// Secrets referenced only in snippets
+	extraSecrets, err := extrasecrets.SecretKeys(&annotatedIng, secConfig)
+	if err != nil && !errors.IsMissingAnnotations(err) {
+		klog.Errorf("error reading secret references in annotation %q: %s", "extra-secrets", err)
+	}
+	refSecrets = append(refSecrets, extraSecrets...)
+	annotationRefSecrets = append(annotationRefSecrets, extraSecrets...)
+
 	// populate map with all secret references
 	s.secretIngressMap.Insert(key, refSecrets...)
+	s.annotationSecretIngressMap.Insert(key, annotationRefSecrets...)
 }
 
 // objectRefAnnotationNsKey returns an object reference formatted as a
@@ -1047,6 +1282,20 @@ func (s *k8sStore) GetService(key string) (*corev1.Service, error) {
 	return s.listers.Service.ByKey(key)
 }
 
+// GetNamespaceLabels returns the labels of a Namespace
+func (s *k8sStore) GetNamespaceLabels(name string) map[string]string {
+	if s.listers.Namespace.Store == nil {
+		return nil
+	}
+
+	ns, err := s.listers.Namespace.ByKey(name)
+	if err != nil {
+		return nil
+	}
+
+	return ns.Labels
+}
+
 func (s *k8sStore) GetIngressClass(ing *networkingv1.Ingress, icConfig *ingressclass.Configuration) (string, error) {
 	// First we try ingressClassName
 	if !icConfig.IgnoreIngressClass && ing.Spec.IngressClassName != nil {
@@ -1120,6 +1369,60 @@ func (s *k8sStore) GetLocalSSLCert(key string) (*ingress.SSLCert, error) {
 	return s.sslStore.ByKey(key)
 }
 
+// isCustomDomainSecret returns true if the Secret matching key contains the
+// certificate of a custom domain. The values of the custom domains are in the
+// form "hostname[,namespace/secret]".
+func (s *k8sStore) isCustomDomainSecret(key string) bool {
+	if s.customDomainsConfigMap == "" {
+		return false
+	}
+
+	configmap, err := s.listers.ConfigMap.ByKey(s.customDomainsConfigMap)
+	if err != nil {
+		return false
+	}
+
+	for _, value := range configmap.Data {
+		if _, secret, ok := strings.Cut(value, ","); ok && strings.TrimSpace(secret) == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// GetSecretHostnames returns the hostnames of the servers using the Secret
+// matching key as TLS certificate. The list is empty when the Secret is the
+// default SSL certificate, is referenced in annotations or in a TLS section
+// without hosts, or contains the certificate of a custom domain, as the
+// servers using it cannot be determined from the Secret.
+func (s *k8sStore) GetSecretHostnames(key string) []string {
+	if key == s.defaultSSLCertificate || s.annotationSecretIngressMap.Has(key) || s.isCustomDomainSecret(key) {
+		return []string{}
+	}
+
+	hosts := sets.New[string]()
+	for _, ingKey := range s.secretIngressMap.Reference(key) {
+		ing, err := s.getIngress(ingKey)
+		if err != nil {
+			klog.Errorf("could not find Ingress %v in local store", ingKey)
+			return []string{}
+		}
+
+		for _, tls := range ing.Spec.TLS {
+			if fmt.Sprintf("%v/%v", ing.Namespace, tls.SecretName) != key {
+				continue
+			}
+			if len(tls.Hosts) == 0 {
+				return []string{}
+			}
+			hosts.Insert(tls.Hosts...)
+		}
+	}
+
+	return sets.List(hosts)
+}
+
 // GetConfigMap returns the ConfigMap matching key.
 func (s *k8sStore) GetConfigMap(key string) (*corev1.ConfigMap, error) {
 	return s.listers.ConfigMap.ByKey(key)
@@ -1197,6 +1500,7 @@ func (s *k8sStore) GetSecurityConfiguration() defaults.SecurityConfiguration {
 	secConfig := defaults.SecurityConfiguration{
 		AllowCrossNamespaceResources: s.backendConfig.AllowCrossNamespaceResources,
 		AnnotationsRiskLevel:         s.backendConfig.AnnotationsRiskLevel,
+		AuthCredentialsInMemory:      s.backendConfig.AuthCredentialsInMemory,
 	}
 	return secConfig
 }
@@ -1209,20 +1513,90 @@ func (s *k8sStore) setConfig(cmap *corev1.ConfigMap) {
 		return
 	}
 
+	s.warnDeprecations(cmap, "ConfigMap/"+k8s.MetaNamespaceKey(cmap), deprecation.ConfigMapKeys(cmap.Data))
+
 	s.backendConfig = ngx_template.ReadConfig(cmap.Data)
 	if s.backendConfig.UseGeoIP2 && !nginx.GeoLite2DBExists() {
 		klog.Warning("The GeoIP2 feature is enabled but the databases are missing. Disabling")
 		s.backendConfig.UseGeoIP2 = false
 	}
 
+	disableUnavailableModuleFeatures(&s.backendConfig, nginx.UnavailableModules(s.backendConfig.DisableModules))
+
 	s.writeSSLSessionTicketKey(cmap, "/etc/ingress-controller/tickets.key")
 }
 
+// deprecationWarningInterval is the minimum interval between the warnings
+// about a deprecated setting of an object
+const deprecationWarningInterval = 24 * time.Hour
+
+// warnDeprecations emits a Warning event with the guidance to replace each
+// deprecated setting used by an object, identified by objectKey, at most
+// once a day
+func (s *k8sStore) warnDeprecations(obj k8sruntime.Object, objectKey string, deprecations []deprecation.Deprecation) {
+	for _, d := range deprecations {
+		if s.deprecationLimiter != nil && !s.deprecationLimiter.Allow(objectKey, d) {
+			continue
+		}
+
+		klog.Warningf("%v: %v", objectKey, d.Message())
+		if s.recorder != nil {
+			s.recorder.Event(obj, corev1.EventTypeWarning, "Deprecated", d.Message())
+		}
+		s.metricCollector.IncDeprecationWarningCount(d.Kind, d.Name)
+	}
+}
+
+// disableUnavailableModuleFeatures disables the features of the configuration
+// requiring an optional NGINX module that is disabled or not present in the image
+func disableUnavailableModuleFeatures(cfg *ngx_config.Configuration, unavailable []string) {
+	for _, module := range unavailable {
+		switch module {
+		case nginx.BrotliModule:
+			if cfg.EnableBrotli {
+				klog.Warningf("The Brotli feature is enabled but the %v module is not available. Disabling", module)
+				cfg.EnableBrotli = false
+			}
+		case nginx.GeoIP2Module:
+			if cfg.UseGeoIP2 {
+				klog.Warningf("The GeoIP2 feature is enabled but the %v module is not available. Disabling", module)
+				cfg.UseGeoIP2 = false
+			}
+		case nginx.ModSecurityModule:
+			if cfg.EnableModsecurity || cfg.EnableOWASPCoreRules {
+				klog.Warningf("The ModSecurity feature is enabled but the %v module is not available. Disabling", module)
+				cfg.EnableModsecurity = false
+				cfg.EnableOWASPCoreRules = false
+			}
+		case nginx.OpentelemetryModule:
+			if cfg.EnableOpentelemetry {
+				klog.Warningf("The OpenTelemetry feature is enabled but the %v module is not available. Disabling", module)
+				cfg.EnableOpentelemetry = false
+			}
+		}
+	}
+}
+
 // Run initiates the synchronization of the informers and the initial
 // synchronization of the secrets.
 func (s *k8sStore) Run(stopCh chan struct{}) {
+	// the permissions to list the IngressClasses are checked after the
+	// creation of the store when the controller starts from the
+	// configuration snapshot
+	if s.icConfig != nil && s.icConfig.IgnoreIngressClass {
+		s.informers.IngressClass = nil
+		s.listers.IngressClass.Store = nil
+	}
+
 	// start informers
 	s.informers.Run(stopCh)
+
+	setInformersSynced(s.informers, s.metricCollector)
+}
+
+// HasSynced checks if the informers of all the resources are synchronized
+func (s *k8sStore) HasSynced() bool {
+	return s.informers.HasSynced()
 }
 
 var runtimeScheme = k8sruntime.NewScheme()
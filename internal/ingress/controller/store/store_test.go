@@ -21,6 +21,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"os"
+	"reflect"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -124,13 +125,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -210,13 +215,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 		ic := createIngressClass(clientSet, t, "not-k8s.io/not-ingress-nginx")
@@ -318,13 +327,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 		validSpec := commonIngressSpec
@@ -438,13 +451,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			ingressClassconfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -572,13 +589,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			ingressClassconfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 		validSpec := commonIngressSpec
@@ -676,13 +697,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -774,13 +799,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 		invalidSpec := commonIngressSpec
@@ -864,13 +893,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -964,13 +997,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -1091,13 +1128,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -1219,13 +1260,17 @@ func TestStore(t *testing.T) {
 			fmt.Sprintf("%v/tcp", ns),
 			fmt.Sprintf("%v/udp", ns),
 			"",
+			"",
 			10*time.Minute,
 			clientSet,
 			updateCh,
 			false,
 			true,
 			DefaultClassConfig,
-			false)
+			nil,
+			false,
+			false,
+			nil)
 
 		storer.Run(stopCh)
 
@@ -1397,6 +1442,9 @@ func newStore() *k8sStore {
 		syncSecretMu:     new(sync.Mutex),
 		backendConfigMu:  new(sync.RWMutex),
 		secretIngressMap: NewObjectRefMap(),
+
+		annotationSecretIngressMap: NewObjectRefMap(),
+		ignoredIngresses:           newIgnoredIngresses(),
 	}
 }
 
@@ -1443,6 +1491,21 @@ func TestUpdateSecretIngressMap(t *testing.T) {
 		}
 	})
 
+	t.Run("with OIDC client secret annotation", func(t *testing.T) {
+		ing := ingTpl.DeepCopy()
+		ing.ObjectMeta.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("auth-oidc-secret"): "oidc-client",
+		})
+		if err := s.listers.Ingress.Update(ing); err != nil {
+			t.Errorf("error updating the Ingress: %v", err)
+		}
+		s.updateSecretIngressMap(ing)
+
+		if l := s.secretIngressMap.Len(); !(l == 1 && s.secretIngressMap.Has("testns/oidc-client")) {
+			t.Errorf("Expected \"testns/oidc-client\" to be the only referenced Secret (got %d)", l)
+		}
+	})
+
 	t.Run("with annotation in namespace/name format", func(t *testing.T) {
 		ing := ingTpl.DeepCopy()
 		ing.ObjectMeta.SetAnnotations(map[string]string{
@@ -1487,6 +1550,90 @@ func TestUpdateSecretIngressMap(t *testing.T) {
 			t.Errorf("Expected 0 referenced Secret (got %d)", l)
 		}
 	})
+
+	t.Run("with extra secrets referenced in snippets", func(t *testing.T) {
+		ing := ingTpl.DeepCopy()
+		ing.ObjectMeta.SetAnnotations(map[string]string{
+			parser.GetAnnotationWithPrefix("extra-secrets"): "client-ca, testns/token",
+		})
+		if err := s.listers.Ingress.Update(ing); err != nil {
+			t.Errorf("error updating the Ingress: %v", err)
+		}
+		s.updateSecretIngressMap(ing)
+
+		if l := s.secretIngressMap.Len(); !(l == 2 && s.secretIngressMap.Has("testns/client-ca") && s.secretIngressMap.Has("testns/token")) {
+			t.Errorf("Expected \"testns/client-ca\" and \"testns/token\" to be the only referenced Secrets (got %d)", l)
+		}
+
+		if !s.annotationSecretIngressMap.Has("testns/client-ca") {
+			t.Errorf("Expected \"testns/client-ca\" to be referenced from annotations")
+		}
+	})
+}
+
+func TestGetSecretHostnames(t *testing.T) {
+	s := newStore()
+
+	ing := &networking.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test",
+			Namespace: "testns",
+		},
+		Spec: networking.IngressSpec{
+			TLS: []networking.IngressTLS{
+				{Hosts: []string{"foo.bar", "bar.baz"}, SecretName: "tls"},
+				{Hosts: []string{"other.baz"}, SecretName: "other"},
+			},
+		},
+	}
+	if err := s.listers.IngressWithAnnotation.Update(&ingress.Ingress{Ingress: *ing}); err != nil {
+		t.Errorf("error updating the Ingress: %v", err)
+	}
+	s.updateSecretIngressMap(ing)
+
+	hosts := s.GetSecretHostnames("testns/tls")
+	if !reflect.DeepEqual(hosts, []string{"bar.baz", "foo.bar"}) {
+		t.Errorf("Expected hostnames [bar.baz foo.bar] but got %v", hosts)
+	}
+
+	ing.ObjectMeta.SetAnnotations(map[string]string{
+		parser.GetAnnotationWithPrefix("auth-tls-secret"): "tls",
+	})
+	if err := s.listers.IngressWithAnnotation.Update(&ingress.Ingress{Ingress: *ing}); err != nil {
+		t.Errorf("error updating the Ingress: %v", err)
+	}
+	s.updateSecretIngressMap(ing)
+
+	if hosts := s.GetSecretHostnames("testns/tls"); len(hosts) != 0 {
+		t.Errorf("Expected no hostnames for a Secret referenced in annotations but got %v", hosts)
+	}
+	if hosts := s.GetSecretHostnames("testns/other"); !reflect.DeepEqual(hosts, []string{"other.baz"}) {
+		t.Errorf("Expected hostnames [other.baz] but got %v", hosts)
+	}
+
+	s.customDomainsConfigMap = "testns/custom-domains"
+	s.listers.ConfigMap.Store = cache.NewStore(cache.MetaNamespaceKeyFunc)
+	if err := s.listers.ConfigMap.Add(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "custom-domains",
+			Namespace: "testns",
+		},
+		Data: map[string]string{
+			"shop.example.com": "other.baz, testns/other",
+		},
+	}); err != nil {
+		t.Errorf("error adding the ConfigMap: %v", err)
+	}
+
+	if !s.isCustomDomainSecret("testns/other") {
+		t.Errorf("Expected the Secret testns/other to be used by a custom domain")
+	}
+	if s.isCustomDomainSecret("testns/tls") {
+		t.Errorf("Expected the Secret testns/tls not to be used by a custom domain")
+	}
+	if hosts := s.GetSecretHostnames("testns/other"); len(hosts) != 0 {
+		t.Errorf("Expected no hostnames for a Secret of a custom domain but got %v", hosts)
+	}
 }
 
 func TestListIngresses(t *testing.T) {
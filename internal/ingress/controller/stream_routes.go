@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+	"k8s.io/ingress-nginx/pkg/tcpproxy"
+)
+
+// StreamRoutesPath is the path of the endpoint returning the routes by SNI
+// of the TLS connections accepted by the SSL passthrough proxy, served in
+// the status port
+const StreamRoutesPath = "/stream/routes"
+
+// StreamRoutesReport is the document returned by the stream routes endpoint
+type StreamRoutesReport struct {
+	Routes []StreamRoute `json:"routes"`
+}
+
+// StreamRoute is a route of the TLS connections, with the certificate
+// selected by NGINX for the routes terminating TLS
+type StreamRoute struct {
+	tcpproxy.Route
+	Certificate *StreamRouteCertificate `json:"certificate,omitempty"`
+}
+
+// StreamRouteCertificate describes the certificate served to the TLS
+// connections of a route
+type StreamRouteCertificate struct {
+	// Secret is the namespace/name of the Secret of the certificate, empty
+	// for the self-signed default certificate
+	Secret  string    `json:"secret,omitempty"`
+	CN      []string  `json:"cn,omitempty"`
+	Expires time.Time `json:"expires"`
+}
+
+// terminatedHosts returns the sorted hostnames and aliases of the servers
+// terminating TLS in NGINX
+func terminatedHosts(servers []*ingress.Server) []string {
+	hosts := map[string]bool{}
+	for _, server := range servers {
+		if server.SSLCert == nil || server.SSLPassthrough || server.Hostname == defServerName {
+			continue
+		}
+
+		hosts[server.Hostname] = true
+		for _, alias := range server.Aliases {
+			hosts[alias] = true
+		}
+	}
+
+	sorted := make([]string, 0, len(hosts))
+	for host := range hosts {
+		sorted = append(sorted, host)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// streamRouteCertificate returns the description of a certificate, or nil
+func streamRouteCertificate(cert *ingress.SSLCert) *StreamRouteCertificate {
+	if cert == nil {
+		return nil
+	}
+
+	c := &StreamRouteCertificate{
+		CN:      cert.CN,
+		Expires: cert.ExpireTime,
+	}
+	if cert.Name != "" {
+		c.Secret = cert.Namespace + "/" + cert.Name
+	}
+	return c
+}
+
+// streamRoutes returns the routes of the proxy with the certificates of the
+// servers of the running configuration terminating TLS
+func streamRoutes(routes []tcpproxy.Route, pcfg *ingress.Configuration) []StreamRoute {
+	streamRoutes := make([]StreamRoute, 0, len(routes))
+	for _, route := range routes {
+		sr := StreamRoute{Route: route}
+		switch route.Mode {
+		case tcpproxy.RouteModeTerminate:
+			if server := findServer(pcfg.Servers, route.Hostname); server != nil {
+				sr.Certificate = streamRouteCertificate(server.SSLCert)
+			}
+		case tcpproxy.RouteModeDefault:
+			sr.Certificate = streamRouteCertificate(pcfg.DefaultSSLCertificate)
+		}
+		streamRoutes = append(streamRoutes, sr)
+	}
+
+	return streamRoutes
+}
+
+// StreamRoutesHandler returns the routes by SNI of the TLS connections
+// accepted by the SSL passthrough proxy with their connection counts,
+// optionally filtered by the mode in the query parameter mode
+func (n *NGINXController) StreamRoutesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "only GET is allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	if n.Proxy == nil {
+		http.Error(w, "SSL passthrough is not enabled (--enable-ssl-passthrough)", http.StatusNotFound)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+	report := &StreamRoutesReport{
+		Routes: []StreamRoute{},
+	}
+	for _, route := range streamRoutes(n.Proxy.Routes(), n.getRunningConfig()) {
+		if mode == "" || route.Mode == mode {
+			report.Routes = append(report.Routes, route)
+		}
+	}
+
+	writeJSON(w, report)
+}
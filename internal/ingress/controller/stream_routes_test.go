@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+	"k8s.io/ingress-nginx/pkg/tcpproxy"
+)
+
+func TestTerminatedHosts(t *testing.T) {
+	cert := &ingress.SSLCert{Namespace: "default", Name: "tls"}
+	hosts := terminatedHosts([]*ingress.Server{
+		{Hostname: defServerName, SSLCert: cert},
+		{Hostname: "www.example.com", Aliases: []string{"example.com"}, SSLCert: cert},
+		{Hostname: "pass.example.com", SSLPassthrough: true, SSLCert: cert},
+		{Hostname: "plain.example.com"},
+	})
+
+	expected := []string{"example.com", "www.example.com"}
+	if !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("expected %v but returned %v", expected, hosts)
+	}
+}
+
+func TestStreamRoutesHandler(t *testing.T) {
+	n := &NGINXController{
+		runningConfig: &ingress.Configuration{
+			Servers: []*ingress.Server{
+				{Hostname: "www.example.com", SSLCert: &ingress.SSLCert{Namespace: "default", Name: "www-tls", CN: []string{"www.example.com"}}},
+			},
+			DefaultSSLCertificate: &ingress.SSLCert{CN: []string{"Kubernetes Ingress Controller Fake Certificate"}},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	n.StreamRoutesHandler(w, httptest.NewRequest(http.MethodGet, StreamRoutesPath, http.NoBody))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("expected status %v without SSL passthrough but returned %v", http.StatusNotFound, w.Code)
+	}
+
+	n.Proxy = &tcpproxy.TCPProxy{
+		Default: &tcpproxy.TCPServer{Hostname: "localhost", IP: "127.0.0.1", Port: 442},
+	}
+	n.Proxy.SetServers([]*tcpproxy.TCPServer{{Hostname: "pass.example.com", IP: "10.0.0.1", Port: 443}}, terminatedHosts(n.runningConfig.Servers))
+
+	testCases := []struct {
+		target   string
+		expected []StreamRoute
+	}{
+		{StreamRoutesPath, []StreamRoute{
+			{Route: tcpproxy.Route{Hostname: "pass.example.com", Mode: tcpproxy.RouteModePassthrough, Backend: "10.0.0.1:443"}},
+			{
+				Route:       tcpproxy.Route{Hostname: "www.example.com", Mode: tcpproxy.RouteModeTerminate},
+				Certificate: &StreamRouteCertificate{Secret: "default/www-tls", CN: []string{"www.example.com"}},
+			},
+			{
+				Route:       tcpproxy.Route{Mode: tcpproxy.RouteModeDefault},
+				Certificate: &StreamRouteCertificate{CN: []string{"Kubernetes Ingress Controller Fake Certificate"}},
+			},
+		}},
+		{StreamRoutesPath + "?mode=passthrough", []StreamRoute{
+			{Route: tcpproxy.Route{Hostname: "pass.example.com", Mode: tcpproxy.RouteModePassthrough, Backend: "10.0.0.1:443"}},
+		}},
+	}
+
+	for _, tc := range testCases {
+		w := httptest.NewRecorder()
+		n.StreamRoutesHandler(w, httptest.NewRequest(http.MethodGet, tc.target, http.NoBody))
+		if w.Code != http.StatusOK {
+			t.Fatalf("expected status %v but returned %v", http.StatusOK, w.Code)
+		}
+
+		var report StreamRoutesReport
+		if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+			t.Fatalf("unexpected error decoding %v: %v", w.Body.String(), err)
+		}
+		if !reflect.DeepEqual(report.Routes, tc.expected) {
+			t.Errorf("%v: expected %+v but returned %+v", tc.target, tc.expected, report.Routes)
+		}
+	}
+}
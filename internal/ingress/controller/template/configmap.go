@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -35,7 +36,7 @@ import (
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ing_net "k8s.io/ingress-nginx/internal/net"
-	"k8s.io/ingress-nginx/pkg/util/runtime"
+	"k8s.io/ingress-nginx/internal/nginx"
 )
 
 const (
@@ -55,6 +56,7 @@ const (
 	nginxStatusIpv6Whitelist      = "nginx-status-ipv6-whitelist"
 	proxyHeaderTimeout            = "proxy-protocol-header-timeout"
 	workerProcesses               = "worker-processes"
+	workerProcessesPolicy         = "worker-processes-policy"
 	globalAllowedResponseHeaders  = "global-allowed-response-headers"
 	globalAuthURL                 = "global-auth-url"
 	globalAuthMethod              = "global-auth-method"
@@ -68,7 +70,14 @@ const (
 	globalAuthAlwaysSetCookie     = "global-auth-always-set-cookie"
 	luaSharedDictsKey             = "lua-shared-dicts"
 	debugConnections              = "debug-connections"
+	disableModules                = "disable-modules"
 	workerSerialReloads           = "enable-serial-reloads"
+	externalNameResolver          = "external-name-resolver"
+	trustedProxies                = "trusted-proxies"
+	upstreamIPFamilyPreference    = "upstream-ip-family-preference"
+	forwardedHeadersMode          = "forwarded-headers-mode"
+	forwardedSchemeOverride       = "forwarded-scheme-override"
+	clientAbortLogSampleRate      = "client-abort-log-sample-rate"
 )
 
 var (
@@ -80,14 +89,22 @@ var (
 		"balancer_ewma":                 10240,
 		"balancer_ewma_last_touched_at": 10240,
 		"balancer_ewma_locks":           1024,
+		"basic_auth_credentials":        1024,
 		"certificate_servers":           5120,
+		"oidc_clients":                  1024,
+		"lua_kv":                        1024,
+		"dns_cache_stats":               1024,
+		"proxy_cache_fills":             1024,
+		"auth_failure_decisions":        1024,
+		"warmup_endpoints":              1024,
 		"ocsp_response_cache":           5120, // keep this same as certificate_servers
 	}
 	defaultGlobalAuthRedirectParam = "rd"
 )
 
 const (
-	maxAllowedLuaDictSize = 204800
+	// MaxAllowedLuaDictSize is the maximum size in kilobytes of a Lua shared dictionary
+	MaxAllowedLuaDictSize = 204800
 	maxNumberOfLuaDicts   = 100
 )
 
@@ -119,6 +136,8 @@ func ReadConfig(src map[string]string) config.Configuration {
 	allowedResponseHeaders := make([]string, 0)
 	luaSharedDicts := make(map[string]int)
 	debugConnectionsList := make([]string, 0)
+	disableModulesList := make([]string, 0)
+	externalNameResolverList := make([]string, 0)
 
 	// parse lua shared dict values
 	if val, ok := conf[luaSharedDictsKey]; ok {
@@ -133,8 +152,8 @@ func ReadConfig(src map[string]string) config.Configuration {
 				klog.Errorf("Ignoring poorly formatted value %v for Lua dictionary %v", results[1], dictName)
 				continue
 			}
-			if size > maxAllowedLuaDictSize {
-				klog.Errorf("Ignoring %v for Lua dictionary %v: maximum size is %vk.", results[1], dictName, maxAllowedLuaDictSize)
+			if size > MaxAllowedLuaDictSize {
+				klog.Errorf("Ignoring %v for Lua dictionary %v: maximum size is %vk.", results[1], dictName, MaxAllowedLuaDictSize)
 				continue
 			}
 			if len(luaSharedDicts)+1 > maxNumberOfLuaDicts {
@@ -146,6 +165,12 @@ func ReadConfig(src map[string]string) config.Configuration {
 			luaSharedDicts[dictName] = size
 		}
 	}
+	// the dictionaries defined by the user are not autosized
+	var luaSharedDictsOverrides []string
+	for name := range luaSharedDicts {
+		luaSharedDictsOverrides = append(luaSharedDictsOverrides, name)
+	}
+	sort.Strings(luaSharedDictsOverrides)
 	// set default Lua shared dicts
 	for k, v := range defaultLuaSharedDicts {
 		if _, ok := luaSharedDicts[k]; !ok {
@@ -394,13 +419,21 @@ func ReadConfig(src map[string]string) config.Configuration {
 		delete(conf, nginxStatusIpv6Whitelist)
 	}
 
-	if val, ok := conf[workerProcesses]; ok {
-		to.WorkerProcesses = val
-		if val == "auto" {
-			to.WorkerProcesses = strconv.Itoa(runtime.NumCPU())
+	workerProcessesVal, hasWorkerProcesses := conf[workerProcesses]
+	policy, hasPolicy := conf[workerProcessesPolicy]
+	if hasWorkerProcesses || hasPolicy {
+		count, err := config.WorkerProcessesCount(workerProcessesVal, policy)
+		if err != nil {
+			klog.Warningf("%v, using the default of %v worker processes", err, to.WorkerProcesses)
+		} else {
+			to.WorkerProcesses = count
+			if policy != "" {
+				to.WorkerProcessesPolicy = policy
+			}
 		}
 
 		delete(conf, workerProcesses)
+		delete(conf, workerProcessesPolicy)
 	}
 
 	if val, ok := conf[workerSerialReloads]; ok {
@@ -432,6 +465,85 @@ func ReadConfig(src map[string]string) config.Configuration {
 		to.DebugConnections = debugConnectionsList
 	}
 
+	if val, ok := conf[trustedProxies]; ok {
+		delete(conf, trustedProxies)
+		trustedProxiesList := make([]string, 0)
+		for _, i := range splitAndTrimSpace(val, ",") {
+			if net.ParseIP(i) == nil {
+				if _, _, err := net.ParseCIDR(i); err != nil {
+					klog.Warningf("%v is not a valid IP or CIDR address of a trusted proxy", i)
+					continue
+				}
+			}
+			trustedProxiesList = append(trustedProxiesList, i)
+		}
+		to.TrustedProxies = trustedProxiesList
+	}
+
+	if val, ok := conf[upstreamIPFamilyPreference]; ok {
+		delete(conf, upstreamIPFamilyPreference)
+		switch val {
+		case "", "ipv4", "ipv6":
+			to.UpstreamIPFamilyPreference = val
+		default:
+			klog.Warningf("%v is not a valid IP family, valid values are ipv4 or ipv6", val)
+		}
+	}
+
+	if val, ok := conf[forwardedHeadersMode]; ok {
+		delete(conf, forwardedHeadersMode)
+		if val == "" || config.IsValidForwardedHeadersMode(val) {
+			to.ForwardedHeadersMode = val
+		} else {
+			klog.Warningf("%v is not a valid mode of the forwarded headers, valid values are trust, overwrite or append", val)
+		}
+	}
+
+	if val, ok := conf[forwardedSchemeOverride]; ok {
+		delete(conf, forwardedSchemeOverride)
+		if val == "" || config.IsValidForwardedScheme(val) {
+			to.ForwardedSchemeOverride = val
+		} else {
+			klog.Warningf("%v is not a valid scheme, valid values are http or https", val)
+		}
+	}
+
+	if val, ok := conf[clientAbortLogSampleRate]; ok {
+		delete(conf, clientAbortLogSampleRate)
+		rate, err := strconv.ParseFloat(val, 32)
+		if err != nil || rate < 0 || rate > 1 {
+			klog.Warningf("%v is not a valid sample rate of the client aborts, valid values are between 0 and 1", val)
+		} else {
+			to.ClientAbortLogSampleRate = float32(rate)
+		}
+	}
+
+	if val, ok := conf[disableModules]; ok {
+		delete(conf, disableModules)
+		for _, i := range splitAndTrimSpace(val, ",") {
+			if !nginx.IsOptionalModule(i) {
+				klog.Warningf("%v is not an optional NGINX module", i)
+				continue
+			}
+
+			disableModulesList = append(disableModulesList, i)
+		}
+		to.DisableModules = disableModulesList
+	}
+
+	if val, ok := conf[externalNameResolver]; ok {
+		delete(conf, externalNameResolver)
+		for _, i := range splitAndTrimSpace(val, ",") {
+			if net.ParseIP(i) == nil {
+				klog.Warningf("%v is not a valid textual representation of an IP address", i)
+				continue
+			}
+
+			externalNameResolverList = append(externalNameResolverList, i)
+		}
+		to.ExternalNameResolver = externalNameResolverList
+	}
+
 	to.CustomHTTPErrors = filterErrors(errors)
 	to.SkipAccessLogURLs = skipUrls
 	to.DenylistSourceRange = denyList
@@ -446,6 +558,7 @@ func ReadConfig(src map[string]string) config.Configuration {
 	to.ProxyStreamResponses = streamResponses
 	to.DisableIpv6DNS = !ing_net.IsIPv6Enabled()
 	to.LuaSharedDicts = luaSharedDicts
+	to.LuaSharedDictsOverrides = luaSharedDictsOverrides
 	to.Backend.AllowedResponseHeaders = allowedResponseHeaders
 
 	decoderConfig := &mapstructure.DecoderConfig{
@@ -19,6 +19,8 @@ package template
 import (
 	"fmt"
 	"reflect"
+	"runtime"
+	"strconv"
 	"testing"
 	"time"
 
@@ -52,31 +54,62 @@ func TestProxyTimeoutParsing(t *testing.T) {
 	}
 }
 
+func TestWorkerProcessesParsing(t *testing.T) {
+	def := config.NewDefault()
+
+	testCases := map[string]struct {
+		input        map[string]string
+		expect       string
+		expectPolicy string
+	}{
+		"number":                     {map[string]string{"worker-processes": "3"}, "3", config.WorkerProcessesPolicyCgroup},
+		"number with fixed":          {map[string]string{"worker-processes": "3", "worker-processes-policy": "fixed"}, "3", config.WorkerProcessesPolicyFixed},
+		"auto":                       {map[string]string{"worker-processes": "auto"}, def.WorkerProcesses, config.WorkerProcessesPolicyCgroup},
+		"auto with fixed":            {map[string]string{"worker-processes": "auto", "worker-processes-policy": "fixed"}, def.WorkerProcesses, config.WorkerProcessesPolicyCgroup},
+		"invalid number":             {map[string]string{"worker-processes": "many"}, def.WorkerProcesses, config.WorkerProcessesPolicyCgroup},
+		"invalid policy":             {map[string]string{"worker-processes-policy": "cores"}, def.WorkerProcesses, config.WorkerProcessesPolicyCgroup},
+		"node policy without number": {map[string]string{"worker-processes-policy": "node"}, strconv.Itoa(runtime.NumCPU()), config.WorkerProcessesPolicyNode},
+	}
+	for n, tc := range testCases {
+		cfg := ReadConfig(tc.input)
+		if cfg.WorkerProcesses != tc.expect || cfg.WorkerProcessesPolicy != tc.expectPolicy {
+			t.Errorf("Testing %v. Expected %v worker processes with the policy %v but got %v with %v", n, tc.expect, tc.expectPolicy, cfg.WorkerProcesses, cfg.WorkerProcessesPolicy)
+		}
+	}
+}
+
 func TestMergeConfigMapToStruct(t *testing.T) {
 	conf := map[string]string{
-		"custom-http-errors":            "300,400,demo",
-		"proxy-read-timeout":            "1",
-		"proxy-send-timeout":            "2",
-		"skip-access-log-urls":          "/log,/demo,/test",
-		"use-proxy-protocol":            "true",
-		"disable-access-log":            "true",
-		"access-log-params":             "buffer=4k gzip",
-		"access-log-path":               "/var/log/test/access.log",
-		"error-log-path":                "/var/log/test/error.log",
-		"use-gzip":                      "false",
-		"gzip-disable":                  "msie6",
-		"gzip-level":                    "9",
-		"gzip-min-length":               "1024",
-		"gzip-types":                    "text/html",
-		"proxy-real-ip-cidr":            "1.1.1.1/8,2.2.2.2/24",
-		"bind-address":                  "1.1.1.1,2.2.2.2,3.3.3,2001:db8:a0b:12f0::1,3731:54:65fe:2::a7,33:33:33::33::33",
-		"worker-shutdown-timeout":       "99s",
-		"nginx-status-ipv4-whitelist":   "127.0.0.1,10.0.0.0/24",
-		"nginx-status-ipv6-whitelist":   "::1,2001::/16",
-		"proxy-add-original-uri-header": "false",
-		"disable-ipv6-dns":              "true",
-		"default-type":                  "text/plain",
-		"debug-connections":             "127.0.0.1,1.1.1.1/24,::1",
+		"custom-http-errors":                  "300,400,demo",
+		"proxy-read-timeout":                  "1",
+		"proxy-send-timeout":                  "2",
+		"skip-access-log-urls":                "/log,/demo,/test",
+		"use-proxy-protocol":                  "true",
+		"disable-access-log":                  "true",
+		"access-log-params":                   "buffer=4k gzip",
+		"access-log-path":                     "/var/log/test/access.log",
+		"error-log-path":                      "/var/log/test/error.log",
+		"use-gzip":                            "false",
+		"gzip-disable":                        "msie6",
+		"gzip-level":                          "9",
+		"gzip-min-length":                     "1024",
+		"gzip-types":                          "text/html",
+		"proxy-real-ip-cidr":                  "1.1.1.1/8,2.2.2.2/24",
+		"bind-address":                        "1.1.1.1,2.2.2.2,3.3.3,2001:db8:a0b:12f0::1,3731:54:65fe:2::a7,33:33:33::33::33",
+		"worker-shutdown-timeout":             "99s",
+		"nginx-status-ipv4-whitelist":         "127.0.0.1,10.0.0.0/24",
+		"nginx-status-ipv6-whitelist":         "::1,2001::/16",
+		"proxy-add-original-uri-header":       "false",
+		"disable-ipv6-dns":                    "true",
+		"default-type":                        "text/plain",
+		"debug-connections":                   "127.0.0.1,1.1.1.1/24,::1",
+		"external-name-resolver":              "10.0.0.10, 2001:db8::53,dns.example.com",
+		"external-name-dns-ttl":               "30",
+		"external-name-dns-refresh":           "false",
+		"trusted-proxies":                     "10.0.0.0/8, 192.168.0.1,invalid,2001:db8::/32",
+		"upstream-ip-family-preference":       "ipv6",
+		"upstream-ip-family-fallback-timeout": "100",
+		"client-abort-log-sample-rate":        "0.1",
 	}
 	def := config.NewDefault()
 	def.CustomHTTPErrors = []int{300, 400}
@@ -103,6 +136,13 @@ func TestMergeConfigMapToStruct(t *testing.T) {
 	def.DisableIpv6DNS = true
 	def.DefaultType = "text/plain"
 	def.DebugConnections = []string{"127.0.0.1", "1.1.1.1/24", "::1"}
+	def.ExternalNameResolver = []string{"10.0.0.10", "2001:db8::53"}
+	def.ExternalNameDNSTTL = 30
+	def.ExternalNameDNSRefresh = false
+	def.TrustedProxies = []string{"10.0.0.0/8", "192.168.0.1", "2001:db8::/32"}
+	def.UpstreamIPFamilyPreference = "ipv6"
+	def.UpstreamIPFamilyFallbackTimeout = 100
+	def.ClientAbortLogSampleRate = 0.1
 
 	hash, err := hashstructure.Hash(def, hashstructure.FormatV1, &hashstructure.HashOptions{
 		TagName: "json",
@@ -422,6 +462,92 @@ func TestLuaSharedDictsParsing(t *testing.T) {
 	}
 }
 
+func TestLuaSharedDictsAutosizeParsing(t *testing.T) {
+	cfg := ReadConfig(map[string]string{})
+	if cfg.LuaSharedDictsAutosize {
+		t.Errorf("expected the autosizing of the Lua shared dictionaries to be disabled by default")
+	}
+	if cfg.LuaSharedDictsOverrides != nil {
+		t.Errorf("expected no overridden Lua shared dictionary but %v was returned", cfg.LuaSharedDictsOverrides)
+	}
+
+	cfg = ReadConfig(map[string]string{
+		"lua-shared-dicts-autosize": "true",
+		"lua-shared-dicts":          "my_custom_plugin: 5, certificate_data: 100, invalid_dict: 1a",
+	})
+	if !cfg.LuaSharedDictsAutosize {
+		t.Errorf("expected the autosizing of the Lua shared dictionaries to be enabled")
+	}
+
+	expected := []string{"certificate_data", "my_custom_plugin"}
+	if !reflect.DeepEqual(cfg.LuaSharedDictsOverrides, expected) {
+		t.Errorf("expected the overridden Lua shared dictionaries %v but %v was returned", expected, cfg.LuaSharedDictsOverrides)
+	}
+}
+
+func TestUpstreamIPFamilyPreferenceParsing(t *testing.T) {
+	testCases := map[string]string{
+		"":     "",
+		"ipv4": "ipv4",
+		"ipv6": "ipv6",
+		"IPv6": "",
+		"both": "",
+	}
+	for value, expected := range testCases {
+		cfg := ReadConfig(map[string]string{"upstream-ip-family-preference": value})
+		if cfg.UpstreamIPFamilyPreference != expected {
+			t.Errorf("expected %q as IP family for %q but %q was returned", expected, value, cfg.UpstreamIPFamilyPreference)
+		}
+	}
+}
+
+func TestForwardedHeadersParsing(t *testing.T) {
+	modes := map[string]string{
+		"":          "",
+		"trust":     "trust",
+		"overwrite": "overwrite",
+		"append":    "append",
+		"Append":    "",
+		"true":      "",
+	}
+	for value, expected := range modes {
+		cfg := ReadConfig(map[string]string{"forwarded-headers-mode": value})
+		if cfg.ForwardedHeadersMode != expected {
+			t.Errorf("expected %q as mode of the forwarded headers for %q but %q was returned", expected, value, cfg.ForwardedHeadersMode)
+		}
+	}
+
+	schemes := map[string]string{
+		"":      "",
+		"http":  "http",
+		"https": "https",
+		"wss":   "",
+	}
+	for value, expected := range schemes {
+		cfg := ReadConfig(map[string]string{"forwarded-scheme-override": value})
+		if cfg.ForwardedSchemeOverride != expected {
+			t.Errorf("expected %q as scheme override for %q but %q was returned", expected, value, cfg.ForwardedSchemeOverride)
+		}
+	}
+}
+
+func TestClientAbortLogSampleRateParsing(t *testing.T) {
+	testCases := map[string]float32{
+		"0":    0,
+		"0.25": 0.25,
+		"1":    1,
+		"1.5":  1,
+		"-1":   1,
+		"half": 1,
+	}
+	for value, expected := range testCases {
+		cfg := ReadConfig(map[string]string{"client-abort-log-sample-rate": value})
+		if cfg.ClientAbortLogSampleRate != expected {
+			t.Errorf("expected %v as sample rate for %q but %v was returned", expected, value, cfg.ClientAbortLogSampleRate)
+		}
+	}
+}
+
 func TestSplitAndTrimSpace(t *testing.T) {
 	testsCases := []struct {
 		name   string
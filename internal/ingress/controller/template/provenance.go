@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// ingressReference returns the namespace, name and resourceVersion of an Ingress
+func ingressReference(ing *ingress.Ingress) string {
+	return fmt.Sprintf("%v/%v (resourceVersion %v)", ing.Namespace, ing.Name, ing.ResourceVersion)
+}
+
+// ingressAnnotations returns the sorted names of the annotations of an
+// Ingress with the prefix of the controller
+func ingressAnnotations(ing *ingress.Ingress) []string {
+	prefix := parser.AnnotationsPrefix + "/"
+
+	names := []string{}
+	for name := range ing.Annotations {
+		if strings.HasPrefix(name, prefix) {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+	return names
+}
+
+// buildProvenanceComment returns a comment naming the Ingresses from which a
+// server or a location was generated, when enable-provenance-comments is set.
+// The Ingresses are not part of the checksum of the configuration, so that the
+// comment alone never causes a reload.
+func buildProvenanceComment(c, input interface{}) string {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return ""
+	}
+
+	if !cfg.EnableProvenanceComments {
+		return ""
+	}
+
+	switch input := input.(type) {
+	case *ingress.Server:
+		references := []string{}
+		for _, location := range input.Locations {
+			if location.Ingress == nil {
+				continue
+			}
+
+			reference := ingressReference(location.Ingress)
+			if !slices.Contains(references, reference) {
+				references = append(references, reference)
+			}
+		}
+
+		if len(references) == 0 {
+			return ""
+		}
+
+		return fmt.Sprintf("# Ingresses: %v", strings.Join(references, ", "))
+	case *ingress.Location:
+		if input.Ingress == nil {
+			return ""
+		}
+
+		comment := fmt.Sprintf("# Ingress: %v", ingressReference(input.Ingress))
+		if annotations := ingressAnnotations(input.Ingress); len(annotations) > 0 {
+			comment += fmt.Sprintf("\n# Annotations: %v", strings.Join(annotations, ", "))
+		}
+
+		return comment
+	default:
+		klog.Errorf("expected an '*ingress.Server' or '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+}
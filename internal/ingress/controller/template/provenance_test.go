@@ -0,0 +1,75 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"testing"
+
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestBuildProvenanceComment(t *testing.T) {
+	ing := &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:       "default",
+				Name:            "foo",
+				ResourceVersion: "123",
+				Annotations: map[string]string{
+					"nginx.ingress.kubernetes.io/rewrite-target": "/",
+					"nginx.ingress.kubernetes.io/app-root":       "/app",
+					"example.com/other":                          "value",
+				},
+			},
+		},
+	}
+	server := &ingress.Server{
+		Hostname: "example.com",
+		Locations: []*ingress.Location{
+			{Path: "/", Ingress: ing},
+			{Path: "/foo", Ingress: ing},
+			{Path: "/default"},
+		},
+	}
+
+	cfg := config.NewDefault()
+	if comment := buildProvenanceComment(cfg, server); comment != "" {
+		t.Errorf("expected no comment by default but returned %q", comment)
+	}
+
+	cfg.EnableProvenanceComments = true
+	testCases := []struct {
+		input    interface{}
+		expected string
+	}{
+		{server, "# Ingresses: default/foo (resourceVersion 123)"},
+		{server.Locations[0], "# Ingress: default/foo (resourceVersion 123)\n# Annotations: nginx.ingress.kubernetes.io/app-root, nginx.ingress.kubernetes.io/rewrite-target"},
+		{server.Locations[2], ""},
+		{&ingress.Server{}, ""},
+		{"invalid", ""},
+	}
+
+	for _, tc := range testCases {
+		if comment := buildProvenanceComment(cfg, tc.input); comment != tc.expected {
+			t.Errorf("expected %q but returned %q", tc.expected, comment)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/mitchellh/hashstructure/v2"
+	networking "k8s.io/api/networking/v1"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// serverBlockTemplate is the name of the template used to render a server block
+const serverBlockTemplate = "SERVER_BLOCK"
+
+// serverCacheKey identifies a rendered server block. A server block depends on
+// the server and on the rest of the configuration (global settings, backends).
+type serverCacheKey struct {
+	global uint64
+	server uint64
+}
+
+// locationIdentity contains the fields of a location used by the server block,
+// like the variables $namespace, $ingress_name, $service_name and $service_port,
+// but excluded from the JSON of the location
+type locationIdentity struct {
+	Namespace   string
+	Name        string
+	Annotations map[string]string
+	Spec        networking.IngressSpec
+	Service     string
+}
+
+// hashServer returns the hash of the server and of the identity of its locations
+func hashServer(server *ingress.Server) (uint64, error) {
+	locations := make([]locationIdentity, len(server.Locations))
+	for i, location := range server.Locations {
+		if location.Ingress != nil {
+			locations[i].Namespace = location.Ingress.Namespace
+			locations[i].Name = location.Ingress.Name
+			locations[i].Annotations = location.Ingress.Annotations
+			locations[i].Spec = location.Ingress.Spec
+		}
+		if location.Service != nil {
+			locations[i].Service = location.Service.Namespace + "/" + location.Service.Name
+		}
+	}
+
+	return hashstructure.Hash(struct {
+		Server    *ingress.Server
+		Locations []locationIdentity
+	}{server, locations}, hashstructure.FormatV1, &hashstructure.HashOptions{
+		TagName: "json",
+	})
+}
+
+// renderServer returns the server block rendered for the server by renderServers
+func renderServer(all config.TemplateConfig, server *ingress.Server) string {
+	return all.RenderedServers[server.Hostname]
+}
+
+// renderServers renders the server blocks of the configuration concurrently.
+// Server blocks rendered in the previous execution are reused when neither the
+// server nor the rest of the configuration changed. The configurations
+// rendered for the admission of an Ingress do not replace the cached blocks.
+func (t *Template) renderServers(conf *config.TemplateConfig) (map[string]string, error) {
+	global := *conf
+	global.Servers = nil
+	globalHash, err := hashstructure.Hash(global, hashstructure.FormatV1, &hashstructure.HashOptions{
+		TagName: "json",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error hashing configuration: %w", err)
+	}
+
+	t.serverCacheLock.Lock()
+	cache := t.serverCache
+	t.serverCacheLock.Unlock()
+
+	rendered := make([]string, len(conf.Servers))
+	keys := make([]serverCacheKey, len(conf.Servers))
+	errs := make([]error, len(conf.Servers))
+
+	workers := runtime.NumCPU()
+	if workers > len(conf.Servers) {
+		workers = len(conf.Servers)
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				keys[i], rendered[i], errs[i] = t.renderServerBlock(*conf, conf.Servers[i], globalHash, cache)
+			}
+		}()
+	}
+
+	for i := range conf.Servers {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	servers := make(map[string]string, len(conf.Servers))
+	newCache := make(map[serverCacheKey]string, len(conf.Servers))
+	for i, server := range conf.Servers {
+		if errs[i] != nil {
+			return nil, errs[i]
+		}
+
+		servers[server.Hostname] = rendered[i]
+		newCache[keys[i]] = rendered[i]
+	}
+
+	if conf.Admission {
+		return servers, nil
+	}
+
+	t.serverCacheLock.Lock()
+	t.serverCache = newCache
+	t.serverCacheLock.Unlock()
+
+	return servers, nil
+}
+
+// renderServerBlock renders the server block of a server or returns it from the cache
+func (t *Template) renderServerBlock(all config.TemplateConfig, server *ingress.Server, globalHash uint64, cache map[serverCacheKey]string) (serverCacheKey, string, error) {
+	serverHash, err := hashServer(server)
+	if err != nil {
+		return serverCacheKey{}, "", fmt.Errorf("unexpected error hashing server %v: %w", server.Hostname, err)
+	}
+
+	key := serverCacheKey{global: globalHash, server: serverHash}
+	if block, ok := cache[key]; ok {
+		return key, block, nil
+	}
+
+	klog.V(3).InfoS("Rendering server block", "hostname", server.Hostname)
+
+	buf := t.bp.Get()
+	defer t.bp.Put(buf)
+
+	err = t.tmpl.ExecuteTemplate(buf, serverBlockTemplate, struct{ First, Second interface{} }{all, server})
+	if err != nil {
+		return serverCacheKey{}, "", err
+	}
+
+	return key, buf.String(), nil
+}
@@ -25,23 +25,31 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"math/big"
 	"net"
 	"net/url"
 	"os"
 	"reflect"
 	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	text_template "text/template"
 
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog/v2"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/csp"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/errorpage"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/splithorizon"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
@@ -78,6 +86,10 @@ type Template struct {
 	tmpl *text_template.Template
 
 	bp *BufferPool
+
+	serverCacheLock sync.Mutex
+	// serverCache contains the server blocks rendered in the last execution
+	serverCache map[serverCacheKey]string
 }
 
 // NewTemplate returns a new Template instance or an
@@ -93,6 +105,14 @@ func NewTemplate(file string) (*Template, error) {
 		return nil, err
 	}
 
+	if err := checkAPIVersion(tmpl); err != nil {
+		return nil, fmt.Errorf("incompatible template %s: %w", file, err)
+	}
+
+	if unknown := unknownFields(tmpl); len(unknown) > 0 {
+		klog.Warningf("The template %s references fields not provided by the controller: %v. Rendering the configuration could fail", file, strings.Join(unknown, ", "))
+	}
+
 	return &Template{
 		tmpl: tmpl,
 		bp:   NewBufferPool(defBufferSize),
@@ -197,6 +217,9 @@ func cleanConf(in, out *bytes.Buffer) error {
 /* LuaConfig defines the structure that will be written as a config for lua scripts
 The json format should follow what's expected by lua:
 		use_forwarded_headers = %t,
+		trusted_proxies = "%v",
+		forwarded_headers_mode = "%v",
+		forwarded_scheme_override = "%v",
 		use_proxy_protocol = %t,
 		is_ssl_passthrough_enabled = %t,
 		http_redirect_code = %v,
@@ -206,21 +229,34 @@ The json format should follow what's expected by lua:
 		hsts_max_age = %v,
 		hsts_include_subdomains = %t,
 		hsts_preload = %t,
+
+		dns_cache_stale_ttl = %v,
+
+		upstream_ip_family_preference = "%v",
+		upstream_ip_family_fallback_timeout = %v,
 */
 
 type LuaConfig struct {
 	EnableMetrics           bool           `json:"enable_metrics"`
 	ListenPorts             LuaListenPorts `json:"listen_ports"`
 	UseForwardedHeaders     bool           `json:"use_forwarded_headers"`
+	TrustedProxies          string         `json:"trusted_proxies"`
+	ForwardedHeadersMode    string         `json:"forwarded_headers_mode"`
+	ForwardedSchemeOverride string         `json:"forwarded_scheme_override"`
 	UseProxyProtocol        bool           `json:"use_proxy_protocol"`
 	IsSSLPassthroughEnabled bool           `json:"is_ssl_passthrough_enabled"`
 	HTTPRedirectCode        int            `json:"http_redirect_code"`
 	EnableOCSP              bool           `json:"enable_ocsp"`
+	OCSPPrefetch            bool           `json:"ocsp_prefetch"`
 	MonitorBatchMaxSize     int            `json:"monitor_batch_max_size"`
 	HSTS                    bool           `json:"hsts"`
 	HSTSMaxAge              string         `json:"hsts_max_age"`
 	HSTSIncludeSubdomains   bool           `json:"hsts_include_subdomains"`
 	HSTSPreload             bool           `json:"hsts_preload"`
+	DNSCacheStaleTTL        int            `json:"dns_cache_stale_ttl"`
+
+	UpstreamIPFamilyPreference      string `json:"upstream_ip_family_preference"`
+	UpstreamIPFamilyFallbackTimeout int    `json:"upstream_ip_family_fallback_timeout"`
 }
 
 type LuaListenPorts struct {
@@ -246,7 +282,16 @@ func (t *Template) Write(conf *config.TemplateConfig) ([]byte, error) {
 		klog.InfoS("NGINX", "configuration", string(b))
 	}
 
-	err := t.tmpl.Execute(tmplBuf, *conf)
+	tc := *conf
+	if t.tmpl.Lookup(serverBlockTemplate) != nil {
+		servers, err := t.renderServers(&tc)
+		if err != nil {
+			return nil, err
+		}
+		tc.RenderedServers = servers
+	}
+
+	err := t.tmpl.Execute(tmplBuf, tc)
 	if err != nil {
 		return nil, err
 	}
@@ -285,12 +330,21 @@ var funcMap = text_template.FuncMap{
 	"buildAuthProxySetHeaders":        buildAuthProxySetHeaders,
 	"buildAuthUpstreamName":           buildAuthUpstreamName,
 	"shouldApplyAuthUpstream":         shouldApplyAuthUpstream,
+	"shouldApplyAuthFailureMode":      shouldApplyAuthFailureMode,
 	"extractHostPort":                 extractHostPort,
+	"shouldResolveAuthURL":            shouldResolveAuthURL,
+	"buildAuthURLPort":                buildAuthURLPort,
 	"changeHostPort":                  changeHostPort,
 	"buildProxyPass":                  buildProxyPass,
 	"filterRateLimits":                filterRateLimits,
+	"filterSplitHorizons":             filterSplitHorizons,
+	"buildForwardedHeadersMode":       buildForwardedHeadersMode,
 	"buildRateLimitZones":             buildRateLimitZones,
 	"buildRateLimit":                  buildRateLimit,
+	"buildLimitAuthenticatedKey":      buildLimitAuthenticatedKey,
+	"buildLimitExemptionVariable":     buildLimitExemptionVariable,
+	"buildLimitExempted":              buildLimitExempted,
+	"buildLimitConnKey":               buildLimitConnKey,
 	"locationConfigForLua":            locationConfigForLua,
 	"buildResolvers":                  buildResolvers,
 	"buildUpstreamName":               buildUpstreamName,
@@ -308,10 +362,12 @@ var funcMap = text_template.FuncMap{
 	"formatIP":                        formatIP,
 	"quote":                           quote,
 	"buildNextUpstream":               buildNextUpstream,
+	"buildBackupNextUpstream":         buildBackupNextUpstream,
 	"getIngressInformation":           getIngressInformation,
 	"serverConfig": func(all config.TemplateConfig, server *ingress.Server) interface{} {
 		return struct{ First, Second interface{} }{all, server}
 	},
+	"renderServer":                       renderServer,
 	"isValidByteSize":                    isValidByteSize,
 	"buildForwardedFor":                  buildForwardedFor,
 	"buildAuthSignURL":                   buildAuthSignURL,
@@ -320,8 +376,11 @@ var funcMap = text_template.FuncMap{
 	"proxySetHeader":                     proxySetHeader,
 	"enforceRegexModifier":               enforceRegexModifier,
 	"buildCustomErrorDeps":               buildCustomErrorDeps,
+	"buildErrorLocationDeps":             buildErrorLocationDeps,
 	"buildCustomErrorLocationsPerServer": buildCustomErrorLocationsPerServer,
 	"shouldLoadModSecurityModule":        shouldLoadModSecurityModule,
+	"shouldLoadBrotliModule":             shouldLoadBrotliModule,
+	"hasOIDCAuthLocations":               hasOIDCAuthLocations,
 	"buildHTTPListener":                  buildHTTPListener,
 	"buildHTTPSListener":                 buildHTTPSListener,
 	"buildOpentelemetryForLocation":      buildOpentelemetryForLocation,
@@ -331,6 +390,13 @@ var funcMap = text_template.FuncMap{
 	"shouldLoadAuthDigestModule":         shouldLoadAuthDigestModule,
 	"buildServerName":                    buildServerName,
 	"buildCorsOriginRegex":               buildCorsOriginRegex,
+	"supportsDirective":                  supportsDirective,
+	"buildProvenanceComment":             buildProvenanceComment,
+	"isGRPCWebLocation":                  isGRPCWebLocation,
+	"buildCSPReportPath":                 buildCSPReportPath,
+	"buildCSPHeaders":                    buildCSPHeaders,
+	"buildHTTPVersionsRegex":             buildHTTPVersionsRegex,
+	"buildClientAbortLogPercentage":      buildClientAbortLogPercentage,
 }
 
 // escapeLiteralDollar will replace the $ character with ${literal_dollar}
@@ -437,21 +503,161 @@ func locationConfigForLua(l, a interface{}) string {
 	    force_no_ssl_redirect = string_to_bool(ngx.var.force_no_ssl_redirect),
 	    preserve_trailing_slash = string_to_bool(ngx.var.preserve_trailing_slash),
 	    use_port_in_redirects = string_to_bool(ngx.var.use_port_in_redirects),
+	    ssl_redirect_code = tonumber(ngx.var.ssl_redirect_code),
+	    ssl_redirect_strip_query = string_to_bool(ngx.var.ssl_redirect_strip_query),
+	    ssl_redirect_exempt_paths = ngx.var.ssl_redirect_exempt_paths,
 	*/
 
-	return fmt.Sprintf(`
+	redirectPolicy := location.Rewrite.SSLRedirectPolicy
+	redirectCode := redirectPolicy.Code
+	if redirectCode == 0 {
+		redirectCode = all.Cfg.HTTPRedirectCode
+	}
+
+	luaConfig := fmt.Sprintf(`
 	    set $force_ssl_redirect "%t";
 	    set $ssl_redirect "%t";
 	    set $force_no_ssl_redirect "%t";
 	    set $preserve_trailing_slash "%t";
 	    set $use_port_in_redirects "%t";
+	    set $ssl_redirect_code "%d";
+	    set $ssl_redirect_strip_query "%t";
+	    set $ssl_redirect_exempt_paths "%s";
 	`,
 		location.Rewrite.ForceSSLRedirect,
 		location.Rewrite.SSLRedirect,
 		isLocationInLocationList(l, all.Cfg.NoTLSRedirectLocations),
 		location.Rewrite.PreserveTrailingSlash,
 		location.UsePortInRedirects,
+		redirectCode,
+		redirectPolicy.StripQuery,
+		strings.Join(redirectPolicy.ExemptPaths, ","),
 	)
+
+	// the read timeout of the location caps the timeout budget of the requests
+	if location.Proxy.TimeoutBudgetHeader != "" {
+		luaConfig += fmt.Sprintf(`    set $timeout_budget_header "%s";
+	    set $timeout_budget_max "%d";
+	`,
+			location.Proxy.TimeoutBudgetHeader,
+			location.Proxy.ReadTimeout,
+		)
+	}
+
+	// the credentials kept in memory are verified by Lua instead of auth_basic
+	if location.BasicDigestAuth.Secured && location.BasicDigestAuth.InMemory &&
+		!isLocationInLocationList(l, all.Cfg.NoAuthLocations) {
+		luaConfig += fmt.Sprintf(`    set $basic_auth_key "%s";
+	    set $basic_auth_realm "%s";
+	`,
+			location.BasicDigestAuth.Key,
+			location.BasicDigestAuth.Realm,
+		)
+	}
+
+	// the HSTS header of the hosts of the Ingress is overridden in the header filter
+	if location.HSTS.MaxAge != "" {
+		luaConfig += fmt.Sprintf(`    set $hsts_max_age "%s";
+	`, location.HSTS.MaxAge)
+	}
+	if location.HSTS.IncludeSubdomainsSet {
+		luaConfig += fmt.Sprintf(`    set $hsts_include_subdomains "%t";
+	`, location.HSTS.IncludeSubdomains)
+	}
+	if location.HSTS.PreloadSet {
+		luaConfig += fmt.Sprintf(`    set $hsts_preload "%t";
+	`, location.HSTS.Preload)
+	}
+	if len(location.HSTS.ExcludeHosts) > 0 {
+		luaConfig += fmt.Sprintf(`    set $hsts_exclude_hosts "%s";
+	`, strings.Join(location.HSTS.ExcludeHosts, ","))
+	}
+
+	// the gRPC-Web requests and responses are translated by Lua
+	if isGRPCWebLocation(location) {
+		luaConfig += `    set $grpc_web "true";
+	`
+	}
+
+	// the request bodies are inspected by Lua, rejecting the bodies exceeding the limits
+	if location.BodyInspection.Enabled() {
+		luaConfig += `    set $body_inspection_rejection "";
+	`
+		limits := []struct {
+			name  string
+			value int
+		}{
+			{"xml_max_depth", location.BodyInspection.XMLMaxDepth},
+			{"xml_max_entity_expansions", location.BodyInspection.XMLMaxEntityExpansions},
+			{"json_max_depth", location.BodyInspection.JSONMaxDepth},
+			{"json_max_array_size", location.BodyInspection.JSONMaxArraySize},
+		}
+		for _, limit := range limits {
+			if limit.value > 0 {
+				luaConfig += fmt.Sprintf(`    set $body_inspection_%s "%d";
+	`, limit.name, limit.value)
+			}
+		}
+	}
+
+	// the forwarded headers are computed by Lua as defined by the IngressClass
+	if location.ForwardedHeaders.Mode != "" {
+		luaConfig += fmt.Sprintf(`    set $forwarded_headers_mode "%s";
+	`, location.ForwardedHeaders.Mode)
+	}
+	if location.ForwardedHeaders.SchemeOverride != "" {
+		luaConfig += fmt.Sprintf(`    set $forwarded_scheme_override "%s";
+	`, location.ForwardedHeaders.SchemeOverride)
+	}
+	if buildForwardedHeadersMode(location, all.Cfg) == config.ForwardedHeadersAppend {
+		luaConfig += `    set $forwarded_host "";
+	    set $forwarded_port "";
+	    set $forwarded_proto "";
+	`
+	}
+
+	// the requests fail over to the backup service in the Lua balancer
+	if location.BackupUpstreamName != "" {
+		codes := make([]string, 0, len(location.Backup.FailureCodes))
+		for _, code := range location.Backup.FailureCodes {
+			codes = append(codes, strconv.Itoa(code))
+		}
+		luaConfig += fmt.Sprintf(`    set $proxy_backup_upstream_name "%s";
+	    set $backup_failure_codes "%s";
+	    set $backup_failover "";
+	`, location.BackupUpstreamName, strings.Join(codes, " "))
+	}
+
+	// the header is sent to the backend when the expression evaluated by Lua is true
+	if location.ConditionalHeader.Expression != nil {
+		luaConfig += fmt.Sprintf(`    set $conditional_header_expression "%s";
+	    set $conditional_header_value "%s";
+	`, location.ConditionalHeader.Expression.Encode(), location.ConditionalHeader.Value)
+	}
+
+	// the users are authenticated by Lua with the OpenID Connect issuer
+	if location.OIDCAuth.Key != "" && !isLocationInLocationList(l, all.Cfg.NoAuthLocations) {
+		luaConfig += fmt.Sprintf(`    set $oidc_key "%s";
+	    set $oidc_issuer "%s";
+	    set $oidc_scopes "%s";
+	    set $oidc_redirect_path "%s";
+	    set $oidc_cookie_name "%s";
+	    set $oidc_cookie_domain "%s";
+	    set $oidc_cookie_samesite "%s";
+	    set $oidc_session_duration "%d";
+	`,
+			location.OIDCAuth.Key,
+			location.OIDCAuth.Issuer,
+			location.OIDCAuth.Scopes,
+			location.OIDCAuth.RedirectPath,
+			location.OIDCAuth.CookieName,
+			location.OIDCAuth.CookieDomain,
+			location.OIDCAuth.CookieSameSite,
+			location.OIDCAuth.SessionDuration,
+		)
+	}
+
+	return luaConfig
 }
 
 // buildResolvers returns the resolvers reading the /etc/resolv.conf file
@@ -667,6 +873,87 @@ func shouldApplyAuthUpstream(l, c interface{}) bool {
 	return true
 }
 
+// shouldApplyAuthFailureMode returns true when ExternalAuth.URL is set and
+// ExternalAuth.FailureMode lets the requests in while the auth service fails.
+// The failures are only seen by the `ngx.location.capture` Lua subrequests.
+func shouldApplyAuthFailureMode(l, c interface{}) bool {
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", l)
+		return false
+	}
+
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return false
+	}
+
+	if location.ExternalAuth.URL == "" {
+		return false
+	}
+
+	if location.ExternalAuth.FailureMode != authreq.FailureModeAllow && location.ExternalAuth.FailureMode != authreq.FailureModeCached {
+		return false
+	}
+
+	if cfg.UseHTTP2 {
+		klog.Warning("The auth-failure-mode annotation is not supported with HTTP/2")
+		return false
+	}
+
+	return true
+}
+
+// shouldResolveAuthURL returns true when the host of the ExternalAuth.URL is
+// resolved by the DNS cache of Lua, when dns-cache-auth-url is enabled and the
+// host is neither an IP address nor contains variables
+func shouldResolveAuthURL(l, c interface{}) bool {
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", l)
+		return false
+	}
+
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return false
+	}
+
+	if !cfg.DNSCacheAuthURL || location.ExternalAuth.URL == "" {
+		return false
+	}
+
+	authURL, err := parser.StringToURL(location.ExternalAuth.URL)
+	if err != nil {
+		return false
+	}
+
+	host := authURL.Hostname()
+	return !strings.Contains(host, "$") && net.ParseIP(host) == nil
+}
+
+// buildAuthURLPort returns the port of the URL specified by url, or the
+// default port of its scheme
+func buildAuthURLPort(newURL string) string {
+	authURL, err := parser.StringToURL(newURL)
+	if err != nil {
+		klog.Errorf("expected a valid URL but %s was returned", newURL)
+		return ""
+	}
+
+	if port := authURL.Port(); port != "" {
+		return port
+	}
+
+	if authURL.Scheme == "https" {
+		return "443"
+	}
+
+	return "80"
+}
+
 // extractHostPort will extract the host:port part from the URL specified by url
 func extractHostPort(newURL string) string {
 	if newURL == "" {
@@ -762,6 +1049,17 @@ func buildProxyPass(_ string, b, loc interface{}) string {
 	// defProxyPass returns the default proxy_pass, just the name of the upstream
 	defProxyPass := fmt.Sprintf("%v %s%s;", proxyPass, proto, upstreamName)
 
+	if len(location.Rewrite.Rules) > 0 {
+		var xForwardedPrefix string
+
+		if location.XForwardedPrefix != "" {
+			xForwardedPrefix = fmt.Sprintf("%s X-Forwarded-Prefix %q;\n", proxySetHeader(location), location.XForwardedPrefix)
+		}
+
+		return fmt.Sprintf(`
+%s%v%v %s%s;`, buildRewriteRules(location), xForwardedPrefix, proxyPass, proto, upstreamName)
+	}
+
 	// if the path in the ingress rule is equals to the target: no special rewrite
 	if path == location.Rewrite.Target {
 		return defProxyPass
@@ -783,6 +1081,50 @@ rewrite "(?i)%s" %s break;
 	return defProxyPass
 }
 
+// buildRewriteRules produces the rewrite directives of the rules of a location,
+// applied in order after the rewrite-target annotation
+func buildRewriteRules(location *ingress.Location) string {
+	var buffer bytes.Buffer
+
+	if location.Rewrite.Target != "" && location.Rewrite.Target != location.Path {
+		buffer.WriteString(fmt.Sprintf("rewrite \"(?i)%s\" %s;\n", location.Path, location.Rewrite.Target))
+	}
+
+	for _, rule := range location.Rewrite.Rules {
+		var transform string
+		switch rule.Transform {
+		case rewrite.LowercaseTransform:
+			transform = "string.lower"
+		case rewrite.UppercaseTransform:
+			transform = "string.upper"
+		}
+
+		if transform != "" {
+			buffer.WriteString("set $rewrite_original_uri $uri;\n")
+		}
+
+		// the NGINX configuration parser removes one level of backslashes in quoted strings
+		buffer.WriteString(fmt.Sprintf("rewrite \"%s\" \"%s\";\n", strings.ReplaceAll(rule.Regex, `\`, `\\`), rule.Replacement))
+
+		// the transformation is applied only when the rule rewrites the URI
+		if transform != "" {
+			buffer.WriteString(fmt.Sprintf(`set_by_lua_block $rewrite_transformed_uri {
+    if ngx.var.uri == ngx.var.rewrite_original_uri then
+        return ngx.var.uri
+    end
+    return %s(ngx.var.uri)
+}
+rewrite ^ $rewrite_transformed_uri;
+`, transform))
+		}
+	}
+
+	// stops processing the rewrite directives without searching a new location
+	buffer.WriteString("break;\n")
+
+	return buffer.String()
+}
+
 func filterRateLimits(input interface{}) []ratelimit.Config {
 	ratelimits := []ratelimit.Config{}
 	found := sets.Set[string]{}
@@ -803,6 +1145,122 @@ func filterRateLimits(input interface{}) []ratelimit.Config {
 	return ratelimits
 }
 
+// buildForwardedHeadersMode returns the mode of the forwarded headers of a location,
+// defined by its IngressClass or else by the configuration
+func buildForwardedHeadersMode(input interface{}, cfg interface{}) string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+
+	c, ok := cfg.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", cfg)
+		return ""
+	}
+
+	if location.ForwardedHeaders.Mode != "" {
+		return location.ForwardedHeaders.Mode
+	}
+	return c.ForwardedHeadersMode
+}
+
+// filterSplitHorizons returns the internal clients of the Ingresses of the
+// locations, once per Ingress
+func filterSplitHorizons(input interface{}) []splithorizon.Config {
+	splitHorizons := []splithorizon.Config{}
+	found := sets.Set[string]{}
+
+	servers, ok := input.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected a '[]*ingress.Server' type but %T was returned", input)
+		return splitHorizons
+	}
+	for _, server := range servers {
+		for _, loc := range server.Locations {
+			if loc.SplitHorizon.ID != "" && !found.Has(loc.SplitHorizon.ID) {
+				found.Insert(loc.SplitHorizon.ID)
+				splitHorizons = append(splitHorizons, loc.SplitHorizon)
+			}
+		}
+	}
+	return splitHorizons
+}
+
+// limitKeyVariable returns the NGINX variable containing the value of a header,
+// of a cookie or of a JWT claim, the claims being read by Lua in jwtVariable
+func limitKeyVariable(source, name, jwtVariable string) string {
+	switch source {
+	case ratelimit.HeaderKey:
+		return fmt.Sprintf("$http_%s", strings.ReplaceAll(strings.ToLower(name), "-", "_"))
+	case ratelimit.CookieKey:
+		return fmt.Sprintf("$cookie_%s", name)
+	case ratelimit.JWTClaimKey:
+		return "$" + jwtVariable
+	default:
+		return "$binary_remote_addr"
+	}
+}
+
+// buildLimitConnKey returns the NGINX variable containing the key of the
+// connection limit of an Ingress rule
+func buildLimitConnKey(input interface{}) string {
+	rl, ok := input.(ratelimit.Config)
+	if !ok {
+		klog.Errorf("expected a 'ratelimit.Config' type but %T was returned", input)
+		return ""
+	}
+
+	source, name := ratelimit.ParseConnectionsKey(rl.ConnectionsKey)
+	return limitKeyVariable(source, name, fmt.Sprintf("limit_jwt_%s", rl.ID))
+}
+
+// buildLimitAuthenticatedKey returns the NGINX variable containing the identity
+// of the authenticated requests of an Ingress rule
+func buildLimitAuthenticatedKey(input interface{}) string {
+	rl, ok := input.(ratelimit.Config)
+	if !ok {
+		klog.Errorf("expected a 'ratelimit.Config' type but %T was returned", input)
+		return ""
+	}
+
+	source, name := ratelimit.ParseConnectionsKey(rl.AuthenticatedKey)
+	return limitKeyVariable(source, name, fmt.Sprintf("limit_authenticated_jwt_%s", rl.ID))
+}
+
+// buildLimitExemptionVariable returns the NGINX variable containing the value
+// of the header or of the JWT claim of an exemption of an Ingress rule
+func buildLimitExemptionVariable(input interface{}, index int) string {
+	rl, ok := input.(ratelimit.Config)
+	if !ok {
+		klog.Errorf("expected a 'ratelimit.Config' type but %T was returned", input)
+		return ""
+	}
+	if index < 0 || index >= len(rl.Exemptions) {
+		klog.Errorf("invalid exemption %d of the rate limit %v", index, rl.Name)
+		return ""
+	}
+
+	exemption := rl.Exemptions[index]
+	return limitKeyVariable(exemption.Source, exemption.Name, fmt.Sprintf("limit_exempt_jwt_%s_%d", rl.ID, index))
+}
+
+// buildLimitExempted returns the NGINX variable that is 1 when the requests of
+// an Ingress rule are not rate limited and 0 otherwise
+func buildLimitExempted(input interface{}) string {
+	rl, ok := input.(ratelimit.Config)
+	if !ok {
+		klog.Errorf("expected a 'ratelimit.Config' type but %T was returned", input)
+		return ""
+	}
+
+	if len(rl.Exemptions) > 0 {
+		return fmt.Sprintf("$limit_exempted_%s", rl.ID)
+	}
+	return fmt.Sprintf("$allowlist_%s", rl.ID)
+}
+
 // buildRateLimitZones produces an array of limit_conn_zone in order to allow
 // rate limiting of request. Each Ingress rule could have up to three zones, one
 // for connection limit by IP address, one for limiting requests per minute, and
@@ -819,7 +1277,13 @@ func buildRateLimitZones(input interface{}) []string {
 	for _, server := range servers {
 		for _, loc := range server.Locations {
 			if loc.RateLimit.Connections.Limit > 0 {
-				zone := fmt.Sprintf("limit_conn_zone $limit_%s zone=%v:%vm;",
+				key := "limit"
+				if loc.RateLimit.ConnectionsKey != "" {
+					key = "limit_conn"
+				}
+
+				zone := fmt.Sprintf("limit_conn_zone $%s_%s zone=%v:%vm;",
+					key,
 					loc.RateLimit.ID,
 					loc.RateLimit.Connections.Name,
 					loc.RateLimit.Connections.SharedSize)
@@ -849,6 +1313,28 @@ func buildRateLimitZones(input interface{}) []string {
 					zones.Insert(zone)
 				}
 			}
+
+			if loc.RateLimit.AuthenticatedRPM.Limit > 0 {
+				zone := fmt.Sprintf("limit_req_zone $limit_authenticated_%s zone=%v:%vm rate=%vr/m;",
+					loc.RateLimit.ID,
+					loc.RateLimit.AuthenticatedRPM.Name,
+					loc.RateLimit.AuthenticatedRPM.SharedSize,
+					loc.RateLimit.AuthenticatedRPM.Limit)
+				if !zones.Has(zone) {
+					zones.Insert(zone)
+				}
+			}
+
+			if loc.RateLimit.AuthenticatedRPS.Limit > 0 {
+				zone := fmt.Sprintf("limit_req_zone $limit_authenticated_%s zone=%v:%vm rate=%vr/s;",
+					loc.RateLimit.ID,
+					loc.RateLimit.AuthenticatedRPS.Name,
+					loc.RateLimit.AuthenticatedRPS.SharedSize,
+					loc.RateLimit.AuthenticatedRPS.Limit)
+				if !zones.Has(zone) {
+					zones.Insert(zone)
+				}
+			}
 		}
 	}
 
@@ -866,7 +1352,24 @@ func buildRateLimit(input interface{}) []string {
 		return limits
 	}
 
+	// the JWT claims of the exemptions and of the identity are read by Lua
+	for i, exemption := range loc.RateLimit.Exemptions {
+		if exemption.Source == ratelimit.JWTClaimKey {
+			limits = append(limits, fmt.Sprintf(`set_by_lua_block $limit_exempt_jwt_%s_%d { return require("util.jwt").claim(ngx.var.http_authorization, %q) }`,
+				loc.RateLimit.ID, i, exemption.Name))
+		}
+	}
+	if source, name := ratelimit.ParseConnectionsKey(loc.RateLimit.AuthenticatedKey); source == ratelimit.JWTClaimKey {
+		limits = append(limits, fmt.Sprintf(`set_by_lua_block $limit_authenticated_jwt_%s { return require("util.jwt").claim(ngx.var.http_authorization, %q) }`,
+			loc.RateLimit.ID, name))
+	}
+
 	if loc.RateLimit.Connections.Limit > 0 {
+		if source, name := ratelimit.ParseConnectionsKey(loc.RateLimit.ConnectionsKey); source == ratelimit.JWTClaimKey {
+			limits = append(limits, fmt.Sprintf(`set_by_lua_block $limit_jwt_%s { return require("util.jwt").claim(ngx.var.http_authorization, %q) }`,
+				loc.RateLimit.ID, name))
+		}
+
 		limit := fmt.Sprintf("limit_conn %v %v;",
 			loc.RateLimit.Connections.Name, loc.RateLimit.Connections.Limit)
 		limits = append(limits, limit)
@@ -884,6 +1387,18 @@ func buildRateLimit(input interface{}) []string {
 		limits = append(limits, limit)
 	}
 
+	if loc.RateLimit.AuthenticatedRPS.Limit > 0 {
+		limit := fmt.Sprintf("limit_req zone=%v burst=%v nodelay;",
+			loc.RateLimit.AuthenticatedRPS.Name, loc.RateLimit.AuthenticatedRPS.Burst)
+		limits = append(limits, limit)
+	}
+
+	if loc.RateLimit.AuthenticatedRPM.Limit > 0 {
+		limit := fmt.Sprintf("limit_req zone=%v burst=%v nodelay;",
+			loc.RateLimit.AuthenticatedRPM.Name, loc.RateLimit.AuthenticatedRPM.Burst)
+		limits = append(limits, limit)
+	}
+
 	if loc.RateLimit.LimitRateAfter > 0 {
 		limit := fmt.Sprintf("limit_rate_after %vk;",
 			loc.RateLimit.LimitRateAfter)
@@ -997,6 +1512,37 @@ func buildNextUpstream(i, r interface{}) string {
 	return strings.Join(nextUpstreamCodes, " ")
 }
 
+// buildBackupNextUpstream returns the cases of the proxy_next_upstream directive
+// of a location, with the failure codes of its backup service: NGINX tries the
+// next upstream on these codes and the Lua balancer picks the backup service
+func buildBackupNextUpstream(l interface{}) string {
+	location, ok := l.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", l)
+		return ""
+	}
+
+	nextUpstream := location.Proxy.NextUpstream
+	if location.BackupUpstreamName == "" || len(location.Backup.FailureCodes) == 0 {
+		return nextUpstream
+	}
+
+	parts := []string{}
+	for _, v := range strings.Split(nextUpstream, " ") {
+		if v != "" && v != "off" {
+			parts = append(parts, v)
+		}
+	}
+	for _, code := range location.Backup.FailureCodes {
+		v := fmt.Sprintf("http_%d", code)
+		if !slices.Contains(parts, v) {
+			parts = append(parts, v)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
+
 // refer to http://nginx.org/en/docs/syntax.html
 // Nginx differentiates between size and offset
 // offset directives support gigabytes in addition
@@ -1243,15 +1789,20 @@ func proxySetHeader(loc interface{}) string {
 	return "proxy_set_header"
 }
 
+// customErrorDeps contains the data required to build the 'CUSTOM_ERRORS' template
+type customErrorDeps struct {
+	UpstreamName       string
+	ErrorCodes         []int
+	EnableMetrics      bool
+	ModsecurityEnabled bool
+	ErrorPage          errorpage.Config
+	ProxySetHeaders    map[string]string
+}
+
 // buildCustomErrorDeps is a utility function returning a struct wrapper with
 // the data required to build the 'CUSTOM_ERRORS' template
 func buildCustomErrorDeps(upstreamName string, errorCodes []int, enableMetrics, modsecurityEnabled bool) interface{} {
-	return struct {
-		UpstreamName       string
-		ErrorCodes         []int
-		EnableMetrics      bool
-		ModsecurityEnabled bool
-	}{
+	return customErrorDeps{
 		UpstreamName:       upstreamName,
 		ErrorCodes:         errorCodes,
 		EnableMetrics:      enableMetrics,
@@ -1259,9 +1810,30 @@ func buildCustomErrorDeps(upstreamName string, errorCodes []int, enableMetrics,
 	}
 }
 
+// buildErrorLocationDeps returns the data required to build the 'CUSTOM_ERRORS'
+// template for an errorLocation returned by buildCustomErrorLocationsPerServer
+func buildErrorLocationDeps(input interface{}, enableMetrics, modsecurityEnabled bool, proxySetHeaders map[string]string) interface{} {
+	errLocation, ok := input.(errorLocation)
+	if !ok {
+		klog.Errorf("expected an 'errorLocation' type but %T was returned", input)
+		return customErrorDeps{}
+	}
+
+	return customErrorDeps{
+		UpstreamName:       errLocation.UpstreamName,
+		ErrorCodes:         errLocation.Codes,
+		EnableMetrics:      enableMetrics,
+		ModsecurityEnabled: modsecurityEnabled,
+		ErrorPage:          errLocation.ErrorPage,
+		ProxySetHeaders:    proxySetHeaders,
+	}
+}
+
 type errorLocation struct {
 	UpstreamName string
 	Codes        []int
+	// ErrorPage defines how the requests are sent to the upstream
+	ErrorPage errorpage.Config
 }
 
 // buildCustomErrorLocationsPerServer is a utility function which will collect all
@@ -1277,12 +1849,22 @@ func buildCustomErrorLocationsPerServer(input interface{}) []errorLocation {
 		return nil
 	}
 
-	codesMap := make(map[string]map[int]bool)
+	// the locations sending the requests to the same upstream in different
+	// ways require different error locations
+	type errorLocationKey struct {
+		upstream  string
+		errorPage errorpage.Config
+	}
+
+	codesMap := make(map[errorLocationKey]map[int]bool)
 	for _, loc := range server.Locations {
-		backendUpstream := loc.DefaultBackendUpstreamName
+		key := errorLocationKey{
+			upstream:  loc.DefaultBackendUpstreamName,
+			errorPage: loc.ErrorPage,
+		}
 
 		var dedupedCodes map[int]bool
-		if existingMap, ok := codesMap[backendUpstream]; ok {
+		if existingMap, ok := codesMap[key]; ok {
 			dedupedCodes = existingMap
 		} else {
 			dedupedCodes = make(map[int]bool)
@@ -1291,25 +1873,30 @@ func buildCustomErrorLocationsPerServer(input interface{}) []errorLocation {
 		for _, code := range loc.CustomHTTPErrors {
 			dedupedCodes[code] = true
 		}
-		codesMap[backendUpstream] = dedupedCodes
+		codesMap[key] = dedupedCodes
 	}
 
 	errorLocations := []errorLocation{}
 
-	for upstream, dedupedCodes := range codesMap {
+	for key, dedupedCodes := range codesMap {
 		codesForUpstream := []int{}
 		for code := range dedupedCodes {
 			codesForUpstream = append(codesForUpstream, code)
 		}
 		sort.Ints(codesForUpstream)
 		errorLocations = append(errorLocations, errorLocation{
-			UpstreamName: upstream,
+			UpstreamName: key.upstream,
 			Codes:        codesForUpstream,
+			ErrorPage:    key.errorPage,
 		})
 	}
 
 	sort.Slice(errorLocations, func(i, j int) bool {
-		return errorLocations[i].UpstreamName < errorLocations[j].UpstreamName
+		if errorLocations[i].UpstreamName != errorLocations[j].UpstreamName {
+			return errorLocations[i].UpstreamName < errorLocations[j].UpstreamName
+		}
+
+		return errorLocations[i].ErrorPage.Suffix() < errorLocations[j].ErrorPage.Suffix()
 	})
 
 	return errorLocations
@@ -1356,7 +1943,61 @@ func shouldLoadModSecurityModule(c, s interface{}) bool {
 	return false
 }
 
-func buildHTTPListener(t, s interface{}) string {
+// shouldLoadBrotliModule determines whether or not the Brotli modules need to be loaded.
+// They are loaded when Brotli is enabled globally or when a location serves Brotli
+// precompressed responses.
+func shouldLoadBrotliModule(c, s interface{}) bool {
+	cfg, ok := c.(config.Configuration)
+	if !ok {
+		klog.Errorf("expected a 'config.Configuration' type but %T was returned", c)
+		return false
+	}
+
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return false
+	}
+
+	if cfg.EnableBrotli {
+		return true
+	}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.Precompressed.Brotli {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// hasOIDCAuthLocations determines whether or not a location authenticates the users
+// with an OpenID Connect issuer, which requires Lua to verify the TLS certificates.
+func hasOIDCAuthLocations(s interface{}) bool {
+	servers, ok := s.([]*ingress.Server)
+	if !ok {
+		klog.Errorf("expected an '[]*ingress.Server' type but %T was returned", s)
+		return false
+	}
+
+	for _, server := range servers {
+		for _, location := range server.Locations {
+			if location.OIDCAuth.Key != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// buildHTTPListener returns the listen directives of the HTTP port of a
+// server. Internal servers only listen in --internal-http-port, and the
+// catch-all server in both ports.
+func buildHTTPListener(t, s, i interface{}) string {
 	var out []string
 
 	tc, ok := t.(config.TemplateConfig)
@@ -1371,6 +2012,12 @@ func buildHTTPListener(t, s interface{}) string {
 		return ""
 	}
 
+	internal, ok := i.(bool)
+	if !ok {
+		klog.Errorf("expected a 'bool' type but %T was returned", i)
+		return ""
+	}
+
 	addrV4 := []string{""}
 	if len(tc.Cfg.BindAddressIpv4) > 0 {
 		addrV4 = tc.Cfg.BindAddressIpv4
@@ -1378,23 +2025,28 @@ func buildHTTPListener(t, s interface{}) string {
 
 	co := commonListenOptions(&tc, hostname)
 
-	out = append(out, httpListener(addrV4, co, &tc)...)
+	for _, port := range listenerPorts(hostname, internal, tc.ListenPorts.HTTP, tc.ListenPorts.InternalHTTP) {
+		out = append(out, httpListener(addrV4, port, co)...)
 
-	if !tc.IsIPV6Enabled {
-		return strings.Join(out, "\n")
-	}
+		if !tc.IsIPV6Enabled {
+			continue
+		}
 
-	addrV6 := []string{"[::]"}
-	if len(tc.Cfg.BindAddressIpv6) > 0 {
-		addrV6 = tc.Cfg.BindAddressIpv6
-	}
+		addrV6 := []string{"[::]"}
+		if len(tc.Cfg.BindAddressIpv6) > 0 {
+			addrV6 = tc.Cfg.BindAddressIpv6
+		}
 
-	out = append(out, httpListener(addrV6, co, &tc)...)
+		out = append(out, httpListener(addrV6, port, co)...)
+	}
 
 	return strings.Join(out, "\n")
 }
 
-func buildHTTPSListener(t, s interface{}) string {
+// buildHTTPSListener returns the listen directives of the HTTPS port of a
+// server. Internal servers only listen in --internal-https-port, and the
+// catch-all server in both ports.
+func buildHTTPSListener(t, s, i interface{}) string {
 	var out []string
 
 	tc, ok := t.(config.TemplateConfig)
@@ -1409,6 +2061,12 @@ func buildHTTPSListener(t, s interface{}) string {
 		return ""
 	}
 
+	internal, ok := i.(bool)
+	if !ok {
+		klog.Errorf("expected a 'bool' type but %T was returned", i)
+		return ""
+	}
+
 	co := commonListenOptions(&tc, hostname)
 
 	addrV4 := []string{""}
@@ -1416,22 +2074,45 @@ func buildHTTPSListener(t, s interface{}) string {
 		addrV4 = tc.Cfg.BindAddressIpv4
 	}
 
-	out = append(out, httpsListener(addrV4, co, &tc)...)
-
-	if !tc.IsIPV6Enabled {
-		return strings.Join(out, "\n")
+	public := tc.ListenPorts.HTTPS
+	if tc.IsSSLPassthroughEnabled {
+		public = tc.ListenPorts.SSLProxy
 	}
 
-	addrV6 := []string{"[::]"}
-	if len(tc.Cfg.BindAddressIpv6) > 0 {
-		addrV6 = tc.Cfg.BindAddressIpv6
-	}
+	for _, port := range listenerPorts(hostname, internal, public, tc.ListenPorts.InternalHTTPS) {
+		// the connections to the SSL passthrough proxy use the PROXY protocol
+		passthrough := tc.IsSSLPassthroughEnabled && port == tc.ListenPorts.SSLProxy
+		out = append(out, httpsListener(addrV4, port, passthrough, co, &tc)...)
+
+		if !tc.IsIPV6Enabled {
+			continue
+		}
+
+		addrV6 := []string{"[::]"}
+		if len(tc.Cfg.BindAddressIpv6) > 0 {
+			addrV6 = tc.Cfg.BindAddressIpv6
+		}
 
-	out = append(out, httpsListener(addrV6, co, &tc)...)
+		out = append(out, httpsListener(addrV6, port, passthrough, co, &tc)...)
+	}
 
 	return strings.Join(out, "\n")
 }
 
+// listenerPorts returns the ports a server listens in: the internal port
+// for the internal servers, the public port for the other servers, and both
+// for the catch-all server when the internal port is defined
+func listenerPorts(hostname string, internal bool, public, internalPort int) []int {
+	switch {
+	case hostname == "_" && internalPort > 0:
+		return []int{public, internalPort}
+	case internal:
+		return []int{internalPort}
+	default:
+		return []int{public}
+	}
+}
+
 func commonListenOptions(template *config.TemplateConfig, hostname string) string {
 	var out []string
 
@@ -1456,15 +2137,15 @@ func commonListenOptions(template *config.TemplateConfig, hostname string) strin
 	return strings.Join(out, " ")
 }
 
-func httpListener(addresses []string, co string, tc *config.TemplateConfig) []string {
+func httpListener(addresses []string, port int, co string) []string {
 	out := make([]string, 0)
 	for _, address := range addresses {
 		lo := []string{"listen"}
 
 		if address == "" {
-			lo = append(lo, fmt.Sprintf("%v", tc.ListenPorts.HTTP))
+			lo = append(lo, fmt.Sprintf("%v", port))
 		} else {
-			lo = append(lo, fmt.Sprintf("%v:%v", address, tc.ListenPorts.HTTP))
+			lo = append(lo, fmt.Sprintf("%v:%v", address, port))
 		}
 
 		lo = append(lo, co, ";")
@@ -1474,30 +2155,27 @@ func httpListener(addresses []string, co string, tc *config.TemplateConfig) []st
 	return out
 }
 
-func httpsListener(addresses []string, co string, tc *config.TemplateConfig) []string {
+func httpsListener(addresses []string, port int, passthrough bool, co string, tc *config.TemplateConfig) []string {
 	out := make([]string, 0)
 	for _, address := range addresses {
 		lo := []string{"listen"}
 
-		if tc.IsSSLPassthroughEnabled {
-			if address == "" {
-				lo = append(lo, fmt.Sprintf("%v", tc.ListenPorts.SSLProxy))
-			} else {
-				lo = append(lo, fmt.Sprintf("%v:%v", address, tc.ListenPorts.SSLProxy))
-			}
-
-			if !strings.Contains(co, "proxy_protocol") {
-				lo = append(lo, "proxy_protocol")
-			}
+		if address == "" {
+			lo = append(lo, fmt.Sprintf("%v", port))
 		} else {
-			if address == "" {
-				lo = append(lo, fmt.Sprintf("%v", tc.ListenPorts.HTTPS))
-			} else {
-				lo = append(lo, fmt.Sprintf("%v:%v", address, tc.ListenPorts.HTTPS))
-			}
+			lo = append(lo, fmt.Sprintf("%v:%v", address, port))
 		}
 
-		lo = append(lo, co, "ssl;")
+		if passthrough && !strings.Contains(co, "proxy_protocol") {
+			lo = append(lo, "proxy_protocol")
+		}
+
+		// flavors of NGINX without the http2 directive enable HTTP/2 in the listener
+		if tc.Cfg.UseHTTP2 && !tc.Dataplane.Supports("http2") {
+			lo = append(lo, co, "ssl http2;")
+		} else {
+			lo = append(lo, co, "ssl;")
+		}
 
 		out = append(out, strings.Join(lo, " "))
 	}
@@ -1505,6 +2183,18 @@ func httpsListener(addresses []string, co string, tc *config.TemplateConfig) []s
 	return out
 }
 
+// supportsDirective checks if the flavor of NGINX driven by the controller
+// supports a directive
+func supportsDirective(t interface{}, directive string) bool {
+	tc, ok := t.(config.TemplateConfig)
+	if !ok {
+		klog.Errorf("expected a 'config.TemplateConfig' type but %T was returned", t)
+		return true
+	}
+
+	return tc.Dataplane.Supports(directive)
+}
+
 func buildOpentelemetryForLocation(isOTEnabled, isOTTrustSet bool, location *ingress.Location) string {
 	isOTEnabledInLoc := location.Opentelemetry.Enabled
 	isOTSetInLoc := location.Opentelemetry.Set
@@ -1526,6 +2216,17 @@ func buildOpentelemetryForLocation(isOTEnabled, isOTTrustSet bool, location *ing
 		opc += "\nopentelemetry_operation_name " + location.Opentelemetry.OperationName + ";"
 	}
 
+	// the labels of the Ingress allowed by --observability-labels
+	names := make([]string, 0, len(location.ObservabilityLabels))
+	for name := range location.ObservabilityLabels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		opc += fmt.Sprintf("\nopentelemetry_attribute %q %q;", name, location.ObservabilityLabels[name])
+	}
+
 	if (!isOTTrustSet && !location.Opentelemetry.TrustSet) ||
 		(location.Opentelemetry.TrustSet && !location.Opentelemetry.TrustEnabled) {
 		opc += "\nopentelemetry_trust_incoming_spans off;"
@@ -1660,6 +2361,87 @@ func shouldLoadAuthDigestModule(s interface{}) bool {
 	return false
 }
 
+// isGRPCWebLocation returns true when the gRPC-Web requests of the location are
+// translated to gRPC, only done with the gRPC backends
+func isGRPCWebLocation(location *ingress.Location) bool {
+	if location == nil || !location.GRPCWeb {
+		return false
+	}
+
+	return location.BackendProtocol == grpcProtocol || location.BackendProtocol == grpcsProtocol
+}
+
+// cspReportPathPrefix is the prefix of the paths of the endpoints receiving
+// the violation reports of the Content Security Policy of the locations
+const cspReportPathPrefix = "/.ingress-nginx/csp-report/"
+
+// buildCSPReportPath returns the path of the endpoint receiving the violation
+// reports of the Content Security Policy of a location, unique in its server
+func buildCSPReportPath(input interface{}) string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+
+	hasher := sha1.New() // #nosec
+	hasher.Write([]byte(location.Path))
+	if location.PathType != nil {
+		hasher.Write([]byte(*location.PathType))
+	}
+	return cspReportPathPrefix + hex.EncodeToString(hasher.Sum(nil))[:16]
+}
+
+// buildCSPHeaders returns the directives sending the Content Security Policy
+// of a location, reporting the violations to the endpoint of the location
+// when enabled
+func buildCSPHeaders(input interface{}) []string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return []string{}
+	}
+
+	cfg := location.ContentSecurityPolicy
+	if cfg.Policy == "" {
+		return []string{}
+	}
+
+	policy := strings.TrimRight(cfg.Policy, "; ")
+	headers := []string{}
+	if cfg.Report {
+		path := buildCSPReportPath(location)
+		policy = fmt.Sprintf("%s; report-uri %s; report-to %s", policy, path, csp.ReportEndpoint)
+		headers = append(headers, fmt.Sprintf(`more_set_headers 'Reporting-Endpoints: %s="%s"';`, csp.ReportEndpoint, path))
+	}
+
+	return append(headers, fmt.Sprintf(`more_set_headers "%s: %s";`, cfg.Header(), policy))
+}
+
+// buildHTTPVersionsRegex returns the regular expression matching the
+// $server_protocol of the HTTP versions allowed in a location
+func buildHTTPVersionsRegex(input interface{}) string {
+	location, ok := input.(*ingress.Location)
+	if !ok {
+		klog.Errorf("expected an '*ingress.Location' type but %T was returned", input)
+		return ""
+	}
+
+	versions := make([]string, 0, len(location.HTTPVersions))
+	for _, version := range location.HTTPVersions {
+		versions = append(versions, regexp.QuoteMeta(version))
+	}
+
+	return fmt.Sprintf("^HTTP/(%s)$", strings.Join(versions, "|"))
+}
+
+// buildClientAbortLogPercentage returns the percentage of split_clients of
+// the requests closed by the client written in the access log, with the two
+// decimals supported by NGINX
+func buildClientAbortLogPercentage(rate float32) string {
+	return fmt.Sprintf("%.2f%%", math.Max(float64(rate)*100, 0.01))
+}
+
 // buildServerName ensures wildcard hostnames are valid
 func buildServerName(hostname string) string {
 	if !strings.HasPrefix(hostname, "*") {
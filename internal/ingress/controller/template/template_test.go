@@ -25,6 +25,8 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"sort"
 	"strings"
 	"testing"
 
@@ -34,12 +36,24 @@ import (
 	networking "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authoidc"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backupservice"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/bodyinspection"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/conditionalheader"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/csp"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/errorpage"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/hsts"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentelemetry"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/precompressed"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/splithorizon"
 	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+	"k8s.io/ingress-nginx/internal/ingress/expression"
 	"k8s.io/ingress-nginx/internal/nginx"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
 )
@@ -653,6 +667,97 @@ func TestShouldApplyAuthUpstream(t *testing.T) {
 	}
 }
 
+func TestShouldApplyAuthFailureMode(t *testing.T) {
+	loc := &ingress.Location{Path: "/cat"}
+	cfg := config.Configuration{}
+
+	testCases := []struct {
+		title       string
+		authURL     string
+		failureMode string
+		expected    bool
+	}{
+		{"authURL, deny", fooAuthHost, authreq.FailureModeDeny, false},
+		{"authURL, allow", fooAuthHost, authreq.FailureModeAllow, true},
+		{"authURL, cached", fooAuthHost, authreq.FailureModeCached, true},
+		{"empty, allow", "", authreq.FailureModeAllow, false},
+	}
+
+	for _, testCase := range testCases {
+		loc.ExternalAuth.URL = testCase.authURL
+		loc.ExternalAuth.FailureMode = testCase.failureMode
+
+		result := shouldApplyAuthFailureMode(loc, cfg)
+		if result != testCase.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, testCase.expected, result)
+		}
+	}
+
+	// the Lua subrequests are not supported with UseHTTP2
+	cfg.UseHTTP2 = true
+	for _, testCase := range testCases {
+		loc.ExternalAuth.URL = testCase.authURL
+		loc.ExternalAuth.FailureMode = testCase.failureMode
+
+		result := shouldApplyAuthFailureMode(loc, cfg)
+		if result != false {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, false, result)
+		}
+	}
+}
+
+func TestShouldResolveAuthURL(t *testing.T) {
+	loc := &ingress.Location{Path: "/cat"}
+	cfg := config.Configuration{DNSCacheAuthURL: true}
+
+	testCases := []struct {
+		title    string
+		authURL  string
+		expected bool
+	}{
+		{"host", "http://foo.com/bar", true},
+		{"host and port", "https://foo.com:8443/bar", true},
+		{"IP address", "http://10.0.0.1/bar", false},
+		{"IPv6 address", "http://[::1]:8080/bar", false},
+		{"variable", "http://$host/bar", false},
+		{"empty", "", false},
+	}
+
+	for _, testCase := range testCases {
+		loc.ExternalAuth.URL = testCase.authURL
+
+		result := shouldResolveAuthURL(loc, cfg)
+		if result != testCase.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, testCase.expected, result)
+		}
+	}
+
+	loc.ExternalAuth.URL = "http://foo.com/bar"
+	if shouldResolveAuthURL(loc, config.Configuration{}) {
+		t.Errorf("expected the auth-url not to be resolved without dns-cache-auth-url")
+	}
+}
+
+func TestBuildAuthURLPort(t *testing.T) {
+	testCases := []struct {
+		title    string
+		url      string
+		expected string
+	}{
+		{"port", "https://my.auth.site:5000/path", "5000"},
+		{"http", "http://my.auth.site/path", "80"},
+		{"https", "https://my.auth.site/path", "443"},
+		{"missing method", "my.auth.site/path", ""},
+	}
+
+	for _, testCase := range testCases {
+		result := buildAuthURLPort(testCase.url)
+		if result != testCase.expected {
+			t.Errorf("%v: expected '%v' but returned '%v'", testCase.title, testCase.expected, result)
+		}
+	}
+}
+
 func TestExtractHostPort(t *testing.T) {
 	testCases := []struct {
 		title    string
@@ -746,6 +851,231 @@ func TestTemplateWithData(t *testing.T) {
 	}
 }
 
+func TestTemplateAlternateDefaultCertificate(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{PemFileName: "/etc/ingress-controller/ssl/default-tls.pem"}
+	rt, err := ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if strings.Contains(string(rt), "alternate") {
+		t.Errorf("expected no alternate default certificate")
+	}
+
+	dat.Cfg.DefaultSSLCertificate.AlternatePemFileName = "/etc/ingress-controller/ssl/default-tls-alternate.pem"
+	rt, err = ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	for _, directive := range []string{
+		"ssl_certificate     /etc/ingress-controller/ssl/default-tls.pem;",
+		"ssl_certificate     /etc/ingress-controller/ssl/default-tls-alternate.pem;",
+		"ssl_certificate_key /etc/ingress-controller/ssl/default-tls-alternate.pem;",
+	} {
+		if !strings.Contains(string(rt), directive) {
+			t.Errorf("expected the directive %q in the configuration", directive)
+		}
+	}
+}
+
+func TestTemplateUpstreamKeepalive(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	directive := "Connection        $connection_upgrade_close;"
+	rt, err := ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if strings.Contains(string(rt), directive) {
+		t.Errorf("expected the upstream connections to be reused by default")
+	}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Connection.KeepaliveDisabled = true
+		}
+	}
+	rt, err = ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	if !strings.Contains(string(rt), directive) {
+		t.Errorf("expected the directive %q in the configuration", directive)
+	}
+}
+
+func TestTemplatePrecompressedResponses(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+	dat.Cfg.EnableBrotli = false
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	rt, err := ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	for _, directive := range []string{"gunzip", "brotli_static", "ngx_http_brotli_static_module.so"} {
+		if strings.Contains(string(rt), directive) {
+			t.Errorf("expected no directive %q by default", directive)
+		}
+	}
+
+	for _, server := range dat.Servers {
+		for _, location := range server.Locations {
+			location.Precompressed = precompressed.Config{Gzip: true, Brotli: true}
+		}
+	}
+	rt, err = ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+	for _, directive := range []string{"gzip_static                             on;", "gunzip                                  on;", "brotli_static                           on;", "ngx_http_brotli_static_module.so"} {
+		if !strings.Contains(string(rt), directive) {
+			t.Errorf("expected the directive %q in the configuration", directive)
+		}
+	}
+}
+
+func TestTemplateServerCache(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(path.Join(pwd, "../../../../test/data/config.json"))
+	if err != nil {
+		t.Fatalf("unexpected error reading json file: %v", err)
+	}
+	var dat config.TemplateConfig
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(data, &dat); err != nil {
+		t.Fatalf("unexpected error unmarshalling json: %v", err)
+	}
+	if dat.ListenPorts == nil {
+		dat.ListenPorts = &config.ListenPorts{}
+	}
+	dat.Cfg.DefaultSSLCertificate = &ingress.SSLCert{}
+
+	ngxTpl, err := NewTemplate(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	first, err := ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if len(ngxTpl.serverCache) != len(dat.Servers) {
+		t.Errorf("expected %v cached server blocks but %v returned", len(dat.Servers), len(ngxTpl.serverCache))
+	}
+
+	second, err := ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if string(first) != string(second) {
+		t.Errorf("expected the same configuration using cached server blocks")
+	}
+
+	dat.Servers[0].Aliases = []string{"cached.alias.example.com"}
+	third, err := ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(third), "cached.alias.example.com") {
+		t.Errorf("expected the server block of the modified server to be rendered again")
+	}
+
+	// the locations of another Ingress render other variables
+	dat.Servers[0].Locations[0].Ingress = &ingress.Ingress{
+		Ingress: networking.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "cached-namespace", Name: "cached"},
+		},
+	}
+	fourth, err := ngxTpl.Write(&dat)
+	if err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if !strings.Contains(string(fourth), `"cached-namespace"`) {
+		t.Errorf("expected the server block of the server with another Ingress to be rendered again")
+	}
+
+	// the admission renders do not replace the cached server blocks
+	cached := len(ngxTpl.serverCache)
+	admission := dat
+	admission.Servers = dat.Servers[:1]
+	admission.Admission = true
+	if _, err := ngxTpl.Write(&admission); err != nil {
+		t.Fatalf("invalid NGINX template: %v", err)
+	}
+
+	if len(ngxTpl.serverCache) != cached {
+		t.Errorf("expected %v cached server blocks after an admission render but %v returned", cached, len(ngxTpl.serverCache))
+	}
+}
+
 func BenchmarkTemplateWithData(b *testing.B) {
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -896,6 +1226,39 @@ func TestBuildResolvers(t *testing.T) {
 	}
 }
 
+func TestBuildBackupNextUpstream(t *testing.T) {
+	cases := map[string]struct {
+		nextUpstream string
+		upstreamName string
+		failureCodes []int
+		expected     string
+	}{
+		"no backup": {
+			"error timeout", "", []int{503}, "error timeout",
+		},
+		"no failure codes": {
+			"error timeout", "backup-default-legacy-80", nil, "error timeout",
+		},
+		"failure codes": {
+			"error timeout http_503", "backup-default-legacy-80", []int{502, 503}, "error timeout http_503 http_502",
+		},
+		"off": {
+			"off", "backup-default-legacy-80", []int{503}, "http_503",
+		},
+	}
+
+	for k, tc := range cases {
+		location := &ingress.Location{
+			Proxy:              proxy.Config{NextUpstream: tc.nextUpstream},
+			Backup:             backupservice.Config{FailureCodes: tc.failureCodes},
+			BackupUpstreamName: tc.upstreamName,
+		}
+		if actual := buildBackupNextUpstream(location); actual != tc.expected {
+			t.Errorf("%s: expected '%v' but returned '%v'", k, tc.expected, actual)
+		}
+	}
+}
+
 func TestBuildNextUpstream(t *testing.T) {
 	invalidType := &ingress.Ingress{}
 	expected := ""
@@ -990,6 +1353,123 @@ func TestBuildRateLimit(t *testing.T) {
 	}
 }
 
+func TestBuildLimitConnKey(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{"", "$binary_remote_addr"},
+		{"header:X-API-Key", "$http_x_api_key"},
+		{"cookie:session", "$cookie_session"},
+		{"jwt:sub", "$limit_jwt_id"},
+	}
+
+	for _, tc := range testCases {
+		actual := buildLimitConnKey(ratelimit.Config{ID: "id", ConnectionsKey: tc.key})
+		if actual != tc.expected {
+			t.Errorf("expected '%v' but returned '%v' for key %v", tc.expected, actual, tc.key)
+		}
+	}
+
+	loc := &ingress.Location{}
+	loc.RateLimit.ID = "id"
+	loc.RateLimit.ConnectionsKey = "jwt:sub"
+	loc.RateLimit.Connections.Name = "con"
+	loc.RateLimit.Connections.Limit = 1
+
+	expected := []string{
+		`set_by_lua_block $limit_jwt_id { return require("util.jwt").claim(ngx.var.http_authorization, "sub") }`,
+		"limit_conn con 1;",
+	}
+	if limits := buildRateLimit(loc); !reflect.DeepEqual(expected, limits) {
+		t.Errorf("expected '%v' but returned '%v'", expected, limits)
+	}
+
+	servers := []*ingress.Server{{Locations: []*ingress.Location{loc}}}
+	loc.RateLimit.Connections.SharedSize = 5
+	expected = []string{"limit_conn_zone $limit_conn_id zone=con:5m;"}
+	if zones := buildRateLimitZones(servers); !reflect.DeepEqual(expected, zones) {
+		t.Errorf("expected '%v' but returned '%v'", expected, zones)
+	}
+}
+
+func TestBuildRateLimitExemptions(t *testing.T) {
+	rl := ratelimit.Config{
+		ID: "id",
+		Exemptions: []ratelimit.Exemption{
+			{Source: ratelimit.HeaderKey, Name: "X-Internal", Value: "true"},
+			{Source: ratelimit.JWTClaimKey, Name: "role", Value: "admin"},
+		},
+	}
+
+	for i, expected := range []string{"$http_x_internal", "$limit_exempt_jwt_id_1", ""} {
+		if actual := buildLimitExemptionVariable(rl, i); actual != expected {
+			t.Errorf("expected '%v' but returned '%v' for the exemption %d", expected, actual, i)
+		}
+	}
+
+	if actual := buildLimitExempted(rl); actual != "$limit_exempted_id" {
+		t.Errorf("expected '$limit_exempted_id' but returned '%v'", actual)
+	}
+	if actual := buildLimitExempted(ratelimit.Config{ID: "id"}); actual != "$allowlist_id" {
+		t.Errorf("expected '$allowlist_id' without exemptions but returned '%v'", actual)
+	}
+
+	loc := &ingress.Location{RateLimit: rl}
+	loc.RateLimit.RPS = ratelimit.Zone{Name: "rps", Limit: 1, Burst: 5}
+	expected := []string{
+		`set_by_lua_block $limit_exempt_jwt_id_1 { return require("util.jwt").claim(ngx.var.http_authorization, "role") }`,
+		"limit_req zone=rps burst=5 nodelay;",
+	}
+	if limits := buildRateLimit(loc); !reflect.DeepEqual(expected, limits) {
+		t.Errorf("expected '%v' but returned '%v'", expected, limits)
+	}
+}
+
+func TestBuildRateLimitAuthenticated(t *testing.T) {
+	testCases := []struct {
+		key      string
+		expected string
+	}{
+		{"header:Authorization", "$http_authorization"},
+		{"cookie:session", "$cookie_session"},
+		{"jwt:sub", "$limit_authenticated_jwt_id"},
+	}
+
+	for _, tc := range testCases {
+		actual := buildLimitAuthenticatedKey(ratelimit.Config{ID: "id", AuthenticatedKey: tc.key})
+		if actual != tc.expected {
+			t.Errorf("expected '%v' but returned '%v' for key %v", tc.expected, actual, tc.key)
+		}
+	}
+
+	loc := &ingress.Location{}
+	loc.RateLimit.ID = "id"
+	loc.RateLimit.AuthenticatedKey = "jwt:sub"
+	loc.RateLimit.RPS = ratelimit.Zone{Name: "rps", Limit: 10, Burst: 50, SharedSize: 5}
+	loc.RateLimit.AuthenticatedRPS = ratelimit.Zone{Name: "authenticated_rps", Limit: 100, Burst: 500, SharedSize: 5}
+
+	expected := []string{
+		`set_by_lua_block $limit_authenticated_jwt_id { return require("util.jwt").claim(ngx.var.http_authorization, "sub") }`,
+		"limit_req zone=rps burst=50 nodelay;",
+		"limit_req zone=authenticated_rps burst=500 nodelay;",
+	}
+	if limits := buildRateLimit(loc); !reflect.DeepEqual(expected, limits) {
+		t.Errorf("expected '%v' but returned '%v'", expected, limits)
+	}
+
+	servers := []*ingress.Server{{Locations: []*ingress.Location{loc}}}
+	zones := buildRateLimitZones(servers)
+	sort.Strings(zones)
+	expected = []string{
+		"limit_req_zone $limit_authenticated_id zone=authenticated_rps:5m rate=100r/s;",
+		"limit_req_zone $limit_id zone=rps:5m rate=10r/s;",
+	}
+	if !reflect.DeepEqual(expected, zones) {
+		t.Errorf("expected '%v' but returned '%v'", expected, zones)
+	}
+}
+
 // TODO: Needs more tests
 func TestBuildRateLimitZones(t *testing.T) {
 	invalidType := &ingress.Ingress{}
@@ -1012,6 +1492,42 @@ func TestFilterRateLimits(t *testing.T) {
 	}
 }
 
+func TestFilterSplitHorizons(t *testing.T) {
+	invalidType := &ingress.Ingress{}
+	expected := []splithorizon.Config{}
+	actual := filterSplitHorizons(invalidType)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected '%v' but returned '%v'", expected, actual)
+	}
+
+	foo := splithorizon.Config{ID: "foo", InternalCIDRs: []string{"10.0.0.0/8"}}
+	bar := splithorizon.Config{ID: "bar", InternalCIDRs: []string{"192.168.0.0/16"}}
+	servers := []*ingress.Server{
+		{
+			Hostname: "foo.example.com",
+			Locations: []*ingress.Location{
+				{Path: "/", SplitHorizon: foo},
+				{Path: "/api", SplitHorizon: foo},
+				{Path: "/public"},
+			},
+		},
+		{
+			Hostname: "bar.example.com",
+			Locations: []*ingress.Location{
+				{Path: "/", SplitHorizon: bar},
+			},
+		},
+	}
+
+	expected = []splithorizon.Config{foo, bar}
+	actual = filterSplitHorizons(servers)
+
+	if !reflect.DeepEqual(expected, actual) {
+		t.Errorf("Expected '%v' but returned '%v'", expected, actual)
+	}
+}
+
 func TestBuildAuthSignURL(t *testing.T) {
 	cases := map[string]struct {
 		Input, RedirectParam, Output string
@@ -1572,6 +2088,30 @@ func TestBuildCustomErrorLocationsPerServer(t *testing.T) {
 				},
 			},
 		},
+		{ // Two ingresses, same backend, different error pages
+			&ingress.Server{Locations: []*ingress.Location{
+				{
+					DefaultBackendUpstreamName: "custom-default-backend-test-backend",
+					CustomHTTPErrors:           []int{404},
+					ErrorPage:                  errorpage.Config{PreserveURI: true},
+				},
+				{
+					DefaultBackendUpstreamName: "custom-default-backend-test-backend",
+					CustomHTTPErrors:           []int{404, 503},
+				},
+			}},
+			[]errorLocation{
+				{
+					UpstreamName: "custom-default-backend-test-backend",
+					Codes:        []int{404, 503},
+				},
+				{
+					UpstreamName: "custom-default-backend-test-backend",
+					Codes:        []int{404},
+					ErrorPage:    errorpage.Config{PreserveURI: true},
+				},
+			},
+		},
 	}
 
 	for _, c := range testCases {
@@ -1732,6 +2272,54 @@ func TestShouldLoadModSecurityModule(t *testing.T) {
 	}
 }
 
+func TestShouldLoadBrotliModule(t *testing.T) {
+	if shouldLoadBrotliModule(config.Configuration{}, &ingress.Ingress{}) {
+		t.Errorf("expected the Brotli modules to not be loaded with an invalid argument")
+	}
+
+	if shouldLoadBrotliModule(config.Configuration{}, []*ingress.Server{}) {
+		t.Errorf("expected the Brotli modules to not be loaded by default")
+	}
+
+	if !shouldLoadBrotliModule(config.Configuration{EnableBrotli: true}, []*ingress.Server{}) {
+		t.Errorf("expected the Brotli modules to be loaded when Brotli is enabled")
+	}
+
+	servers := []*ingress.Server{
+		{
+			Locations: []*ingress.Location{
+				{Precompressed: precompressed.Config{Brotli: true}},
+			},
+		},
+	}
+	if !shouldLoadBrotliModule(config.Configuration{}, servers) {
+		t.Errorf("expected the Brotli modules to be loaded for the Brotli precompressed responses")
+	}
+}
+
+func TestHasOIDCAuthLocations(t *testing.T) {
+	if hasOIDCAuthLocations(&ingress.Ingress{}) {
+		t.Errorf("expected no OpenID Connect authentication with an invalid argument")
+	}
+
+	servers := []*ingress.Server{
+		{
+			Locations: []*ingress.Location{{Path: "/"}},
+		},
+	}
+	if hasOIDCAuthLocations(servers) {
+		t.Errorf("expected no OpenID Connect authentication by default")
+	}
+
+	servers[0].Locations = append(servers[0].Locations, &ingress.Location{
+		Path:     "/dashboard",
+		OIDCAuth: authoidc.Config{Key: "default-ing-secret"},
+	})
+	if !hasOIDCAuthLocations(servers) {
+		t.Errorf("expected the OpenID Connect authentication of the location /dashboard")
+	}
+}
+
 func TestOpentelemetryForLocation(t *testing.T) {
 	trueVal := true
 	falseVal := false
@@ -1780,6 +2368,29 @@ opentelemetry_trust_incoming_spans off;`
 	}
 }
 
+func TestOpentelemetryForLocationObservabilityLabels(t *testing.T) {
+	expected := `opentelemetry on;
+opentelemetry_propagate;
+opentelemetry_attribute "app_kubernetes_io_name" "checkout";
+opentelemetry_attribute "team" "payments";
+opentelemetry_trust_incoming_spans on;`
+
+	il := &ingress.Location{
+		ObservabilityLabels: map[string]string{
+			"team":                   "payments",
+			"app_kubernetes_io_name": "checkout",
+		},
+	}
+
+	if actual := buildOpentelemetryForLocation(true, true, il); actual != expected {
+		t.Errorf("expected '%v' but returned '%v'", expected, actual)
+	}
+
+	if actual := buildOpentelemetryForLocation(false, true, il); actual != "" {
+		t.Errorf("expected no configuration with OpenTelemetry disabled but returned '%v'", actual)
+	}
+}
+
 //nolint:dupl // Ignore dupl errors for similar test case
 func TestShouldLoadOpentelemetryModule(t *testing.T) {
 	// ### Invalid argument type tests ###
@@ -1956,3 +2567,479 @@ func TestCleanConf(t *testing.T) {
 		t.Errorf("cleanConf result don't match with expected: %s", diff)
 	}
 }
+
+func TestLocationConfigForLuaTimeoutBudget(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{
+		Path:  "/",
+		Proxy: proxy.Config{ReadTimeout: 60},
+	}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "timeout_budget") {
+		t.Errorf("unexpected timeout budget configuration: %v", actual)
+	}
+
+	location.Proxy.TimeoutBudgetHeader = "grpc-timeout"
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{`set $timeout_budget_header "grpc-timeout";`, `set $timeout_budget_max "60";`} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+}
+
+func TestLocationConfigForLuaBasicAuthInMemory(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{
+		Path: "/",
+		BasicDigestAuth: auth.Config{
+			Type:    "basic",
+			Realm:   "Restricted",
+			File:    "/etc/ingress-controller/auth/default-ing-secret.passwd",
+			Secured: true,
+		},
+	}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "basic_auth") {
+		t.Errorf("unexpected basic authentication configuration: %v", actual)
+	}
+
+	location.BasicDigestAuth = auth.Config{
+		Type:     "basic",
+		Realm:    "Restricted",
+		Secured:  true,
+		InMemory: true,
+		Key:      "default-ing-secret",
+	}
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{`set $basic_auth_key "default-ing-secret";`, `set $basic_auth_realm "Restricted";`} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+
+	all.Cfg.NoAuthLocations = "/"
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "basic_auth") {
+		t.Errorf("unexpected basic authentication configuration in a location without authentication: %v", actual)
+	}
+}
+
+func TestLocationConfigForLuaHSTS(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/"}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "hsts") {
+		t.Errorf("unexpected HSTS configuration: %v", actual)
+	}
+
+	location.HSTS = hsts.Config{
+		MaxAge:               "63072000",
+		IncludeSubdomainsSet: true,
+		ExcludeHosts:         []string{"dev.example.com", "*.dev.example.com"},
+	}
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{
+		`set $hsts_max_age "63072000";`,
+		`set $hsts_include_subdomains "false";`,
+		`set $hsts_exclude_hosts "dev.example.com,*.dev.example.com";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+	if strings.Contains(actual, "hsts_preload") {
+		t.Errorf("unexpected HSTS preload configuration: %v", actual)
+	}
+}
+
+func TestLocationConfigForLuaOIDCAuth(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/"}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "oidc") {
+		t.Errorf("unexpected OpenID Connect configuration: %v", actual)
+	}
+
+	location.OIDCAuth = authoidc.Config{
+		Issuer:          "https://accounts.example.com",
+		Key:             "default-ing-secret",
+		Scopes:          "openid email",
+		RedirectPath:    "/oauth2/callback",
+		CookieName:      "_oidc_session",
+		CookieSameSite:  "Lax",
+		SessionDuration: 28800,
+	}
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{
+		`set $oidc_key "default-ing-secret";`,
+		`set $oidc_issuer "https://accounts.example.com";`,
+		`set $oidc_scopes "openid email";`,
+		`set $oidc_redirect_path "/oauth2/callback";`,
+		`set $oidc_cookie_domain "";`,
+		`set $oidc_session_duration "28800";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+
+	all.Cfg.NoAuthLocations = "/"
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "oidc") {
+		t.Errorf("unexpected OpenID Connect configuration in a location without authentication: %v", actual)
+	}
+}
+
+func TestLocationConfigForLuaSSLRedirectPolicy(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/"}
+
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{
+		fmt.Sprintf(`set $ssl_redirect_code "%d";`, all.Cfg.HTTPRedirectCode),
+		`set $ssl_redirect_strip_query "false";`,
+		`set $ssl_redirect_exempt_paths "";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+
+	location.Rewrite.SSLRedirectPolicy = rewrite.SSLRedirectPolicy{
+		Code:        307,
+		StripQuery:  true,
+		ExemptPaths: []string{"/.well-known/acme-challenge", "/healthz"},
+	}
+
+	actual = locationConfigForLua(location, all)
+	for _, expected := range []string{
+		`set $ssl_redirect_code "307";`,
+		`set $ssl_redirect_strip_query "true";`,
+		`set $ssl_redirect_exempt_paths "/.well-known/acme-challenge,/healthz";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+}
+
+func TestLocationConfigForLuaGRPCWeb(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/", GRPCWeb: true, BackendProtocol: "HTTP"}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "grpc_web") {
+		t.Errorf("unexpected gRPC-Web configuration without a gRPC backend: %v", actual)
+	}
+
+	location.BackendProtocol = "GRPC"
+	if actual, expected := locationConfigForLua(location, all), `set $grpc_web "true";`; !strings.Contains(actual, expected) {
+		t.Errorf("expected %v in %v", expected, actual)
+	}
+}
+
+func TestLocationConfigForLuaBodyInspection(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/"}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "body_inspection") {
+		t.Errorf("unexpected body inspection configuration without limits: %v", actual)
+	}
+
+	location.BodyInspection = bodyinspection.Config{XMLMaxDepth: 32, JSONMaxArraySize: 1000}
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{
+		`set $body_inspection_rejection "";`,
+		`set $body_inspection_xml_max_depth "32";`,
+		`set $body_inspection_json_max_array_size "1000";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+	if strings.Contains(actual, "json_max_depth") {
+		t.Errorf("unexpected limit without a value in %v", actual)
+	}
+}
+
+func TestLocationConfigForLuaConditionalHeader(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/"}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "conditional_header") {
+		t.Errorf("unexpected conditional header configuration without expression: %v", actual)
+	}
+
+	exp, err := expression.Compile(`request.cookies["beta"] == "1"`)
+	if err != nil {
+		t.Fatalf("unexpected error compiling the expression: %v", err)
+	}
+	location.ConditionalHeader = conditionalheader.Config{Name: "X-Beta", Value: "on", Expression: exp}
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{
+		fmt.Sprintf(`set $conditional_header_expression "%s";`, exp.Encode()),
+		`set $conditional_header_value "on";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+}
+
+func TestLocationConfigForLuaBackup(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/"}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "backup") {
+		t.Errorf("unexpected backup configuration without backup upstream: %v", actual)
+	}
+
+	location.BackupUpstreamName = "backup-default-legacy-80"
+	location.Backup = backupservice.Config{FailureCodes: []int{502, 503}}
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{
+		`set $proxy_backup_upstream_name "backup-default-legacy-80";`,
+		`set $backup_failure_codes "502 503";`,
+		`set $backup_failover "";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+}
+
+func TestLocationConfigForLuaForwardedHeaders(t *testing.T) {
+	all := config.TemplateConfig{Cfg: config.NewDefault()}
+	location := &ingress.Location{Path: "/"}
+
+	if actual := locationConfigForLua(location, all); strings.Contains(actual, "forwarded_") {
+		t.Errorf("unexpected forwarded headers configuration without mode: %v", actual)
+	}
+
+	location.ForwardedHeaders = ingress.ForwardedHeaders{Mode: "append", SchemeOverride: "https"}
+	actual := locationConfigForLua(location, all)
+	for _, expected := range []string{
+		`set $forwarded_headers_mode "append";`,
+		`set $forwarded_scheme_override "https";`,
+		`set $forwarded_host "";`,
+		`set $forwarded_proto "";`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %v in %v", expected, actual)
+		}
+	}
+
+	location.ForwardedHeaders = ingress.ForwardedHeaders{}
+	all.Cfg.ForwardedHeadersMode = "append"
+	actual = locationConfigForLua(location, all)
+	if strings.Contains(actual, "forwarded_headers_mode") || !strings.Contains(actual, `set $forwarded_port "";`) {
+		t.Errorf("expected the variables of the global append mode in %v", actual)
+	}
+}
+
+func TestBuildForwardedHeadersMode(t *testing.T) {
+	cfg := config.NewDefault()
+	location := &ingress.Location{}
+
+	if actual := buildForwardedHeadersMode(location, cfg); actual != "" {
+		t.Errorf("expected no mode but %q returned", actual)
+	}
+
+	cfg.ForwardedHeadersMode = "trust"
+	if actual := buildForwardedHeadersMode(location, cfg); actual != "trust" {
+		t.Errorf("expected the mode of the configuration but %q returned", actual)
+	}
+
+	location.ForwardedHeaders.Mode = "overwrite"
+	if actual := buildForwardedHeadersMode(location, cfg); actual != "overwrite" {
+		t.Errorf("expected the mode of the location but %q returned", actual)
+	}
+}
+
+func TestIsGRPCWebLocation(t *testing.T) {
+	testCases := []struct {
+		location *ingress.Location
+		expected bool
+	}{
+		{nil, false},
+		{&ingress.Location{BackendProtocol: "GRPC"}, false},
+		{&ingress.Location{GRPCWeb: true, BackendProtocol: "HTTP"}, false},
+		{&ingress.Location{GRPCWeb: true, BackendProtocol: "GRPC"}, true},
+		{&ingress.Location{GRPCWeb: true, BackendProtocol: "GRPCS"}, true},
+	}
+
+	for _, testCase := range testCases {
+		if actual := isGRPCWebLocation(testCase.location); actual != testCase.expected {
+			t.Errorf("expected %v but returned %v for %+v", testCase.expected, actual, testCase.location)
+		}
+	}
+}
+
+func TestBuildCSPHeaders(t *testing.T) {
+	prefix := networking.PathTypePrefix
+	exact := networking.PathTypeExact
+	location := &ingress.Location{Path: "/app", PathType: &prefix}
+
+	if headers := buildCSPHeaders(location); len(headers) != 0 {
+		t.Errorf("expected no headers without policy but returned %v", headers)
+	}
+
+	location.ContentSecurityPolicy = csp.Config{Policy: "default-src 'self';"}
+	expected := []string{`more_set_headers "Content-Security-Policy: default-src 'self'";`}
+	if headers := buildCSPHeaders(location); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %v but returned %v", expected, headers)
+	}
+
+	location.ContentSecurityPolicy = csp.Config{Policy: "default-src 'self'", ReportOnly: true, Report: true}
+	path := buildCSPReportPath(location)
+	if !strings.HasPrefix(path, cspReportPathPrefix) {
+		t.Errorf("expected a report path starting with %v but returned %v", cspReportPathPrefix, path)
+	}
+	expected = []string{
+		`more_set_headers 'Reporting-Endpoints: ingress-nginx-csp="` + path + `"';`,
+		`more_set_headers "Content-Security-Policy-Report-Only: default-src 'self'; report-uri ` + path + `; report-to ingress-nginx-csp";`,
+	}
+	if headers := buildCSPHeaders(location); !reflect.DeepEqual(headers, expected) {
+		t.Errorf("expected %v but returned %v", expected, headers)
+	}
+
+	if other := buildCSPReportPath(&ingress.Location{Path: "/app", PathType: &exact}); other == path {
+		t.Errorf("expected different report paths for the locations with different path types")
+	}
+}
+
+func TestBuildHTTPVersionsRegex(t *testing.T) {
+	testCases := []struct {
+		versions []string
+		matches  []string
+		rejects  []string
+	}{
+		{[]string{"1.1"}, []string{"HTTP/1.1"}, []string{"HTTP/1.0", "HTTP/2.0", "HTTP/101"}},
+		{[]string{"1.1", "2.0"}, []string{"HTTP/1.1", "HTTP/2.0"}, []string{"HTTP/1.0", "HTTP/3.0", "HTTP/2.0 "}},
+	}
+
+	for _, testCase := range testCases {
+		regex := regexp.MustCompile(buildHTTPVersionsRegex(&ingress.Location{HTTPVersions: testCase.versions}))
+		for _, protocol := range testCase.matches {
+			if !regex.MatchString(protocol) {
+				t.Errorf("expected %v to match %q for the versions %v", regex, protocol, testCase.versions)
+			}
+		}
+		for _, protocol := range testCase.rejects {
+			if regex.MatchString(protocol) {
+				t.Errorf("expected %v not to match %q for the versions %v", regex, protocol, testCase.versions)
+			}
+		}
+	}
+}
+
+func TestBuildClientAbortLogPercentage(t *testing.T) {
+	testCases := map[float32]string{
+		0.5:     "50.00%",
+		0.125:   "12.50%",
+		0.01:    "1.00%",
+		0.00001: "0.01%",
+	}
+	for rate, expected := range testCases {
+		if actual := buildClientAbortLogPercentage(rate); actual != expected {
+			t.Errorf("expected %v for the rate %v but %v was returned", expected, rate, actual)
+		}
+	}
+}
+
+func TestBuildProxyPassRewriteRules(t *testing.T) {
+	backends := []*ingress.Backend{{Name: "upstream-name"}}
+	location := &ingress.Location{
+		Path:    "/",
+		Backend: "upstream-name",
+		Rewrite: rewrite.Config{
+			Rules: []rewrite.Rule{
+				{Regex: `^/users/(?<id>\d+)$`, Replacement: "/api/users/$id"},
+				{Regex: "^/Docs/(.*)", Replacement: "/docs/$1", Transform: rewrite.LowercaseTransform},
+			},
+		},
+	}
+
+	expected := `
+rewrite "^/users/(?<id>\\d+)$" "/api/users/$id";
+set $rewrite_original_uri $uri;
+rewrite "^/Docs/(.*)" "/docs/$1";
+set_by_lua_block $rewrite_transformed_uri {
+    if ngx.var.uri == ngx.var.rewrite_original_uri then
+        return ngx.var.uri
+    end
+    return string.lower(ngx.var.uri)
+}
+rewrite ^ $rewrite_transformed_uri;
+break;
+proxy_pass http://upstream_balancer;`
+
+	if actual := buildProxyPass("", backends, location); actual != expected {
+		t.Errorf("expected \n'%v'\nbut returned \n'%v'", expected, actual)
+	}
+}
+
+func TestBuildInternalListeners(t *testing.T) {
+	all := config.TemplateConfig{
+		ListenPorts: &config.ListenPorts{HTTP: 80, HTTPS: 443, InternalHTTP: 8080, InternalHTTPS: 8443},
+		BacklogSize: 511,
+	}
+
+	testCases := []struct {
+		hostname    string
+		internal    bool
+		expHTTP     string
+		expHTTPS    string
+		passthrough bool
+	}{
+		{"example.com", false, "listen 80  ;", "listen 443  ssl;", false},
+		{"internal.example.com", true, "listen 8080  ;", "listen 8443  ssl;", false},
+		{"_", false, "listen 80 default_server backlog=511 ;\nlisten 8080 default_server backlog=511 ;",
+			"listen 443 default_server backlog=511 ssl;\nlisten 8443 default_server backlog=511 ssl;", false},
+		{"internal.example.com", true, "listen 8080  ;", "listen 8443  ssl;", true},
+		{"example.com", false, "listen 80  ;", "listen 442 proxy_protocol  ssl;", true},
+	}
+
+	for _, tc := range testCases {
+		all.IsSSLPassthroughEnabled = tc.passthrough
+		all.ListenPorts.SSLProxy = 442
+
+		if actual := buildHTTPListener(all, tc.hostname, tc.internal); actual != tc.expHTTP {
+			t.Errorf("expected '%v' but returned '%v' for %v", tc.expHTTP, actual, tc.hostname)
+		}
+		if actual := buildHTTPSListener(all, tc.hostname, tc.internal); actual != tc.expHTTPS {
+			t.Errorf("expected '%v' but returned '%v' for %v", tc.expHTTPS, actual, tc.hostname)
+		}
+	}
+}
+
+func TestBuildHTTPSListenerDataplane(t *testing.T) {
+	openresty, err := nginx.GetDataplane(nginx.OpenRestyDataplane)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		dataplane *nginx.Dataplane
+		useHTTP2  bool
+		expected  string
+	}{
+		{nil, true, "listen 443  ssl;"},
+		{openresty, true, "listen 443  ssl http2;"},
+		{openresty, false, "listen 443  ssl;"},
+	}
+
+	for _, tc := range testCases {
+		all := config.TemplateConfig{
+			Cfg:         config.Configuration{UseHTTP2: tc.useHTTP2},
+			ListenPorts: &config.ListenPorts{HTTPS: 443},
+			Dataplane:   tc.dataplane,
+		}
+
+		if actual := buildHTTPSListener(all, "example.com", false); actual != tc.expected {
+			t.Errorf("expected '%v' but returned '%v'", tc.expected, actual)
+		}
+
+		if supported := supportsDirective(all, "http2"); supported != (tc.dataplane == nil) {
+			t.Errorf("unexpected support of the http2 directive: %v", supported)
+		}
+	}
+}
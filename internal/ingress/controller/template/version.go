@@ -0,0 +1,212 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	text_template "text/template"
+	"text/template/parse"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/internal/ingress/controller/config"
+	utilingress "k8s.io/ingress-nginx/pkg/util/ingress"
+)
+
+// TemplateAPIVersion is the version of the data and the functions provided by
+// the controller to the NGINX template. It must be increased when a change
+// requires custom templates to be updated, like renaming or removing a field.
+const TemplateAPIVersion = 1
+
+// apiVersionTemplate is the name of the template declaring the version of the
+// template API targeted by a template, like
+//
+//	{{ define "TEMPLATE_API_VERSION" }}1{{ end }}
+const apiVersionTemplate = "TEMPLATE_API_VERSION"
+
+// checkAPIVersion checks the template targets the version of the template API
+// provided by the controller. The error contains the fields referenced by the
+// template and not provided by the controller.
+func checkAPIVersion(tmpl *text_template.Template) error {
+	if tmpl.Lookup(apiVersionTemplate) == nil {
+		return fmt.Errorf("the template does not declare the version of the template API it targets. "+
+			"Update the template to the version %v and add {{ define %q }}%v{{ end }}%v",
+			TemplateAPIVersion, apiVersionTemplate, TemplateAPIVersion, describeUnknownFields(tmpl))
+	}
+
+	buf := &bytes.Buffer{}
+	if err := tmpl.ExecuteTemplate(buf, apiVersionTemplate, nil); err != nil {
+		return fmt.Errorf("unexpected error reading the version of the template API: %w", err)
+	}
+
+	version, err := strconv.Atoi(strings.TrimSpace(buf.String()))
+	if err != nil {
+		return fmt.Errorf("invalid version of the template API %q: %w", buf.String(), err)
+	}
+
+	if version != TemplateAPIVersion {
+		return fmt.Errorf("the template targets the version %v of the template API but the controller provides the version %v%v",
+			version, TemplateAPIVersion, describeUnknownFields(tmpl))
+	}
+
+	return nil
+}
+
+// describeUnknownFields returns the fields referenced by the template and
+// not provided by the controller, to be added to an error message
+func describeUnknownFields(tmpl *text_template.Template) string {
+	unknown := unknownFields(tmpl)
+	if len(unknown) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf(". Fields referenced by the template and not provided by the controller: %v", strings.Join(unknown, ", "))
+}
+
+// unknownFields returns the sorted names of the fields and methods referenced
+// by the template that are not part of the data provided by the controller
+func unknownFields(tmpl *text_template.Template) []string {
+	known := templateFields()
+	unknown := sets.New[string]()
+
+	for _, t := range tmpl.Templates() {
+		if t.Tree == nil || t.Tree.Root == nil {
+			continue
+		}
+
+		walkFields(t.Tree.Root, func(name string) {
+			if !known.Has(name) {
+				unknown.Insert(name)
+			}
+		})
+	}
+
+	return sets.List(unknown)
+}
+
+// templateFields returns the names of the fields and methods of the data
+// provided to the template and of the values returned by the template functions
+func templateFields() sets.Set[string] {
+	fields := sets.New[string]()
+	visited := map[reflect.Type]bool{}
+
+	collectFields(reflect.TypeOf(config.TemplateConfig{}), fields, visited)
+
+	// values provided as interface{}, like the data of the server blocks
+	for _, data := range []interface{}{
+		struct{ First, Second interface{} }{},
+		buildCustomErrorDeps("", nil, false, false),
+		utilingress.BuildRedirects(nil),
+	} {
+		collectFields(reflect.TypeOf(data), fields, visited)
+	}
+
+	for _, fn := range funcMap {
+		ft := reflect.TypeOf(fn)
+		for i := 0; i < ft.NumOut(); i++ {
+			collectFields(ft.Out(i), fields, visited)
+		}
+	}
+
+	return fields
+}
+
+// collectFields adds the names of the exported fields and methods of a type
+// and of the types it contains
+func collectFields(t reflect.Type, fields sets.Set[string], visited map[reflect.Type]bool) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+
+	for i := 0; i < t.NumMethod(); i++ {
+		fields.Insert(t.Method(i).Name)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map, reflect.Chan:
+		collectFields(t.Elem(), fields, visited)
+	case reflect.Struct:
+		collectFields(reflect.PointerTo(t), fields, visited)
+
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+
+			fields.Insert(field.Name)
+			collectFields(field.Type, fields, visited)
+		}
+	}
+}
+
+// walkFields calls fn with the name of each field referenced in a node of a template
+func walkFields(node parse.Node, fn func(string)) {
+	if node == nil || reflect.ValueOf(node).IsNil() {
+		return
+	}
+
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			walkFields(child, fn)
+		}
+	case *parse.ActionNode:
+		walkFields(n.Pipe, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		walkFields(n.Pipe, fn)
+	case *parse.PipeNode:
+		for _, cmd := range n.Cmds {
+			walkFields(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walkFields(arg, fn)
+		}
+	case *parse.FieldNode:
+		for _, ident := range n.Ident {
+			fn(ident)
+		}
+	case *parse.ChainNode:
+		walkFields(n.Node, fn)
+		for _, field := range n.Field {
+			fn(field)
+		}
+	case *parse.VariableNode:
+		// the first identifier is the name of the variable
+		for _, ident := range n.Ident[1:] {
+			fn(ident)
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(string)) {
+	walkFields(n.Pipe, fn)
+	walkFields(n.List, fn)
+	walkFields(n.ElseList, fn)
+}
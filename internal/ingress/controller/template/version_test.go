@@ -0,0 +1,102 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	text_template "text/template"
+
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+func TestCheckAPIVersion(t *testing.T) {
+	testCases := []struct {
+		name      string
+		template  string
+		expectErr string
+	}{
+		{
+			name:     "same version",
+			template: `{{ define "TEMPLATE_API_VERSION" }}1{{ end }}pid {{ .PID }};`,
+		},
+		{
+			name:      "without version",
+			template:  `pid {{ .PID }};`,
+			expectErr: "does not declare the version",
+		},
+		{
+			name:      "other version",
+			template:  `{{ define "TEMPLATE_API_VERSION" }}0{{ end }}pid {{ .PIDFilePath }};`,
+			expectErr: "not provided by the controller: PIDFilePath",
+		},
+		{
+			name:      "invalid version",
+			template:  `{{ define "TEMPLATE_API_VERSION" }}v1{{ end }}`,
+			expectErr: "invalid version",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := text_template.New("nginx.tmpl").Funcs(funcMap).Parse(tc.template)
+			if err != nil {
+				t.Fatalf("unexpected error parsing the template: %v", err)
+			}
+
+			err = checkAPIVersion(tmpl)
+			if tc.expectErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tc.expectErr) {
+				t.Errorf("expected an error containing %q but returned %v", tc.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	data, err := os.ReadFile(nginx.TemplatePath)
+	if err != nil {
+		t.Fatalf("unexpected error reading the template: %v", err)
+	}
+
+	tmpl, err := text_template.New("nginx.tmpl").Funcs(funcMap).Parse(string(data))
+	if err != nil {
+		t.Fatalf("unexpected error parsing the template: %v", err)
+	}
+
+	if unknown := unknownFields(tmpl); len(unknown) > 0 {
+		t.Errorf("unexpected fields in the default template: %v", unknown)
+	}
+
+	tmpl, err = text_template.New("nginx.tmpl").Funcs(funcMap).Parse(`{{ $all := . }}{{ $all.Cfg.UnknownSetting }}{{ range .Servers }}{{ .Hostname }}{{ .MissingStruct.MissingField }}{{ end }}`)
+	if err != nil {
+		t.Fatalf("unexpected error parsing the template: %v", err)
+	}
+
+	expected := []string{"MissingField", "MissingStruct", "UnknownSetting"}
+	if unknown := unknownFields(tmpl); !reflect.DeepEqual(expected, unknown) {
+		t.Errorf("expected %v but returned %v", expected, unknown)
+	}
+}
@@ -17,13 +17,16 @@ limitations under the License.
 package controller
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path"
+	"regexp"
 	"strconv"
 	"strings"
 	"syscall"
+	"time"
 
 	api "k8s.io/api/core/v1"
 	networking "k8s.io/api/networking/v1"
@@ -73,6 +76,49 @@ func upstreamServiceNameAndPort(service *networking.IngressServiceBackend) (stri
 	return "", intstr.IntOrString{}
 }
 
+var (
+	validUDPResponses = regexp.MustCompile(`^[0-9]+$`)
+	validUDPTimeout   = regexp.MustCompile(`^[0-9]+(ms|s|m|h|d)?$`)
+)
+
+// parseUDPConfig parses the options of an UDP service definition
+// (responses=<number>, timeout=<time> and affinity=client-ip)
+func parseUDPConfig(svcRef string, options []string) ingress.UDPConfig {
+	cfg := ingress.UDPConfig{}
+	for _, option := range options {
+		key, value, found := strings.Cut(option, "=")
+		if !found {
+			klog.Warningf("Ignoring invalid option %q in UDP service definition %q", option, svcRef)
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "responses":
+			if !validUDPResponses.MatchString(value) {
+				klog.Warningf("Ignoring invalid number of responses %q in UDP service definition %q", value, svcRef)
+				continue
+			}
+			cfg.ProxyResponses = value
+		case "timeout":
+			if !validUDPTimeout.MatchString(value) {
+				klog.Warningf("Ignoring invalid timeout %q in UDP service definition %q", value, svcRef)
+				continue
+			}
+			cfg.ProxyTimeout = value
+		case "affinity":
+			if value != "client-ip" {
+				klog.Warningf("Ignoring unsupported affinity %q in UDP service definition %q", value, svcRef)
+				continue
+			}
+			cfg.Affinity = value
+		default:
+			klog.Warningf("Ignoring unknown option %q in UDP service definition %q", key, svcRef)
+		}
+	}
+
+	return cfg
+}
+
 // sysctlSomaxconn returns the maximum number of connections that can be queued
 // for acceptance (value of net.core.somaxconn)
 // http://nginx.org/en/docs/http/ngx_http_core_module.html#listen
@@ -101,18 +147,34 @@ const (
 	defBinary  = "/usr/bin/nginx"
 	cfgPath    = "/etc/nginx/nginx.conf"
 	luaCfgPath = "/etc/nginx/lua/cfg.json"
+
+	// sharedBlocksPath is the directory of the blocks of directives shared
+	// by the locations when the configuration is optimized
+	sharedBlocksPath = "/etc/nginx/shared"
+
+	// serverBlocksPath is the directory of the servers moved to their own
+	// file when the configuration exceeds the size budget
+	serverBlocksPath = "/etc/nginx/servers"
+
+	// splitServersPath is the directory of the servers moved to their own
+	// file when the configuration is split
+	splitServersPath = "/etc/nginx/conf.d"
 )
 
 // NginxExecTester defines the interface to execute
 // command like reload or test configuration
 type NginxExecTester interface {
 	ExecCommand(args ...string) *exec.Cmd
+	ExecCommandContext(ctx context.Context, args ...string) *exec.Cmd
 	Test(cfg string) ([]byte, error)
 }
 
 // NginxCommand stores context around a given nginx executable path
 type NginxCommand struct {
 	Binary string
+	// Timeout is the maximum duration of the test of a configuration,
+	// 0 means no timeout
+	Timeout time.Duration
 }
 
 // NewNginxCommand returns a new NginxCommand from which path
@@ -140,10 +202,33 @@ func (nc NginxCommand) ExecCommand(args ...string) *exec.Cmd {
 	return exec.Command(nc.Binary, cmdArgs...)
 }
 
+// ExecCommandContext instantiates an exec.Cmd object to call nginx program,
+// killed when the context is done
+func (nc NginxCommand) ExecCommandContext(ctx context.Context, args ...string) *exec.Cmd {
+	cmdArgs := []string{}
+
+	cmdArgs = append(cmdArgs, "-c", cfgPath)
+	cmdArgs = append(cmdArgs, args...)
+	//nolint:gosec // Ignore G204 error
+	return exec.CommandContext(ctx, nc.Binary, cmdArgs...)
+}
+
 // Test checks if config file is a syntax valid nginx configuration
 func (nc NginxCommand) Test(cfg string) ([]byte, error) {
+	ctx := context.Background()
+	if nc.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, nc.Timeout)
+		defer cancel()
+	}
+
 	//nolint:gosec // Ignore G204 error
-	return exec.Command(nc.Binary, "-c", cfg, "-t").CombinedOutput()
+	out, err := exec.CommandContext(ctx, nc.Binary, "-c", cfg, "-t").CombinedOutput()
+	if ctx.Err() == context.DeadlineExceeded {
+		return out, fmt.Errorf("test of the configuration timed out after %v", nc.Timeout)
+	}
+
+	return out, err
 }
 
 // getSysctl returns the value for the specified sysctl setting
@@ -18,6 +18,8 @@ package controller
 
 import (
 	"testing"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
 )
 
 func TestRlimitMaxNumFiles(t *testing.T) {
@@ -33,3 +35,32 @@ func TestSysctlSomaxconn(t *testing.T) {
 		t.Errorf("returned %v but expected >= 511", i)
 	}
 }
+
+func TestParseUDPConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		options  []string
+		expected ingress.UDPConfig
+	}{
+		{"no options", []string{}, ingress.UDPConfig{}},
+		{
+			"all options",
+			[]string{"responses=1", "timeout=10s", "affinity=client-ip"},
+			ingress.UDPConfig{ProxyResponses: "1", ProxyTimeout: "10s", Affinity: "client-ip"},
+		},
+		{
+			"invalid values are ignored",
+			[]string{"responses=one", "timeout=10 s", "affinity=cookie", "PROXY", "unknown=1"},
+			ingress.UDPConfig{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := parseUDPConfig("default/svc:53", tc.options)
+			if cfg != tc.expected {
+				t.Errorf("expected %+v but returned %+v", tc.expected, cfg)
+			}
+		})
+	}
+}
@@ -83,6 +83,11 @@ type Backend struct {
 	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_next_upstream
 	ProxyNextUpstream string `json:"proxy-next-upstream"`
 
+	// RetryNonIdempotent since 1.9.13 NGINX will not retry non-idempotent requests (POST, LOCK, PATCH)
+	// in case of an error. The previous behavior can be restored using the value true.
+	// The locations defining proxy-next-upstream only retry them when it contains non_idempotent
+	RetryNonIdempotent bool `json:"retry-non-idempotent"`
+
 	// Limits the time during which a request can be passed to the next server.
 	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_next_upstream_timeout
 	ProxyNextUpstreamTimeout int `json:"proxy-next-upstream-timeout"`
@@ -173,12 +178,36 @@ type Backend struct {
 	// http://nginx.org/en/docs/http/ngx_http_proxy_module.html#proxy_max_temp_file_size
 	ProxyMaxTempFileSize string `json:"proxy-max-temp-file-size"`
 
+	// Name of the request header containing the time the client is willing to wait
+	// for the response, like "grpc-timeout". When the header is present the read
+	// timeout to the backend is reduced to the remaining budget of the request.
+	// By default this is disabled
+	ProxyTimeoutBudgetHeader string `json:"proxy-timeout-budget-header"`
+
 	// By default, the NGINX ingress controller uses a list of all endpoints (Pod IP/port) in the NGINX upstream configuration.
 	// It disables that behavior and instead uses a single upstream in NGINX, the service's Cluster IP and port.
 	ServiceUpstream bool `json:"service-upstream"`
 
 	// AllowedResponseHeaders allows to define allow response headers for custom header annotation
 	AllowedResponseHeaders []string `json:"global-allowed-response-headers"`
+
+	// Name servers used to resolve the name of the Services of type ExternalName.
+	// By default the name servers of the NGINX resolver are used.
+	ExternalNameResolver []string `json:"external-name-resolver"`
+
+	// Overrides the TTL in seconds of the addresses of the Services of type ExternalName.
+	// By default the TTL of the DNS answers is used.
+	ExternalNameDNSTTL int `json:"external-name-dns-ttl"`
+
+	// Enables or disables the resolution of the name of the Services of type ExternalName
+	// when the TTL of their addresses expires, without reloading NGINX.
+	// Default: true
+	ExternalNameDNSRefresh bool `json:"external-name-dns-refresh"`
+
+	// Minimum max-age in seconds of the HSTS header configured by the annotations
+	// of the Ingresses, which cannot exclude hosts from HSTS when it is set.
+	// By default there is no minimum.
+	HSTSMinMaxAge int `json:"hsts-min-max-age"`
 }
 
 type SecurityConfiguration struct {
@@ -191,4 +220,8 @@ type SecurityConfiguration struct {
 	// AnnotationsRiskLevel represents the risk accepted on an annotation. If the risk is, for instance `Medium`, annotations
 	// with risk High and Critical will not be accepted
 	AnnotationsRiskLevel string `json:"annotations-risk-level"`
+
+	// AuthCredentialsInMemory keeps the credentials of the basic authentication
+	// in memory instead of files
+	AuthCredentialsInMemory bool `json:"auth-credentials-in-memory"`
 }
@@ -0,0 +1,207 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package deprecation contains the registry of the deprecated annotations
+// and ConfigMap keys still accepted by the controller, with the guidance
+// to replace them.
+package deprecation
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
+)
+
+// Kinds of the deprecated settings
+const (
+	// Annotation is an annotation of the Ingresses, without the prefix
+	Annotation = "annotation"
+	// ConfigMapKey is a key of the ConfigMap of the controller
+	ConfigMapKey = "configmap-key"
+)
+
+// Deprecation describes a deprecated setting
+type Deprecation struct {
+	// Kind is the kind of the setting, Annotation or ConfigMapKey
+	Kind string
+	// Name is the name of the setting
+	Name string
+	// Replacement is the setting of the same kind replacing the deprecated one, if any
+	Replacement string
+	// Guidance explains the effect of the setting and how to migrate
+	Guidance string
+}
+
+// Message returns the warning shown to the users of the setting
+func (d Deprecation) Message() string {
+	msg := fmt.Sprintf("the %v %v is deprecated", strings.ReplaceAll(d.Kind, "-", " "), d.Name)
+	if d.Replacement != "" {
+		msg += fmt.Sprintf(", use %v instead", d.Replacement)
+	}
+	if d.Guidance != "" {
+		msg += ". " + d.Guidance
+	}
+
+	return msg
+}
+
+const influxDBGuidance = "The InfluxDB integration was removed and the annotation is ignored, use the Prometheus metrics or OpenTelemetry instead"
+
+// registry contains the deprecated settings indexed by kind and name
+var registry = index([]Deprecation{
+	{Kind: Annotation, Name: "enable-influxdb", Guidance: influxDBGuidance},
+	{Kind: Annotation, Name: "influxdb-measurement", Guidance: influxDBGuidance},
+	{Kind: Annotation, Name: "influxdb-port", Guidance: influxDBGuidance},
+	{Kind: Annotation, Name: "influxdb-host", Guidance: influxDBGuidance},
+	{Kind: Annotation, Name: "influxdb-server-name", Guidance: influxDBGuidance},
+	{
+		Kind:        Annotation,
+		Name:        "secure-verify-ca-secret",
+		Replacement: "proxy-ssl-secret",
+		Guidance:    "The annotation is ignored",
+	},
+	{
+		Kind:        ConfigMapKey,
+		Name:        "http2-max-field-size",
+		Replacement: "large-client-header-buffers",
+	},
+	{
+		Kind:        ConfigMapKey,
+		Name:        "http2-max-header-size",
+		Replacement: "large-client-header-buffers",
+	},
+	{
+		Kind:        ConfigMapKey,
+		Name:        "http2-max-requests",
+		Replacement: "keep-alive-requests",
+	},
+})
+
+func index(deprecations []Deprecation) map[string]Deprecation {
+	indexed := make(map[string]Deprecation, len(deprecations))
+	for _, d := range deprecations {
+		indexed[d.Kind+"/"+d.Name] = d
+	}
+
+	return indexed
+}
+
+// Lookup returns the deprecation of a setting
+func Lookup(kind, name string) (Deprecation, bool) {
+	d, ok := registry[kind+"/"+name]
+	return d, ok
+}
+
+// IsDeprecated checks if a setting is deprecated
+func IsDeprecated(kind, name string) bool {
+	_, ok := Lookup(kind, name)
+	return ok
+}
+
+// Annotations returns the deprecations of the annotations with the prefix,
+// sorted by name
+func Annotations(annotations map[string]string) []Deprecation {
+	names := make([]string, 0, len(annotations))
+	for key := range annotations {
+		if strings.HasPrefix(key, parser.AnnotationsPrefix+"/") {
+			names = append(names, parser.TrimAnnotationPrefix(key))
+		}
+	}
+
+	return find(Annotation, names)
+}
+
+// ConfigMapKeys returns the deprecations of the keys of the ConfigMap data,
+// sorted by name
+func ConfigMapKeys(data map[string]string) []Deprecation {
+	names := make([]string, 0, len(data))
+	for key := range data {
+		names = append(names, key)
+	}
+
+	return find(ConfigMapKey, names)
+}
+
+func find(kind string, names []string) []Deprecation {
+	sort.Strings(names)
+
+	deprecations := []Deprecation{}
+	for _, name := range names {
+		if d, ok := Lookup(kind, name); ok {
+			deprecations = append(deprecations, d)
+		}
+	}
+
+	return deprecations
+}
+
+// Limiter limits the warnings of a deprecated setting to one per object in
+// each interval, to not flood the events of the objects synchronized often
+type Limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	// warned contains the time of the last warning of each key
+	warned    map[string]time.Time
+	lastPrune time.Time
+
+	now func() time.Time
+}
+
+// NewLimiter returns a limiter allowing a warning per key in each interval
+func NewLimiter(interval time.Duration) *Limiter {
+	return &Limiter{
+		interval: interval,
+		warned:   map[string]time.Time{},
+		now:      time.Now,
+	}
+}
+
+// Allow checks if the warning of a deprecated setting of an object, identified
+// by objectKey, can be emitted and records it
+func (l *Limiter) Allow(objectKey string, d Deprecation) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	key := objectKey + "/" + d.Kind + "/" + d.Name
+	if last, ok := l.warned[key]; ok && now.Sub(last) < l.interval {
+		return false
+	}
+
+	l.warned[key] = now
+	return true
+}
+
+// prune removes the warnings older than the interval, like the ones of
+// deleted objects
+func (l *Limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < l.interval {
+		return
+	}
+
+	for key, last := range l.warned {
+		if now.Sub(last) >= l.interval {
+			delete(l.warned, key)
+		}
+	}
+	l.lastPrune = now
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package deprecation
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func names(deprecations []Deprecation) []string {
+	n := []string{}
+	for _, d := range deprecations {
+		n = append(n, d.Name)
+	}
+	return n
+}
+
+func TestAnnotations(t *testing.T) {
+	deprecations := Annotations(map[string]string{
+		"nginx.ingress.kubernetes.io/secure-verify-ca-secret": "ca",
+		"nginx.ingress.kubernetes.io/enable-influxdb":         "true",
+		"nginx.ingress.kubernetes.io/rewrite-target":          "/",
+		"example.com/enable-influxdb":                         "true",
+	})
+
+	expected := []string{"enable-influxdb", "secure-verify-ca-secret"}
+	if n := names(deprecations); !reflect.DeepEqual(n, expected) {
+		t.Errorf("expected %v but returned %v", expected, n)
+	}
+}
+
+func TestConfigMapKeys(t *testing.T) {
+	deprecations := ConfigMapKeys(map[string]string{
+		"http2-max-requests":   "1000",
+		"http2-max-field-size": "4k",
+		"keep-alive-requests":  "1000",
+	})
+
+	expected := []string{"http2-max-field-size", "http2-max-requests"}
+	if n := names(deprecations); !reflect.DeepEqual(n, expected) {
+		t.Errorf("expected %v but returned %v", expected, n)
+	}
+
+	if IsDeprecated(Annotation, "http2-max-requests") {
+		t.Errorf("expected the annotation http2-max-requests to not be deprecated")
+	}
+}
+
+func TestMessage(t *testing.T) {
+	tests := []struct {
+		kind     string
+		name     string
+		expected string
+	}{
+		{
+			ConfigMapKey, "http2-max-requests",
+			"the configmap key http2-max-requests is deprecated, use keep-alive-requests instead",
+		},
+		{
+			Annotation, "secure-verify-ca-secret",
+			"the annotation secure-verify-ca-secret is deprecated, use proxy-ssl-secret instead. The annotation is ignored",
+		},
+	}
+
+	for _, tt := range tests {
+		d, ok := Lookup(tt.kind, tt.name)
+		if !ok {
+			t.Fatalf("expected the %v %v to be deprecated", tt.kind, tt.name)
+		}
+		if msg := d.Message(); msg != tt.expected {
+			t.Errorf("expected %q but returned %q", tt.expected, msg)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := NewLimiter(24 * time.Hour)
+	l.now = func() time.Time { return now }
+
+	influxdb, _ := Lookup(Annotation, "enable-influxdb")
+	verify, _ := Lookup(Annotation, "secure-verify-ca-secret")
+
+	if !l.Allow("default/foo", influxdb) {
+		t.Errorf("expected the first warning to be allowed")
+	}
+	if l.Allow("default/foo", influxdb) {
+		t.Errorf("expected the second warning of the same object to be limited")
+	}
+	if !l.Allow("default/foo", verify) {
+		t.Errorf("expected the warning of another setting to be allowed")
+	}
+	if !l.Allow("default/bar", influxdb) {
+		t.Errorf("expected the warning of another object to be allowed")
+	}
+
+	now = now.Add(23 * time.Hour)
+	if l.Allow("default/foo", influxdb) {
+		t.Errorf("expected the warning to be limited before the interval")
+	}
+
+	now = now.Add(time.Hour)
+	if !l.Allow("default/foo", influxdb) {
+		t.Errorf("expected the warning to be allowed after the interval")
+	}
+	if len(l.warned) != 1 {
+		t.Errorf("expected the expired warnings to be pruned but %v are recorded", len(l.warned))
+	}
+}
@@ -0,0 +1,687 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package expression compiles the expressions of the annotations evaluated per
+// request by the Lua code of NGINX. The expressions are written in a subset of
+// the Common Expression Language (CEL) over the attributes of the request:
+//
+//	request.method, request.path, request.host, request.scheme and client.ip are strings
+//	request.headers, request.query and request.cookies are maps of strings
+//
+// They support the literals (strings, integers, booleans and lists), the
+// operators ==, !=, <, <=, >, >=, in, &&, || and !, and the string methods
+// startsWith, endsWith, contains, matches and size.
+package expression
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// MaxLength is the maximum length of an expression
+	MaxLength = 1024
+	// MaxDepth is the maximum nesting depth of an expression
+	MaxDepth = 32
+)
+
+// Node is a node of a compiled expression, evaluated by the Lua code of NGINX
+type Node struct {
+	// Op is the operation of the node: literal, list, var, index, not, and, or,
+	// eq, ne, lt, le, gt, ge, in, has, startsWith, endsWith, contains, matches or size
+	Op string `json:"op"`
+	// Name is the name of the attribute of a var or index node
+	Name string `json:"name,omitempty"`
+	// Value is the value of a literal node
+	Value interface{} `json:"value"`
+	// Args are the operands of the node
+	Args []*Node `json:"args,omitempty"`
+}
+
+// Equal tests for equality between two compiled expressions
+func (n1 *Node) Equal(n2 *Node) bool {
+	if n1 == n2 {
+		return true
+	}
+	if n1 == nil || n2 == nil {
+		return false
+	}
+	if n1.Op != n2.Op || n1.Name != n2.Name || n1.Value != n2.Value {
+		return false
+	}
+	if len(n1.Args) != len(n2.Args) {
+		return false
+	}
+	for i := range n1.Args {
+		if !n1.Args[i].Equal(n2.Args[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// DeepCopyInto copies the receiver and its operands into out
+func (n *Node) DeepCopyInto(out *Node) {
+	*out = *n
+	if n.Args != nil {
+		out.Args = make([]*Node, len(n.Args))
+		for i, arg := range n.Args {
+			out.Args[i] = arg.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy returns a copy of the receiver and its operands
+func (n *Node) DeepCopy() *Node {
+	if n == nil {
+		return nil
+	}
+	out := new(Node)
+	n.DeepCopyInto(out)
+	return out
+}
+
+// Encode returns the base64 encoded JSON of a compiled expression, usable in
+// the value of an NGINX variable
+func (n *Node) Encode() string {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return ""
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+	kindMap
+	kindStringList
+	kindIntList
+	kindBoolList
+	kindEmptyList
+)
+
+func (k kind) String() string {
+	switch k {
+	case kindString:
+		return "string"
+	case kindInt:
+		return "int"
+	case kindBool:
+		return "bool"
+	case kindMap:
+		return "map"
+	case kindEmptyList:
+		return "list"
+	default:
+		return "list(" + k.elem().String() + ")"
+	}
+}
+
+// elem returns the kind of the elements of a list
+func (k kind) elem() kind {
+	switch k {
+	case kindStringList:
+		return kindString
+	case kindIntList:
+		return kindInt
+	default:
+		return kindBool
+	}
+}
+
+func listOf(k kind) kind {
+	switch k {
+	case kindString:
+		return kindStringList
+	case kindInt:
+		return kindIntList
+	default:
+		return kindBoolList
+	}
+}
+
+func (k kind) isList() bool {
+	return k >= kindStringList
+}
+
+// attributes are the attributes of the requests and their kind
+var attributes = map[string]kind{
+	"request.method":  kindString,
+	"request.path":    kindString,
+	"request.host":    kindString,
+	"request.scheme":  kindString,
+	"request.headers": kindMap,
+	"request.query":   kindMap,
+	"request.cookies": kindMap,
+	"client.ip":       kindString,
+}
+
+// stringMethods are the methods of the strings with one string argument returning a bool
+var stringMethods = map[string]bool{
+	"startsWith": true,
+	"endsWith":   true,
+	"contains":   true,
+	"matches":    true,
+}
+
+var relations = map[string]string{
+	"==": "eq",
+	"!=": "ne",
+	"<":  "lt",
+	"<=": "le",
+	">":  "gt",
+	">=": "ge",
+}
+
+// Compile compiles an expression returning a bool
+func Compile(src string) (*Node, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("the expression is longer than %d characters", MaxLength)
+	}
+
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &compiler{tokens: tokens}
+	node, k, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s at position %d", p.peek(), p.peek().pos)
+	}
+	if k != kindBool {
+		return nil, fmt.Errorf("the expression returns a %s instead of a bool", k)
+	}
+
+	return node, nil
+}
+
+type compiler struct {
+	tokens []token
+	pos    int
+	depth  int
+}
+
+func (p *compiler) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *compiler) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *compiler) accept(value string) bool {
+	if t := p.peek(); t.kind == tokenPunct && t.value == value {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *compiler) expect(value string) error {
+	if !p.accept(value) {
+		return fmt.Errorf("expected %q but found %s at position %d", value, p.peek(), p.peek().pos)
+	}
+	return nil
+}
+
+func (p *compiler) enter() error {
+	p.depth++
+	if p.depth > MaxDepth {
+		return fmt.Errorf("the expression is nested deeper than %d levels", MaxDepth)
+	}
+	return nil
+}
+
+func (p *compiler) leave() {
+	p.depth--
+}
+
+// or parses a || b
+func (p *compiler) or() (*Node, kind, error) {
+	left, k, err := p.and()
+	if err != nil {
+		return nil, k, err
+	}
+
+	for p.accept("||") {
+		right, rk, err := p.and()
+		if err != nil {
+			return nil, rk, err
+		}
+		if k != kindBool || rk != kindBool {
+			return nil, k, fmt.Errorf("the operands of || must be bools, found %s and %s", k, rk)
+		}
+		left = &Node{Op: "or", Args: []*Node{left, right}}
+	}
+
+	return left, k, nil
+}
+
+// and parses a && b
+func (p *compiler) and() (*Node, kind, error) {
+	left, k, err := p.relation()
+	if err != nil {
+		return nil, k, err
+	}
+
+	for p.accept("&&") {
+		right, rk, err := p.relation()
+		if err != nil {
+			return nil, rk, err
+		}
+		if k != kindBool || rk != kindBool {
+			return nil, k, fmt.Errorf("the operands of && must be bools, found %s and %s", k, rk)
+		}
+		left = &Node{Op: "and", Args: []*Node{left, right}}
+	}
+
+	return left, k, nil
+}
+
+// relation parses a == b, a < b, a in b...
+func (p *compiler) relation() (*Node, kind, error) {
+	left, k, err := p.unary()
+	if err != nil {
+		return nil, k, err
+	}
+
+	t := p.peek()
+	if t.kind == tokenIdent && t.value == "in" {
+		p.next()
+		right, rk, err := p.unary()
+		if err != nil {
+			return nil, rk, err
+		}
+
+		switch {
+		case rk == kindMap && k == kindString:
+			return &Node{Op: "has", Args: []*Node{left, right}}, kindBool, nil
+		case rk == kindEmptyList || (rk.isList() && rk.elem() == k):
+			return &Node{Op: "in", Args: []*Node{left, right}}, kindBool, nil
+		default:
+			return nil, k, fmt.Errorf("a %s cannot be in a %s", k, rk)
+		}
+	}
+
+	op, ok := relations[t.value]
+	if t.kind != tokenPunct || !ok {
+		return left, k, nil
+	}
+	p.next()
+
+	right, rk, err := p.unary()
+	if err != nil {
+		return nil, rk, err
+	}
+	if k != rk {
+		return nil, k, fmt.Errorf("the operands of %s must have the same type, found %s and %s", t.value, k, rk)
+	}
+	if (op != "eq" && op != "ne") && k != kindString && k != kindInt {
+		return nil, k, fmt.Errorf("the operands of %s must be strings or ints, found %s", t.value, k)
+	}
+	if k == kindMap || k.isList() || k == kindEmptyList {
+		return nil, k, fmt.Errorf("the %s values cannot be compared", k)
+	}
+
+	return &Node{Op: op, Args: []*Node{left, right}}, kindBool, nil
+}
+
+// unary parses !a
+func (p *compiler) unary() (*Node, kind, error) {
+	if !p.accept("!") {
+		return p.member()
+	}
+
+	if err := p.enter(); err != nil {
+		return nil, kindBool, err
+	}
+	defer p.leave()
+
+	node, k, err := p.unary()
+	if err != nil {
+		return nil, k, err
+	}
+	if k != kindBool {
+		return nil, k, fmt.Errorf("the operand of ! must be a bool, found %s", k)
+	}
+
+	return &Node{Op: "not", Args: []*Node{node}}, kindBool, nil
+}
+
+// member parses the method calls and the indexes of a primary expression
+func (p *compiler) member() (*Node, kind, error) {
+	node, k, err := p.primary()
+	if err != nil {
+		return nil, k, err
+	}
+
+	for {
+		switch {
+		case p.accept("["):
+			if k != kindMap {
+				return nil, k, fmt.Errorf("a %s cannot be indexed", k)
+			}
+			if err := p.enter(); err != nil {
+				return nil, k, err
+			}
+			key, kk, err := p.or()
+			p.leave()
+			if err != nil {
+				return nil, kk, err
+			}
+			if kk != kindString {
+				return nil, kk, fmt.Errorf("the keys of %s are strings, found %s", node.Name, kk)
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, k, err
+			}
+			node, k = &Node{Op: "index", Name: node.Name, Args: []*Node{key}}, kindString
+
+		case p.accept("."):
+			method := p.next()
+			if method.kind != tokenIdent {
+				return nil, k, fmt.Errorf("expected a method but found %s at position %d", method, method.pos)
+			}
+			if k != kindString {
+				return nil, k, fmt.Errorf("a %s has no method %s", k, method.value)
+			}
+			node, k, err = p.call(node, method)
+			if err != nil {
+				return nil, k, err
+			}
+
+		default:
+			return node, k, nil
+		}
+	}
+}
+
+// call parses the arguments of a method of a string
+func (p *compiler) call(receiver *Node, method token) (*Node, kind, error) {
+	if err := p.expect("("); err != nil {
+		return nil, kindString, err
+	}
+
+	if method.value == "size" {
+		if err := p.expect(")"); err != nil {
+			return nil, kindInt, err
+		}
+		return &Node{Op: "size", Args: []*Node{receiver}}, kindInt, nil
+	}
+
+	if !stringMethods[method.value] {
+		return nil, kindString, fmt.Errorf("unknown method %s at position %d", method.value, method.pos)
+	}
+
+	if err := p.enter(); err != nil {
+		return nil, kindString, err
+	}
+	arg, k, err := p.or()
+	p.leave()
+	if err != nil {
+		return nil, k, err
+	}
+	if k != kindString {
+		return nil, k, fmt.Errorf("the argument of %s must be a string, found %s", method.value, k)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, k, err
+	}
+
+	if method.value == "matches" {
+		// the regular expressions are compiled once by Lua, they must be constant
+		pattern, ok := arg.Value.(string)
+		if arg.Op != "literal" || !ok {
+			return nil, k, fmt.Errorf("the argument of matches must be a string literal")
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return nil, k, fmt.Errorf("invalid regular expression %q: %w", pattern, err)
+		}
+	}
+
+	return &Node{Op: method.value, Args: []*Node{receiver, arg}}, kindBool, nil
+}
+
+// primary parses the literals, the attributes, the lists and the parenthesized expressions
+func (p *compiler) primary() (*Node, kind, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return &Node{Op: "literal", Value: t.value}, kindString, nil
+
+	case tokenInt:
+		i, err := strconv.ParseInt(t.value, 10, 64)
+		if err != nil {
+			return nil, kindInt, fmt.Errorf("invalid integer %s at position %d", t.value, t.pos)
+		}
+		return &Node{Op: "literal", Value: i}, kindInt, nil
+
+	case tokenIdent:
+		switch t.value {
+		case "true", "false":
+			return &Node{Op: "literal", Value: t.value == "true"}, kindBool, nil
+		case "request", "client":
+			if err := p.expect("."); err != nil {
+				return nil, kindString, err
+			}
+			field := p.next()
+			name := t.value + "." + field.value
+			k, ok := attributes[name]
+			if field.kind != tokenIdent || !ok {
+				return nil, kindString, fmt.Errorf("unknown attribute %s at position %d", name, t.pos)
+			}
+			return &Node{Op: "var", Name: name}, k, nil
+		}
+		return nil, kindString, fmt.Errorf("unknown identifier %s at position %d", t.value, t.pos)
+
+	case tokenPunct:
+		switch t.value {
+		case "(":
+			if err := p.enter(); err != nil {
+				return nil, kindBool, err
+			}
+			defer p.leave()
+
+			node, k, err := p.or()
+			if err != nil {
+				return nil, k, err
+			}
+			return node, k, p.expect(")")
+
+		case "[":
+			return p.list()
+		}
+	}
+
+	return nil, kindString, fmt.Errorf("unexpected %s at position %d", t, t.pos)
+}
+
+// list parses the elements of a list, all of the same type
+func (p *compiler) list() (*Node, kind, error) {
+	if err := p.enter(); err != nil {
+		return nil, kindEmptyList, err
+	}
+	defer p.leave()
+
+	node := &Node{Op: "list", Args: []*Node{}}
+	if p.accept("]") {
+		return node, kindEmptyList, nil
+	}
+
+	var elem kind
+	for {
+		arg, k, err := p.or()
+		if err != nil {
+			return nil, k, err
+		}
+		if len(node.Args) == 0 {
+			elem = k
+		} else if k != elem {
+			return nil, k, fmt.Errorf("the elements of a list must have the same type, found %s and %s", elem, k)
+		}
+		if k != kindString && k != kindInt && k != kindBool {
+			return nil, k, fmt.Errorf("the elements of a list cannot be %ss", k)
+		}
+		node.Args = append(node.Args, arg)
+
+		if p.accept("]") {
+			return node, listOf(elem), nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, k, err
+		}
+	}
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenInt
+	tokenPunct
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "the end of the expression"
+	case tokenString:
+		return strconv.Quote(t.value)
+	default:
+		return fmt.Sprintf("%q", t.value)
+	}
+}
+
+// punctuations are the operators and the delimiters, the longest first
+var punctuations = []string{"==", "!=", "<=", ">=", "&&", "||", "<", ">", "!", "(", ")", "[", "]", ",", "."}
+
+func tokenize(src string) ([]token, error) {
+	tokens := []token{}
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '_' || isLetter(c):
+			start := i
+			for i < len(src) && (src[i] == '_' || isLetter(src[i]) || isDigit(src[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, value: src[start:i], pos: start})
+
+		case isDigit(c):
+			start := i
+			for i < len(src) && isDigit(src[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenInt, value: src[start:i], pos: start})
+
+		case c == '"' || c == '\'':
+			value, end, err := unquote(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, value: value, pos: i})
+			i = end
+
+		default:
+			found := false
+			for _, punct := range punctuations {
+				if strings.HasPrefix(src[i:], punct) {
+					tokens = append(tokens, token{kind: tokenPunct, value: punct, pos: i})
+					i += len(punct)
+					found = true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// unquote returns the value of the string literal starting at a position and the position following it
+func unquote(src string, start int) (value string, end int, err error) {
+	quote := src[start]
+
+	var b strings.Builder
+	for i := start + 1; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == quote:
+			return b.String(), i + 1, nil
+		case c == '\n':
+			return "", i, fmt.Errorf("unterminated string at position %d", start)
+		case c == '\\':
+			i++
+			if i == len(src) {
+				return "", i, fmt.Errorf("unterminated string at position %d", start)
+			}
+			switch src[i] {
+			case '\\', '"', '\'':
+				b.WriteByte(src[i])
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				return "", i, fmt.Errorf("unsupported escape sequence \\%c at position %d", src[i], i-1)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", len(src), fmt.Errorf("unterminated string at position %d", start)
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package expression
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		src      string
+		expected *Node
+	}{
+		{
+			`request.method == "POST"`,
+			&Node{Op: "eq", Args: []*Node{{Op: "var", Name: "request.method"}, {Op: "literal", Value: "POST"}}},
+		},
+		{
+			`request.headers["X-Env"] == 'staging' && !request.path.startsWith("/admin")`,
+			&Node{Op: "and", Args: []*Node{
+				{Op: "eq", Args: []*Node{
+					{Op: "index", Name: "request.headers", Args: []*Node{{Op: "literal", Value: "X-Env"}}},
+					{Op: "literal", Value: "staging"},
+				}},
+				{Op: "not", Args: []*Node{
+					{Op: "startsWith", Args: []*Node{{Op: "var", Name: "request.path"}, {Op: "literal", Value: "/admin"}}},
+				}},
+			}},
+		},
+		{
+			`request.method in ["GET", "HEAD"] || "beta" in request.cookies`,
+			&Node{Op: "or", Args: []*Node{
+				{Op: "in", Args: []*Node{
+					{Op: "var", Name: "request.method"},
+					{Op: "list", Args: []*Node{{Op: "literal", Value: "GET"}, {Op: "literal", Value: "HEAD"}}},
+				}},
+				{Op: "has", Args: []*Node{{Op: "literal", Value: "beta"}, {Op: "var", Name: "request.cookies"}}},
+			}},
+		},
+		{
+			`(request.query["v"].size() > 2)`,
+			&Node{Op: "gt", Args: []*Node{
+				{Op: "size", Args: []*Node{{Op: "index", Name: "request.query", Args: []*Node{{Op: "literal", Value: "v"}}}}},
+				{Op: "literal", Value: int64(2)},
+			}},
+		},
+		{
+			`client.ip.matches("^10\\.")`,
+			&Node{Op: "matches", Args: []*Node{{Op: "var", Name: "client.ip"}, {Op: "literal", Value: `^10\.`}}},
+		},
+		{
+			`true`,
+			&Node{Op: "literal", Value: true},
+		},
+	}
+
+	for _, tc := range testCases {
+		node, err := Compile(tc.src)
+		if err != nil {
+			t.Errorf("unexpected error compiling %s: %v", tc.src, err)
+			continue
+		}
+		if !reflect.DeepEqual(node, tc.expected) {
+			actual, _ := json.Marshal(node)
+			expected, _ := json.Marshal(tc.expected)
+			t.Errorf("expected %s but %s returned for %s", expected, actual, tc.src)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	testCases := map[string]string{
+		"not a bool":             `request.method`,
+		"unknown attribute":      `request.body == "a"`,
+		"unknown identifier":     `foo == "a"`,
+		"unknown method":         `request.path.lower() == "a"`,
+		"mismatched types":       `request.method == 1`,
+		"bool ordering":          `true < false`,
+		"not of a string":        `!request.method`,
+		"index of a string":      `request.method["a"] == "a"`,
+		"int key":                `request.headers[1] == "a"`,
+		"string in int list":     `request.method in [1, 2]`,
+		"mixed list":             `request.method in ["a", 1]`,
+		"dynamic regex":          `request.path.matches(request.host)`,
+		"invalid regex":          `request.path.matches("(")`,
+		"unterminated string":    `request.method == "POST`,
+		"unsupported escape":     `request.method == "\d"`,
+		"unexpected character":   `request.method == "a" ; true`,
+		"trailing tokens":        `true true`,
+		"missing parenthesis":    `(true`,
+		"map comparison":         `request.headers == request.query`,
+		"method of a map":        `request.headers.contains("a")`,
+		"statement":              `request.method = "POST"`,
+		"too deep":               strings.Repeat("(", MaxDepth+1) + "true" + strings.Repeat(")", MaxDepth+1),
+		"too long":               `request.method == "` + strings.Repeat("a", MaxLength) + `"`,
+		"empty":                  ``,
+		"operand of or":          `true || "a"`,
+		"argument of startsWith": `request.path.startsWith(1)`,
+	}
+
+	for name, src := range testCases {
+		if _, err := Compile(src); err == nil {
+			t.Errorf("%s: expected an error compiling %s", name, src)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	a, _ := Compile(`request.method in ["GET", "HEAD"]`)
+	b, _ := Compile(`request.method in ["GET", "HEAD"]`)
+	c, _ := Compile(`request.method in ["GET", "POST"]`)
+
+	if !a.Equal(b) {
+		t.Errorf("expected the expressions to be equal")
+	}
+	if a.Equal(c) {
+		t.Errorf("expected the expressions to be different")
+	}
+	if a.Equal(nil) {
+		t.Errorf("expected an expression to differ from nil")
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	n, err := Compile(`request.method in ["GET", "HEAD"] && !request.path.startsWith("/admin")`)
+	if err != nil {
+		t.Fatalf("unexpected error compiling the expression: %v", err)
+	}
+
+	c := n.DeepCopy()
+	if !n.Equal(c) {
+		t.Fatalf("expected the copy to equal the expression")
+	}
+	c.Args[1].Args[0].Args[1].Value = "/internal"
+	if n.Equal(c) {
+		t.Errorf("expected the operands of the copy to be independent of the expression")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	node, err := Compile(`request.method == "POST"`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(node.Encode())
+	if err != nil {
+		t.Fatalf("unexpected error decoding the expression: %v", err)
+	}
+
+	expected := `{"op":"eq","value":null,"args":[{"op":"var","name":"request.method","value":null},{"op":"literal","value":"POST"}]}`
+	if string(data) != expected {
+		t.Errorf("expected %s but %s returned", expected, data)
+	}
+}
@@ -18,6 +18,8 @@ package collectors
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -35,6 +37,18 @@ var (
 	orphanityLabels  = []string{"controller_namespace", "controller_class", "controller_pod", "namespace", "ingress", "type"}
 )
 
+// AnnotationUsage is the number of Ingresses using an annotation
+type AnnotationUsage struct {
+	// Annotation is the name of the annotation without the prefix
+	Annotation string
+	// Group is the feature the annotation belongs to
+	Group string
+	// Risk is the risk level of the annotation
+	Risk       string
+	Deprecated bool
+	Ingresses  int
+}
+
 // Controller defines base metrics about the ingress controller
 type Controller struct {
 	prometheus.Collector
@@ -43,8 +57,35 @@ type Controller struct {
 	configSuccess     prometheus.Gauge
 	configSuccessTime prometheus.Gauge
 
+	configSize          prometheus.Gauge
+	configOptimizedSize prometheus.Gauge
+	configSharedBlocks  prometheus.Gauge
+	configServers       prometheus.Gauge
+	configLocations     prometheus.Gauge
+
+	configDriftedReplicas prometheus.Gauge
+
+	workerFDUtilization prometheus.Gauge
+
+	stuckReloadWorkers prometheus.Gauge
+	oldWorkers         prometheus.Gauge
+	oldWorkersKilled   *prometheus.CounterVec
+
+	luaSharedDictUtilization *prometheus.GaugeVec
+
+	ocspResponseAge *prometheus.GaugeVec
+	ocspFetchErrors *prometheus.CounterVec
+
+	pathConflicts *prometheus.GaugeVec
+
+	nginxMasterExits     *prometheus.CounterVec
+	nginxMasterCrashLoop prometheus.Gauge
+
 	reloadOperation             *prometheus.CounterVec
 	reloadOperationErrors       *prometheus.CounterVec
+	reloadOperationAvoided      *prometheus.CounterVec
+	statusUpdates               *prometheus.CounterVec
+	statusRemovalsDampened      *prometheus.CounterVec
 	checkIngressOperation       *prometheus.CounterVec
 	checkIngressOperationErrors *prometheus.CounterVec
 	sslExpireTime               *prometheus.GaugeVec
@@ -56,6 +97,18 @@ type Controller struct {
 
 	leaderElection *prometheus.GaugeVec
 
+	informerSynced        *prometheus.GaugeVec
+	informerWatchRestarts *prometheus.CounterVec
+	informerEventLatency  *prometheus.HistogramVec
+	informerLastEventTime *prometheus.GaugeVec
+
+	componentHealthy         *prometheus.GaugeVec
+	componentLastSuccessTime *prometheus.GaugeVec
+
+	annotationUsage    *prometheus.GaugeVec
+	ignoredIngresses   *prometheus.GaugeVec
+	deprecationWarning *prometheus.CounterVec
+
 	buildInfo prometheus.Collector
 }
 
@@ -115,6 +168,130 @@ func NewController(pod, namespace, class string) *Controller {
 				Help:        "Timestamp of the last successful configuration reload.",
 				ConstLabels: constLabels,
 			}),
+		configSize: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_size_bytes",
+				Help:        "Size of the NGINX configuration generated from the template",
+				ConstLabels: constLabels,
+			}),
+		configOptimizedSize: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_optimized_size_bytes",
+				Help:        "Size of the NGINX configuration and of the blocks shared by its locations after the optimization",
+				ConstLabels: constLabels,
+			}),
+		configSharedBlocks: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_shared_blocks",
+				Help:        "Number of blocks of directives shared by the locations of the optimized NGINX configuration",
+				ConstLabels: constLabels,
+			}),
+		configServers: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_servers",
+				Help:        "Number of servers of the NGINX configuration",
+				ConstLabels: constLabels,
+			}),
+		configLocations: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_locations",
+				Help:        "Number of locations of the servers of the NGINX configuration",
+				ConstLabels: constLabels,
+			}),
+		configDriftedReplicas: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "config_drifted_replicas",
+				Help:        "Number of replicas of the controller running a configuration different from the other replicas for longer than the threshold, reported by the leader",
+				ConstLabels: constLabels,
+			}),
+		workerFDUtilization: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_worker_fd_utilization_ratio",
+				Help:        "Highest ratio between open file descriptors and the limit of open files of the NGINX worker processes",
+				ConstLabels: constLabels,
+			}),
+		stuckReloadWorkers: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_stuck_workers",
+				Help:        "Number of NGINX workers of previous configurations still running after worker-shutdown-timeout and a margin",
+				ConstLabels: constLabels,
+			}),
+		oldWorkers: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_old_workers",
+				Help:        "Number of NGINX workers of previous configurations shutting down after a reload",
+				ConstLabels: constLabels,
+			}),
+		oldWorkersKilled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_old_workers_killed",
+				Help:        "Cumulative number of NGINX workers of previous configurations killed by reason (age, count)",
+				ConstLabels: constLabels,
+			},
+			[]string{"reason"},
+		),
+		luaSharedDictUtilization: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "lua_shared_dict_utilization_ratio",
+				Help:        "Ratio between the used memory and the capacity of a Lua shared dictionary",
+				ConstLabels: constLabels,
+			},
+			[]string{"dict"},
+		),
+		ocspResponseAge: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "ocsp_response_age_seconds",
+				Help:        "Number of seconds since the OCSP response stapled to the certificate of a Secret was produced",
+				ConstLabels: constLabels,
+			},
+			[]string{"namespace", "secret_name"},
+		),
+		pathConflicts: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "path_conflicts",
+				Help:        "Number of paths of an Ingress not configured because the same host and path are defined by another Ingress",
+				ConstLabels: constLabels,
+			},
+			[]string{"namespace", "ingress"},
+		),
+		ocspFetchErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "ocsp_fetch_errors",
+				Help:        "Cumulative number of errors fetching the OCSP response of the certificate of a Secret by reason (certificate, request, response, status)",
+				ConstLabels: constLabels,
+			},
+			[]string{"namespace", "secret_name", "reason"},
+		),
+		nginxMasterExits: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_master_exits",
+				Help:        "Cumulative number of unexpected exits of the NGINX master process by reason (exited, signaled, core_dumped)",
+				ConstLabels: constLabels,
+			},
+			[]string{"reason"},
+		),
+		nginxMasterCrashLoop: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "nginx_master_crash_loop",
+				Help:        "Whether the NGINX master process exits too often to be respawned",
+				ConstLabels: constLabels,
+			}),
 		reloadOperation: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: PrometheusNamespace,
@@ -131,6 +308,30 @@ func NewController(pod, namespace, class string) *Controller {
 			},
 			operation,
 		),
+		reloadOperationAvoided: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "avoided_reloads",
+				Help:      `Cumulative number of configuration changes applied without rendering the configuration and reloading NGINX`,
+			},
+			operation,
+		),
+		statusUpdates: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "ingress_status_updates",
+				Help:      `Cumulative number of updates of the load-balancer status of Ingresses`,
+			},
+			operation,
+		),
+		statusRemovalsDampened: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: PrometheusNamespace,
+				Name:      "ingress_status_removals_dampened",
+				Help:      `Cumulative number of times an address missing from the publish service was kept in the load-balancer status of Ingresses`,
+			},
+			operation,
+		),
 		checkIngressOperationErrors: prometheus.NewCounterVec(
 			prometheus.CounterOpts{
 				Namespace: PrometheusNamespace,
@@ -173,6 +374,88 @@ func NewController(pod, namespace, class string) *Controller {
 			},
 			[]string{"name"},
 		),
+		informerSynced: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "informer_synced",
+				Help:        "Whether the local cache of a resource type is synchronized with the Kubernetes API server",
+				ConstLabels: constLabels,
+			},
+			[]string{"resource"},
+		),
+		informerWatchRestarts: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "informer_watch_restarts",
+				Help:        "Cumulative number of watches of a resource type restarted after an error",
+				ConstLabels: constLabels,
+			},
+			[]string{"resource"},
+		),
+		informerEventLatency: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "informer_event_latency_seconds",
+				Help:        "Time elapsed between the change of an object in the Kubernetes API server and the reception of the event by the controller",
+				ConstLabels: constLabels,
+				Buckets:     []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 300},
+			},
+			[]string{"resource"},
+		),
+		informerLastEventTime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "informer_last_event_timestamp_seconds",
+				Help:        "Timestamp of the last change of a resource type received from the Kubernetes API server",
+				ConstLabels: constLabels,
+			},
+			[]string{"resource"},
+		),
+		componentHealthy: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "component_healthy",
+				Help:        "Whether a component of the controller reported in the verbose health check is healthy",
+				ConstLabels: constLabels,
+			},
+			[]string{"component"},
+		),
+		componentLastSuccessTime: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "component_last_success_timestamp_seconds",
+				Help:        "Timestamp of the last successful operation of a component of the controller reported in the verbose health check",
+				ConstLabels: constLabels,
+			},
+			[]string{"component"},
+		),
+		annotationUsage: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "annotation_usage",
+				Help:        "Number of Ingresses using an annotation",
+				ConstLabels: constLabels,
+			},
+			[]string{"annotation", "group", "risk", "deprecated"},
+		),
+		ignoredIngresses: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "ignored_ingresses",
+				Help:        "Number of Ingresses seen but not configured by the controller, or configured with their locations denied, by reason",
+				ConstLabels: constLabels,
+			},
+			[]string{"reason"},
+		),
+		deprecationWarning: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace:   PrometheusNamespace,
+				Name:        "deprecation_warnings",
+				Help:        "Cumulative number of warnings about a deprecated annotation or ConfigMap key",
+				ConstLabels: constLabels,
+			},
+			[]string{"kind", "name"},
+		),
 		OrphanIngress: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
 				Namespace: PrometheusNamespace,
@@ -197,6 +480,177 @@ func (cm *Controller) IncReloadErrorCount() {
 	cm.reloadOperationErrors.With(cm.constLabels).Inc()
 }
 
+// IncReloadAvoidedCount increment the avoided reload counter
+func (cm *Controller) IncReloadAvoidedCount() {
+	cm.reloadOperationAvoided.With(cm.constLabels).Inc()
+}
+
+// IncStatusUpdateCount increment the Ingress status update counter
+func (cm *Controller) IncStatusUpdateCount() {
+	cm.statusUpdates.With(cm.constLabels).Inc()
+}
+
+// IncStatusRemovalDampenedCount increment the counter of addresses kept in the Ingress status
+func (cm *Controller) IncStatusRemovalDampenedCount() {
+	cm.statusRemovalsDampened.With(cm.constLabels).Inc()
+}
+
+// SetInformerSynced sets if the local cache of a resource type is synchronized
+func (cm *Controller) SetInformerSynced(resource string, synced bool) {
+	value := 0.0
+	if synced {
+		value = 1.0
+	}
+
+	cm.informerSynced.WithLabelValues(resource).Set(value)
+}
+
+// IncInformerWatchRestartCount increment the counter of watch restarts of a resource type
+func (cm *Controller) IncInformerWatchRestartCount(resource string) {
+	cm.informerWatchRestarts.WithLabelValues(resource).Inc()
+}
+
+// ObserveInformerEventLatency records the reception of a change of a resource
+// type and the time elapsed since the change in the API server
+func (cm *Controller) ObserveInformerEventLatency(resource string, latency time.Duration) {
+	cm.informerEventLatency.WithLabelValues(resource).Observe(latency.Seconds())
+	cm.informerLastEventTime.WithLabelValues(resource).SetToCurrentTime()
+}
+
+// SetComponentHealth sets if a component of the controller is healthy and the
+// time of its last successful operation, when known
+func (cm *Controller) SetComponentHealth(component string, healthy bool, lastSuccess time.Time) {
+	value := 0.0
+	if healthy {
+		value = 1.0
+	}
+
+	cm.componentHealthy.WithLabelValues(component).Set(value)
+
+	if !lastSuccess.IsZero() {
+		cm.componentLastSuccessTime.WithLabelValues(component).Set(float64(lastSuccess.Unix()))
+	}
+}
+
+// SetAnnotationUsage sets the number of Ingresses using each annotation,
+// removing the annotations not used anymore
+func (cm *Controller) SetAnnotationUsage(usage []AnnotationUsage) {
+	cm.annotationUsage.Reset()
+
+	for _, u := range usage {
+		cm.annotationUsage.WithLabelValues(u.Annotation, u.Group, u.Risk, strconv.FormatBool(u.Deprecated)).Set(float64(u.Ingresses))
+	}
+}
+
+// SetIgnoredIngresses sets the number of Ingresses ignored by the controller by reason
+func (cm *Controller) SetIgnoredIngresses(counts map[string]int) {
+	for reason, count := range counts {
+		cm.ignoredIngresses.WithLabelValues(reason).Set(float64(count))
+	}
+}
+
+// IncDeprecationWarningCount increments the number of warnings about a
+// deprecated setting of a kind, annotation or configmap-key
+func (cm *Controller) IncDeprecationWarningCount(kind, name string) {
+	cm.deprecationWarning.WithLabelValues(kind, name).Inc()
+}
+
+// SetConfigSize sets the size of the NGINX configuration generated from the
+// template and after the optimization, with the number of shared blocks
+func (cm *Controller) SetConfigSize(size, optimizedSize, sharedBlocks int) {
+	cm.configSize.Set(float64(size))
+	cm.configOptimizedSize.Set(float64(optimizedSize))
+	cm.configSharedBlocks.Set(float64(sharedBlocks))
+}
+
+// SetConfigObjects sets the number of servers and locations of the NGINX
+// configuration
+func (cm *Controller) SetConfigObjects(servers, locations int) {
+	cm.configServers.Set(float64(servers))
+	cm.configLocations.Set(float64(locations))
+}
+
+// SetConfigDriftedReplicas sets the number of replicas running a
+// configuration different from the other replicas
+func (cm *Controller) SetConfigDriftedReplicas(replicas int) {
+	cm.configDriftedReplicas.Set(float64(replicas))
+}
+
+// SetWorkerFDUtilization sets the highest ratio of open file descriptors of the NGINX workers
+func (cm *Controller) SetWorkerFDUtilization(ratio float64) {
+	cm.workerFDUtilization.Set(ratio)
+}
+
+// SetStuckReloadWorkers sets the number of NGINX workers of previous
+// configurations not exiting after a reload
+func (cm *Controller) SetStuckReloadWorkers(workers int) {
+	cm.stuckReloadWorkers.Set(float64(workers))
+}
+
+// SetOldWorkers sets the number of NGINX workers of previous configurations
+// shutting down
+func (cm *Controller) SetOldWorkers(workers int) {
+	cm.oldWorkers.Set(float64(workers))
+}
+
+// AddOldWorkersKilled adds the number of NGINX workers of previous
+// configurations killed for a reason
+func (cm *Controller) AddOldWorkersKilled(reason string, workers int) {
+	cm.oldWorkersKilled.WithLabelValues(reason).Add(float64(workers))
+}
+
+// SetLuaSharedDictUtilization sets the ratio of the memory used in each Lua shared dictionary
+func (cm *Controller) SetLuaSharedDictUtilization(utilization map[string]float64) {
+	cm.luaSharedDictUtilization.Reset()
+
+	for dict, ratio := range utilization {
+		cm.luaSharedDictUtilization.WithLabelValues(dict).Set(ratio)
+	}
+}
+
+// SetOCSPResponseAge sets the age of the OCSP response of each Secret, by namespace/name
+func (cm *Controller) SetOCSPResponseAge(ages map[string]float64) {
+	cm.ocspResponseAge.Reset()
+
+	for secret, age := range ages {
+		namespace, name, _ := strings.Cut(secret, "/")
+		cm.ocspResponseAge.WithLabelValues(namespace, name).Set(age)
+	}
+}
+
+// SetPathConflicts sets the number of paths of each Ingress, by
+// namespace/name, shadowed by the same paths of other Ingresses
+func (cm *Controller) SetPathConflicts(conflicts map[string]int) {
+	cm.pathConflicts.Reset()
+
+	for ing, paths := range conflicts {
+		namespace, name, _ := strings.Cut(ing, "/")
+		cm.pathConflicts.WithLabelValues(namespace, name).Set(float64(paths))
+	}
+}
+
+// IncOCSPFetchErrorCount increments the number of errors fetching the OCSP
+// response of a Secret, by namespace/name, by reason
+func (cm *Controller) IncOCSPFetchErrorCount(secret, reason string) {
+	namespace, name, _ := strings.Cut(secret, "/")
+	cm.ocspFetchErrors.WithLabelValues(namespace, name, reason).Inc()
+}
+
+// IncNGINXMasterExitCount increment the counter of unexpected exits of the NGINX master process
+func (cm *Controller) IncNGINXMasterExitCount(reason string) {
+	cm.nginxMasterExits.WithLabelValues(reason).Inc()
+}
+
+// SetNGINXMasterCrashLoop sets if the NGINX master process is in a crash loop
+func (cm *Controller) SetNGINXMasterCrashLoop(crashLoop bool) {
+	value := 0.0
+	if crashLoop {
+		value = 1.0
+	}
+
+	cm.nginxMasterCrashLoop.Set(value)
+}
+
 // OnStartedLeading indicates the pod was elected as the leader
 func (cm *Controller) OnStartedLeading(electionID string) {
 	cm.leaderElection.WithLabelValues(electionID).Set(1.0)
@@ -265,13 +719,41 @@ func (cm *Controller) Describe(ch chan<- *prometheus.Desc) {
 	cm.configHash.Describe(ch)
 	cm.configSuccess.Describe(ch)
 	cm.configSuccessTime.Describe(ch)
+	cm.configSize.Describe(ch)
+	cm.configOptimizedSize.Describe(ch)
+	cm.configSharedBlocks.Describe(ch)
+	cm.configServers.Describe(ch)
+	cm.configLocations.Describe(ch)
+	cm.configDriftedReplicas.Describe(ch)
+	cm.workerFDUtilization.Describe(ch)
+	cm.stuckReloadWorkers.Describe(ch)
+	cm.oldWorkers.Describe(ch)
+	cm.oldWorkersKilled.Describe(ch)
+	cm.luaSharedDictUtilization.Describe(ch)
+	cm.ocspResponseAge.Describe(ch)
+	cm.ocspFetchErrors.Describe(ch)
+	cm.pathConflicts.Describe(ch)
+	cm.nginxMasterExits.Describe(ch)
+	cm.nginxMasterCrashLoop.Describe(ch)
 	cm.reloadOperation.Describe(ch)
 	cm.reloadOperationErrors.Describe(ch)
+	cm.reloadOperationAvoided.Describe(ch)
+	cm.statusUpdates.Describe(ch)
+	cm.statusRemovalsDampened.Describe(ch)
 	cm.checkIngressOperation.Describe(ch)
 	cm.checkIngressOperationErrors.Describe(ch)
 	cm.sslExpireTime.Describe(ch)
 	cm.sslInfo.Describe(ch)
 	cm.leaderElection.Describe(ch)
+	cm.informerSynced.Describe(ch)
+	cm.informerWatchRestarts.Describe(ch)
+	cm.informerEventLatency.Describe(ch)
+	cm.informerLastEventTime.Describe(ch)
+	cm.componentHealthy.Describe(ch)
+	cm.componentLastSuccessTime.Describe(ch)
+	cm.annotationUsage.Describe(ch)
+	cm.ignoredIngresses.Describe(ch)
+	cm.deprecationWarning.Describe(ch)
 	cm.buildInfo.Describe(ch)
 	cm.OrphanIngress.Describe(ch)
 }
@@ -281,13 +763,41 @@ func (cm *Controller) Collect(ch chan<- prometheus.Metric) {
 	cm.configHash.Collect(ch)
 	cm.configSuccess.Collect(ch)
 	cm.configSuccessTime.Collect(ch)
+	cm.configSize.Collect(ch)
+	cm.configOptimizedSize.Collect(ch)
+	cm.configSharedBlocks.Collect(ch)
+	cm.configServers.Collect(ch)
+	cm.configLocations.Collect(ch)
+	cm.configDriftedReplicas.Collect(ch)
+	cm.workerFDUtilization.Collect(ch)
+	cm.stuckReloadWorkers.Collect(ch)
+	cm.oldWorkers.Collect(ch)
+	cm.oldWorkersKilled.Collect(ch)
+	cm.luaSharedDictUtilization.Collect(ch)
+	cm.ocspResponseAge.Collect(ch)
+	cm.ocspFetchErrors.Collect(ch)
+	cm.pathConflicts.Collect(ch)
+	cm.nginxMasterExits.Collect(ch)
+	cm.nginxMasterCrashLoop.Collect(ch)
 	cm.reloadOperation.Collect(ch)
 	cm.reloadOperationErrors.Collect(ch)
+	cm.reloadOperationAvoided.Collect(ch)
+	cm.statusUpdates.Collect(ch)
+	cm.statusRemovalsDampened.Collect(ch)
 	cm.checkIngressOperation.Collect(ch)
 	cm.checkIngressOperationErrors.Collect(ch)
 	cm.sslExpireTime.Collect(ch)
 	cm.sslInfo.Collect(ch)
 	cm.leaderElection.Collect(ch)
+	cm.informerSynced.Collect(ch)
+	cm.informerWatchRestarts.Collect(ch)
+	cm.informerEventLatency.Collect(ch)
+	cm.informerLastEventTime.Collect(ch)
+	cm.componentHealthy.Collect(ch)
+	cm.componentLastSuccessTime.Collect(ch)
+	cm.annotationUsage.Collect(ch)
+	cm.ignoredIngresses.Collect(ch)
+	cm.deprecationWarning.Collect(ch)
 	cm.buildInfo.Collect(ch)
 	cm.OrphanIngress.Collect(ch)
 }
@@ -73,6 +73,269 @@ func TestControllerCounters(t *testing.T) {
 			`,
 			metrics: []string{"nginx_ingress_controller_errors"},
 		},
+		{
+			name: "should set the size of the NGINX configuration",
+			test: func(cm *Controller) {
+				cm.SetConfigSize(4096, 1024, 2)
+			},
+			want: `
+				# HELP nginx_ingress_controller_config_optimized_size_bytes Size of the NGINX configuration and of the blocks shared by its locations after the optimization
+				# TYPE nginx_ingress_controller_config_optimized_size_bytes gauge
+				nginx_ingress_controller_config_optimized_size_bytes{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1024
+				# HELP nginx_ingress_controller_config_shared_blocks Number of blocks of directives shared by the locations of the optimized NGINX configuration
+				# TYPE nginx_ingress_controller_config_shared_blocks gauge
+				nginx_ingress_controller_config_shared_blocks{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 2
+				# HELP nginx_ingress_controller_config_size_bytes Size of the NGINX configuration generated from the template
+				# TYPE nginx_ingress_controller_config_size_bytes gauge
+				nginx_ingress_controller_config_size_bytes{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 4096
+			`,
+			metrics: []string{
+				"nginx_ingress_controller_config_size_bytes",
+				"nginx_ingress_controller_config_optimized_size_bytes",
+				"nginx_ingress_controller_config_shared_blocks",
+			},
+		},
+		{
+			name: "should set the number of servers and locations of the NGINX configuration",
+			test: func(cm *Controller) {
+				cm.SetConfigObjects(2, 5)
+			},
+			want: `
+				# HELP nginx_ingress_controller_config_locations Number of locations of the servers of the NGINX configuration
+				# TYPE nginx_ingress_controller_config_locations gauge
+				nginx_ingress_controller_config_locations{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 5
+				# HELP nginx_ingress_controller_config_servers Number of servers of the NGINX configuration
+				# TYPE nginx_ingress_controller_config_servers gauge
+				nginx_ingress_controller_config_servers{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 2
+			`,
+			metrics: []string{"nginx_ingress_controller_config_servers", "nginx_ingress_controller_config_locations"},
+		},
+		{
+			name: "should set the number of drifted replicas",
+			test: func(cm *Controller) {
+				cm.SetConfigDriftedReplicas(1)
+			},
+			want: `
+				# HELP nginx_ingress_controller_config_drifted_replicas Number of replicas of the controller running a configuration different from the other replicas for longer than the threshold, reported by the leader
+				# TYPE nginx_ingress_controller_config_drifted_replicas gauge
+				nginx_ingress_controller_config_drifted_replicas{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_config_drifted_replicas"},
+		},
+		{
+			name: "should set the file descriptor utilization of the NGINX workers",
+			test: func(cm *Controller) {
+				cm.SetWorkerFDUtilization(0.5)
+			},
+			want: `
+				# HELP nginx_ingress_controller_nginx_worker_fd_utilization_ratio Highest ratio between open file descriptors and the limit of open files of the NGINX worker processes
+				# TYPE nginx_ingress_controller_nginx_worker_fd_utilization_ratio gauge
+				nginx_ingress_controller_nginx_worker_fd_utilization_ratio{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 0.5
+			`,
+			metrics: []string{"nginx_ingress_controller_nginx_worker_fd_utilization_ratio"},
+		},
+		{
+			name: "should set the number of stuck NGINX workers",
+			test: func(cm *Controller) {
+				cm.SetStuckReloadWorkers(2)
+			},
+			want: `
+				# HELP nginx_ingress_controller_nginx_stuck_workers Number of NGINX workers of previous configurations still running after worker-shutdown-timeout and a margin
+				# TYPE nginx_ingress_controller_nginx_stuck_workers gauge
+				nginx_ingress_controller_nginx_stuck_workers{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 2
+			`,
+			metrics: []string{"nginx_ingress_controller_nginx_stuck_workers"},
+		},
+		{
+			name: "should set the number of old NGINX workers and count the killed workers",
+			test: func(cm *Controller) {
+				cm.SetOldWorkers(3)
+				cm.AddOldWorkersKilled("age", 2)
+				cm.AddOldWorkersKilled("age", 1)
+				cm.AddOldWorkersKilled("count", 1)
+			},
+			want: `
+				# HELP nginx_ingress_controller_nginx_old_workers Number of NGINX workers of previous configurations shutting down after a reload
+				# TYPE nginx_ingress_controller_nginx_old_workers gauge
+				nginx_ingress_controller_nginx_old_workers{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 3
+				# HELP nginx_ingress_controller_nginx_old_workers_killed Cumulative number of NGINX workers of previous configurations killed by reason (age, count)
+				# TYPE nginx_ingress_controller_nginx_old_workers_killed counter
+				nginx_ingress_controller_nginx_old_workers_killed{controller_class="nginx",controller_namespace="default",controller_pod="pod",reason="age"} 3
+				nginx_ingress_controller_nginx_old_workers_killed{controller_class="nginx",controller_namespace="default",controller_pod="pod",reason="count"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_nginx_old_workers", "nginx_ingress_controller_nginx_old_workers_killed"},
+		},
+		{
+			name: "should set the utilization of the Lua shared dictionaries",
+			test: func(cm *Controller) {
+				cm.SetLuaSharedDictUtilization(map[string]float64{"stale": 1})
+				cm.SetLuaSharedDictUtilization(map[string]float64{
+					"certificate_data":   0.25,
+					"configuration_data": 0.5,
+				})
+			},
+			want: `
+				# HELP nginx_ingress_controller_lua_shared_dict_utilization_ratio Ratio between the used memory and the capacity of a Lua shared dictionary
+				# TYPE nginx_ingress_controller_lua_shared_dict_utilization_ratio gauge
+				nginx_ingress_controller_lua_shared_dict_utilization_ratio{controller_class="nginx",controller_namespace="default",controller_pod="pod",dict="certificate_data"} 0.25
+				nginx_ingress_controller_lua_shared_dict_utilization_ratio{controller_class="nginx",controller_namespace="default",controller_pod="pod",dict="configuration_data"} 0.5
+			`,
+			metrics: []string{"nginx_ingress_controller_lua_shared_dict_utilization_ratio"},
+		},
+		{
+			name: "should set the age of the OCSP responses and count the errors fetching them",
+			test: func(cm *Controller) {
+				cm.SetOCSPResponseAge(map[string]float64{"default/removed": 60})
+				cm.SetOCSPResponseAge(map[string]float64{"default/example": 120})
+				cm.IncOCSPFetchErrorCount("default/example", "request")
+				cm.IncOCSPFetchErrorCount("default/example", "request")
+				cm.IncOCSPFetchErrorCount("default/other", "status")
+			},
+			want: `
+				# HELP nginx_ingress_controller_ocsp_fetch_errors Cumulative number of errors fetching the OCSP response of the certificate of a Secret by reason (certificate, request, response, status)
+				# TYPE nginx_ingress_controller_ocsp_fetch_errors counter
+				nginx_ingress_controller_ocsp_fetch_errors{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="default",reason="request",secret_name="example"} 2
+				nginx_ingress_controller_ocsp_fetch_errors{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="default",reason="status",secret_name="other"} 1
+				# HELP nginx_ingress_controller_ocsp_response_age_seconds Number of seconds since the OCSP response stapled to the certificate of a Secret was produced
+				# TYPE nginx_ingress_controller_ocsp_response_age_seconds gauge
+				nginx_ingress_controller_ocsp_response_age_seconds{controller_class="nginx",controller_namespace="default",controller_pod="pod",namespace="default",secret_name="example"} 120
+			`,
+			metrics: []string{"nginx_ingress_controller_ocsp_response_age_seconds", "nginx_ingress_controller_ocsp_fetch_errors"},
+		},
+		{
+			name: "should set the number of conflicting paths of the Ingresses",
+			test: func(cm *Controller) {
+				cm.SetPathConflicts(map[string]int{"default/resolved": 1})
+				cm.SetPathConflicts(map[string]int{"default/shadowed": 2, "other/shadowed": 1})
+			},
+			want: `
+				# HELP nginx_ingress_controller_path_conflicts Number of paths of an Ingress not configured because the same host and path are defined by another Ingress
+				# TYPE nginx_ingress_controller_path_conflicts gauge
+				nginx_ingress_controller_path_conflicts{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="shadowed",namespace="default"} 2
+				nginx_ingress_controller_path_conflicts{controller_class="nginx",controller_namespace="default",controller_pod="pod",ingress="shadowed",namespace="other"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_path_conflicts"},
+		},
+		{
+			name: "should count the exits of the NGINX master process",
+			test: func(cm *Controller) {
+				cm.IncNGINXMasterExitCount("signaled")
+				cm.IncNGINXMasterExitCount("signaled")
+				cm.IncNGINXMasterExitCount("core_dumped")
+				cm.SetNGINXMasterCrashLoop(true)
+			},
+			want: `
+				# HELP nginx_ingress_controller_nginx_master_crash_loop Whether the NGINX master process exits too often to be respawned
+				# TYPE nginx_ingress_controller_nginx_master_crash_loop gauge
+				nginx_ingress_controller_nginx_master_crash_loop{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1
+				# HELP nginx_ingress_controller_nginx_master_exits Cumulative number of unexpected exits of the NGINX master process by reason (exited, signaled, core_dumped)
+				# TYPE nginx_ingress_controller_nginx_master_exits counter
+				nginx_ingress_controller_nginx_master_exits{controller_class="nginx",controller_namespace="default",controller_pod="pod",reason="core_dumped"} 1
+				nginx_ingress_controller_nginx_master_exits{controller_class="nginx",controller_namespace="default",controller_pod="pod",reason="signaled"} 2
+			`,
+			metrics: []string{"nginx_ingress_controller_nginx_master_exits", "nginx_ingress_controller_nginx_master_crash_loop"},
+		},
+		{
+			name: "should count the updates of the Ingress status",
+			test: func(cm *Controller) {
+				cm.IncStatusUpdateCount()
+				cm.IncStatusUpdateCount()
+				cm.IncStatusRemovalDampenedCount()
+			},
+			want: `
+				# HELP nginx_ingress_controller_ingress_status_removals_dampened Cumulative number of times an address missing from the publish service was kept in the load-balancer status of Ingresses
+				# TYPE nginx_ingress_controller_ingress_status_removals_dampened counter
+				nginx_ingress_controller_ingress_status_removals_dampened{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1
+				# HELP nginx_ingress_controller_ingress_status_updates Cumulative number of updates of the load-balancer status of Ingresses
+				# TYPE nginx_ingress_controller_ingress_status_updates counter
+				nginx_ingress_controller_ingress_status_updates{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 2
+			`,
+			metrics: []string{"nginx_ingress_controller_ingress_status_updates", "nginx_ingress_controller_ingress_status_removals_dampened"},
+		},
+		{
+			name: "should return informer metrics",
+			test: func(cm *Controller) {
+				cm.SetInformerSynced("ingresses", true)
+				cm.SetInformerSynced("secrets", false)
+				cm.IncInformerWatchRestartCount("secrets")
+			},
+			want: `
+				# HELP nginx_ingress_controller_informer_synced Whether the local cache of a resource type is synchronized with the Kubernetes API server
+				# TYPE nginx_ingress_controller_informer_synced gauge
+				nginx_ingress_controller_informer_synced{controller_class="nginx",controller_namespace="default",controller_pod="pod",resource="ingresses"} 1
+				nginx_ingress_controller_informer_synced{controller_class="nginx",controller_namespace="default",controller_pod="pod",resource="secrets"} 0
+				# HELP nginx_ingress_controller_informer_watch_restarts Cumulative number of watches of a resource type restarted after an error
+				# TYPE nginx_ingress_controller_informer_watch_restarts counter
+				nginx_ingress_controller_informer_watch_restarts{controller_class="nginx",controller_namespace="default",controller_pod="pod",resource="secrets"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_informer_synced", "nginx_ingress_controller_informer_watch_restarts"},
+		},
+		{
+			name: "should return component health metrics",
+			test: func(cm *Controller) {
+				cm.SetComponentHealth("reload", true, time.Unix(1700000000, 0))
+				cm.SetComponentHealth("informers", false, time.Time{})
+			},
+			want: `
+				# HELP nginx_ingress_controller_component_healthy Whether a component of the controller reported in the verbose health check is healthy
+				# TYPE nginx_ingress_controller_component_healthy gauge
+				nginx_ingress_controller_component_healthy{component="informers",controller_class="nginx",controller_namespace="default",controller_pod="pod"} 0
+				nginx_ingress_controller_component_healthy{component="reload",controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1
+				# HELP nginx_ingress_controller_component_last_success_timestamp_seconds Timestamp of the last successful operation of a component of the controller reported in the verbose health check
+				# TYPE nginx_ingress_controller_component_last_success_timestamp_seconds gauge
+				nginx_ingress_controller_component_last_success_timestamp_seconds{component="reload",controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1.7e+09
+			`,
+			metrics: []string{"nginx_ingress_controller_component_healthy", "nginx_ingress_controller_component_last_success_timestamp_seconds"},
+		},
+		{
+			name: "should return annotation usage metrics",
+			test: func(cm *Controller) {
+				cm.SetAnnotationUsage([]AnnotationUsage{
+					{Annotation: "rewrite-target", Group: "Rewrite", Risk: "Medium", Ingresses: 2},
+					{Annotation: "enable-influxdb", Deprecated: true, Ingresses: 1},
+				})
+				cm.SetAnnotationUsage([]AnnotationUsage{
+					{Annotation: "configuration-snippet", Group: "ConfigurationSnippet", Risk: "Critical", Ingresses: 3},
+					{Annotation: "enable-influxdb", Deprecated: true, Ingresses: 1},
+				})
+			},
+			want: `
+				# HELP nginx_ingress_controller_annotation_usage Number of Ingresses using an annotation
+				# TYPE nginx_ingress_controller_annotation_usage gauge
+				nginx_ingress_controller_annotation_usage{annotation="configuration-snippet",controller_class="nginx",controller_namespace="default",controller_pod="pod",deprecated="false",group="ConfigurationSnippet",risk="Critical"} 3
+				nginx_ingress_controller_annotation_usage{annotation="enable-influxdb",controller_class="nginx",controller_namespace="default",controller_pod="pod",deprecated="true",group="",risk=""} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_annotation_usage"},
+		},
+		{
+			name: "should return ignored ingresses metrics",
+			test: func(cm *Controller) {
+				cm.SetIgnoredIngresses(map[string]int{"IngressClass": 2, "DeniedLocations": 1})
+				cm.SetIgnoredIngresses(map[string]int{"IngressClass": 3, "DeniedLocations": 0})
+			},
+			want: `
+				# HELP nginx_ingress_controller_ignored_ingresses Number of Ingresses seen but not configured by the controller, or configured with their locations denied, by reason
+				# TYPE nginx_ingress_controller_ignored_ingresses gauge
+				nginx_ingress_controller_ignored_ingresses{controller_class="nginx",controller_namespace="default",controller_pod="pod",reason="DeniedLocations"} 0
+				nginx_ingress_controller_ignored_ingresses{controller_class="nginx",controller_namespace="default",controller_pod="pod",reason="IngressClass"} 3
+			`,
+			metrics: []string{"nginx_ingress_controller_ignored_ingresses"},
+		},
+		{
+			name: "should count the deprecation warnings",
+			test: func(cm *Controller) {
+				cm.IncDeprecationWarningCount("annotation", "enable-influxdb")
+				cm.IncDeprecationWarningCount("annotation", "enable-influxdb")
+				cm.IncDeprecationWarningCount("configmap-key", "http2-max-requests")
+			},
+			want: `
+				# HELP nginx_ingress_controller_deprecation_warnings Cumulative number of warnings about a deprecated annotation or ConfigMap key
+				# TYPE nginx_ingress_controller_deprecation_warnings counter
+				nginx_ingress_controller_deprecation_warnings{controller_class="nginx",controller_namespace="default",controller_pod="pod",kind="annotation",name="enable-influxdb"} 2
+				nginx_ingress_controller_deprecation_warnings{controller_class="nginx",controller_namespace="default",controller_pod="pod",kind="configmap-key",name="http2-max-requests"} 1
+			`,
+			metrics: []string{"nginx_ingress_controller_deprecation_warnings"},
+		},
 		{
 			name: "should set SSL certificates metrics",
 			test: func(cm *Controller) {
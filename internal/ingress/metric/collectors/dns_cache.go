@@ -0,0 +1,180 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+// DNSCachePath is the path of the NGINX status server returning the
+// statistics of the DNS cache of Lua
+const DNSCachePath = "/configuration/dns-cache"
+
+type (
+	dnsCacheCollector struct {
+		scrapeChan chan scrapeRequest
+
+		data *dnsCacheData
+	}
+
+	dnsCacheData struct {
+		lookups            *prometheus.Desc
+		staleResponses     *prometheus.Desc
+		resolutionErrors   *prometheus.Desc
+		resolutionDuration *prometheus.Desc
+	}
+
+	// dnsCacheStats is the cumulative statistics of the DNS cache shared by
+	// the NGINX workers
+	dnsCacheStats struct {
+		Hits              uint64            `json:"hits"`
+		Misses            uint64            `json:"misses"`
+		Stale             uint64            `json:"stale"`
+		Errors            uint64            `json:"errors"`
+		Resolutions       uint64            `json:"resolutions"`
+		ResolutionSeconds float64           `json:"resolution_seconds"`
+		ResolutionBuckets map[string]uint64 `json:"resolution_buckets"`
+	}
+)
+
+// DNSCacheCollector defines a collector of the statistics of the DNS cache
+type DNSCacheCollector interface {
+	prometheus.Collector
+
+	Start()
+	Stop()
+}
+
+// NewDNSCache returns a new prometheus collector of the DNS cache used by Lua
+// to resolve the Services of type ExternalName and the auth-url
+func NewDNSCache(podName, namespace, ingressClass string) (DNSCacheCollector, error) {
+	p := dnsCacheCollector{
+		scrapeChan: make(chan scrapeRequest),
+	}
+
+	constLabels := prometheus.Labels{
+		"controller_namespace": namespace,
+		"controller_class":     ingressClass,
+		"controller_pod":       podName,
+	}
+
+	p.data = &dnsCacheData{
+		lookups: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "dns_cache", "lookups_total"),
+			"Cumulative number of lookups of the DNS cache by result {hit, miss}",
+			[]string{"result"}, constLabels),
+
+		staleResponses: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "dns_cache", "stale_responses_total"),
+			"Cumulative number of expired addresses returned by the DNS cache after a failed resolution",
+			nil, constLabels),
+
+		resolutionErrors: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "dns_cache", "resolution_errors_total"),
+			"Cumulative number of failed resolutions of the DNS cache",
+			nil, constLabels),
+
+		resolutionDuration: prometheus.NewDesc(
+			prometheus.BuildFQName(PrometheusNamespace, "dns_cache", "resolution_duration_seconds"),
+			"Time spent resolving the hosts missing from the DNS cache",
+			nil, constLabels),
+	}
+
+	return p, nil
+}
+
+// Describe implements prometheus.Collector.
+func (p dnsCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- p.data.lookups
+	ch <- p.data.staleResponses
+	ch <- p.data.resolutionErrors
+	ch <- p.data.resolutionDuration
+}
+
+// Collect implements prometheus.Collector.
+func (p dnsCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	req := scrapeRequest{results: ch, done: make(chan struct{})}
+	p.scrapeChan <- req
+	<-req.done
+}
+
+func (p dnsCacheCollector) Start() {
+	for req := range p.scrapeChan {
+		ch := req.results
+		p.scrape(ch)
+		req.done <- struct{}{}
+	}
+}
+
+func (p dnsCacheCollector) Stop() {
+	close(p.scrapeChan)
+}
+
+// resolutionBuckets returns the cumulative counts of the buckets of the
+// resolution durations by upper bound
+func (s *dnsCacheStats) resolutionBuckets() map[float64]uint64 {
+	buckets := make(map[float64]uint64, len(s.ResolutionBuckets))
+	for bound, count := range s.ResolutionBuckets {
+		upperBound, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			klog.Warningf("unexpected upper bound %q of a bucket of the DNS cache resolutions", bound)
+			continue
+		}
+
+		buckets[upperBound] = count
+	}
+
+	return buckets
+}
+
+// scrape obtains the statistics of the DNS cache from the NGINX status server
+func (p dnsCacheCollector) scrape(ch chan<- prometheus.Metric) {
+	status, data, err := nginx.NewGetStatusRequest(DNSCachePath)
+	if err != nil {
+		klog.V(3).InfoS("Unable to obtain the statistics of the DNS cache", "err", err)
+		return
+	}
+
+	if status != http.StatusOK {
+		klog.Warningf("Unexpected status code %v obtaining the statistics of the DNS cache", status)
+		return
+	}
+
+	var s dnsCacheStats
+	if err := json.Unmarshal(data, &s); err != nil {
+		klog.Warningf("Error decoding the statistics of the DNS cache: %v", err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(p.data.lookups,
+		prometheus.CounterValue, float64(s.Hits), "hit")
+	ch <- prometheus.MustNewConstMetric(p.data.lookups,
+		prometheus.CounterValue, float64(s.Misses), "miss")
+	ch <- prometheus.MustNewConstMetric(p.data.staleResponses,
+		prometheus.CounterValue, float64(s.Stale))
+	ch <- prometheus.MustNewConstMetric(p.data.resolutionErrors,
+		prometheus.CounterValue, float64(s.Errors))
+	ch <- prometheus.MustNewConstHistogram(p.data.resolutionDuration,
+		s.Resolutions, s.ResolutionSeconds, s.resolutionBuckets())
+}
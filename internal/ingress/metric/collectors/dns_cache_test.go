@@ -0,0 +1,101 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/ingress-nginx/internal/nginx"
+)
+
+func TestDNSCacheCollector(t *testing.T) {
+	listener, err := tryListen("tcp", fmt.Sprintf(":%v", nginx.StatusPort))
+	if err != nil {
+		t.Fatalf("crating unix listener: %s", err)
+	}
+
+	server := &httptest.Server{
+		Listener: listener,
+		Config: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { //nolint:gosec // Ignore the gosec error in testing
+			if r.URL.Path != DNSCachePath {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, `{"hits":10,"misses":3,"stale":1,"errors":2,"resolutions":3,"resolution_seconds":0.5,"resolution_buckets":{"0.1":1,"0.5":2,"1":3}}`)
+		})},
+	}
+	server.Start()
+	defer func() {
+		server.Close()
+		listener.Close()
+	}()
+
+	time.Sleep(1 * time.Second)
+
+	cm, err := NewDNSCache("pod", "default", "nginx")
+	if err != nil {
+		t.Fatalf("unexpected error creating the DNS cache collector: %v", err)
+	}
+
+	go cm.Start()
+	defer cm.Stop()
+
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(cm); err != nil {
+		t.Errorf("registering collector failed: %s", err)
+	}
+
+	want := `
+		# HELP nginx_ingress_controller_dns_cache_lookups_total Cumulative number of lookups of the DNS cache by result {hit, miss}
+		# TYPE nginx_ingress_controller_dns_cache_lookups_total counter
+		nginx_ingress_controller_dns_cache_lookups_total{controller_class="nginx",controller_namespace="default",controller_pod="pod",result="hit"} 10
+		nginx_ingress_controller_dns_cache_lookups_total{controller_class="nginx",controller_namespace="default",controller_pod="pod",result="miss"} 3
+		# HELP nginx_ingress_controller_dns_cache_resolution_duration_seconds Time spent resolving the hosts missing from the DNS cache
+		# TYPE nginx_ingress_controller_dns_cache_resolution_duration_seconds histogram
+		nginx_ingress_controller_dns_cache_resolution_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="0.1"} 1
+		nginx_ingress_controller_dns_cache_resolution_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="0.5"} 2
+		nginx_ingress_controller_dns_cache_resolution_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="1"} 3
+		nginx_ingress_controller_dns_cache_resolution_duration_seconds_bucket{controller_class="nginx",controller_namespace="default",controller_pod="pod",le="+Inf"} 3
+		nginx_ingress_controller_dns_cache_resolution_duration_seconds_sum{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 0.5
+		nginx_ingress_controller_dns_cache_resolution_duration_seconds_count{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 3
+		# HELP nginx_ingress_controller_dns_cache_resolution_errors_total Cumulative number of failed resolutions of the DNS cache
+		# TYPE nginx_ingress_controller_dns_cache_resolution_errors_total counter
+		nginx_ingress_controller_dns_cache_resolution_errors_total{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 2
+		# HELP nginx_ingress_controller_dns_cache_stale_responses_total Cumulative number of expired addresses returned by the DNS cache after a failed resolution
+		# TYPE nginx_ingress_controller_dns_cache_stale_responses_total counter
+		nginx_ingress_controller_dns_cache_stale_responses_total{controller_class="nginx",controller_namespace="default",controller_pod="pod"} 1
+	`
+	metrics := []string{
+		"nginx_ingress_controller_dns_cache_lookups_total",
+		"nginx_ingress_controller_dns_cache_resolution_duration_seconds",
+		"nginx_ingress_controller_dns_cache_resolution_errors_total",
+		"nginx_ingress_controller_dns_cache_stale_responses_total",
+	}
+	if err := GatherAndCompare(cm, want, metrics, reg); err != nil {
+		t.Errorf("unexpected collecting result:\n%s", err)
+	}
+
+	reg.Unregister(cm)
+}
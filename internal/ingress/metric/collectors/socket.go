@@ -21,7 +21,10 @@ import (
 	"io"
 	"net"
 	"os"
+	"regexp"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"syscall"
 
 	jsoniter "github.com/json-iterator/go"
@@ -49,6 +52,124 @@ type socketData struct {
 	Service      string  `json:"service"`
 	Canary       string  `json:"canary"`
 	Path         string  `json:"path"`
+
+	LimitConnStatus string `json:"limitConnStatus"`
+	LimitReqStatus  string `json:"limitReqStatus"`
+
+	CanaryDecision string `json:"canaryDecision"`
+
+	BackupFailover string `json:"backupFailover"`
+
+	BodyInspectionRejection string `json:"bodyInspectionRejection"`
+
+	CacheCoalesced bool `json:"cacheCoalesced"`
+
+	CSPViolations string `json:"cspViolations"`
+
+	UpstreamConnectFailures string `json:"upstreamConnectFailures"`
+
+	Endpoint             string  `json:"endpoint"`
+	EndpointStatus       string  `json:"endpointStatus"`
+	EndpointResponseTime float64 `json:"endpointResponseTime"`
+
+	Upstream             string    `json:"upstream"`
+	UpstreamConnectTimes []float64 `json:"upstreamConnectTimes"`
+	UpstreamHeaderTimes  []float64 `json:"upstreamHeaderTimes"`
+}
+
+// limitRejectedStatus is the value of the variables $limit_conn_status
+// and $limit_req_status of a request rejected by the limit
+const limitRejectedStatus = "REJECTED"
+
+var limitRejectionTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"limit",
+}
+
+// canaryDecisionReasons are the values of the variable $canary_decision of a
+// request to a backend with a canary, the rule that routed the request
+var canaryDecisionReasons = sets.New[string]("affinity", "header", "cookie", "expression", "weight")
+
+var canaryDecisionTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"backend",
+	"reason",
+}
+
+// backupFailoverReasons are the values of the variable $backup_failover of a
+// request served by the backup service of an Ingress, the reason of the failover
+var backupFailoverReasons = sets.New[string]("endpoints", "status")
+
+var backupFailoverTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"reason",
+}
+
+// bodyInspectionReasons are the values of the variable $body_inspection_rejection
+// of a request whose body exceeds a limit of the inspection
+var bodyInspectionReasons = sets.New[string]("xml_depth", "xml_entity_expansions", "json_depth", "json_array_size")
+
+var bodyInspectionRejectionTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"reason",
+}
+
+var cacheCoalescedTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+}
+
+// cspViolationDirectives are the directives of the variable $csp_violations
+// of a request reporting violations of a Content Security Policy
+var cspViolationDirectives = sets.New[string](
+	"base-uri", "child-src", "connect-src", "default-src", "font-src", "form-action", "frame-ancestors",
+	"frame-src", "img-src", "manifest-src", "media-src", "object-src", "require-trusted-types-for", "sandbox",
+	"script-src", "script-src-attr", "script-src-elem", "style-src", "style-src-attr", "style-src-elem",
+	"trusted-types", "worker-src", "other",
+)
+
+var cspViolationTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"directive",
+}
+
+// ipFamilies are the families of the upstream endpoints a request failed to
+// connect to, computed from the variables $upstream_addr and $upstream_connect_time
+var ipFamilies = sets.New[string]("ipv4", "ipv6")
+
+var upstreamConnectFailureTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"family",
+}
+
+// clientAbortStatus is the status code of the requests closed by the client
+// before the response was sent
+const clientAbortStatus = "499"
+
+var clientAbortTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+}
+
+var upstreamTimeTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"backend",
 }
 
 // HistogramBuckets allow customizing prometheus histogram buckets values
@@ -75,15 +196,61 @@ type SocketCollector struct {
 
 	requests *prometheus.CounterVec
 
+	limitRejections *prometheus.CounterVec
+
+	canaryDecisions *prometheus.CounterVec
+
+	backupFailovers *prometheus.CounterVec
+
+	bodyInspectionRejections *prometheus.CounterVec
+
+	cacheCoalescedRequests *prometheus.CounterVec
+
+	cspViolations *prometheus.CounterVec
+
+	upstreamConnectFailures *prometheus.CounterVec
+
+	clientAborts *prometheus.CounterVec
+
+	upstreamConnectTime *prometheus.HistogramVec
+	upstreamHeaderTime  *prometheus.HistogramVec
+
+	endpointRequests       *prometheus.CounterVec
+	endpointErrors         *prometheus.CounterVec
+	endpointResponseTime   *prometheus.HistogramVec
+	endpointRequestLength  *prometheus.HistogramVec
+	endpointResponseLength *prometheus.HistogramVec
+
+	// endpoints tracks the series of the metrics by endpoint when they are enabled
+	endpoints *endpointSeries
+
 	listener net.Listener
 
 	metricMapping metricMapping
 
 	hosts sets.Set[string]
 
+	// metricsLabels contains the names of the labels that can be defined
+	// in Ingresses using the annotation metrics-labels
+	metricsLabels []string
+
+	// ingressLabels contains the labels of the Ingresses indexed by namespace/name
+	ingressLabels   map[string]map[string]string
+	ingressLabelsMu sync.RWMutex
+
 	metricsPerHost          bool
 	metricsPerUndefinedHost bool
 	reportStatusClasses     bool
+
+	// shards receive the batches of requests when sharding is enabled
+	shards    []chan []byte
+	nextShard atomic.Uint64
+	// generation is incremented when series are deleted, to reset the
+	// caches of the shards
+	generation atomic.Uint64
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
 }
 
 var requestTags = []string{
@@ -98,9 +265,37 @@ var requestTags = []string{
 	"canary",
 }
 
+// labelNameRegex validates the name of a Prometheus label
+var labelNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateMetricsLabels checks the names of the labels defined using the flag
+// --metrics-labels are valid and do not collide with the labels of the metrics
+func validateMetricsLabels(metricsLabels []string) error {
+	reserved := sets.New[string](requestTags...)
+	reserved.Insert("host", "controller_namespace", "controller_class", "controller_pod")
+
+	for _, label := range metricsLabels {
+		if !labelNameRegex.MatchString(label) || strings.HasPrefix(label, "__") {
+			return fmt.Errorf("invalid metrics label name %q", label)
+		}
+
+		if reserved.Has(label) {
+			return fmt.Errorf("metrics label %q is reserved", label)
+		}
+
+		reserved.Insert(label)
+	}
+
+	return nil
+}
+
 // NewSocketCollector creates a new SocketCollector instance using
 // the ingress watch namespace and class used by the controller
-func NewSocketCollector(pod, namespace, class string, metricsPerHost, metricsPerUndefinedHost, reportStatusClasses bool, buckets HistogramBuckets, bucketFactor float64, maxBuckets uint32, excludeMetrics []string) (*SocketCollector, error) {
+func NewSocketCollector(pod, namespace, class string, metricsPerHost, metricsPerUndefinedHost, reportStatusClasses bool, buckets HistogramBuckets, bucketFactor float64, maxBuckets uint32, excludeMetrics, metricsLabels []string) (*SocketCollector, error) {
+	if err := validateMetricsLabels(metricsLabels); err != nil {
+		return nil, err
+	}
+
 	socket := "/tmp/nginx/prometheus-nginx.socket"
 	// unix sockets must be unlink()ed before being used
 	//nolint:errcheck // Ignore unlink error
@@ -122,10 +317,11 @@ func NewSocketCollector(pod, namespace, class string, metricsPerHost, metricsPer
 		"controller_pod":       pod,
 	}
 
-	requestTags := requestTags
+	requestTags := append([]string{}, requestTags...)
 	if metricsPerHost {
 		requestTags = append(requestTags, "host")
 	}
+	requestTags = append(requestTags, metricsLabels...)
 
 	em := make(map[string]struct{}, len(excludeMetrics))
 	for _, m := range excludeMetrics {
@@ -143,6 +339,9 @@ func NewSocketCollector(pod, namespace, class string, metricsPerHost, metricsPer
 		metricsPerHost:          metricsPerHost,
 		metricsPerUndefinedHost: metricsPerUndefinedHost,
 		reportStatusClasses:     reportStatusClasses,
+		metricsLabels:           metricsLabels,
+
+		stopCh: make(chan struct{}),
 
 		connectTime: histogramMetric(
 			&prometheus.HistogramOpts{
@@ -245,6 +444,201 @@ func NewSocketCollector(pod, namespace, class string, metricsPerHost, metricsPer
 			mm,
 		),
 
+		limitRejections: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "limit_rejections",
+				Help:        "The total number of client requests rejected by the connection and request limits",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			limitRejectionTags,
+			em,
+			mm,
+		),
+
+		canaryDecisions: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "canary_decisions",
+				Help:        "The total number of client requests to a backend with a canary, by routed backend and canary rule",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			canaryDecisionTags,
+			em,
+			mm,
+		),
+
+		backupFailovers: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "backup_failovers",
+				Help:        "The total number of client requests served by the backup service of an Ingress, by failover reason",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			backupFailoverTags,
+			em,
+			mm,
+		),
+
+		bodyInspectionRejections: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "body_inspection_rejections",
+				Help:        "The total number of client requests rejected by the inspection of their body, by exceeded limit",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			bodyInspectionRejectionTags,
+			em,
+			mm,
+		),
+
+		cacheCoalescedRequests: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "cache_coalesced_requests",
+				Help:        "The total number of client requests served from the cache entry filled by a concurrent request instead of the backend",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			cacheCoalescedTags,
+			em,
+			mm,
+		),
+
+		cspViolations: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "csp_violations",
+				Help:        "The total number of violations of the Content Security Policy reported by the browsers, by violated directive",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			cspViolationTags,
+			em,
+			mm,
+		),
+
+		upstreamConnectFailures: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "upstream_connect_failures",
+				Help:        "The total number of failed connections to the upstream endpoints, by IP family",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			upstreamConnectFailureTags,
+			em,
+			mm,
+		),
+
+		clientAborts: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "client_aborted_requests",
+				Help:        "The total number of client requests closed by the client before the response was sent (status code 499)",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			clientAbortTags,
+			em,
+			mm,
+		),
+
+		upstreamConnectTime: histogramMetric(
+			&prometheus.HistogramOpts{
+				Name:                           "upstream_connect_duration_seconds",
+				Help:                           "The time spent on establishing each connection with the upstream servers of a backend",
+				Namespace:                      PrometheusNamespace,
+				ConstLabels:                    constLabels,
+				Buckets:                        buckets.TimeBuckets,
+				NativeHistogramBucketFactor:    bucketFactor,
+				NativeHistogramMaxBucketNumber: maxBuckets,
+			},
+			upstreamTimeTags,
+			em,
+			mm,
+		),
+
+		upstreamHeaderTime: histogramMetric(
+			&prometheus.HistogramOpts{
+				Name:                           "upstream_header_duration_seconds",
+				Help:                           "The time spent on receiving the first header from each upstream server of a backend after connecting",
+				Namespace:                      PrometheusNamespace,
+				ConstLabels:                    constLabels,
+				Buckets:                        buckets.TimeBuckets,
+				NativeHistogramBucketFactor:    bucketFactor,
+				NativeHistogramMaxBucketNumber: maxBuckets,
+			},
+			upstreamTimeTags,
+			em,
+			mm,
+		),
+
+		endpointRequests: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "endpoint_requests",
+				Help:        "The total number of client requests served by each endpoint of a service",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			endpointTags,
+			em,
+			mm,
+		),
+
+		endpointErrors: counterMetric(
+			&prometheus.CounterOpts{
+				Name:        "endpoint_errors",
+				Help:        "The total number of client requests to which each endpoint of a service responded with a 5xx status code",
+				Namespace:   PrometheusNamespace,
+				ConstLabels: constLabels,
+			},
+			endpointTags,
+			em,
+			mm,
+		),
+
+		endpointResponseTime: histogramMetric(
+			&prometheus.HistogramOpts{
+				Name:                           "endpoint_response_duration_seconds",
+				Help:                           "The time spent on receiving the response from each endpoint of a service",
+				Namespace:                      PrometheusNamespace,
+				ConstLabels:                    constLabels,
+				Buckets:                        buckets.TimeBuckets,
+				NativeHistogramBucketFactor:    bucketFactor,
+				NativeHistogramMaxBucketNumber: maxBuckets,
+			},
+			endpointTags,
+			em,
+			mm,
+		),
+
+		endpointRequestLength: histogramMetric(
+			&prometheus.HistogramOpts{
+				Name:                           "endpoint_request_size",
+				Help:                           "The request length (including request line, header, and request body) of the requests served by each endpoint of a service",
+				Namespace:                      PrometheusNamespace,
+				ConstLabels:                    constLabels,
+				Buckets:                        buckets.LengthBuckets,
+				NativeHistogramBucketFactor:    bucketFactor,
+				NativeHistogramMaxBucketNumber: maxBuckets,
+			},
+			endpointTags,
+			em,
+			mm,
+		),
+
+		endpointResponseLength: histogramMetric(
+			&prometheus.HistogramOpts{
+				Name:                           "endpoint_response_size",
+				Help:                           "The response length (including request line, header, and request body) of the requests served by each endpoint of a service",
+				Namespace:                      PrometheusNamespace,
+				ConstLabels:                    constLabels,
+				Buckets:                        buckets.LengthBuckets,
+				NativeHistogramBucketFactor:    bucketFactor,
+				NativeHistogramMaxBucketNumber: maxBuckets,
+			},
+			endpointTags,
+			em,
+			mm,
+		),
+
 		bytesSent: histogramMetric(
 			&prometheus.HistogramOpts{
 				Name:        "bytes_sent",
@@ -296,6 +690,12 @@ func histogramMetric(opts *prometheus.HistogramOpts, requestTags []string, exclu
 }
 
 func (sc *SocketCollector) handleMessage(msg []byte) {
+	sc.handleBatch(msg, nil)
+}
+
+// handleBatch updates the metrics of a batch of requests, looking up the
+// series in the cache of a shard when there is one
+func (sc *SocketCollector) handleBatch(msg []byte, cache *seriesCache) {
 	klog.V(5).InfoS("Metric", "message", string(msg))
 
 	// Unmarshal bytes
@@ -306,6 +706,8 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 		return
 	}
 
+	cache.sync(sc.generation.Load())
+
 	for i := range statsBatch {
 		stats := &statsBatch[i]
 		if sc.metricsPerHost && !sc.hosts.Has(stats.Host) && !sc.metricsPerUndefinedHost {
@@ -313,6 +715,11 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			continue
 		}
 
+		// the status code is reduced to its class below
+		if sc.clientAborts != nil && stats.Status == clientAbortStatus {
+			sc.observeClientAbort(cache, stats)
+		}
+
 		if sc.reportStatusClasses && stats.Status != "" {
 			stats.Status = fmt.Sprintf("%cxx", stats.Status[0])
 		}
@@ -342,8 +749,19 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			collectorLabels["host"] = stats.Host
 		}
 
+		if len(sc.metricsLabels) > 0 {
+			ingressLabels := sc.getIngressLabels(stats.Namespace, stats.Ingress)
+			for _, label := range sc.metricsLabels {
+				requestLabels[label] = ingressLabels[label]
+				collectorLabels[label] = ingressLabels[label]
+			}
+		}
+
+		// the request and collector labels contain the same values
+		key := cache.key(requestLabels)
+
 		if sc.requests != nil {
-			requestsMetric, err := sc.requests.GetMetricWith(collectorLabels)
+			requestsMetric, err := cache.counter("requests", sc.requests, key, collectorLabels)
 			if err != nil {
 				klog.ErrorS(err, "Error fetching requests metric")
 			} else {
@@ -351,9 +769,46 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			}
 		}
 
+		if sc.limitRejections != nil {
+			sc.observeLimitRejection(cache, stats, "connections", stats.LimitConnStatus)
+			sc.observeLimitRejection(cache, stats, "requests", stats.LimitReqStatus)
+		}
+
+		if sc.canaryDecisions != nil {
+			sc.observeCanaryDecision(cache, stats)
+		}
+
+		if sc.backupFailovers != nil {
+			sc.observeBackupFailover(cache, stats)
+		}
+
+		if sc.bodyInspectionRejections != nil {
+			sc.observeBodyInspectionRejection(cache, stats)
+		}
+
+		if sc.cacheCoalescedRequests != nil && stats.CacheCoalesced {
+			sc.observeCacheCoalesced(cache, stats)
+		}
+
+		if sc.cspViolations != nil && stats.CSPViolations != "" && stats.CSPViolations != "-" {
+			sc.observeCSPViolations(cache, stats)
+		}
+
+		if sc.upstreamConnectFailures != nil && stats.UpstreamConnectFailures != "" && stats.UpstreamConnectFailures != "-" {
+			sc.observeUpstreamConnectFailures(cache, stats)
+		}
+
+		if stats.Upstream != "" && stats.Upstream != "-" {
+			sc.observeUpstreamTimes(cache, stats)
+		}
+
+		if sc.endpoints != nil && stats.Endpoint != "" && stats.Endpoint != "-" {
+			sc.observeEndpoint(cache, stats)
+		}
+
 		if stats.Latency != -1 {
 			if sc.connectTime != nil {
-				connectTimeMetric, err := sc.connectTime.GetMetricWith(requestLabels)
+				connectTimeMetric, err := cache.observer("connect_time", sc.connectTime, key, requestLabels)
 				if err != nil {
 					klog.ErrorS(err, "Error fetching connect time metric")
 				} else {
@@ -363,7 +818,7 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 		}
 
 		if stats.HeaderTime != -1 && sc.headerTime != nil {
-			headerTimeMetric, err := sc.headerTime.GetMetricWith(requestLabels)
+			headerTimeMetric, err := cache.observer("header_time", sc.headerTime, key, requestLabels)
 			if err != nil {
 				klog.ErrorS(err, "Error fetching header time metric")
 			} else {
@@ -372,7 +827,7 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 		}
 
 		if stats.RequestTime != -1 && sc.requestTime != nil {
-			requestTimeMetric, err := sc.requestTime.GetMetricWith(requestLabels)
+			requestTimeMetric, err := cache.observer("request_time", sc.requestTime, key, requestLabels)
 			if err != nil {
 				klog.ErrorS(err, "Error fetching request duration metric")
 			} else {
@@ -381,7 +836,7 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 		}
 
 		if stats.RequestLength != -1 && sc.requestLength != nil {
-			requestLengthMetric, err := sc.requestLength.GetMetricWith(requestLabels)
+			requestLengthMetric, err := cache.observer("request_length", sc.requestLength, key, requestLabels)
 			if err != nil {
 				klog.ErrorS(err, "Error fetching request length metric")
 			} else {
@@ -390,7 +845,7 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 		}
 
 		if stats.ResponseTime != -1 && sc.responseTime != nil {
-			responseTimeMetric, err := sc.responseTime.GetMetricWith(requestLabels)
+			responseTimeMetric, err := cache.observer("response_time", sc.responseTime, key, requestLabels)
 			if err != nil {
 				klog.ErrorS(err, "Error fetching upstream response time metric")
 			} else {
@@ -400,7 +855,7 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 
 		if stats.ResponseLength != -1 {
 			if sc.bytesSent != nil {
-				bytesSentMetric, err := sc.bytesSent.GetMetricWith(requestLabels)
+				bytesSentMetric, err := cache.observer("bytes_sent", sc.bytesSent, key, requestLabels)
 				if err != nil {
 					klog.ErrorS(err, "Error fetching bytes sent metric")
 				} else {
@@ -409,7 +864,7 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 			}
 
 			if sc.responseLength != nil {
-				responseSizeMetric, err := sc.responseLength.GetMetricWith(requestLabels)
+				responseSizeMetric, err := cache.observer("response_length", sc.responseLength, key, requestLabels)
 				if err != nil {
 					klog.ErrorS(err, "Error fetching bytes sent metric")
 				} else {
@@ -420,21 +875,240 @@ func (sc *SocketCollector) handleMessage(msg []byte) {
 	}
 }
 
+// observeLimitRejection counts the requests rejected by a limit of an Ingress
+func (sc *SocketCollector) observeLimitRejection(cache *seriesCache, stats *socketData, limit, status string) {
+	if status != limitRejectedStatus {
+		return
+	}
+
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"limit":     limit,
+	}
+	limitRejectionsMetric, err := cache.counter("limit_rejections", sc.limitRejections, cache.key(labels), labels)
+	if err != nil {
+		klog.ErrorS(err, "Error fetching limit rejections metric")
+		return
+	}
+
+	limitRejectionsMetric.Inc()
+}
+
+// observeCanaryDecision counts the requests routed to the canary or to the
+// stable backend of an Ingress by the rule that routed them
+func (sc *SocketCollector) observeCanaryDecision(cache *seriesCache, stats *socketData) {
+	if !canaryDecisionReasons.Has(stats.CanaryDecision) {
+		return
+	}
+
+	backend := "stable"
+	if stats.Canary != "" && stats.Canary != "-" {
+		backend = "canary"
+	}
+
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"backend":   backend,
+		"reason":    stats.CanaryDecision,
+	}
+	canaryDecisionsMetric, err := cache.counter("canary_decisions", sc.canaryDecisions, cache.key(labels), labels)
+	if err != nil {
+		klog.ErrorS(err, "Error fetching canary decisions metric")
+		return
+	}
+
+	canaryDecisionsMetric.Inc()
+}
+
+// observeBackupFailover counts the requests served by the backup service of
+// an Ingress by the reason of the failover
+func (sc *SocketCollector) observeBackupFailover(cache *seriesCache, stats *socketData) {
+	if !backupFailoverReasons.Has(stats.BackupFailover) {
+		return
+	}
+
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"reason":    stats.BackupFailover,
+	}
+	backupFailoversMetric, err := cache.counter("backup_failovers", sc.backupFailovers, cache.key(labels), labels)
+	if err != nil {
+		klog.ErrorS(err, "Error fetching backup failovers metric")
+		return
+	}
+
+	backupFailoversMetric.Inc()
+}
+
+// observeBodyInspectionRejection counts the requests rejected by the
+// inspection of their body by exceeded limit
+func (sc *SocketCollector) observeBodyInspectionRejection(cache *seriesCache, stats *socketData) {
+	if !bodyInspectionReasons.Has(stats.BodyInspectionRejection) {
+		return
+	}
+
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"reason":    stats.BodyInspectionRejection,
+	}
+	bodyInspectionRejectionsMetric, err := cache.counter("body_inspection_rejections", sc.bodyInspectionRejections, cache.key(labels), labels)
+	if err != nil {
+		klog.ErrorS(err, "Error fetching body inspection rejections metric")
+		return
+	}
+
+	bodyInspectionRejectionsMetric.Inc()
+}
+
+// observeCacheCoalesced counts the requests that waited for a concurrent
+// request to fill their cache entry
+func (sc *SocketCollector) observeCacheCoalesced(cache *seriesCache, stats *socketData) {
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+	}
+	cacheCoalescedMetric, err := cache.counter("cache_coalesced_requests", sc.cacheCoalescedRequests, cache.key(labels), labels)
+	if err != nil {
+		klog.ErrorS(err, "Error fetching cache coalesced requests metric")
+		return
+	}
+
+	cacheCoalescedMetric.Inc()
+}
+
+// observeCSPViolations counts the violations of a Content Security Policy
+// reported by a request by violated directive
+func (sc *SocketCollector) observeCSPViolations(cache *seriesCache, stats *socketData) {
+	for _, directive := range strings.Fields(stats.CSPViolations) {
+		if !cspViolationDirectives.Has(directive) {
+			continue
+		}
+
+		labels := prometheus.Labels{
+			"namespace": stats.Namespace,
+			"ingress":   stats.Ingress,
+			"service":   stats.Service,
+			"directive": directive,
+		}
+		cspViolationsMetric, err := cache.counter("csp_violations", sc.cspViolations, cache.key(labels), labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching CSP violations metric")
+			return
+		}
+
+		cspViolationsMetric.Inc()
+	}
+}
+
+// observeUpstreamConnectFailures counts the failed connections of a request
+// to the upstream endpoints by IP family
+func (sc *SocketCollector) observeUpstreamConnectFailures(cache *seriesCache, stats *socketData) {
+	for _, family := range strings.Fields(stats.UpstreamConnectFailures) {
+		if !ipFamilies.Has(family) {
+			continue
+		}
+
+		labels := prometheus.Labels{
+			"namespace": stats.Namespace,
+			"ingress":   stats.Ingress,
+			"service":   stats.Service,
+			"family":    family,
+		}
+		upstreamConnectFailuresMetric, err := cache.counter("upstream_connect_failures", sc.upstreamConnectFailures, cache.key(labels), labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching upstream connect failures metric")
+			return
+		}
+
+		upstreamConnectFailuresMetric.Inc()
+	}
+}
+
+// observeClientAbort counts the requests closed by the client
+func (sc *SocketCollector) observeClientAbort(cache *seriesCache, stats *socketData) {
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+	}
+	clientAbortsMetric, err := cache.counter("client_aborted_requests", sc.clientAborts, cache.key(labels), labels)
+	if err != nil {
+		klog.ErrorS(err, "Error fetching client aborted requests metric")
+		return
+	}
+
+	clientAbortsMetric.Inc()
+}
+
+// observeUpstreamTimes observes the connect and header times of each try of
+// a request by backend
+func (sc *SocketCollector) observeUpstreamTimes(cache *seriesCache, stats *socketData) {
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"backend":   stats.Upstream,
+	}
+	key := cache.key(labels)
+
+	if sc.upstreamConnectTime != nil && len(stats.UpstreamConnectTimes) > 0 {
+		upstreamConnectTimeMetric, err := cache.observer("upstream_connect_time", sc.upstreamConnectTime, key, labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching upstream connect time metric")
+		} else {
+			for _, t := range stats.UpstreamConnectTimes {
+				upstreamConnectTimeMetric.Observe(t)
+			}
+		}
+	}
+
+	if sc.upstreamHeaderTime != nil && len(stats.UpstreamHeaderTimes) > 0 {
+		upstreamHeaderTimeMetric, err := cache.observer("upstream_header_time", sc.upstreamHeaderTime, key, labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching upstream header time metric")
+		} else {
+			for _, t := range stats.UpstreamHeaderTimes {
+				upstreamHeaderTimeMetric.Observe(t)
+			}
+		}
+	}
+}
+
 // Start listen for connections in the unix socket and spawns a goroutine to process the content
 func (sc *SocketCollector) Start() {
+	handle := sc.handleMessage
+	if len(sc.shards) > 0 {
+		for _, shard := range sc.shards {
+			go sc.runShard(shard)
+		}
+		handle = sc.dispatch
+	}
+
 	for {
 		conn, err := sc.listener.Accept()
 		if err != nil {
 			continue
 		}
 
-		go handleMessages(conn, sc.handleMessage)
+		go handleMessages(conn, handle)
 	}
 }
 
 // Stop stops unix listener
 func (sc *SocketCollector) Stop() {
 	sc.listener.Close()
+	sc.stopOnce.Do(func() {
+		close(sc.stopCh)
+	})
 }
 
 // RemoveMetrics deletes prometheus metrics from prometheus for ingresses and
@@ -505,6 +1179,13 @@ func (sc *SocketCollector) RemoveMetrics(ingresses []string, registry prometheus
 			}
 		}
 	}
+
+	if sc.endpoints != nil {
+		sc.endpoints.forgetIngresses(ingresses)
+	}
+
+	// the shards no longer use the deleted series
+	sc.generation.Add(1)
 }
 
 // Describe implements prometheus.Collector
@@ -527,6 +1208,24 @@ func (sc *SocketCollector) SetHosts(hosts sets.Set[string]) {
 	sc.hosts = hosts
 }
 
+// SetIngressLabels sets the labels defined in the Ingresses using the annotation
+// metrics-labels, indexed by namespace/name. Only the labels defined using the
+// flag --metrics-labels are added to the metrics.
+func (sc *SocketCollector) SetIngressLabels(labels map[string]map[string]string) {
+	sc.ingressLabelsMu.Lock()
+	defer sc.ingressLabelsMu.Unlock()
+
+	sc.ingressLabels = labels
+}
+
+// getIngressLabels returns the labels defined in an Ingress
+func (sc *SocketCollector) getIngressLabels(namespace, name string) map[string]string {
+	sc.ingressLabelsMu.RLock()
+	defer sc.ingressLabelsMu.RUnlock()
+
+	return sc.ingressLabels[fmt.Sprintf("%v/%v", namespace, name)]
+}
+
 // handleMessages process the content received in a network connection
 func handleMessages(conn io.ReadCloser, fn func([]byte)) {
 	defer conn.Close()
@@ -0,0 +1,254 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+// endpointTags are the labels of the metrics of the requests by endpoint, the
+// endpoint being a hash of the name of the pod that served the request
+var endpointTags = []string{
+	"namespace",
+	"ingress",
+	"service",
+	"endpoint",
+}
+
+// endpointOverflow is the endpoint of the requests counted once the maximum
+// number of series by endpoint is reached
+const endpointOverflow = "other"
+
+// endpointSeries contains the pods of the endpoints of the backends and the
+// series of the metrics by endpoint, whose number is limited
+type endpointSeries struct {
+	mu sync.Mutex
+
+	// pods contains the endpoint labels of the pods indexed by address
+	pods map[string]string
+	// series contains the series by namespace/ingress/service/endpoint
+	series    sets.Set[string]
+	maxSeries int
+	overflow  bool
+}
+
+// EnableEndpointMetrics makes the collector export the metrics of the requests
+// by endpoint, up to a maximum number of series. The requests to the other
+// endpoints are counted with the endpoint "other". Must be called before Start.
+func (sc *SocketCollector) EnableEndpointMetrics(maxSeries int) {
+	sc.endpoints = &endpointSeries{
+		pods:      map[string]string{},
+		series:    sets.New[string](),
+		maxSeries: maxSeries,
+	}
+}
+
+// endpointLabel returns the endpoint of a pod, a hash of its name keeping the
+// label short and stable
+func endpointLabel(namespace, name string) string {
+	h := fnv.New32a()
+	h.Write([]byte(namespace + "/" + name)) //nolint:errcheck // Writing to a hash never fails
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// SetEndpoints sets the pods of the endpoints of the backends, deleting the
+// series of the endpoints that no longer exist
+func (sc *SocketCollector) SetEndpoints(backends []*ingress.Backend) {
+	if sc.endpoints == nil {
+		return
+	}
+
+	pods := make(map[string]string)
+	for _, backend := range backends {
+		for i := range backend.Endpoints {
+			ep := &backend.Endpoints[i]
+			if ep.Target == nil || ep.Target.Kind != "Pod" {
+				continue
+			}
+
+			pods[net.JoinHostPort(ep.Address, ep.Port)] = endpointLabel(ep.Target.Namespace, ep.Target.Name)
+		}
+	}
+
+	removed := sc.endpoints.setPods(pods)
+	if removed.Len() == 0 {
+		return
+	}
+
+	for _, endpoint := range removed.UnsortedList() {
+		labels := prometheus.Labels{"endpoint": endpoint}
+		for _, vec := range []*prometheus.CounterVec{sc.endpointRequests, sc.endpointErrors} {
+			if vec != nil {
+				vec.DeletePartialMatch(labels)
+			}
+		}
+		for _, vec := range []*prometheus.HistogramVec{sc.endpointResponseTime, sc.endpointRequestLength, sc.endpointResponseLength} {
+			if vec != nil {
+				vec.DeletePartialMatch(labels)
+			}
+		}
+	}
+
+	// the shards no longer use the deleted series
+	sc.generation.Add(1)
+}
+
+// setPods sets the pods of the endpoints, returning the endpoints removed
+func (es *endpointSeries) setPods(pods map[string]string) sets.Set[string] {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	current := sets.New[string]()
+	for _, endpoint := range pods {
+		current.Insert(endpoint)
+	}
+
+	removed := sets.New[string]()
+	for _, endpoint := range es.pods {
+		if !current.Has(endpoint) {
+			removed.Insert(endpoint)
+		}
+	}
+
+	es.pods = pods
+	es.forget(func(key string) bool {
+		return removed.Has(key[strings.LastIndex(key, "/")+1:])
+	})
+
+	return removed
+}
+
+// forgetIngresses forgets the series of the removed Ingresses, indexed by namespace/name
+func (es *endpointSeries) forgetIngresses(ingresses []string) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	removed := sets.New[string](ingresses...)
+	es.forget(func(key string) bool {
+		parts := strings.SplitN(key, "/", 3)
+		return removed.Has(parts[0] + "/" + parts[1])
+	})
+}
+
+// forget forgets the series matching a function, the lock being held
+func (es *endpointSeries) forget(matches func(string) bool) {
+	for _, key := range es.series.UnsortedList() {
+		if matches(key) {
+			es.series.Delete(key)
+			es.overflow = false
+		}
+	}
+}
+
+// endpoint returns the endpoint of the request to an address, or false when
+// the address is not the one of a pod
+func (es *endpointSeries) endpoint(namespace, ingressName, service, address string) (string, bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	endpoint, ok := es.pods[address]
+	if !ok {
+		return "", false
+	}
+
+	key := strings.Join([]string{namespace, ingressName, service, endpoint}, "/")
+	if es.series.Has(key) {
+		return endpoint, true
+	}
+
+	if es.series.Len() >= es.maxSeries {
+		if !es.overflow {
+			klog.Warningf("Maximum number of series of the metrics by endpoint reached (%v), the requests to the new endpoints are counted with the endpoint %q", es.maxSeries, endpointOverflow)
+			es.overflow = true
+		}
+		return endpointOverflow, true
+	}
+
+	es.series.Insert(key)
+	return endpoint, true
+}
+
+// observeEndpoint updates the metrics of a request by the endpoint that served it
+func (sc *SocketCollector) observeEndpoint(cache *seriesCache, stats *socketData) {
+	endpoint, ok := sc.endpoints.endpoint(stats.Namespace, stats.Ingress, stats.Service, stats.Endpoint)
+	if !ok {
+		return
+	}
+
+	labels := prometheus.Labels{
+		"namespace": stats.Namespace,
+		"ingress":   stats.Ingress,
+		"service":   stats.Service,
+		"endpoint":  endpoint,
+	}
+	key := cache.key(labels)
+
+	if sc.endpointRequests != nil {
+		endpointRequestsMetric, err := cache.counter("endpoint_requests", sc.endpointRequests, key, labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching endpoint requests metric")
+		} else {
+			endpointRequestsMetric.Inc()
+		}
+	}
+
+	if sc.endpointErrors != nil && strings.HasPrefix(stats.EndpointStatus, "5") {
+		endpointErrorsMetric, err := cache.counter("endpoint_errors", sc.endpointErrors, key, labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching endpoint errors metric")
+		} else {
+			endpointErrorsMetric.Inc()
+		}
+	}
+
+	if sc.endpointResponseTime != nil && stats.EndpointResponseTime != -1 {
+		endpointResponseTimeMetric, err := cache.observer("endpoint_response_time", sc.endpointResponseTime, key, labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching endpoint response time metric")
+		} else {
+			endpointResponseTimeMetric.Observe(stats.EndpointResponseTime)
+		}
+	}
+
+	if sc.endpointRequestLength != nil && stats.RequestLength != -1 {
+		endpointRequestLengthMetric, err := cache.observer("endpoint_request_length", sc.endpointRequestLength, key, labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching endpoint request length metric")
+		} else {
+			endpointRequestLengthMetric.Observe(stats.RequestLength)
+		}
+	}
+
+	if sc.endpointResponseLength != nil && stats.ResponseLength != -1 {
+		endpointResponseLengthMetric, err := cache.observer("endpoint_response_length", sc.endpointResponseLength, key, labels)
+		if err != nil {
+			klog.ErrorS(err, "Error fetching endpoint response length metric")
+		} else {
+			endpointResponseLengthMetric.Observe(stats.ResponseLength)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	apiv1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"k8s.io/ingress-nginx/pkg/apis/ingress"
+)
+
+func TestEndpointSeries(t *testing.T) {
+	es := &endpointSeries{series: sets.New[string](), maxSeries: 2}
+	es.setPods(map[string]string{
+		"10.0.0.1:8080": "a",
+		"10.0.0.2:8080": "b",
+		"10.0.0.3:8080": "c",
+	})
+
+	if _, ok := es.endpoint("ns", "ing", "svc", "10.0.0.9:8080"); ok {
+		t.Errorf("expected no endpoint for an address that is not the one of a pod")
+	}
+
+	for address, expected := range map[string]string{"10.0.0.1:8080": "a", "10.0.0.2:8080": "b", "10.0.0.3:8080": endpointOverflow} {
+		if endpoint, _ := es.endpoint("ns", "ing", "svc", address); endpoint != expected {
+			t.Errorf("expected the endpoint %q of %v but returned %q", expected, address, endpoint)
+		}
+		// the maximum number of series is reached by the first two endpoints
+		if expected == "b" {
+			if endpoint, _ := es.endpoint("ns", "ing", "svc", "10.0.0.1:8080"); endpoint != "a" {
+				t.Errorf("expected the endpoint of a known series but returned %q", endpoint)
+			}
+		}
+	}
+
+	removed := es.setPods(map[string]string{"10.0.0.2:8080": "b", "10.0.0.3:8080": "c"})
+	if !removed.Equal(sets.New[string]("a")) {
+		t.Errorf("expected the endpoint a to be removed but returned %v", removed.UnsortedList())
+	}
+	if endpoint, _ := es.endpoint("ns", "ing", "svc", "10.0.0.3:8080"); endpoint != "c" {
+		t.Errorf("expected the endpoint c once a series is removed but returned %q", endpoint)
+	}
+
+	es.forgetIngresses([]string{"ns/ing"})
+	if es.series.Len() != 0 {
+		t.Errorf("expected no series after the Ingress was removed but returned %v", es.series.UnsortedList())
+	}
+}
+
+func TestSetEndpoints(t *testing.T) {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, endpointTags)
+	sc := &SocketCollector{endpointRequests: vec}
+	sc.EnableEndpointMetrics(10)
+
+	pod := func(name string) *apiv1.ObjectReference {
+		return &apiv1.ObjectReference{Kind: "Pod", Namespace: "ns", Name: name}
+	}
+	backends := []*ingress.Backend{{
+		Endpoints: []ingress.Endpoint{
+			{Address: "10.0.0.1", Port: "8080", Target: pod("web-1")},
+			{Address: "fd00::2", Port: "8080", Target: pod("web-2")},
+			{Address: "10.0.0.3", Port: "8080"},
+		},
+	}}
+	sc.SetEndpoints(backends)
+
+	for _, address := range []string{"10.0.0.1:8080", "[fd00::2]:8080", "10.0.0.3:8080"} {
+		sc.observeEndpoint(nil, &socketData{Namespace: "ns", Ingress: "ing", Service: "svc", Endpoint: address, EndpointResponseTime: -1})
+	}
+	if count := testutil.CollectAndCount(vec); count != 2 {
+		t.Errorf("expected 2 series but returned %v", count)
+	}
+	if value := testutil.ToFloat64(vec.WithLabelValues("ns", "ing", "svc", endpointLabel("ns", "web-2"))); value != 1 {
+		t.Errorf("expected 1 request to the IPv6 endpoint but returned %v", value)
+	}
+
+	backends[0].Endpoints = backends[0].Endpoints[1:]
+	generation := sc.generation.Load()
+	sc.SetEndpoints(backends)
+	if count := testutil.CollectAndCount(vec); count != 1 {
+		t.Errorf("expected the series of the removed endpoint to be deleted but returned %v series", count)
+	}
+	if sc.generation.Load() == generation {
+		t.Errorf("expected the generation to change once series are deleted")
+	}
+}
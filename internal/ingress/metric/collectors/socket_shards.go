@@ -0,0 +1,146 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// seriesCache caches the series of the metric vectors by name and label
+// values. A cache is used by a single shard, so that the series are looked up
+// without locking the metric vectors. A nil cache looks up the series in the
+// metric vectors.
+type seriesCache struct {
+	generation uint64
+	observers  map[string]prometheus.Observer
+	counters   map[string]prometheus.Counter
+}
+
+func newSeriesCache() *seriesCache {
+	return &seriesCache{
+		observers: map[string]prometheus.Observer{},
+		counters:  map[string]prometheus.Counter{},
+	}
+}
+
+// sync empties the cache when series were deleted from the metric vectors
+func (c *seriesCache) sync(generation uint64) {
+	if c == nil || c.generation == generation {
+		return
+	}
+
+	c.generation = generation
+	c.observers = map[string]prometheus.Observer{}
+	c.counters = map[string]prometheus.Counter{}
+}
+
+// key returns the key of the label values in the cache
+func (c *seriesCache) key(labels prometheus.Labels) string {
+	if c == nil {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var key strings.Builder
+	for _, name := range names {
+		key.WriteString(name)
+		key.WriteByte('=')
+		key.WriteString(labels[name])
+		key.WriteByte(0xff)
+	}
+
+	return key.String()
+}
+
+func (c *seriesCache) observer(name string, vec *prometheus.HistogramVec, key string, labels prometheus.Labels) (prometheus.Observer, error) {
+	if c == nil {
+		return vec.GetMetricWith(labels)
+	}
+
+	key = name + "\xff" + key
+	if observer, ok := c.observers[key]; ok {
+		return observer, nil
+	}
+
+	observer, err := vec.GetMetricWith(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	c.observers[key] = observer
+	return observer, nil
+}
+
+func (c *seriesCache) counter(name string, vec *prometheus.CounterVec, key string, labels prometheus.Labels) (prometheus.Counter, error) {
+	if c == nil {
+		return vec.GetMetricWith(labels)
+	}
+
+	key = name + "\xff" + key
+	if counter, ok := c.counters[key]; ok {
+		return counter, nil
+	}
+
+	counter, err := vec.GetMetricWith(labels)
+	if err != nil {
+		return nil, err
+	}
+
+	c.counters[key] = counter
+	return counter, nil
+}
+
+// EnableShards makes the collector process the batches of requests in a
+// number of shards, each one caching the series it updates. The series are
+// then updated without contention on the locks of the metric vectors under
+// high request rates. Must be called before Start.
+func (sc *SocketCollector) EnableShards(shards int) {
+	sc.shards = make([]chan []byte, shards)
+	for i := range sc.shards {
+		sc.shards[i] = make(chan []byte, 1)
+	}
+}
+
+// dispatch sends a batch of requests to the next shard
+func (sc *SocketCollector) dispatch(msg []byte) {
+	shard := sc.shards[sc.nextShard.Add(1)%uint64(len(sc.shards))]
+	select {
+	case shard <- msg:
+	case <-sc.stopCh:
+	}
+}
+
+// runShard processes the batches of requests of a shard until the collector stops
+func (sc *SocketCollector) runShard(batches <-chan []byte) {
+	cache := newSeriesCache()
+	for {
+		select {
+		case msg := <-batches:
+			sc.handleBatch(msg, cache)
+		case <-sc.stopCh:
+			return
+		}
+	}
+}
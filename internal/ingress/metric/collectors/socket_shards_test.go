@@ -0,0 +1,66 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package collectors
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSeriesCache(t *testing.T) {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: "test_total"}, []string{"a", "b"})
+	labels := prometheus.Labels{"a": "1", "b": "2"}
+
+	cache := newSeriesCache()
+	key := cache.key(labels)
+	if other := cache.key(prometheus.Labels{"b": "2", "a": "1"}); key != other {
+		t.Errorf("expected the same key for the same labels but returned %q and %q", key, other)
+	}
+	if other := cache.key(prometheus.Labels{"a": "12", "b": ""}); key == other {
+		t.Errorf("expected different keys for different labels but returned %q", key)
+	}
+
+	counter, err := cache.counter("test", vec, key, labels)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	counter.Inc()
+
+	vec.Delete(labels)
+	if cached, _ := cache.counter("test", vec, key, labels); cached != counter {
+		t.Errorf("expected the cached series")
+	}
+
+	cache.sync(1)
+	recreated, err := cache.counter("test", vec, key, labels)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if recreated == counter {
+		t.Errorf("expected a new series after the generation changed")
+	}
+
+	var nilCache *seriesCache
+	nilCache.sync(2)
+	if key := nilCache.key(labels); key != "" {
+		t.Errorf("expected an empty key without a cache but returned %q", key)
+	}
+	if _, err := nilCache.counter("test", vec, "", labels); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
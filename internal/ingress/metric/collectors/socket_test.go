@@ -93,6 +93,8 @@ func TestCollector(t *testing.T) {
 		metricsPerUndefinedHost bool
 		useStatusClasses        bool
 		excludeMetrics          []string
+		metricsLabels           []string
+		ingressLabels           map[string]map[string]string
 		wantBefore              string
 		removeIngresses         []string
 		wantAfter               string
@@ -648,13 +650,673 @@ func TestCollector(t *testing.T) {
 			metrics:          []string{"nginx_ingress_controller_requests"},
 			useStatusClasses: true,
 		},
+		{
+			name: "labels defined in the ingress should be added to the metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":""
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"other",
+				"service":"test-app",
+				"canary":""
+			}]`},
+			metrics:       []string{"nginx_ingress_controller_requests"},
+			metricsLabels: []string{"team", "tier"},
+			ingressLabels: map[string]map[string]string{
+				"test-app-production/web-yml": {"team": "payments", "unknown": "value"},
+			},
+			wantBefore: `
+				# HELP nginx_ingress_controller_requests The total number of client requests
+				# TYPE nginx_ingress_controller_requests counter
+				nginx_ingress_controller_requests{canary="",controller_class="ingress",controller_namespace="default",controller_pod="pod",host="testshop.com",ingress="other",method="GET",namespace="test-app-production",path="/",service="test-app",status="200",team="",tier=""} 1
+				nginx_ingress_controller_requests{canary="",controller_class="ingress",controller_namespace="default",controller_pod="pod",host="testshop.com",ingress="web-yml",method="GET",namespace="test-app-production",path="/admin",service="test-app",status="200",team="payments",tier=""} 1
+			`,
+		},
+		{
+			name: "requests rejected by a limit should update limit rejections metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"503",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"limitConnStatus":"REJECTED",
+				"limitReqStatus":"-"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"limitConnStatus":"PASSED",
+				"limitReqStatus":"REJECTED_DRY_RUN"
+			}]`},
+			metrics: []string{"nginx_ingress_controller_limit_rejections"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_limit_rejections The total number of client requests rejected by the connection and request limits
+				# TYPE nginx_ingress_controller_limit_rejections counter
+				nginx_ingress_controller_limit_rejections{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",limit="connections",namespace="test-app-production",service="test-app"} 1
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "requests to a backend with a canary should update canary decisions metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"test-app-production-test-app-canary-80",
+				"canaryDecision":"weight"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"-",
+				"canaryDecision":"weight"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"-",
+				"canaryDecision":"weight"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"test-app-production-test-app-canary-80",
+				"canaryDecision":"header"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"-",
+				"canaryDecision":"-"
+			}]`},
+			metrics: []string{"nginx_ingress_controller_canary_decisions"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_canary_decisions The total number of client requests to a backend with a canary, by routed backend and canary rule
+				# TYPE nginx_ingress_controller_canary_decisions counter
+				nginx_ingress_controller_canary_decisions{backend="canary",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",reason="header",service="test-app"} 1
+				nginx_ingress_controller_canary_decisions{backend="canary",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",reason="weight",service="test-app"} 1
+				nginx_ingress_controller_canary_decisions{backend="stable",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",reason="weight",service="test-app"} 2
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "requests served by a backup service should update backup failovers metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"backupFailover":"endpoints"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"backupFailover":"status"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"backupFailover":"status"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"backupFailover":"-"
+			}]`},
+			metrics: []string{"nginx_ingress_controller_backup_failovers"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_backup_failovers The total number of client requests served by the backup service of an Ingress, by failover reason
+				# TYPE nginx_ingress_controller_backup_failovers counter
+				nginx_ingress_controller_backup_failovers{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",reason="endpoints",service="test-app"} 1
+				nginx_ingress_controller_backup_failovers{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",reason="status",service="test-app"} 2
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "requests rejected by the body inspection should update body inspection rejections metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"400",
+				"method":"POST",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"bodyInspectionRejection":"xml_entity_expansions"
+			}, {
+				"host":"testshop.com",
+				"status":"400",
+				"method":"POST",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"bodyInspectionRejection":"json_depth"
+			}, {
+				"host":"testshop.com",
+				"status":"400",
+				"method":"POST",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"bodyInspectionRejection":"json_depth"
+			}, {
+				"host":"testshop.com",
+				"status":"400",
+				"method":"POST",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"bodyInspectionRejection":"-"
+			}]`},
+			metrics: []string{"nginx_ingress_controller_body_inspection_rejections"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_body_inspection_rejections The total number of client requests rejected by the inspection of their body, by exceeded limit
+				# TYPE nginx_ingress_controller_body_inspection_rejections counter
+				nginx_ingress_controller_body_inspection_rejections{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",reason="json_depth",service="test-app"} 2
+				nginx_ingress_controller_body_inspection_rejections{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",reason="xml_entity_expansions",service="test-app"} 1
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "requests served from a cache entry filled by a concurrent request should update cache coalesced requests metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"cacheCoalesced":true
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"cacheCoalesced":true
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"cacheCoalesced":false
+			}]`},
+			metrics: []string{"nginx_ingress_controller_cache_coalesced_requests"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_cache_coalesced_requests The total number of client requests served from the cache entry filled by a concurrent request instead of the backend
+				# TYPE nginx_ingress_controller_cache_coalesced_requests counter
+				nginx_ingress_controller_cache_coalesced_requests{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app"} 2
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "violation reports of a Content Security Policy should update CSP violations metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"204",
+				"method":"POST",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"cspViolations":"script-src-elem img-src script-src-elem"
+			}, {
+				"host":"testshop.com",
+				"status":"204",
+				"method":"POST",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"cspViolations":"other unknown-src"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"cspViolations":"-"
+			}]`},
+			metrics: []string{"nginx_ingress_controller_csp_violations"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_csp_violations The total number of violations of the Content Security Policy reported by the browsers, by violated directive
+				# TYPE nginx_ingress_controller_csp_violations counter
+				nginx_ingress_controller_csp_violations{controller_class="ingress",controller_namespace="default",controller_pod="pod",directive="img-src",ingress="web-yml",namespace="test-app-production",service="test-app"} 1
+				nginx_ingress_controller_csp_violations{controller_class="ingress",controller_namespace="default",controller_pod="pod",directive="other",ingress="web-yml",namespace="test-app-production",service="test-app"} 1
+				nginx_ingress_controller_csp_violations{controller_class="ingress",controller_namespace="default",controller_pod="pod",directive="script-src-elem",ingress="web-yml",namespace="test-app-production",service="test-app"} 2
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "failed connections to upstream endpoints should update upstream connect failures metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"upstreamConnectFailures":"ipv6 ipv6 ipv4"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"upstreamConnectFailures":"unix"
+			}, {
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"upstreamConnectFailures":""
+			}]`},
+			metrics: []string{"nginx_ingress_controller_upstream_connect_failures"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_upstream_connect_failures The total number of failed connections to the upstream endpoints, by IP family
+				# TYPE nginx_ingress_controller_upstream_connect_failures counter
+				nginx_ingress_controller_upstream_connect_failures{controller_class="ingress",controller_namespace="default",controller_pod="pod",family="ipv4",ingress="web-yml",namespace="test-app-production",service="test-app"} 1
+				nginx_ingress_controller_upstream_connect_failures{controller_class="ingress",controller_namespace="default",controller_pod="pod",family="ipv6",ingress="web-yml",namespace="test-app-production",service="test-app"} 2
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "requests closed by the client should update client aborted requests metrics",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"499",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":""
+			}, {
+				"host":"testshop.com",
+				"status":"404",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":""
+			}, {
+				"host":"testshop.com",
+				"status":"499",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":""
+			}]`},
+			metrics:          []string{"nginx_ingress_controller_client_aborted_requests"},
+			useStatusClasses: true,
+			wantBefore: `
+				# HELP nginx_ingress_controller_client_aborted_requests The total number of client requests closed by the client before the response was sent (status code 499)
+				# TYPE nginx_ingress_controller_client_aborted_requests counter
+				nginx_ingress_controller_client_aborted_requests{controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app"} 2
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
+		{
+			name: "upstream connect and header times should update the per backend histograms",
+			data: []string{`[{
+				"host":"testshop.com",
+				"status":"200",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"upstream":"test-app-production-test-app-80",
+				"upstreamConnectTimes":[3, 0.004],
+				"upstreamHeaderTimes":[0.2]
+			}, {
+				"host":"testshop.com",
+				"status":"502",
+				"method":"GET",
+				"path":"/admin",
+				"requestLength":-1,
+				"requestTime":-1,
+				"upstreamLatency":-1,
+				"upstreamHeaderTime":-1,
+				"upstreamResponseTime":-1,
+				"responseLength":-1,
+				"namespace":"test-app-production",
+				"ingress":"web-yml",
+				"service":"test-app",
+				"canary":"",
+				"upstream":"test-app-production-test-app-80"
+			}]`},
+			metrics: []string{"nginx_ingress_controller_upstream_connect_duration_seconds", "nginx_ingress_controller_upstream_header_duration_seconds"},
+			wantBefore: `
+				# HELP nginx_ingress_controller_upstream_connect_duration_seconds The time spent on establishing each connection with the upstream servers of a backend
+				# TYPE nginx_ingress_controller_upstream_connect_duration_seconds histogram
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.005"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.01"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.025"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.05"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.1"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.25"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.5"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="1"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="2.5"} 1
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="5"} 2
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="10"} 2
+				nginx_ingress_controller_upstream_connect_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="+Inf"} 2
+				nginx_ingress_controller_upstream_connect_duration_seconds_sum{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app"} 3.004
+				nginx_ingress_controller_upstream_connect_duration_seconds_count{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app"} 2
+				# HELP nginx_ingress_controller_upstream_header_duration_seconds The time spent on receiving the first header from each upstream server of a backend after connecting
+				# TYPE nginx_ingress_controller_upstream_header_duration_seconds histogram
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.005"} 0
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.01"} 0
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.025"} 0
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.05"} 0
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.1"} 0
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.25"} 1
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="0.5"} 1
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="1"} 1
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="2.5"} 1
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="5"} 1
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="10"} 1
+				nginx_ingress_controller_upstream_header_duration_seconds_bucket{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app",le="+Inf"} 1
+				nginx_ingress_controller_upstream_header_duration_seconds_sum{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app"} 0.2
+				nginx_ingress_controller_upstream_header_duration_seconds_count{backend="test-app-production-test-app-80",controller_class="ingress",controller_namespace="default",controller_pod="pod",ingress="web-yml",namespace="test-app-production",service="test-app"} 1
+			`,
+			removeIngresses: []string{"test-app-production/web-yml"},
+			wantAfter: `
+			`,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.name, func(t *testing.T) {
 			registry := prometheus.NewPedanticRegistry()
 
-			sc, err := NewSocketCollector("pod", "default", "ingress", true, c.metricsPerUndefinedHost, c.useStatusClasses, buckets, bucketFactor, maxBuckets, c.excludeMetrics)
+			sc, err := NewSocketCollector("pod", "default", "ingress", true, c.metricsPerUndefinedHost, c.useStatusClasses, buckets, bucketFactor, maxBuckets, c.excludeMetrics, c.metricsLabels)
 			if err != nil {
 				t.Errorf("%v: unexpected error creating new SocketCollector: %v", c.name, err)
 			}
@@ -664,6 +1326,7 @@ func TestCollector(t *testing.T) {
 			}
 
 			sc.SetHosts(sets.New[string]("testshop.com"))
+			sc.SetIngressLabels(c.ingressLabels)
 
 			for _, d := range c.data {
 				sc.handleMessage([]byte(d))
@@ -688,3 +1351,26 @@ func TestCollector(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateMetricsLabels(t *testing.T) {
+	testCases := []struct {
+		labels    []string
+		expectErr bool
+	}{
+		{nil, false},
+		{[]string{"team", "tier"}, false},
+		{[]string{"team", "team"}, true},
+		{[]string{"namespace"}, true},
+		{[]string{"host"}, true},
+		{[]string{"controller_pod"}, true},
+		{[]string{"1team"}, true},
+		{[]string{"__team"}, true},
+	}
+
+	for _, tc := range testCases {
+		err := validateMetricsLabels(tc.labels)
+		if (err != nil) != tc.expectErr {
+			t.Errorf("expected error: %t but got error: %v for labels %v", tc.expectErr, err, tc.labels)
+		}
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package metric
 
 import (
+	"time"
+
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
 )
 
@@ -41,6 +44,75 @@ func (dc DummyCollector) IncReloadCount() {}
 // IncReloadErrorCount dummy implementation
 func (dc DummyCollector) IncReloadErrorCount() {}
 
+// IncReloadAvoidedCount dummy implementation
+func (dc DummyCollector) IncReloadAvoidedCount() {}
+
+// IncNGINXMasterExitCount dummy implementation
+func (dc DummyCollector) IncNGINXMasterExitCount(string) {}
+
+// SetNGINXMasterCrashLoop dummy implementation
+func (dc DummyCollector) SetNGINXMasterCrashLoop(bool) {}
+
+// IncStatusUpdateCount dummy implementation
+func (dc DummyCollector) IncStatusUpdateCount() {}
+
+// IncStatusRemovalDampenedCount dummy implementation
+func (dc DummyCollector) IncStatusRemovalDampenedCount() {}
+
+// SetInformerSynced dummy implementation
+func (dc DummyCollector) SetInformerSynced(string, bool) {}
+
+// IncInformerWatchRestartCount dummy implementation
+func (dc DummyCollector) IncInformerWatchRestartCount(string) {}
+
+// ObserveInformerEventLatency dummy implementation
+func (dc DummyCollector) ObserveInformerEventLatency(string, time.Duration) {}
+
+// SetComponentHealth dummy implementation
+func (dc DummyCollector) SetComponentHealth(string, bool, time.Time) {}
+
+// SetAnnotationUsage dummy implementation
+func (dc DummyCollector) SetAnnotationUsage([]collectors.AnnotationUsage) {}
+
+// SetIgnoredIngresses dummy implementation
+func (dc DummyCollector) SetIgnoredIngresses(map[string]int) {}
+
+// IncDeprecationWarningCount dummy implementation
+func (dc DummyCollector) IncDeprecationWarningCount(string, string) {}
+
+// SetConfigSize dummy implementation
+func (dc DummyCollector) SetConfigSize(int, int, int) {}
+
+// SetConfigObjects dummy implementation
+func (dc DummyCollector) SetConfigObjects(int, int) {}
+
+// SetConfigDriftedReplicas dummy implementation
+func (dc DummyCollector) SetConfigDriftedReplicas(int) {}
+
+// SetWorkerFDUtilization dummy implementation
+func (dc DummyCollector) SetWorkerFDUtilization(float64) {}
+
+// SetStuckReloadWorkers dummy implementation
+func (dc DummyCollector) SetStuckReloadWorkers(int) {}
+
+// SetOldWorkers dummy implementation
+func (dc DummyCollector) SetOldWorkers(int) {}
+
+// AddOldWorkersKilled dummy implementation
+func (dc DummyCollector) AddOldWorkersKilled(string, int) {}
+
+// SetPathConflicts dummy implementation
+func (dc DummyCollector) SetPathConflicts(map[string]int) {}
+
+// SetLuaSharedDictUtilization dummy implementation
+func (dc DummyCollector) SetLuaSharedDictUtilization(map[string]float64) {}
+
+// SetOCSPResponseAge dummy implementation
+func (dc DummyCollector) SetOCSPResponseAge(map[string]float64) {}
+
+// IncOCSPFetchErrorCount dummy implementation
+func (dc DummyCollector) IncOCSPFetchErrorCount(string, string) {}
+
 // IncOrphanIngress dummy implementation
 func (dc DummyCollector) IncOrphanIngress(string, string, string) {}
 
@@ -71,6 +143,12 @@ func (dc DummyCollector) SetSSLExpireTime([]*ingress.Server) {}
 // SetHosts dummy implementation
 func (dc DummyCollector) SetHosts(_ sets.Set[string]) {}
 
+// SetIngressLabels dummy implementation
+func (dc DummyCollector) SetIngressLabels(map[string]map[string]string) {}
+
+// SetEndpoints dummy implementation
+func (dc DummyCollector) SetEndpoints([]*ingress.Backend) {}
+
 // OnStartedLeading indicates the pod is not the current leader
 func (dc DummyCollector) OnStartedLeading(_ string) {}
 
@@ -35,6 +35,60 @@ type Collector interface {
 
 	IncReloadCount()
 	IncReloadErrorCount()
+	IncReloadAvoidedCount()
+
+	// SetConfigSize sets the size of the NGINX configuration generated from the template and
+	// after the optimization, with the number of blocks shared by the locations
+	SetConfigSize(int, int, int)
+	// SetConfigObjects sets the number of servers and locations of the NGINX configuration
+	SetConfigObjects(int, int)
+	// SetConfigDriftedReplicas sets the number of replicas running a configuration different from the other replicas
+	SetConfigDriftedReplicas(int)
+	// SetPathConflicts sets the number of paths of each Ingress shadowed by the same paths of other Ingresses, by namespace/name
+	SetPathConflicts(map[string]int)
+
+	// SetWorkerFDUtilization sets the highest ratio of open file descriptors of the NGINX workers
+	SetWorkerFDUtilization(float64)
+	// SetStuckReloadWorkers sets the number of NGINX workers of previous configurations not exiting after a reload
+	SetStuckReloadWorkers(int)
+	// SetOldWorkers sets the number of NGINX workers of previous configurations shutting down
+	SetOldWorkers(int)
+	// AddOldWorkersKilled adds the number of NGINX workers of previous configurations killed for a reason
+	AddOldWorkersKilled(string, int)
+	// SetLuaSharedDictUtilization sets the ratio of the memory used in each Lua shared dictionary
+	SetLuaSharedDictUtilization(map[string]float64)
+
+	// SetOCSPResponseAge sets the age of the OCSP response of each Secret, by namespace/name
+	SetOCSPResponseAge(map[string]float64)
+	// IncOCSPFetchErrorCount increments the number of errors fetching the OCSP response of a Secret by reason
+	IncOCSPFetchErrorCount(string, string)
+
+	// IncNGINXMasterExitCount increments the number of unexpected exits of the NGINX master process by reason
+	IncNGINXMasterExitCount(string)
+	// SetNGINXMasterCrashLoop sets if the NGINX master process is in a crash loop
+	SetNGINXMasterCrashLoop(bool)
+
+	// IncStatusUpdateCount increments the number of updates of the status of Ingresses
+	IncStatusUpdateCount()
+	// IncStatusRemovalDampenedCount increments the number of addresses kept in the status while missing
+	IncStatusRemovalDampenedCount()
+
+	// SetInformerSynced sets if the local cache of a resource type is synchronized
+	SetInformerSynced(string, bool)
+	// IncInformerWatchRestartCount increments the number of watch restarts of a resource type
+	IncInformerWatchRestartCount(string)
+	// ObserveInformerEventLatency records the latency of a change of a resource type
+	ObserveInformerEventLatency(string, time.Duration)
+
+	// SetAnnotationUsage sets the number of Ingresses using each annotation
+	SetAnnotationUsage([]collectors.AnnotationUsage)
+	// SetIgnoredIngresses sets the number of Ingresses ignored by the controller by reason
+	SetIgnoredIngresses(map[string]int)
+	// IncDeprecationWarningCount increments the number of warnings about a deprecated setting
+	IncDeprecationWarningCount(string, string)
+
+	// SetComponentHealth sets if a component of the controller is healthy and the time of its last successful operation
+	SetComponentHealth(string, bool, time.Time)
 
 	SetAdmissionMetrics(float64, float64, float64, float64, float64, float64)
 
@@ -54,6 +108,12 @@ type Collector interface {
 	// SetHosts sets the hostnames that are being served by the ingress controller
 	SetHosts(set sets.Set[string])
 
+	// SetIngressLabels sets the labels of the request metrics of each Ingress
+	SetIngressLabels(map[string]map[string]string)
+
+	// SetEndpoints sets the endpoints of the backends of the request metrics by endpoint
+	SetEndpoints([]*ingress.Backend)
+
 	Start(string)
 	Stop(string)
 }
@@ -61,6 +121,7 @@ type Collector interface {
 type collector struct {
 	nginxStatus  collectors.NGINXStatusCollector
 	nginxProcess collectors.NGINXProcessCollector
+	dnsCache     collectors.DNSCacheCollector
 
 	ingressController   *collectors.Controller
 	admissionController *collectors.AdmissionCollector
@@ -71,7 +132,24 @@ type collector struct {
 }
 
 // NewCollector creates a new metric collector the for ingress controller
-func NewCollector(metricsPerHost, metricsPerUndefinedHost, reportStatusClasses bool, registry *prometheus.Registry, ingressclass string, buckets collectors.HistogramBuckets, bucketFactor float64, maxBuckets uint32, excludedSocketMetrics []string) (Collector, error) {
+func NewCollector(metricsPerHost, metricsPerUndefinedHost, reportStatusClasses bool, registry *prometheus.Registry, ingressclass string, buckets collectors.HistogramBuckets, bucketFactor float64, maxBuckets uint32, excludedSocketMetrics, metricsLabels []string) (Collector, error) {
+	return newCollector(&Options{
+		MetricsPerHost:          metricsPerHost,
+		MetricsPerUndefinedHost: metricsPerUndefinedHost,
+		ReportStatusClasses:     reportStatusClasses,
+		Registry:                registry,
+		IngressClass:            ingressclass,
+		Buckets:                 buckets,
+		BucketFactor:            bucketFactor,
+		MaxBuckets:              maxBuckets,
+		ExcludedSocketMetrics:   excludedSocketMetrics,
+		MetricsLabels:           metricsLabels,
+	}, 0)
+}
+
+// newCollector creates the Prometheus metric collector, processing the
+// metrics of the requests in a number of shards when shards is above 0
+func newCollector(opts *Options, shards int) (Collector, error) {
 	podNamespace := os.Getenv("POD_NAMESPACE")
 	if podNamespace == "" {
 		podNamespace = "default"
@@ -79,35 +157,49 @@ func NewCollector(metricsPerHost, metricsPerUndefinedHost, reportStatusClasses b
 
 	podName := os.Getenv("POD_NAME")
 
-	nc, err := collectors.NewNGINXStatus(podName, podNamespace, ingressclass)
+	nc, err := collectors.NewNGINXStatus(podName, podNamespace, opts.IngressClass)
+	if err != nil {
+		return nil, err
+	}
+
+	pc, err := collectors.NewNGINXProcess(podName, podNamespace, opts.IngressClass)
 	if err != nil {
 		return nil, err
 	}
 
-	pc, err := collectors.NewNGINXProcess(podName, podNamespace, ingressclass)
+	dc, err := collectors.NewDNSCache(podName, podNamespace, opts.IngressClass)
 	if err != nil {
 		return nil, err
 	}
 
-	s, err := collectors.NewSocketCollector(podName, podNamespace, ingressclass, metricsPerHost, metricsPerUndefinedHost, reportStatusClasses, buckets, bucketFactor, maxBuckets, excludedSocketMetrics)
+	s, err := collectors.NewSocketCollector(podName, podNamespace, opts.IngressClass, opts.MetricsPerHost, opts.MetricsPerUndefinedHost, opts.ReportStatusClasses, opts.Buckets, opts.BucketFactor, opts.MaxBuckets, opts.ExcludedSocketMetrics, opts.MetricsLabels)
 	if err != nil {
 		return nil, err
 	}
 
-	ic := collectors.NewController(podName, podNamespace, ingressclass)
+	if shards > 0 {
+		s.EnableShards(shards)
+	}
+
+	if opts.MetricsPerEndpoint {
+		s.EnableEndpointMetrics(opts.MetricsPerEndpointMaxSeries)
+	}
+
+	ic := collectors.NewController(podName, podNamespace, opts.IngressClass)
 
-	am := collectors.NewAdmissionCollector(podName, podNamespace, ingressclass)
+	am := collectors.NewAdmissionCollector(podName, podNamespace, opts.IngressClass)
 
 	return Collector(&collector{
 		nginxStatus:  nc,
 		nginxProcess: pc,
+		dnsCache:     dc,
 
 		admissionController: am,
 		ingressController:   ic,
 
 		socket: s,
 
-		registry: registry,
+		registry: opts.Registry,
 	}), nil
 }
 
@@ -131,6 +223,98 @@ func (c *collector) IncReloadErrorCount() {
 	c.ingressController.IncReloadErrorCount()
 }
 
+func (c *collector) IncReloadAvoidedCount() {
+	c.ingressController.IncReloadAvoidedCount()
+}
+
+func (c *collector) IncNGINXMasterExitCount(reason string) {
+	c.ingressController.IncNGINXMasterExitCount(reason)
+}
+
+func (c *collector) SetNGINXMasterCrashLoop(crashLoop bool) {
+	c.ingressController.SetNGINXMasterCrashLoop(crashLoop)
+}
+
+func (c *collector) IncStatusUpdateCount() {
+	c.ingressController.IncStatusUpdateCount()
+}
+
+func (c *collector) IncStatusRemovalDampenedCount() {
+	c.ingressController.IncStatusRemovalDampenedCount()
+}
+
+func (c *collector) SetInformerSynced(resource string, synced bool) {
+	c.ingressController.SetInformerSynced(resource, synced)
+}
+
+func (c *collector) IncInformerWatchRestartCount(resource string) {
+	c.ingressController.IncInformerWatchRestartCount(resource)
+}
+
+func (c *collector) ObserveInformerEventLatency(resource string, latency time.Duration) {
+	c.ingressController.ObserveInformerEventLatency(resource, latency)
+}
+
+func (c *collector) SetComponentHealth(component string, healthy bool, lastSuccess time.Time) {
+	c.ingressController.SetComponentHealth(component, healthy, lastSuccess)
+}
+
+func (c *collector) SetAnnotationUsage(usage []collectors.AnnotationUsage) {
+	c.ingressController.SetAnnotationUsage(usage)
+}
+
+func (c *collector) SetIgnoredIngresses(counts map[string]int) {
+	c.ingressController.SetIgnoredIngresses(counts)
+}
+
+func (c *collector) IncDeprecationWarningCount(kind, name string) {
+	c.ingressController.IncDeprecationWarningCount(kind, name)
+}
+
+func (c *collector) SetConfigSize(size, optimizedSize, sharedBlocks int) {
+	c.ingressController.SetConfigSize(size, optimizedSize, sharedBlocks)
+}
+
+func (c *collector) SetConfigObjects(servers, locations int) {
+	c.ingressController.SetConfigObjects(servers, locations)
+}
+
+func (c *collector) SetPathConflicts(conflicts map[string]int) {
+	c.ingressController.SetPathConflicts(conflicts)
+}
+
+func (c *collector) SetConfigDriftedReplicas(replicas int) {
+	c.ingressController.SetConfigDriftedReplicas(replicas)
+}
+
+func (c *collector) SetWorkerFDUtilization(ratio float64) {
+	c.ingressController.SetWorkerFDUtilization(ratio)
+}
+
+func (c *collector) SetStuckReloadWorkers(workers int) {
+	c.ingressController.SetStuckReloadWorkers(workers)
+}
+
+func (c *collector) SetOldWorkers(workers int) {
+	c.ingressController.SetOldWorkers(workers)
+}
+
+func (c *collector) AddOldWorkersKilled(reason string, workers int) {
+	c.ingressController.AddOldWorkersKilled(reason, workers)
+}
+
+func (c *collector) SetLuaSharedDictUtilization(utilization map[string]float64) {
+	c.ingressController.SetLuaSharedDictUtilization(utilization)
+}
+
+func (c *collector) SetOCSPResponseAge(ages map[string]float64) {
+	c.ingressController.SetOCSPResponseAge(ages)
+}
+
+func (c *collector) IncOCSPFetchErrorCount(secret, reason string) {
+	c.ingressController.IncOCSPFetchErrorCount(secret, reason)
+}
+
 func (c *collector) RemoveMetrics(ingresses, certificates []string) {
 	c.socket.RemoveMetrics(ingresses, c.registry)
 	c.ingressController.RemoveMetrics(certificates, c.registry)
@@ -139,6 +323,7 @@ func (c *collector) RemoveMetrics(ingresses, certificates []string) {
 func (c *collector) Start(admissionStatus string) {
 	c.registry.MustRegister(c.nginxStatus)
 	c.registry.MustRegister(c.nginxProcess)
+	c.registry.MustRegister(c.dnsCache)
 	if admissionStatus != "" {
 		c.registry.MustRegister(c.admissionController)
 	}
@@ -149,6 +334,7 @@ func (c *collector) Start(admissionStatus string) {
 	// a server section with the status port
 	go func() {
 		time.Sleep(5 * time.Second)
+		go c.dnsCache.Start()
 		c.nginxStatus.Start()
 	}()
 	go c.nginxProcess.Start()
@@ -158,6 +344,7 @@ func (c *collector) Start(admissionStatus string) {
 func (c *collector) Stop(admissionStatus string) {
 	c.registry.Unregister(c.nginxStatus)
 	c.registry.Unregister(c.nginxProcess)
+	c.registry.Unregister(c.dnsCache)
 	if admissionStatus != "" {
 		c.registry.Unregister(c.admissionController)
 	}
@@ -166,6 +353,7 @@ func (c *collector) Stop(admissionStatus string) {
 
 	c.nginxStatus.Stop()
 	c.nginxProcess.Stop()
+	c.dnsCache.Stop()
 	c.socket.Stop()
 }
 
@@ -195,6 +383,14 @@ func (c *collector) SetHosts(hosts sets.Set[string]) {
 	c.socket.SetHosts(hosts)
 }
 
+func (c *collector) SetIngressLabels(labels map[string]map[string]string) {
+	c.socket.SetIngressLabels(labels)
+}
+
+func (c *collector) SetEndpoints(backends []*ingress.Backend) {
+	c.socket.SetEndpoints(backends)
+}
+
 func (c *collector) SetAdmissionMetrics(testedIngressLength, testedIngressTime, renderingIngressLength, renderingIngressTime, testedConfigurationSize, admissionTime float64) {
 	c.admissionController.SetAdmissionMetrics(
 		testedIngressLength,
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
+)
+
+const (
+	// PrometheusCollector is the name of the default metric collector,
+	// exporting the metrics to Prometheus
+	PrometheusCollector = "prometheus"
+	// ShardedCollector is the name of the Prometheus metric collector
+	// processing the metrics of the requests in one shard per CPU
+	ShardedCollector = "sharded"
+	// NoopCollector is the name of the metric collector discarding the metrics
+	NoopCollector = "noop"
+)
+
+// Options contains the settings of the metric collectors
+type Options struct {
+	MetricsPerHost          bool
+	MetricsPerUndefinedHost bool
+	ReportStatusClasses     bool
+
+	// MetricsPerEndpoint enables the request metrics by endpoint, up to
+	// MetricsPerEndpointMaxSeries series
+	MetricsPerEndpoint          bool
+	MetricsPerEndpointMaxSeries int
+
+	// Registry is the Prometheus registry of the controller
+	Registry *prometheus.Registry
+
+	IngressClass string
+
+	Buckets      collectors.HistogramBuckets
+	BucketFactor float64
+	MaxBuckets   uint32
+
+	ExcludedSocketMetrics []string
+	MetricsLabels         []string
+}
+
+// CollectorFactory creates a metric collector
+type CollectorFactory func(opts *Options) (Collector, error)
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = map[string]CollectorFactory{
+		PrometheusCollector: func(opts *Options) (Collector, error) {
+			return newCollector(opts, 0)
+		},
+		ShardedCollector: func(opts *Options) (Collector, error) {
+			return newCollector(opts, runtime.GOMAXPROCS(0))
+		},
+		NoopCollector: func(*Options) (Collector, error) {
+			return NewDummyCollector(), nil
+		},
+	}
+)
+
+// RegisterCollector registers a metric collector that can be selected using
+// the flag --metrics-collector. Custom collectors are compiled in the
+// controller with a package calling RegisterCollector in its init function.
+func RegisterCollector(name string, factory CollectorFactory) error {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if _, ok := factories[name]; ok {
+		return fmt.Errorf("metric collector %q is already registered", name)
+	}
+
+	factories[name] = factory
+	return nil
+}
+
+// CollectorNames returns the sorted names of the registered metric collectors
+func CollectorNames() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// NewCollectorByName creates a registered metric collector
+func NewCollectorByName(name string, opts *Options) (Collector, error) {
+	factoriesMu.RLock()
+	factory, ok := factories[name]
+	factoriesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown metric collector %q", name)
+	}
+
+	return factory(opts)
+}
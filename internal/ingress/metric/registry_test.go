@@ -0,0 +1,49 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metric
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRegisterCollector(t *testing.T) {
+	factory := func(*Options) (Collector, error) {
+		return NewDummyCollector(), nil
+	}
+
+	if err := RegisterCollector(NoopCollector, factory); err == nil {
+		t.Errorf("expected an error registering a collector twice")
+	}
+
+	if err := RegisterCollector("custom", factory); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := []string{"custom", NoopCollector, PrometheusCollector, ShardedCollector}
+	if names := CollectorNames(); !slices.Equal(names, expected) {
+		t.Errorf("expected %v but returned %v", expected, names)
+	}
+
+	if _, err := NewCollectorByName("custom", &Options{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if _, err := NewCollectorByName("unknown", &Options{}); err == nil {
+		t.Errorf("expected an error creating an unknown collector")
+	}
+}
@@ -29,6 +29,7 @@ type Mock struct {
 	ConfigMaps           map[string]*apiv1.ConfigMap
 	AnnotationsRiskLevel string
 	AllowCrossNamespace  bool
+	AuthInMemory         bool
 }
 
 // GetDefaultBackend returns the backend that must be used as default
@@ -44,6 +45,7 @@ func (m Mock) GetSecurityConfiguration() defaults.SecurityConfiguration {
 	return defaults.SecurityConfiguration{
 		AnnotationsRiskLevel:         defRisk,
 		AllowCrossNamespaceResources: m.AllowCrossNamespace,
+		AuthCredentialsInMemory:      m.AuthInMemory,
 	}
 }
 
@@ -0,0 +1,139 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"encoding/json"
+
+	v1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+// stateAnnotation is the annotation of the Lease of the leader election
+// containing the state of the status synchronization, so a new leader
+// continues from the state of the previous one
+const stateAnnotation = "ingress-nginx.kubernetes.io/status-sync-state"
+
+// syncState is the state of the status synchronization handed off between
+// leaders
+type syncState struct {
+	// Published contains the addresses published in the last sync
+	Published []v1.IngressLoadBalancerIngress `json:"published,omitempty"`
+
+	// Missing contains the published addresses that are no longer running
+	Missing map[string]missingState `json:"missing,omitempty"`
+
+	// Pending contains the Ingresses (namespace/name) with a status update
+	// in progress or failed
+	Pending []string `json:"pending,omitempty"`
+}
+
+type missingState struct {
+	Since        metav1.Time `json:"since"`
+	Observations int         `json:"observations"`
+}
+
+// restoreState loads the state handed off by the previous leader in the
+// Lease of the leader election
+func (s *statusSync) restoreState() {
+	if s.ElectionID == "" {
+		return
+	}
+
+	lease, err := s.Client.CoordinationV1().Leases(k8s.IngressPodDetails.Namespace).Get(context.TODO(), s.ElectionID, metav1.GetOptions{})
+	if err != nil {
+		klog.ErrorS(err, "error obtaining status sync state", "lease", s.ElectionID)
+		return
+	}
+
+	data, ok := lease.Annotations[stateAnnotation]
+	if !ok {
+		return
+	}
+
+	var state syncState
+	if err := json.Unmarshal([]byte(data), &state); err != nil {
+		klog.ErrorS(err, "ignoring invalid status sync state", "lease", s.ElectionID)
+		return
+	}
+
+	s.published = state.Published
+	s.missing = make(map[string]*missingAddress, len(state.Missing))
+	for key, missing := range state.Missing {
+		s.missing[key] = &missingAddress{since: missing.Since.Time, observations: missing.Observations}
+	}
+	s.pending = state.Pending
+	s.savedState = data
+
+	klog.InfoS("restored status sync state", "published", s.published, "missing", len(s.missing), "pending", len(s.pending))
+}
+
+// saveState stores the state of the status synchronization in the Lease of
+// the leader election. The Lease is only updated when the state changes.
+func (s *statusSync) saveState() {
+	if s.ElectionID == "" {
+		return
+	}
+
+	state := syncState{
+		Published: s.published,
+		Pending:   s.pending,
+	}
+	if len(s.missing) > 0 {
+		state.Missing = make(map[string]missingState, len(s.missing))
+		for key, missing := range s.missing {
+			state.Missing[key] = missingState{Since: metav1.NewTime(missing.since), Observations: missing.observations}
+		}
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		klog.ErrorS(err, "error encoding status sync state")
+		return
+	}
+
+	if string(data) == s.savedState {
+		return
+	}
+
+	leases := s.Client.CoordinationV1().Leases(k8s.IngressPodDetails.Namespace)
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		lease, err := leases.Get(context.TODO(), s.ElectionID, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		if lease.Annotations == nil {
+			lease.Annotations = map[string]string{}
+		}
+		lease.Annotations[stateAnnotation] = string(data)
+
+		_, err = leases.Update(context.TODO(), lease, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		klog.ErrorS(err, "error saving status sync state", "lease", s.ElectionID)
+		return
+	}
+
+	s.savedState = string(data)
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package status
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apiv1 "k8s.io/api/core/v1"
+	networking "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/internal/k8s"
+)
+
+const testElectionID = "ingress-controller-leader"
+
+func buildHandoffStatusSync(t *testing.T) *statusSync {
+	k8s.IngressPodDetails = &k8s.PodInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo1",
+			Namespace: apiv1.NamespaceDefault,
+		},
+	}
+
+	client := buildSimpleClientSet()
+	_, err := client.CoordinationV1().Leases(apiv1.NamespaceDefault).Create(context.TODO(), &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testElectionID,
+			Namespace: apiv1.NamespaceDefault,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error creating lease: %v", err)
+	}
+
+	fk, ok := NewStatusSyncer(Config{
+		Client:        client,
+		IngressLister: buildIngressLister(),
+		ElectionID:    testElectionID,
+	}).(*statusSync)
+	if !ok {
+		t.Fatalf("unexpected type: %T", fk)
+	}
+
+	return fk
+}
+
+func TestStateHandoff(t *testing.T) {
+	leader := buildHandoffStatusSync(t)
+
+	since := time.Now().Add(-time.Minute).Truncate(time.Second)
+	leader.published = []networking.IngressLoadBalancerIngress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}
+	leader.missing["10.0.0.2"] = &missingAddress{since: since, observations: 2}
+	leader.pending = []string{"default/foo_ingress_1"}
+	leader.saveState()
+
+	lease, err := leader.Client.CoordinationV1().Leases(apiv1.NamespaceDefault).Get(context.TODO(), testElectionID, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := lease.Annotations[stateAnnotation]; !ok {
+		t.Fatalf("expected the annotation %v in the lease", stateAnnotation)
+	}
+
+	config := leader.Config
+	config.RemovalObservations = 5
+
+	next, ok := NewStatusSyncer(config).(*statusSync)
+	if !ok {
+		t.Fatalf("unexpected type: %T", next)
+	}
+	next.restoreState()
+
+	if !ingressSliceEqual(next.published, leader.published) {
+		t.Errorf("expected published addresses %v but returned %v", leader.published, next.published)
+	}
+
+	missing, ok := next.missing["10.0.0.2"]
+	if !ok || len(next.missing) != 1 {
+		t.Fatalf("expected the missing address 10.0.0.2 but returned %v", next.missing)
+	}
+	if !missing.since.Equal(since) || missing.observations != 2 {
+		t.Errorf("expected the address missing since %v in 2 observations but returned %v in %v", since, missing.since, missing.observations)
+	}
+
+	if !reflect.DeepEqual(next.pending, leader.pending) {
+		t.Errorf("expected pending updates %v but returned %v", leader.pending, next.pending)
+	}
+
+	// the missing address is still published by the new leader
+	r := next.dampenRemovals([]networking.IngressLoadBalancerIngress{{IP: "10.0.0.1"}}, time.Now())
+	if !ingressSliceEqual(standardizeLoadBalancerIngresses(r), leader.published) {
+		t.Errorf("expected addresses %v but returned %v", leader.published, r)
+	}
+	if next.missing["10.0.0.2"].observations != 3 {
+		t.Errorf("expected 3 observations of the missing address but returned %v", next.missing["10.0.0.2"].observations)
+	}
+}
+
+func TestUpdateStatusPending(t *testing.T) {
+	fk := buildHandoffStatusSync(t)
+
+	// the status of foo_ingress_1 in the lister is already up to date but its
+	// update was in progress when the previous leader stopped
+	fk.pending = []string{"default/foo_ingress_1"}
+	fk.updateStatus(buildLoadBalancerIngressByIP())
+
+	ing, err := fk.Client.NetworkingV1().Ingresses(apiv1.NamespaceDefault).Get(context.TODO(), "foo_ingress_1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	expected := standardizeLoadBalancerIngresses(buildLoadBalancerIngressByIP())
+	curIPs := ing.Status.LoadBalancer.Ingress
+	if !ingressSliceEqual(standardizeLoadBalancerIngresses(curIPs), expected) {
+		t.Errorf("expected status %v but returned %v", expected, curIPs)
+	}
+
+	// foo_ingress_non_01 does not exist so its update fails
+	pending := []string{"default/foo_ingress_non_01"}
+	if !reflect.DeepEqual(fk.pending, pending) {
+		t.Errorf("expected pending updates %v but returned %v", pending, fk.pending)
+	}
+
+	next, ok := NewStatusSyncer(fk.Config).(*statusSync)
+	if !ok {
+		t.Fatalf("unexpected type: %T", next)
+	}
+	next.restoreState()
+
+	if !reflect.DeepEqual(next.pending, pending) {
+		t.Errorf("expected handed off pending updates %v but returned %v", pending, next.pending)
+	}
+}
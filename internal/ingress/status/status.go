@@ -23,6 +23,7 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -35,6 +36,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 	clientset "k8s.io/client-go/kubernetes"
 
+	"k8s.io/ingress-nginx/internal/ingress/controller/shard"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/task"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
@@ -68,7 +71,25 @@ type Config struct {
 
 	UseNodeInternalIP bool
 
+	// RemovalHoldTime is the minimum time an address must be missing
+	// before it is removed from the status
+	RemovalHoldTime time.Duration
+
+	// RemovalObservations is the number of consecutive syncs in which an
+	// address must be missing before it is removed from the status
+	RemovalObservations int
+
+	// ElectionID is the name of the Lease of the leader election used to
+	// hand off the state of the synchronization to the next leader
+	ElectionID string
+
+	// Shard defines the Ingresses whose status is updated when the
+	// Ingresses are split between several controller deployments
+	Shard *shard.Configuration
+
 	IngressLister ingressLister
+
+	MetricCollector metric.Collector
 }
 
 // statusSync keeps the status IP in each Ingress rule updated executing a periodic check
@@ -84,10 +105,32 @@ type statusSync struct {
 	// workqueue used to keep in sync the status IP/s
 	// in the Ingress rules
 	syncQueue *task.Queue
+
+	// published contains the addresses published in the last sync
+	published []v1.IngressLoadBalancerIngress
+
+	// missing contains the published addresses that are no longer running
+	missing map[string]*missingAddress
+
+	// pending contains the Ingresses (namespace/name) with a status update
+	// in progress or failed, updated by the next sync even without changes
+	pending []string
+
+	// savedState contains the last state saved in the Lease
+	savedState string
+}
+
+// missingAddress tracks a published address that is no longer running
+type missingAddress struct {
+	since        time.Time
+	observations int
 }
 
 // Start starts the loop to keep the status in sync
 func (s *statusSync) Run(stopCh chan struct{}) {
+	// continue from the state of the previous leader
+	s.restoreState()
+
 	go s.syncQueue.Run(time.Second, stopCh)
 
 	// trigger initial sync
@@ -106,7 +149,7 @@ func (s *statusSync) Run(stopCh chan struct{}) {
 }
 
 // Shutdown stops the sync. In case the instance is the leader it will remove the current IP
-// if there is no other instances running.
+// if there is no other ready instances running.
 func (s *statusSync) Shutdown() {
 	go s.syncQueue.Shutdown()
 
@@ -127,8 +170,16 @@ func (s *statusSync) Shutdown() {
 		return
 	}
 
-	if s.isRunningMultiplePods() {
-		klog.V(2).InfoS("skipping Ingress status update (multiple pods running - another one will be elected as master)")
+	others, err := s.otherReadyPods()
+	if err != nil {
+		// without the list of pods we cannot know if other replicas are
+		// serving traffic so the status is preserved
+		klog.ErrorS(err, "skipping Ingress status update (error obtaining running pods)")
+		return
+	}
+
+	if others > 0 {
+		klog.V(2).InfoS("skipping Ingress status update (multiple pods running - another one will be elected as master)", "ready", others)
 		return
 	}
 
@@ -146,11 +197,62 @@ func (s *statusSync) sync(_ interface{}) error {
 	if err != nil {
 		return err
 	}
-	s.updateStatus(standardizeLoadBalancerIngresses(addrs))
+	s.updateStatus(standardizeLoadBalancerIngresses(s.dampenRemovals(addrs, time.Now())))
 
 	return nil
 }
 
+// dampenRemovals returns the addresses to publish in the status. Addresses
+// published in a previous sync that are no longer running are kept until they
+// are missing during RemovalHoldTime and RemovalObservations consecutive syncs.
+func (s *statusSync) dampenRemovals(addrs []v1.IngressLoadBalancerIngress, now time.Time) []v1.IngressLoadBalancerIngress {
+	running := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		running[loadBalancerIngressKey(addr)] = true
+	}
+
+	for key := range s.missing {
+		if running[key] {
+			delete(s.missing, key)
+		}
+	}
+
+	result := append([]v1.IngressLoadBalancerIngress{}, addrs...)
+	for _, addr := range s.published {
+		key := loadBalancerIngressKey(addr)
+		if running[key] {
+			continue
+		}
+
+		missing, ok := s.missing[key]
+		if !ok {
+			missing = &missingAddress{since: now}
+			s.missing[key] = missing
+		}
+		missing.observations++
+
+		if missing.observations >= s.RemovalObservations && now.Sub(missing.since) >= s.RemovalHoldTime {
+			delete(s.missing, key)
+			continue
+		}
+
+		klog.V(2).InfoS("keeping address in Ingress status", "address", key, "missingSince", missing.since, "observations", missing.observations)
+		s.MetricCollector.IncStatusRemovalDampenedCount()
+		result = append(result, addr)
+	}
+
+	s.published = result
+	return result
+}
+
+func loadBalancerIngressKey(addr v1.IngressLoadBalancerIngress) string {
+	if addr.IP != "" {
+		return addr.IP
+	}
+
+	return addr.Hostname
+}
+
 func (s *statusSync) keyfunc(input interface{}) (interface{}, error) {
 	return input, nil
 }
@@ -158,7 +260,14 @@ func (s *statusSync) keyfunc(input interface{}) (interface{}, error) {
 // NewStatusSyncer returns a new Syncer instance
 func NewStatusSyncer(config Config) Syncer {
 	st := &statusSync{
-		Config: config,
+		Config:  config,
+		missing: map[string]*missingAddress{},
+	}
+	if st.MetricCollector == nil {
+		st.MetricCollector = metric.DummyCollector{}
+	}
+	if st.RemovalObservations < 1 {
+		st.RemovalObservations = 1
 	}
 	st.syncQueue = task.NewCustomTaskQueue(st.sync, st.keyfunc)
 
@@ -207,15 +316,7 @@ func (s *statusSync) runningAddresses() ([]v1.IngressLoadBalancerIngress, error)
 		}
 
 		// only Ready pods are valid
-		isPodReady := false
-		for _, cond := range pod.Status.Conditions {
-			if cond.Type == apiv1.PodReady && cond.Status == apiv1.ConditionTrue {
-				isPodReady = true
-				break
-			}
-		}
-
-		if !isPodReady {
+		if !isPodReady(&pod) {
 			klog.InfoS("POD is not ready", "pod", klog.KObj(&pod), "node", pod.Spec.NodeName)
 			continue
 		}
@@ -229,7 +330,9 @@ func (s *statusSync) runningAddresses() ([]v1.IngressLoadBalancerIngress, error)
 	return addrs, nil
 }
 
-func (s *statusSync) isRunningMultiplePods() bool {
+// otherReadyPods returns the number of ready pods of the ingress controller
+// deployment, excluding the current pod and the pods being terminated
+func (s *statusSync) otherReadyPods() (int, error) {
 	// As a standard, app.kubernetes.io are "reserved well-known" labels.
 	// In our case, we add those labels as identifiers of the Ingress
 	// deployment in this namespace, so we can select it as a set of Ingress instances.
@@ -246,10 +349,35 @@ func (s *statusSync) isRunningMultiplePods() bool {
 		LabelSelector: labels.SelectorFromSet(podLabel).String(),
 	})
 	if err != nil {
-		return false
+		return 0, err
 	}
 
-	return len(pods.Items) > 1
+	ready := 0
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Name == k8s.IngressPodDetails.Name || pod.DeletionTimestamp != nil {
+			continue
+		}
+
+		if pod.Status.Phase != apiv1.PodRunning || !isPodReady(pod) {
+			continue
+		}
+
+		ready++
+	}
+
+	return ready, nil
+}
+
+// isPodReady checks if the pod contains the condition Ready
+func isPodReady(pod *apiv1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == apiv1.PodReady && cond.Status == apiv1.ConditionTrue {
+			return true
+		}
+	}
+
+	return false
 }
 
 // standardizeLoadBalancerIngresses sorts the list of loadbalancer by
@@ -266,25 +394,75 @@ func standardizeLoadBalancerIngresses(lbi []v1.IngressLoadBalancerIngress) []v1.
 func (s *statusSync) updateStatus(newIngressPoint []v1.IngressLoadBalancerIngress) {
 	ings := s.IngressLister.ListIngresses()
 
-	p := pool.NewLimited(10)
-	defer p.Close()
+	pending := make(map[string]bool, len(s.pending))
+	for _, key := range s.pending {
+		pending[key] = true
+	}
 
-	batch := p.Batch()
 	sort.SliceStable(newIngressPoint, lessLoadBalancerIngress(newIngressPoint))
 
+	updates := make([]*ingress.Ingress, 0)
+	s.pending = make([]string, 0)
 	for _, ing := range ings {
+		if !s.Shard.OwnsStatus(&ing.Ingress) {
+			continue
+		}
+
+		key := k8s.MetaNamespaceKey(ing)
 		curIPs := ing.Status.LoadBalancer.Ingress
 		sort.SliceStable(curIPs, lessLoadBalancerIngress(curIPs))
-		if ingressSliceEqual(curIPs, newIngressPoint) {
+		if !pending[key] && ingressSliceEqual(curIPs, newIngressPoint) {
 			klog.V(3).InfoS("skipping update of Ingress (no change)", "namespace", ing.Namespace, "ingress", ing.Name)
 			continue
 		}
 
-		batch.Queue(runUpdate(ing, newIngressPoint, s.Client))
+		updates = append(updates, ing)
+		s.pending = append(s.pending, key)
+	}
+
+	// the updates in progress are handed off to the next leader
+	// in case this instance stops leading before completing them
+	s.saveState()
+
+	if len(updates) == 0 {
+		return
+	}
+
+	p := pool.NewLimited(10)
+	defer p.Close()
+
+	batch := p.Batch()
+
+	var mu sync.Mutex
+	failed := make([]string, 0)
+
+	for _, ing := range updates {
+		key := k8s.MetaNamespaceKey(ing)
+		update := runUpdate(ing, newIngressPoint, s.Client)
+
+		s.MetricCollector.IncStatusUpdateCount()
+		batch.Queue(func(wu pool.WorkUnit) (interface{}, error) {
+			result, err := update(wu)
+			if err != nil || result == nil {
+				if err != nil {
+					klog.Warningf("%v", err)
+				}
+
+				mu.Lock()
+				failed = append(failed, key)
+				mu.Unlock()
+			}
+
+			return result, err
+		})
 	}
 
 	batch.QueueComplete()
 	batch.WaitAll()
+
+	sort.Strings(failed)
+	s.pending = failed
+	s.saveState()
 }
 
 func runUpdate(ing *ingress.Ingress, status []v1.IngressLoadBalancerIngress,
@@ -301,11 +479,18 @@ func runUpdate(ing *ingress.Ingress, status []v1.IngressLoadBalancerIngress,
 			return nil, fmt.Errorf("unexpected error searching Ingress %s/%s: %w", ing.Namespace, ing.Name, err)
 		}
 
+		curIPs := append([]v1.IngressLoadBalancerIngress{}, currIng.Status.LoadBalancer.Ingress...)
+		sort.SliceStable(curIPs, lessLoadBalancerIngress(curIPs))
+		if ingressSliceEqual(curIPs, status) {
+			klog.V(3).InfoS("skipping update of Ingress (no change)", "namespace", currIng.Namespace, "ingress", currIng.Name)
+			return true, nil
+		}
+
 		klog.InfoS("updating Ingress status", "namespace", currIng.Namespace, "ingress", currIng.Name, "currentValue", currIng.Status.LoadBalancer.Ingress, "newValue", status)
 		currIng.Status.LoadBalancer.Ingress = status
 		_, err = ingClient.UpdateStatus(context.TODO(), currIng, metav1.UpdateOptions{})
 		if err != nil {
-			klog.Warningf("error updating ingress rule: %v", err)
+			return nil, fmt.Errorf("error updating ingress rule: %w", err)
 		}
 
 		return true, nil
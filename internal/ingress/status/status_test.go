@@ -295,7 +295,7 @@ func TestStatusActions(t *testing.T) {
 
 	k8s.IngressPodDetails = &k8s.PodInfo{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "foo_base_pod",
+			Name:      "foo1",
 			Namespace: apiv1.NamespaceDefault,
 			Labels: map[string]string{
 				"label_sig": "foo_pod",
@@ -607,6 +607,75 @@ func TestRunningAddressesWithPods(t *testing.T) {
 	}
 }
 
+func TestOtherReadyPods(t *testing.T) {
+	labels := map[string]string{"app": "ingress-nginx"}
+	readyPod := func(name string) apiv1.Pod {
+		return apiv1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: apiv1.NamespaceDefault,
+				Labels:    labels,
+			},
+			Status: apiv1.PodStatus{
+				Phase: apiv1.PodRunning,
+				Conditions: []apiv1.PodCondition{
+					{
+						Type:   apiv1.PodReady,
+						Status: apiv1.ConditionTrue,
+					},
+				},
+			},
+		}
+	}
+
+	terminating := readyPod("terminating")
+	now := metav1.Now()
+	terminating.DeletionTimestamp = &now
+
+	notReady := readyPod("not-ready")
+	notReady.Status.Conditions[0].Status = apiv1.ConditionFalse
+
+	pending := readyPod("pending")
+	pending.Status.Phase = apiv1.PodPending
+
+	k8s.IngressPodDetails = &k8s.PodInfo{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "self",
+			Namespace: apiv1.NamespaceDefault,
+			Labels: map[string]string{
+				"app":               "ingress-nginx",
+				"pod-template-hash": "abc",
+			},
+		},
+	}
+
+	testCases := []struct {
+		name     string
+		pods     []apiv1.Pod
+		expected int
+	}{
+		{"only the current pod", []apiv1.Pod{readyPod("self")}, 0},
+		{"pods not ready or terminating", []apiv1.Pod{readyPod("self"), terminating, notReady, pending}, 0},
+		{"another ready pod", []apiv1.Pod{readyPod("self"), terminating, readyPod("other")}, 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			fk := buildStatusSync()
+			fk.Client = testclient.NewSimpleClientset(&apiv1.PodList{Items: tc.pods})
+
+			others, err := fk.otherReadyPods()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if others != tc.expected {
+				t.Errorf("expected %v ready pods but %v returned", tc.expected, others)
+			}
+		})
+	}
+}
+
 func TestRunningAddressesWithPublishStatusAddress(t *testing.T) {
 	fk := buildStatusSync()
 	fk.PublishStatusAddress = localhost
@@ -740,3 +809,40 @@ func TestIngressSliceEqual(t *testing.T) {
 		}
 	}
 }
+
+func TestDampenRemovals(t *testing.T) {
+	fk, ok := NewStatusSyncer(Config{
+		RemovalHoldTime:     time.Minute,
+		RemovalObservations: 2,
+	}).(*statusSync)
+	if !ok {
+		t.Fatalf("unexpected type: %T", fk)
+	}
+
+	both := []networking.IngressLoadBalancerIngress{{IP: "10.0.0.1"}, {IP: "10.0.0.2"}}
+	one := []networking.IngressLoadBalancerIngress{{IP: "10.0.0.1"}}
+	now := time.Now()
+
+	fooTests := []struct {
+		title    string
+		addrs    []networking.IngressLoadBalancerIngress
+		elapsed  time.Duration
+		expected []networking.IngressLoadBalancerIngress
+	}{
+		{"initial addresses are published", both, 0, both},
+		{"missing address is kept in the first observation", one, 0, both},
+		{"missing address is kept before the hold time", one, 30 * time.Second, both},
+		{"address is back", both, 40 * time.Second, both},
+		{"missing address is kept after flapping", one, 50 * time.Second, both},
+		{"missing address is kept in the second observation before the hold time", one, 80 * time.Second, both},
+		{"missing address is removed after the hold time", one, 120 * time.Second, one},
+		{"removed address is not published again", one, 130 * time.Second, one},
+	}
+
+	for _, fooTest := range fooTests {
+		r := fk.dampenRemovals(fooTest.addrs, now.Add(fooTest.elapsed))
+		if !ingressSliceEqual(standardizeLoadBalancerIngresses(r), fooTest.expected) {
+			t.Errorf("%v: returned %v but expected %v", fooTest.title, r, fooTest.expected)
+		}
+	}
+}
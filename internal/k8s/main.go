@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"k8s.io/klog/v2"
@@ -42,6 +43,33 @@ func ParseNameNS(input string) (ns, name string, err error) {
 	return nsName[0], nsName[1], nil
 }
 
+// ParseNamespaces returns the namespaces contained in a comma-separated list.
+// An empty list means all the namespaces.
+func ParseNamespaces(input string) []string {
+	namespaces := []string{}
+	for _, ns := range strings.Split(input, ",") {
+		ns = strings.TrimSpace(ns)
+		if ns == "" || slices.Contains(namespaces, ns) {
+			continue
+		}
+
+		namespaces = append(namespaces, ns)
+	}
+
+	return namespaces
+}
+
+// SingleNamespace returns the namespace of a comma-separated list of namespaces
+// when it contains only one namespace, or all the namespaces otherwise
+func SingleNamespace(input string) string {
+	namespaces := ParseNamespaces(input)
+	if len(namespaces) != 1 {
+		return apiv1.NamespaceAll
+	}
+
+	return namespaces[0]
+}
+
 // GetNodeIPOrName returns the IP address or the name of a node in the cluster
 func GetNodeIPOrName(kubeClient clientset.Interface, name string, useInternalIP bool) string {
 	node, err := kubeClient.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
@@ -17,6 +17,7 @@ limitations under the License.
 package k8s
 
 import (
+	"reflect"
 	"testing"
 
 	apiv1 "k8s.io/api/core/v1"
@@ -55,6 +56,29 @@ func TestParseNameNS(t *testing.T) {
 	}
 }
 
+func TestParseNamespaces(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+		single   string
+	}{
+		{"", []string{}, ""},
+		{"default", []string{"default"}, "default"},
+		{"team-a, team-b,,team-a", []string{"team-a", "team-b"}, ""},
+	}
+
+	for _, test := range tests {
+		namespaces := ParseNamespaces(test.input)
+		if !reflect.DeepEqual(namespaces, test.expected) {
+			t.Errorf("%q: expected %v but returned %v", test.input, test.expected, namespaces)
+		}
+
+		if single := SingleNamespace(test.input); single != test.single {
+			t.Errorf("%q: expected %q but returned %q", test.input, test.single, single)
+		}
+	}
+}
+
 func TestGetNodeIP(t *testing.T) {
 	fKNodes := []struct {
 		name          string
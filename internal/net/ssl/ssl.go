@@ -137,6 +137,40 @@ func CreateSSLCert(cert, key []byte, uid string) (*ingress.SSLCert, error) {
 	}, nil
 }
 
+// AddAlternateCertificate validates an alternate certificate and key and adds
+// them to sslCert. The alternate certificate must use a different key type
+// than the certificate of sslCert.
+func AddAlternateCertificate(sslCert *ingress.SSLCert, cert, key []byte) error {
+	alternate, err := CreateSSLCert(cert, key, "")
+	if err != nil {
+		return fmt.Errorf("invalid alternate certificate: %w", err)
+	}
+
+	if err := CheckAlternateCertificate(sslCert.Certificate, alternate.Certificate); err != nil {
+		return err
+	}
+
+	sslCert.AlternatePemCertKey = alternate.PemCertKey
+	sslCert.AlternatePemSHA = alternate.PemSHA
+
+	return nil
+}
+
+// CheckAlternateCertificate checks if a certificate can be served with an
+// alternate certificate. NGINX keeps one certificate per key type, so the
+// certificates must use different key types, like RSA and ECDSA.
+func CheckAlternateCertificate(cert, alternate *x509.Certificate) error {
+	if cert == nil || alternate == nil {
+		return errors.New("missing certificate")
+	}
+
+	if cert.PublicKeyAlgorithm == alternate.PublicKeyAlgorithm {
+		return fmt.Errorf("the alternate certificate uses the same key type (%v) as the certificate", alternate.PublicKeyAlgorithm)
+	}
+
+	return nil
+}
+
 // CreateCACert is similar to CreateSSLCert but it creates instance of SSLCert only based on given ca after
 // parsing and validating it
 func CreateCACert(ca []byte) (*ingress.SSLCert, error) {
@@ -188,6 +222,19 @@ func StoreSSLCertOnDisk(name string, sslCert *ingress.SSLCert) (string, error) {
 	return pemFileName, nil
 }
 
+// StoreAlternateSSLCertOnDisk creates a .pem file with the alternate
+// certificate and key of sslCert and returns its path
+func StoreAlternateSSLCertOnDisk(name string, sslCert *ingress.SSLCert) (string, error) {
+	pemFileName, _ := getPemFileName(fmt.Sprintf("%v-alternate", name))
+
+	err := os.WriteFile(pemFileName, []byte(sslCert.AlternatePemCertKey), file.ReadWriteByUser)
+	if err != nil {
+		return "", fmt.Errorf("could not create PEM certificate file %v: %v", pemFileName, err)
+	}
+
+	return pemFileName, nil
+}
+
 // ConfigureCACertWithCertAndKey appends ca into existing PEM file consisting of cert and key
 // and sets relevant fields in sslCert object
 func ConfigureCACertWithCertAndKey(_ string, ca []byte, sslCert *ingress.SSLCert) error {
@@ -19,6 +19,8 @@ package ssl
 import (
 	"bytes"
 	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
@@ -270,6 +272,58 @@ func TestCreateSSLCert(t *testing.T) {
 	}
 }
 
+func TestAddAlternateCertificate(t *testing.T) {
+	rsaCert, ca, err := generateRSACerts("echoheaders")
+	if err != nil {
+		t.Fatalf("unexpected error creating SSL certificate: %v", err)
+	}
+
+	sslCert, err := CreateSSLCert(encodeCertPEM(rsaCert.Cert), encodePrivateKeyPEM(rsaCert.Key), FakeSSLCertificateUID)
+	if err != nil {
+		t.Fatalf("unexpected error creating SSL certificate: %v", err)
+	}
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error creating ECDSA key: %v", err)
+	}
+
+	ecdsaCert, err := newSignedCert(&certutil.Config{
+		CommonName: "echoheaders",
+		Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}, ecdsaKey, ca.Cert, ca.Key)
+	if err != nil {
+		t.Fatalf("unexpected error signing ECDSA certificate: %v", err)
+	}
+
+	der, err := x509.MarshalECPrivateKey(ecdsaKey)
+	if err != nil {
+		t.Fatalf("unexpected error encoding ECDSA key: %v", err)
+	}
+	c := encodeCertPEM(ecdsaCert)
+	k := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+
+	if err := AddAlternateCertificate(sslCert, c, encodePrivateKeyPEM(rsaCert.Key)); err == nil {
+		t.Errorf("expected an error adding an alternate certificate with a mismatched key")
+	}
+
+	if err := AddAlternateCertificate(sslCert, encodeCertPEM(rsaCert.Cert), encodePrivateKeyPEM(rsaCert.Key)); err == nil {
+		t.Errorf("expected an error adding an alternate certificate with the same key type")
+	}
+
+	if err := AddAlternateCertificate(sslCert, c, k); err != nil {
+		t.Fatalf("unexpected error adding the alternate certificate: %v", err)
+	}
+
+	if sslCert.AlternatePemCertKey != string(c)+"\n"+string(k) {
+		t.Errorf("expected concatenated PEM alternate cert and key but returned %v", sslCert.AlternatePemCertKey)
+	}
+
+	if sslCert.AlternatePemSHA == "" || sslCert.AlternatePemSHA == sslCert.PemSHA {
+		t.Errorf("expected the sha of the alternate certificate but returned %q", sslCert.AlternatePemSHA)
+	}
+}
+
 type keyPair struct {
 	Key  *rsa.PrivateKey
 	Cert *x509.Certificate
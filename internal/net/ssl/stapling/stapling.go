@@ -0,0 +1,318 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package stapling prefetches and caches the OCSP responses stapled by NGINX
+// to the certificates it serves.
+package stapling
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+// reasons of the errors fetching an OCSP response
+const (
+	// ReasonCertificate means the certificate or its issuer could not be parsed
+	ReasonCertificate = "certificate"
+	// ReasonRequest means the OCSP responder could not be reached
+	ReasonRequest = "request"
+	// ReasonResponse means the OCSP response is invalid
+	ReasonResponse = "response"
+	// ReasonStatus means the OCSP response does not report the certificate as good
+	ReasonStatus = "status"
+)
+
+const (
+	// defaultValidity is the validity of the OCSP responses without a next update
+	defaultValidity = time.Hour
+
+	// retryInterval is the minimum interval between two requests for the
+	// OCSP response of a certificate after an error
+	retryInterval = 5 * time.Minute
+
+	// maxResponseSize limits the size of the OCSP responses
+	maxResponseSize = 1024 * 1024
+)
+
+// Certificate is a certificate served by NGINX
+type Certificate struct {
+	// UID identifies the certificate, the UID of its Secret
+	UID string
+	// Secret is the namespace and name of the Secret of the certificate
+	Secret string
+	// PEM contains the certificate followed by its chain, in PEM format
+	PEM string
+}
+
+// Response is a validated OCSP response of a certificate
+type Response struct {
+	// Raw is the OCSP response in DER format
+	Raw []byte
+	// ThisUpdate is the time at which the status of the certificate was correct
+	ThisUpdate time.Time
+	// NextUpdate is the time at which the OCSP response expires
+	NextUpdate time.Time
+
+	// fingerprint is the hash of the PEM of the certificate of the response
+	fingerprint [sha256.Size]byte
+}
+
+// refreshAt returns the time at which the OCSP response should be refreshed,
+// at half of its validity
+func (r *Response) refreshAt() time.Time {
+	if r.NextUpdate.IsZero() {
+		return r.ThisUpdate.Add(defaultValidity / 2)
+	}
+
+	return r.ThisUpdate.Add(r.NextUpdate.Sub(r.ThisUpdate) / 2)
+}
+
+// Expiry returns the time at which the OCSP response expires
+func (r *Response) Expiry() time.Time {
+	if r.NextUpdate.IsZero() {
+		return r.ThisUpdate.Add(defaultValidity)
+	}
+
+	return r.NextUpdate
+}
+
+// failure is an error fetching the OCSP response of a certificate
+type failure struct {
+	at          time.Time
+	fingerprint [sha256.Size]byte
+}
+
+// Error is an error fetching the OCSP response of a certificate
+type Error struct {
+	// Reason is one of the Reason constants
+	Reason string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Reason, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Stapler prefetches the OCSP responses of the certificates and keeps them
+// until they are refreshed, at half of their validity
+type Stapler struct {
+	client *http.Client
+
+	mu        sync.Mutex
+	responses map[string]*Response
+	// failures contains the last error fetching the response of a certificate, by UID
+	failures map[string]failure
+
+	now func() time.Time
+}
+
+// NewStapler returns a Stapler sending the OCSP requests with a timeout
+func NewStapler(timeout time.Duration) *Stapler {
+	return &Stapler{
+		client:    &http.Client{Timeout: timeout},
+		responses: map[string]*Response{},
+		failures:  map[string]failure{},
+		now:       time.Now,
+	}
+}
+
+// Refresh fetches the OCSP responses of the certificates without a valid
+// response, and the responses to refresh. The certificates without an OCSP
+// responder are ignored, and the responses of the certificates not in the
+// list are removed. It returns the responses fetched, and the
+// errors by UID of certificate. The response of a certificate is kept until
+// it expires when its refresh fails.
+func (s *Stapler) Refresh(certificates []Certificate) (fetched map[string]*Response, errs map[string]error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	fetched = map[string]*Response{}
+	errs = map[string]error{}
+
+	uids := make(map[string]bool, len(certificates))
+	for _, certificate := range certificates {
+		if uids[certificate.UID] {
+			continue
+		}
+
+		cert, issuer, err := parseChain(certificate.PEM)
+		if err == nil && len(cert.OCSPServer) == 0 {
+			continue
+		}
+		uids[certificate.UID] = true
+
+		fingerprint := sha256.Sum256([]byte(certificate.PEM))
+		response, ok := s.responses[certificate.UID]
+		if ok && response.fingerprint == fingerprint && now.Before(response.refreshAt()) {
+			continue
+		}
+
+		if f, ok := s.failures[certificate.UID]; ok && f.fingerprint == fingerprint && now.Sub(f.at) < retryInterval {
+			continue
+		}
+
+		switch {
+		case err != nil:
+			err = &Error{Reason: ReasonCertificate, Err: err}
+		case issuer == nil:
+			err = &Error{Reason: ReasonCertificate, Err: errors.New("the chain of the certificate does not contain its issuer")}
+		default:
+			response, err = s.fetch(cert, issuer)
+		}
+		if err != nil {
+			errs[certificate.UID] = err
+			s.failures[certificate.UID] = failure{at: now, fingerprint: fingerprint}
+			if current, ok := s.responses[certificate.UID]; ok && (current.fingerprint != fingerprint || !now.Before(current.Expiry())) {
+				delete(s.responses, certificate.UID)
+			}
+			continue
+		}
+
+		response.fingerprint = fingerprint
+		delete(s.failures, certificate.UID)
+		s.responses[certificate.UID] = response
+		fetched[certificate.UID] = response
+	}
+
+	for uid := range s.responses {
+		if !uids[uid] {
+			delete(s.responses, uid)
+		}
+	}
+	for uid := range s.failures {
+		if !uids[uid] {
+			delete(s.failures, uid)
+		}
+	}
+
+	return fetched, errs
+}
+
+// Responses returns the OCSP responses by UID of certificate
+func (s *Stapler) Responses() map[string]*Response {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	responses := make(map[string]*Response, len(s.responses))
+	for uid, response := range s.responses {
+		responses[uid] = response
+	}
+
+	return responses
+}
+
+// fetch requests the OCSP response of a certificate to its responder, and
+// validates it
+func (s *Stapler) fetch(cert, issuer *x509.Certificate) (*Response, error) {
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, &Error{Reason: ReasonCertificate, Err: err}
+	}
+
+	httpResponse, err := s.client.Post(cert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(request))
+	if err != nil {
+		return nil, &Error{Reason: ReasonRequest, Err: err}
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, &Error{Reason: ReasonRequest, Err: fmt.Errorf("unexpected status code %v from the OCSP responder %v", httpResponse.StatusCode, cert.OCSPServer[0])}
+	}
+
+	raw, err := io.ReadAll(io.LimitReader(httpResponse.Body, maxResponseSize))
+	if err != nil {
+		return nil, &Error{Reason: ReasonRequest, Err: err}
+	}
+
+	response, err := ocsp.ParseResponseForCert(raw, cert, issuer)
+	if err != nil {
+		return nil, &Error{Reason: ReasonResponse, Err: err}
+	}
+
+	if response.Status != ocsp.Good {
+		return nil, &Error{Reason: ReasonStatus, Err: fmt.Errorf("the OCSP responder reports the status %v for the certificate %v", statusString(response.Status), cert.Subject)}
+	}
+
+	if !response.NextUpdate.IsZero() && !s.now().Before(response.NextUpdate) {
+		return nil, &Error{Reason: ReasonResponse, Err: fmt.Errorf("the OCSP response expired at %v", response.NextUpdate)}
+	}
+
+	return &Response{
+		Raw:        raw,
+		ThisUpdate: response.ThisUpdate,
+		NextUpdate: response.NextUpdate,
+	}, nil
+}
+
+// parseChain returns the certificate and its issuer, or nil, from the PEM of
+// a certificate followed by its chain
+func parseChain(data string) (cert, issuer *x509.Certificate, err error) {
+	certificates := []*x509.Certificate{}
+	rest := []byte(data)
+	for len(certificates) < 2 {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		c, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		certificates = append(certificates, c)
+	}
+
+	switch len(certificates) {
+	case 0:
+		return nil, nil, errors.New("no certificate found")
+	case 1:
+		return certificates[0], nil, nil
+	default:
+		return certificates[0], certificates[1], nil
+	}
+}
+
+func statusString(status int) string {
+	switch status {
+	case ocsp.Good:
+		return "good"
+	case ocsp.Revoked:
+		return "revoked"
+	default:
+		return "unknown"
+	}
+}
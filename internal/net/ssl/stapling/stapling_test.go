@@ -0,0 +1,234 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stapling
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+type responder struct {
+	issuer   *x509.Certificate
+	key      crypto.Signer
+	status   int
+	now      time.Time
+	requests int
+}
+
+func (r *responder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.requests++
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	request, err := ocsp.ParseRequest(body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	response, err := ocsp.CreateResponse(r.issuer, r.issuer, ocsp.Response{
+		Status:       r.status,
+		SerialNumber: request.SerialNumber,
+		ThisUpdate:   r.now,
+		NextUpdate:   r.now.Add(4 * 24 * time.Hour),
+		RevokedAt:    r.now,
+	}, r.key)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	_, _ = w.Write(response)
+}
+
+func newCertificate(t *testing.T, template, parent *x509.Certificate, parentKey crypto.Signer) (*x509.Certificate, crypto.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error generating a key: %v", err)
+	}
+
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, key.Public(), parentKey)
+	if err != nil {
+		t.Fatalf("unexpected error creating a certificate: %v", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("unexpected error parsing a certificate: %v", err)
+	}
+
+	return cert, key
+}
+
+func encodeChain(certificates ...*x509.Certificate) string {
+	data := []byte{}
+	for _, cert := range certificates {
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})...)
+	}
+	return string(data)
+}
+
+func newTestStapler(t *testing.T, status int) (s *Stapler, r *responder, chain func(serial int64, ocspServers ...string) string) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(365 * 24 * time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}, nil, nil)
+
+	r = &responder{issuer: ca, key: caKey, status: status, now: now}
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+
+	chain = func(serial int64, ocspServers ...string) string {
+		if ocspServers == nil {
+			ocspServers = []string{server.URL}
+		}
+
+		cert, _ := newCertificate(t, &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "example.com"},
+			DNSNames:     []string{"example.com"},
+			NotBefore:    now.Add(-time.Hour),
+			NotAfter:     now.Add(90 * 24 * time.Hour),
+			OCSPServer:   ocspServers,
+		}, ca, caKey)
+
+		return encodeChain(cert, ca)
+	}
+
+	s = NewStapler(time.Second)
+	s.now = func() time.Time { return now }
+
+	return s, r, chain
+}
+
+func TestRefresh(t *testing.T) {
+	s, r, chain := newTestStapler(t, ocsp.Good)
+	certificates := []Certificate{
+		{UID: "uid-1", Secret: "default/foo", PEM: chain(10)},
+		{UID: "uid-1", Secret: "default/foo", PEM: chain(10)},
+		{UID: "uid-2", Secret: "default/bar", PEM: chain(11, []string{}...)},
+	}
+
+	fetched, errs := s.Refresh(certificates)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if len(fetched) != 1 || fetched["uid-1"] == nil {
+		t.Fatalf("expected the response of uid-1 but returned %v", fetched)
+	}
+	if r.requests != 1 {
+		t.Errorf("expected 1 request to the OCSP responder but %v were sent", r.requests)
+	}
+
+	response, err := ocsp.ParseResponse(fetched["uid-1"].Raw, nil)
+	if err != nil || response.SerialNumber.Int64() != 10 {
+		t.Errorf("expected the response of the certificate 10 but returned %v, %v", response, err)
+	}
+
+	// the response is valid for 4 days and refreshed after 2 days
+	s.now = func() time.Time { return r.now.Add(24 * time.Hour) }
+	if fetched, _ := s.Refresh(certificates); len(fetched) != 0 || r.requests != 1 {
+		t.Errorf("expected the response to not be refreshed but returned %v after %v requests", fetched, r.requests)
+	}
+
+	s.now = func() time.Time { return r.now.Add(49 * time.Hour) }
+	if fetched, _ := s.Refresh(certificates); len(fetched) != 1 || r.requests != 2 {
+		t.Errorf("expected the response to be refreshed but returned %v after %v requests", fetched, r.requests)
+	}
+
+	// a renewed certificate is fetched immediately
+	certificates[0].PEM = chain(12)
+	if fetched, _ := s.Refresh(certificates[:1]); len(fetched) != 1 || r.requests != 3 {
+		t.Errorf("expected the response of the renewed certificate but returned %v after %v requests", fetched, r.requests)
+	}
+
+	if fetched, _ := s.Refresh(nil); len(fetched) != 0 || len(s.Responses()) != 0 {
+		t.Errorf("expected the responses of the removed certificates to be removed but returned %v", s.Responses())
+	}
+}
+
+func TestRefreshErrors(t *testing.T) {
+	s, r, chain := newTestStapler(t, ocsp.Revoked)
+	certificates := []Certificate{{UID: "uid-1", Secret: "default/foo", PEM: chain(10)}}
+
+	_, errs := s.Refresh(certificates)
+	var e *Error
+	if !errors.As(errs["uid-1"], &e) || e.Reason != ReasonStatus {
+		t.Errorf("expected an error with the reason %v but returned %v", ReasonStatus, errs)
+	}
+
+	// the failed requests are retried after retryInterval
+	if _, errs := s.Refresh(certificates); len(errs) != 0 || r.requests != 1 {
+		t.Errorf("expected no request before the retry interval but returned %v after %v requests", errs, r.requests)
+	}
+
+	r.status = ocsp.Good
+	s.now = func() time.Time { return r.now.Add(retryInterval) }
+	if fetched, errs := s.Refresh(certificates); len(errs) != 0 || len(fetched) != 1 {
+		t.Errorf("expected the response after the retry interval but returned %v, %v", fetched, errs)
+	}
+
+	// the chains without the issuer cannot be stapled
+	block, _ := pem.Decode([]byte(chain(11)))
+	certificates[0].PEM = string(pem.EncodeToMemory(block))
+	_, errs = s.Refresh(certificates)
+	if !errors.As(errs["uid-1"], &e) || e.Reason != ReasonCertificate {
+		t.Errorf("expected an error with the reason %v but returned %v", ReasonCertificate, errs)
+	}
+	if len(s.Responses()) != 0 {
+		t.Errorf("expected the response of the previous certificate to be removed")
+	}
+}
+
+func TestRefreshUnreachableResponder(t *testing.T) {
+	s, _, chain := newTestStapler(t, ocsp.Good)
+	certificates := []Certificate{{UID: "uid-1", Secret: "default/foo", PEM: chain(10, "http://127.0.0.1:1")}}
+
+	_, errs := s.Refresh(certificates)
+	var e *Error
+	if !errors.As(errs["uid-1"], &e) || e.Reason != ReasonRequest {
+		t.Errorf("expected an error with the reason %v but returned %v", ReasonRequest, errs)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Flavors of NGINX the controller can drive
+const (
+	// NGINXDataplane is the NGINX build shipped in the controller image
+	NGINXDataplane = "nginx"
+	// FreenginxDataplane is the freenginx fork of NGINX
+	FreenginxDataplane = "freenginx"
+	// OpenRestyDataplane is OpenResty based on NGINX 1.21
+	OpenRestyDataplane = "openresty"
+)
+
+// Dataplane describes the capabilities of a flavor of NGINX. The template uses
+// it to avoid the directives the flavor does not support.
+type Dataplane struct {
+	// Name of the flavor
+	Name string `json:"name"`
+	// Unsupported contains the directives not available in the flavor
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// Supports checks if a directive is available in the flavor.
+// A nil Dataplane supports all the directives.
+func (d *Dataplane) Supports(directive string) bool {
+	if d == nil {
+		return true
+	}
+
+	for _, unsupported := range d.Unsupported {
+		if unsupported == directive {
+			return false
+		}
+	}
+
+	return true
+}
+
+// obsoleteHTTP2Directives are the HTTP/2 directives removed from the recent
+// releases of NGINX, which only log a warning when they are used
+var obsoleteHTTP2Directives = []string{
+	"http2_max_field_size",
+	"http2_max_header_size",
+	"http2_max_requests",
+	"http2_push_preload",
+}
+
+// dataplanes contains the known flavors of NGINX
+var dataplanes = map[string]*Dataplane{
+	NGINXDataplane: {
+		Name: NGINXDataplane,
+	},
+	FreenginxDataplane: {
+		Name:        FreenginxDataplane,
+		Unsupported: obsoleteHTTP2Directives,
+	},
+	OpenRestyDataplane: {
+		Name: OpenRestyDataplane,
+		// the http2 directive was introduced in NGINX 1.25.1,
+		// HTTP/2 is enabled using a parameter of the listen directive
+		Unsupported: append([]string{"http2"}, obsoleteHTTP2Directives...),
+	},
+}
+
+// Dataplanes returns the sorted names of the known flavors of NGINX
+func Dataplanes() []string {
+	names := make([]string, 0, len(dataplanes))
+	for name := range dataplanes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// GetDataplane returns the description of a flavor of NGINX
+func GetDataplane(name string) (*Dataplane, error) {
+	dataplane, ok := dataplanes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown dataplane %q (valid values: %v)", name, strings.Join(Dataplanes(), ", "))
+	}
+
+	return dataplane, nil
+}
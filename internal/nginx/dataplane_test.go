@@ -0,0 +1,70 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"testing"
+)
+
+func TestGetDataplane(t *testing.T) {
+	for _, name := range Dataplanes() {
+		dataplane, err := GetDataplane(name)
+		if err != nil {
+			t.Fatalf("unexpected error getting dataplane %v: %v", name, err)
+		}
+
+		if dataplane.Name != name {
+			t.Errorf("expected dataplane %v but %v returned", name, dataplane.Name)
+		}
+	}
+
+	if _, err := GetDataplane("invalid"); err == nil {
+		t.Errorf("expected an error getting an unknown dataplane")
+	}
+}
+
+func TestDataplaneSupports(t *testing.T) {
+	testCases := []struct {
+		dataplane string
+		directive string
+		expected  bool
+	}{
+		{NGINXDataplane, "http2", true},
+		{NGINXDataplane, "http2_push_preload", true},
+		{FreenginxDataplane, "http2", true},
+		{FreenginxDataplane, "http2_push_preload", false},
+		{OpenRestyDataplane, "http2", false},
+		{OpenRestyDataplane, "http2_max_requests", false},
+		{OpenRestyDataplane, "ssl_reject_handshake", true},
+	}
+
+	for _, tc := range testCases {
+		dataplane, err := GetDataplane(tc.dataplane)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		if supported := dataplane.Supports(tc.directive); supported != tc.expected {
+			t.Errorf("expected %v for directive %v of dataplane %v but %v returned", tc.expected, tc.directive, tc.dataplane, supported)
+		}
+	}
+
+	var dataplane *Dataplane
+	if !dataplane.Supports("http2") {
+		t.Errorf("expected a nil dataplane to support all the directives")
+	}
+}
@@ -18,6 +18,7 @@ package nginx
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -54,6 +55,9 @@ var HealthPath = "/healthz"
 // HealthCheckTimeout defines the time limit in seconds for a probe to health-check-path to succeed
 var HealthCheckTimeout = 10 * time.Second
 
+// VersionTimeout defines the time limit to obtain the version of NGINX
+var VersionTimeout = 10 * time.Second
+
 // StatusPath defines the path used to expose the NGINX status page
 // http://nginx.org/en/docs/http/ngx_http_stub_status_module.html
 var StatusPath = "/nginx_status"
@@ -61,6 +65,20 @@ var StatusPath = "/nginx_status"
 // StreamPort defines the port used by NGINX for the NGINX stream configuration socket
 var StreamPort = 10247
 
+// ServerAliasSockets defines the UNIX sockets, for HTTP and HTTPS, used by the
+// default server to proxy the server aliases added without a reload and the
+// custom domains to the servers handling them
+var ServerAliasSockets = ServerAliasSocketPaths{
+	HTTP:  "/tmp/nginx/server-alias-http.sock",
+	HTTPS: "/tmp/nginx/server-alias-https.sock",
+}
+
+// ServerAliasSocketPaths contains the paths of the UNIX sockets of the server aliases
+type ServerAliasSocketPaths struct {
+	HTTP  string `json:"HTTP"`
+	HTTPS string `json:"HTTPS"`
+}
+
 // NewGetStatusRequest creates a new GET request to the internal NGINX status server
 func NewGetStatusRequest(path string) (statusCode int, data []byte, err error) {
 	url := fmt.Sprintf("http://127.0.0.1:%v%v", StatusPort, path)
@@ -151,7 +169,10 @@ func Version() string {
 		flag = "-V"
 	}
 
-	cmd := exec.Command("nginx", flag)
+	ctx, cancel := context.WithTimeout(context.Background(), VersionTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "nginx", flag)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		klog.ErrorS(err, "unexpected error obtaining NGINX version")
@@ -0,0 +1,86 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"path"
+	"slices"
+
+	klog "k8s.io/klog/v2"
+)
+
+// ModulesPath is the directory containing the dynamic modules of NGINX
+var ModulesPath = "/etc/nginx/modules"
+
+// Optional dynamic modules of NGINX. They are loaded only when a feature
+// requires them and can be disabled using the disable-modules setting.
+const (
+	BrotliModule        = "brotli"
+	GeoIP2Module        = "geoip2"
+	ModSecurityModule   = "modsecurity"
+	OpentelemetryModule = "opentelemetry"
+)
+
+// moduleFiles contains the files loaded by each optional module
+var moduleFiles = map[string][]string{
+	BrotliModule:        {"ngx_http_brotli_filter_module.so", "ngx_http_brotli_static_module.so"},
+	GeoIP2Module:        {"ngx_http_geoip2_module.so"},
+	ModSecurityModule:   {"ngx_http_modsecurity_module.so"},
+	OpentelemetryModule: {"otel_ngx_module.so"},
+}
+
+// IsOptionalModule checks if the name is an optional dynamic module
+func IsOptionalModule(name string) bool {
+	_, ok := moduleFiles[name]
+	return ok
+}
+
+// ModuleExists checks if the files of an optional module are present in the filesystem
+func ModuleExists(name string) bool {
+	files, ok := moduleFiles[name]
+	if !ok {
+		return false
+	}
+
+	for _, file := range files {
+		if !fileExists(path.Join(ModulesPath, file)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// UnavailableModules returns the sorted list of optional modules that are
+// disabled or not present in the filesystem
+func UnavailableModules(disabled []string) []string {
+	unavailable := []string{}
+	for name := range moduleFiles {
+		if slices.Contains(disabled, name) {
+			unavailable = append(unavailable, name)
+			continue
+		}
+
+		if !ModuleExists(name) {
+			klog.V(3).InfoS("NGINX module not found", "module", name, "path", ModulesPath)
+			unavailable = append(unavailable, name)
+		}
+	}
+
+	slices.Sort(unavailable)
+	return unavailable
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestUnavailableModules(t *testing.T) {
+	defer resetForTesting()
+
+	present := map[string]bool{
+		filepath.Join(ModulesPath, "ngx_http_brotli_filter_module.so"): true,
+		filepath.Join(ModulesPath, "ngx_http_brotli_static_module.so"): true,
+		filepath.Join(ModulesPath, "ngx_http_geoip2_module.so"):        true,
+		filepath.Join(ModulesPath, "otel_ngx_module.so"):               true,
+	}
+	fileExists = func(filePath string) bool {
+		return present[filePath]
+	}
+
+	tests := []struct {
+		name     string
+		disabled []string
+		want     []string
+	}{
+		{
+			name: "missing module",
+			want: []string{ModSecurityModule},
+		},
+		{
+			name:     "disabled modules",
+			disabled: []string{BrotliModule, OpentelemetryModule},
+			want:     []string{BrotliModule, ModSecurityModule, OpentelemetryModule},
+		},
+		{
+			name:     "unknown module",
+			disabled: []string{"unknown"},
+			want:     []string{ModSecurityModule},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnavailableModules(tt.disabled); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnavailableModules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !IsOptionalModule(GeoIP2Module) || IsOptionalModule("auth-digest") {
+		t.Errorf("unexpected optional modules")
+	}
+}
@@ -0,0 +1,295 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Optimization is an NGINX configuration with blocks of directives moved to
+// files included in their place
+type Optimization struct {
+	// Config is the optimized configuration
+	Config []byte
+	// SharedBlocks contains the content of the files included by the
+	// configuration, by file name
+	SharedBlocks map[string][]byte
+}
+
+// statement is a directive of a configuration with its arguments and block
+type statement struct {
+	name string
+	// args contains the arguments as written in the configuration
+	args []string
+	// start and end are the offsets of the statement in the configuration,
+	// from the directive to the semicolon or the brace closing the block
+	start int
+	end   int
+	block []*statement
+}
+
+// replacement replaces the text between two offsets of a configuration
+type replacement struct {
+	start int
+	end   int
+	text  string
+}
+
+// Optimize reduces the size of an NGINX configuration without changing its
+// meaning. The runs of directives of at least minSize bytes repeated in
+// several locations are moved to shared files in dir, included by the
+// locations in their place, and the names repeated in a server_name
+// directive are removed.
+func Optimize(cfg []byte, dir string, minSize int) (*Optimization, error) {
+	statements, err := parseConfiguration(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := [][]*statement{}
+	replacements := []replacement{}
+	walkStatements(statements, func(s *statement) {
+		switch s.name {
+		case "location":
+			// the locations with nested locations are not optimized to
+			// avoid overlapping replacements
+			if s.block != nil && !containsLocation(s.block) {
+				locations = append(locations, s.block)
+			}
+		case "server_name":
+			if names := uniqueNames(s.args); len(names) < len(s.args) {
+				replacements = append(replacements, replacement{
+					start: s.start,
+					end:   s.end,
+					text:  fmt.Sprintf("server_name %v;", strings.Join(names, " ")),
+				})
+			}
+		}
+	})
+
+	sharedBlocks := map[string][]byte{}
+	for _, run := range repeatedRuns(cfg, locations, minSize) {
+		sum := sha256.Sum256([]byte(run.text))
+		name := hex.EncodeToString(sum[:8]) + ".conf"
+		sharedBlocks[name] = []byte(run.text)
+
+		replacements = append(replacements, replacement{
+			start: run.start,
+			end:   run.end,
+			text:  fmt.Sprintf("include %v;", filepath.Join(dir, name)),
+		})
+	}
+
+	return &Optimization{
+		Config:       replace(cfg, replacements),
+		SharedBlocks: sharedBlocks,
+	}, nil
+}
+
+// parseConfiguration returns the statements of a configuration
+func parseConfiguration(cfg []byte) ([]*statement, error) {
+	tokens, err := newLexer(cfg).tokens()
+	if err != nil {
+		return nil, err
+	}
+
+	statements, pos, err := parseStatements(cfg, tokens, 0)
+	if err != nil {
+		return nil, err
+	}
+	if pos < len(tokens) {
+		return nil, &SyntaxError{Line: tokens[pos].line, Message: `unexpected "}"`}
+	}
+
+	return statements, nil
+}
+
+// replace returns cfg with the replacements applied
+func replace(cfg []byte, replacements []replacement) []byte {
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].start < replacements[j].start
+	})
+
+	var b strings.Builder
+	b.Grow(len(cfg))
+	last := 0
+	for _, r := range replacements {
+		b.Write(cfg[last:r.start])
+		b.WriteString(r.text)
+		last = r.end
+	}
+	b.Write(cfg[last:])
+
+	return []byte(b.String())
+}
+
+// parseStatements returns the statements of the tokens of cfg from pos until
+// the end of the block, and the position of the brace closing the block
+func parseStatements(cfg []byte, tokens []token, pos int) ([]*statement, int, error) {
+	statements := []*statement{}
+
+	for pos < len(tokens) && tokens[pos].kind != tokenClose {
+		if tokens[pos].kind != tokenWord {
+			return nil, pos, &SyntaxError{Line: tokens[pos].line, Message: "unexpected token"}
+		}
+
+		s := &statement{name: tokens[pos].value, start: tokens[pos].start}
+		pos++
+
+		for pos < len(tokens) && tokens[pos].kind == tokenWord {
+			s.args = append(s.args, string(cfg[tokens[pos].start:tokens[pos].end]))
+			pos++
+		}
+
+		if pos >= len(tokens) {
+			return nil, pos, &SyntaxError{Line: tokens[pos-1].line, Message: `unexpected end of file, expecting ";" or "}"`}
+		}
+
+		switch tokens[pos].kind {
+		case tokenSemicolon:
+			s.end = tokens[pos].end
+			pos++
+		case tokenOpen:
+			pos++
+			if pos < len(tokens) && tokens[pos].kind == tokenLua {
+				pos++
+			} else {
+				block, end, err := parseStatements(cfg, tokens, pos)
+				if err != nil {
+					return nil, end, err
+				}
+				s.block = block
+				pos = end
+			}
+
+			if pos >= len(tokens) {
+				return nil, pos, &SyntaxError{Line: tokens[pos-1].line, Message: fmt.Sprintf("unexpected end of file, expecting \"}\" closing the block of %q", s.name)}
+			}
+			s.end = tokens[pos].end
+			pos++
+		default:
+			return nil, pos, &SyntaxError{Line: tokens[pos].line, Message: fmt.Sprintf("directive %q is not terminated by \";\"", s.name)}
+		}
+
+		statements = append(statements, s)
+	}
+
+	return statements, pos, nil
+}
+
+// walkStatements calls fn for each statement, including the statements of
+// the blocks
+func walkStatements(statements []*statement, fn func(*statement)) {
+	for _, s := range statements {
+		fn(s)
+		walkStatements(s.block, fn)
+	}
+}
+
+func containsLocation(statements []*statement) bool {
+	found := false
+	walkStatements(statements, func(s *statement) {
+		if s.name == "location" {
+			found = true
+		}
+	})
+
+	return found
+}
+
+// uniqueNames returns the names without the repeated ones, in order
+func uniqueNames(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			unique = append(unique, name)
+		}
+	}
+
+	return unique
+}
+
+// run is a sequence of consecutive statements of a location
+type run struct {
+	start int
+	end   int
+	// text contains the statements of the run, one per line
+	text string
+}
+
+// repeatedRuns returns the maximal runs of statements present in several
+// locations, of at least minSize bytes, that are repeated in the locations.
+// The statements of the runs are kept in the same order, so including the
+// run in place of the statements does not change the configuration.
+func repeatedRuns(cfg []byte, locations [][]*statement, minSize int) []run {
+	text := func(s *statement) string {
+		return string(cfg[s.start:s.end])
+	}
+
+	// number of locations containing each statement
+	locationsWithStatement := map[string]int{}
+	for _, location := range locations {
+		seen := map[string]bool{}
+		for _, s := range location {
+			if t := text(s); !seen[t] {
+				seen[t] = true
+				locationsWithStatement[t]++
+			}
+		}
+	}
+
+	runs := []run{}
+	for _, location := range locations {
+		for i := 0; i < len(location); {
+			if locationsWithStatement[text(location[i])] < 2 {
+				i++
+				continue
+			}
+
+			j := i
+			var b strings.Builder
+			for ; j < len(location) && locationsWithStatement[text(location[j])] > 1; j++ {
+				b.WriteString(text(location[j]))
+				b.WriteString("\n")
+			}
+
+			runs = append(runs, run{start: location[i].start, end: location[j-1].end, text: b.String()})
+			i = j
+		}
+	}
+
+	occurrences := map[string]int{}
+	for _, r := range runs {
+		occurrences[r.text]++
+	}
+
+	repeated := []run{}
+	for _, r := range runs {
+		if occurrences[r.text] > 1 && len(r.text) >= minSize {
+			repeated = append(repeated, r)
+		}
+	}
+
+	return repeated
+}
@@ -0,0 +1,162 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// optimizedLocation returns a location of an Ingress with the directives
+// generated for every location
+func optimizedLocation(ingress string) string {
+	return fmt.Sprintf(`
+        location /%v/ {
+            set $namespace      "default";
+            set $ingress_name   %q;
+            set $service_name   "http-svc";
+
+            # the proxy settings are the same in all the locations
+            proxy_set_header Host              $best_http_host;
+            proxy_set_header X-Request-ID      $req_id;
+            proxy_set_header X-Forwarded-For   $remote_addr;
+            proxy_connect_timeout              5s;
+            proxy_send_timeout                 60s;
+            proxy_read_timeout                 60s;
+            if ($request_method = OPTIONS) {
+                return 204;
+            }
+
+            proxy_pass http://upstream_balancer;
+        }
+`, ingress, ingress)
+}
+
+// expandIncludes replaces the include directives of the shared blocks
+// with the content of the blocks
+func expandIncludes(t *testing.T, cfg string, optimization *Optimization) string {
+	for name, block := range optimization.SharedBlocks {
+		include := fmt.Sprintf("include %v;", filepath.Join("/etc/nginx/shared", name))
+		if !strings.Contains(cfg, include) {
+			t.Errorf("expected the configuration to include %v", name)
+		}
+		cfg = strings.ReplaceAll(cfg, include, string(block))
+	}
+
+	return cfg
+}
+
+// directives returns the tokens of a configuration, without the comments
+// and the layout
+func directives(t *testing.T, cfg string) []string {
+	tokens, err := newLexer([]byte(cfg)).tokens()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	values := make([]string, 0, len(tokens))
+	for _, tok := range tokens {
+		values = append(values, fmt.Sprintf("%v:%v", tok.kind, tok.value))
+	}
+
+	return values
+}
+
+func TestOptimize(t *testing.T) {
+	cfg := "http {\n    server {\n        server_name foo.com www.foo.com foo.com;\n" +
+		optimizedLocation("one") + optimizedLocation("two") + optimizedLocation("three") +
+		`
+        location /unique/ {
+            proxy_pass http://upstream_balancer;
+        }
+    }
+}
+`
+
+	optimization, err := Optimize([]byte(cfg), "/etc/nginx/shared", 128)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(optimization.SharedBlocks) != 1 {
+		t.Fatalf("expected one shared block but returned %v", len(optimization.SharedBlocks))
+	}
+	for _, block := range optimization.SharedBlocks {
+		if !strings.Contains(string(block), "proxy_set_header Host") || !strings.Contains(string(block), "return 204;") ||
+			strings.Contains(string(block), "$ingress_name") {
+			t.Errorf("unexpected shared block %q", block)
+		}
+	}
+
+	optimized := string(optimization.Config)
+	if len(optimized) >= len(cfg) {
+		t.Errorf("expected a smaller configuration but returned %v bytes instead of %v", len(optimized), len(cfg))
+	}
+	if err := CheckSyntax(optimization.Config); err != nil {
+		t.Errorf("unexpected error checking the optimized configuration: %v", err)
+	}
+	if !strings.Contains(optimized, "server_name foo.com www.foo.com;") {
+		t.Errorf("expected the repeated server names to be removed from %v", optimized)
+	}
+	if strings.Count(optimized, `set $ingress_name`) != 3 {
+		t.Errorf("expected the directives specific to each location to be kept in %v", optimized)
+	}
+
+	expected := directives(t, strings.Replace(cfg, "foo.com www.foo.com foo.com", "foo.com www.foo.com", 1))
+	expanded := directives(t, expandIncludes(t, optimized, optimization))
+	if strings.Join(expanded, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected the configuration with the shared blocks to be the original configuration\n%v", optimized)
+	}
+}
+
+func TestOptimizeSmallBlocks(t *testing.T) {
+	cfg := "http {\n    server {\n" + optimizedLocation("one") + optimizedLocation("two") + "    }\n}\n"
+
+	optimization, err := Optimize([]byte(cfg), "/etc/nginx/shared", 4096)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(optimization.SharedBlocks) != 0 || string(optimization.Config) != cfg {
+		t.Errorf("expected the configuration without the blocks smaller than the minimum size to be unchanged")
+	}
+}
+
+func TestOptimizeConfigurationFile(t *testing.T) {
+	cfg, err := os.ReadFile("../../rootfs/etc/nginx/nginx.conf")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	optimization, err := Optimize(cfg, "/etc/nginx/shared", 128)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := CheckSyntax(optimization.Config); err != nil {
+		t.Errorf("unexpected error checking the optimized configuration: %v", err)
+	}
+}
+
+func TestOptimizeInvalidConfiguration(t *testing.T) {
+	if _, err := Optimize([]byte("http { server { listen 80 }"), "/etc/nginx/shared", 128); err == nil {
+		t.Errorf("expected an error optimizing an invalid configuration")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxServerFileNameLength is the maximal length of the server name in
+	// the name of the file of a server
+	maxServerFileNameLength = 128
+
+	// defaultServerFileName names the files of the servers without a name
+	defaultServerFileName = "default"
+)
+
+var (
+	invalidServerFileNameChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+	serverFileNameRegex        = regexp.MustCompile(`^(.+)-[0-9a-f]{16}\.conf$`)
+)
+
+// SplitServers moves each server block of the http block of an NGINX
+// configuration to its own file in dir, included by the http block in
+// place of the server. The files are named after the first name of the
+// server and their content, so the servers not changed between two
+// configurations keep the same file and the files of a server can be
+// compared between two configurations.
+func SplitServers(cfg []byte, dir string) (*Optimization, error) {
+	statements, err := parseConfiguration(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	serverBlocks := map[string][]byte{}
+	replacements := []replacement{}
+	for _, s := range statements {
+		if s.name != "http" {
+			continue
+		}
+
+		for _, server := range s.block {
+			if server.name != "server" || server.block == nil {
+				continue
+			}
+
+			text := cfg[server.start:server.end]
+			sum := sha256.Sum256(text)
+			name := fmt.Sprintf("%v-%v.conf", serverFileName(server), hex.EncodeToString(sum[:8]))
+			serverBlocks[name] = append([]byte{}, text...)
+
+			replacements = append(replacements, replacement{
+				start: server.start,
+				end:   server.end,
+				text:  fmt.Sprintf("include %v;", filepath.Join(dir, name)),
+			})
+		}
+	}
+
+	return &Optimization{
+		Config:       replace(cfg, replacements),
+		SharedBlocks: serverBlocks,
+	}, nil
+}
+
+// ServerFileName returns the name of the server of a file written by
+// SplitServers, or an empty string when the file was not written by
+// SplitServers
+func ServerFileName(name string) string {
+	matches := serverFileNameRegex.FindStringSubmatch(name)
+	if matches == nil {
+		return ""
+	}
+	return matches[1]
+}
+
+// serverFileName returns the first name of a server, with the characters
+// not allowed in the name of a file replaced
+func serverFileName(server *statement) string {
+	for _, s := range server.block {
+		if s.name != "server_name" || len(s.args) == 0 {
+			continue
+		}
+
+		name := invalidServerFileNameChars.ReplaceAllString(strings.Trim(s.args[0], `"'`), "_")
+		if len(name) > maxServerFileNameLength {
+			name = name[:maxServerFileNameLength]
+		}
+		if name == "" {
+			break
+		}
+		return name
+	}
+
+	return defaultServerFileName
+}
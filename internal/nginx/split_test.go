@@ -0,0 +1,130 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSplitServers(t *testing.T) {
+	cfg := `events {
+    worker_connections 16384;
+}
+http {
+    include mime.types;
+
+    ## start server foo.com
+    server {
+        server_name foo.com;
+` + optimizedLocation("foo") + `    }
+    ## end server foo.com
+
+    ## start server bar.com
+    server {
+        server_name bar.com;
+` + optimizedLocation("bar") + `    }
+    ## end server bar.com
+}
+stream {
+    server {
+        listen 8443;
+    }
+}
+`
+
+	split, err := SplitServers([]byte(cfg), "/etc/nginx/servers")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(split.SharedBlocks) != 2 {
+		t.Fatalf("expected two server files but returned %v", len(split.SharedBlocks))
+	}
+
+	config := string(split.Config)
+	if err := CheckSyntax(split.Config); err != nil {
+		t.Errorf("unexpected error checking the split configuration: %v", err)
+	}
+	if strings.Contains(config, "server_name") || !strings.Contains(config, "listen 8443;") {
+		t.Errorf("expected only the servers of the http block to be split in %v", config)
+	}
+
+	expanded := config
+	servers := []string{}
+	for name, block := range split.SharedBlocks {
+		servers = append(servers, ServerFileName(name))
+		include := fmt.Sprintf("include %v;", filepath.Join("/etc/nginx/servers", name))
+		if !strings.Contains(expanded, include) {
+			t.Errorf("expected the configuration to include %v", name)
+		}
+		if err := CheckSyntax(block); err != nil {
+			t.Errorf("unexpected error checking the server file %v: %v", name, err)
+		}
+		expanded = strings.ReplaceAll(expanded, include, string(block))
+	}
+	if expanded != cfg {
+		t.Errorf("expected the configuration with the server files to be the original configuration\n%v", expanded)
+	}
+
+	sort.Strings(servers)
+	if expected := []string{"bar.com", "foo.com"}; !reflect.DeepEqual(servers, expected) {
+		t.Errorf("expected the server files of %v but returned %v", expected, servers)
+	}
+}
+
+func TestServerFileName(t *testing.T) {
+	testCases := map[string]struct {
+		serverName string
+		expected   string
+	}{
+		"hostname":          {"server_name foo.com www.foo.com;", "foo.com"},
+		"wildcard hostname": {"server_name *.foo.com;", "_.foo.com"},
+		"quoted hostname":   {`server_name "~^(?<sub>.+)\.foo\.com$";`, "_____sub_.___.foo_.com_"},
+		"catch-all server":  {"server_name _;", "_"},
+		"no server name":    {"", "default"},
+	}
+
+	for title, tc := range testCases {
+		t.Run(title, func(t *testing.T) {
+			split, err := SplitServers([]byte("http {\nserver {\n"+tc.serverName+"\nlisten 80;\n}\n}\n"), "/etc/nginx/servers")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			for name := range split.SharedBlocks {
+				if serverName := ServerFileName(name); serverName != tc.expected {
+					t.Errorf("expected the server %v but returned %v for the file %v", tc.expected, serverName, name)
+				}
+			}
+		})
+	}
+
+	if name := ServerFileName("0123456789abcdef.conf"); name != "" {
+		t.Errorf("expected no server for a file not written by SplitServers but returned %v", name)
+	}
+}
+
+func TestSplitServersInvalidConfiguration(t *testing.T) {
+	if _, err := SplitServers([]byte("http { server { listen 80 }"), "/etc/nginx/servers"); err == nil {
+		t.Errorf("expected an error splitting an invalid configuration")
+	}
+}
@@ -0,0 +1,446 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SyntaxError is an error in the syntax of an NGINX configuration
+type SyntaxError struct {
+	Line    int
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%v in line %v", e.Message, e.Line)
+}
+
+// blockDirectives contains the directives that always open a block
+var blockDirectives = map[string]bool{
+	"events":        true,
+	"geo":           true,
+	"http":          true,
+	"if":            true,
+	"limit_except":  true,
+	"location":      true,
+	"map":           true,
+	"split_clients": true,
+	"stream":        true,
+	"types":         true,
+	"upstream":      true,
+}
+
+// luaBlockSuffix is the suffix of the directives of lua-nginx-module
+// containing Lua code instead of directives
+const luaBlockSuffix = "_by_lua_block"
+
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenSemicolon
+	tokenOpen
+	tokenClose
+	tokenLua
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	line  int
+	// start and end are the offsets of the token in the configuration
+	start int
+	end   int
+}
+
+// CheckSyntax checks the syntax of an NGINX configuration without running
+// NGINX: the quotes, the termination of the directives and the balance of
+// the blocks, including the Lua code of the *_by_lua_block directives. The
+// files included by the configuration are not read. It is much faster than
+// "nginx -t", which is still required to check the directives and their
+// arguments.
+func CheckSyntax(cfg []byte) error {
+	tokens, err := newLexer(cfg).tokens()
+	if err != nil {
+		return err
+	}
+
+	p := &parser{tokens: tokens}
+	if err := p.block(""); err != nil {
+		return err
+	}
+
+	if p.pos < len(p.tokens) {
+		return &SyntaxError{Line: p.tokens[p.pos].line, Message: `unexpected "}"`}
+	}
+
+	return nil
+}
+
+// lexer splits an NGINX configuration in tokens like ngx_conf_read_token
+type lexer struct {
+	data []byte
+	pos  int
+	line int
+}
+
+func newLexer(data []byte) *lexer {
+	return &lexer{data: data, line: 1}
+}
+
+func (l *lexer) tokens() ([]token, error) {
+	tokens := []token{}
+	// name of the directive of the current statement
+	directive := ""
+	statementStart := true
+
+	for {
+		l.skipSpaces()
+		if l.pos >= len(l.data) {
+			return tokens, nil
+		}
+
+		c := l.data[l.pos]
+		switch c {
+		case '#':
+			l.skipLine()
+			continue
+		case ';':
+			tokens = append(tokens, token{kind: tokenSemicolon, line: l.line, start: l.pos, end: l.pos + 1})
+			l.pos++
+			statementStart = true
+			continue
+		case '{':
+			tokens = append(tokens, token{kind: tokenOpen, line: l.line, start: l.pos, end: l.pos + 1})
+			l.pos++
+			statementStart = true
+
+			if strings.HasSuffix(directive, luaBlockSuffix) {
+				line := l.line
+				start := l.pos
+				code, err := l.luaBlock()
+				if err != nil {
+					return nil, err
+				}
+				tokens = append(tokens, token{kind: tokenLua, value: code, line: line, start: start, end: l.pos})
+			}
+			continue
+		case '}':
+			tokens = append(tokens, token{kind: tokenClose, line: l.line, start: l.pos, end: l.pos + 1})
+			l.pos++
+			statementStart = true
+			continue
+		}
+
+		tok, err := l.word()
+		if err != nil {
+			return nil, err
+		}
+
+		if statementStart {
+			directive = tok.value
+			statementStart = false
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func (l *lexer) skipSpaces() {
+	for l.pos < len(l.data) {
+		switch l.data[l.pos] {
+		case '\n':
+			l.line++
+		case ' ', '\t', '\r':
+		default:
+			return
+		}
+		l.pos++
+	}
+}
+
+func (l *lexer) skipLine() {
+	for l.pos < len(l.data) && l.data[l.pos] != '\n' {
+		l.pos++
+	}
+}
+
+// word reads a quoted or unquoted argument
+func (l *lexer) word() (token, error) {
+	tok := token{kind: tokenWord, line: l.line, start: l.pos}
+	start := l.pos
+
+	if quote := l.data[l.pos]; quote == '"' || quote == '\'' {
+		l.pos++
+		for {
+			if l.pos >= len(l.data) {
+				return tok, &SyntaxError{Line: tok.line, Message: "unexpected end of file, unterminated string"}
+			}
+
+			c := l.data[l.pos]
+			l.pos++
+			switch c {
+			case '\\':
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.line++
+				}
+				l.pos++
+			case '\n':
+				l.line++
+			case quote:
+				tok.value = string(l.data[start+1 : l.pos-1])
+				tok.end = l.pos
+
+				if l.pos < len(l.data) && !isDelimiter(l.data[l.pos]) {
+					return tok, &SyntaxError{Line: l.line, Message: fmt.Sprintf("unexpected %q", l.data[l.pos])}
+				}
+				return tok, nil
+			}
+		}
+	}
+
+	// a brace after $ is part of a variable like ${name}
+	variable := false
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case c == '\\':
+			l.pos += 2
+			variable = false
+			continue
+		case c == '{' && variable:
+			l.pos++
+			variable = false
+			continue
+		case c == '$':
+			l.pos++
+			variable = true
+			continue
+		case isDelimiter(c):
+			tok.value = string(l.data[start:l.pos])
+			tok.end = l.pos
+			return tok, nil
+		}
+
+		variable = false
+		l.pos++
+	}
+
+	if l.pos > len(l.data) {
+		l.pos = len(l.data)
+	}
+	tok.value = string(l.data[start:l.pos])
+	tok.end = l.pos
+	return tok, nil
+}
+
+func isDelimiter(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', ';', '{':
+		return true
+	}
+
+	return false
+}
+
+// luaBlock reads the Lua code of a *_by_lua_block directive until the brace
+// closing the block, skipping the braces in the strings and comments
+func (l *lexer) luaBlock() (string, error) {
+	start := l.pos
+	line := l.line
+	depth := 1
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == '{':
+			depth++
+			l.pos++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return string(l.data[start:l.pos]), nil
+			}
+			l.pos++
+		case c == '"' || c == '\'':
+			if err := l.luaString(c); err != nil {
+				return "", err
+			}
+		case c == '[' && l.longBracketLevel() >= 0:
+			if err := l.luaLongBracket(); err != nil {
+				return "", err
+			}
+		case c == '-' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '-':
+			l.pos += 2
+			if l.pos < len(l.data) && l.data[l.pos] == '[' && l.longBracketLevel() >= 0 {
+				if err := l.luaLongBracket(); err != nil {
+					return "", err
+				}
+				continue
+			}
+			l.skipLine()
+		default:
+			l.pos++
+		}
+	}
+
+	return "", &SyntaxError{Line: line, Message: `unexpected end of file, expecting "}" closing the Lua block`}
+}
+
+func (l *lexer) luaString(quote byte) error {
+	line := l.line
+	l.pos++
+
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '\\':
+			if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+				l.line++
+			}
+			l.pos++
+		case '\n':
+			return &SyntaxError{Line: line, Message: "unterminated Lua string"}
+		case quote:
+			return nil
+		}
+	}
+
+	return &SyntaxError{Line: line, Message: "unterminated Lua string"}
+}
+
+// longBracketLevel returns the level of the Lua long bracket [[, [=[, ...
+// starting in the current position, or -1
+func (l *lexer) longBracketLevel() int {
+	level := 0
+	for i := l.pos + 1; i < len(l.data); i++ {
+		switch l.data[i] {
+		case '=':
+			level++
+		case '[':
+			return level
+		default:
+			return -1
+		}
+	}
+
+	return -1
+}
+
+func (l *lexer) luaLongBracket() error {
+	line := l.line
+	level := l.longBracketLevel()
+	closing := "]" + strings.Repeat("=", level) + "]"
+	l.pos += level + 2
+
+	end := strings.Index(string(l.data[l.pos:]), closing)
+	if end < 0 {
+		return &SyntaxError{Line: line, Message: "unterminated Lua long string or comment"}
+	}
+
+	l.line += strings.Count(string(l.data[l.pos:l.pos+end]), "\n")
+	l.pos += end + len(closing)
+	return nil
+}
+
+// parser checks the statements and blocks of the tokens of a configuration
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// block checks the statements until the end of the block of the directive
+// parent, or the end of the configuration for the main context
+func (p *parser) block(parent string) error {
+	for p.pos < len(p.tokens) {
+		tok := p.tokens[p.pos]
+
+		switch tok.kind {
+		case tokenClose:
+			return nil
+		case tokenSemicolon:
+			return &SyntaxError{Line: tok.line, Message: `unexpected ";"`}
+		case tokenOpen:
+			return &SyntaxError{Line: tok.line, Message: `unexpected "{"`}
+		}
+
+		if err := p.statement(parent); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// statement checks a directive with its arguments, terminated by a
+// semicolon or followed by a block
+func (p *parser) statement(parent string) error {
+	name := p.tokens[p.pos].value
+	line := p.tokens[p.pos].line
+	p.pos++
+
+	for p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenWord {
+		p.pos++
+	}
+
+	if p.pos >= len(p.tokens) {
+		return &SyntaxError{Line: line, Message: `unexpected end of file, expecting ";" or "}"`}
+	}
+
+	tok := p.tokens[p.pos]
+	switch tok.kind {
+	case tokenSemicolon:
+		if requiresBlock(name, parent) {
+			return &SyntaxError{Line: tok.line, Message: fmt.Sprintf("directive %q has no opening \"{\"", name)}
+		}
+		p.pos++
+		return nil
+	case tokenOpen:
+		p.pos++
+
+		if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenLua {
+			p.pos++
+		} else if err := p.block(name); err != nil {
+			return err
+		}
+
+		if p.pos >= len(p.tokens) {
+			return &SyntaxError{Line: line, Message: fmt.Sprintf("unexpected end of file, expecting \"}\" closing the block of %q", name)}
+		}
+		// closing brace
+		p.pos++
+		return nil
+	default:
+		return &SyntaxError{Line: tok.line, Message: fmt.Sprintf("directive %q is not terminated by \";\"", name)}
+	}
+}
+
+// requiresBlock checks if a directive requires a block in the context of
+// the directive parent
+func requiresBlock(name, parent string) bool {
+	if name == "server" {
+		// the servers of the upstreams are simple directives
+		return parent == "http" || parent == "stream"
+	}
+
+	return blockDirectives[name]
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nginx
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCheckSyntax(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		// line of the error, 0 when the configuration is valid
+		line int
+	}{
+		{
+			name: "valid configuration",
+			cfg: `
+# comment with { and ;
+events { worker_connections 1024; }
+http {
+    log_format upstreaminfo '$remote_addr - "$request" {$status}';
+    map $http_upgrade $connection_upgrade {
+        default upgrade;
+        ''      close;
+    }
+    upstream backend {
+        server 127.0.0.1:8080 max_fails=0;
+    }
+    server {
+        listen 80;
+        server_name "~^(?<name>\w+)\.foo\.com$";
+        set $proxy_upstream_name "-";
+        location ~* "^/api/v[0-9]{1,2}/" {
+            add_header X-Var ${proxy_upstream_name}suffix;
+            if ($request_method = OPTIONS) {
+                return 204;
+            }
+            proxy_pass http://backend;
+        }
+    }
+}
+`,
+		},
+		{
+			name: "lua block",
+			cfg: `
+http {
+    init_by_lua_block {
+        -- comment with } and {
+        local t = { a = "}", b = '{', c = [[ } ]], d = [==[ ]] } ]==] }
+        --[[ multi-line
+             comment } ]]
+        if t.a ~= "}" then error("unexpected") end
+    }
+}
+`,
+		},
+		{
+			name: "missing semicolon",
+			cfg:  "http {\n    sendfile on\n}\n",
+			line: 3,
+		},
+		{
+			name: "unbalanced closing brace",
+			cfg:  "http {\n}\n}\n",
+			line: 3,
+		},
+		{
+			name: "unclosed block",
+			cfg:  "http {\n    server {\n        listen 80;\n    }\n",
+			line: 1,
+		},
+		{
+			name: "unterminated string",
+			cfg:  "http {\n    add_header X-Foo \"bar;\n}\n",
+			line: 2,
+		},
+		{
+			name: "block directive without block",
+			cfg:  "http {\n    server;\n}\n",
+			line: 2,
+		},
+		{
+			name: "unexpected character after quote",
+			cfg:  "http {\n    add_header X-Foo \"bar\"baz;\n}\n",
+			line: 2,
+		},
+		{
+			name: "unclosed lua block",
+			cfg:  "http {\n    init_by_lua_block {\n        local s = \"}\"\n",
+			line: 2,
+		},
+		{
+			name: "unterminated lua string",
+			cfg:  "http {\n    init_by_lua_block {\n        local s = \"}\n    }\n}\n",
+			line: 3,
+		},
+		{
+			name: "block without directive",
+			cfg:  "http {\n    {\n    }\n}\n",
+			line: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckSyntax([]byte(tt.cfg))
+			if tt.line == 0 {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("expected a syntax error but returned %v", err)
+			}
+			if syntaxErr.Line != tt.line {
+				t.Errorf("expected an error in line %v but returned %v", tt.line, err)
+			}
+		})
+	}
+}
+
+func TestCheckSyntaxConfigurationFiles(t *testing.T) {
+	for _, file := range []string{
+		"../../rootfs/etc/nginx/nginx.conf",
+		"../../images/e2e-test-echo/rootfs/nginx.conf",
+	} {
+		cfg, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("unexpected error reading %v: %v", file, err)
+		}
+
+		if err := CheckSyntax(cfg); err != nil {
+			t.Errorf("unexpected error checking %v: %v", file, err)
+		}
+	}
+}
@@ -17,7 +17,10 @@ limitations under the License.
 package task
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -30,6 +33,11 @@ import (
 
 var keyFunc = cache.DeletionHandlingMetaNamespaceKeyFunc
 
+// maxSupersededSyncs is the maximum number of consecutive syncs canceled
+// because a newer element was enqueued. It avoids starving the sync when
+// elements are continuously enqueued.
+const maxSupersededSyncs = 3
+
 // Queue manages a time work queue through an independent worker that invokes the
 // given sync function for every work item inserted.
 // The queue uses an internal timestamp that allows the removal of certain elements
@@ -38,7 +46,16 @@ type Queue struct {
 	// queue is the work queue the worker polls
 	queue workqueue.TypedRateLimitingInterface[any]
 	// sync is called for each item in the queue
-	sync func(interface{}) error
+	sync func(context.Context, interface{}) error
+	// ctx is canceled when the queue shuts down
+	ctx    context.Context
+	cancel context.CancelFunc
+	// mu protects cancelSync and superseded
+	mu sync.Mutex
+	// cancelSync cancels the context of the sync in progress
+	cancelSync context.CancelFunc
+	// superseded is the number of consecutive syncs canceled by newer elements
+	superseded int
 	// workerDone is closed when the worker exits
 	workerDone chan bool
 	// fn makes a key for an API object
@@ -52,6 +69,9 @@ type Element struct {
 	Key         interface{}
 	Timestamp   int64
 	IsSkippable bool
+	// IsPartial indicates the sync of the element only covers the object
+	// it references and not the complete state of the cluster
+	IsPartial bool
 }
 
 // Run starts processing elements in the queue
@@ -61,17 +81,24 @@ func (t *Queue) Run(period time.Duration, stopCh <-chan struct{}) {
 
 // EnqueueTask enqueues ns/name of the given api object in the task queue.
 func (t *Queue) EnqueueTask(obj interface{}) {
-	t.enqueue(obj, false)
+	t.enqueue(obj, false, false)
 }
 
 // EnqueueSkippableTask enqueues ns/name of the given api object in
 // the task queue that can be skipped
 func (t *Queue) EnqueueSkippableTask(obj interface{}) {
-	t.enqueue(obj, true)
+	t.enqueue(obj, true, false)
+}
+
+// EnqueuePartialTask enqueues ns/name of the given api object in the task
+// queue. The sync of a partial task only covers the given object, which
+// means older tasks are not skipped after it is processed.
+func (t *Queue) EnqueuePartialTask(obj interface{}) {
+	t.enqueue(obj, true, true)
 }
 
 // enqueue enqueues ns/name of the given api object in the task queue.
-func (t *Queue) enqueue(obj interface{}, skippable bool) {
+func (t *Queue) enqueue(obj interface{}, skippable, partial bool) {
 	if t.IsShuttingDown() {
 		klog.ErrorS(nil, "queue has been shutdown, failed to enqueue", "key", obj)
 		return
@@ -91,7 +118,29 @@ func (t *Queue) enqueue(obj interface{}, skippable bool) {
 	t.queue.Add(Element{
 		Key:       key,
 		Timestamp: ts,
+		IsPartial: partial,
 	})
+
+	// a partial element does not cover the state synced by the element in
+	// progress, so only complete elements supersede it
+	if !partial {
+		t.supersede()
+	}
+}
+
+// supersede cancels the sync in progress, which will be followed by the sync
+// of a newer element.
+func (t *Queue) supersede() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.cancelSync == nil || t.superseded >= maxSupersededSyncs {
+		return
+	}
+
+	klog.V(2).InfoS("canceling sync in progress (superseded by a newer element)")
+	t.cancelSync()
+	t.cancelSync = nil
 }
 
 func (t *Queue) defaultKeyFunc(obj interface{}) (interface{}, error) {
@@ -127,21 +176,53 @@ func (t *Queue) worker() {
 		}
 
 		klog.V(3).InfoS("syncing", "key", item.Key)
-		if err := t.sync(key); err != nil {
+		err := t.syncElement(key)
+		switch {
+		case errors.Is(err, context.Canceled):
+			// the element is superseded by a newer one or the queue is shutting down
+			klog.V(2).InfoS("sync canceled", "key", item.Key)
+			t.queue.Forget(key)
+		case err != nil:
 			klog.ErrorS(err, "requeuing", "key", item.Key)
 			t.queue.AddRateLimited(Element{
 				Key:       item.Key,
 				Timestamp: 0,
 			})
-		} else {
+		default:
 			t.queue.Forget(key)
-			t.lastSync = ts
+			if !item.IsPartial {
+				t.lastSync = ts
+			}
 		}
 
 		t.queue.Done(key)
 	}
 }
 
+// syncElement invokes sync with a context canceled when the element is
+// superseded or the queue shuts down
+func (t *Queue) syncElement(key interface{}) error {
+	ctx, cancel := context.WithCancel(t.ctx)
+	defer cancel()
+
+	t.mu.Lock()
+	t.cancelSync = cancel
+	t.mu.Unlock()
+
+	err := t.sync(ctx, key)
+
+	t.mu.Lock()
+	t.cancelSync = nil
+	if errors.Is(err, context.Canceled) {
+		t.superseded++
+	} else {
+		t.superseded = 0
+	}
+	t.mu.Unlock()
+
+	return err
+}
+
 func isClosed(ch <-chan bool) bool {
 	select {
 	case <-ch:
@@ -154,6 +235,7 @@ func isClosed(ch <-chan bool) bool {
 
 // Shutdown shuts down the work queue and waits for the worker to ACK
 func (t *Queue) Shutdown() {
+	t.cancel()
 	t.queue.ShutDown()
 	<-t.workerDone
 }
@@ -169,11 +251,28 @@ func NewTaskQueue(syncFn func(interface{}) error) *Queue {
 	return NewCustomTaskQueue(syncFn, nil)
 }
 
+// NewContextTaskQueue creates a new task queue with the given sync function.
+// The context passed to the sync function is canceled when a newer element
+// supersedes the element in progress or when the queue shuts down.
+func NewContextTaskQueue(syncFn func(context.Context, interface{}) error) *Queue {
+	return newQueue(syncFn, nil)
+}
+
 // NewCustomTaskQueue creates a new custom task queue with the given sync function.
 func NewCustomTaskQueue(syncFn func(interface{}) error, fn func(interface{}) (interface{}, error)) *Queue {
+	return newQueue(func(_ context.Context, obj interface{}) error {
+		return syncFn(obj)
+	}, fn)
+}
+
+func newQueue(syncFn func(context.Context, interface{}) error, fn func(interface{}) (interface{}, error)) *Queue {
+	ctx, cancel := context.WithCancel(context.Background())
+
 	q := &Queue{
 		queue:      workqueue.NewTypedRateLimitingQueue(workqueue.DefaultTypedControllerRateLimiter[any]()),
 		sync:       syncFn,
+		ctx:        ctx,
+		cancel:     cancel,
 		workerDone: make(chan bool),
 		fn:         fn,
 	}
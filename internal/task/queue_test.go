@@ -17,6 +17,7 @@ limitations under the License.
 package task
 
 import (
+	"context"
 	"fmt"
 	"sync/atomic"
 	"testing"
@@ -157,3 +158,103 @@ func TestSkipEnqueue(t *testing.T) {
 	// shutdown queue before exit
 	q.Shutdown()
 }
+
+func TestPartialEnqueue(t *testing.T) {
+	// initialize result
+	atomic.StoreUint32(&sr, 0)
+	q := NewCustomTaskQueue(mockSynFn, mockKeyFn)
+	stopCh := make(chan struct{})
+	// mock object which will be enqueue
+	mo := mockEnqueueObj{
+		k: "testKey",
+		v: "testValue",
+	}
+	q.EnqueuePartialTask(mo)
+	q.EnqueueSkippableTask(mo)
+	q.EnqueueSkippableTask(mo)
+	// run queue
+	go q.Run(time.Second, stopCh)
+	// wait for 'mockSynFn'
+	time.Sleep(time.Millisecond * 10)
+	// the partial task must not skip the first skippable task
+	if atomic.LoadUint32(&sr) != 2 {
+		t.Errorf("sr should be 2, but is %d", sr)
+	}
+
+	// shutdown queue before exit
+	q.Shutdown()
+}
+
+func TestSupersedeSync(t *testing.T) {
+	var canceled, completed uint32
+	started := make(chan struct{}, 1)
+
+	q := NewContextTaskQueue(func(ctx context.Context, _ interface{}) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			atomic.AddUint32(&canceled, 1)
+			return ctx.Err()
+		case <-time.After(100 * time.Millisecond):
+			atomic.AddUint32(&completed, 1)
+			return nil
+		}
+	})
+	stopCh := make(chan struct{})
+	// run queue
+	go q.Run(time.Second, stopCh)
+
+	q.EnqueueSkippableTask(GetDummyObject("sync"))
+	<-started
+
+	// a partial task does not supersede the sync in progress
+	q.EnqueuePartialTask(GetDummyObject("partial"))
+	// a newer task supersedes the sync in progress
+	q.EnqueueSkippableTask(GetDummyObject("newer"))
+
+	// wait for the sync of the partial and the newer tasks
+	time.Sleep(time.Millisecond * 500)
+	if atomic.LoadUint32(&canceled) != 1 {
+		t.Errorf("canceled should be 1, but is %d", canceled)
+	}
+	if atomic.LoadUint32(&completed) != 2 {
+		t.Errorf("completed should be 2, but is %d", completed)
+	}
+
+	// shutdown queue before exit
+	q.Shutdown()
+}
+
+func TestSupersedeSyncLimit(t *testing.T) {
+	var completed uint32
+
+	q := NewContextTaskQueue(func(ctx context.Context, _ interface{}) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+			atomic.AddUint32(&completed, 1)
+			return nil
+		}
+	})
+	stopCh := make(chan struct{})
+	// run queue
+	go q.Run(time.Second, stopCh)
+
+	// continuously enqueue tasks superseding the sync in progress
+	for i := 0; i < 40; i++ {
+		q.EnqueueSkippableTask(GetDummyObject(fmt.Sprintf("sync-%d", i)))
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if atomic.LoadUint32(&completed) == 0 {
+		t.Errorf("some syncs should be completed while tasks are enqueued")
+	}
+
+	// shutdown queue before exit
+	q.Shutdown()
+}
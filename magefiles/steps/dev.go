@@ -0,0 +1,231 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package steps
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/magefile/mage/mg"
+	"github.com/magefile/mage/sh"
+
+	utils "k8s.io/ingress-nginx/magefiles/utils"
+)
+
+type Dev mg.Namespace
+
+const (
+	devClusterName = "ingress-nginx-dev"
+	devNamespace   = "ingress-nginx"
+	devRelease     = "ingress-nginx"
+	devDeployment  = "ingress-nginx-controller"
+	devTag         = "1.0.0-dev"
+	devKindConfig  = "build/kind.yaml"
+	devChartPath   = "charts/ingress-nginx"
+	devK8sVersion  = "v1.29.2@sha256:51a1434a5397193442f0be2a297b488b6c919ce8a3931be0ce822606ea5ca245"
+
+	// devWatchInterval is the interval between the scans of the sources
+	devWatchInterval = 2 * time.Second
+)
+
+// devWatchedDirs are the directories of the sources built in the image of the controller
+var devWatchedDirs = []string{"cmd", "internal", "pkg", "rootfs/etc/nginx"}
+
+// devWatchedExtensions are the extensions of the sources built in the image of the controller
+var devWatchedExtensions = []string{".go", ".tmpl", ".lua"}
+
+// Up creates a kind cluster running the controller built from the local sources,
+// then rebuilds and restarts the controller whenever the sources change
+func (Dev) Up() error {
+	for _, tool := range []string{"docker", "kind", "kubectl", "helm"} {
+		if _, err := exec.LookPath(tool); err != nil {
+			return fmt.Errorf("%s is required by the development environment: %w", tool, err)
+		}
+	}
+
+	if err := devBuildAndLoad(); err != nil {
+		return err
+	}
+
+	if err := devDeploy(); err != nil {
+		return err
+	}
+
+	utils.Info("Kubernetes cluster ready and ingress-nginx listening in localhost using ports 80 and 443")
+	utils.Info("Watching %s for changes, to delete the cluster run 'mage dev:down'", strings.Join(devWatchedDirs, ", "))
+
+	return devWatch()
+}
+
+// Down deletes the kind cluster of the development environment
+func (Dev) Down() error {
+	return sh.RunV("kind", "delete", "cluster", "--name", devClusterName)
+}
+
+// devImage returns the image of the controller built for the development environment
+func devImage() string {
+	return fmt.Sprintf("%s/controller:%s", devRegistry(), devTag)
+}
+
+func devRegistry() string {
+	if registry := os.Getenv("REGISTRY"); registry != "" {
+		return registry
+	}
+	return "ingress-controller"
+}
+
+// devBuildAndLoad builds the image of the controller and loads it in the kind cluster,
+// creating the cluster when it does not exist
+func devBuildAndLoad() error {
+	utils.Info("Building the image %s", devImage())
+	err := sh.RunWithV(map[string]string{"TAG": devTag, "REGISTRY": devRegistry()}, "make", "build", "image")
+	if err != nil {
+		return fmt.Errorf("could not build the image: %w", err)
+	}
+
+	clusters, err := sh.Output("kind", "get", "clusters", "-q")
+	if err != nil {
+		return fmt.Errorf("could not list the kind clusters: %w", err)
+	}
+
+	if !strings.Contains(clusters, devClusterName) {
+		utils.Info("Creating the Kubernetes cluster %s with kind", devClusterName)
+		k8sVersion := os.Getenv("K8S_VERSION")
+		if k8sVersion == "" {
+			k8sVersion = devK8sVersion
+		}
+
+		err := sh.RunV("kind", "create", "cluster",
+			"--name", devClusterName,
+			"--image", "kindest/node:"+k8sVersion,
+			"--config", devKindConfig)
+		if err != nil {
+			return fmt.Errorf("could not create the kind cluster: %w", err)
+		}
+	}
+
+	utils.Info("Loading the image %s in the cluster", devImage())
+	return sh.RunV("kind", "load", "docker-image", "--name", devClusterName, devImage())
+}
+
+// devDeploy installs or upgrades the chart with the image of the development environment
+func devDeploy() error {
+	utils.Info("Deploying the NGINX Ingress controller")
+	return sh.RunV("helm", "upgrade", "--install", devRelease, devChartPath,
+		"--kube-context", "kind-"+devClusterName,
+		"--namespace", devNamespace,
+		"--create-namespace",
+		"--set", "controller.image.repository="+devRegistry()+"/controller",
+		"--set", "controller.image.tag="+devTag,
+		"--set", "controller.image.digest=",
+		"--set-string", "controller.config.worker-processes=1",
+		"--set", "controller.updateStrategy.type=RollingUpdate",
+		"--set", "controller.updateStrategy.rollingUpdate.maxUnavailable=1",
+		"--set", "controller.hostPort.enabled=true",
+		"--set", "controller.terminationGracePeriodSeconds=0",
+		"--set", "controller.service.type=NodePort")
+}
+
+// devRestart restarts the controller to run the image loaded in the cluster
+func devRestart() error {
+	utils.Info("Restarting the NGINX Ingress controller")
+	err := sh.RunV("kubectl", "--context", "kind-"+devClusterName, "--namespace", devNamespace,
+		"rollout", "restart", "deployment/"+devDeployment)
+	if err != nil {
+		return err
+	}
+
+	return sh.RunV("kubectl", "--context", "kind-"+devClusterName, "--namespace", devNamespace,
+		"rollout", "status", "deployment/"+devDeployment)
+}
+
+// devWatch scans the sources and rebuilds and restarts the controller when one of them changed
+func devWatch() error {
+	last, err := devLastModification()
+	if err != nil {
+		return err
+	}
+
+	for {
+		time.Sleep(devWatchInterval)
+
+		modified, err := devLastModification()
+		if err != nil {
+			return err
+		}
+		if !modified.After(last) {
+			continue
+		}
+		last = modified
+
+		// a failed build is reported and waits for the next change, the developer is editing
+		if err := devBuildAndLoad(); err != nil {
+			utils.Warning("Could not rebuild the controller: %v", err)
+			continue
+		}
+		if err := devRestart(); err != nil {
+			utils.Warning("Could not restart the controller: %v", err)
+		}
+	}
+}
+
+// devLastModification returns the last modification time of the watched sources
+func devLastModification() (time.Time, error) {
+	var last time.Time
+	for _, dir := range devWatchedDirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !devWatched(path) {
+				return nil
+			}
+
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.ModTime().After(last) {
+				last = info.ModTime()
+			}
+			return nil
+		})
+		if err != nil {
+			return last, fmt.Errorf("could not scan the sources of %s: %w", dir, err)
+		}
+	}
+
+	return last, nil
+}
+
+func devWatched(path string) bool {
+	if strings.HasSuffix(path, "_test.go") {
+		return false
+	}
+
+	for _, ext := range devWatchedExtensions {
+		if filepath.Ext(path) == ext {
+			return true
+		}
+	}
+	return false
+}
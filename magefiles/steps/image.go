@@ -0,0 +1,105 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package steps
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/magefile/mage/mg"
+	"github.com/magefile/mage/sh"
+
+	utils "k8s.io/ingress-nginx/magefiles/utils"
+)
+
+type Image mg.Namespace
+
+// imageArchs are the architectures of the multi-arch images of the controller
+var imageArchs = []string{"amd64", "arm64", "s390x"}
+
+// controllerImages are the names of the images of the controller and their Dockerfile
+var controllerImages = map[string]string{
+	"controller":        "rootfs/Dockerfile",
+	"controller-chroot": "rootfs/Dockerfile-chroot",
+}
+
+// EnsureBuildx configures a buildx builder able to build the images of all the architectures
+func (Image) EnsureBuildx() error {
+	return sh.RunV("./hack/init-buildx.sh")
+}
+
+// MultiArch builds the binaries and the images of the controller for amd64, arm64
+// and s390x, and pushes the images with a manifest list to the registry in one step
+func (Image) MultiArch(registry, tag string) error {
+	if registry == "" || tag == "" {
+		return fmt.Errorf("the registry and the tag of the images are required")
+	}
+
+	mg.Deps(Image.EnsureBuildx)
+
+	commit, err := git("rev-parse", "--short", "HEAD")
+	if err != nil {
+		return fmt.Errorf("could not read the commit: %w", err)
+	}
+
+	baseImage, err := os.ReadFile("NGINX_BASE")
+	if err != nil {
+		return fmt.Errorf("could not read the NGINX base image: %w", err)
+	}
+
+	buildID := os.Getenv("BUILD_ID")
+	if buildID == "" {
+		buildID = "UNSET"
+	}
+
+	for _, arch := range imageArchs {
+		utils.Info("Building the binaries for %s", arch)
+		err := sh.RunWithV(map[string]string{"ARCH": arch, "TAG": tag, "REGISTRY": registry}, "make", "build")
+		if err != nil {
+			return fmt.Errorf("could not build the binaries for %s: %w", arch, err)
+		}
+	}
+
+	platforms := make([]string, 0, len(imageArchs))
+	for _, arch := range imageArchs {
+		platforms = append(platforms, "linux/"+arch)
+	}
+
+	for name, dockerfile := range controllerImages {
+		image := fmt.Sprintf("%s/%s:%s", registry, name, tag)
+		utils.Info("Building and pushing %s for %s", image, strings.Join(platforms, ","))
+		err := sh.RunV("docker", "buildx", "build",
+			"--no-cache",
+			"--push",
+			"--pull",
+			"--progress", "plain",
+			"--platform", strings.Join(platforms, ","),
+			"--build-arg", "BASE_IMAGE="+strings.TrimSpace(string(baseImage)),
+			"--build-arg", "VERSION="+tag,
+			"--build-arg", "COMMIT_SHA=git-"+commit,
+			"--build-arg", "BUILD_ID="+buildID,
+			"-t", image,
+			"-f", dockerfile,
+			"rootfs")
+		if err != nil {
+			return fmt.Errorf("could not build the image %s: %w", image, err)
+		}
+	}
+
+	return nil
+}
@@ -61,6 +61,17 @@ type SSLCert struct {
 	// Pem encoded certificate and key concatenated
 	PemCertKey string `json:"pemCertKey,omitempty"`
 
+	// AlternatePemCertKey contains the alternate certificate and key of the
+	// Secret, using a different key type, concatenated and PEM encoded
+	AlternatePemCertKey string `json:"alternatePemCertKey,omitempty"`
+
+	// AlternatePemFileName contains the path to the file with the alternate
+	// certificate and key concatenated. Only used by the default certificate
+	AlternatePemFileName string `json:"alternatePemFileName,omitempty"`
+
+	// AlternatePemSHA contains the sha1 of the alternate certificate
+	AlternatePemSHA string `json:"alternatePemSha,omitempty"`
+
 	// UID unique identifier of the Kubernetes Secret
 	UID string `json:"uid"`
 }
@@ -83,7 +94,7 @@ func (s *SSLCert) Identifier() string {
 // HashInclude defines if a field should be used or not to calculate the hash
 func (s *SSLCert) HashInclude(field string, _ interface{}) (bool, error) {
 	switch field {
-	case "PemSHA", "CASHA", "ExpireTime":
+	case "PemSHA", "AlternatePemSHA", "CASHA", "ExpireTime":
 		return true, nil
 	default:
 		return false, nil
@@ -23,23 +23,38 @@ import (
 
 	"k8s.io/ingress-nginx/internal/ingress/annotations"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authoidc"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authreq"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/authtls"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/backupservice"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/bodyinspection"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/conditionalheader"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/connection"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/cors"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/csp"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/customheaders"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/errorpage"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/extrasecrets"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/fastcgi"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/hsts"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipallowlist"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ipdenylist"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/largefiledelivery"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/log"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/luakv"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/mirror"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/modsecurity"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/opentelemetry"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/precompressed"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxy"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/proxycache"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/proxyssl"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/ratelimit"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/redirect"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/rewrite"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/splithorizon"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/upstreamproxyprotocol"
+	"k8s.io/ingress-nginx/internal/ingress/expression"
 )
 
 // TODO: The API shouldn't be importing structs from annotation code. Instead we probably want a conversion from internal
@@ -63,10 +78,19 @@ type Configuration struct {
 	// It contains information about the associated Server Name Indication (SNI).
 	// +optional
 	PassthroughBackends []*SSLPassthroughBackend `json:"passthroughBackends,omitempty"`
+	// CustomDomains contains the domains served by an existing server
+	// without a server block of their own.
+	// +optional
+	CustomDomains []CustomDomain `json:"customDomains,omitempty"`
 
 	// BackendConfigChecksum contains the particular checksum of a Configuration object
 	BackendConfigChecksum string `json:"BackendConfigChecksum,omitempty"`
 
+	// ErrorLogLevel replaces the error-log-level of the ConfigMap while
+	// the log levels are changed at runtime
+	// +optional
+	ErrorLogLevel string `json:"errorLogLevel,omitempty"`
+
 	// ConfigurationChecksum contains the particular checksum of a Configuration object
 	ConfigurationChecksum string `json:"configurationChecksum,omitempty"`
 
@@ -75,6 +99,18 @@ type Configuration struct {
 	StreamSnippets []string `json:"StreamSnippets"`
 }
 
+// CustomDomain describes a domain that is not defined in any Ingress and is
+// resolved dynamically to the server of an existing hostname
+type CustomDomain struct {
+	// Hostname is the custom domain
+	Hostname string `json:"hostname"`
+	// Server is the hostname of the server that handles the requests
+	Server string `json:"server"`
+	// SSLCert is the certificate served for the custom domain
+	// +optional
+	SSLCert *SSLCert `json:"sslCert,omitempty"`
+}
+
 // Backend describes one or more remote server/s (endpoints) associated with a service
 // +k8s:deepcopy-gen=true
 type Backend struct {
@@ -92,6 +128,10 @@ type Backend struct {
 	UpstreamHashBy UpstreamHashByConfig `json:"upstreamHashByConfig,omitempty"`
 	// LB algorithm configuration per ingress
 	LoadBalancing string `json:"load-balance,omitempty"`
+	// Settings of the LB algorithm per ingress
+	LoadBalanceTuning LoadBalanceTuning `json:"loadBalanceTuning,omitempty"`
+	// Requests warming up the new endpoints before they are load balanced
+	Warmup Warmup `json:"warmup,omitempty"`
 	// Denotes if a backend has no server. The backend instead shares a server with another backend and acts as an
 	// alternative backend.
 	// This can be used to share multiple upstreams in the sam nginx server block.
@@ -102,6 +142,21 @@ type Backend struct {
 	// Contains a list of backends without servers that are associated with this backend.
 	// +optional
 	AlternativeBackends []string `json:"alternativeBackends,omitempty"`
+	// ExternalNameResolution configures the resolution of the name of a service of type ExternalName
+	// +optional
+	ExternalNameResolution ExternalNameResolution `json:"externalNameResolution,omitempty"`
+}
+
+// ExternalNameResolution describes how the name of a service of type ExternalName is resolved
+// +k8s:deepcopy-gen=true
+type ExternalNameResolution struct {
+	// Nameservers used to resolve the name. The nameservers of /etc/resolv.conf are used if empty.
+	Nameservers []string `json:"nameservers,omitempty"`
+	// TTL in seconds of the resolved addresses. The TTL of the DNS answers is used if 0.
+	TTL int `json:"ttl,omitempty"`
+	// Static indicates that the name is only resolved when the backend changes, and not
+	// again when the TTL of the addresses expires.
+	Static bool `json:"static,omitempty"`
 }
 
 // TrafficShapingPolicy describes the policies to put in place when a backend has no server and is used as an
@@ -125,6 +180,11 @@ type TrafficShapingPolicy struct {
 	HeaderPattern string `json:"headerPattern"`
 	// Cookie on which to redirect requests to this backend
 	Cookie string `json:"cookie"`
+	// DecisionHeader is the response header returning the backend and the rule
+	// that routed a request
+	DecisionHeader string `json:"decisionHeader,omitempty"`
+	// Expression on which to redirect requests to this backend
+	Expression *expression.Node `json:"expression,omitempty"`
 }
 
 // HashInclude defines if a field should be used or not to calculate the hash
@@ -167,9 +227,24 @@ type CookieSessionAffinity struct {
 
 // UpstreamHashByConfig described setting from the upstream-hash-by* annotations.
 type UpstreamHashByConfig struct {
-	UpstreamHashBy           string `json:"upstream-hash-by,omitempty"`
-	UpstreamHashBySubset     bool   `json:"upstream-hash-by-subset,omitempty"`
-	UpstreamHashBySubsetSize int    `json:"upstream-hash-by-subset-size,omitempty"`
+	UpstreamHashBy                  string `json:"upstream-hash-by,omitempty"`
+	UpstreamHashBySubset            bool   `json:"upstream-hash-by-subset,omitempty"`
+	UpstreamHashBySubsetSize        int    `json:"upstream-hash-by-subset-size,omitempty"`
+	UpstreamHashByBalanceFactor     int    `json:"upstream-hash-by-balance-factor,omitempty"`
+	UpstreamHashByReplicationFactor int    `json:"upstream-hash-by-replication-factor,omitempty"`
+}
+
+// LoadBalanceTuning described setting from the load-balance-* annotations.
+type LoadBalanceTuning struct {
+	P2CChoices        int `json:"p2c-choices,omitempty"`
+	LeastLatencyDecay int `json:"least-latency-decay,omitempty"`
+}
+
+// Warmup described setting from the warmup-* annotations.
+type Warmup struct {
+	Path     string `json:"path,omitempty"`
+	Requests int    `json:"requests,omitempty"`
+	Timeout  int    `json:"timeout,omitempty"`
 }
 
 // Endpoint describes a kubernetes endpoint in a backend
@@ -192,12 +267,22 @@ type Server struct {
 	SSLPassthrough bool `json:"sslPassthrough"`
 	// SSLCert describes the certificate that will be used on the server
 	SSLCert *SSLCert `json:"sslCert"`
+	// AlternateSSLCert describes the certificate served with SSLCert to the
+	// clients supporting its key type, from the ssl-alternate-secret annotation
+	// +optional
+	AlternateSSLCert *SSLCert `json:"alternateSSLCert,omitempty"`
 	// Locations list of URIs configured in the server.
 	Locations []*Location `json:"locations,omitempty"`
 	// Aliases return the alias of the server name
 	Aliases []string `json:"aliases,omitempty"`
 	// RedirectFromToWWW returns if a redirect to/from prefix www is required
 	RedirectFromToWWW bool `json:"redirectFromToWWW,omitempty"`
+	// Internal indicates the server only listens in the internal ports
+	// because an Ingress of the host has the scope internal
+	Internal bool `json:"internal,omitempty"`
+	// TrustedProxies defines the networks whose forwarded headers are honored
+	// by the server, overriding the trusted proxies of the configuration
+	TrustedProxies []string `json:"trustedProxies,omitempty"`
 	// CertificateAuth indicates this server requires mutual authentication
 	// +optional
 	CertificateAuth authtls.Config `json:"certificateAuth"`
@@ -212,6 +297,11 @@ type Server struct {
 	// SSLPreferServerCiphers indicates that server ciphers should be preferred
 	// over client ciphers when using the TLS protocols.
 	SSLPreferServerCiphers string `json:"sslPreferServerCiphers,omitempty"`
+	// SSLProtocols returns list of protocols to be enabled
+	SSLProtocols string `json:"sslProtocols,omitempty"`
+	// HTTP2 is the value of the http2 directive of the server, overriding
+	// the use-http2 configuration when not empty
+	HTTP2 string `json:"http2,omitempty"`
 	// AuthTLSError contains the reason why the access to a server should be denied
 	AuthTLSError string `json:"authTLSError,omitempty"`
 }
@@ -257,10 +347,18 @@ type Location struct {
 	// vhost of the incoming request.
 	// +optional
 	UpstreamVhost string `json:"upstream-vhost"`
+	// UpstreamProxyProtocol is the PROXY protocol header sent to the
+	// backend of a SSL passthrough server
+	// +optional
+	UpstreamProxyProtocol upstreamproxyprotocol.Config `json:"upstreamProxyProtocol,omitempty"`
 	// BasicDigestAuth returns authentication configuration for
 	// an Ingress rule.
 	// +optional
 	BasicDigestAuth auth.Config `json:"basicDigestAuth,omitempty"`
+	// OIDCAuth indicates the access to this location requires the
+	// authentication of the users with an OpenID Connect issuer
+	// +optional
+	OIDCAuth authoidc.Config `json:"oidcAuth,omitempty"`
 	// Denied returns an error when this location cannot not be allowed
 	// Requesting a denied location should return HTTP code 403.
 	Denied        *string              `json:"denied,omitempty"`
@@ -268,6 +366,10 @@ type Location struct {
 	// CorsConfig returns the Cors Configuration for the ingress rule
 	// +optional
 	CorsConfig cors.Config `json:"corsConfig,omitempty"`
+	// ConditionalHeader describes the header sent to the backend with the
+	// requests matching an expression
+	// +optional
+	ConditionalHeader conditionalheader.Config `json:"conditionalHeader,omitempty"`
 	// ExternalAuth indicates the access to this location requires
 	// authentication using an external provider
 	// +optional
@@ -275,10 +377,27 @@ type Location struct {
 	// EnableGlobalAuth indicates if the access to this location requires
 	// authentication using an external provider defined in controller's config
 	EnableGlobalAuth bool `json:"enableGlobalAuth"`
+	// HSTS overrides the HSTS header of the hosts of the Ingress
+	// +optional
+	HSTS hsts.Config `json:"hsts,omitempty"`
+	// GRPCWeb indicates the gRPC-Web requests are translated to gRPC for
+	// the backend, and the responses to gRPC-Web
+	// +optional
+	GRPCWeb bool `json:"grpcWeb,omitempty"`
+	// BodyInspection limits the structure of the XML and JSON request bodies
+	// +optional
+	BodyInspection bodyinspection.Config `json:"bodyInspection,omitempty"`
+	// ContentSecurityPolicy is the Content Security Policy of the responses
+	// +optional
+	ContentSecurityPolicy csp.Config `json:"contentSecurityPolicy,omitempty"`
 	// HTTP2PushPreload allows to configure the HTTP2 Push Preload from backend
 	// original location.
 	// +optional
 	HTTP2PushPreload bool `json:"http2PushPreload,omitempty"`
+	// HTTPVersions are the HTTP versions of the clients allowed in the
+	// location, or empty to allow all the versions
+	// +optional
+	HTTPVersions []string `json:"httpVersions,omitempty"`
 	// RateLimit describes a limit in the number of connections per IP
 	// address or connections per second.
 	// The Redirect annotation precedes RateLimit
@@ -302,6 +421,12 @@ type Location struct {
 	// to be used in connections against endpoints
 	// +optional
 	Proxy proxy.Config `json:"proxy,omitempty"`
+	// ProxyCache contains the cache of the responses of the backends
+	// +optional
+	ProxyCache proxycache.Config `json:"proxyCache,omitempty"`
+	// LargeFileDelivery contains the settings of the delivery of large files
+	// +optional
+	LargeFileDelivery largefiledelivery.Config `json:"largeFileDelivery,omitempty"`
 	// ProxySSL contains information about SSL configuration parameters
 	// to be used in connections against endpoints
 	// +optional
@@ -309,6 +434,10 @@ type Location struct {
 	// UsePortInRedirects indicates if redirects must specify the port
 	// +optional
 	UsePortInRedirects bool `json:"usePortInRedirects"`
+	// Paused indicates the requests to the location return a status code
+	// of 503 instead of being sent to the backend
+	// +optional
+	Paused bool `json:"paused,omitempty"`
 	// ConfigurationSnippet contains additional configuration for the backend
 	// to be considered in the configuration of the location
 	ConfigurationSnippet string `json:"configurationSnippet"`
@@ -326,6 +455,20 @@ type Location struct {
 	// DefaultBackendUpstreamName is the upstream-formatted string for the name of
 	// this location's custom default backend
 	DefaultBackendUpstreamName string `json:"defaultBackendUpstreamName,omitempty"`
+	// SplitHorizon describes the internal clients of the location and the
+	// service serving them
+	// +optional
+	SplitHorizon splithorizon.Config `json:"splitHorizon,omitempty"`
+	// SplitHorizonUpstreamName is the upstream-formatted string for the name of
+	// the service serving the internal clients of this location
+	SplitHorizonUpstreamName string `json:"splitHorizonUpstreamName,omitempty"`
+	// Backup describes the service serving the requests when the service of
+	// the location fails
+	// +optional
+	Backup backupservice.Config `json:"backup,omitempty"`
+	// BackupUpstreamName is the upstream-formatted string for the name of
+	// the backup service of this location
+	BackupUpstreamName string `json:"backupUpstreamName,omitempty"`
 	// XForwardedPrefix allows to add a header X-Forwarded-Prefix to the request with the
 	// original location.
 	// +optional
@@ -333,6 +476,10 @@ type Location struct {
 	// Logs allows to enable or disable the nginx logs
 	// By default access logs are enabled and rewrite logs are disabled
 	Logs log.Config `json:"logs,omitempty"`
+	// LuaKV contains the key/value store readable by the Lua code of the
+	// location, read from a ConfigMap of the Ingress
+	// +optional
+	LuaKV luakv.Config `json:"luaKV,omitempty"`
 	// BackendProtocol indicates which protocol should be used to communicate with the service
 	// By default this is HTTP
 	BackendProtocol string `json:"backend-protocol"`
@@ -347,6 +494,10 @@ type Location struct {
 	// but service-a can return 404 and 503 error codes without intercept
 	// +optional
 	DisableProxyInterceptErrors bool `json:"disable-proxy-intercept-errors"`
+	// ErrorPage defines how the requests are sent to the default backend
+	// serving the error codes defined in CustomHTTPErrors
+	// +optional
+	ErrorPage errorpage.Config `json:"errorPage"`
 	// ModSecurity allows to enable and configure modsecurity
 	// +optional
 	ModSecurity modsecurity.Config `json:"modsecurity"`
@@ -358,6 +509,21 @@ type Location struct {
 	// Opentelemetry allows the global opentelemetry setting to be overridden for a location
 	// +optional
 	Opentelemetry opentelemetry.Config `json:"opentelemetry"`
+	// ObservabilityLabels are the labels of the Ingress and of its namespace
+	// allowed by the flag --observability-labels, added to the tracing spans,
+	// indexed by attribute name
+	// +optional
+	ObservabilityLabels map[string]string `json:"observabilityLabels,omitempty"`
+	// ForwardedHeaders defines how the forwarded headers are computed for the
+	// Ingresses of the IngressClass of the location
+	// +optional
+	ForwardedHeaders ForwardedHeaders `json:"forwardedHeaders,omitempty"`
+	// ExtraSecrets are the Secrets referenced in the snippets of the location
+	// +optional
+	ExtraSecrets []extrasecrets.Secret `json:"extraSecrets,omitempty"`
+	// Precompressed defines the encodings of the precompressed responses served by the location
+	// +optional
+	Precompressed precompressed.Config `json:"precompressed,omitempty"`
 }
 
 // SSLPassthroughBackend describes a SSL upstream server configured
@@ -371,6 +537,8 @@ type SSLPassthroughBackend struct {
 	Backend string `json:"namespace,omitempty"`
 	// Hostname returns the FQDN of the server
 	Hostname string `json:"hostname"`
+	// ProxyProtocol is the PROXY protocol header sent to the backend
+	ProxyProtocol upstreamproxyprotocol.Config `json:"proxyProtocol,omitempty"`
 }
 
 // L4Service describes a L4 Ingress service.
@@ -393,6 +561,8 @@ type L4Backend struct {
 	Protocol  apiv1.Protocol     `json:"protocol"`
 	// +optional
 	ProxyProtocol ProxyProtocol `json:"proxyProtocol"`
+	// +optional
+	UDP UDPConfig `json:"udp"`
 }
 
 // ProxyProtocol describes the proxy protocol configuration
@@ -401,10 +571,34 @@ type ProxyProtocol struct {
 	Encode bool `json:"encode"`
 }
 
+// UDPConfig describes the configuration of an UDP service
+type UDPConfig struct {
+	// ProxyResponses number of datagrams expected from the backend in
+	// response to a client datagram (proxy_responses)
+	ProxyResponses string `json:"proxyResponses,omitempty"`
+	// ProxyTimeout timeout between two successive read or write
+	// operations on client or proxied server connections (proxy_timeout)
+	ProxyTimeout string `json:"proxyTimeout,omitempty"`
+	// Affinity defines how the endpoint is selected for a client.
+	// client-ip uses a consistent hash of the client address
+	Affinity string `json:"affinity,omitempty"`
+}
+
 // Ingress holds the definition of an Ingress plus its annotations
 type Ingress struct {
 	networking.Ingress `json:"-"`
 	ParsedAnnotations  *annotations.Ingress `json:"parsedAnnotations"`
+	// ForwardedHeaders is defined by the parameters of the IngressClass
+	ForwardedHeaders ForwardedHeaders `json:"forwardedHeaders,omitempty"`
+}
+
+// ForwardedHeaders describes how the X-Forwarded-Host, X-Forwarded-Proto and
+// X-Forwarded-Port headers are computed, overriding the global configuration
+type ForwardedHeaders struct {
+	// Mode is trust, overwrite or append
+	Mode string `json:"mode,omitempty"`
+	// SchemeOverride is the scheme of the connections of the clients, http or https
+	SchemeOverride string `json:"schemeOverride,omitempty"`
 }
 
 // GeneralConfig holds the definition of lua general configuration data
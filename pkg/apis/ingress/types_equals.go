@@ -17,6 +17,9 @@ limitations under the License.
 package ingress
 
 import (
+	"maps"
+	"slices"
+
 	"k8s.io/ingress-nginx/pkg/util/sets"
 )
 
@@ -76,9 +79,42 @@ func (c1 *Configuration) Equal(c2 *Configuration) bool {
 		}
 	}
 
+	if len(c1.CustomDomains) != len(c2.CustomDomains) {
+		return false
+	}
+
+	// CustomDomains are sorted
+	for idx := range c1.CustomDomains {
+		if !(&c1.CustomDomains[idx]).Equal(&c2.CustomDomains[idx]) {
+			return false
+		}
+	}
+
+	if c1.ErrorLogLevel != c2.ErrorLogLevel {
+		return false
+	}
+
 	return c1.BackendConfigChecksum == c2.BackendConfigChecksum
 }
 
+// Equal tests for equality between two CustomDomain types
+func (d1 *CustomDomain) Equal(d2 *CustomDomain) bool {
+	if d1 == d2 {
+		return true
+	}
+	if d1 == nil || d2 == nil {
+		return false
+	}
+	if d1.Hostname != d2.Hostname {
+		return false
+	}
+	if d1.Server != d2.Server {
+		return false
+	}
+
+	return d1.SSLCert.Equal(d2.SSLCert)
+}
+
 // Equal tests for equality between two Backend types
 func (b *Backend) Equal(newB *Backend) bool {
 	if b == newB {
@@ -121,6 +157,12 @@ func (b *Backend) Equal(newB *Backend) bool {
 	if b.LoadBalancing != newB.LoadBalancing {
 		return false
 	}
+	if b.LoadBalanceTuning != newB.LoadBalanceTuning {
+		return false
+	}
+	if b.Warmup != newB.Warmup {
+		return false
+	}
 
 	match := compareEndpoints(b.Endpoints, newB.Endpoints)
 	if !match {
@@ -131,6 +173,10 @@ func (b *Backend) Equal(newB *Backend) bool {
 		return false
 	}
 
+	if !b.ExternalNameResolution.Equal(&newB.ExternalNameResolution) {
+		return false
+	}
+
 	return sets.StringElementsMatch(b.AlternativeBackends, newB.AlternativeBackends)
 }
 
@@ -208,6 +254,12 @@ func (u1 *UpstreamHashByConfig) Equal(u2 *UpstreamHashByConfig) bool {
 	if u1.UpstreamHashBySubsetSize != u2.UpstreamHashBySubsetSize {
 		return false
 	}
+	if u1.UpstreamHashByBalanceFactor != u2.UpstreamHashByBalanceFactor {
+		return false
+	}
+	if u1.UpstreamHashByReplicationFactor != u2.UpstreamHashByReplicationFactor {
+		return false
+	}
 
 	return true
 }
@@ -259,10 +311,28 @@ func (tsp1 *TrafficShapingPolicy) Equal(tsp2 *TrafficShapingPolicy) bool {
 	if tsp1.Cookie != tsp2.Cookie {
 		return false
 	}
+	if tsp1.DecisionHeader != tsp2.DecisionHeader {
+		return false
+	}
+	if !tsp1.Expression.Equal(tsp2.Expression) {
+		return false
+	}
 
 	return true
 }
 
+// Equal tests for equality between two ExternalNameResolution types
+func (enr1 *ExternalNameResolution) Equal(enr2 *ExternalNameResolution) bool {
+	if enr1.TTL != enr2.TTL {
+		return false
+	}
+	if enr1.Static != enr2.Static {
+		return false
+	}
+
+	return slices.Equal(enr1.Nameservers, enr2.Nameservers)
+}
+
 // Equal tests for equality between two Server types
 func (s1 *Server) Equal(s2 *Server) bool {
 	if s1 == s2 {
@@ -277,9 +347,18 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.SSLPassthrough != s2.SSLPassthrough {
 		return false
 	}
+	if s1.Internal != s2.Internal {
+		return false
+	}
+	if !sets.StringElementsMatch(s1.TrustedProxies, s2.TrustedProxies) {
+		return false
+	}
 	if !s1.SSLCert.Equal(s2.SSLCert) {
 		return false
 	}
+	if !s1.AlternateSSLCert.Equal(s2.AlternateSSLCert) {
+		return false
+	}
 
 	if len(s1.Aliases) != len(s2.Aliases) {
 		return false
@@ -313,6 +392,12 @@ func (s1 *Server) Equal(s2 *Server) bool {
 	if s1.SSLPreferServerCiphers != s2.SSLPreferServerCiphers {
 		return false
 	}
+	if s1.HTTP2 != s2.HTTP2 {
+		return false
+	}
+	if s1.SSLProtocols != s2.SSLProtocols {
+		return false
+	}
 	if s1.AuthTLSError != s2.AuthTLSError {
 		return false
 	}
@@ -372,21 +457,42 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if !(&l1.BasicDigestAuth).Equal(&l2.BasicDigestAuth) {
 		return false
 	}
+	if !(&l1.OIDCAuth).Equal(&l2.OIDCAuth) {
+		return false
+	}
 	if l1.Denied != l2.Denied {
 		return false
 	}
 	if !(&l1.CorsConfig).Equal(&l2.CorsConfig) {
 		return false
 	}
+	if !(&l1.ConditionalHeader).Equal(&l2.ConditionalHeader) {
+		return false
+	}
 	if !(&l1.ExternalAuth).Equal(&l2.ExternalAuth) {
 		return false
 	}
 	if l1.EnableGlobalAuth != l2.EnableGlobalAuth {
 		return false
 	}
+	if !(&l1.HSTS).Equal(&l2.HSTS) {
+		return false
+	}
+	if l1.GRPCWeb != l2.GRPCWeb {
+		return false
+	}
+	if l1.BodyInspection != l2.BodyInspection {
+		return false
+	}
+	if l1.ContentSecurityPolicy != l2.ContentSecurityPolicy {
+		return false
+	}
 	if l1.HTTP2PushPreload != l2.HTTP2PushPreload {
 		return false
 	}
+	if !sets.StringElementsMatch(l1.HTTPVersions, l2.HTTPVersions) {
+		return false
+	}
 	if !(&l1.RateLimit).Equal(&l2.RateLimit) {
 		return false
 	}
@@ -405,12 +511,21 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if !(&l1.Proxy).Equal(&l2.Proxy) {
 		return false
 	}
+	if !(&l1.ProxyCache).Equal(&l2.ProxyCache) {
+		return false
+	}
+	if l1.LargeFileDelivery != l2.LargeFileDelivery {
+		return false
+	}
 	if !(&l1.ProxySSL).Equal(&l2.ProxySSL) {
 		return false
 	}
 	if l1.UsePortInRedirects != l2.UsePortInRedirects {
 		return false
 	}
+	if l1.Paused != l2.Paused {
+		return false
+	}
 	if l1.ConfigurationSnippet != l2.ConfigurationSnippet {
 		return false
 	}
@@ -420,15 +535,24 @@ func (l1 *Location) Equal(l2 *Location) bool {
 	if l1.UpstreamVhost != l2.UpstreamVhost {
 		return false
 	}
+	if !(&l1.UpstreamProxyProtocol).Equal(&l2.UpstreamProxyProtocol) {
+		return false
+	}
 	if l1.XForwardedPrefix != l2.XForwardedPrefix {
 		return false
 	}
 	if !(&l1.Connection).Equal(&l2.Connection) {
 		return false
 	}
+	if !(&l1.Precompressed).Equal(&l2.Precompressed) {
+		return false
+	}
 	if !(&l1.Logs).Equal(&l2.Logs) {
 		return false
 	}
+	if !(&l1.LuaKV).Equal(&l2.LuaKV) {
+		return false
+	}
 
 	if l1.BackendProtocol != l2.BackendProtocol {
 		return false
@@ -455,6 +579,22 @@ func (l1 *Location) Equal(l2 *Location) bool {
 		return false
 	}
 
+	if !(&l1.SplitHorizon).Equal(&l2.SplitHorizon) {
+		return false
+	}
+
+	if l1.SplitHorizonUpstreamName != l2.SplitHorizonUpstreamName {
+		return false
+	}
+
+	if !(&l1.Backup).Equal(&l2.Backup) {
+		return false
+	}
+
+	if l1.BackupUpstreamName != l2.BackupUpstreamName {
+		return false
+	}
+
 	if !l1.Opentelemetry.Equal(&l2.Opentelemetry) {
 		return false
 	}
@@ -467,6 +607,22 @@ func (l1 *Location) Equal(l2 *Location) bool {
 		return false
 	}
 
+	if !l1.ErrorPage.Equal(&l2.ErrorPage) {
+		return false
+	}
+
+	if !maps.Equal(l1.ObservabilityLabels, l2.ObservabilityLabels) {
+		return false
+	}
+
+	if l1.ForwardedHeaders != l2.ForwardedHeaders {
+		return false
+	}
+
+	if !slices.Equal(l1.ExtraSecrets, l2.ExtraSecrets) {
+		return false
+	}
+
 	return true
 }
 
@@ -487,6 +643,9 @@ func (ptb1 *SSLPassthroughBackend) Equal(ptb2 *SSLPassthroughBackend) bool {
 	if ptb1.Port != ptb2.Port {
 		return false
 	}
+	if !(&ptb1.ProxyProtocol).Equal(&ptb2.ProxyProtocol) {
+		return false
+	}
 
 	if ptb1.Service != ptb2.Service {
 		if ptb1.Service == nil || ptb2.Service == nil {
@@ -544,6 +703,9 @@ func (l4b1 *L4Backend) Equal(l4b2 *L4Backend) bool {
 	if l4b1.ProxyProtocol != l4b2.ProxyProtocol {
 		return false
 	}
+	if l4b1.UDP != l4b2.UDP {
+		return false
+	}
 
 	return true
 }
@@ -577,6 +739,12 @@ func (s *SSLCert) Equal(newS *SSLCert) bool {
 	if s.PemCertKey != newS.PemCertKey {
 		return false
 	}
+	if s.AlternatePemCertKey != newS.AlternatePemCertKey {
+		return false
+	}
+	if s.AlternatePemSHA != newS.AlternatePemSHA {
+		return false
+	}
 	if s.UID != newS.UID {
 		return false
 	}
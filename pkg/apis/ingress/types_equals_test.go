@@ -306,3 +306,25 @@ func TestSSLCertMatch(t *testing.T) {
 		}
 	}
 }
+
+func TestExternalNameResolutionMatch(t *testing.T) {
+	resolution := &ExternalNameResolution{Nameservers: []string{"10.0.0.1", "10.0.0.2"}, TTL: 30, Static: true}
+
+	testCases := []struct {
+		resolutionB *ExternalNameResolution
+		expected    bool
+	}{
+		{&ExternalNameResolution{Nameservers: []string{"10.0.0.1", "10.0.0.2"}, TTL: 30, Static: true}, true},
+		{&ExternalNameResolution{Nameservers: []string{"10.0.0.2", "10.0.0.1"}, TTL: 30, Static: true}, false},
+		{&ExternalNameResolution{Nameservers: []string{"10.0.0.1", "10.0.0.2"}, TTL: 60, Static: true}, false},
+		{&ExternalNameResolution{Nameservers: []string{"10.0.0.1", "10.0.0.2"}, TTL: 30}, false},
+		{&ExternalNameResolution{TTL: 30, Static: true}, false},
+	}
+
+	for _, testCase := range testCases {
+		result := resolution.Equal(testCase.resolutionB)
+		if result != testCase.expected {
+			t.Errorf("expected %v but returned %v (%v - %v)", testCase.expected, result, resolution, testCase.resolutionB)
+		}
+	}
+}
@@ -23,6 +23,7 @@ package ingress
 
 import (
 	v1 "k8s.io/api/core/v1"
+	expression "k8s.io/ingress-nginx/internal/ingress/expression"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -43,12 +44,15 @@ func (in *Backend) DeepCopyInto(out *Backend) {
 	}
 	in.SessionAffinity.DeepCopyInto(&out.SessionAffinity)
 	out.UpstreamHashBy = in.UpstreamHashBy
-	out.TrafficShapingPolicy = in.TrafficShapingPolicy
+	out.LoadBalanceTuning = in.LoadBalanceTuning
+	out.Warmup = in.Warmup
+	in.TrafficShapingPolicy.DeepCopyInto(&out.TrafficShapingPolicy)
 	if in.AlternativeBackends != nil {
 		in, out := &in.AlternativeBackends, &out.AlternativeBackends
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	in.ExternalNameResolution.DeepCopyInto(&out.ExternalNameResolution)
 	return
 }
 
@@ -114,6 +118,27 @@ func (in *Endpoint) DeepCopy() *Endpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ExternalNameResolution) DeepCopyInto(out *ExternalNameResolution) {
+	*out = *in
+	if in.Nameservers != nil {
+		in, out := &in.Nameservers, &out.Nameservers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ExternalNameResolution.
+func (in *ExternalNameResolution) DeepCopy() *ExternalNameResolution {
+	if in == nil {
+		return nil
+	}
+	out := new(ExternalNameResolution)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *SessionAffinityConfig) DeepCopyInto(out *SessionAffinityConfig) {
 	*out = *in
@@ -134,6 +159,11 @@ func (in *SessionAffinityConfig) DeepCopy() *SessionAffinityConfig {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *TrafficShapingPolicy) DeepCopyInto(out *TrafficShapingPolicy) {
 	*out = *in
+	if in.Expression != nil {
+		in, out := &in.Expression, &out.Expression
+		*out = new(expression.Node)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -22,18 +22,24 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/spf13/pflag"
 	apiv1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/ingress-nginx/internal/ingress/annotations/parser"
 	"k8s.io/ingress-nginx/internal/ingress/controller"
 	ngx_config "k8s.io/ingress-nginx/internal/ingress/controller/config"
 	"k8s.io/ingress-nginx/internal/ingress/controller/ingressclass"
+	"k8s.io/ingress-nginx/internal/ingress/controller/shard"
+	"k8s.io/ingress-nginx/internal/ingress/metric"
 	"k8s.io/ingress-nginx/internal/ingress/metric/collectors"
 	"k8s.io/ingress-nginx/internal/ingress/status"
+	"k8s.io/ingress-nginx/internal/k8s"
 	ing_net "k8s.io/ingress-nginx/internal/net"
 	"k8s.io/ingress-nginx/internal/nginx"
 	klog "k8s.io/klog/v2"
@@ -101,13 +107,21 @@ The key in the map indicates the external port to be used. The value is a
 reference to a Service in the form "namespace/name:port", where "port" can
 either be a port name or number.`)
 
+		customDomainsConfigMapName = flags.String("custom-domains-configmap", "",
+			`Name of the ConfigMap containing custom domains served without a server block
+of their own. The key in the map is the custom domain. The value is the hostname
+of an existing server, optionally followed by a comma and a reference to the
+TLS Secret of the custom domain in the form "namespace/name". Custom domains
+are updated without reloading NGINX.`)
+
 		resyncPeriod = flags.Duration("sync-period", 0,
 			`Period at which the controller forces the repopulation of its local object stores. Disabled by default.`)
 
 		watchNamespace = flags.String("watch-namespace", apiv1.NamespaceAll,
 			`Namespace the controller watches for updates to Kubernetes objects.
-This includes Ingresses, Services and all configuration resources. All
-namespaces are watched if this parameter is left empty.`)
+This includes Ingresses, Services and all configuration resources. Several
+namespaces can be watched using a comma-separated list. All namespaces are
+watched if this parameter is left empty.`)
 
 		watchNamespaceSelector = flags.String("watch-namespace-selector", "",
 			`Selector selects namespaces the controller watches for updates to Kubernetes objects.`)
@@ -131,15 +145,33 @@ defined by the healthz-port parameter are forwarded internally to this path.`)
 Requires setting the publish-service parameter to a valid Service reference.`)
 
 		electionID = flags.String("election-id", "ingress-controller-leader",
-			`Election id to use for Ingress status updates.`)
+			`Election id to use for Ingress status updates. The leader stores the state of the status synchronization (published addresses and pending updates) in the annotation ingress-nginx.kubernetes.io/status-sync-state of this Lease, so a new leader continues from it after a failover.`)
 
 		electionTTL = flags.Duration("election-ttl", 30*time.Second,
 			`Duration a leader election is valid before it's getting re-elected`)
 
 		updateStatusOnShutdown = flags.Bool("update-status-on-shutdown", true,
 			`Update the load-balancer status of Ingress objects when the controller shuts down.
+The status is removed only when no other ready replica of the controller is running.
+Requires the update-status parameter.`)
+
+		statusRemovalHoldTime = flags.Duration("status-removal-hold-time", 0,
+			`Minimum time an address must be missing before it is removed from the
+load-balancer status of Ingress objects. Avoids updating the status when the
+addresses of the publish service flap. Requires the update-status parameter.`)
+
+		statusRemovalObservations = flags.Int("status-removal-observations", 1,
+			`Number of consecutive status synchronizations in which an address must be missing
+before it is removed from the load-balancer status of Ingress objects.
 Requires the update-status parameter.`)
 
+		configDriftThreshold = flags.Duration("config-drift-threshold", 0,
+			`Minimum time a replica of the controller must run a configuration different from
+the other replicas before a ConfigurationDrift Event is emitted on its pod. The replicas
+publish the checksum of their configuration in the annotation
+ingress-nginx.kubernetes.io/configuration-checksums of the Lease of the leader election,
+compared by the leader. 0 disables the detection. Requires the leader election.`)
+
 		useNodeInternalIP = flags.Bool("report-node-internal-ip-address", false,
 			`Set the load-balancer status of Ingress objects to internal Node addresses instead of external.
 Requires the update-status parameter.`)
@@ -182,6 +214,12 @@ Requires the update-status parameter.`)
 			`Export metrics per-host even if the host is not defined in an ingress. Requires --metrics-per-host to be set to true.`)
 		reportStatusClasses = flags.Bool("report-status-classes", false,
 			`Use status classes (2xx, 3xx, 4xx and 5xx) instead of status codes in metrics.`)
+		metricsPerEndpoint = flags.Bool("metrics-per-endpoint", false,
+			`Export the number of requests, the number of 5xx errors, the response time and the request and response sizes
+by endpoint of the services, the endpoints being labeled by a hash of the name of their pod.`)
+		metricsPerEndpointMaxSeries = flags.Int("metrics-per-endpoint-max-series", 1000,
+			`Maximum number of series of each metric by endpoint. The requests to the endpoints of the further series are
+counted with the endpoint "other". Requires --metrics-per-endpoint.`)
 
 		timeBuckets          = flags.Float64Slice("time-buckets", prometheus.DefBuckets, "Set of buckets which will be used for prometheus histogram metrics such as RequestTime, ResponseTime.")
 		lengthBuckets        = flags.Float64Slice("length-buckets", prometheus.LinearBuckets(10, 10, 10), "Set of buckets which will be used for prometheus histogram metrics such as RequestLength, ResponseLength.")
@@ -190,10 +228,24 @@ Requires the update-status parameter.`)
 		maxBuckets           = flags.Uint32("max-buckets", 100, "Maximum number of buckets for native histograms.")
 		excludeSocketMetrics = flags.StringSlice("exclude-socket-metrics", []string{}, "et of socket request metrics to exclude which won't be exported nor being calculated. E.g. 'nginx_ingress_controller_success,nginx_ingress_controller_header_duration_seconds'.")
 		monitorMaxBatchSize  = flags.Int("monitor-max-batch-size", 10000, "Max batch size of NGINX metrics.")
+		metricsCollector     = flags.String("metrics-collector", metric.PrometheusCollector,
+			fmt.Sprintf(`Implementation of the metric collector. The "sharded" collector processes the metrics of the
+requests in one shard per CPU, reducing the contention under high request rates. Valid values: %v.`, strings.Join(metric.CollectorNames(), ", ")))
+		metricsLabels       = flags.StringSlice("metrics-labels", []string{}, "Set of label names that Ingresses can add to the socket request metrics using the annotation metrics-labels. E.g. 'team,tier'.")
+		observabilityLabels = flags.StringSlice("observability-labels", []string{},
+			`Set of labels of the Ingresses and of their namespaces added to the socket request metrics and to the OpenTelemetry spans
+of the Ingresses. The labels of the Ingresses override the labels of their namespaces. E.g. 'team,app.kubernetes.io/name'.`)
 
 		httpPort  = flags.Int("http-port", 80, `Port to use for servicing HTTP traffic.`)
 		httpsPort = flags.Int("https-port", 443, `Port to use for servicing HTTPS traffic.`)
 
+		internalHTTPPort = flags.Int("internal-http-port", 0,
+			`Port to use for servicing the HTTP traffic of the Ingresses with the annotation scope set to internal, which are not
+served in --http-port. Requires --internal-https-port. 0 disables the internal listeners.`)
+		internalHTTPSPort = flags.Int("internal-https-port", 0,
+			`Port to use for servicing the HTTPS traffic of the Ingresses with the annotation scope set to internal, which are not
+served in --https-port. Requires --internal-http-port. 0 disables the internal listeners.`)
+
 		sslProxyPort  = flags.Int("ssl-passthrough-proxy-port", 442, `Port to use internally for SSL Passthrough.`)
 		defServerPort = flags.Int("default-server-port", 8181, `Port to use for exposing the default server (catch-all).`)
 		healthzPort   = flags.Int("healthz-port", 10254, "Port to use for the healthz endpoint.")
@@ -202,6 +254,13 @@ Requires the update-status parameter.`)
 		disableCatchAll = flags.Bool("disable-catch-all", false,
 			`Disable support for catch-all Ingresses.`)
 
+		shardCount = flags.Int("shard-count", 0,
+			`Number of controller deployments sharing the Ingresses of the cluster. Each hostname is owned by the shard of
+its hash modulo the number of shards, unless the Ingress sets its shard with the label nginx.ingress.kubernetes.io/shard.
+Each deployment requires a different --election-id. 0 and 1 disable the sharding.`)
+		shardIndex = flags.Int("shard-index", 0,
+			`Shard of the controller when --shard-count is greater than 1, from 0 to --shard-count minus 1.`)
+
 		validationWebhook = flags.String("validating-webhook", "",
 			`The address to start an admission controller on to validate incoming ingresses.
 Takes the form "<host>:port". If not provided, no admission controller is started.`)
@@ -211,6 +270,35 @@ Takes the form "<host>:port". If not provided, no admission controller is starte
 			`The path of the validating webhook key PEM.`)
 		disableFullValidationTest = flags.Bool("disable-full-test", false,
 			`Disable full test of all merged ingresses at the admission stage and tests the template of the ingress being created or updated  (full test of all ingresses is enabled by default).`)
+		syntaxOnlyValidationTest = flags.Bool("syntax-only-test", false,
+			`Test only the syntax of the configuration at the admission stage with the built-in parser, without running nginx -t. The directives and their arguments are not validated.`)
+		admissionMaxRenderSeconds = flags.Float64("admission-max-render-seconds", 0,
+			`Duration in seconds of the full test of all merged ingresses at the admission stage above which the ingresses are tested
+incrementally, rendering only the servers of the ingress being created or updated. The full test is measured again every 10 minutes. 0 disables the budget.`)
+		admissionMaxIngressesRendered = flags.Int("admission-max-ingresses-rendered", 0,
+			`Number of ingresses above which the ingresses are tested incrementally at the admission stage, rendering only the servers
+of the ingress being created or updated with the ingresses sharing these servers. 0 disables the budget.`)
+
+		optimizeConfiguration = flags.Bool("optimize-configuration", false,
+			`Reduce the size of the NGINX configuration moving the blocks of directives repeated in the locations to shared files
+included by the locations, and removing the repeated server names. Recommended with thousands of similar Ingresses.`)
+		configSizeBudget = flags.Int("config-size-budget", 0,
+			`Size in megabytes of the NGINX configuration above which a ConfigurationSize Event is emitted on the pod and each
+server is moved to its own file included by the configuration. 0 disables the budget.`)
+		splitConfiguration = flags.Bool("split-configuration", false,
+			`Move each server of the NGINX configuration to its own file in /etc/nginx/conf.d, named after the server and its
+content. Only the files of the changed servers are written, and the changes are logged by server.`)
+		reloadTimeout = flags.Duration("reload-timeout", 2*time.Minute,
+			`Maximum duration of the commands testing and reloading the NGINX configuration, after which the command is killed
+and the reload fails.`)
+		binaryUpgradeReload = flags.Bool("binary-upgrade-reload", false,
+			`Load the changes of the NGINX configuration requiring a reload with a binary upgrade of the NGINX master process
+(SIGUSR2), the new master process inheriting the listening sockets, then gracefully stop the previous master process and its
+workers (SIGQUIT). The controller adopts the new master process. The Lua shared dictionaries of the new master process are empty
+and the dynamic configuration is sent again.`)
+		logLevelDuration = flags.Duration("log-level-duration", 15*time.Minute,
+			`Default duration of the changes of the log levels of the controller and NGINX made at runtime with the endpoint
+/debug/log-level of the profiler, after which the log levels are reverted. Changes are limited to 24 hours.`)
 
 		statusPort = flags.Int("status-port", 10246, `Port to use for the lua HTTP endpoint configuration.`)
 		streamPort = flags.Int("stream-port", 10247, "Port to use for the lua TCP/UDP endpoint configuration.")
@@ -228,11 +316,40 @@ Takes the form "<host>:port". If not provided, no admission controller is starte
 
 		deepInspector = flags.Bool("deep-inspect", true, "Enables ingress object security deep inspector")
 
+		configurationSnapshot = flags.String("configuration-snapshot", "",
+			`Path of the file used to persist the last configuration applied successfully.
+When the controller starts without access to the Kubernetes API server, NGINX
+is started with this configuration until the API server is available.
+No snapshot is persisted while the configuration uses basic or OpenID Connect
+authentication, client or backend CA certificates, or Lua key/value stores.
+The private keys of the certificates are not written unless
+--configuration-snapshot-tls-keys is set. Disabled by default.`)
+
+		configurationSnapshotTLSKeys = flags.Bool("configuration-snapshot-tls-keys", false,
+			`Write the private keys of the TLS certificates in plain text in the file of
+--configuration-snapshot. Without it, the snapshot is only used when the
+certificates are present in the SSL directory, like the default certificate.`)
+
+		configurationExport = flags.String("configuration-export", "",
+			`Path of the file where the logical configuration (servers, locations, backends
+and policies) is written in YAML after each successful synchronization. The file
+is normalized and sorted to be tracked in Git to detect configuration drift.
+Disabled by default.`)
+
 		dynamicConfigurationRetries = flags.Int("dynamic-configuration-retries", 15, "Number of times to retry failed dynamic configuration before failing to sync an ingress.")
 
 		disableSyncEvents = flags.Bool("disable-sync-events", false, "Disables the creation of 'Sync' event resources")
 
+		offline = flags.Bool("offline", false,
+			`Disable the outbound calls of the controller, for air-gapped environments: the GeoIP2
+databases are not downloaded and must be present on disk, and OCSP stapling is disabled.
+Cannot be used with --enable-ssl-chain-completion.`)
+
 		enableTopologyAwareRouting = flags.Bool("enable-topology-aware-routing", false, "Enable topology aware routing feature, needs service object annotation service.kubernetes.io/topology-mode sets to auto.")
+
+		dataplane = flags.String("dataplane", nginx.NGINXDataplane,
+			fmt.Sprintf(`Flavor of NGINX driven by the controller. The directives not supported by the
+flavor are not rendered in the configuration. Valid values: %v.`, strings.Join(nginx.Dataplanes(), ", ")))
 	)
 
 	flags.StringVar(&nginx.MaxmindMirror, "maxmind-mirror", "", `Maxmind mirror url (example: http://geoip.local/databases.`)
@@ -262,6 +379,57 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 		status.UpdateInterval = *statusUpdateInterval
 	}
 
+	if *statusRemovalObservations < 1 {
+		return false, nil, fmt.Errorf("flag --status-removal-observations must be greater than zero")
+	}
+
+	if *configSizeBudget < 0 {
+		return false, nil, fmt.Errorf("flag --config-size-budget must not be negative")
+	}
+
+	if *admissionMaxRenderSeconds < 0 {
+		return false, nil, fmt.Errorf("flag --admission-max-render-seconds must not be negative")
+	}
+
+	if *admissionMaxIngressesRendered < 0 {
+		return false, nil, fmt.Errorf("flag --admission-max-ingresses-rendered must not be negative")
+	}
+
+	if *shardCount < 0 {
+		return false, nil, fmt.Errorf("flag --shard-count must not be negative")
+	}
+
+	if *shardCount > 1 && (*shardIndex < 0 || *shardIndex >= *shardCount) {
+		return false, nil, fmt.Errorf("flag --shard-index must be between 0 and %d", *shardCount-1)
+	}
+
+	if *reloadTimeout <= 0 {
+		return false, nil, fmt.Errorf("flag --reload-timeout must be greater than zero")
+	}
+
+	if *logLevelDuration <= 0 || *logLevelDuration > 24*time.Hour {
+		return false, nil, fmt.Errorf("flag --log-level-duration must be greater than zero and at most 24h")
+	}
+
+	if !slices.Contains(metric.CollectorNames(), *metricsCollector) {
+		return false, nil, fmt.Errorf("invalid value for flag --metrics-collector: unknown metric collector %q", *metricsCollector)
+	}
+
+	if *offline && *enableSSLChainCompletion {
+		return false, nil, fmt.Errorf("flags --offline and --enable-ssl-chain-completion are mutually exclusive: the completion of the certificate chains downloads the intermediate CA certificates")
+	}
+
+	for _, key := range *observabilityLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return false, nil, fmt.Errorf("invalid label %q in flag --observability-labels: %v", key, strings.Join(errs, ", "))
+		}
+	}
+
+	dataplaneConfig, err := nginx.GetDataplane(*dataplane)
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid value for flag --dataplane: %w", err)
+	}
+
 	parser.AnnotationsPrefix = *annotationsPrefix
 	parser.EnableAnnotationValidation = *enableAnnotationValidation
 
@@ -274,6 +442,18 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 		return false, nil, fmt.Errorf("port %v is already in use. Please check the flag --https-port", *httpsPort)
 	}
 
+	if (*internalHTTPPort > 0) != (*internalHTTPSPort > 0) {
+		return false, nil, fmt.Errorf("flags --internal-http-port and --internal-https-port must be defined together")
+	}
+
+	if *internalHTTPPort > 0 && !ing_net.IsPortAvailable(*internalHTTPPort) {
+		return false, nil, fmt.Errorf("port %v is already in use. Please check the flag --internal-http-port", *internalHTTPPort)
+	}
+
+	if *internalHTTPSPort > 0 && !ing_net.IsPortAvailable(*internalHTTPSPort) {
+		return false, nil, fmt.Errorf("port %v is already in use. Please check the flag --internal-https-port", *internalHTTPSPort)
+	}
+
 	if !ing_net.IsPortAvailable(*defServerPort) {
 		return false, nil, fmt.Errorf("port %v is already in use. Please check the flag --default-server-port", *defServerPort)
 	}
@@ -313,6 +493,12 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 		return false, nil, fmt.Errorf("flags --watch-namespace and --watch-namespace-selector are mutually exclusive")
 	}
 
+	for _, ns := range k8s.ParseNamespaces(*watchNamespace) {
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return false, nil, fmt.Errorf("invalid namespace %q in --watch-namespace: %v", ns, strings.Join(errs, ", "))
+		}
+	}
+
 	var namespaceSelector labels.Selector
 	if *watchNamespaceSelector != "" {
 		var err error
@@ -326,6 +512,10 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 		return false, nil, errors.New("--metrics-per-undefined-host=true must be passed with --metrics-per-host=true")
 	}
 
+	if *metricsPerEndpoint && *metricsPerEndpointMaxSeries <= 0 {
+		return false, nil, errors.New("--metrics-per-endpoint-max-series must be greater than 0")
+	}
+
 	if *electionTTL <= 0 {
 		*electionTTL = 30 * time.Second
 	}
@@ -339,50 +529,76 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 	ngx_config.EnableSSLChainCompletion = *enableSSLChainCompletion
 
 	config := &controller.Configuration{
-		APIServerHost:               *apiserverHost,
-		KubeConfigFile:              *kubeConfigFile,
-		UpdateStatus:                *updateStatus,
-		ElectionID:                  *electionID,
-		ElectionTTL:                 *electionTTL,
-		EnableProfiling:             *profiling,
-		EnableMetrics:               *enableMetrics,
-		MetricsPerHost:              *metricsPerHost,
-		MetricsPerUndefinedHost:     *metricsPerUndefinedHost,
-		MetricsBuckets:              histogramBuckets,
-		MetricsBucketFactor:         *bucketFactor,
-		MetricsMaxBuckets:           *maxBuckets,
-		ReportStatusClasses:         *reportStatusClasses,
-		ExcludeSocketMetrics:        *excludeSocketMetrics,
-		MonitorMaxBatchSize:         *monitorMaxBatchSize,
-		DisableServiceExternalName:  *disableServiceExternalName,
-		EnableSSLPassthrough:        *enableSSLPassthrough,
-		DisableLeaderElection:       *disableLeaderElection,
-		ResyncPeriod:                *resyncPeriod,
-		DefaultService:              *defaultSvc,
-		Namespace:                   *watchNamespace,
-		WatchNamespaceSelector:      namespaceSelector,
-		ConfigMapName:               *configMap,
-		TCPConfigMapName:            *tcpConfigMapName,
-		UDPConfigMapName:            *udpConfigMapName,
-		DisableFullValidationTest:   *disableFullValidationTest,
-		DefaultSSLCertificate:       *defSSLCertificate,
-		DeepInspector:               *deepInspector,
-		PublishService:              *publishSvc,
-		PublishStatusAddress:        *publishStatusAddress,
-		UpdateStatusOnShutdown:      *updateStatusOnShutdown,
-		ShutdownGracePeriod:         *shutdownGracePeriod,
-		PostShutdownGracePeriod:     *postShutdownGracePeriod,
-		UseNodeInternalIP:           *useNodeInternalIP,
-		SyncRateLimit:               *syncRateLimit,
-		HealthCheckHost:             *healthzHost,
-		DynamicConfigurationRetries: *dynamicConfigurationRetries,
-		EnableTopologyAwareRouting:  *enableTopologyAwareRouting,
+		APIServerHost:                 *apiserverHost,
+		KubeConfigFile:                *kubeConfigFile,
+		UpdateStatus:                  *updateStatus,
+		ElectionID:                    *electionID,
+		ElectionTTL:                   *electionTTL,
+		EnableProfiling:               *profiling,
+		EnableMetrics:                 *enableMetrics,
+		MetricsCollector:              *metricsCollector,
+		MetricsPerHost:                *metricsPerHost,
+		MetricsPerUndefinedHost:       *metricsPerUndefinedHost,
+		MetricsBuckets:                histogramBuckets,
+		MetricsBucketFactor:           *bucketFactor,
+		MetricsMaxBuckets:             *maxBuckets,
+		ReportStatusClasses:           *reportStatusClasses,
+		MetricsPerEndpoint:            *metricsPerEndpoint,
+		MetricsPerEndpointMaxSeries:   *metricsPerEndpointMaxSeries,
+		ExcludeSocketMetrics:          *excludeSocketMetrics,
+		MetricsLabels:                 metricsLabelNames(*metricsLabels, *observabilityLabels),
+		ObservabilityLabels:           *observabilityLabels,
+		MonitorMaxBatchSize:           *monitorMaxBatchSize,
+		DisableServiceExternalName:    *disableServiceExternalName,
+		EnableSSLPassthrough:          *enableSSLPassthrough,
+		DisableLeaderElection:         *disableLeaderElection,
+		ResyncPeriod:                  *resyncPeriod,
+		DefaultService:                *defaultSvc,
+		Namespace:                     *watchNamespace,
+		WatchNamespaceSelector:        namespaceSelector,
+		ConfigMapName:                 *configMap,
+		TCPConfigMapName:              *tcpConfigMapName,
+		UDPConfigMapName:              *udpConfigMapName,
+		CustomDomainsConfigMapName:    *customDomainsConfigMapName,
+		DisableFullValidationTest:     *disableFullValidationTest,
+		SyntaxOnlyValidationTest:      *syntaxOnlyValidationTest,
+		AdmissionMaxRenderSeconds:     *admissionMaxRenderSeconds,
+		AdmissionMaxIngressesRendered: *admissionMaxIngressesRendered,
+		OptimizeConfiguration:         *optimizeConfiguration,
+		ConfigSizeBudget:              *configSizeBudget * 1024 * 1024,
+		SplitConfiguration:            *splitConfiguration,
+		ReloadTimeout:                 *reloadTimeout,
+		BinaryUpgradeReload:           *binaryUpgradeReload,
+		LogLevelDuration:              *logLevelDuration,
+		Offline:                       *offline,
+		DefaultSSLCertificate:         *defSSLCertificate,
+		DeepInspector:                 *deepInspector,
+		PublishService:                *publishSvc,
+		PublishStatusAddress:          *publishStatusAddress,
+		UpdateStatusOnShutdown:        *updateStatusOnShutdown,
+		StatusRemovalHoldTime:         *statusRemovalHoldTime,
+		StatusRemovalObservations:     *statusRemovalObservations,
+		ConfigDriftThreshold:          *configDriftThreshold,
+		ShutdownGracePeriod:           *shutdownGracePeriod,
+		PostShutdownGracePeriod:       *postShutdownGracePeriod,
+		UseNodeInternalIP:             *useNodeInternalIP,
+		SyncRateLimit:                 *syncRateLimit,
+		HealthCheckHost:               *healthzHost,
+		DynamicConfigurationRetries:   *dynamicConfigurationRetries,
+		ConfigurationSnapshot:         *configurationSnapshot,
+		ConfigurationSnapshotTLSKeys:  *configurationSnapshotTLSKeys,
+		ConfigurationExport:           *configurationExport,
+		EnableTopologyAwareRouting:    *enableTopologyAwareRouting,
+		Dataplane:                     dataplaneConfig,
 		ListenPorts: &ngx_config.ListenPorts{
 			Default:  *defServerPort,
 			Health:   *healthzPort,
 			HTTP:     *httpPort,
 			HTTPS:    *httpsPort,
 			SSLProxy: *sslProxyPort,
+
+			InternalHTTP:  *internalHTTPPort,
+			InternalHTTPS: *internalHTTPSPort,
 		},
 		IngressClassConfiguration: &ingressclass.Configuration{
 			Controller:         *ingressClassController,
@@ -390,6 +606,10 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 			WatchWithoutClass:  *watchWithoutClass,
 			IngressClassByName: *ingressClassByName,
 		},
+		Shard: &shard.Configuration{
+			Count: *shardCount,
+			Index: *shardIndex,
+		},
 		DisableCatchAll:           *disableCatchAll,
 		ValidationWebhook:         *validationWebhook,
 		ValidationWebhookCertPath: *validationWebhookCert,
@@ -402,12 +622,13 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 		config.RootCAFile = *rootCAFile
 	}
 
-	var err error
 	if nginx.MaxmindEditionIDs != "" {
 		if err := nginx.ValidateGeoLite2DBEditions(); err != nil {
 			return false, nil, err
 		}
-		if nginx.MaxmindLicenseKey != "" || nginx.MaxmindMirror != "" {
+		if *offline {
+			klog.InfoS("offline mode, using the maxmind GeoIP2 databases present on disk")
+		} else if nginx.MaxmindLicenseKey != "" || nginx.MaxmindMirror != "" {
 			klog.InfoS("downloading maxmind GeoIP2 databases")
 			if err = nginx.DownloadGeoLite2DB(nginx.MaxmindRetriesCount, nginx.MaxmindRetriesTimeout); err != nil {
 				klog.ErrorS(err, "unexpected error downloading GeoIP2 database")
@@ -419,6 +640,20 @@ https://blog.maxmind.com/2019/12/significant-changes-to-accessing-and-using-geol
 	return false, config, err
 }
 
+// metricsLabelNames returns the names of the labels of the socket request
+// metrics defined by --metrics-labels and --observability-labels
+func metricsLabelNames(metricsLabels, observabilityLabels []string) []string {
+	names := append([]string{}, metricsLabels...)
+	for _, key := range observabilityLabels {
+		name := controller.ObservabilityLabelName(key)
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
 // ResetForTesting clears all flag state and sets the usage function as directed.
 // After calling resetForTesting, parse errors in flag handling will not
 // exit the program.
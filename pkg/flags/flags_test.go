@@ -72,6 +72,45 @@ func TestFlagConflict(t *testing.T) {
 	}
 }
 
+func TestOfflineFlagConflict(t *testing.T) {
+	ResetForTesting(func() { t.Fatal("Parsing failed") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--http-port", "0", "--https-port", "0", "--offline", "--enable-ssl-chain-completion"}
+
+	_, _, err := ParseFlags()
+	if err == nil {
+		t.Fatalf("Expected an error parsing flags but none returned")
+	}
+}
+
+func TestWatchNamespaces(t *testing.T) {
+	ResetForTesting(func() { t.Fatal("Parsing failed") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--http-port", "0", "--https-port", "0", "--watch-namespace", "team-a,team-b"}
+
+	_, conf, err := ParseFlags()
+	if err != nil {
+		t.Fatalf("Unexpected error parsing flags: %v", err)
+	}
+
+	if conf.Namespace != "team-a,team-b" {
+		t.Errorf("expected namespaces team-a,team-b but %v returned", conf.Namespace)
+	}
+
+	ResetForTesting(func() { t.Fatal("Parsing failed") })
+
+	os.Args = []string{"cmd", "--http-port", "0", "--https-port", "0", "--watch-namespace", "team-a,Team_B"}
+
+	_, _, err = ParseFlags()
+	if err == nil {
+		t.Fatalf("Expected an error parsing flags but none returned")
+	}
+}
+
 func TestMaxmindEdition(t *testing.T) {
 	ResetForTesting(func() { t.Fatal("Parsing failed") })
 
@@ -226,6 +265,19 @@ func TestMetricsPerUndefinedHost(t *testing.T) {
 	}
 }
 
+func TestMetricsPerEndpointMaxSeries(t *testing.T) {
+	ResetForTesting(func() { t.Fatal("Parsing failed") })
+
+	oldArgs := os.Args
+	defer func() { os.Args = oldArgs }()
+	os.Args = []string{"cmd", "--metrics-per-endpoint=true", "--metrics-per-endpoint-max-series=0"}
+
+	_, _, err := ParseFlags()
+	if err == nil {
+		t.Fatalf("Expected an error parsing flags but none returned")
+	}
+}
+
 func TestMetricsPerUndefinedHostWithMetricsPerHostFalse(t *testing.T) {
 	ResetForTesting(func() { t.Fatal("Parsing failed") })
 
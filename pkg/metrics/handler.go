@@ -50,7 +50,13 @@ func RegisterMetrics(reg *prometheus.Registry, mux *http.ServeMux) {
 	)
 }
 
-func RegisterProfiler(host string, port int) {
+// DebugHandler defines an additional endpoint exposed in the profiler server
+type DebugHandler struct {
+	Path    string
+	Handler http.HandlerFunc
+}
+
+func RegisterProfiler(host string, port int, handlers ...DebugHandler) {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/debug/pprof/", pprof.Index)
@@ -64,6 +70,10 @@ func RegisterProfiler(host string, port int) {
 	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
 	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
 
+	for _, h := range handlers {
+		mux.HandleFunc(h.Path, h.Handler)
+	}
+
 	server := &http.Server{
 		Addr: fmt.Sprintf("%s:%d", host, port),
 		// G112 (CWE-400): Potential Slowloris Attack
@@ -0,0 +1,123 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcpproxy
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// the versions of the PROXY protocol header
+const (
+	ProxyProtocolV1 = 1
+	ProxyProtocolV2 = 2
+)
+
+// See: https://www.haproxy.org/download/2.9/doc/proxy-protocol.txt
+var proxyProtocolV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+const (
+	pp2CommandLocal  = 0x20
+	pp2CommandProxy  = 0x21
+	pp2FamilyUnspec  = 0x00
+	pp2FamilyTCP4    = 0x11
+	pp2FamilyTCP6    = 0x21
+	pp2TypeAuthority = 0x02
+)
+
+// TLV is a Type-Length-Value vector appended to the PROXY protocol v2 header
+type TLV struct {
+	Type  byte
+	Value []byte
+}
+
+// proxyProtocolHeader returns the PROXY protocol header of a connection
+// from the client address src to the local address dst. The version 2
+// header carries the SNI of the connection, when known, in a
+// PP2_TYPE_AUTHORITY TLV followed by the tlvs.
+func proxyProtocolHeader(version int, src, dst net.Addr, authority string, tlvs []TLV) []byte {
+	srcAddr, srcOk := src.(*net.TCPAddr)
+	dstAddr, dstOk := dst.(*net.TCPAddr)
+	if !srcOk || !dstOk {
+		srcAddr, dstAddr = nil, nil
+	}
+
+	if version == ProxyProtocolV2 {
+		return proxyProtocolV2Header(srcAddr, dstAddr, authority, tlvs)
+	}
+	return proxyProtocolV1Header(srcAddr, dstAddr)
+}
+
+func proxyProtocolV1Header(src, dst *net.TCPAddr) []byte {
+	if src == nil || dst == nil {
+		return []byte("PROXY UNKNOWN\r\n")
+	}
+
+	protocol := "TCP6"
+	if src.IP.To4() != nil {
+		protocol = "TCP4"
+	}
+	return []byte(fmt.Sprintf("PROXY %s %s %s %d %d\r\n", protocol, src.IP.String(), dst.IP.String(), src.Port, dst.Port))
+}
+
+func proxyProtocolV2Header(src, dst *net.TCPAddr, authority string, tlvs []TLV) []byte {
+	command := byte(pp2CommandProxy)
+	family := byte(pp2FamilyUnspec)
+	var payload []byte
+
+	switch {
+	case src == nil || dst == nil:
+		// the receiver must ignore the addresses of a LOCAL header
+		command = pp2CommandLocal
+	case src.IP.To4() != nil && dst.IP.To4() != nil:
+		family = pp2FamilyTCP4
+		payload = append(payload, src.IP.To4()...)
+		payload = append(payload, dst.IP.To4()...)
+	default:
+		family = pp2FamilyTCP6
+		payload = append(payload, src.IP.To16()...)
+		payload = append(payload, dst.IP.To16()...)
+	}
+	if family != pp2FamilyUnspec {
+		//nolint:gosec // The ports are between 0 and 65535
+		payload = binary.BigEndian.AppendUint16(payload, uint16(src.Port))
+		//nolint:gosec // The ports are between 0 and 65535
+		payload = binary.BigEndian.AppendUint16(payload, uint16(dst.Port))
+	}
+
+	if authority != "" {
+		payload = appendTLV(payload, TLV{Type: pp2TypeAuthority, Value: []byte(authority)})
+	}
+	for _, tlv := range tlvs {
+		payload = appendTLV(payload, tlv)
+	}
+
+	header := make([]byte, 0, len(proxyProtocolV2Signature)+4+len(payload))
+	header = append(header, proxyProtocolV2Signature...)
+	header = append(header, command, family)
+	//nolint:gosec // The TLVs of the annotations are shorter than 256 bytes
+	header = binary.BigEndian.AppendUint16(header, uint16(len(payload)))
+	return append(header, payload...)
+}
+
+func appendTLV(b []byte, tlv TLV) []byte {
+	b = append(b, tlv.Type)
+	//nolint:gosec // The TLVs of the annotations are shorter than 256 bytes
+	b = binary.BigEndian.AppendUint16(b, uint16(len(tlv.Value)))
+	return append(b, tlv.Value...)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcpproxy
+
+import (
+	"bytes"
+	"net"
+	"testing"
+)
+
+func TestProxyProtocolHeader(t *testing.T) {
+	src4 := &net.TCPAddr{IP: net.ParseIP("192.168.0.1"), Port: 56324}
+	dst4 := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 443}
+	src6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 56324}
+	dst6 := &net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 443}
+	signature := "\r\n\r\n\x00\r\nQUIT\n"
+
+	testCases := []struct {
+		name      string
+		version   int
+		src, dst  net.Addr
+		authority string
+		tlvs      []TLV
+		expected  string
+	}{
+		{"v1 tcp4", ProxyProtocolV1, src4, dst4, "example.com", nil, "PROXY TCP4 192.168.0.1 10.0.0.1 56324 443\r\n"},
+		{"v1 tcp6", ProxyProtocolV1, src6, dst6, "", nil, "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"},
+		{"v1 by default", 0, src4, dst4, "", nil, "PROXY TCP4 192.168.0.1 10.0.0.1 56324 443\r\n"},
+		{"v1 unknown", ProxyProtocolV1, &net.UnixAddr{Name: "@", Net: "unix"}, dst4, "", nil, "PROXY UNKNOWN\r\n"},
+		{
+			"v2 tcp4", ProxyProtocolV2, src4, dst4, "", nil,
+			signature + "\x21\x11\x00\x0c" + "\xc0\xa8\x00\x01" + "\x0a\x00\x00\x01" + "\xdc\x04" + "\x01\xbb",
+		},
+		{
+			"v2 tcp4 with tlvs", ProxyProtocolV2, src4, dst4, "a.io", []TLV{{Type: 0xE0, Value: []byte("zone")}},
+			signature + "\x21\x11\x00\x1a" + "\xc0\xa8\x00\x01" + "\x0a\x00\x00\x01" + "\xdc\x04" + "\x01\xbb" +
+				"\x02\x00\x04a.io" + "\xe0\x00\x04zone",
+		},
+		{
+			"v2 tcp6", ProxyProtocolV2, src6, dst6, "", nil,
+			signature + "\x21\x21\x00\x24" +
+				"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01" +
+				"\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02" +
+				"\xdc\x04" + "\x01\xbb",
+		},
+		{"v2 local", ProxyProtocolV2, &net.UnixAddr{Name: "@", Net: "unix"}, dst4, "", nil, signature + "\x20\x00\x00\x00"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			header := proxyProtocolHeader(tc.version, tc.src, tc.dst, tc.authority, tc.tlvs)
+			if !bytes.Equal(header, []byte(tc.expected)) {
+				t.Errorf("expected header %q but got %q", tc.expected, header)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcpproxy
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync/atomic"
+)
+
+const (
+	// RouteModePassthrough is the mode of the routes passing the TLS
+	// connections through to a backend
+	RouteModePassthrough = "passthrough"
+	// RouteModeTerminate is the mode of the routes of the servers terminating
+	// TLS in NGINX
+	RouteModeTerminate = "terminate"
+	// RouteModeDefault is the mode of the route of the connections without
+	// SNI or with an unknown SNI, terminated by the default server of NGINX
+	RouteModeDefault = "default"
+)
+
+type routeStats struct {
+	active atomic.Int64
+	total  atomic.Int64
+	errors atomic.Int64
+}
+
+// ConnectionStats counts the connections of a route
+type ConnectionStats struct {
+	// Active is the number of connections in progress
+	Active int64 `json:"active"`
+	// Total is the number of connections since the start of the controller
+	Total int64 `json:"total"`
+	// Errors is the number of connections not proxied, because the
+	// destination of the route could not be reached
+	Errors int64 `json:"errors"`
+}
+
+func (s *routeStats) snapshot() ConnectionStats {
+	return ConnectionStats{
+		Active: s.active.Load(),
+		Total:  s.total.Load(),
+		Errors: s.errors.Load(),
+	}
+}
+
+// Route describes where the TLS connections with an SNI are proxied
+type Route struct {
+	// Hostname is the SNI of the connections, empty for the default route
+	Hostname string `json:"hostname,omitempty"`
+	// Mode is passthrough, terminate or default
+	Mode string `json:"mode"`
+	// Backend is the address of the destination of the passthrough routes
+	Backend string `json:"backend,omitempty"`
+	// ProxyProtocol is true when the PROXY protocol header is sent to the backend
+	ProxyProtocol bool `json:"proxyProtocol,omitempty"`
+	// ProxyProtocolVersion is the version of the PROXY protocol header
+	ProxyProtocolVersion int `json:"proxyProtocolVersion,omitempty"`
+	// Connections counts the connections of the route
+	Connections ConnectionStats `json:"connections"`
+}
+
+// Routes returns the passthrough routes, the routes of the servers
+// terminating TLS in NGINX, then the default route, each group sorted by
+// hostname
+func (p *TCPProxy) Routes() []Route {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	routes := []Route{}
+	passthrough := map[string]bool{}
+	for _, s := range p.ServerList {
+		if passthrough[s.Hostname] {
+			continue
+		}
+		passthrough[s.Hostname] = true
+
+		route := Route{
+			Hostname:      s.Hostname,
+			Mode:          RouteModePassthrough,
+			Backend:       net.JoinHostPort(s.IP, fmt.Sprintf("%v", s.Port)),
+			ProxyProtocol: s.ProxyProtocol,
+		}
+		if s.ProxyProtocol {
+			route.ProxyProtocolVersion = ProxyProtocolV1
+			if s.ProxyProtocolVersion == ProxyProtocolV2 {
+				route.ProxyProtocolVersion = ProxyProtocolV2
+			}
+		}
+		if stats, ok := p.stats[s.Hostname]; ok {
+			route.Connections = stats.snapshot()
+		}
+		routes = append(routes, route)
+	}
+
+	terminated := []Route{}
+	for _, hostname := range p.terminatedHosts {
+		if passthrough[hostname] {
+			continue
+		}
+		route := Route{
+			Hostname: hostname,
+			Mode:     RouteModeTerminate,
+		}
+		if stats, ok := p.stats[hostname]; ok {
+			route.Connections = stats.snapshot()
+		}
+		terminated = append(terminated, route)
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].Hostname < routes[j].Hostname })
+	sort.SliceStable(terminated, func(i, j int) bool { return terminated[i].Hostname < terminated[j].Hostname })
+
+	routes = append(routes, terminated...)
+	return append(routes, Route{
+		Mode:        RouteModeDefault,
+		Connections: p.defaultStats.snapshot(),
+	})
+}
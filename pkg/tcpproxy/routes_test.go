@@ -0,0 +1,81 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tcpproxy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRoutes(t *testing.T) {
+	p := &TCPProxy{
+		Default: &TCPServer{Hostname: "localhost", IP: "127.0.0.1", Port: 442, ProxyProtocol: true},
+	}
+	p.SetServers([]*TCPServer{
+		{Hostname: "pass.example.com", IP: "10.0.0.1", Port: 443},
+	}, []string{"www.example.com", "*.example.org"})
+
+	for _, host := range []string{"pass.example.com", "www.example.com", "app.example.org", "other.example.com", ""} {
+		proxy, stats := p.route(host)
+		stats.total.Add(1)
+		if proxy == nil {
+			t.Fatalf("expected a server for %q", host)
+		}
+	}
+	_, stats := p.route("pass.example.com")
+	stats.active.Add(1)
+	stats.errors.Add(1)
+
+	expected := []Route{
+		{Hostname: "pass.example.com", Mode: RouteModePassthrough, Backend: "10.0.0.1:443", Connections: ConnectionStats{Active: 1, Total: 1, Errors: 1}},
+		{Hostname: "*.example.org", Mode: RouteModeTerminate, Connections: ConnectionStats{Total: 1}},
+		{Hostname: "www.example.com", Mode: RouteModeTerminate, Connections: ConnectionStats{Total: 1}},
+		{Mode: RouteModeDefault, Connections: ConnectionStats{Total: 2}},
+	}
+	if routes := p.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expected routes %+v but got %+v", expected, routes)
+	}
+
+	// the counters of the hostnames kept are preserved
+	p.SetServers(nil, []string{"www.example.com"})
+	expected = []Route{
+		{Hostname: "www.example.com", Mode: RouteModeTerminate, Connections: ConnectionStats{Total: 1}},
+		{Mode: RouteModeDefault, Connections: ConnectionStats{Total: 2}},
+	}
+	if routes := p.Routes(); !reflect.DeepEqual(routes, expected) {
+		t.Errorf("expected routes %+v but got %+v", expected, routes)
+	}
+}
+
+func TestRoute(t *testing.T) {
+	p := &TCPProxy{
+		Default: &TCPServer{Hostname: "localhost", IP: "127.0.0.1", Port: 442},
+	}
+
+	proxy, stats := p.route("www.example.com")
+	if proxy != p.Default || stats != &p.defaultStats {
+		t.Errorf("expected the default route without servers")
+	}
+
+	p.SetServers([]*TCPServer{{Hostname: "pass.example.com", IP: "10.0.0.1", Port: 443}}, nil)
+	if proxy, _ := p.route("pass.example.com"); proxy.IP != "10.0.0.1" {
+		t.Errorf("expected the passthrough server but got %+v", proxy)
+	}
+	if proxy := p.Get("www.example.com"); proxy != p.Default {
+		t.Errorf("expected the default server but got %+v", proxy)
+	}
+}
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strings"
+	"sync"
 
 	"k8s.io/klog/v2"
 
@@ -32,16 +34,66 @@ type TCPServer struct {
 	IP            string
 	Port          int
 	ProxyProtocol bool
+	// ProxyProtocolVersion is the version of the PROXY protocol header,
+	// the version 1 unless ProxyProtocolV2
+	ProxyProtocolVersion int
+	// ProxyProtocolTLVs are appended to the version 2 header
+	ProxyProtocolTLVs []TLV
 }
 
 // TCPProxy describes the passthrough servers and a default as catch all.
 type TCPProxy struct {
 	ServerList []*TCPServer
 	Default    *TCPServer
+
+	// mu protects ServerList and the connection counters replaced by SetServers
+	mu sync.RWMutex
+	// stats are the connection counters of the passthrough servers and of
+	// the servers terminating TLS in NGINX, by hostname
+	stats map[string]*routeStats
+	// terminatedHosts are the hostnames of the servers terminating TLS in NGINX
+	terminatedHosts []string
+	// defaultStats counts the connections to the default server without
+	// a known hostname
+	defaultStats routeStats
+}
+
+// SetServers replaces the passthrough servers, and the hostnames of the
+// servers terminating TLS in NGINX whose connections are counted. The
+// counters of the hostnames kept are preserved.
+func (p *TCPProxy) SetServers(servers []*TCPServer, terminatedHosts []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := make(map[string]*routeStats, len(servers)+len(terminatedHosts))
+	keep := func(hostname string) {
+		if s, ok := p.stats[hostname]; ok {
+			stats[hostname] = s
+		} else {
+			stats[hostname] = &routeStats{}
+		}
+	}
+	for _, s := range servers {
+		keep(s.Hostname)
+	}
+	for _, hostname := range terminatedHosts {
+		keep(hostname)
+	}
+
+	p.ServerList = servers
+	p.terminatedHosts = terminatedHosts
+	p.stats = stats
 }
 
 // Get returns the TCPServer to use for a given host.
 func (p *TCPProxy) Get(host string) *TCPServer {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return p.get(host)
+}
+
+func (p *TCPProxy) get(host string) *TCPServer {
 	if p.ServerList == nil {
 		return p.Default
 	}
@@ -55,6 +107,32 @@ func (p *TCPProxy) Get(host string) *TCPServer {
 	return p.Default
 }
 
+// route returns the TCPServer to use for a given host and the counters of
+// its connections
+func (p *TCPProxy) route(host string) (*TCPServer, *routeStats) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	proxy := p.get(host)
+	if proxy != nil && proxy != p.Default {
+		if s, ok := p.stats[proxy.Hostname]; ok {
+			return proxy, s
+		}
+	}
+
+	if s, ok := p.stats[host]; ok && host != "" {
+		return proxy, s
+	}
+	// the servers with a wildcard hostname match a single label
+	if i := strings.Index(host, "."); i > 0 {
+		if s, ok := p.stats["*"+host[i:]]; ok {
+			return proxy, s
+		}
+	}
+
+	return proxy, &p.defaultStats
+}
+
 // Handle reads enough information from the connection to extract the hostname
 // and open a connection to the passthrough server.
 func (p *TCPProxy) Handle(conn net.Conn) {
@@ -68,15 +146,21 @@ func (p *TCPProxy) Handle(conn net.Conn) {
 		return
 	}
 
-	proxy := p.Default
 	hostname, err := parser.GetHostname(data)
 	if err == nil {
 		klog.V(4).InfoS("TLS Client Hello", "host", hostname)
-		proxy = p.Get(hostname)
+	} else {
+		hostname = ""
 	}
+	proxy, stats := p.route(hostname)
+
+	stats.total.Add(1)
+	stats.active.Add(1)
+	defer stats.active.Add(-1)
 
 	if proxy == nil {
 		klog.V(4).InfoS("There is no configured proxy for SSL connections.")
+		stats.errors.Add(1)
 		return
 	}
 
@@ -85,29 +169,16 @@ func (p *TCPProxy) Handle(conn net.Conn) {
 	clientConn, err := net.Dial("tcp", hostPort)
 	if err != nil {
 		klog.V(4).ErrorS(err, "error dialing proxy", "ip", proxy.IP, "port", proxy.Port, "hostname", proxy.Hostname)
+		stats.errors.Add(1)
 		return
 	}
 	defer clientConn.Close()
 
 	if proxy.ProxyProtocol {
 		// write out the Proxy Protocol header
-		localAddr, ok := conn.LocalAddr().(*net.TCPAddr)
-		if !ok {
-			klog.Errorf("unexpected type: %T", conn.LocalAddr())
-		}
-		remoteAddr, ok := conn.RemoteAddr().(*net.TCPAddr)
-		if !ok {
-			klog.Errorf("unexpected type: %T", conn.RemoteAddr())
-		}
-		protocol := "UNKNOWN"
-		if remoteAddr.IP.To4() != nil {
-			protocol = "TCP4"
-		} else if remoteAddr.IP.To16() != nil {
-			protocol = "TCP6"
-		}
-		proxyProtocolHeader := fmt.Sprintf("PROXY %s %s %s %d %d\r\n", protocol, remoteAddr.IP.String(), localAddr.IP.String(), remoteAddr.Port, localAddr.Port)
-		klog.V(4).InfoS("Writing Proxy Protocol", "header", proxyProtocolHeader)
-		_, err = fmt.Fprint(clientConn, proxyProtocolHeader)
+		header := proxyProtocolHeader(proxy.ProxyProtocolVersion, conn.RemoteAddr(), conn.LocalAddr(), hostname, proxy.ProxyProtocolTLVs)
+		klog.V(4).InfoS("Writing Proxy Protocol", "version", proxy.ProxyProtocolVersion, "header", fmt.Sprintf("%q", header))
+		_, err = clientConn.Write(header)
 	}
 	if err != nil {
 		klog.ErrorS(err, "Error writing Proxy Protocol header")
@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authoidc"
 	"k8s.io/ingress-nginx/internal/k8s"
 	"k8s.io/ingress-nginx/internal/net/ssl"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
@@ -127,9 +128,54 @@ func IsDynamicConfigurationEnough(newcfg, oldcfg *ingress.Configuration) bool {
 	clearCertificates(&copyOfRunningConfig)
 	clearCertificates(&copyOfPcfg)
 
+	copyOfRunningConfig.CustomDomains = nil
+	copyOfPcfg.CustomDomains = nil
+
+	clearAuthCredentials(&copyOfRunningConfig)
+	clearAuthCredentials(&copyOfPcfg)
+
+	clearLuaKV(&copyOfRunningConfig)
+	clearLuaKV(&copyOfPcfg)
+
 	return copyOfRunningConfig.Equal(&copyOfPcfg)
 }
 
+// IsServerAliasesAddition returns whether the only difference between the
+// configurations that cannot be applied dynamically is the addition of server aliases.
+// Removing an alias always requires a reload.
+func IsServerAliasesAddition(newcfg, oldcfg *ingress.Configuration) bool {
+	newAliases := make(map[string]sets.String, len(newcfg.Servers))
+	for _, server := range newcfg.Servers {
+		newAliases[server.Hostname] = sets.NewString(server.Aliases...)
+	}
+
+	for _, server := range oldcfg.Servers {
+		aliases, ok := newAliases[server.Hostname]
+		if !ok || !aliases.HasAll(server.Aliases...) {
+			return false
+		}
+	}
+
+	copyOfRunningConfig := *oldcfg
+	copyOfPcfg := *newcfg
+
+	clearServerAliases(&copyOfRunningConfig)
+	clearServerAliases(&copyOfPcfg)
+
+	return IsDynamicConfigurationEnough(&copyOfPcfg, &copyOfRunningConfig)
+}
+
+// clearServerAliases is a helper function to clear the aliases of the servers
+func clearServerAliases(config *ingress.Configuration) {
+	clearedServers := make([]*ingress.Server, 0, len(config.Servers))
+	for _, server := range config.Servers {
+		copyOfServer := *server
+		copyOfServer.Aliases = nil
+		clearedServers = append(clearedServers, &copyOfServer)
+	}
+	config.Servers = clearedServers
+}
+
 // clearL4serviceEndpoints is a helper function to clear endpoints from the ingress configuration since they should be ignored when
 // checking if the new configuration changes can be applied dynamically.
 func clearL4serviceEndpoints(config *ingress.Configuration) {
@@ -164,15 +210,67 @@ func clearCertificates(config *ingress.Configuration) {
 	for _, server := range config.Servers {
 		copyOfServer := *server
 		copyOfServer.SSLCert = nil
+		copyOfServer.AlternateSSLCert = nil
+		clearedServers = append(clearedServers, &copyOfServer)
+	}
+	config.Servers = clearedServers
+}
+
+// clearAuthCredentials is a helper function to clear the hash of the credentials kept in memory from the ingress configuration
+// since they are updated dynamically. The credentials of the OpenID Connect clients are always kept in memory.
+func clearAuthCredentials(config *ingress.Configuration) {
+	clearedServers := make([]*ingress.Server, 0, len(config.Servers))
+	for _, server := range config.Servers {
+		copyOfServer := *server
+		copyOfServer.Locations = make([]*ingress.Location, 0, len(server.Locations))
+		for _, location := range server.Locations {
+			if !location.BasicDigestAuth.InMemory && location.OIDCAuth.Key == "" {
+				copyOfServer.Locations = append(copyOfServer.Locations, location)
+				continue
+			}
+
+			copyOfLocation := *location
+			if location.BasicDigestAuth.InMemory {
+				copyOfLocation.BasicDigestAuth.FileSHA = ""
+				copyOfLocation.BasicDigestAuth.Credentials = nil
+			}
+			copyOfLocation.OIDCAuth.ClientSHA = ""
+			copyOfLocation.OIDCAuth.Client = authoidc.Client{}
+			copyOfServer.Locations = append(copyOfServer.Locations, &copyOfLocation)
+		}
+		clearedServers = append(clearedServers, &copyOfServer)
+	}
+	config.Servers = clearedServers
+}
+
+// clearLuaKV is a helper function to clear the hash of the key/value stores of the Lua code from the ingress configuration
+// since they are updated dynamically.
+func clearLuaKV(config *ingress.Configuration) {
+	clearedServers := make([]*ingress.Server, 0, len(config.Servers))
+	for _, server := range config.Servers {
+		copyOfServer := *server
+		copyOfServer.Locations = make([]*ingress.Location, 0, len(server.Locations))
+		for _, location := range server.Locations {
+			if location.LuaKV.ConfigMap == "" {
+				copyOfServer.Locations = append(copyOfServer.Locations, location)
+				continue
+			}
+
+			copyOfLocation := *location
+			copyOfLocation.LuaKV.DataSHA = ""
+			copyOfLocation.LuaKV.Data = nil
+			copyOfServer.Locations = append(copyOfServer.Locations, &copyOfLocation)
+		}
 		clearedServers = append(clearedServers, &copyOfServer)
 	}
 	config.Servers = clearedServers
 }
 
 type Redirect struct {
-	From    string
-	To      string
-	SSLCert *ingress.SSLCert
+	From     string
+	To       string
+	SSLCert  *ingress.SSLCert
+	Internal bool
 }
 
 // BuildRedirects build the redirects of servers based on configurations and certificates
@@ -213,8 +311,9 @@ func BuildRedirects(servers []*ingress.Server) []*Redirect {
 		}
 
 		r := &Redirect{
-			From: from,
-			To:   to,
+			From:     from,
+			To:       to,
+			Internal: srv.Internal,
 		}
 
 		if srv.SSLCert != nil {
@@ -19,6 +19,9 @@ package ingress
 import (
 	"testing"
 
+	"k8s.io/ingress-nginx/internal/ingress/annotations/auth"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/authoidc"
+	"k8s.io/ingress-nginx/internal/ingress/annotations/luakv"
 	"k8s.io/ingress-nginx/pkg/apis/ingress"
 )
 
@@ -130,3 +133,158 @@ func TestIsDynamicConfigurationEnough(t *testing.T) {
 		t.Errorf("Expected new config to not change")
 	}
 }
+
+func TestIsDynamicConfigurationEnoughAuthCredentials(t *testing.T) {
+	newConfig := func(inMemory bool, fileSHA, realm string) *ingress.Configuration {
+		return &ingress.Configuration{
+			Servers: []*ingress.Server{{
+				Hostname: "myapp.fake",
+				Locations: []*ingress.Location{{
+					Path: "/",
+					BasicDigestAuth: auth.Config{
+						Type:     "basic",
+						Realm:    realm,
+						Secured:  true,
+						FileSHA:  fileSHA,
+						InMemory: inMemory,
+						Key:      "default-ing-secret",
+					},
+				}},
+			}},
+		}
+	}
+
+	runningConfig := newConfig(true, "old", "Restricted")
+	if !IsDynamicConfigurationEnough(newConfig(true, "new", "Restricted"), runningConfig) {
+		t.Errorf("Expected to be dynamically configurable when only the credentials in memory change")
+	}
+
+	if IsDynamicConfigurationEnough(newConfig(true, "new", "Private"), runningConfig) {
+		t.Errorf("Expected to not be dynamically configurable when the realm changes")
+	}
+
+	if IsDynamicConfigurationEnough(newConfig(false, "new", "Restricted"), newConfig(false, "old", "Restricted")) {
+		t.Errorf("Expected to not be dynamically configurable when a password file changes")
+	}
+
+	if runningConfig.Servers[0].Locations[0].BasicDigestAuth.FileSHA != "old" {
+		t.Errorf("Expected running config to not change")
+	}
+}
+
+func TestIsDynamicConfigurationEnoughOIDCClients(t *testing.T) {
+	newConfig := func(clientSecret, scopes string) *ingress.Configuration {
+		return &ingress.Configuration{
+			Servers: []*ingress.Server{{
+				Hostname: "myapp.fake",
+				Locations: []*ingress.Location{{
+					Path: "/",
+					OIDCAuth: authoidc.Config{
+						Issuer:    "https://accounts.example.com",
+						Key:       "default-ing-secret",
+						Scopes:    scopes,
+						ClientSHA: clientSecret,
+						Client:    authoidc.Client{ID: "dashboard", Secret: clientSecret},
+					},
+				}},
+			}},
+		}
+	}
+
+	runningConfig := newConfig("old", "openid")
+	if !IsDynamicConfigurationEnough(newConfig("new", "openid"), runningConfig) {
+		t.Errorf("Expected to be dynamically configurable when only the client credentials change")
+	}
+
+	if IsDynamicConfigurationEnough(newConfig("new", "openid email"), runningConfig) {
+		t.Errorf("Expected to not be dynamically configurable when the scopes change")
+	}
+
+	if runningConfig.Servers[0].Locations[0].OIDCAuth.Client.Secret != "old" {
+		t.Errorf("Expected running config to not change")
+	}
+}
+
+func TestIsDynamicConfigurationEnoughLuaKV(t *testing.T) {
+	newConfig := func(configMap, value string) *ingress.Configuration {
+		data := map[string]string{"key": value}
+		return &ingress.Configuration{
+			Servers: []*ingress.Server{{
+				Hostname: "myapp.fake",
+				Locations: []*ingress.Location{{
+					Path: "/",
+					LuaKV: luakv.Config{
+						ConfigMap: configMap,
+						DataSHA:   value,
+						Data:      data,
+					},
+				}},
+			}},
+		}
+	}
+
+	runningConfig := newConfig("default/kv", "old")
+	if !IsDynamicConfigurationEnough(newConfig("default/kv", "new"), runningConfig) {
+		t.Errorf("Expected to be dynamically configurable when only the data of the store changes")
+	}
+
+	if IsDynamicConfigurationEnough(newConfig("default/other", "new"), runningConfig) {
+		t.Errorf("Expected to not be dynamically configurable when the configmap changes")
+	}
+
+	if runningConfig.Servers[0].Locations[0].LuaKV.DataSHA != "old" {
+		t.Errorf("Expected running config to not change")
+	}
+}
+
+func TestIsServerAliasesAddition(t *testing.T) {
+	backends := []*ingress.Backend{{Name: "fakenamespace-myapp-80"}}
+
+	newServer := func(aliases ...string) *ingress.Server {
+		return &ingress.Server{
+			Hostname: "myapp.fake",
+			Aliases:  aliases,
+			Locations: []*ingress.Location{
+				{
+					Path:    "/",
+					Backend: "fakenamespace-myapp-80",
+				},
+			},
+		}
+	}
+
+	runningConfig := &ingress.Configuration{
+		Backends: backends,
+		Servers:  []*ingress.Server{newServer("alias.fake")},
+	}
+
+	testCases := []struct {
+		title    string
+		servers  []*ingress.Server
+		expected bool
+	}{
+		{"same aliases", []*ingress.Server{newServer("alias.fake")}, true},
+		{"added alias", []*ingress.Server{newServer("alias.fake", "other.fake")}, true},
+		{"removed alias", []*ingress.Server{newServer()}, false},
+		{"replaced alias", []*ingress.Server{newServer("other.fake")}, false},
+		{"added server", []*ingress.Server{newServer("alias.fake"), {Hostname: "another.fake"}}, false},
+		{"removed server", []*ingress.Server{}, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.title, func(t *testing.T) {
+			newConfig := &ingress.Configuration{
+				Backends: backends,
+				Servers:  tc.servers,
+			}
+
+			if result := IsServerAliasesAddition(newConfig, runningConfig); result != tc.expected {
+				t.Errorf("expected %v but %v returned", tc.expected, result)
+			}
+		})
+	}
+
+	if len(runningConfig.Servers[0].Aliases) != 1 {
+		t.Errorf("expected the running configuration to not change")
+	}
+}
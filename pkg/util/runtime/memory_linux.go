@@ -0,0 +1,72 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package runtime
+
+import (
+	libcontainercgroups "github.com/opencontainers/runc/libcontainer/cgroups"
+)
+
+// cgroupV1UnlimitedMemory is the lowest value of memory.limit_in_bytes
+// considered as no limit, the page-aligned maximum of int64 in most kernels
+const cgroupV1UnlimitedMemory = int64(1) << 62
+
+// MemoryLimit returns the memory limit in bytes of the cgroup of the current
+// process, or -1 when the memory is not limited
+func MemoryLimit() int64 {
+	return MemoryLimitWithCustomPath("")
+}
+
+func MemoryLimitWithCustomPath(path string) int64 {
+	cgroupVersionCheckPath := path
+
+	if cgroupVersionCheckPath == "" {
+		cgroupVersionCheckPath = "/sys/fs/cgroup/"
+	}
+
+	limit := int64(-1)
+
+	if GetCgroupVersion(cgroupVersionCheckPath) == 1 {
+		cgroupPath := path
+		if cgroupPath == "" {
+			cgroupPathRd, err := libcontainercgroups.FindCgroupMountpoint("", "memory")
+			if err != nil {
+				return -1
+			}
+			cgroupPath = cgroupPathRd
+		}
+		limit = readCgroupFileToInt64(cgroupPath, "memory.limit_in_bytes")
+		if limit >= cgroupV1UnlimitedMemory {
+			return -1
+		}
+	} else {
+		cgroupPath := "/sys/fs/cgroup/"
+		if path != "" {
+			cgroupPath = path
+		}
+		// the content is "max" without limit, which is not an integer
+		limit = readCgroupFileToInt64(cgroupPath, "memory.max")
+	}
+
+	if limit <= 0 {
+		return -1
+	}
+
+	return limit
+}
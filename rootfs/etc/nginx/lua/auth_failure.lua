@@ -0,0 +1,97 @@
+local ngx = ngx
+local cjson = require("cjson.safe")
+local pairs = pairs
+local type = type
+local string_lower = string.lower
+
+local decisions = ngx.shared.auth_failure_decisions
+
+local _M = {}
+
+-- statuses of the responses of the auth service replayed by the cached mode
+local DECISIONS = {
+  [ngx.HTTP_OK] = true,
+  [ngx.HTTP_UNAUTHORIZED] = true,
+  [ngx.HTTP_FORBIDDEN] = true,
+}
+
+-- decision_key returns the key of the decisions of the credentials of the
+-- request, or nil
+local function decision_key()
+  local key = ngx.var.auth_failure_key
+  if not key then
+    return nil
+  end
+  return ngx.encode_base64(ngx.sha1_bin(key))
+end
+
+-- remember caches the decision of the auth service, without the cookies
+-- it sets
+local function remember(res, ttl)
+  local key = decision_key()
+  if not key then
+    return
+  end
+
+  local header = {}
+  for name, value in pairs(res.header) do
+    if string_lower(name) ~= "set-cookie" then
+      header[name] = value
+    end
+  end
+
+  local decision = cjson.encode({ status = res.status, header = header })
+  local ok, err = decisions:set(key, decision, ttl)
+  if not ok then
+    ngx.log(ngx.WARN, "error caching the decision of the auth service: ", err)
+  end
+end
+
+-- cached returns the last decision of the auth service for the credentials
+-- of the request, or nil
+local function cached()
+  local key = decision_key()
+  if not key then
+    return nil
+  end
+
+  local decision = cjson.decode(decisions:get(key) or "")
+  if type(decision) ~= "table" or not DECISIONS[decision.status] then
+    return nil
+  end
+  if type(decision.header) ~= "table" then
+    decision.header = {}
+  end
+  return decision
+end
+
+-- response returns the response of the auth service to use for the request:
+-- the response of the service, or when the service fails, an allowed response
+-- in the allow mode and the last decision of the service for the same
+-- credentials in the cached mode
+function _M.response(res, mode, ttl)
+  if res.status < ngx.HTTP_INTERNAL_SERVER_ERROR then
+    if mode == "cached" and DECISIONS[res.status] then
+      remember(res, ttl)
+    end
+    return res
+  end
+
+  if mode == "allow" then
+    ngx.log(ngx.WARN, "auth service failed with the status ", res.status, ", allowing the request")
+    return { status = ngx.HTTP_OK, header = {} }
+  end
+
+  if mode == "cached" then
+    local decision = cached()
+    if decision then
+      ngx.log(ngx.WARN, "auth service failed with the status ", res.status,
+        ", replaying its last decision ", decision.status)
+      return decision
+    end
+  end
+
+  return res
+end
+
+return _M
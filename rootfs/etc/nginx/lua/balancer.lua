@@ -9,6 +9,11 @@ local chashsubset = require("balancer.chashsubset")
 local sticky_balanced = require("balancer.sticky_balanced")
 local sticky_persistent = require("balancer.sticky_persistent")
 local ewma = require("balancer.ewma")
+local p2c = require("balancer.p2c")
+local least_latency = require("balancer.least_latency")
+local timeout_budget = require("timeout_budget")
+local expression = require("expression")
+local warmup = require("warmup")
 local string = string
 local ipairs = ipairs
 local table = table
@@ -32,14 +37,25 @@ local IMPLEMENTATIONS = {
   sticky_balanced = sticky_balanced,
   sticky_persistent = sticky_persistent,
   ewma = ewma,
+  p2c = p2c,
+  least_latency = least_latency,
 }
 
 local PROHIBITED_LOCALHOST_PORT = configuration.prohibited_localhost_port or '10246'
 local PROHIBITED_PEER_PATTERN = "^127.*:" .. PROHIBITED_LOCALHOST_PORT .. "$"
 
-local _M = {}
+local IP_FAMILIES = { ipv4 = true, ipv6 = true }
+
+local _M = {
+  -- family of the endpoints tried first when a backend has IPv4 and IPv6
+  -- endpoints, "ipv4" or "ipv6", or nil to balance between all the endpoints
+  ip_family_preference = nil,
+  -- connect timeout in milliseconds of the first try to the preferred family
+  ip_family_fallback_timeout = nil,
+}
 local balancers = {}
 local backends_with_external_name = {}
+local backends_warming_up = {}
 local backends_last_synced_at = 0
 
 local function get_implementation(backend)
@@ -76,7 +92,7 @@ local function resolve_external_names(original_backend)
   local backend = util.deepcopy(original_backend)
   local endpoints = {}
   for _, endpoint in ipairs(backend.endpoints) do
-    local ips = dns_lookup(endpoint.address)
+    local ips = dns_lookup(endpoint.address, backend.externalNameResolution)
     for _, ip in ipairs(ips) do
       table.insert(endpoints, { address = ip, port = endpoint.port })
     end
@@ -97,30 +113,60 @@ local function format_ipv6_endpoints(endpoints)
   return formatted_endpoints
 end
 
-local function is_backend_with_external_name(backend)
-  local serv_type = backend.service and backend.service.spec
-                      and backend.service.spec["type"]
-  return serv_type == "ExternalName"
+local function ip_family(address)
+  if string.find(address, ":", 1, true) then
+    return "ipv6"
+  end
+  return "ipv4"
 end
 
-local function sync_backend(backend)
-  if not backend.endpoints or #backend.endpoints == 0 then
-    balancers[backend.name] = nil
-    return
+-- with_endpoints returns a copy of a backend with other endpoints
+local function with_endpoints(backend, endpoints)
+  local copy = {}
+  for key, value in pairs(backend) do
+    copy[key] = value
   end
+  copy.endpoints = endpoints
+  return copy
+end
 
-  if is_backend_with_external_name(backend) then
-    backend = resolve_external_names(backend)
+-- split_by_ip_family returns the backend restricted to the endpoints of the
+-- preferred family and the backend restricted to the endpoints of the other
+-- family, or the backend and nil when it has endpoints of a single family
+local function split_by_ip_family(backend, preference)
+  if not IP_FAMILIES[preference] then
+    return backend, nil
   end
 
-  backend.endpoints = format_ipv6_endpoints(backend.endpoints)
+  local preferred, other = {}, {}
+  for _, endpoint in ipairs(backend.endpoints) do
+    if ip_family(endpoint.address) == preference then
+      table.insert(preferred, endpoint)
+    else
+      table.insert(other, endpoint)
+    end
+  end
+
+  if #preferred == 0 or #other == 0 then
+    return backend, nil
+  end
+
+  return with_endpoints(backend, preferred), with_endpoints(backend, other)
+end
 
+local function is_backend_with_external_name(backend)
+  local serv_type = backend.service and backend.service.spec
+                      and backend.service.spec["type"]
+  return serv_type == "ExternalName"
+end
+
+-- sync_balancer returns the balancer of a backend, the existing balancer
+-- synced with the backend or a new balancer
+local function sync_balancer(balancer, backend)
   local implementation = get_implementation(backend)
-  local balancer = balancers[backend.name]
 
   if not balancer then
-    balancers[backend.name] = implementation:new(backend)
-    return
+    return implementation:new(backend)
   end
 
   -- every implementation is the metatable of its instances (see .new(...) functions)
@@ -130,16 +176,76 @@ local function sync_backend(backend)
     ngx.log(ngx.INFO,
         string.format("LB algorithm changed from %s to %s, resetting the instance",
                       balancer.name, implementation.name))
-    balancers[backend.name] = implementation:new(backend)
-    return
+    return implementation:new(backend)
   end
 
   balancer:sync(backend)
+  return balancer
+end
+
+-- warm_up returns the backend restricted to its endpoints load balanced by
+-- the worker, the backend being synced again until its new endpoints are
+-- warmed up
+local function warm_up(backend)
+  backends_warming_up[backend.name] = nil
+
+  local endpoints, warming = warmup.endpoints(backend)
+  if not warming then
+    return backend
+  end
+
+  backends_warming_up[backend.name] = util.deepcopy(backend)
+  return with_endpoints(backend, endpoints)
+end
+
+local function sync_backend(backend)
+  if not backend.endpoints or #backend.endpoints == 0 then
+    balancers[backend.name] = nil
+    backends_warming_up[backend.name] = nil
+    warmup.forget(backend.name)
+    return
+  end
+
+  if is_backend_with_external_name(backend) then
+    backend = resolve_external_names(backend)
+  else
+    backend = warm_up(backend)
+  end
+
+  backend.endpoints = format_ipv6_endpoints(backend.endpoints)
+
+  local fallback_backend
+  backend, fallback_backend = split_by_ip_family(backend, _M.ip_family_preference)
+
+  local balancer = sync_balancer(balancers[backend.name], backend)
+  balancers[backend.name] = balancer
+
+  -- the endpoints of the other family are tried when the connection to an
+  -- endpoint of the preferred family fails
+  if fallback_backend then
+    balancer.ip_family_fallback = sync_balancer(balancer.ip_family_fallback, fallback_backend)
+  else
+    balancer.ip_family_fallback = nil
+  end
+end
+
+local function is_static_external_name(backend)
+  return backend.externalNameResolution and backend.externalNameResolution.static
 end
 
 local function sync_backends_with_external_name()
-  for _, backend_with_external_name in pairs(backends_with_external_name) do
+  for name, backend_with_external_name in pairs(backends_with_external_name) do
     sync_backend(backend_with_external_name)
+    -- the name of a static backend is resolved again only when the backend changes
+    if is_static_external_name(backend_with_external_name) and balancers[name] then
+      backends_with_external_name[name] = nil
+    end
+  end
+end
+
+local function sync_backends_warming_up()
+  for _, backend_warming_up in pairs(backends_warming_up) do
+    sync_backend(backend_warming_up)
   end
 end
 
@@ -176,19 +282,24 @@ local function sync_backends()
     if not balancers_to_keep[backend_name] then
       balancers[backend_name] = nil
       backends_with_external_name[backend_name] = nil
+      backends_warming_up[backend_name] = nil
+      warmup.forget(backend_name)
     end
   end
   backends_last_synced_at = raw_backends_last_synced_at
 end
 
+-- route_to_alternative_balancer returns whether the request is routed to the
+-- alternative balancer, and the rule of the traffic shaping policy that routed
+-- it: "affinity", "header", "cookie" or "weight"
 local function route_to_alternative_balancer(balancer)
-  if balancer.is_affinitized(balancer) then
-    -- If request is already affinitized to a primary balancer, keep the primary balancer.
+  if not balancer.alternative_backends then
     return false
   end
 
-  if not balancer.alternative_backends then
-    return false
+  if balancer.is_affinitized(balancer) then
+    -- If request is already affinitized to a primary balancer, keep the primary balancer.
+    return false, "affinity"
   end
 
   -- TODO: support traffic shaping for n > 1 alternative backends
@@ -208,7 +319,7 @@ local function route_to_alternative_balancer(balancer)
   if alternative_balancer.is_affinitized(alternative_balancer) then
     -- If request is affinitized to an alternative balancer, instruct caller to
     -- switch to alternative.
-    return true
+    return true, "affinity"
   end
 
   -- Use traffic shaping policy, if request didn't have affinity set.
@@ -226,22 +337,22 @@ local function route_to_alternative_balancer(balancer)
     if traffic_shaping_policy.headerValue
 	   and #traffic_shaping_policy.headerValue > 0 then
       if traffic_shaping_policy.headerValue == header then
-        return true
+        return true, "header"
       end
     elseif traffic_shaping_policy.headerPattern
        and #traffic_shaping_policy.headerPattern > 0 then
       local m, err = ngx.re.match(header, traffic_shaping_policy.headerPattern)
       if m then
-        return true
+        return true, "header"
       elseif  err then
           ngx.log(ngx.ERR, "error when matching canary-by-header-pattern: '",
                   traffic_shaping_policy.headerPattern, "', error: ", err)
           return false
       end
     elseif header == "always" then
-      return true
+      return true, "header"
     elseif header == "never" then
-      return false
+      return false, "header"
     end
   end
 
@@ -249,27 +360,57 @@ local function route_to_alternative_balancer(balancer)
   local cookie = ngx.var["cookie_" .. target_cookie]
   if cookie then
     if cookie == "always" then
-      return true
+      return true, "cookie"
     elseif cookie == "never" then
-      return false
+      return false, "cookie"
     end
   end
 
+  if traffic_shaping_policy.expression
+     and expression.evaluate(traffic_shaping_policy.expression) then
+    return true, "expression"
+  end
+
   local weightTotal = 100
   if traffic_shaping_policy.weightTotal ~= nil and traffic_shaping_policy.weightTotal > 100 then
     weightTotal = traffic_shaping_policy.weightTotal
   end
   if math.random(weightTotal) <= traffic_shaping_policy.weight then
-    return true
+    return true, "weight"
   end
 
-  return false
+  return false, "weight"
 end
 
 local function get_balancer_by_upstream_name(upstream_name)
   return balancers[upstream_name]
 end
 
+-- get_backup_balancer returns the balancer of the backup backend of the
+-- location, or nil when the location has no backup backend with endpoints
+local function get_backup_balancer()
+  local backend_name = ngx.var.proxy_backup_upstream_name
+  if not backend_name or backend_name == "" then
+    return nil
+  end
+
+  return balancers[backend_name]
+end
+
+-- fail_over switches the request to the balancer of the backup backend of
+-- the location, recording the reason of the failover: "endpoints" when the
+-- backend has no endpoint, "status" when it responded with a failure code
+local function fail_over(reason)
+  local balancer = get_backup_balancer()
+  if not balancer then
+    return nil
+  end
+
+  ngx.var.backup_failover = reason
+  ngx.ctx.balancer = balancer
+  return balancer
+end
+
 local function get_balancer()
   if ngx.ctx.balancer then
     return ngx.ctx.balancer
@@ -279,10 +420,26 @@ local function get_balancer()
 
   local balancer = balancers[backend_name]
   if not balancer then
-    return nil
+    return fail_over("endpoints")
+  end
+
+  local route_to_alternative, canary_decision = route_to_alternative_balancer(balancer)
+  if canary_decision then
+    ngx.var.canary_decision = canary_decision
+
+    -- the decision is returned in a response header when the canary defines it
+    local alternative_balancer = balancers[balancer.alternative_backends[1]]
+    local traffic_shaping_policy = alternative_balancer and alternative_balancer.traffic_shaping_policy
+    if traffic_shaping_policy and traffic_shaping_policy.decisionHeader
+       and #traffic_shaping_policy.decisionHeader > 0 then
+      ngx.ctx.canary_decision_header = {
+        name = traffic_shaping_policy.decisionHeader,
+        value = (route_to_alternative and "canary" or "stable") .. "; reason=" .. canary_decision,
+      }
+    end
   end
 
-  if route_to_alternative_balancer(balancer) then
+  if route_to_alternative then
     local alternative_backend_name = balancer.alternative_backends[1]
     ngx.var.proxy_alternative_upstream_name = alternative_backend_name
 
@@ -314,6 +471,10 @@ function _M.init_worker()
     ngx.log(ngx.ERR, "error when setting up timer.every for sync_backends_with_external_name: ",
             err)
   end
+  ok, err = ngx.timer.every(BACKENDS_SYNC_INTERVAL, sync_backends_warming_up)
+  if not ok then
+    ngx.log(ngx.ERR, "error when setting up timer.every for sync_backends_warming_up: ", err)
+  end
 end
 
 function _M.rewrite()
@@ -324,12 +485,58 @@ function _M.rewrite()
   end
 end
 
+-- balancer_of_try returns the balancer of the current try and its connect
+-- timeout: the first try connects to an endpoint of the preferred family with
+-- the fallback timeout, the next tries to the endpoints of the other family
+local function balancer_of_try(balancer)
+  local fallback = balancer.ip_family_fallback
+  if not fallback then
+    return balancer, nil
+  end
+
+  if ngx_balancer.get_last_failure() then
+    return fallback, nil
+  end
+
+  local timeout = _M.ip_family_fallback_timeout
+  if not timeout or timeout <= 0 then
+    return balancer, nil
+  end
+  return balancer, timeout / 1000
+end
+
+-- balancer_of_failure returns the balancer of the backup backend when the
+-- last try failed with one of the failure codes of the location, or else the
+-- balancer
+local function balancer_of_failure(balancer)
+  local state, status = ngx_balancer.get_last_failure()
+  if state ~= "next" or not status then
+    return balancer
+  end
+
+  local codes = ngx.var.backup_failure_codes
+  if not codes or not string.find(" " .. codes .. " ", " " .. status .. " ", 1, true) then
+    return balancer
+  end
+
+  if get_backup_balancer() == balancer then
+    return balancer
+  end
+
+  return fail_over("status") or balancer
+end
+
 function _M.balance()
   local balancer = get_balancer()
   if not balancer then
     return
   end
 
+  balancer = balancer_of_failure(balancer)
+
+  local connect_timeout
+  balancer, connect_timeout = balancer_of_try(balancer)
+
   local peer = balancer:balance()
   if not peer then
     ngx.log(ngx.WARN, "no peer was returned, balancer: " .. balancer.name)
@@ -348,6 +555,47 @@ function _M.balance()
     ngx.log(ngx.ERR, "error while setting current upstream peer ", peer,
             ": ", err)
   end
+
+  if connect_timeout then
+    ok, err = ngx_balancer.set_timeouts(connect_timeout, nil, nil)
+    if not ok then
+      ngx.log(ngx.ERR, "error setting the connect timeout of the preferred IP family: ", err)
+    end
+  end
+
+  timeout_budget.apply()
+end
+
+-- resolves the host of an external destination, like the auth-url, with the
+-- DNS cache before the balancer phase, where the resolver cannot be used
+function _M.resolve_host(host)
+  ngx.ctx.resolved_addresses = dns_lookup(host)
+end
+
+-- sets the peer of the upstream of an external destination to one of the
+-- addresses resolved by resolve_host()
+function _M.balance_host(port)
+  local addresses = ngx.ctx.resolved_addresses
+  if not addresses or #addresses == 0 then
+    ngx.log(ngx.ERR, "no address was resolved for the upstream ", ngx.var.proxy_host)
+    return
+  end
+
+  local address = addresses[math.random(#addresses)]
+  local ok, err = ngx_balancer.set_current_peer(address, port)
+  if not ok then
+    ngx.log(ngx.ERR, "error while setting current upstream peer ", address, ":", port,
+            ": ", err)
+  end
+end
+
+function _M.header_filter()
+  local canary_decision_header = ngx.ctx.canary_decision_header
+  if not canary_decision_header then
+    return
+  end
+
+  ngx.header[canary_decision_header.name] = canary_decision_header.value
 end
 
 function _M.log()
@@ -369,6 +617,10 @@ setmetatable(_M, {__index = {
   route_to_alternative_balancer = route_to_alternative_balancer,
   get_balancer = get_balancer,
   get_balancer_by_upstream_name = get_balancer_by_upstream_name,
+  balancer_of_failure = balancer_of_failure,
+  split_by_ip_family = split_by_ip_family,
+  sync_backends_warming_up = sync_backends_warming_up,
+  balancer_of_try = balancer_of_try,
 }})
 
 return _M
@@ -1,14 +1,49 @@
+-- Consistent hashing of the value of upstream-hash-by. The load of the
+-- endpoints is bounded by upstream-hash-by-balance-factor when set, the
+-- requests of an endpoint above the bound going to the next endpoint of the
+-- ring.
+-- https://arxiv.org/abs/1608.01350
+
 local balancer_resty = require("balancer.resty")
+local inflight = require("balancer.inflight")
 local resty_chash = require("resty.chash")
 local util = require("util")
 local ngx_log = ngx.log
 local ngx_ERR = ngx.ERR
+local INFO = ngx.INFO
+local math = math
+local pairs = pairs
+local tonumber = tonumber
 local setmetatable = setmetatable
+local string_format = string.format
 
 local _M = balancer_resty:new({ factory = resty_chash, name = "chash" })
 
-function _M.new(self, backend)
+local function get_nodes(backend)
   local nodes = util.get_nodes(backend.endpoints)
+
+  local replication_factor =
+    tonumber(backend["upstreamHashByConfig"]["upstream-hash-by-replication-factor"])
+  if replication_factor and replication_factor > 1 then
+    for name, weight in pairs(nodes) do
+      nodes[name] = weight * replication_factor
+    end
+  end
+
+  return nodes
+end
+
+local function get_balance_factor(backend)
+  local balance_factor = tonumber(backend["upstreamHashByConfig"]["upstream-hash-by-balance-factor"])
+  if not balance_factor or balance_factor < 100 then
+    return nil
+  end
+
+  return balance_factor / 100
+end
+
+function _M.new(self, backend)
+  local nodes = get_nodes(backend)
   local complex_val, err =
     util.parse_complex_value(backend["upstreamHashByConfig"]["upstream-hash-by"])
   if err ~= nil then
@@ -18,6 +53,7 @@ function _M.new(self, backend)
   local o = {
     instance = self.factory:new(nodes),
     hash_by = complex_val,
+    balance_factor = get_balance_factor(backend),
     traffic_shaping_policy = backend.trafficShapingPolicy,
     alternative_backends = backend.alternativeBackends,
   }
@@ -26,9 +62,71 @@ function _M.new(self, backend)
   return o
 end
 
+-- capacity returns the number of requests in progress an endpoint can have
+-- before the requests go to the next endpoint of the ring
+function _M.capacity(self)
+  local total, count = 1, 0
+  for endpoint_name, _ in pairs(self.instance.nodes) do
+    total = total + inflight.get(endpoint_name)
+    count = count + 1
+  end
+
+  return math.ceil(self.balance_factor * total / count)
+end
+
 function _M.balance(self)
   local key = util.generate_var_value(self.hash_by)
-  return self.instance:find(key)
+  if not self.balance_factor then
+    return self.instance:find(key)
+  end
+
+  local capacity = self:capacity()
+  local endpoint_name, index = self.instance:find(key)
+  local first_endpoint_name = endpoint_name
+
+  -- the ring has at least one point per endpoint, so that walking as many
+  -- points as the ring has visits every endpoint
+  for _ = 1, self.instance.npoints or 0 do
+    if not endpoint_name or inflight.get(endpoint_name) < capacity then
+      break
+    end
+    endpoint_name, index = self.instance:next(index)
+  end
+
+  endpoint_name = endpoint_name or first_endpoint_name
+  if endpoint_name then
+    inflight.acquire(endpoint_name)
+  end
+
+  return endpoint_name
+end
+
+function _M.after_balance(self)
+  if self.balance_factor then
+    inflight.release()
+  end
+end
+
+function _M.sync(self, backend)
+  self.traffic_shaping_policy = backend.trafficShapingPolicy
+  self.alternative_backends = backend.alternativeBackends
+
+  local complex_val, err =
+    util.parse_complex_value(backend["upstreamHashByConfig"]["upstream-hash-by"])
+  if err ~= nil then
+    ngx_log(ngx_ERR, "could not parse the value of the upstream-hash-by: ", err)
+  end
+  self.hash_by = complex_val
+  self.balance_factor = get_balance_factor(backend)
+
+  local nodes = get_nodes(backend)
+  if util.deep_compare(self.instance.nodes, nodes) then
+    return
+  end
+
+  ngx_log(INFO, string_format("[%s] nodes have changed for backend %s", self.name, backend.name))
+
+  self.instance:reinit(nodes)
 end
 
 return _M
@@ -0,0 +1,81 @@
+-- Counts the requests proxied by the worker to each endpoint, from the
+-- selection of the endpoint to the end of the request, and the endpoints
+-- already tried by the retries of a request.
+
+local ngx = ngx
+local ipairs = ipairs
+local table_insert = table.insert
+
+local _M = {}
+
+local counts = {}
+
+local function get_endpoint_name(endpoint)
+  return endpoint.address .. ":" .. endpoint.port
+end
+
+function _M.get(endpoint_name)
+  return counts[endpoint_name] or 0
+end
+
+-- untried returns a copy of the peers not tried yet by the request, or of
+-- all the peers when they have all been tried
+function _M.untried(peers)
+  local tried_endpoints = ngx.ctx.balancer_tried_endpoints or {}
+
+  local untried_peers = {}
+  for _, peer in ipairs(peers) do
+    if not tried_endpoints[get_endpoint_name(peer)] then
+      table_insert(untried_peers, peer)
+    end
+  end
+
+  if #untried_peers == 0 then
+    ngx.log(ngx.WARN, "all endpoints have been retried")
+    for _, peer in ipairs(peers) do
+      table_insert(untried_peers, peer)
+    end
+  end
+
+  return untried_peers
+end
+
+-- release ends the request to the endpoint selected by the last attempt
+function _M.release()
+  local endpoint_name = ngx.ctx.balancer_inflight_endpoint
+  if not endpoint_name then
+    return
+  end
+  ngx.ctx.balancer_inflight_endpoint = nil
+
+  local count = (counts[endpoint_name] or 1) - 1
+  if count > 0 then
+    counts[endpoint_name] = count
+  else
+    counts[endpoint_name] = nil
+  end
+end
+
+-- acquire starts a request to the endpoint, ending the request of the
+-- previous attempt
+function _M.acquire(endpoint_name)
+  _M.release()
+
+  counts[endpoint_name] = (counts[endpoint_name] or 0) + 1
+  ngx.ctx.balancer_inflight_endpoint = endpoint_name
+
+  local tried_endpoints = ngx.ctx.balancer_tried_endpoints
+  if not tried_endpoints then
+    tried_endpoints = {}
+    ngx.ctx.balancer_tried_endpoints = tried_endpoints
+  end
+  tried_endpoints[endpoint_name] = true
+end
+
+function _M.reset()
+  counts = {}
+end
+
+_M.get_endpoint_name = get_endpoint_name
+
+return _M
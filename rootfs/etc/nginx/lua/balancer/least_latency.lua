@@ -0,0 +1,150 @@
+-- Least latency: the endpoint with the lowest average response time,
+-- weighted by the requests in progress. The average decays with time, the
+-- endpoints without a recent measure get the average of the other endpoints
+-- to be tried again.
+
+local inflight = require("balancer.inflight")
+local util = require("util")
+local split = require("util.split")
+
+local ngx = ngx
+local math = math
+local ipairs = ipairs
+local tonumber = tonumber
+local setmetatable = setmetatable
+local string_format = string.format
+local ngx_log = ngx.log
+local INFO = ngx.INFO
+
+local DEFAULT_DECAY_TIME = 10 -- this value is in seconds
+
+local _M = { name = "least_latency" }
+
+-- latencies of the endpoints measured by the worker, by endpoint name
+local latencies = {}
+
+local function get_decay_time(backend)
+  local tuning = backend.loadBalanceTuning
+  local decay_time = tuning and tonumber(tuning["least-latency-decay"])
+  if not decay_time or decay_time <= 0 then
+    return DEFAULT_DECAY_TIME
+  end
+
+  return decay_time
+end
+
+-- get_latency returns the average latency of an endpoint, nil when the
+-- endpoint has no measure more recent than the decay time
+local function get_latency(endpoint_name, decay_time, now)
+  local latency = latencies[endpoint_name]
+  if not latency or now - latency.updated_at > decay_time then
+    return nil
+  end
+
+  return latency.value
+end
+
+local function update_latency(endpoint_name, rtt, decay_time, now)
+  local latency = latencies[endpoint_name]
+  if not latency then
+    latencies[endpoint_name] = { value = rtt, updated_at = now }
+    return
+  end
+
+  local td = now - latency.updated_at
+  td = (td > 0) and td or 0
+  local weight = math.exp(-td / decay_time)
+
+  latency.value = latency.value * weight + rtt * (1.0 - weight)
+  latency.updated_at = now
+end
+
+function _M.is_affinitized()
+  return false
+end
+
+function _M.balance(self)
+  local peers = inflight.untried(self.peers)
+  local now = ngx.now()
+
+  local measured_latencies = {}
+  local total_latency, measured = 0, 0
+  for i, peer in ipairs(peers) do
+    local latency = get_latency(inflight.get_endpoint_name(peer), self.decay_time, now)
+    if latency then
+      measured_latencies[i] = latency
+      total_latency = total_latency + latency
+      measured = measured + 1
+    end
+  end
+  local default_latency = (measured > 0) and (total_latency / measured) or 0
+
+  -- starts at a random endpoint to spread the requests between the
+  -- endpoints with the same score
+  local endpoint_name, lowest_score
+  local offset = math.random(#peers)
+  for n = 0, #peers - 1 do
+    local i = (offset + n - 1) % #peers + 1
+    local name = inflight.get_endpoint_name(peers[i])
+    local score = (measured_latencies[i] or default_latency) * (inflight.get(name) + 1)
+    if not lowest_score or score < lowest_score then
+      endpoint_name, lowest_score = name, score
+    end
+  end
+
+  inflight.acquire(endpoint_name)
+
+  return endpoint_name
+end
+
+function _M.after_balance(self)
+  inflight.release()
+
+  local upstream = split.get_last_value(ngx.var.upstream_addr)
+  if util.is_blank(upstream) then
+    return
+  end
+
+  local response_time = tonumber(split.get_last_value(ngx.var.upstream_response_time)) or 0
+  local connect_time = tonumber(split.get_last_value(ngx.var.upstream_connect_time)) or 0
+
+  update_latency(upstream, connect_time + response_time, self.decay_time, ngx.now())
+end
+
+function _M.sync(self, backend)
+  self.traffic_shaping_policy = backend.trafficShapingPolicy
+  self.alternative_backends = backend.alternativeBackends
+  self.decay_time = get_decay_time(backend)
+
+  local endpoints_added, endpoints_removed = util.diff_endpoints(self.peers, backend.endpoints)
+  if #endpoints_added == 0 and #endpoints_removed == 0 then
+    return
+  end
+
+  ngx_log(INFO, string_format("[%s] peers have changed for backend %s", self.name, backend.name))
+
+  self.peers = backend.endpoints
+
+  for _, endpoint_name in ipairs(endpoints_removed) do
+    latencies[endpoint_name] = nil
+  end
+end
+
+function _M.new(self, backend)
+  local o = {
+    peers = backend.endpoints,
+    decay_time = get_decay_time(backend),
+    traffic_shaping_policy = backend.trafficShapingPolicy,
+    alternative_backends = backend.alternativeBackends,
+  }
+  setmetatable(o, self)
+  self.__index = self
+  return o
+end
+
+setmetatable(_M, {__index = {
+  latencies = function() return latencies end,
+  reset = function() latencies = {} end,
+}})
+
+return _M
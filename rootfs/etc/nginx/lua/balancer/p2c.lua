@@ -0,0 +1,87 @@
+-- Power of two choices: the endpoint with the fewest requests in progress
+-- among a few endpoints picked at random.
+-- https://www.eecs.harvard.edu/~michaelm/postscripts/mythesis.pdf
+
+local inflight = require("balancer.inflight")
+local util = require("util")
+
+local ngx = ngx
+local math = math
+local tonumber = tonumber
+local setmetatable = setmetatable
+local string_format = string.format
+local ngx_log = ngx.log
+local INFO = ngx.INFO
+
+local DEFAULT_CHOICES = 2
+
+local _M = { name = "p2c" }
+
+local function get_choices(backend)
+  local tuning = backend.loadBalanceTuning
+  local choices = tuning and tonumber(tuning["p2c-choices"])
+  if not choices or choices < 2 then
+    return DEFAULT_CHOICES
+  end
+
+  return choices
+end
+
+function _M.is_affinitized()
+  return false
+end
+
+function _M.balance(self)
+  local peers = inflight.untried(self.peers)
+  local k = math.min(self.choices, #peers)
+
+  -- picks k endpoints at random with a partial Fisher-Yates shuffle
+  local endpoint_name, lowest_count
+  for i = 1, k do
+    local rand_index = math.random(i, #peers)
+    peers[i], peers[rand_index] = peers[rand_index], peers[i]
+
+    local name = inflight.get_endpoint_name(peers[i])
+    local count = inflight.get(name)
+    if not lowest_count or count < lowest_count then
+      endpoint_name, lowest_count = name, count
+    end
+  end
+
+  inflight.acquire(endpoint_name)
+
+  return endpoint_name
+end
+
+function _M.after_balance(_)
+  inflight.release()
+end
+
+function _M.sync(self, backend)
+  self.traffic_shaping_policy = backend.trafficShapingPolicy
+  self.alternative_backends = backend.alternativeBackends
+  self.choices = get_choices(backend)
+
+  local endpoints_added, endpoints_removed = util.diff_endpoints(self.peers, backend.endpoints)
+  if #endpoints_added == 0 and #endpoints_removed == 0 then
+    return
+  end
+
+  ngx_log(INFO, string_format("[%s] peers have changed for backend %s", self.name, backend.name))
+
+  self.peers = backend.endpoints
+end
+
+function _M.new(self, backend)
+  local o = {
+    peers = backend.endpoints,
+    choices = get_choices(backend),
+    traffic_shaping_policy = backend.trafficShapingPolicy,
+    alternative_backends = backend.alternativeBackends,
+  }
+  setmetatable(o, self)
+  self.__index = self
+  return o
+end
+
+return _M
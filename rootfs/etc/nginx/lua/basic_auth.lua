@@ -0,0 +1,251 @@
+local ffi = require("ffi")
+local bit = require("bit")
+local lrucache = require("resty.lrucache")
+
+local ngx = ngx
+local pcall = pcall
+local string_byte = string.byte
+local string_char = string.char
+local string_find = string.find
+local string_match = string.match
+local string_sub = string.sub
+local table_concat = table.concat
+local band = bit.band
+local bor = bit.bor
+local bxor = bit.bxor
+local lshift = bit.lshift
+local rshift = bit.rshift
+
+local credentials = ngx.shared.basic_auth_credentials
+
+local _M = {}
+
+-- verifications of the credentials cached in the worker. The keys contain
+-- the hash stored for the user, so the entries of a user are invalidated
+-- when the Secret containing the credentials changes.
+local VERIFIED_CACHE_SIZE = 1024
+local VERIFIED_CACHE_TTL = 60
+local verified, cache_err = lrucache.new(VERIFIED_CACHE_SIZE)
+if not verified then
+  error("failed to create the cache of the verified credentials: " .. (cache_err or "unknown"))
+end
+
+-- hash verified when the user does not exist, to not reveal the users by timing
+local DUMMY_HASH = "{SHA}2jmj7l5rSw0yVb/vlWAYkK/YBwk="
+
+local APR1_MAGIC = "$apr1$"
+local ITOA64 = "./0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+local crypt
+do
+  pcall(ffi.cdef, "char *crypt(const char *key, const char *salt);")
+  local ok, libcrypt = pcall(ffi.load, "crypt")
+  if not ok then
+    libcrypt = ffi.C
+  end
+
+  crypt = function(password, hash)
+    local success, result = pcall(libcrypt.crypt, password, hash)
+    if not success or result == nil then
+      return nil
+    end
+    return ffi.string(result)
+  end
+end
+
+-- credentials_key returns the key of a user in the shared dictionary
+function _M.credentials_key(key, user)
+  return key .. ":" .. user
+end
+
+-- constant_time_equals compares two strings in a time that only depends on
+-- the length of the first one
+function _M.constant_time_equals(a, b)
+  local length_a, length_b = #a, #b
+  if length_b == 0 then
+    return length_a == 0
+  end
+
+  local result = length_a == length_b and 0 or 1
+  for i = 1, length_a do
+    local byte_b = string_byte(b, (i - 1) % length_b + 1)
+    result = bor(result, bxor(string_byte(a, i), byte_b))
+  end
+
+  return result == 0
+end
+
+local function to64(value, length)
+  local chars = {}
+  for i = 1, length do
+    local index = band(value, 0x3f) + 1
+    chars[i] = string_sub(ITOA64, index, index)
+    value = rshift(value, 6)
+  end
+  return table_concat(chars)
+end
+
+-- apr1 returns the hash of a password using the Apache MD5 algorithm, the
+-- default algorithm of htpasswd
+function _M.apr1(password, hash)
+  local salt = string_match(hash, "^%$apr1%$([^$]*)")
+  if not salt then
+    return nil
+  end
+  salt = string_sub(salt, 1, 8)
+
+  local final = ngx.md5_bin(password .. salt .. password)
+
+  local ctx = { password, APR1_MAGIC, salt }
+  local length = #password
+  while length > 0 do
+    ctx[#ctx + 1] = string_sub(final, 1, length > 16 and 16 or length)
+    length = length - 16
+  end
+
+  length = #password
+  while length > 0 do
+    if band(length, 1) == 1 then
+      ctx[#ctx + 1] = string_char(0)
+    else
+      ctx[#ctx + 1] = string_sub(password, 1, 1)
+    end
+    length = rshift(length, 1)
+  end
+
+  final = ngx.md5_bin(table_concat(ctx))
+
+  for i = 0, 999 do
+    local round = {}
+    if band(i, 1) == 1 then
+      round[#round + 1] = password
+    else
+      round[#round + 1] = final
+    end
+    if i % 3 ~= 0 then
+      round[#round + 1] = salt
+    end
+    if i % 7 ~= 0 then
+      round[#round + 1] = password
+    end
+    if band(i, 1) == 1 then
+      round[#round + 1] = final
+    else
+      round[#round + 1] = password
+    end
+    final = ngx.md5_bin(table_concat(round))
+  end
+
+  local f = { string_byte(final, 1, 16) }
+  local encoded = {
+    to64(bor(lshift(f[1], 16), lshift(f[7], 8), f[13]), 4),
+    to64(bor(lshift(f[2], 16), lshift(f[8], 8), f[14]), 4),
+    to64(bor(lshift(f[3], 16), lshift(f[9], 8), f[15]), 4),
+    to64(bor(lshift(f[4], 16), lshift(f[10], 8), f[16]), 4),
+    to64(bor(lshift(f[5], 16), lshift(f[11], 8), f[6]), 4),
+    to64(f[12], 2),
+  }
+
+  return APR1_MAGIC .. salt .. "$" .. table_concat(encoded)
+end
+
+-- hash_password returns the hash of a password using the scheme and the salt
+-- of the stored hash. The schemes are the ones supported by auth_basic_user_file.
+local function hash_password(password, hash)
+  if string_sub(hash, 1, 7) == "{PLAIN}" then
+    return "{PLAIN}" .. password
+  end
+
+  if string_sub(hash, 1, 5) == "{SHA}" then
+    return "{SHA}" .. ngx.encode_base64(ngx.sha1_bin(password))
+  end
+
+  if string_sub(hash, 1, 6) == "{SSHA}" then
+    local decoded = ngx.decode_base64(string_sub(hash, 7))
+    if not decoded or #decoded < 20 then
+      return nil
+    end
+    local salt = string_sub(decoded, 21)
+    return "{SSHA}" .. ngx.encode_base64(ngx.sha1_bin(password .. salt) .. salt)
+  end
+
+  if string_sub(hash, 1, #APR1_MAGIC) == APR1_MAGIC then
+    return _M.apr1(password, hash)
+  end
+
+  return crypt(password, hash)
+end
+
+-- verify checks if the password matches the stored hash
+function _M.verify(password, hash)
+  local cache_key = hash .. ":" .. ngx.sha1_bin(password)
+  if verified:get(cache_key) then
+    return true
+  end
+
+  local computed = hash_password(password, hash)
+  if not computed or not _M.constant_time_equals(computed, hash) then
+    return false
+  end
+
+  verified:set(cache_key, true, VERIFIED_CACHE_TTL)
+  return true
+end
+
+local function parse_authorization(header)
+  if not header then
+    return nil
+  end
+
+  local encoded = string_match(header, "^%s*[Bb][Aa][Ss][Ii][Cc]%s+(%S+)%s*$")
+  if not encoded then
+    return nil
+  end
+
+  local decoded = ngx.decode_base64(encoded)
+  if not decoded then
+    return nil
+  end
+
+  local separator = string_find(decoded, ":", 1, true)
+  if not separator then
+    return nil
+  end
+
+  return string_sub(decoded, 1, separator - 1), string_sub(decoded, separator + 1)
+end
+
+local function unauthorized(realm)
+  ngx.header["WWW-Authenticate"] = 'Basic realm="' .. realm .. '"'
+  return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+end
+
+-- rewrite verifies the credentials of the request against the credentials
+-- of the location stored in the basic_auth_credentials shared dictionary
+function _M.rewrite()
+  local key = ngx.var.basic_auth_key
+  if not key or key == "" then
+    return
+  end
+
+  local realm = ngx.var.basic_auth_realm or ""
+
+  local user, password = parse_authorization(ngx.var.http_authorization)
+  if not user then
+    return unauthorized(realm)
+  end
+
+  local hash = credentials:get(_M.credentials_key(key, user))
+  if not hash then
+    _M.verify(password, DUMMY_HASH)
+    ngx.log(ngx.INFO, "user \"", user, "\" was not found in the credentials of ", key)
+    return unauthorized(realm)
+  end
+
+  if not _M.verify(password, hash) then
+    ngx.log(ngx.INFO, "user \"", user, "\": password mismatch")
+    return unauthorized(realm)
+  end
+end
+
+return _M
@@ -0,0 +1,285 @@
+local ngx = ngx
+local io = io
+local next = next
+local tonumber = tonumber
+local string_find = string.find
+local string_gmatch = string.gmatch
+local string_lower = string.lower
+local string_sub = string.sub
+
+local _M = {}
+
+-- characters of the names of the XML entities
+local NAME = "[%w_:%.%-]+"
+
+-- tag_end returns the position of the end of a tag, skipping the quoted
+-- attribute values
+local function tag_end(body, pos)
+  while true do
+    local s = string_find(body, "[>\"']", pos)
+    if not s then
+      return nil
+    end
+
+    local c = string_sub(body, s, s)
+    if c == ">" then
+      return s
+    end
+
+    local q = string_find(body, c, s + 1, true)
+    if not q then
+      return nil
+    end
+    pos = q + 1
+  end
+end
+
+-- entity_declarations returns the values of the entities declared in the
+-- internal subset of a DTD. External entities have an empty value.
+local function entity_declarations(subset)
+  local entities = {}
+  for name, _, value in string_gmatch(subset, "<!ENTITY%s+(" .. NAME .. ")%s+([\"'])(.-)%2%s*>") do
+    entities[name] = value
+  end
+  for name in string_gmatch(subset, "<!ENTITY%s+(" .. NAME .. ")%s+SYSTEM") do
+    entities[name] = ""
+  end
+  for name in string_gmatch(subset, "<!ENTITY%s+(" .. NAME .. ")%s+PUBLIC") do
+    entities[name] = ""
+  end
+  return entities
+end
+
+-- entity_expansions returns the number of expansions of a reference to an
+-- entity, the references of its value included, capped to max + 1. Recursive
+-- entities exceed the limit.
+local function entity_expansions(entities, name, max, memo, depth)
+  if memo[name] then
+    return memo[name]
+  end
+
+  -- each level of references expands at least one entity
+  if depth > max then
+    return max + 1
+  end
+
+  memo[name] = max + 1
+
+  local count = 1
+  for ref in string_gmatch(entities[name], "&(" .. NAME .. ");") do
+    if entities[ref] then
+      count = count + entity_expansions(entities, ref, max, memo, depth + 1)
+      if count > max then
+        count = max + 1
+        break
+      end
+    end
+  end
+
+  memo[name] = count
+  return count
+end
+
+-- inspect_xml returns the reason of the rejection of an XML body exceeding
+-- the maximum depth of its elements or the maximum number of expansions of
+-- its entities, or nil
+function _M.inspect_xml(body, max_depth, max_expansions)
+  local depth = 0
+  local entities = {}
+  local content_start = 1
+  local pos = 1
+
+  while true do
+    local s = string_find(body, "<", pos, true)
+    if not s then
+      break
+    end
+
+    local c = string_sub(body, s + 1, s + 1)
+    local e, _
+    if c == "?" then
+      _, e = string_find(body, "?>", s + 2, true)
+    elseif string_sub(body, s, s + 3) == "<!--" then
+      _, e = string_find(body, "-->", s + 4, true)
+    elseif string_sub(body, s, s + 8) == "<![CDATA[" then
+      _, e = string_find(body, "]]>", s + 9, true)
+    elseif c == "!" then
+      -- the internal subset of the DTD declares the entities
+      local b = string_find(body, "[%[>]", s + 2)
+      if b and string_sub(body, b, b) == "[" then
+        local subset_end
+        subset_end, e = string_find(body, "]%s*>", b + 1)
+        if subset_end then
+          entities = entity_declarations(string_sub(body, b + 1, subset_end - 1))
+        end
+      else
+        e = b
+      end
+      content_start = (e or #body) + 1
+    elseif c == "/" then
+      _, e = string_find(body, ">", s + 2, true)
+      depth = depth - 1
+    else
+      e = tag_end(body, s + 1)
+      if e and string_sub(body, e - 1, e - 1) ~= "/" then
+        depth = depth + 1
+        if max_depth and depth > max_depth then
+          return "xml_depth"
+        end
+      end
+    end
+
+    if not e then
+      break
+    end
+    pos = e + 1
+  end
+
+  if max_expansions and next(entities) then
+    local memo = {}
+    local total = 0
+    for ref in string_gmatch(string_sub(body, content_start), "&(" .. NAME .. ");") do
+      if entities[ref] then
+        total = total + entity_expansions(entities, ref, max_expansions, memo, 1)
+        if total > max_expansions then
+          return "xml_entity_expansions"
+        end
+      end
+    end
+  end
+
+  return nil
+end
+
+-- inspect_json returns the reason of the rejection of a JSON body exceeding
+-- the maximum depth of its objects and arrays or the maximum size of its
+-- arrays, or nil
+function _M.inspect_json(body, max_depth, max_array_size)
+  local depth = 0
+  local arrays = {}
+  local sizes = {}
+  local pos = 1
+
+  while true do
+    local s = string_find(body, "[%[%]{}\",]", pos)
+    if not s then
+      return nil
+    end
+
+    local c = string_sub(body, s, s)
+    if c == "\"" then
+      -- skip the string, with its escaped characters
+      local e = s + 1
+      while true do
+        local q = string_find(body, "[\"\\]", e)
+        if not q then
+          return nil
+        end
+        if string_sub(body, q, q) == "\"" then
+          e = q
+          break
+        end
+        e = q + 2
+      end
+      pos = e + 1
+    else
+      if c == "[" or c == "{" then
+        depth = depth + 1
+        if max_depth and depth > max_depth then
+          return "json_depth"
+        end
+
+        arrays[depth] = c == "["
+        sizes[depth] = 0
+        if c == "[" and not string_find(body, "^%s*%]", s + 1) then
+          sizes[depth] = 1
+        end
+      elseif c == "]" or c == "}" then
+        depth = depth - 1
+        if depth < 0 then
+          return nil
+        end
+      elseif arrays[depth] then
+        sizes[depth] = sizes[depth] + 1
+      end
+
+      if max_array_size and arrays[depth] and sizes[depth] > max_array_size then
+        return "json_array_size"
+      end
+      pos = s + 1
+    end
+  end
+end
+
+-- read_body returns the request body, buffered in memory or in a file
+local function read_body()
+  ngx.req.read_body()
+
+  local body = ngx.req.get_body_data()
+  if body then
+    return body
+  end
+
+  local file_name = ngx.req.get_body_file()
+  if not file_name then
+    return nil
+  end
+
+  local file, err = io.open(file_name, "rb")
+  if not file then
+    ngx.log(ngx.ERR, "error opening the request body file: ", err)
+    return nil
+  end
+
+  body = file:read("*a")
+  file:close()
+  return body
+end
+
+-- rewrite rejects the XML and JSON request bodies exceeding the limits of
+-- the location with the status code 400
+function _M.rewrite()
+  local xml_max_depth = tonumber(ngx.var.body_inspection_xml_max_depth)
+  local xml_max_entity_expansions = tonumber(ngx.var.body_inspection_xml_max_entity_expansions)
+  local json_max_depth = tonumber(ngx.var.body_inspection_json_max_depth)
+  local json_max_array_size = tonumber(ngx.var.body_inspection_json_max_array_size)
+
+  local content_type = ngx.var.content_type
+  if not content_type then
+    return
+  end
+  content_type = string_lower(content_type)
+
+  local inspect
+  if string_find(content_type, "json", 1, true) then
+    if not json_max_depth and not json_max_array_size then
+      return
+    end
+    inspect = function(body)
+      return _M.inspect_json(body, json_max_depth, json_max_array_size)
+    end
+  elseif string_find(content_type, "xml", 1, true) then
+    if not xml_max_depth and not xml_max_entity_expansions then
+      return
+    end
+    inspect = function(body)
+      return _M.inspect_xml(body, xml_max_depth, xml_max_entity_expansions)
+    end
+  else
+    return
+  end
+
+  local body = read_body()
+  if not body then
+    return
+  end
+
+  local reason = inspect(body)
+  if reason then
+    ngx.var.body_inspection_rejection = reason
+    ngx.log(ngx.INFO, "request body rejected by the inspection: ", reason)
+    return ngx.exit(ngx.HTTP_BAD_REQUEST)
+  end
+end
+
+return _M
@@ -10,10 +10,13 @@ local unpack = unpack
 local dns_lookup = require("util.dns").lookup
 
 local _M = {
-  is_ocsp_stapling_enabled = false
+  is_ocsp_stapling_enabled = false,
+  is_ocsp_prefetch_enabled = false,
 }
 
 local DEFAULT_CERT_HOSTNAME = "_"
+-- prefix of the keys of the alternate certificates in certificate_servers
+local ALTERNATE_PREFIX = "alternate:"
 
 local certificate_data = ngx.shared.certificate_data
 local certificate_servers = ngx.shared.certificate_servers
@@ -45,6 +48,8 @@ local function set_der_cert_and_key(der_cert, der_priv_key)
   end
 end
 
+-- get_pem_cert_uid returns the UID of the certificate of a hostname, and the
+-- key of certificate_servers it was found with
 local function get_pem_cert_uid(raw_hostname)
   -- Convert hostname to ASCII lowercase (see RFC 6125 6.4.1) so that requests with uppercase
   -- host would lead to the right certificate being chosen (controller serves certificates for
@@ -54,7 +59,7 @@ local function get_pem_cert_uid(raw_hostname)
 
   local uid = certificate_servers:get(hostname)
   if uid then
-    return uid
+    return uid, hostname
   end
 
   local wildcard_hostname, _, err = re_sub(hostname, "^[^\\.]+\\.", "*.", "jo")
@@ -67,7 +72,29 @@ local function get_pem_cert_uid(raw_hostname)
     uid = certificate_servers:get(wildcard_hostname)
   end
 
-  return uid
+  return uid, wildcard_hostname
+end
+
+-- set_alternate_cert_and_key sets the alternate certificate of the server
+-- found with key, served in addition to its certificate to the clients
+-- supporting the key type of the alternate certificate
+local function set_alternate_cert_and_key(key)
+  local uid = certificate_servers:get(ALTERNATE_PREFIX .. key)
+  if not uid then
+    return nil
+  end
+
+  local pem_cert = certificate_data:get(uid)
+  if not pem_cert then
+    return "alternate certificate not found for " .. tostring(key)
+  end
+
+  local der_cert, der_priv_key, der_err = get_der_cert_and_priv_key(pem_cert)
+  if der_err then
+    return der_err
+  end
+
+  return set_der_cert_and_key(der_cert, der_priv_key)
 end
 
 local function is_ocsp_stapling_enabled_for(_)
@@ -199,10 +226,15 @@ end
 -- Serving stale response ensures that we don't serve another request without OCSP response
 -- when the cache entry expires. Instead we serve the single request with stale response
 -- and enqueue fetch_and_cache_ocsp_response for refetch.
+--
+-- When the responses are prefetched by the controller, the cache entries expire
+-- with the responses and nothing is fetched from the workers.
 local function ocsp_staple(uid, der_cert)
   local response, _, is_stale = ocsp_response_cache:get_stale(uid)
   if not response or is_stale then
-    ngx.timer.at(0, function() fetch_and_cache_ocsp_response(uid, der_cert) end)
+    if not _M.is_ocsp_prefetch_enabled then
+      ngx.timer.at(0, function() fetch_and_cache_ocsp_response(uid, der_cert) end)
+    end
     return false, nil
   end
 
@@ -234,9 +266,9 @@ function _M.call()
   end
 
   local pem_cert
-  local pem_cert_uid = get_pem_cert_uid(hostname)
+  local pem_cert_uid, pem_cert_key = get_pem_cert_uid(hostname)
   if not pem_cert_uid then
-    pem_cert_uid = get_pem_cert_uid(DEFAULT_CERT_HOSTNAME)
+    pem_cert_uid, pem_cert_key = get_pem_cert_uid(DEFAULT_CERT_HOSTNAME)
   end
   if pem_cert_uid then
     pem_cert = certificate_data:get(pem_cert_uid)
@@ -265,6 +297,13 @@ function _M.call()
     return ngx.exit(ngx.ERROR)
   end
 
+  -- the alternate certificate is optional, the handshake continues with the
+  -- certificate when it cannot be set
+  local alternate_err = set_alternate_cert_and_key(pem_cert_key)
+  if alternate_err then
+    ngx.log(ngx.ERR, "failed to set the alternate certificate: ", alternate_err)
+  end
+
   if is_ocsp_stapling_enabled_for(pem_cert_uid) then
     local _, err = ocsp_staple(pem_cert_uid, der_cert)
     if err then
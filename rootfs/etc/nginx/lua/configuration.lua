@@ -1,4 +1,8 @@
 local cjson = require("cjson.safe")
+local ssl = require("ngx.ssl")
+local ocsp = require("ngx.ocsp")
+local basic_auth = require("basic_auth")
+local dns = require("util.dns")
 
 local io = io
 local ngx = ngx
@@ -6,17 +10,26 @@ local tostring = tostring
 local string = string
 local table = table
 local pairs = pairs
+local ipairs = ipairs
+local type = type
 
 -- this is the Lua representation of Configuration struct in internal/ingress/types.go
 local configuration_data = ngx.shared.configuration_data
 local certificate_data = ngx.shared.certificate_data
 local certificate_servers = ngx.shared.certificate_servers
 local ocsp_response_cache = ngx.shared.ocsp_response_cache
+local basic_auth_credentials = ngx.shared.basic_auth_credentials
+local oidc_clients = ngx.shared.oidc_clients
+local lua_kv = ngx.shared.lua_kv
 
 local EMPTY_UID = "-1"
 
 local _M = {}
 
+-- server aliases decoded in this worker and the version they belong to
+local server_aliases = {}
+local server_aliases_version = nil
+
 function _M.get_backends_data()
   return configuration_data:get("backends")
 end
@@ -33,6 +46,23 @@ function _M.get_raw_backends_last_synced_at()
   return raw_backends_last_synced_at
 end
 
+-- returns the hostname of the server containing the alias, or nil
+function _M.get_server_alias(hostname)
+  local version = configuration_data:get("server_aliases_version")
+  if version ~= server_aliases_version then
+    local aliases, err = cjson.decode(configuration_data:get("server_aliases") or "{}")
+    if type(aliases) ~= "table" then
+      ngx.log(ngx.ERR, "could not parse server aliases: ", err)
+      aliases = {}
+    end
+
+    server_aliases = aliases
+    server_aliases_version = version
+  end
+
+  return server_aliases[hostname]
+end
+
 local function fetch_request_body()
   ngx.req.read_body()
   local body = ngx.req.get_body_data()
@@ -62,43 +92,99 @@ local function get_pem_cert(hostname)
   return certificate_data:get(uid)
 end
 
-local function handle_servers()
-  if ngx.var.request_method ~= "POST" then
-    ngx.status = ngx.HTTP_BAD_REQUEST
-    ngx.print("Only POST requests are allowed!")
-    return
+-- set_ocsp_response caches an OCSP response prefetched by the controller,
+-- after checking it belongs to the certificate currently stored for the UID
+local function set_ocsp_response(uid, ocsp_response)
+  local pem_cert = certificate_data:get(uid)
+  if not pem_cert then
+    return "certificate not found"
   end
 
-  local raw_configuration = fetch_request_body()
+  if type(ocsp_response) ~= "table" or type(ocsp_response.response) ~= "string"
+      or type(ocsp_response.expiry) ~= "number" then
+    return "invalid OCSP response"
+  end
 
-  local configuration, err = cjson.decode(raw_configuration)
-  if not configuration then
-    ngx.log(ngx.ERR, "could not parse configuration: ", err)
-    ngx.status = ngx.HTTP_BAD_REQUEST
-    return
+  if ocsp_response.expiry <= 0 then
+    ocsp_response_cache:delete(uid)
+    return nil
   end
 
-  local err_buf = {}
+  local response = ngx.decode_base64(ocsp_response.response)
+  if not response then
+    return "invalid OCSP response encoding"
+  end
+
+  local der_cert, err = ssl.cert_pem_to_der(pem_cert)
+  if not der_cert then
+    return err
+  end
 
-  for server, uid in pairs(configuration.servers) do
+  local ok
+  ok, err = ocsp.validate_ocsp_response(response, der_cert)
+  if not ok then
+    return err
+  end
+
+  local forcible
+  ok, err, forcible = ocsp_response_cache:set(uid, response, ocsp_response.expiry)
+  if not ok then
+    return err
+  end
+  if forcible then
+    ngx.log(ngx.WARN, "ocsp_response_cache dictionary is full, "
+      .. "LRU entry has been removed to store ", uid)
+  end
+
+  return nil
+end
+
+-- set_certificate_servers stores the UID of the certificate of each server,
+-- with the prefix in its key, and appends the errors to err_buf
+local function set_certificate_servers(servers, prefix, err_buf)
+  for server, uid in pairs(servers) do
+    local key = prefix .. server
     if uid == EMPTY_UID then
       -- notice that we do not delete certificate corresponding to this server
       -- this is because a certificate can be used by multiple servers/hostnames
-      certificate_servers:delete(server)
+      certificate_servers:delete(key)
     else
-      local success, set_err, forcible = certificate_servers:set(server, uid)
+      local success, set_err, forcible = certificate_servers:set(key, uid)
       if not success then
         local err_msg = string.format("error setting certificate for %s: %s\n",
-          server, tostring(set_err))
+          key, tostring(set_err))
         table.insert(err_buf, err_msg)
       end
       if forcible then
         local msg = string.format("certificate_servers dictionary is full, "
-          .. "LRU entry has been removed to store %s", server)
+          .. "LRU entry has been removed to store %s", key)
         ngx.log(ngx.WARN, msg)
       end
     end
   end
+end
+
+local function handle_servers()
+  if ngx.var.request_method ~= "POST" then
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    ngx.print("Only POST requests are allowed!")
+    return
+  end
+
+  local raw_configuration = fetch_request_body()
+
+  local configuration, err = cjson.decode(raw_configuration)
+  if not configuration then
+    ngx.log(ngx.ERR, "could not parse configuration: ", err)
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local err_buf = {}
+
+  set_certificate_servers(configuration.servers, "", err_buf)
+  -- the alternate certificates are stored with a prefix, see certificate.lua
+  set_certificate_servers(configuration.alternateServers or {}, "alternate:", err_buf)
 
   for uid, cert in pairs(configuration.certificates) do
     -- don't delete the cache here, certificate_data[uid] is not replaced yet.
@@ -125,6 +211,14 @@ local function handle_servers()
     end
   end
 
+  for uid, ocsp_response in pairs(configuration.ocspResponses or {}) do
+    local err = set_ocsp_response(uid, ocsp_response)
+    if err then
+      table.insert(err_buf, string.format("error setting OCSP response for %s: %s\n",
+        uid, tostring(err)))
+    end
+  end
+
   if #err_buf > 0 then
     ngx.log(ngx.ERR, table.concat(err_buf))
     ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
@@ -186,6 +280,37 @@ local function handle_certs()
 end
 
 
+local function handle_server_aliases()
+  if ngx.var.request_method == "GET" then
+    ngx.status = ngx.HTTP_OK
+    ngx.print(configuration_data:get("server_aliases"))
+    return
+  end
+
+  local aliases = fetch_request_body()
+  if not aliases then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read valid request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local success, err = configuration_data:set("server_aliases", aliases)
+  if not success then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating server aliases: " .. tostring(err))
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local _, incr_err = configuration_data:incr("server_aliases_version", 1, 0)
+  if incr_err then
+    ngx.log(ngx.ERR, "dynamic-configuration: error updating server aliases version: " .. tostring(incr_err))
+    ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+    return
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
 local function handle_backends()
   if ngx.var.request_method == "GET" then
     ngx.status = ngx.HTTP_OK
@@ -220,6 +345,170 @@ local function handle_backends()
   ngx.status = ngx.HTTP_CREATED
 end
 
+-- replaces the credentials of the locations using basic authentication with
+-- the credentials in memory. The body maps the key of each location to the
+-- hashes of the passwords of its users.
+local function handle_auth_credentials()
+  if ngx.var.request_method ~= "POST" then
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    ngx.print("Only POST requests are allowed!")
+    return
+  end
+
+  local raw_credentials = fetch_request_body()
+  if not raw_credentials then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read valid request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local credentials, err = cjson.decode(raw_credentials)
+  if type(credentials) ~= "table" then
+    ngx.log(ngx.ERR, "could not parse auth credentials: ", err)
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local current = {}
+  for key, users in pairs(credentials) do
+    for user, hash in pairs(users) do
+      local credentials_key = basic_auth.credentials_key(key, user)
+      current[credentials_key] = true
+
+      local success, set_err = basic_auth_credentials:safe_set(credentials_key, hash)
+      if not success then
+        ngx.log(ngx.ERR, "dynamic-configuration: error setting the credentials of ", key, ": ",
+                tostring(set_err))
+        ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+        return
+      end
+    end
+  end
+
+  -- the credentials removed from the Secrets are deleted after the new ones
+  -- are stored, to not reject the requests of the users that did not change
+  for _, credentials_key in ipairs(basic_auth_credentials:get_keys(0)) do
+    if not current[credentials_key] then
+      basic_auth_credentials:delete(credentials_key)
+    end
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
+-- replaces the credentials of the OpenID Connect clients of the locations.
+-- The body maps the key of each location to the credentials of its client.
+local function handle_oidc_clients()
+  if ngx.var.request_method ~= "POST" then
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    ngx.print("Only POST requests are allowed!")
+    return
+  end
+
+  local raw_clients = fetch_request_body()
+  if not raw_clients then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read valid request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local clients, err = cjson.decode(raw_clients)
+  if type(clients) ~= "table" then
+    ngx.log(ngx.ERR, "could not parse OIDC clients: ", err)
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  for key, client in pairs(clients) do
+    local success, set_err = oidc_clients:safe_set(key, cjson.encode(client))
+    if not success then
+      ngx.log(ngx.ERR, "dynamic-configuration: error setting the OIDC client of ", key, ": ",
+              tostring(set_err))
+      ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+      return
+    end
+  end
+
+  for _, key in ipairs(oidc_clients:get_keys(0)) do
+    if not clients[key] then
+      oidc_clients:delete(key)
+    end
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
+-- replaces the key/value stores of the Ingresses. The body maps the key of
+-- each Ingress to the data of its store.
+local function handle_lua_kv()
+  if ngx.var.request_method ~= "POST" then
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    ngx.print("Only POST requests are allowed!")
+    return
+  end
+
+  local raw_stores = fetch_request_body()
+  if not raw_stores then
+    ngx.log(ngx.ERR, "dynamic-configuration: unable to read valid request body")
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  local stores, err = cjson.decode(raw_stores)
+  if type(stores) ~= "table" then
+    ngx.log(ngx.ERR, "could not parse key/value stores: ", err)
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    return
+  end
+
+  for key, data in pairs(stores) do
+    local success, set_err = lua_kv:safe_set(key, cjson.encode(data))
+    if not success then
+      ngx.log(ngx.ERR, "dynamic-configuration: error setting the key/value store of ", key, ": ",
+              tostring(set_err))
+      ngx.status = ngx.HTTP_INTERNAL_SERVER_ERROR
+      return
+    end
+  end
+
+  for _, key in ipairs(lua_kv:get_keys(0)) do
+    if not stores[key] then
+      lua_kv:delete(key)
+    end
+  end
+
+  ngx.status = ngx.HTTP_CREATED
+end
+
+-- returns the capacity and the free space in bytes of each shared dictionary
+local function handle_shared_dicts()
+  if ngx.var.request_method ~= "GET" then
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    ngx.print("Only GET requests are allowed!")
+    return
+  end
+
+  local dicts = {}
+  for name, dict in pairs(ngx.shared) do
+    dicts[name] = { capacity = dict:capacity(), free_space = dict:free_space() }
+  end
+
+  ngx.status = ngx.HTTP_OK
+  ngx.print(cjson.encode(dicts))
+end
+
+-- returns the statistics of the DNS cache
+local function handle_dns_cache()
+  if ngx.var.request_method ~= "GET" then
+    ngx.status = ngx.HTTP_BAD_REQUEST
+    ngx.print("Only GET requests are allowed!")
+    return
+  end
+
+  ngx.status = ngx.HTTP_OK
+  ngx.print(cjson.encode(dns.stats()))
+end
+
 function _M.call()
   if ngx.var.request_method ~= "POST" and ngx.var.request_method ~= "GET" then
     ngx.status = ngx.HTTP_BAD_REQUEST
@@ -242,11 +531,41 @@ function _M.call()
     return
   end
 
+  if ngx.var.request_uri == "/configuration/server-aliases" then
+    handle_server_aliases()
+    return
+  end
+
   if ngx.var.request_uri == "/configuration/backends" then
     handle_backends()
     return
   end
 
+  if ngx.var.request_uri == "/configuration/auth-credentials" then
+    handle_auth_credentials()
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/oidc-clients" then
+    handle_oidc_clients()
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/lua-kv" then
+    handle_lua_kv()
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/shared-dicts" then
+    handle_shared_dicts()
+    return
+  end
+
+  if ngx.var.request_uri == "/configuration/dns-cache" then
+    handle_dns_cache()
+    return
+  end
+
   ngx.status = ngx.HTTP_NOT_FOUND
   ngx.print("Not found!")
 end
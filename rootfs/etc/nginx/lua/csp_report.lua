@@ -0,0 +1,143 @@
+local ngx = ngx
+local cjson = require("cjson.safe")
+local ipairs = ipairs
+local type = type
+local string_lower = string.lower
+local string_match = string.match
+local string_sub = string.sub
+local table_concat = table.concat
+
+local _M = {}
+
+-- maximum number of the reports of a request counted in the metrics
+local MAX_REPORTS = 100
+
+-- maximum length of the values of a report written in the logs
+local MAX_VALUE_LENGTH = 256
+
+-- directives counted in the metrics, the other directives are counted as "other"
+local DIRECTIVES = {
+  ["base-uri"] = true,
+  ["child-src"] = true,
+  ["connect-src"] = true,
+  ["default-src"] = true,
+  ["font-src"] = true,
+  ["form-action"] = true,
+  ["frame-ancestors"] = true,
+  ["frame-src"] = true,
+  ["img-src"] = true,
+  ["manifest-src"] = true,
+  ["media-src"] = true,
+  ["object-src"] = true,
+  ["require-trusted-types-for"] = true,
+  ["sandbox"] = true,
+  ["script-src"] = true,
+  ["script-src-attr"] = true,
+  ["script-src-elem"] = true,
+  ["style-src"] = true,
+  ["style-src-attr"] = true,
+  ["style-src-elem"] = true,
+  ["trusted-types"] = true,
+  ["worker-src"] = true,
+}
+
+-- directive returns the name of a violated directive counted in the metrics
+local function directive(value)
+  if type(value) ~= "string" then
+    return "other"
+  end
+
+  local name = string_match(value, "^%s*([%w%-]+)")
+  if not name then
+    return "other"
+  end
+
+  name = string_lower(name)
+  if not DIRECTIVES[name] then
+    return "other"
+  end
+  return name
+end
+
+-- loggable returns a value of a report safe to write in the logs
+local function loggable(value)
+  if type(value) ~= "string" then
+    return "-"
+  end
+
+  local encoded = cjson.encode(string_sub(value, 1, MAX_VALUE_LENGTH))
+  return encoded or "-"
+end
+
+-- violations returns the violations of the reports of a body sent to the
+-- report-uri directive or with the Reporting API, or nil when the body is not
+-- a violation report
+function _M.violations(body)
+  local data = cjson.decode(body)
+  if type(data) ~= "table" then
+    return nil
+  end
+
+  local report = data["csp-report"]
+  if type(report) == "table" then
+    return {
+      {
+        directive = directive(report["effective-directive"] or report["violated-directive"]),
+        blocked = report["blocked-uri"],
+        document = report["document-uri"],
+        disposition = report["disposition"],
+      },
+    }
+  end
+
+  local violations = {}
+  for _, r in ipairs(data) do
+    if type(r) == "table" and r.type == "csp-violation" and type(r.body) == "table" then
+      violations[#violations + 1] = {
+        directive = directive(r.body.effectiveDirective),
+        blocked = r.body.blockedURL,
+        document = r.body.documentURL,
+        disposition = r.body.disposition,
+      }
+      if #violations >= MAX_REPORTS then
+        break
+      end
+    end
+  end
+  return violations
+end
+
+-- content logs the violation reports of a request and counts them in the
+-- variable $csp_violations, responding with the status code 204
+function _M.content()
+  if ngx.req.get_method() ~= "POST" then
+    return ngx.exit(ngx.HTTP_NOT_ALLOWED)
+  end
+
+  ngx.req.read_body()
+  -- the bodies larger than client_body_buffer_size are not read
+  local body = ngx.req.get_body_data()
+  if not body then
+    return ngx.exit(ngx.HTTP_BAD_REQUEST)
+  end
+
+  local violations = _M.violations(body)
+  if not violations then
+    return ngx.exit(ngx.HTTP_BAD_REQUEST)
+  end
+
+  local directives = {}
+  for i, violation in ipairs(violations) do
+    directives[i] = violation.directive
+    ngx.log(ngx.NOTICE, "Content Security Policy violation of ", violation.directive,
+      " in the location ", ngx.var.namespace, "/", ngx.var.ingress_name, " ", ngx.var.location_path,
+      ", document: ", loggable(violation.document),
+      ", blocked: ", loggable(violation.blocked),
+      ", disposition: ", loggable(violation.disposition))
+  end
+
+  ngx.var.csp_violations = table_concat(directives, " ")
+  return ngx.exit(ngx.HTTP_NO_CONTENT)
+end
+
+return _M
@@ -0,0 +1,173 @@
+local ngx = ngx
+local cjson = require("cjson.safe")
+local ipairs = ipairs
+local pcall = pcall
+local select = select
+local tostring = tostring
+local type = type
+local string_find = string.find
+local string_gsub = string.gsub
+local string_lower = string.lower
+local string_sub = string.sub
+
+local _M = {}
+
+-- maximum number of the decoded expressions kept in the cache
+local MAX_CACHED_EXPRESSIONS = 1000
+
+local cached_expressions = {}
+local cached_expressions_count = 0
+
+-- variables of the string attributes of the requests
+local ATTRIBUTES = {
+  ["request.method"] = "request_method",
+  ["request.path"] = "uri",
+  ["request.host"] = "host",
+  ["request.scheme"] = "scheme",
+  ["client.ip"] = "remote_addr",
+}
+
+-- prefixes of the variables of the map attributes of the requests
+local MAPS = {
+  ["request.headers"] = "http_",
+  ["request.query"] = "arg_",
+  ["request.cookies"] = "cookie_",
+}
+
+-- map_value returns the value of a key of a map attribute, or nil
+local function map_value(name, key)
+  local prefix = MAPS[name]
+  if not prefix or type(key) ~= "string" then
+    return nil
+  end
+
+  if prefix == "http_" then
+    key = string_gsub(string_lower(key), "-", "_")
+  end
+  return ngx.var[prefix .. key]
+end
+
+local evaluate
+
+local OPERATIONS = {
+  literal = function(node) return node.value end,
+  list = function(node)
+    local values = {}
+    for i, arg in ipairs(node.args or {}) do
+      values[i] = evaluate(arg)
+    end
+    return values
+  end,
+  -- the missing attributes and keys are empty strings
+  var = function(node) return ngx.var[ATTRIBUTES[node.name]] or "" end,
+  index = function(node) return map_value(node.name, evaluate(node.args[1])) or "" end,
+  has = function(node) return map_value(node.args[2].name, evaluate(node.args[1])) ~= nil end,
+  ["not"] = function(node) return not evaluate(node.args[1]) end,
+  ["and"] = function(node) return evaluate(node.args[1]) and evaluate(node.args[2]) end,
+  ["or"] = function(node) return evaluate(node.args[1]) or evaluate(node.args[2]) end,
+  eq = function(node) return evaluate(node.args[1]) == evaluate(node.args[2]) end,
+  ne = function(node) return evaluate(node.args[1]) ~= evaluate(node.args[2]) end,
+  lt = function(node) return evaluate(node.args[1]) < evaluate(node.args[2]) end,
+  le = function(node) return evaluate(node.args[1]) <= evaluate(node.args[2]) end,
+  gt = function(node) return evaluate(node.args[1]) > evaluate(node.args[2]) end,
+  ge = function(node) return evaluate(node.args[1]) >= evaluate(node.args[2]) end,
+  ["in"] = function(node)
+    local value = evaluate(node.args[1])
+    for _, v in ipairs(evaluate(node.args[2])) do
+      if v == value then
+        return true
+      end
+    end
+    return false
+  end,
+  startsWith = function(node)
+    local prefix = evaluate(node.args[2])
+    return string_sub(evaluate(node.args[1]), 1, #prefix) == prefix
+  end,
+  endsWith = function(node)
+    local suffix = evaluate(node.args[2])
+    return #suffix == 0 or string_sub(evaluate(node.args[1]), -#suffix) == suffix
+  end,
+  contains = function(node)
+    return string_find(evaluate(node.args[1]), evaluate(node.args[2]), 1, true) ~= nil
+  end,
+  matches = function(node)
+    local from, _, err = ngx.re.find(evaluate(node.args[1]), node.args[2].value, "jo")
+    if err then
+      error("invalid regular expression " .. tostring(node.args[2].value) .. ": " .. err)
+    end
+    return from ~= nil
+  end,
+  -- size counts the code points of a string, like CEL
+  size = function(node)
+    return select(2, string_gsub(evaluate(node.args[1]), "[^\128-\191]", ""))
+  end,
+}
+
+evaluate = function(node)
+  local operation = OPERATIONS[node.op]
+  if not operation then
+    error("unknown operation " .. tostring(node.op))
+  end
+  return operation(node)
+end
+
+-- evaluate returns the value of a compiled expression for the current request,
+-- or false when its evaluation fails
+function _M.evaluate(node)
+  local ok, value = pcall(evaluate, node)
+  if not ok then
+    ngx.log(ngx.ERR, "error evaluating expression: ", tostring(value))
+    return false
+  end
+  return value == true
+end
+
+-- decode returns the compiled expression encoded by the controller in the
+-- value of a variable, or nil
+function _M.decode(encoded)
+  local node = cached_expressions[encoded]
+  if node then
+    return node
+  end
+
+  local data = ngx.decode_base64(encoded)
+  if not data then
+    return nil
+  end
+
+  node = cjson.decode(data)
+  if type(node) ~= "table" then
+    return nil
+  end
+
+  if cached_expressions_count >= MAX_CACHED_EXPRESSIONS then
+    cached_expressions = {}
+    cached_expressions_count = 0
+  end
+  cached_expressions[encoded] = node
+  cached_expressions_count = cached_expressions_count + 1
+
+  return node
+end
+
+-- rewrite removes the conditional request header of the location when its
+-- expression is false
+function _M.rewrite()
+  local encoded = ngx.var.conditional_header_expression
+  if not encoded or encoded == "" then
+    return
+  end
+
+  local node = _M.decode(encoded)
+  if not node then
+    ngx.log(ngx.ERR, "invalid conditional header expression: ", encoded)
+  end
+
+  if not node or not _M.evaluate(node) then
+    -- the headers with an empty value are not sent to the backend
+    ngx.var.conditional_header_value = ""
+  end
+end
+
+return _M
@@ -0,0 +1,177 @@
+-- Translates the gRPC-Web requests of the browsers to gRPC for the backends,
+-- and the gRPC responses of the backends to gRPC-Web. The messages are framed
+-- the same way by both protocols, the trailers of the gRPC responses being
+-- sent in a last frame of the gRPC-Web responses.
+-- https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-WEB.md
+
+local ngx = ngx
+local string_char = string.char
+local string_find = string.find
+local string_gmatch = string.gmatch
+local string_lower = string.lower
+local string_sub = string.sub
+local table_concat = table.concat
+local table_insert = table.insert
+local io_open = io.open
+local math_floor = math.floor
+
+local _M = {}
+
+local GRPC_WEB_CONTENT_TYPE = "application/grpc-web"
+local GRPC_WEB_TEXT_CONTENT_TYPE = "application/grpc-web-text"
+local GRPC_CONTENT_TYPE = "application/grpc"
+
+-- flag of the frames containing the trailers
+local TRAILERS_FLAG = string_char(0x80)
+
+local function has_prefix(value, prefix)
+  return string_sub(value, 1, #prefix) == prefix
+end
+
+-- decode returns the data of a gRPC-Web text body, made of base64 encoded
+-- segments possibly padded
+local function decode(body)
+  local data = {}
+  for segment in string_gmatch(body, "[^=]+=*") do
+    local decoded = ngx.decode_base64(segment)
+    if not decoded then
+      return nil
+    end
+    table_insert(data, decoded)
+  end
+
+  return table_concat(data)
+end
+
+local function read_body()
+  ngx.req.read_body()
+
+  local body = ngx.req.get_body_data()
+  if body then
+    return body
+  end
+
+  local file_name = ngx.req.get_body_file()
+  if not file_name then
+    return ""
+  end
+
+  local file = io_open(file_name, "rb")
+  if not file then
+    return nil
+  end
+  body = file:read("*a")
+  file:close()
+
+  return body
+end
+
+-- trailers_frame returns the frame containing the trailers of the gRPC
+-- response, or nil when the status was sent in the headers of the response
+function _M.trailers_frame(status, message)
+  if not status then
+    return nil
+  end
+
+  local trailers = "grpc-status:" .. status .. "\r\n"
+  if message and message ~= "" then
+    trailers = trailers .. "grpc-message:" .. message .. "\r\n"
+  end
+
+  local length = #trailers
+  return TRAILERS_FLAG ..
+    string_char(math_floor(length / 16777216) % 256, math_floor(length / 65536) % 256,
+                math_floor(length / 256) % 256, length % 256) ..
+    trailers
+end
+
+-- rewrite translates the content type of the gRPC-Web requests, and decodes
+-- the body of the requests in text mode
+function _M.rewrite()
+  if ngx.var.grpc_web ~= "true" then
+    return
+  end
+
+  local content_type = ngx.var.http_content_type
+  if not content_type then
+    return
+  end
+  content_type = string_lower(content_type)
+
+  local text = has_prefix(content_type, GRPC_WEB_TEXT_CONTENT_TYPE)
+  if not text and not has_prefix(content_type, GRPC_WEB_CONTENT_TYPE) then
+    return
+  end
+
+  local prefix = text and GRPC_WEB_TEXT_CONTENT_TYPE or GRPC_WEB_CONTENT_TYPE
+  ngx.req.set_header("Content-Type", GRPC_CONTENT_TYPE .. string_sub(content_type, #prefix + 1))
+
+  if text then
+    local body = read_body()
+    local data = body and decode(body)
+    if not data then
+      ngx.log(ngx.WARN, "invalid body of gRPC-Web text request")
+      return ngx.exit(ngx.HTTP_BAD_REQUEST)
+    end
+    ngx.req.set_body_data(data)
+  end
+
+  ngx.ctx.grpc_web = { text = text, pending = "" }
+end
+
+-- header_filter translates the content type of the responses to gRPC-Web
+function _M.header_filter()
+  local grpc_web = ngx.ctx.grpc_web
+  if not grpc_web then
+    return
+  end
+
+  local content_type = ngx.header["Content-Type"]
+  local suffix = ""
+  if content_type and string_find(content_type, GRPC_CONTENT_TYPE, 1, true) == 1 then
+    suffix = string_sub(content_type, #GRPC_CONTENT_TYPE + 1)
+  end
+
+  local prefix = grpc_web.text and GRPC_WEB_TEXT_CONTENT_TYPE or GRPC_WEB_CONTENT_TYPE
+  ngx.header["Content-Type"] = prefix .. suffix
+  ngx.header["Content-Length"] = nil
+end
+
+-- body_filter appends the trailers of the gRPC response to the body, and
+-- encodes the body in text mode. The data encoded in a chunk is a multiple
+-- of 3 bytes, the rest being encoded with the next chunk.
+function _M.body_filter()
+  local grpc_web = ngx.ctx.grpc_web
+  if not grpc_web then
+    return
+  end
+
+  local chunk, eof = ngx.arg[1], ngx.arg[2]
+
+  local trailers
+  if eof then
+    trailers = _M.trailers_frame(ngx.var.upstream_trailer_grpc_status,
+                                 ngx.var.upstream_trailer_grpc_message)
+  end
+
+  if not grpc_web.text then
+    if trailers then
+      ngx.arg[1] = chunk .. trailers
+    end
+    return
+  end
+
+  local data = grpc_web.pending .. chunk
+  if eof then
+    grpc_web.pending = ""
+    local encoded = #data > 0 and ngx.encode_base64(data) or ""
+    ngx.arg[1] = encoded .. (trailers and ngx.encode_base64(trailers) or "")
+    return
+  end
+
+  local length = #data - #data % 3
+  grpc_web.pending = string_sub(data, length + 1)
+  ngx.arg[1] = length > 0 and ngx.encode_base64(string_sub(data, 1, length)) or ""
+end
+
+return _M
@@ -0,0 +1,67 @@
+local cjson = require("cjson.safe")
+
+local ngx = ngx
+local pairs = pairs
+local type = type
+
+local stores = ngx.shared.lua_kv
+
+local _M = {}
+
+-- maximum number of the decoded stores kept in the cache of the worker
+local MAX_CACHED_STORES = 1000
+
+local cached_stores = {}
+local cached_stores_count = 0
+
+-- store returns the data of the key/value store of the Ingress of the
+-- request, an empty table when the Ingress has no store
+local function store()
+  local namespace, name = ngx.var.namespace, ngx.var.ingress_name
+  if not namespace or not name or namespace == "" or name == "" then
+    return {}
+  end
+
+  local raw = stores:get(namespace .. "/" .. name)
+  if not raw then
+    return {}
+  end
+
+  -- the stores are decoded once per worker until their data changes
+  local data = cached_stores[raw]
+  if data then
+    return data
+  end
+
+  data = cjson.decode(raw)
+  if type(data) ~= "table" then
+    ngx.log(ngx.ERR, "invalid key/value store of the Ingress ", namespace, "/", name)
+    return {}
+  end
+
+  if cached_stores_count >= MAX_CACHED_STORES then
+    cached_stores = {}
+    cached_stores_count = 0
+  end
+  cached_stores[raw] = data
+  cached_stores_count = cached_stores_count + 1
+
+  return data
+end
+
+-- get returns the value of a key of the store of the Ingress of the request,
+-- or nil
+function _M.get(key)
+  return store()[key]
+end
+
+-- get_all returns a copy of the data of the store of the Ingress of the request
+function _M.get_all()
+  local data = {}
+  for key, value in pairs(store()) do
+    data[key] = value
+  end
+  return data
+end
+
+return _M
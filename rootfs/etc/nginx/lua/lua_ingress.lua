@@ -1,5 +1,6 @@
 local ngx_re_split = require("ngx.re").split
 local string_to_bool = require("util").string_to_bool
+local trusted_proxies = require("trusted_proxies")
 
 local certificate_configured_for_current_request =
   require("certificate").configured_for_current_request
@@ -62,11 +63,38 @@ local function randomseed()
   math.randomseed(seed)
 end
 
+-- exempted_from_redirect checks if the path of the request starts with one of
+-- the comma separated prefixes of the ssl-redirect-exempt-paths annotation
+local function exempted_from_redirect(exempt_paths)
+  if not exempt_paths or exempt_paths == "" then
+    return false
+  end
+
+  local prefixes, err = ngx_re_split(exempt_paths, ",")
+  if err then
+    ngx.log(ngx.ERR, "could not parse variable: ", err)
+    return false
+  end
+
+  local uri = ngx.var.uri
+  for _, prefix in ipairs(prefixes) do
+    if prefix ~= "" and string.sub(uri, 1, #prefix) == prefix then
+      return true
+    end
+  end
+
+  return false
+end
+
 local function redirect_to_https(location_config)
   if location_config.force_no_ssl_redirect then
     return false
   end
 
+  if exempted_from_redirect(location_config.ssl_redirect_exempt_paths) then
+    return false
+  end
+
   if location_config.force_ssl_redirect and ngx.var.pass_access_scheme == "http" then
     return true
   end
@@ -98,6 +126,102 @@ local function parse_x_forwarded_host()
   return hosts[1]
 end
 
+-- location_setting returns the value of a variable set by the annotations of
+-- the location, or nil when it is not set
+local function location_setting(name)
+  local value = ngx.var[name]
+  if not value or value == "" then
+    return nil
+  end
+  return value
+end
+
+-- setting returns the value of a variable set for the location, or else the
+-- value of the global configuration, or nil when neither is set
+local function setting(name)
+  local value = location_setting(name)
+  if value then
+    return value
+  end
+
+  value = config[name]
+  if not value or value == "" then
+    return nil
+  end
+  return value
+end
+
+-- first_value returns the first value of a comma separated header, or nil
+local function first_value(header)
+  if not header then
+    return nil
+  end
+
+  local value = string.match(header, "^%s*([^,%s]+)")
+  return value
+end
+
+-- append_value returns the value of a forwarded header sent to the backend
+-- appending the value of this hop to the value sent by the peer
+local function append_value(header, value)
+  if not header or header == "" then
+    return tostring(value)
+  end
+  return header .. ", " .. tostring(value)
+end
+
+-- external_port returns the port the clients connected to, the ports
+-- receiving the TLS connections being seen by the clients as the port 443
+local function external_port(port)
+  if config.is_ssl_passthrough_enabled then
+    if port == config.listen_ports.ssl_proxy then
+      return 443
+    end
+  elseif port == config.listen_ports.https then
+    return 443
+  end
+  return port
+end
+
+-- forwarded_headers sets the scheme, the host and the port of the request
+-- from the X-Forwarded-Proto, X-Forwarded-Host and X-Forwarded-Port headers
+-- as defined by the mode: "trust" honors the headers of the trusted peers,
+-- "overwrite" replaces them by the values of the connection and "append"
+-- honors them, the values of the connection being appended to the headers
+-- sent to the backend
+local function forwarded_headers(mode, scheme)
+  local host = ngx.var.http_host or ngx.var.host
+  local port = external_port(ngx.var.pass_server_port)
+
+  ngx.var.pass_access_scheme = scheme
+  ngx.var.best_http_host = host
+
+  local trusted = mode ~= "overwrite" and trusted_proxies.is_trusted(true, config.trusted_proxies)
+  if trusted then
+    local forwarded_proto = first_value(ngx.var.http_x_forwarded_proto)
+    if forwarded_proto then
+      ngx.var.pass_access_scheme = forwarded_proto
+    end
+
+    local forwarded_port = first_value(ngx.var.http_x_forwarded_port)
+    if forwarded_port then
+      ngx.var.pass_server_port = forwarded_port
+    end
+
+    if ngx.var.http_x_forwarded_host then
+      ngx.var.best_http_host = parse_x_forwarded_host()
+    end
+  end
+
+  ngx.var.pass_port = external_port(ngx.var.pass_server_port)
+
+  if mode == "append" then
+    ngx.var.forwarded_proto = append_value(trusted and ngx.var.http_x_forwarded_proto, scheme)
+    ngx.var.forwarded_host = append_value(trusted and ngx.var.http_x_forwarded_host, host)
+    ngx.var.forwarded_port = append_value(trusted and ngx.var.http_x_forwarded_port, port)
+  end
+end
+
 function _M.init_worker()
   randomseed()
 end
@@ -117,13 +241,29 @@ function _M.rewrite()
     force_no_ssl_redirect = string_to_bool(ngx.var.force_no_ssl_redirect),
     preserve_trailing_slash = string_to_bool(ngx.var.preserve_trailing_slash),
     use_port_in_redirects = string_to_bool(ngx.var.use_port_in_redirects),
+    ssl_redirect_code = tonumber(ngx.var.ssl_redirect_code),
+    ssl_redirect_strip_query = string_to_bool(ngx.var.ssl_redirect_strip_query),
+    ssl_redirect_exempt_paths = ngx.var.ssl_redirect_exempt_paths,
   }
 
-  ngx.var.pass_access_scheme = ngx.var.scheme
+  local scheme_override = setting("forwarded_scheme_override")
+  ngx.var.pass_access_scheme = scheme_override or ngx.var.scheme
 
   ngx.var.best_http_host = ngx.var.http_host or ngx.var.host
 
-  if config.use_forwarded_headers then
+  local forwarded_headers_trusted =
+    trusted_proxies.is_trusted(config.use_forwarded_headers, config.trusted_proxies)
+  trusted_proxies.rewrite(forwarded_headers_trusted)
+
+  local forwarded_headers_mode = setting("forwarded_headers_mode")
+  if forwarded_headers_mode then
+    local scheme = scheme_override or ngx.var.scheme
+    if not scheme_override and config.use_proxy_protocol
+      and ngx.var.proxy_protocol_server_port == "443" then
+      scheme = "https"
+    end
+    forwarded_headers(forwarded_headers_mode, scheme)
+  elseif forwarded_headers_trusted then
     -- trust http_x_forwarded_proto headers correctly indicate ssl offloading
     if ngx.var.http_x_forwarded_proto then
       ngx.var.pass_access_scheme = ngx.var.http_x_forwarded_proto
@@ -139,23 +279,22 @@ function _M.rewrite()
     end
   end
 
-  if config.use_proxy_protocol then
-    if ngx.var.proxy_protocol_server_port == "443" then
-      ngx.var.pass_access_scheme = "https"
+  if not forwarded_headers_mode then
+    if config.use_proxy_protocol and not scheme_override then
+      if ngx.var.proxy_protocol_server_port == "443" then
+        ngx.var.pass_access_scheme = "https"
+      end
     end
-  end
 
-  ngx.var.pass_port = ngx.var.pass_server_port
-  if config.is_ssl_passthrough_enabled then
-    if ngx.var.pass_server_port == config.listen_ports.ssl_proxy then
-      ngx.var.pass_port = 443
-    end
-  elseif ngx.var.pass_server_port == config.listen_ports.https then
-    ngx.var.pass_port = 443
+    ngx.var.pass_port = external_port(ngx.var.pass_server_port)
   end
 
   if redirect_to_https(location_config) then
     local request_uri = ngx.var.request_uri
+    if location_config.ssl_redirect_strip_query then
+      request_uri = string.match(request_uri, "^[^?]*")
+    end
+
     -- do not append a trailing slash on redirects unless enabled by annotations
     if location_config.preserve_trailing_slash == false then
       if string.byte(request_uri, -1, -1) == string.byte('/') then
@@ -170,22 +309,69 @@ function _M.rewrite()
         config.listen_ports.https, request_uri)
     end
 
-    return ngx_redirect(uri, config.http_redirect_code)
+    local code = location_config.ssl_redirect_code
+    if not code or code == 0 then
+      code = config.http_redirect_code
+    end
+
+    return ngx_redirect(uri, code)
   end
 
 end
 
-function _M.header()
-  if config.hsts and ngx.var.scheme == "https" and certificate_configured_for_current_request then
-    local value = "max-age=" .. config.hsts_max_age
-    if config.hsts_include_subdomains then
-      value = value .. "; includeSubDomains"
+-- is_hsts_excluded_host checks if a host is in the comma separated list of
+-- the hosts excluded from HSTS, which can start with the wildcard "*."
+local function is_hsts_excluded_host(host, excluded_hosts)
+  for excluded_host in string.gmatch(excluded_hosts, "[^,]+") do
+    if excluded_host == host then
+      return true
     end
-    if config.hsts_preload then
-      value = value .. "; preload"
+
+    if string.sub(excluded_host, 1, 2) == "*." then
+      local suffix = string.sub(excluded_host, 2)
+      if #host > #suffix and string.sub(host, -#suffix) == suffix then
+        return true
+      end
     end
-    ngx.header["Strict-Transport-Security"] = value
   end
+
+  return false
+end
+
+function _M.header()
+  if ngx.var.scheme ~= "https" or not certificate_configured_for_current_request then
+    return
+  end
+
+  -- the annotations of the Ingresses override the global configuration
+  local max_age = location_setting("hsts_max_age")
+  if not config.hsts and not max_age then
+    return
+  end
+
+  local excluded_hosts = location_setting("hsts_exclude_hosts")
+  if excluded_hosts and is_hsts_excluded_host(ngx.var.host, excluded_hosts) then
+    return
+  end
+
+  local include_subdomains = config.hsts_include_subdomains
+  if location_setting("hsts_include_subdomains") then
+    include_subdomains = string_to_bool(ngx.var.hsts_include_subdomains)
+  end
+
+  local preload = config.hsts_preload
+  if location_setting("hsts_preload") then
+    preload = string_to_bool(ngx.var.hsts_preload)
+  end
+
+  local value = "max-age=" .. (max_age or config.hsts_max_age)
+  if include_subdomains then
+    value = value .. "; includeSubDomains"
+  end
+  if preload then
+    value = value .. "; preload"
+  end
+  ngx.header["Strict-Transport-Security"] = value
 end
 
 return _M
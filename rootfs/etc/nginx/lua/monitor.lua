@@ -9,6 +9,7 @@ local new_tab = require "table.new"
 local clear_tab = require "table.clear"
 local table = table
 local pairs = pairs
+local ipairs = ipairs
 
 
 -- if an Nginx worker processes more than (MAX_BATCH_SIZE/FLUSH_INTERVAL) RPS
@@ -31,6 +32,82 @@ local function send(payload)
   assert(s:close())
 end
 
+-- upstream_values returns the values of an upstream variable for each try of
+-- a request, the tries of the internal redirects included
+local function upstream_values(value)
+  local values = {}
+  local tries = string.gsub(value, " : ", ", ")
+  for v in string.gmatch(tries, "[^,%s]+") do
+    table.insert(values, v)
+  end
+  return values
+end
+
+-- upstream_connect_failures returns the IP families, separated by spaces, of
+-- the upstream endpoints the request failed to connect to
+local function upstream_connect_failures()
+  local addresses, connect_times = ngx.var.upstream_addr, ngx.var.upstream_connect_time
+  if not addresses or not connect_times then
+    return "-"
+  end
+
+  connect_times = upstream_values(connect_times)
+  local families = {}
+  for i, address in ipairs(upstream_values(addresses)) do
+    -- the connect time of a try is "-" when no connection was established
+    if connect_times[i] == "-" then
+      if string.find(address, "^%[") then
+        table.insert(families, "ipv6")
+      elseif string.find(address, "^%d+%.%d+%.%d+%.%d+:") then
+        table.insert(families, "ipv4")
+      end
+    end
+  end
+  return table.concat(families, " ")
+end
+
+-- upstream_times returns the times in seconds of an upstream variable for
+-- each try of a request that established a connection, or nil
+local function upstream_times(value)
+  if not value then
+    return nil
+  end
+
+  local times = {}
+  for _, v in ipairs(upstream_values(value)) do
+    local time = tonumber(v)
+    if time then
+      table.insert(times, time)
+    end
+  end
+
+  -- the empty tables would be encoded as JSON objects
+  if #times == 0 then
+    return nil
+  end
+  return times
+end
+
+-- last_upstream_value returns the value of an upstream variable for the last
+-- try of a request, the one of the endpoint that sent the response, or nil
+local function last_upstream_value(value)
+  if not value then
+    return nil
+  end
+
+  local values = upstream_values(value)
+  return values[#values]
+end
+
+-- upstream_name returns the name of the backend the request was routed to
+local function upstream_name()
+  local alternative = ngx.var.proxy_alternative_upstream_name
+  if alternative and alternative ~= "" then
+    return alternative
+  end
+  return ngx.var.proxy_upstream_name or "-"
+end
+
 local function metrics()
   return {
     host = ngx.var.host or "-",
@@ -50,6 +127,27 @@ local function metrics()
     upstreamHeaderTime = tonumber(ngx.var.upstream_header_time) or -1,
     upstreamResponseTime = tonumber(ngx.var.upstream_response_time) or -1,
     upstreamResponseLength = tonumber(ngx.var.upstream_response_length) or -1,
+
+    upstream = upstream_name(),
+    upstreamConnectTimes = upstream_times(ngx.var.upstream_connect_time),
+    upstreamHeaderTimes = upstream_times(ngx.var.upstream_header_time),
+
+    limitConnStatus = ngx.var.limit_conn_status or "-",
+    limitReqStatus = ngx.var.limit_req_status or "-",
+
+    canaryDecision = ngx.var.canary_decision or "-",
+
+    backupFailover = ngx.var.backup_failover or "-",
+
+    bodyInspectionRejection = ngx.var.body_inspection_rejection or "-",
+    cacheCoalesced = ngx.var.proxy_cache_coalesced == "true",
+
+    cspViolations = ngx.var.csp_violations or "-",
+    upstreamConnectFailures = upstream_connect_failures(),
+
+    endpoint = last_upstream_value(ngx.var.upstream_addr) or "-",
+    endpointStatus = last_upstream_value(ngx.var.upstream_status) or "-",
+    endpointResponseTime = tonumber(last_upstream_value(ngx.var.upstream_response_time)) or -1,
     --upstreamStatus = ngx.var.upstream_status or "-",
   }
 end
@@ -117,6 +215,9 @@ end
 setmetatable(_M, {__index = {
   flush = flush,
   set_metrics_max_batch_size = set_metrics_max_batch_size,
+  upstream_connect_failures = upstream_connect_failures,
+  upstream_times = upstream_times,
+  last_upstream_value = last_upstream_value,
   get_metrics_batch = function() return metrics_batch end,
 }})
 
@@ -0,0 +1,2 @@
+local balancer = require("balancer")
+balancer.balance_host(tonumber(ngx.var.auth_port))
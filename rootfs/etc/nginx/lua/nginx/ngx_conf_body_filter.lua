@@ -0,0 +1,3 @@
+local grpc_web = require("grpc_web")
+
+grpc_web.body_filter()
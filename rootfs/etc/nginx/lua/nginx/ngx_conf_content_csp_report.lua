@@ -0,0 +1,3 @@
+local csp_report = require("csp_report")
+
+csp_report.content()
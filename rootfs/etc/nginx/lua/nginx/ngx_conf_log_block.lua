@@ -1,5 +1,6 @@
 local balancer = require("balancer")
 local monitor = require("monitor")
+local proxy_cache = require("proxy_cache")
 
 local luaconfig = ngx.shared.luaconfig
 local enablemetrics = luaconfig:get("enablemetrics")
@@ -7,5 +8,6 @@ local enablemetrics = luaconfig:get("enablemetrics")
 balancer.log()
 
 if enablemetrics then
+    proxy_cache.log()
     monitor.call()
 end
\ No newline at end of file
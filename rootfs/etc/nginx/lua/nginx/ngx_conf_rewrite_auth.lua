@@ -1 +1,11 @@
-ngx.var.cache_key = ngx.encode_base64(ngx.sha1_bin(ngx.var.tmp_cache_key))
\ No newline at end of file
+local balancer = require("balancer")
+
+local tmp_cache_key = ngx.var.tmp_cache_key
+if tmp_cache_key and tmp_cache_key ~= "" then
+  ngx.var.cache_key = ngx.encode_base64(ngx.sha1_bin(tmp_cache_key))
+end
+
+local auth_host = ngx.var.auth_host
+if auth_host and auth_host ~= "" then
+  balancer.resolve_host(auth_host)
+end
@@ -1,2 +1,9 @@
 local lua_ingress = require("lua_ingress")
-lua_ingress.header()
\ No newline at end of file
+local balancer = require("balancer")
+local grpc_web = require("grpc_web")
+local proxy_cache = require("proxy_cache")
+
+lua_ingress.header()
+balancer.header_filter()
+grpc_web.header_filter()
+proxy_cache.header_filter()
@@ -1,5 +1,18 @@
 local lua_ingress = require("lua_ingress")
 local balancer = require("balancer")
 
+local basic_auth = require("basic_auth")
+local body_inspection = require("body_inspection")
+local expression = require("expression")
+local grpc_web = require("grpc_web")
+local oidc = require("oidc")
+local timeout_budget = require("timeout_budget")
+
 lua_ingress.rewrite()
-balancer.rewrite()
\ No newline at end of file
+basic_auth.rewrite()
+oidc.rewrite()
+timeout_budget.rewrite()
+body_inspection.rewrite()
+grpc_web.rewrite()
+expression.rewrite()
+balancer.rewrite()
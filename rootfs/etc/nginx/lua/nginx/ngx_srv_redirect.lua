@@ -1,8 +1,11 @@
 local request_uri = ngx.var.request_uri
 local redirect_to = ngx.arg[1]
 
+local trusted_proxies = require("trusted_proxies")
+
 local luaconfig = ngx.shared.luaconfig
 local use_forwarded_headers = luaconfig:get("use_forwarded_headers")
+local global_trusted_proxies = luaconfig:get("trusted_proxies")
 
 if string.sub(request_uri, -1) == "/" then
     request_uri = string.sub(request_uri, 1, -2)
@@ -11,7 +14,7 @@ end
 local redirectScheme = ngx.var.scheme
 local redirectPort = ngx.var.server_port
 
-if use_forwarded_headers then
+if trusted_proxies.is_trusted(use_forwarded_headers, global_trusted_proxies) then
     if ngx.var.http_x_forwarded_proto then
         redirectScheme = ngx.var.http_x_forwarded_proto
     end
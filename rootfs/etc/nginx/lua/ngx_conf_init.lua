@@ -9,6 +9,8 @@ local configfile = cjson.decode(content)
 local luaconfig = ngx.shared.luaconfig
 luaconfig:set("enablemetrics", configfile.enable_metrics)
 luaconfig:set("use_forwarded_headers", configfile.use_forwarded_headers)
+luaconfig:set("trusted_proxies", configfile.trusted_proxies)
+require("util.dns").stale_ttl = configfile.dns_cache_stale_ttl or 0
 -- init modules
 local ok, res
 ok, res = pcall(require, "lua_ingress")
@@ -33,6 +35,10 @@ if not ok then
   error("require failed: " .. tostring(res))
 else
   balancer = res
+  if configfile.upstream_ip_family_preference ~= "" then
+    balancer.ip_family_preference = configfile.upstream_ip_family_preference
+    balancer.ip_family_fallback_timeout = configfile.upstream_ip_family_fallback_timeout
+  end
 end
 if configfile.enable_metrics then
     ok, res = pcall(require, "monitor")
@@ -49,5 +55,6 @@ else
   certificate = res
   if configfile.enable_ocsp then
     certificate.is_ocsp_stapling_enabled = configfile.enable_ocsp
+    certificate.is_ocsp_prefetch_enabled = configfile.ocsp_prefetch
   end
 end
\ No newline at end of file
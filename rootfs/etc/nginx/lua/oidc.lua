@@ -0,0 +1,432 @@
+local cjson = require("cjson.safe")
+local http = require("resty.http")
+local aes = require("resty.aes")
+local random = require("resty.random")
+local resty_sha256 = require("resty.sha256")
+local lrucache = require("resty.lrucache")
+local jwt = require("util.jwt")
+local basic_auth = require("basic_auth")
+
+local ngx = ngx
+local type = type
+local ipairs = ipairs
+local tonumber = tonumber
+local tostring = tostring
+local string_find = string.find
+local string_gsub = string.gsub
+local string_match = string.match
+local string_sub = string.sub
+local table_concat = table.concat
+
+local clients = ngx.shared.oidc_clients
+
+local _M = {}
+
+-- the discovery documents of the issuers are cached in the worker
+local DISCOVERY_CACHE_SIZE = 64
+local DISCOVERY_CACHE_TTL = 3600
+local discovery_cache, cache_err = lrucache.new(DISCOVERY_CACHE_SIZE)
+if not discovery_cache then
+  error("failed to create the cache of the OIDC discovery documents: " .. (cache_err or "unknown"))
+end
+
+local HTTP_TIMEOUT = 5000
+
+-- duration in seconds of the login of the users with the issuer
+local STATE_DURATION = 600
+local STATE_COOKIE_SUFFIX = "_state"
+
+local HTML_ENTITIES = {
+  ["&"] = "&amp;",
+  ["<"] = "&lt;",
+  [">"] = "&gt;",
+  ['"'] = "&quot;",
+  ["'"] = "&#39;",
+}
+
+-- headers set from the identity of the users
+local USER_HEADER = "X-Auth-Request-User"
+local EMAIL_HEADER = "X-Auth-Request-Email"
+
+local function sha256(value)
+  local hasher = resty_sha256:new()
+  hasher:update(value)
+  return hasher:final()
+end
+
+local function encode_base64url(value)
+  local encoded = ngx.encode_base64(value, true)
+  encoded = string_gsub(encoded, "%+", "-")
+  encoded = string_gsub(encoded, "/", "_")
+  return encoded
+end
+
+local function decode_base64url(value)
+  local padding = #value % 4
+  if padding > 0 then
+    value = value .. string.rep("=", 4 - padding)
+  end
+
+  value = string_gsub(value, "%-", "+")
+  value = string_gsub(value, "_", "/")
+  return ngx.decode_base64(value)
+end
+
+local function random_string()
+  return encode_base64url(random.bytes(32, true))
+end
+
+-- keys returns the keys encrypting and authenticating the cookies of a
+-- location. They are derived from the cookie secret of the client, or from
+-- its client secret when the Secret does not define a cookie secret.
+function _M.keys(key, client)
+  local secret = client.cookieSecret
+  if not secret or secret == "" then
+    secret = client.clientSecret
+  end
+
+  return {
+    encryption = sha256("encryption:" .. key .. ":" .. secret),
+    authentication = sha256("authentication:" .. key .. ":" .. secret),
+  }
+end
+
+-- seal encrypts a table and authenticates the result, to be stored in a cookie
+function _M.seal(keys, data)
+  local iv = random.bytes(16, true)
+  local cipher = aes:new(keys.encryption, nil, aes.cipher(256, "cbc"), { iv = iv })
+  local encrypted = iv .. cipher:encrypt(cjson.encode(data))
+  return encode_base64url(encrypted) .. "." .. encode_base64url(ngx.hmac_sha1(keys.authentication, encrypted))
+end
+
+-- unseal returns the table of a sealed value, or nil when it cannot be
+-- authenticated or decrypted
+function _M.unseal(keys, value)
+  if type(value) ~= "string" then
+    return nil
+  end
+
+  local encoded, encoded_mac = string_match(value, "^([%w%-_]+)%.([%w%-_]+)$")
+  if not encoded then
+    return nil
+  end
+
+  local encrypted = decode_base64url(encoded)
+  local mac = decode_base64url(encoded_mac)
+  if not encrypted or not mac or #encrypted <= 16 then
+    return nil
+  end
+
+  if not basic_auth.constant_time_equals(ngx.hmac_sha1(keys.authentication, encrypted), mac) then
+    return nil
+  end
+
+  local cipher = aes:new(keys.encryption, nil, aes.cipher(256, "cbc"), { iv = string_sub(encrypted, 1, 16) })
+  local decrypted = cipher:decrypt(string_sub(encrypted, 17))
+  if not decrypted then
+    return nil
+  end
+
+  local data = cjson.decode(decrypted)
+  if type(data) ~= "table" then
+    return nil
+  end
+
+  return data
+end
+
+local function cookie(config, name, value, max_age, same_site)
+  local attributes = {
+    name .. "=" .. value,
+    "Path=/",
+    "Max-Age=" .. max_age,
+    "Secure",
+    "HttpOnly",
+    "SameSite=" .. same_site,
+  }
+  if config.cookie_domain ~= "" then
+    attributes[#attributes + 1] = "Domain=" .. config.cookie_domain
+  end
+
+  return table_concat(attributes, "; ")
+end
+
+local function without_trailing_slash(url)
+  return (string_gsub(url, "/+$", ""))
+end
+
+local function request_json(url, params)
+  local httpc = http.new()
+  httpc:set_timeout(HTTP_TIMEOUT)
+
+  params.ssl_verify = true
+  local res, err = httpc:request_uri(url, params)
+  if not res then
+    return nil, err
+  end
+
+  if res.status ~= ngx.HTTP_OK then
+    return nil, "unexpected status code " .. tostring(res.status)
+  end
+
+  local data = cjson.decode(res.body)
+  if type(data) ~= "table" then
+    return nil, "invalid JSON response"
+  end
+
+  return data
+end
+
+-- discover returns the OpenID Connect discovery document of an issuer
+function _M.discover(issuer)
+  local discovery = discovery_cache:get(issuer)
+  if discovery then
+    return discovery
+  end
+
+  local err
+  discovery, err = request_json(without_trailing_slash(issuer) .. "/.well-known/openid-configuration",
+                                { method = "GET" })
+  if not discovery then
+    return nil, err
+  end
+
+  if type(discovery.issuer) ~= "string" or
+      without_trailing_slash(discovery.issuer) ~= without_trailing_slash(issuer) then
+    return nil, "the discovery document belongs to the issuer " .. tostring(discovery.issuer)
+  end
+
+  for _, endpoint in ipairs({ "authorization_endpoint", "token_endpoint" }) do
+    if type(discovery[endpoint]) ~= "string" or string_sub(discovery[endpoint], 1, 8) ~= "https://" then
+      return nil, "invalid " .. endpoint .. " in the discovery document"
+    end
+  end
+
+  discovery_cache:set(issuer, discovery, DISCOVERY_CACHE_TTL)
+  return discovery
+end
+
+-- validate_id_token returns the claims of an ID token received from the token
+-- endpoint. The token is received from the issuer over TLS, so its signature
+-- is not verified, as allowed by OpenID Connect Core 1.0 section 3.1.3.7.
+function _M.validate_id_token(id_token, discovery, client_id, nonce)
+  local claims = jwt.claims(id_token)
+  if not claims then
+    return nil, "invalid ID token"
+  end
+
+  if type(claims.iss) ~= "string" or without_trailing_slash(claims.iss) ~= without_trailing_slash(discovery.issuer) then
+    return nil, "invalid issuer " .. tostring(claims.iss)
+  end
+
+  local audiences = claims.aud
+  if type(audiences) ~= "table" then
+    audiences = { audiences }
+  end
+  local audience_found = false
+  for _, audience in ipairs(audiences) do
+    if audience == client_id then
+      audience_found = true
+    end
+  end
+  if not audience_found or (#audiences > 1 and claims.azp ~= client_id) then
+    return nil, "the ID token was not issued to the client " .. client_id
+  end
+
+  if type(claims.exp) ~= "number" or claims.exp <= ngx.time() then
+    return nil, "the ID token is expired"
+  end
+
+  if claims.nonce ~= nonce then
+    return nil, "invalid nonce"
+  end
+
+  if type(claims.sub) ~= "string" or claims.sub == "" then
+    return nil, "the ID token does not contain a subject"
+  end
+
+  return claims
+end
+
+local function redirect_uri(config)
+  local scheme = ngx.var.pass_access_scheme or ngx.var.scheme
+  return scheme .. "://" .. ngx.var.host .. config.redirect_path
+end
+
+-- is_local_target checks if the target of a redirection is a path of the
+-- host, to not redirect the users to other websites after the login
+local function is_local_target(target)
+  return type(target) == "string" and string_sub(target, 1, 1) == "/" and
+    string_sub(target, 2, 2) ~= "/" and string_sub(target, 2, 2) ~= "\\"
+end
+
+local function login(config, client, keys, discovery)
+  local state = random_string()
+  local nonce = random_string()
+  local verifier = random_string()
+
+  local sealed = _M.seal(keys, {
+    state = state,
+    nonce = nonce,
+    verifier = verifier,
+    target = ngx.var.request_uri,
+    exp = ngx.time() + STATE_DURATION,
+  })
+
+  -- the state cookie is sent in the redirection from the issuer, which is
+  -- a cross-site request for the browsers
+  ngx.header["Set-Cookie"] = cookie(config, config.cookie_name .. STATE_COOKIE_SUFFIX, sealed, STATE_DURATION, "Lax")
+
+  local separator = string_find(discovery.authorization_endpoint, "?", 1, true) and "&" or "?"
+  return ngx.redirect(discovery.authorization_endpoint .. separator .. ngx.encode_args({
+    response_type = "code",
+    client_id = client.clientId,
+    redirect_uri = redirect_uri(config),
+    scope = config.scopes,
+    state = state,
+    nonce = nonce,
+    code_challenge = encode_base64url(sha256(verifier)),
+    code_challenge_method = "S256",
+  }))
+end
+
+local function exchange_code(config, client, discovery, code, verifier)
+  local credentials = ngx.escape_uri(client.clientId) .. ":" .. ngx.escape_uri(client.clientSecret)
+  return request_json(discovery.token_endpoint, {
+    method = "POST",
+    body = ngx.encode_args({
+      grant_type = "authorization_code",
+      code = code,
+      redirect_uri = redirect_uri(config),
+      code_verifier = verifier,
+    }),
+    headers = {
+      ["Authorization"] = "Basic " .. ngx.encode_base64(credentials),
+      ["Content-Type"] = "application/x-www-form-urlencoded",
+      ["Accept"] = "application/json",
+    },
+  })
+end
+
+local function callback(config, client, keys, discovery)
+  local args = ngx.req.get_uri_args()
+  local state_cookie_name = config.cookie_name .. STATE_COOKIE_SUFFIX
+  local state = _M.unseal(keys, ngx.var["cookie_" .. state_cookie_name])
+  if not state or type(state.exp) ~= "number" or state.exp <= ngx.time() then
+    ngx.log(ngx.INFO, "OIDC callback without a valid state cookie")
+    return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+
+  if args.error then
+    ngx.log(ngx.INFO, "OIDC authentication error: ", tostring(args.error))
+    return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+
+  if type(args.state) ~= "string" or not basic_auth.constant_time_equals(args.state, state.state) or
+      type(args.code) ~= "string" then
+    ngx.log(ngx.INFO, "OIDC callback with an invalid state")
+    return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+
+  local tokens, err = exchange_code(config, client, discovery, args.code, state.verifier)
+  if not tokens then
+    ngx.log(ngx.ERR, "error exchanging the OIDC authorization code with ", config.issuer, ": ", err)
+    return ngx.exit(ngx.HTTP_BAD_GATEWAY)
+  end
+
+  local claims
+  claims, err = _M.validate_id_token(tokens.id_token, discovery, client.clientId, state.nonce)
+  if not claims then
+    ngx.log(ngx.WARN, "invalid OIDC ID token from ", config.issuer, ": ", err)
+    return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+  end
+
+  local session = _M.seal(keys, {
+    sub = claims.sub,
+    user = claims.preferred_username or claims.email or claims.sub,
+    email = claims.email,
+    exp = ngx.time() + config.session_duration,
+  })
+  ngx.header["Set-Cookie"] = {
+    cookie(config, config.cookie_name, session, config.session_duration, config.cookie_samesite),
+    cookie(config, state_cookie_name, "", 0, "Lax"),
+  }
+
+  local target = is_local_target(state.target) and state.target or "/"
+
+  -- the browsers do not send the Strict cookies in the redirections of a
+  -- cross-site request, so the page is reloaded from the host instead
+  if config.cookie_samesite == "Strict" then
+    ngx.header["Content-Type"] = "text/html"
+    local escaped_target = string_gsub(target, "[&<>\"']", HTML_ENTITIES)
+    ngx.say('<html><head><meta http-equiv="refresh" content="0;url=', escaped_target, '"></head></html>')
+    return ngx.exit(ngx.HTTP_OK)
+  end
+
+  return ngx.redirect(target)
+end
+
+local function location_config()
+  return {
+    issuer = ngx.var.oidc_issuer,
+    scopes = ngx.var.oidc_scopes,
+    redirect_path = ngx.var.oidc_redirect_path,
+    cookie_name = ngx.var.oidc_cookie_name,
+    cookie_domain = ngx.var.oidc_cookie_domain or "",
+    cookie_samesite = ngx.var.oidc_cookie_samesite,
+    session_duration = tonumber(ngx.var.oidc_session_duration),
+  }
+end
+
+-- rewrite authenticates the users of the locations using OpenID Connect with
+-- the authorization code flow, and sets the headers of their identity
+function _M.rewrite()
+  local key = ngx.var.oidc_key
+  if not key or key == "" then
+    return
+  end
+
+  -- the headers of the identity cannot be set by the clients
+  ngx.req.clear_header(USER_HEADER)
+  ngx.req.clear_header(EMAIL_HEADER)
+
+  local client = cjson.decode(clients:get(key) or "")
+  if type(client) ~= "table" then
+    ngx.log(ngx.ERR, "the OIDC client of ", key, " is not configured")
+    return ngx.exit(ngx.HTTP_SERVICE_UNAVAILABLE)
+  end
+
+  local config = location_config()
+  local keys = _M.keys(key, client)
+
+  if ngx.var.uri ~= config.redirect_path then
+    local session = _M.unseal(keys, ngx.var["cookie_" .. config.cookie_name])
+    if session and type(session.exp) == "number" and session.exp > ngx.time() then
+      ngx.req.set_header(USER_HEADER, session.user)
+      if session.email then
+        ngx.req.set_header(EMAIL_HEADER, session.email)
+      end
+      return
+    end
+
+    -- only the navigations can be redirected to the issuer
+    local method = ngx.req.get_method()
+    if method ~= "GET" and method ~= "HEAD" then
+      return ngx.exit(ngx.HTTP_UNAUTHORIZED)
+    end
+  end
+
+  local discovery, err = _M.discover(config.issuer)
+  if not discovery then
+    ngx.log(ngx.ERR, "error discovering the OIDC issuer ", config.issuer, ": ", err)
+    return ngx.exit(ngx.HTTP_BAD_GATEWAY)
+  end
+
+  if ngx.var.uri == config.redirect_path then
+    return callback(config, client, keys, discovery)
+  end
+
+  return login(config, client, keys, discovery)
+end
+
+return _M
@@ -0,0 +1,55 @@
+local ngx = ngx
+local tonumber = tonumber
+
+local fills = ngx.shared.proxy_cache_fills
+
+local _M = {}
+
+-- time in seconds the fills of the cache entries are remembered, longer than
+-- the lock timeout of the locations
+local FILL_TTL = 60
+
+-- cache statuses of the requests sent to the backend to fill a cache entry
+local FILL_STATUSES = {
+  MISS = true,
+  EXPIRED = true,
+}
+
+local function cache_key()
+  local key = ngx.var.response_cache_key
+  if not key or key == "" then
+    return nil
+  end
+  return key
+end
+
+-- header_filter records the time a cache entry starts being filled with the
+-- response of the backend
+function _M.header_filter()
+  local key = cache_key()
+  if not key or not FILL_STATUSES[ngx.var.upstream_cache_status] then
+    return
+  end
+
+  local ok, err = fills:set(key, ngx.now(), FILL_TTL)
+  if not ok then
+    ngx.log(ngx.WARN, "error recording the fill of the cache entry: ", err)
+  end
+end
+
+-- log marks the requests served from the cache that started before their
+-- cache entry was filled: these requests waited for the request filling the
+-- entry instead of being sent to the backend
+function _M.log()
+  local key = cache_key()
+  if not key or ngx.var.upstream_cache_status ~= "HIT" then
+    return
+  end
+
+  local filled_at = tonumber(fills:get(key))
+  if filled_at and ngx.req.start_time() < filled_at then
+    ngx.var.proxy_cache_coalesced = "true"
+  end
+end
+
+return _M
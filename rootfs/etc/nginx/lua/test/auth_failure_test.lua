@@ -0,0 +1,58 @@
+describe("auth_failure", function()
+  local unmocked_ngx = _G.ngx
+  local auth_failure
+
+  before_each(function()
+    _G.ngx = setmetatable({
+      var = {
+        auth_failure_key = "example.com/_external-auth-Lw-PrefixBearer token",
+      },
+      log = function() end,
+    }, { __index = unmocked_ngx })
+
+    ngx.shared.auth_failure_decisions:flush_all()
+    auth_failure = require_without_cache("auth_failure")
+  end)
+
+  after_each(function()
+    _G.ngx = unmocked_ngx
+  end)
+
+  it("returns the responses of the auth service", function()
+    local res = { status = ngx.HTTP_UNAUTHORIZED, header = {} }
+    assert.are.equal(res, auth_failure.response(res, "allow", 0))
+  end)
+
+  it("allows the requests when the auth service fails in the allow mode", function()
+    local res = auth_failure.response({ status = ngx.HTTP_BAD_GATEWAY, header = {} }, "allow", 0)
+    assert.are.equal(ngx.HTTP_OK, res.status)
+  end)
+
+  it("replays the last decision when the auth service fails in the cached mode", function()
+    auth_failure.response({
+      status = ngx.HTTP_OK,
+      header = { ["X-User"] = "alice", ["Set-Cookie"] = "session=1" },
+    }, "cached", 60)
+
+    local res = auth_failure.response({ status = ngx.HTTP_GATEWAY_TIMEOUT, header = {} }, "cached", 60)
+    assert.are.equal(ngx.HTTP_OK, res.status)
+    assert.are.equal("alice", res.header["X-User"])
+    assert.is_nil(res.header["Set-Cookie"])
+  end)
+
+  it("does not replay the decisions of other credentials", function()
+    auth_failure.response({ status = ngx.HTTP_OK, header = {} }, "cached", 60)
+
+    ngx.var.auth_failure_key = "example.com/_external-auth-Lw-Prefix"
+    local res = auth_failure.response({ status = ngx.HTTP_BAD_GATEWAY, header = {} }, "cached", 60)
+    assert.are.equal(ngx.HTTP_BAD_GATEWAY, res.status)
+  end)
+
+  it("does not cache the failures of the auth service", function()
+    auth_failure.response({ status = ngx.HTTP_FORBIDDEN, header = {} }, "cached", 60)
+    auth_failure.response({ status = ngx.HTTP_INTERNAL_SERVER_ERROR, header = {} }, "cached", 60)
+
+    local res = auth_failure.response({ status = ngx.HTTP_BAD_GATEWAY, header = {} }, "cached", 60)
+    assert.are.equal(ngx.HTTP_FORBIDDEN, res.status)
+  end)
+end)
@@ -27,5 +27,80 @@ describe("Balancer chash", function()
       local peer = instance:balance()
       assert.equal("10.184.7.40:8080", peer)
     end)
+
+    it("uses the next endpoint of the ring above the balance factor", function()
+      ngx.var = { remote_addr = "10.10.10.1", http_x_tenant = "foo" }
+      local balancer_chash = require_without_cache("balancer.chash")
+      local inflight = require_without_cache("balancer.inflight")
+
+      local ring = { "10.184.7.40:8080", "10.184.7.41:8080", "10.184.7.42:8080" }
+      local resty_chash = package.loaded["resty.chash"]
+      resty_chash.new = function(self, nodes)
+        return {
+          nodes = nodes,
+          npoints = #ring,
+          find = function(self, key)
+            assert.equal("10.10.10.1,foo", key)
+            return ring[1], 1
+          end,
+          next = function(self, index)
+            local next_index = index % #ring + 1
+            return ring[next_index], next_index
+          end,
+        }
+      end
+
+      local backend = {
+        name = "my-dummy-backend",
+        upstreamHashByConfig = {
+          ["upstream-hash-by"] = "$remote_addr,$http_x_tenant",
+          ["upstream-hash-by-balance-factor"] = 125,
+        },
+        endpoints = {
+          { address = "10.184.7.40", port = "8080", maxFails = 0, failTimeout = 0 },
+          { address = "10.184.7.41", port = "8080", maxFails = 0, failTimeout = 0 },
+          { address = "10.184.7.42", port = "8080", maxFails = 0, failTimeout = 0 },
+        }
+      }
+      local instance = balancer_chash:new(backend)
+
+      -- the capacity is ceil(1.25 * (inflight + 1) / 3)
+      local peers = {}
+      for _ = 1, 4 do
+        ngx.ctx = {}
+        table.insert(peers, instance:balance())
+      end
+      assert.are.same({ ring[1], ring[2], ring[1], ring[2] }, peers)
+
+      inflight.reset()
+    end)
+  end)
+
+  describe("sync()", function()
+    it("multiplies the weight of the endpoints by the replication factor", function()
+      local balancer_chash = require_without_cache("balancer.chash")
+
+      local resty_chash = package.loaded["resty.chash"]
+      resty_chash.new = function(self, nodes)
+        return {
+          nodes = nodes,
+          reinit = function(self, new_nodes)
+            self.nodes = new_nodes
+          end,
+        }
+      end
+
+      local backend = {
+        name = "my-dummy-backend",
+        upstreamHashByConfig = { ["upstream-hash-by"] = "$request_uri" },
+        endpoints = { { address = "10.184.7.40", port = "8080", maxFails = 0, failTimeout = 0 } }
+      }
+      local instance = balancer_chash:new(backend)
+      assert.are.same({ ["10.184.7.40:8080"] = 1 }, instance.instance.nodes)
+
+      backend.upstreamHashByConfig["upstream-hash-by-replication-factor"] = 4
+      instance:sync(backend)
+      assert.are.same({ ["10.184.7.40:8080"] = 4 }, instance.instance.nodes)
+    end)
   end)
 end)
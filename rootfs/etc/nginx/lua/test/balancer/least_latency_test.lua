@@ -0,0 +1,164 @@
+local util = require("util")
+
+local original_ngx = ngx
+local function reset_ngx()
+  _G.ngx = original_ngx
+end
+
+local function mock_ngx(mock)
+  local _ngx = mock
+  setmetatable(_ngx, { __index = ngx })
+  _G.ngx = _ngx
+end
+
+describe("Balancer least_latency", function()
+  local balancer_least_latency = require("balancer.least_latency")
+  local inflight = require("balancer.inflight")
+  local ngx_now = 1543238266
+  local backend, instance
+
+  local function respond(upstream_addr, response_time)
+    ngx.var = { upstream_addr = upstream_addr, upstream_connect_time = "0", upstream_response_time = response_time }
+    instance:after_balance()
+  end
+
+  before_each(function()
+    mock_ngx({ now = function() return ngx_now end, ctx = {}, var = {} })
+    inflight.reset()
+    balancer_least_latency.reset()
+
+    backend = {
+      name = "namespace-service-port", ["load-balance"] = "least_latency",
+      endpoints = {
+        { address = "10.10.10.1", port = "8080", maxFails = 0, failTimeout = 0 },
+        { address = "10.10.10.2", port = "8080", maxFails = 0, failTimeout = 0 },
+        { address = "10.10.10.3", port = "8080", maxFails = 0, failTimeout = 0 },
+      }
+    }
+    instance = balancer_least_latency:new(backend)
+  end)
+
+  after_each(function()
+    reset_ngx()
+    inflight.reset()
+    balancer_least_latency.reset()
+  end)
+
+  describe("new()", function()
+    it("uses the decay time of the backend", function()
+      assert.are.equals(10, instance.decay_time)
+
+      backend.loadBalanceTuning = { ["least-latency-decay"] = 30 }
+      assert.are.equals(30, balancer_least_latency:new(backend).decay_time)
+    end)
+  end)
+
+  describe("after_balance()", function()
+    it("measures the latency of the last upstream", function()
+      ngx.var = { upstream_addr = "10.10.10.1:8080, 10.10.10.2:8080",
+                  upstream_connect_time = "0.05, 0.02", upstream_response_time = "0.2, 0.1" }
+      instance:after_balance()
+
+      assert.are.same({ ["10.10.10.2:8080"] = { value = 0.02 + 0.1, updated_at = ngx_now } },
+                      balancer_least_latency.latencies())
+    end)
+
+    it("decays the latency with time", function()
+      respond("10.10.10.1:8080", "0.2")
+      ngx_now = ngx_now + 5
+      respond("10.10.10.1:8080", "0.1")
+
+      local weight = math.exp(-5 / 10)
+      assert.are.equals(0.2 * weight + 0.1 * (1.0 - weight),
+                        balancer_least_latency.latencies()["10.10.10.1:8080"].value)
+    end)
+
+    it("ends the request to the endpoint", function()
+      local peer = instance:balance()
+      respond(peer, "0.1")
+
+      assert.are.equals(0, inflight.get(peer))
+    end)
+  end)
+
+  describe("balance()", function()
+    it("picks the endpoint with the lowest latency", function()
+      respond("10.10.10.1:8080", "0.2")
+      respond("10.10.10.2:8080", "0.1")
+      respond("10.10.10.3:8080", "0.3")
+
+      assert.are.equals("10.10.10.2:8080", instance:balance())
+    end)
+
+    it("weights the latency with the requests in progress", function()
+      respond("10.10.10.1:8080", "0.2")
+      respond("10.10.10.2:8080", "0.1")
+      respond("10.10.10.3:8080", "0.3")
+
+      inflight.acquire("10.10.10.2:8080")
+      ngx.ctx = {}
+      inflight.acquire("10.10.10.2:8080")
+      ngx.ctx = {}
+
+      assert.are.equals("10.10.10.1:8080", instance:balance())
+    end)
+
+    it("gives the average latency to the endpoints without a recent measure", function()
+      respond("10.10.10.1:8080", "1")
+      ngx_now = ngx_now + 20
+      respond("10.10.10.2:8080", "0.2")
+      respond("10.10.10.3:8080", "0.4")
+
+      inflight.acquire("10.10.10.2:8080")
+      ngx.ctx = {}
+      inflight.acquire("10.10.10.3:8080")
+      ngx.ctx = {}
+
+      -- 0.3 for the endpoint without a recent measure, 0.4 and 0.8 for
+      -- the others with a request in progress
+      assert.are.equals("10.10.10.1:8080", instance:balance())
+    end)
+
+    it("doesn't pick the tried endpoints while retrying", function()
+      respond("10.10.10.1:8080", "0.1")
+      ngx.ctx.balancer_tried_endpoints = { ["10.10.10.1:8080"] = true }
+
+      assert.are_not.equals("10.10.10.1:8080", instance:balance())
+    end)
+  end)
+
+  describe("sync()", function()
+    it("forgets the latency of the removed endpoints", function()
+      respond("10.10.10.1:8080", "0.1")
+      respond("10.10.10.2:8080", "0.1")
+
+      local new_backend = util.deepcopy(backend)
+      table.remove(new_backend.endpoints, 1)
+      instance:sync(new_backend)
+
+      assert.are.same(new_backend.endpoints, instance.peers)
+      assert.is_nil(balancer_least_latency.latencies()["10.10.10.1:8080"])
+      assert.is_not_nil(balancer_least_latency.latencies()["10.10.10.2:8080"])
+    end)
+  end)
+
+  describe("benchmark", function()
+    it("sends most of the requests to the fastest endpoint", function()
+      local latencies = { ["10.10.10.1:8080"] = "0.1", ["10.10.10.2:8080"] = "0.01", ["10.10.10.3:8080"] = "0.05" }
+      local requests = {}
+
+      -- simulates 10000 requests, one every 10ms
+      for _ = 1, 10000 do
+        ngx.ctx = {}
+        local peer = instance:balance()
+        requests[peer] = (requests[peer] or 0) + 1
+        respond(peer, latencies[peer])
+        ngx_now = ngx_now + 0.01
+      end
+
+      -- the slower endpoints are only tried again when their latency is
+      -- older than the decay time
+      assert.is_true(requests["10.10.10.2:8080"] / 10000 > 0.9)
+    end)
+  end)
+end)
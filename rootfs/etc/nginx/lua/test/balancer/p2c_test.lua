@@ -0,0 +1,143 @@
+local util = require("util")
+
+local original_ngx = ngx
+local function reset_ngx()
+  _G.ngx = original_ngx
+end
+
+local function mock_ngx(mock)
+  local _ngx = mock
+  setmetatable(_ngx, { __index = ngx })
+  _G.ngx = _ngx
+end
+
+describe("Balancer p2c", function()
+  local balancer_p2c = require("balancer.p2c")
+  local inflight = require("balancer.inflight")
+  local backend, instance
+
+  before_each(function()
+    mock_ngx({ ctx = {} })
+    inflight.reset()
+
+    backend = {
+      name = "namespace-service-port", ["load-balance"] = "p2c",
+      endpoints = {
+        { address = "10.10.10.1", port = "8080", maxFails = 0, failTimeout = 0 },
+        { address = "10.10.10.2", port = "8080", maxFails = 0, failTimeout = 0 },
+        { address = "10.10.10.3", port = "8080", maxFails = 0, failTimeout = 0 },
+      }
+    }
+    instance = balancer_p2c:new(backend)
+  end)
+
+  after_each(function()
+    reset_ngx()
+    inflight.reset()
+  end)
+
+  describe("new()", function()
+    it("uses the number of choices of the backend", function()
+      assert.are.equals(2, instance.choices)
+
+      backend.loadBalanceTuning = { ["p2c-choices"] = 3 }
+      assert.are.equals(3, balancer_p2c:new(backend).choices)
+
+      backend.loadBalanceTuning = { ["p2c-choices"] = 1 }
+      assert.are.equals(2, balancer_p2c:new(backend).choices)
+    end)
+  end)
+
+  describe("balance()", function()
+    it("returns the single endpoint of the backend", function()
+      local single_endpoint_backend = util.deepcopy(backend)
+      table.remove(single_endpoint_backend.endpoints, 3)
+      table.remove(single_endpoint_backend.endpoints, 2)
+
+      assert.are.equals("10.10.10.1:8080", balancer_p2c:new(single_endpoint_backend):balance())
+      assert.are.equals(1, inflight.get("10.10.10.1:8080"))
+    end)
+
+    it("picks the endpoint with the fewest requests in progress", function()
+      inflight.acquire("10.10.10.1:8080")
+      inflight.acquire("10.10.10.2:8080")
+      ngx.ctx = {}
+      inflight.acquire("10.10.10.2:8080")
+      ngx.ctx = {}
+
+      backend.loadBalanceTuning = { ["p2c-choices"] = 3 }
+      instance = balancer_p2c:new(backend)
+
+      assert.are.equals("10.10.10.3:8080", instance:balance())
+    end)
+
+    it("doesn't pick the tried endpoints while retrying", function()
+      ngx.ctx.balancer_tried_endpoints = { ["10.10.10.1:8080"] = true, ["10.10.10.3:8080"] = true }
+
+      assert.are.equals("10.10.10.2:8080", instance:balance())
+      assert.are.same({ ["10.10.10.1:8080"] = true, ["10.10.10.2:8080"] = true, ["10.10.10.3:8080"] = true },
+                      ngx.ctx.balancer_tried_endpoints)
+    end)
+
+    it("ends the request of the previous attempt", function()
+      local first = instance:balance()
+      local second = instance:balance()
+
+      assert.are_not.equals(first, second)
+      assert.are.equals(0, inflight.get(first))
+      assert.are.equals(1, inflight.get(second))
+    end)
+  end)
+
+  describe("after_balance()", function()
+    it("ends the request to the endpoint", function()
+      local peer = instance:balance()
+      instance:after_balance()
+
+      assert.are.equals(0, inflight.get(peer))
+      assert.is_nil(ngx.ctx.balancer_inflight_endpoint)
+    end)
+  end)
+
+  describe("sync()", function()
+    it("updates the peers and the number of choices", function()
+      local new_backend = util.deepcopy(backend)
+      table.remove(new_backend.endpoints, 1)
+      new_backend.loadBalanceTuning = { ["p2c-choices"] = 3 }
+
+      instance:sync(new_backend)
+
+      assert.are.same(new_backend.endpoints, instance.peers)
+      assert.are.equals(3, instance.choices)
+    end)
+  end)
+
+  describe("benchmark", function()
+    it("keeps the requests in progress balanced between the endpoints", function()
+      -- simulates 10000 requests, each in progress during the selection of
+      -- the endpoints of the 9 next requests
+      local in_progress = {}
+      local total_highest = 0
+      for i = 1, 10000 do
+        ngx.ctx = {}
+        instance:balance()
+        in_progress[i % 10] = ngx.ctx
+
+        local highest = 0
+        for _, endpoint in ipairs(backend.endpoints) do
+          highest = math.max(highest, inflight.get(endpoint.address .. ":" .. endpoint.port))
+        end
+        total_highest = total_highest + highest
+
+        ngx.ctx = in_progress[(i + 1) % 10]
+        if ngx.ctx then
+          instance:after_balance()
+        end
+      end
+
+      -- a random selection averages 4.9 requests on the busiest endpoint,
+      -- the power of two choices 4
+      assert.is_true(total_highest / 10000 < 4.5)
+    end)
+  end)
+end)
@@ -9,6 +9,7 @@ local function reset_ngx()
 
   -- Ensure balancer cache is reset.
   _G.ngx.ctx.balancer = nil
+  _G.ngx.ctx.canary_decision_header = nil
 end
 
 local function reset_balancer()
@@ -37,7 +38,9 @@ local function reset_expected_implementations()
     ["my-dummy-app-3"] = package.loaded["balancer.sticky_persistent"],
     ["my-dummy-app-4"] = package.loaded["balancer.ewma"],
     ["my-dummy-app-5"] = package.loaded["balancer.sticky_balanced"],
-    ["my-dummy-app-6"] = package.loaded["balancer.chashsubset"]
+    ["my-dummy-app-6"] = package.loaded["balancer.chashsubset"],
+    ["my-dummy-app-7"] = package.loaded["balancer.p2c"],
+    ["my-dummy-app-8"] = package.loaded["balancer.least_latency"]
   }
 end
 
@@ -88,6 +91,14 @@ local function reset_backends()
       ["load-balance"] = "ewma",                  -- upstreamHashByConfig will take priority.
       upstreamHashByConfig = { ["upstream-hash-by"] = "$request_uri", ["upstream-hash-by-subset"] = "true", }
     },
+    {
+      name = "my-dummy-app-7",
+      ["load-balance"] = "p2c",
+    },
+    {
+      name = "my-dummy-app-8",
+      ["load-balance"] = "least_latency",
+    },
   }
 end
 
@@ -148,6 +159,69 @@ describe("Balancer", function()
         assert.are.same(expected, balancer.get_balancer())
       end
     end)
+
+    describe("canary decision", function()
+      local backend, canary_backend, headers
+
+      before_each(function()
+        backend = {
+          name = "my-dummy-app-100", ["load-balance"] = "round_robin",
+          alternativeBackends = { "my-dummy-canary-app-100" },
+          endpoints = { { address = "10.184.7.40", port = "8080", maxFails = 0, failTimeout = 0 } },
+          trafficShapingPolicy = { weight = 0, header = "", headerValue = "", cookie = "" },
+        }
+        canary_backend = {
+          name = "my-dummy-canary-app-100", ["load-balance"] = "round_robin",
+          endpoints = { { address = "11.184.7.40", port = "8080", maxFails = 0, failTimeout = 0 } },
+          trafficShapingPolicy = {
+            weight = 100,
+            header = "canaryHeader",
+            headerValue = "",
+            cookie = "",
+            decisionHeader = "X-Canary-Decision",
+          },
+        }
+        headers = {}
+      end)
+
+      it("sets the decision and its response header", function()
+        mock_ngx({ var = { proxy_upstream_name = backend.name }, header = headers })
+        balancer.sync_backend(backend)
+        balancer.sync_backend(canary_backend)
+
+        assert.are.equal(balancer.get_balancer_by_upstream_name(canary_backend.name), balancer.get_balancer())
+        assert.are.equal("weight", ngx.var.canary_decision)
+
+        balancer.header_filter()
+        assert.are.equal("canary; reason=weight", headers["X-Canary-Decision"])
+      end)
+
+      it("sets the decision of the requests routed to the primary backend", function()
+        mock_ngx({ var = { proxy_upstream_name = backend.name, http_canaryHeader = "never" }, header = headers })
+        balancer.sync_backend(backend)
+        balancer.sync_backend(canary_backend)
+
+        assert.are.equal(balancer.get_balancer_by_upstream_name(backend.name), balancer.get_balancer())
+        assert.are.equal("header", ngx.var.canary_decision)
+        assert.is_nil(ngx.var.proxy_alternative_upstream_name)
+
+        balancer.header_filter()
+        assert.are.equal("stable; reason=header", headers["X-Canary-Decision"])
+      end)
+
+      it("does not set the response header when the canary does not define it", function()
+        canary_backend.trafficShapingPolicy.decisionHeader = ""
+        mock_ngx({ var = { proxy_upstream_name = backend.name }, header = headers })
+        balancer.sync_backend(backend)
+        balancer.sync_backend(canary_backend)
+
+        balancer.get_balancer()
+        assert.are.equal("weight", ngx.var.canary_decision)
+
+        balancer.header_filter()
+        assert.are.same({}, headers)
+      end)
+    end)
   end)
 
   describe("route_to_alternative_balancer()", function()
@@ -204,6 +278,13 @@ describe("Balancer", function()
           assert.equal(false, balancer.route_to_alternative_balancer(_primaryBalancer))
         end)
 
+        it("returns the weight as the rule that routed the request", function()
+          backend.trafficShapingPolicy.weight = 100
+          balancer.sync_backend(backend)
+          local _, reason = balancer.route_to_alternative_balancer(_primaryBalancer)
+          assert.equal("weight", reason)
+        end)
+
         it("returns true when weight is 1000 and weight total is 1000", function()
           backend.trafficShapingPolicy.weight = 1000
           backend.trafficShapingPolicy.weightTotal = 1000
@@ -261,6 +342,40 @@ describe("Balancer", function()
         end)
       end)
 
+      describe("canary by expression", function()
+        local tenant_expression = {
+          op = "eq",
+          args = {
+            { op = "index", name = "request.headers", args = { { op = "literal", value = "x-tenant" } } },
+            { op = "literal", value = "acme" },
+          },
+        }
+
+        after_each(function()
+          backend.trafficShapingPolicy.expression = nil
+        end)
+
+        it("returns true when the expression is true", function()
+          mock_ngx({ var = { http_x_tenant = "acme", request_uri = "/" } })
+          backend.trafficShapingPolicy.expression = tenant_expression
+          balancer.sync_backend(backend)
+
+          local routed, reason = balancer.route_to_alternative_balancer(_primaryBalancer)
+          assert.equal(true, routed)
+          assert.equal("expression", reason)
+          reset_ngx()
+        end)
+
+        it("returns false when the expression is false", function()
+          mock_ngx({ var = { http_x_tenant = "globex", request_uri = "/" } })
+          backend.trafficShapingPolicy.expression = tenant_expression
+          balancer.sync_backend(backend)
+
+          assert.equal(false, balancer.route_to_alternative_balancer(_primaryBalancer))
+          reset_ngx()
+        end)
+      end)
+
       describe("canary by header", function()
         it("returns correct result for given headers", function()
           local test_patterns = {
@@ -439,6 +554,37 @@ describe("Balancer", function()
       assert.stub(mock_instance.sync).was_called_with(mock_instance, expected_backend)
     end)
 
+    it("resolves external name with the resolution options of the backend", function()
+      local resolution = { nameservers = { "10.0.0.53" }, ttl = 30, static = true }
+      backend = {
+        name = "example-com", service = { spec = { ["type"] = "ExternalName" } },
+        externalNameResolution = resolution,
+        endpoints = {
+          { address = "example.com", port = "80", maxFails = 0, failTimeout = 0 }
+        }
+      }
+
+      helpers.mock_resty_dns_query(nil, {
+        {
+          name = "example.com",
+          address = "192.168.1.1",
+          ttl = 3600,
+        }
+      })
+
+      local mock_instance = { sync = function(backend) end }
+      setmetatable(mock_instance, implementation)
+      implementation.new = function(self, backend) return mock_instance end
+      local s = spy.on(implementation, "new")
+      assert.has_no.errors(function() balancer.sync_backend(backend) end)
+      assert.spy(s).was_called_with(implementation, {
+        name = "example-com", service = { spec = { ["type"] = "ExternalName" } },
+        externalNameResolution = resolution,
+        endpoints = { { address = "192.168.1.1", port = "80" } }
+      })
+      assert.are.same({ "192.168.1.1" }, require("util.dns")._cache:get("example.com@10.0.0.53"))
+    end)
+
     it("wraps IPv6 addresses into square brackets", function()
       local backend = {
         name = "example-com",
@@ -497,6 +643,254 @@ describe("Balancer", function()
     end)
   end)
 
+  describe("IP family preference", function()
+    local backend
+
+    before_each(function()
+      backend = {
+        name = "dual-stack", ["load-balance"] = "round_robin",
+        endpoints = {
+          { address = "10.0.0.1", port = "8080", maxFails = 0, failTimeout = 0 },
+          { address = "2001:db8::1", port = "8080", maxFails = 0, failTimeout = 0 },
+          { address = "10.0.0.2", port = "8080", maxFails = 0, failTimeout = 0 },
+        }
+      }
+    end)
+
+    describe("split_by_ip_family()", function()
+      it("splits the endpoints of the preferred and of the other family", function()
+        local preferred, fallback = balancer.split_by_ip_family(backend, "ipv6")
+
+        assert.are.same({ backend.endpoints[2] }, preferred.endpoints)
+        assert.are.same({ backend.endpoints[1], backend.endpoints[3] }, fallback.endpoints)
+        assert.equal("dual-stack", fallback.name)
+        assert.equal(3, #backend.endpoints)
+      end)
+
+      it("returns the backend without fallback when it has a single family", function()
+        backend.endpoints[2] = nil
+
+        local preferred, fallback = balancer.split_by_ip_family(backend, "ipv6")
+        assert.equal(backend, preferred)
+        assert.is_nil(fallback)
+      end)
+
+      it("returns the backend without fallback without preference", function()
+        local preferred, fallback = balancer.split_by_ip_family(backend, nil)
+        assert.equal(backend, preferred)
+        assert.is_nil(fallback)
+      end)
+    end)
+
+    describe("sync_backend()", function()
+      it("creates a fallback balancer with the endpoints of the other family", function()
+        balancer.ip_family_preference = "ipv4"
+        balancer.sync_backend(backend)
+
+        local primary = balancer.get_balancer_by_upstream_name("dual-stack")
+        assert.is_not_nil(primary.ip_family_fallback)
+        assert.equal("[2001:db8::1]:8080", primary.ip_family_fallback:balance())
+        assert.are_not.equal("[2001:db8::1]:8080", primary:balance())
+
+        backend.endpoints[2] = nil
+        balancer.sync_backend(backend)
+        assert.is_nil(balancer.get_balancer_by_upstream_name("dual-stack").ip_family_fallback)
+      end)
+    end)
+
+    describe("balancer_of_try()", function()
+      local ngx_balancer = require("ngx.balancer")
+      local primary
+
+      before_each(function()
+        balancer.ip_family_preference = "ipv4"
+        balancer.ip_family_fallback_timeout = 250
+        balancer.sync_backend(backend)
+        primary = balancer.get_balancer_by_upstream_name("dual-stack")
+      end)
+
+      after_each(function()
+        ngx_balancer.get_last_failure:revert()
+      end)
+
+      it("returns the preferred balancer and the fallback timeout on the first try", function()
+        stub(ngx_balancer, "get_last_failure", nil)
+
+        local selected, timeout = balancer.balancer_of_try(primary)
+        assert.equal(primary, selected)
+        assert.equal(0.25, timeout)
+      end)
+
+      it("returns the fallback balancer after a failure", function()
+        stub(ngx_balancer, "get_last_failure", "failed")
+
+        local selected, timeout = balancer.balancer_of_try(primary)
+        assert.equal(primary.ip_family_fallback, selected)
+        assert.is_nil(timeout)
+      end)
+    end)
+  end)
+
+  describe("warm-up", function()
+    local backend, warm_ups
+
+    before_each(function()
+      warm_ups = {}
+      ngx.shared.warmup_endpoints:flush_all()
+      mock_ngx({ timer = setmetatable({
+        at = function(_, _, backend_name, endpoint)
+          table.insert(warm_ups, backend_name .. " " .. endpoint.address .. ":" .. endpoint.port)
+          return true
+        end,
+      }, { __index = ngx.timer }) }, function()
+        package.loaded["warmup"] = nil
+      end)
+
+      backend = {
+        name = "warm-app-80", ["load-balance"] = "round_robin",
+        warmup = { path = "/warmup", requests = 2, timeout = 1 },
+        endpoints = { { address = "10.184.7.40", port = "8080", maxFails = 0, failTimeout = 0 } },
+      }
+    end)
+
+    after_each(function()
+      package.loaded["warmup"] = nil
+    end)
+
+    local function balanced_peers()
+      local instance = balancer.get_balancer_by_upstream_name(backend.name)
+      local peers = {}
+      for _ = 1, 4 do
+        peers[instance:balance()] = true
+      end
+      return peers
+    end
+
+    it("balances the endpoints of a new backend without warm-up", function()
+      balancer.sync_backend(util.deepcopy(backend))
+
+      assert.are.same({}, warm_ups)
+      assert.are.same({ ["10.184.7.40:8080"] = true }, balanced_peers())
+    end)
+
+    it("balances the new endpoints once they are warmed up", function()
+      balancer.sync_backend(util.deepcopy(backend))
+      table.insert(backend.endpoints, { address = "10.184.7.41", port = "8080", maxFails = 0, failTimeout = 0 })
+      balancer.sync_backend(util.deepcopy(backend))
+
+      assert.are.same({ "warm-app-80 10.184.7.41:8080" }, warm_ups)
+      assert.are.same({ ["10.184.7.40:8080"] = true }, balanced_peers())
+
+      balancer.sync_backends_warming_up()
+      assert.equal(1, #warm_ups)
+      assert.are.same({ ["10.184.7.40:8080"] = true }, balanced_peers())
+
+      ngx.shared.warmup_endpoints:set("warm-app-80 10.184.7.41:8080", true)
+      balancer.sync_backends_warming_up()
+      assert.are.same({ ["10.184.7.40:8080"] = true, ["10.184.7.41:8080"] = true }, balanced_peers())
+    end)
+
+    it("balances the new endpoints of a backend without warmed up endpoint", function()
+      balancer.sync_backend(util.deepcopy(backend))
+      backend.endpoints = { { address = "10.184.7.41", port = "8080", maxFails = 0, failTimeout = 0 } }
+      balancer.sync_backend(util.deepcopy(backend))
+
+      assert.are.same({ ["10.184.7.41:8080"] = true }, balanced_peers())
+    end)
+  end)
+
+  describe("backup backend", function()
+    local ngx_balancer = require("ngx.balancer")
+    local backend, backup_backend
+
+    before_each(function()
+      backend = {
+        name = "legacy-app-80", ["load-balance"] = "round_robin",
+        endpoints = { { address = "10.184.7.40", port = "8080", maxFails = 0, failTimeout = 0 } },
+      }
+      backup_backend = {
+        name = "backup-default-new-app-80", ["load-balance"] = "round_robin",
+        endpoints = { { address = "10.184.7.41", port = "8080", maxFails = 0, failTimeout = 0 } },
+      }
+    end)
+
+    local function mock_location()
+      mock_ngx({ var = {
+        proxy_upstream_name = backend.name,
+        proxy_backup_upstream_name = backup_backend.name,
+        backup_failure_codes = "502 503",
+        backup_failover = "",
+      } })
+      balancer.sync_backend(backend)
+      balancer.sync_backend(backup_backend)
+    end
+
+    describe("get_balancer()", function()
+      it("returns the primary balancer when the backend has endpoints", function()
+        mock_location()
+
+        assert.are.equal(balancer.get_balancer_by_upstream_name(backend.name), balancer.get_balancer())
+        assert.are.equal("", ngx.var.backup_failover)
+      end)
+
+      it("fails over to the backup balancer when the backend has no endpoint", function()
+        backend.endpoints = {}
+        mock_location()
+
+        assert.are.equal(balancer.get_balancer_by_upstream_name(backup_backend.name), balancer.get_balancer())
+        assert.are.equal("endpoints", ngx.var.backup_failover)
+      end)
+
+      it("returns nil when the backend and the backup backend have no endpoint", function()
+        backend.endpoints = {}
+        backup_backend.endpoints = {}
+        mock_location()
+
+        assert.is_nil(balancer.get_balancer())
+        assert.are.equal("", ngx.var.backup_failover)
+      end)
+    end)
+
+    describe("balancer_of_failure()", function()
+      local primary
+
+      before_each(function()
+        mock_location()
+        primary = balancer.get_balancer()
+      end)
+
+      after_each(function()
+        ngx_balancer.get_last_failure:revert()
+      end)
+
+      it("returns the primary balancer on the first try", function()
+        stub(ngx_balancer, "get_last_failure", nil)
+
+        assert.are.equal(primary, balancer.balancer_of_failure(primary))
+        assert.are.equal("", ngx.var.backup_failover)
+      end)
+
+      it("fails over to the backup balancer after a failure code", function()
+        stub(ngx_balancer, "get_last_failure", "next", 503)
+
+        local backup = balancer.get_balancer_by_upstream_name(backup_backend.name)
+        assert.are.equal(backup, balancer.balancer_of_failure(primary))
+        assert.are.equal("status", ngx.var.backup_failover)
+        assert.are.equal(backup, balancer.get_balancer())
+      end)
+
+      it("returns the primary balancer after the other failures", function()
+        stub(ngx_balancer, "get_last_failure", "next", 500)
+        assert.are.equal(primary, balancer.balancer_of_failure(primary))
+
+        ngx_balancer.get_last_failure:revert()
+        stub(ngx_balancer, "get_last_failure", "failed", 502)
+        assert.are.equal(primary, balancer.balancer_of_failure(primary))
+        assert.are.equal("", ngx.var.backup_failover)
+      end)
+    end)
+  end)
+
   describe("sync_backends()", function()
 
     after_each(function()
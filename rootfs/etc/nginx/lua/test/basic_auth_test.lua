@@ -0,0 +1,101 @@
+local basic_auth = require("basic_auth")
+
+local credentials = ngx.shared.basic_auth_credentials
+
+describe("basic_auth", function()
+  describe("constant_time_equals()", function()
+    it("compares strings", function()
+      assert.is_true(basic_auth.constant_time_equals("secret", "secret"))
+      assert.is_true(basic_auth.constant_time_equals("", ""))
+      assert.is_false(basic_auth.constant_time_equals("secret", "Secret"))
+      assert.is_false(basic_auth.constant_time_equals("secret", "secret1"))
+      assert.is_false(basic_auth.constant_time_equals("secret1", "secret"))
+      assert.is_false(basic_auth.constant_time_equals("secret", ""))
+    end)
+  end)
+
+  describe("apr1()", function()
+    it("returns the hash of htpasswd", function()
+      assert.are.equal("$apr1$OFG3Xybp$c/LQ2xh8j6fUdirIuYn3L1",
+        basic_auth.apr1("foo", "$apr1$OFG3Xybp$ckL0FHDAkoXYIlH9.cysT0"))
+      assert.are.equal("$apr1$xy$cKQIC7N0iBHeW6Fl2m00c.", basic_auth.apr1(string.rep("a", 40), "$apr1$xy$"))
+      assert.is_nil(basic_auth.apr1("foo", "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00="))
+    end)
+  end)
+
+  describe("verify()", function()
+    it("verifies the schemes of auth_basic_user_file", function()
+      assert.is_true(basic_auth.verify("bar", "{PLAIN}bar"))
+      assert.is_true(basic_auth.verify("bar", "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00="))
+      assert.is_true(basic_auth.verify("bar", "{SSHA}aTajYe2SJsRWrbKsIV9ZttXLp9RzYWx0"))
+      assert.is_true(basic_auth.verify("bar", "$apr1$12345678$3p.dIdBVp71jB4lGsnviE1"))
+    end)
+
+    it("rejects wrong passwords", function()
+      assert.is_false(basic_auth.verify("baz", "{PLAIN}bar"))
+      assert.is_false(basic_auth.verify("baz", "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00="))
+      assert.is_false(basic_auth.verify("baz", "{SSHA}aTajYe2SJsRWrbKsIV9ZttXLp9RzYWx0"))
+      assert.is_false(basic_auth.verify("baz", "$apr1$12345678$3p.dIdBVp71jB4lGsnviE1"))
+      assert.is_false(basic_auth.verify("bar", "{SSHA}invalid"))
+    end)
+  end)
+
+  describe("rewrite()", function()
+    local unmocked_ngx = _G.ngx
+    local exit_status
+
+    before_each(function()
+      exit_status = nil
+      _G.ngx = setmetatable({
+        var = { basic_auth_key = "default-ing-secret", basic_auth_realm = "Restricted" },
+        header = {},
+        exit = function(status) exit_status = status end,
+      }, { __index = unmocked_ngx })
+      basic_auth = require_without_cache("basic_auth")
+
+      credentials:set(basic_auth.credentials_key("default-ing-secret", "foo"), "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00=")
+    end)
+
+    after_each(function()
+      _G.ngx = unmocked_ngx
+      credentials:flush_all()
+    end)
+
+    it("does nothing in the locations without basic authentication", function()
+      ngx.var = {}
+      basic_auth.rewrite()
+      assert.is_nil(exit_status)
+    end)
+
+    it("allows the requests with valid credentials", function()
+      ngx.var.http_authorization = "Basic " .. ngx.encode_base64("foo:bar")
+      basic_auth.rewrite()
+      assert.is_nil(exit_status)
+    end)
+
+    it("rejects the requests without credentials", function()
+      basic_auth.rewrite()
+      assert.are.equal(ngx.HTTP_UNAUTHORIZED, exit_status)
+      assert.are.equal('Basic realm="Restricted"', ngx.header["WWW-Authenticate"])
+    end)
+
+    it("rejects the requests with invalid credentials", function()
+      for _, value in ipairs({ "foo:baz", "bar:bar", "foo" }) do
+        exit_status = nil
+        ngx.var.http_authorization = "Basic " .. ngx.encode_base64(value)
+        basic_auth.rewrite()
+        assert.are.equal(ngx.HTTP_UNAUTHORIZED, exit_status)
+      end
+    end)
+
+    it("rejects the requests when the credentials are removed", function()
+      ngx.var.http_authorization = "Basic " .. ngx.encode_base64("foo:bar")
+      basic_auth.rewrite()
+      assert.is_nil(exit_status)
+
+      credentials:delete(basic_auth.credentials_key("default-ing-secret", "foo"))
+      basic_auth.rewrite()
+      assert.are.equal(ngx.HTTP_UNAUTHORIZED, exit_status)
+    end)
+  end)
+end)
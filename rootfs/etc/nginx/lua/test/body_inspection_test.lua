@@ -0,0 +1,116 @@
+local BILLION_LAUGHS = [[<?xml version="1.0"?>
+<!DOCTYPE lolz [
+  <!ENTITY lol "lol">
+  <!ENTITY lol1 "&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;&lol;">
+  <!ENTITY lol2 "&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;&lol1;">
+]>
+<lolz>&lol2;</lolz>]]
+
+describe("body_inspection", function()
+  local body_inspection = require_without_cache("body_inspection")
+
+  describe("inspect_json()", function()
+    it("accepts the bodies within the limits", function()
+      assert.is_nil(body_inspection.inspect_json('{"a":[1,2,3],"b":{"c":[]}}', 3, 3))
+      assert.is_nil(body_inspection.inspect_json('{"a":"[[[,,,]]]"}', 1, 1))
+      assert.is_nil(body_inspection.inspect_json('{"a":"\\"[[[,,,"}', 1, 1))
+      assert.is_nil(body_inspection.inspect_json('[[1, 2], [3, 4]]', 2, 2))
+    end)
+
+    it("rejects the bodies deeper than the maximum depth", function()
+      assert.are.equal("json_depth", body_inspection.inspect_json('[[[1]]]', 2, nil))
+      assert.are.equal("json_depth", body_inspection.inspect_json('{"a":{"b":{}}}', 2, nil))
+    end)
+
+    it("rejects the bodies with arrays larger than the maximum size", function()
+      assert.are.equal("json_array_size", body_inspection.inspect_json('{"a":[1,2,3]}', nil, 2))
+      assert.are.equal("json_array_size", body_inspection.inspect_json('[[1], ["a", "b", {"c": 1}]]', nil, 2))
+    end)
+  end)
+
+  describe("inspect_xml()", function()
+    it("accepts the bodies within the limits", function()
+      local body = [==[<?xml version="1.0"?>
+<!-- <a><b><c> -->
+<a x="1>2"><b><c/></b><b><![CDATA[<c><d>]]></b></a>]==]
+      assert.is_nil(body_inspection.inspect_xml(body, 2, 10))
+      assert.is_nil(body_inspection.inspect_xml(BILLION_LAUGHS, 10, 111))
+    end)
+
+    it("rejects the bodies deeper than the maximum depth", function()
+      assert.are.equal("xml_depth", body_inspection.inspect_xml("<a><b><c/></b></a>", 1, nil))
+      assert.are.equal("xml_depth", body_inspection.inspect_xml("<a><b></b><b><c></c></b></a>", 2, nil))
+    end)
+
+    it("rejects the bodies expanding more entities than the maximum", function()
+      assert.are.equal("xml_entity_expansions", body_inspection.inspect_xml(BILLION_LAUGHS, nil, 110))
+    end)
+
+    it("rejects the bodies with recursive entities", function()
+      local body = [[<!DOCTYPE a [
+  <!ENTITY a "&b;">
+  <!ENTITY b '&a;'>
+]>
+<a>&a;</a>]]
+      assert.are.equal("xml_entity_expansions", body_inspection.inspect_xml(body, nil, 1000))
+    end)
+  end)
+
+  describe("rewrite()", function()
+    local unmocked_ngx = _G.ngx
+    local exit_status, body
+
+    before_each(function()
+      exit_status, body = nil, '{"a":[1,2,3]}'
+
+      _G.ngx = setmetatable({
+        var = {
+          content_type = "application/json; charset=utf-8",
+          body_inspection_json_max_array_size = "2",
+          body_inspection_rejection = "",
+        },
+        exit = function(status) exit_status = status end,
+        req = setmetatable({
+          read_body = function() end,
+          get_body_data = function() return body end,
+          get_body_file = function() return nil end,
+        }, { __index = unmocked_ngx.req }),
+      }, { __index = unmocked_ngx })
+      body_inspection = require_without_cache("body_inspection")
+    end)
+
+    after_each(function()
+      _G.ngx = unmocked_ngx
+    end)
+
+    it("rejects the bodies exceeding the limits of the location", function()
+      body_inspection.rewrite()
+      assert.are.equal(ngx.HTTP_BAD_REQUEST, exit_status)
+      assert.are.equal("json_array_size", ngx.var.body_inspection_rejection)
+    end)
+
+    it("accepts the bodies within the limits of the location", function()
+      body = '{"a":[1,2]}'
+
+      body_inspection.rewrite()
+      assert.is_nil(exit_status)
+      assert.are.equal("", ngx.var.body_inspection_rejection)
+    end)
+
+    it("ignores the bodies of other content types", function()
+      ngx.var.content_type = "application/xml"
+
+      body_inspection.rewrite()
+      assert.is_nil(exit_status)
+    end)
+
+    it("does nothing in the locations without inspection", function()
+      ngx.var = { content_type = "application/json" }
+      local s = spy.on(ngx.req, "read_body")
+
+      body_inspection.rewrite()
+      assert.is_nil(exit_status)
+      assert.spy(s).was_not_called()
+    end)
+  end)
+end)
@@ -13,6 +13,7 @@ local DEFAULT_CERT = read_file("rootfs/etc/nginx/lua/test/fixtures/default-cert.
 local DEFAULT_CERT_HOSTNAME = "_"
 local UUID = "2ea8adb5-8ebb-4b14-a79b-0cdcd892e884"
 local DEFAULT_UUID = "00000000-0000-0000-0000-000000000000"
+local ALTERNATE_UUID = "6f0c3c8d-3a4e-4c47-9e8d-4a2b1b6f0b2d"
 
 local function assert_certificate_is_set(cert)
   spy.on(ngx, "log")
@@ -100,6 +101,37 @@ describe("Certificate", function()
       assert_certificate_is_set(EXAMPLE_CERT)
     end)
 
+    it("sets the alternate certificate and key of the hostname", function()
+      set_certificate("hostname", EXAMPLE_CERT, UUID)
+      set_certificate("alternate:hostname", DEFAULT_CERT, ALTERNATE_UUID)
+
+      assert_certificate_is_set(EXAMPLE_CERT)
+      assert.spy(ssl.set_der_cert).was_called_with(ssl.cert_pem_to_der(DEFAULT_CERT))
+      assert.spy(ssl.set_der_priv_key).was_called_with(ssl.priv_key_pem_to_der(DEFAULT_CERT))
+    end)
+
+    it("sets the alternate certificate and key of the wildcard hostname", function()
+      ssl.server_name = function() return "sub.hostname", nil end
+      set_certificate("*.hostname", EXAMPLE_CERT, UUID)
+      set_certificate("alternate:*.hostname", DEFAULT_CERT, ALTERNATE_UUID)
+
+      assert_certificate_is_set(EXAMPLE_CERT)
+      assert.spy(ssl.set_der_cert).was_called_with(ssl.cert_pem_to_der(DEFAULT_CERT))
+    end)
+
+    it("keeps the certificate when the alternate certificate is invalid", function()
+      set_certificate("hostname", EXAMPLE_CERT, UUID)
+      set_certificate("alternate:hostname", "invalid", ALTERNATE_UUID)
+
+      spy.on(ngx, "log")
+      spy.on(ssl, "set_der_cert")
+
+      assert.has_no.errors(certificate.call)
+      assert.spy(ssl.set_der_cert).was_called_with(ssl.cert_pem_to_der(EXAMPLE_CERT))
+      assert.spy(ngx.log).was_called_with(ngx.ERR, "failed to set the alternate certificate: ",
+        "failed to convert certificate chain from PEM to DER: PEM_read_bio_X509_AUX() failed")
+    end)
+
     it("logs error message when certificate in dictionary is invalid", function()
       set_certificate("hostname", "something invalid", UUID)
 
@@ -155,6 +187,17 @@ describe("Certificate", function()
 
       it("does negative caching when the request to OCSP responder fails", function()
       end)
+
+      it("does not fetch OCSP response when the responses are prefetched", function()
+        certificate.is_ocsp_prefetch_enabled = true
+        set_certificate("hostname", EXAMPLE_CERT, UUID)
+        spy.on(ngx.timer, "at")
+
+        assert_certificate_is_set(EXAMPLE_CERT)
+        assert.spy(ngx.timer.at).was_not_called()
+
+        certificate.is_ocsp_prefetch_enabled = false
+      end)
     end)
   end)
 
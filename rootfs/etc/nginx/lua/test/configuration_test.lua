@@ -256,6 +256,30 @@ describe("Configuration", function()
       assert.same(ngx.HTTP_CREATED, ngx.status)
     end)
 
+    it("stores and deletes the alternate certificates of the servers", function()
+      local ALTERNATE_UUID = "6f0c3c8d-3a4e-4c47-9e8d-4a2b1b6f0b2d"
+      mock_ssl_configuration({
+        servers = { ["hostname"] = UUID },
+        alternateServers = { ["hostname"] = ALTERNATE_UUID },
+        certificates = { [UUID] = "pemCertKey", [ALTERNATE_UUID] = "alternatePemCertKey" }
+      })
+      assert.has_no.errors(configuration.handle_servers)
+      assert.same(UUID, certificate_servers:get("hostname"))
+      assert.same(ALTERNATE_UUID, certificate_servers:get("alternate:hostname"))
+      assert.same("alternatePemCertKey", certificate_data:get(ALTERNATE_UUID))
+      assert.same(ngx.HTTP_CREATED, ngx.status)
+
+      mock_ssl_configuration({
+        servers = { ["hostname"] = UUID },
+        alternateServers = { ["hostname"] = "-1" },
+        certificates = { [UUID] = "pemCertKey" }
+      })
+      assert.has_no.errors(configuration.handle_servers)
+      assert.same(UUID, certificate_servers:get("hostname"))
+      assert.same(nil, certificate_servers:get("alternate:hostname"))
+      assert.same(ngx.HTTP_CREATED, ngx.status)
+    end)
+
     it("should successfully update certificates and keys for each host", function()
       mock_ssl_configuration({
         servers = { ["hostname"] = UUID },
@@ -304,5 +328,246 @@ describe("Configuration", function()
       assert.equal("pemCertKey2", stored_entries[uuid2])
       assert.same(ngx.HTTP_CREATED, ngx.status)
     end)
+
+    describe("OCSP responses", function()
+      local ssl = require("ngx.ssl")
+      local ocsp = require("ngx.ocsp")
+      local unmocked_cert_pem_to_der = ssl.cert_pem_to_der
+      local unmocked_validate_ocsp_response = ocsp.validate_ocsp_response
+
+      before_each(function()
+        ngx.shared.certificate_data.get = function(self, uid)
+          return uid == UUID and "pemCertKey" or nil
+        end
+        ssl.cert_pem_to_der = function(pem) return "der:" .. pem end
+        ocsp.validate_ocsp_response = function(response, der_cert)
+          if response == "ocspResponse" and der_cert == "der:pemCertKey" then
+            return true
+          end
+          return false, "OCSP response does not match the certificate"
+        end
+      end)
+
+      after_each(function()
+        ssl.cert_pem_to_der = unmocked_cert_pem_to_der
+        ocsp.validate_ocsp_response = unmocked_validate_ocsp_response
+        ocsp_response_cache:flush_all()
+      end)
+
+      it("caches the OCSP responses prefetched by the controller", function()
+        mock_ssl_configuration({
+          servers = {},
+          certificates = {},
+          ocspResponses = { [UUID] = { response = ngx.encode_base64("ocspResponse"), expiry = 3600 } },
+        })
+
+        assert.has_no.errors(configuration.handle_servers)
+        assert.equal("ocspResponse", ocsp_response_cache:get(UUID))
+        assert.same(ngx.HTTP_CREATED, ngx.status)
+      end)
+
+      it("does not cache the OCSP responses of other certificates", function()
+        local uuid2 = "8ea8adb5-8ebb-4b14-a79b-0cdcd892e999"
+        mock_ssl_configuration({
+          servers = {},
+          certificates = {},
+          ocspResponses = {
+            [UUID] = { response = ngx.encode_base64("otherResponse"), expiry = 3600 },
+            [uuid2] = { response = ngx.encode_base64("ocspResponse"), expiry = 3600 },
+          },
+        })
+
+        assert.has_no.errors(configuration.handle_servers)
+        assert.is_nil(ocsp_response_cache:get(UUID))
+        assert.is_nil(ocsp_response_cache:get(uuid2))
+        assert.same(ngx.HTTP_INTERNAL_SERVER_ERROR, ngx.status)
+      end)
+
+      it("removes the expired OCSP responses", function()
+        ocsp_response_cache:set(UUID, "ocspResponse")
+        mock_ssl_configuration({
+          servers = {},
+          certificates = {},
+          ocspResponses = { [UUID] = { response = ngx.encode_base64("ocspResponse"), expiry = 0 } },
+        })
+
+        assert.has_no.errors(configuration.handle_servers)
+        assert.is_nil(ocsp_response_cache:get(UUID))
+        assert.same(ngx.HTTP_CREATED, ngx.status)
+      end)
+    end)
+  end)
+
+  describe("Shared dictionaries", function()
+    before_each(function()
+      ngx.var.request_uri = "/configuration/shared-dicts"
+    end)
+
+    it("returns the capacity and the free space of the dictionaries", function()
+      ngx.var.request_method = "GET"
+      local body
+      ngx.print = function(msg) body = msg end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_OK, ngx.status)
+
+      local dicts = cjson.decode(body)
+      assert.is_true(dicts.configuration_data.capacity > 0)
+      assert.is_true(dicts.configuration_data.free_space <= dicts.configuration_data.capacity)
+    end)
+
+    it("only allows GET requests", function()
+      ngx.var.request_method = "POST"
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_BAD_REQUEST, ngx.status)
+    end)
+  end)
+
+  describe("Auth credentials", function()
+    local basic_auth_credentials = ngx.shared.basic_auth_credentials
+
+    before_each(function()
+      ngx.var.request_method = "POST"
+      ngx.var.request_uri = "/configuration/auth-credentials"
+    end)
+
+    after_each(function()
+      basic_auth_credentials:flush_all()
+    end)
+
+    it("replaces the credentials", function()
+      basic_auth_credentials:set("default-ing-secret:removed", "{PLAIN}removed")
+
+      ngx.req.get_body_data = function()
+        return cjson.encode({ ["default-ing-secret"] = { foo = "{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00=" } })
+      end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_CREATED, ngx.status)
+      assert.equal("{SHA}Ys23Ag/5IOWqZCw9QGaVDdHwH00=", basic_auth_credentials:get("default-ing-secret:foo"))
+      assert.is_nil(basic_auth_credentials:get("default-ing-secret:removed"))
+    end)
+
+    it("returns a status code of 400 when the body is invalid", function()
+      ngx.req.get_body_data = function() return "{" end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_BAD_REQUEST, ngx.status)
+    end)
+
+    it("only allows POST requests", function()
+      ngx.var.request_method = "GET"
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_BAD_REQUEST, ngx.status)
+    end)
+  end)
+
+  describe("OIDC clients", function()
+    local oidc_clients = ngx.shared.oidc_clients
+
+    before_each(function()
+      ngx.var.request_method = "POST"
+      ngx.var.request_uri = "/configuration/oidc-clients"
+    end)
+
+    after_each(function()
+      oidc_clients:flush_all()
+    end)
+
+    it("replaces the clients", function()
+      oidc_clients:set("default-removed-secret", cjson.encode({ clientId = "removed" }))
+
+      ngx.req.get_body_data = function()
+        return cjson.encode({ ["default-ing-secret"] = { clientId = "dashboard", clientSecret = "secret" } })
+      end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_CREATED, ngx.status)
+      assert.same({ clientId = "dashboard", clientSecret = "secret" },
+                  cjson.decode(oidc_clients:get("default-ing-secret")))
+      assert.is_nil(oidc_clients:get("default-removed-secret"))
+    end)
+
+    it("returns a status code of 400 when the body is invalid", function()
+      ngx.req.get_body_data = function() return "{" end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_BAD_REQUEST, ngx.status)
+    end)
+
+    it("only allows POST requests", function()
+      ngx.var.request_method = "GET"
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_BAD_REQUEST, ngx.status)
+    end)
+  end)
+
+  describe("Key/value stores", function()
+    local lua_kv = ngx.shared.lua_kv
+
+    before_each(function()
+      ngx.var.request_method = "POST"
+      ngx.var.request_uri = "/configuration/lua-kv"
+    end)
+
+    after_each(function()
+      lua_kv:flush_all()
+    end)
+
+    it("replaces the stores", function()
+      lua_kv:set("default/removed", cjson.encode({ color = "red" }))
+
+      ngx.req.get_body_data = function()
+        return cjson.encode({ ["default/app"] = { color = "blue", size = "10" } })
+      end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_CREATED, ngx.status)
+      assert.same({ color = "blue", size = "10" }, cjson.decode(lua_kv:get("default/app")))
+      assert.is_nil(lua_kv:get("default/removed"))
+    end)
+
+    it("returns a status code of 400 when the body is invalid", function()
+      ngx.req.get_body_data = function() return "{" end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_BAD_REQUEST, ngx.status)
+    end)
+  end)
+
+  describe("Server aliases", function()
+    before_each(function()
+      ngx.var.request_method = "POST"
+      ngx.var.request_uri = "/configuration/server-aliases"
+    end)
+
+    it("stores the aliases and returns them by hostname", function()
+      ngx.req.get_body_data = function()
+        return cjson.encode({ ["alias.example.com"] = "example.com" })
+      end
+
+      assert.has_no.errors(configuration.call)
+      assert.equal(ngx.HTTP_CREATED, ngx.status)
+      assert.equal("example.com", configuration.get_server_alias("alias.example.com"))
+      assert.is_nil(configuration.get_server_alias("unknown.example.com"))
+    end)
+
+    it("returns the new aliases after an update", function()
+      ngx.req.get_body_data = function()
+        return cjson.encode({ ["alias.example.com"] = "example.com" })
+      end
+      assert.has_no.errors(configuration.call)
+      assert.equal("example.com", configuration.get_server_alias("alias.example.com"))
+
+      ngx.req.get_body_data = function()
+        return cjson.encode({ ["other.example.com"] = "example.com" })
+      end
+      assert.has_no.errors(configuration.call)
+      assert.is_nil(configuration.get_server_alias("alias.example.com"))
+      assert.equal("example.com", configuration.get_server_alias("other.example.com"))
+    end)
   end)
 end)
@@ -0,0 +1,101 @@
+describe("csp_report", function()
+  local csp_report = require_without_cache("csp_report")
+
+  describe("violations()", function()
+    it("returns the violation of a report sent to report-uri", function()
+      local body = [[{"csp-report": {
+        "document-uri": "https://example.com/index.html",
+        "violated-directive": "script-src-elem 'self'",
+        "effective-directive": "script-src-elem",
+        "blocked-uri": "https://cdn.example.org/lib.js",
+        "disposition": "report"
+      }}]]
+
+      assert.are.same({
+        {
+          directive = "script-src-elem",
+          document = "https://example.com/index.html",
+          blocked = "https://cdn.example.org/lib.js",
+          disposition = "report",
+        },
+      }, csp_report.violations(body))
+    end)
+
+    it("returns the violated directive of the reports without effective directive", function()
+      local violations = csp_report.violations([[{"csp-report": {"violated-directive": "IMG-SRC 'none'"}}]])
+      assert.are.equal("img-src", violations[1].directive)
+    end)
+
+    it("returns the violations of the reports sent with the Reporting API", function()
+      local body = [[[
+        {"type": "csp-violation", "body": {"effectiveDirective": "style-src", "blockedURL": "inline", "documentURL": "https://example.com/"}},
+        {"type": "deprecation", "body": {"id": "foo"}},
+        {"type": "csp-violation", "body": {"effectiveDirective": "webrtc", "blockedURL": "https://example.org/"}}
+      ]]]
+
+      local violations = csp_report.violations(body)
+      assert.are.equal(2, #violations)
+      assert.are.equal("style-src", violations[1].directive)
+      assert.are.equal("inline", violations[1].blocked)
+      assert.are.equal("other", violations[2].directive)
+    end)
+
+    it("returns nil for the bodies that are not reports", function()
+      assert.is_nil(csp_report.violations("not json"))
+      assert.is_nil(csp_report.violations("null"))
+      assert.is_nil(csp_report.violations('"csp-report"'))
+    end)
+  end)
+
+  describe("content()", function()
+    local unmocked_ngx = _G.ngx
+    local exit_status, body, method
+
+    before_each(function()
+      exit_status, method = nil, "POST"
+      body = [[{"csp-report": {"effective-directive": "img-src", "blocked-uri": "data"}}]]
+
+      _G.ngx = setmetatable({
+        var = {
+          namespace = "default",
+          ingress_name = "example",
+          location_path = "/",
+          csp_violations = "",
+        },
+        exit = function(status) exit_status = status end,
+        log = function() end,
+        req = setmetatable({
+          get_method = function() return method end,
+          read_body = function() end,
+          get_body_data = function() return body end,
+        }, { __index = unmocked_ngx.req }),
+      }, { __index = unmocked_ngx })
+      csp_report = require_without_cache("csp_report")
+    end)
+
+    after_each(function()
+      _G.ngx = unmocked_ngx
+    end)
+
+    it("counts the violations of the reports", function()
+      csp_report.content()
+      assert.are.equal(ngx.HTTP_NO_CONTENT, exit_status)
+      assert.are.equal("img-src", ngx.var.csp_violations)
+    end)
+
+    it("rejects the bodies that are not reports", function()
+      body = "<html></html>"
+
+      csp_report.content()
+      assert.are.equal(ngx.HTTP_BAD_REQUEST, exit_status)
+      assert.are.equal("", ngx.var.csp_violations)
+    end)
+
+    it("rejects the methods other than POST", function()
+      method = "GET"
+
+      csp_report.content()
+      assert.are.equal(ngx.HTTP_NOT_ALLOWED, exit_status)
+    end)
+  end)
+end)
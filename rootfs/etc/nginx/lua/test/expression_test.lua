@@ -0,0 +1,131 @@
+local cjson = require("cjson.safe")
+
+local function literal(value)
+  return { op = "literal", value = value }
+end
+
+local function var(name)
+  return { op = "var", name = name }
+end
+
+local function index(name, key)
+  return { op = "index", name = name, args = { literal(key) } }
+end
+
+describe("expression", function()
+  local expression = require_without_cache("expression")
+  local unmocked_ngx = _G.ngx
+
+  before_each(function()
+    _G.ngx = setmetatable({
+      var = {
+        request_method = "POST",
+        uri = "/api/v1/orders",
+        host = "example.com",
+        remote_addr = "10.0.0.1",
+        http_x_tenant = "acme",
+        arg_version = "2",
+        cookie_beta = "1",
+      },
+      log = function() end,
+    }, { __index = unmocked_ngx })
+    expression = require_without_cache("expression")
+  end)
+
+  after_each(function()
+    _G.ngx = unmocked_ngx
+  end)
+
+  describe("evaluate()", function()
+    it("compares the attributes of the request", function()
+      assert.is_true(expression.evaluate({ op = "eq", args = { var("request.method"), literal("POST") } }))
+      assert.is_false(expression.evaluate({ op = "ne", args = { var("request.host"), literal("example.com") } }))
+      assert.is_true(expression.evaluate({ op = "eq", args = { index("request.headers", "X-Tenant"), literal("acme") } }))
+      assert.is_true(expression.evaluate({ op = "eq", args = { index("request.query", "version"), literal("2") } }))
+    end)
+
+    it("evaluates the missing attributes as empty strings", function()
+      assert.is_true(expression.evaluate({ op = "eq", args = { index("request.headers", "x-missing"), literal("") } }))
+      assert.is_true(expression.evaluate({ op = "eq", args = { var("request.scheme"), literal("") } }))
+    end)
+
+    it("evaluates the presence of the keys of the maps", function()
+      assert.is_true(expression.evaluate({ op = "has", args = { literal("beta"), var("request.cookies") } }))
+      assert.is_false(expression.evaluate({ op = "has", args = { literal("alpha"), var("request.cookies") } }))
+    end)
+
+    it("evaluates the logical operators", function()
+      local is_post = { op = "eq", args = { var("request.method"), literal("POST") } }
+      local is_get = { op = "eq", args = { var("request.method"), literal("GET") } }
+
+      assert.is_false(expression.evaluate({ op = "and", args = { is_post, is_get } }))
+      assert.is_true(expression.evaluate({ op = "or", args = { is_get, is_post } }))
+      assert.is_true(expression.evaluate({ op = "not", args = { is_get } }))
+    end)
+
+    it("evaluates the lists", function()
+      local methods = { op = "list", args = { literal("GET"), literal("POST") } }
+      assert.is_true(expression.evaluate({ op = "in", args = { var("request.method"), methods } }))
+      assert.is_false(expression.evaluate({ op = "in", args = { var("request.method"), { op = "list" } } }))
+    end)
+
+    it("evaluates the methods of the strings", function()
+      local path = var("request.path")
+      assert.is_true(expression.evaluate({ op = "startsWith", args = { path, literal("/api/") } }))
+      assert.is_false(expression.evaluate({ op = "startsWith", args = { path, literal("/admin") } }))
+      assert.is_true(expression.evaluate({ op = "endsWith", args = { path, literal("orders") } }))
+      assert.is_true(expression.evaluate({ op = "contains", args = { path, literal("/v1/") } }))
+      assert.is_true(expression.evaluate({ op = "matches", args = { path, literal("^/api/v[0-9]+/") } }))
+      assert.is_true(expression.evaluate({ op = "gt", args = { { op = "size", args = { path } }, literal(10) } }))
+    end)
+
+    it("counts the code points of the strings", function()
+      assert.is_true(expression.evaluate({ op = "eq", args = { { op = "size", args = { literal("héllo") } }, literal(5) } }))
+    end)
+
+    it("returns false when the evaluation fails", function()
+      assert.is_false(expression.evaluate({ op = "unknown" }))
+      assert.is_false(expression.evaluate({ op = "matches", args = { var("request.path"), literal("(") } }))
+    end)
+  end)
+
+  describe("decode()", function()
+    it("decodes the expressions encoded by the controller", function()
+      local node = { op = "eq", args = { var("request.method"), literal("POST") } }
+      local decoded = expression.decode(ngx.encode_base64(cjson.encode(node)))
+      assert.are.same(node, decoded)
+    end)
+
+    it("returns nil for the invalid expressions", function()
+      assert.is_nil(expression.decode("not base64!"))
+      assert.is_nil(expression.decode(ngx.encode_base64("not json")))
+    end)
+  end)
+
+  describe("rewrite()", function()
+    local encoded = function(node)
+      return ngx.encode_base64(cjson.encode(node))
+    end
+
+    it("keeps the header when the expression is true", function()
+      ngx.var.conditional_header_expression = encoded({ op = "eq", args = { var("request.method"), literal("POST") } })
+      ngx.var.conditional_header_value = "on"
+
+      expression.rewrite()
+      assert.are.equal("on", ngx.var.conditional_header_value)
+    end)
+
+    it("removes the header when the expression is false", function()
+      ngx.var.conditional_header_expression = encoded({ op = "eq", args = { var("request.method"), literal("GET") } })
+      ngx.var.conditional_header_value = "on"
+
+      expression.rewrite()
+      assert.are.equal("", ngx.var.conditional_header_value)
+    end)
+
+    it("does nothing in the locations without conditional header", function()
+      expression.rewrite()
+      assert.is_nil(ngx.var.conditional_header_value)
+    end)
+  end)
+end)
@@ -0,0 +1,129 @@
+describe("grpc_web", function()
+  local unmocked_ngx = _G.ngx
+  local grpc_web, request_headers, body_data, exit_status
+
+  before_each(function()
+    request_headers, body_data, exit_status = {}, nil, nil
+
+    _G.ngx = setmetatable({
+      var = { grpc_web = "true" },
+      ctx = {},
+      arg = {},
+      header = {},
+      exit = function(status) exit_status = status end,
+      req = setmetatable({
+        set_header = function(name, value) request_headers[name] = value end,
+        read_body = function() end,
+        get_body_data = function() return body_data end,
+        get_body_file = function() return nil end,
+        set_body_data = function(data) body_data = data end,
+      }, { __index = unmocked_ngx.req }),
+    }, { __index = unmocked_ngx })
+    grpc_web = require_without_cache("grpc_web")
+  end)
+
+  after_each(function()
+    _G.ngx = unmocked_ngx
+  end)
+
+  describe("trailers_frame()", function()
+    it("returns the frame of the trailers", function()
+      local trailers = "grpc-status:3\r\ngrpc-message:invalid\r\n"
+      assert.are.equal("\128\0\0\0" .. string.char(#trailers) .. trailers, grpc_web.trailers_frame("3", "invalid"))
+      assert.are.equal("\128\0\0\0\15grpc-status:0\r\n", grpc_web.trailers_frame("0", ""))
+    end)
+
+    it("returns nil without status", function()
+      assert.is_nil(grpc_web.trailers_frame(nil, nil))
+    end)
+  end)
+
+  describe("rewrite()", function()
+    it("does nothing in the locations without gRPC-Web", function()
+      ngx.var = { http_content_type = "application/grpc-web+proto" }
+      grpc_web.rewrite()
+      assert.is_nil(request_headers["Content-Type"])
+      assert.is_nil(ngx.ctx.grpc_web)
+    end)
+
+    it("does nothing for the other requests", function()
+      ngx.var.http_content_type = "application/grpc"
+      grpc_web.rewrite()
+      assert.is_nil(request_headers["Content-Type"])
+      assert.is_nil(ngx.ctx.grpc_web)
+    end)
+
+    it("translates the content type of the binary requests", function()
+      ngx.var.http_content_type = "application/grpc-web+proto"
+      grpc_web.rewrite()
+      assert.are.equal("application/grpc+proto", request_headers["Content-Type"])
+      assert.is_false(ngx.ctx.grpc_web.text)
+    end)
+
+    it("decodes the body of the text requests", function()
+      ngx.var.http_content_type = "application/grpc-web-text"
+      body_data = ngx.encode_base64("\0\0\0\0\1a") .. ngx.encode_base64("\0\0\0\0\2bc")
+      grpc_web.rewrite()
+      assert.are.equal("application/grpc", request_headers["Content-Type"])
+      assert.are.equal("\0\0\0\0\1a\0\0\0\0\2bc", body_data)
+      assert.is_true(ngx.ctx.grpc_web.text)
+    end)
+
+    it("rejects the text requests with an invalid body", function()
+      ngx.var.http_content_type = "application/grpc-web-text"
+      body_data = "!!!!"
+      grpc_web.rewrite()
+      assert.are.equal(ngx.HTTP_BAD_REQUEST, exit_status)
+    end)
+  end)
+
+  describe("header_filter()", function()
+    it("translates the content type of the responses", function()
+      ngx.ctx.grpc_web = { text = false, pending = "" }
+      ngx.header["Content-Type"] = "application/grpc+proto"
+      ngx.header["Content-Length"] = "10"
+      grpc_web.header_filter()
+      assert.are.equal("application/grpc-web+proto", ngx.header["Content-Type"])
+      assert.is_nil(ngx.header["Content-Length"])
+
+      ngx.ctx.grpc_web = { text = true, pending = "" }
+      ngx.header["Content-Type"] = "application/grpc"
+      grpc_web.header_filter()
+      assert.are.equal("application/grpc-web-text", ngx.header["Content-Type"])
+    end)
+  end)
+
+  describe("body_filter()", function()
+    local trailers
+
+    before_each(function()
+      ngx.var.upstream_trailer_grpc_status = "0"
+      trailers = grpc_web.trailers_frame("0")
+    end)
+
+    it("appends the trailers to the binary responses", function()
+      ngx.ctx.grpc_web = { text = false, pending = "" }
+
+      ngx.arg[1], ngx.arg[2] = "\0\0\0\0\1a", false
+      grpc_web.body_filter()
+      assert.are.equal("\0\0\0\0\1a", ngx.arg[1])
+
+      ngx.arg[1], ngx.arg[2] = "", true
+      grpc_web.body_filter()
+      assert.are.equal(trailers, ngx.arg[1])
+    end)
+
+    it("encodes the text responses by multiples of 3 bytes", function()
+      ngx.ctx.grpc_web = { text = true, pending = "" }
+
+      local encoded = {}
+      for _, chunk in ipairs({ "\0\0\0\0", "\1a", "" }) do
+        ngx.arg[1], ngx.arg[2] = chunk, chunk == ""
+        grpc_web.body_filter()
+        table.insert(encoded, ngx.arg[1])
+      end
+
+      assert.are.same({ ngx.encode_base64("\0\0\0"), ngx.encode_base64("\0\1a"), ngx.encode_base64(trailers) }, encoded)
+    end)
+  end)
+end)
@@ -0,0 +1,51 @@
+local cjson = require("cjson.safe")
+
+describe("kv", function()
+  local unmocked_ngx = _G.ngx
+  local lua_kv = ngx.shared.lua_kv
+  local kv
+
+  before_each(function()
+    _G.ngx = setmetatable({
+      var = { namespace = "default", ingress_name = "app" },
+    }, { __index = unmocked_ngx })
+    kv = require_without_cache("kv")
+  end)
+
+  after_each(function()
+    _G.ngx = unmocked_ngx
+    lua_kv:flush_all()
+  end)
+
+  it("returns the values of the store of the Ingress", function()
+    lua_kv:set("default/app", cjson.encode({ color = "blue", size = "10" }))
+    lua_kv:set("default/other", cjson.encode({ color = "red" }))
+
+    assert.are.equal("blue", kv.get("color"))
+    assert.is_nil(kv.get("missing"))
+    assert.are.same({ color = "blue", size = "10" }, kv.get_all())
+  end)
+
+  it("returns the updated values of the store", function()
+    lua_kv:set("default/app", cjson.encode({ color = "blue" }))
+    assert.are.equal("blue", kv.get("color"))
+
+    lua_kv:set("default/app", cjson.encode({ color = "green" }))
+    assert.are.equal("green", kv.get("color"))
+  end)
+
+  it("returns a copy of the data of the store", function()
+    lua_kv:set("default/app", cjson.encode({ color = "blue" }))
+
+    kv.get_all().color = "red"
+    assert.are.equal("blue", kv.get("color"))
+  end)
+
+  it("returns no values without store", function()
+    assert.is_nil(kv.get("color"))
+    assert.are.same({}, kv.get_all())
+
+    ngx.var = {}
+    assert.are.same({}, kv.get_all())
+  end)
+end)
@@ -6,4 +6,161 @@ describe("lua_ingress", function()
     assert.spy(s).was_called_with(ngx.WARN,
       string.format("ignoring math.randomseed(%d) since PRNG is already seeded for worker %d", 100, ngx.worker.pid()))
   end)
+
+  describe("header()", function()
+    local unmocked_ngx = _G.ngx
+    local lua_ingress
+
+    before_each(function()
+      _G.ngx = setmetatable({
+        var = { scheme = "https", host = "app.example.com" },
+        header = {},
+      }, { __index = unmocked_ngx })
+      lua_ingress = require_without_cache("lua_ingress")
+      lua_ingress.set_config({
+        hsts = true,
+        hsts_max_age = "31536000",
+        hsts_include_subdomains = true,
+        hsts_preload = false,
+      })
+    end)
+
+    after_each(function()
+      _G.ngx = unmocked_ngx
+    end)
+
+    it("sets the global HSTS header", function()
+      lua_ingress.header()
+      assert.are.equal("max-age=31536000; includeSubDomains", ngx.header["Strict-Transport-Security"])
+    end)
+
+    it("does not set the HSTS header over HTTP", function()
+      ngx.var.scheme = "http"
+      lua_ingress.header()
+      assert.is_nil(ngx.header["Strict-Transport-Security"])
+    end)
+
+    it("overrides the HSTS header with the settings of the location", function()
+      ngx.var.hsts_max_age = "63072000"
+      ngx.var.hsts_include_subdomains = "false"
+      ngx.var.hsts_preload = "true"
+      lua_ingress.header()
+      assert.are.equal("max-age=63072000; preload", ngx.header["Strict-Transport-Security"])
+    end)
+
+    it("sets the HSTS header of the locations with a max-age when it is disabled globally", function()
+      lua_ingress.set_config({ hsts = false, hsts_max_age = "31536000" })
+      lua_ingress.header()
+      assert.is_nil(ngx.header["Strict-Transport-Security"])
+
+      ngx.var.hsts_max_age = "600"
+      lua_ingress.header()
+      assert.are.equal("max-age=600", ngx.header["Strict-Transport-Security"])
+    end)
+
+    it("does not set the HSTS header of the excluded hosts", function()
+      ngx.var.hsts_exclude_hosts = "dev.example.com,*.staging.example.com"
+
+      for _, host in ipairs({ "dev.example.com", "app.staging.example.com", "a.b.staging.example.com" }) do
+        ngx.header = {}
+        ngx.var.host = host
+        lua_ingress.header()
+        assert.is_nil(ngx.header["Strict-Transport-Security"], host)
+      end
+
+      for _, host in ipairs({ "app.dev.example.com", "staging.example.com", "app.example.com" }) do
+        ngx.header = {}
+        ngx.var.host = host
+        lua_ingress.header()
+        assert.is_not_nil(ngx.header["Strict-Transport-Security"], host)
+      end
+    end)
+  end)
+
+  describe("rewrite()", function()
+    local unmocked_ngx = _G.ngx
+    local lua_ingress
+
+    before_each(function()
+      _G.ngx = setmetatable({
+        var = {
+          scheme = "http",
+          http_host = "app.example.com",
+          host = "app.example.com",
+          uri = "/",
+          request_uri = "/",
+          remote_addr = "10.0.0.1",
+          realip_remote_addr = "10.0.0.1",
+          pass_server_port = "80",
+          http_x_forwarded_proto = "https",
+          http_x_forwarded_host = "www.example.com, lb.example.com",
+          http_x_forwarded_port = "443",
+        },
+      }, { __index = unmocked_ngx })
+      lua_ingress = require_without_cache("lua_ingress")
+      lua_ingress.set_config({
+        use_forwarded_headers = false,
+        trusted_proxies = "",
+        listen_ports = { https = "443", ssl_proxy = "442" },
+      })
+    end)
+
+    after_each(function()
+      _G.ngx = unmocked_ngx
+    end)
+
+    it("ignores the forwarded headers when they are not used", function()
+      lua_ingress.rewrite()
+      assert.are.equal("http", ngx.var.pass_access_scheme)
+      assert.are.equal("app.example.com", ngx.var.best_http_host)
+      assert.are.equal("80", ngx.var.pass_port)
+    end)
+
+    it("honors the forwarded headers in the trust mode", function()
+      ngx.var.forwarded_headers_mode = "trust"
+      lua_ingress.rewrite()
+      assert.are.equal("https", ngx.var.pass_access_scheme)
+      assert.are.equal("www.example.com", ngx.var.best_http_host)
+      assert.are.equal(443, ngx.var.pass_port)
+    end)
+
+    it("ignores the forwarded headers of the untrusted peers in the trust mode", function()
+      ngx.var.forwarded_headers_mode = "trust"
+      lua_ingress.set_config({
+        trusted_proxies = "192.168.0.0/16",
+        listen_ports = { https = "443", ssl_proxy = "442" },
+      })
+      lua_ingress.rewrite()
+      assert.are.equal("http", ngx.var.pass_access_scheme)
+      assert.are.equal("app.example.com", ngx.var.best_http_host)
+    end)
+
+    it("replaces the forwarded headers in the overwrite mode", function()
+      lua_ingress.set_config({
+        use_forwarded_headers = true,
+        forwarded_headers_mode = "overwrite",
+        listen_ports = { https = "443", ssl_proxy = "442" },
+      })
+      lua_ingress.rewrite()
+      assert.are.equal("http", ngx.var.pass_access_scheme)
+      assert.are.equal("app.example.com", ngx.var.best_http_host)
+      assert.are.equal("80", ngx.var.pass_port)
+    end)
+
+    it("appends the values of the connection in the append mode", function()
+      ngx.var.forwarded_headers_mode = "append"
+      lua_ingress.rewrite()
+      assert.are.equal("https", ngx.var.pass_access_scheme)
+      assert.are.equal("https, http", ngx.var.forwarded_proto)
+      assert.are.equal("www.example.com, lb.example.com, app.example.com", ngx.var.forwarded_host)
+      assert.are.equal("443, 80", ngx.var.forwarded_port)
+    end)
+
+    it("overrides the scheme of the connection", function()
+      ngx.var.forwarded_headers_mode = "overwrite"
+      ngx.var.forwarded_scheme_override = "https"
+      lua_ingress.rewrite()
+      assert.are.equal("https", ngx.var.pass_access_scheme)
+    end)
+  end)
 end)
@@ -97,6 +97,18 @@ describe("Monitor", function()
         upstream_response_time = "0.03",
         upstream_response_length = "456",
         upstream_status = "200",
+
+        limit_conn_status = "REJECTED",
+
+        canary_decision = "weight",
+
+        backup_failover = "status",
+
+        body_inspection_rejection = "json_depth",
+
+        proxy_cache_coalesced = "true",
+
+        csp_violations = "img-src script-src",
       }
       mock_ngx({ var = ngx_var_mock })
       local monitor = require("monitor")
@@ -129,6 +141,28 @@ describe("Monitor", function()
           upstreamHeaderTime = 0.02,
           upstreamResponseTime = 0.03,
           upstreamResponseLength = 456,
+
+          upstream = "default-http-svc-canary-80",
+          upstreamConnectTimes = { 0.01 },
+          upstreamHeaderTimes = { 0.02 },
+
+          limitConnStatus = "REJECTED",
+          limitReqStatus = "-",
+
+          canaryDecision = "weight",
+
+          backupFailover = "status",
+
+          bodyInspectionRejection = "json_depth",
+
+          cacheCoalesced = true,
+
+          cspViolations = "img-src script-src",
+          upstreamConnectFailures = "",
+
+          endpoint = "10.10.0.1",
+          endpointStatus = "200",
+          endpointResponseTime = 0.03,
         },
         {
           host = "example.com",
@@ -148,6 +182,28 @@ describe("Monitor", function()
           upstreamHeaderTime = 0.02,
           upstreamResponseTime = 0.03,
           upstreamResponseLength = 456,
+
+          upstream = "default-http-svc-canary-80",
+          upstreamConnectTimes = { 0.01 },
+          upstreamHeaderTimes = { 0.02 },
+
+          limitConnStatus = "REJECTED",
+          limitReqStatus = "-",
+
+          canaryDecision = "weight",
+
+          backupFailover = "status",
+
+          bodyInspectionRejection = "json_depth",
+
+          cacheCoalesced = true,
+
+          cspViolations = "img-src script-src",
+          upstreamConnectFailures = "",
+
+          endpoint = "10.10.0.1",
+          endpointStatus = "200",
+          endpointResponseTime = 0.03,
         },
       })
 
@@ -156,4 +212,71 @@ describe("Monitor", function()
       assert.stub(tcp_mock.close).was_called_with(tcp_mock)
     end)
   end)
+
+  describe("upstream_connect_failures", function()
+    it("returns the IP families of the failed connections", function()
+      mock_ngx({ var = {
+        upstream_addr = "[2001:db8::1]:8080, 10.10.0.1:8080, 10.10.0.2:8080 : [2001:db8::2]:8080",
+        upstream_connect_time = "-, -, 0.001 : -",
+      } })
+      local monitor = require("monitor")
+
+      assert.equal("ipv6 ipv4 ipv6", monitor.upstream_connect_failures())
+    end)
+
+    it("returns no families when the connections succeeded", function()
+      mock_ngx({ var = { upstream_addr = "10.10.0.1:8080", upstream_connect_time = "0.001" } })
+      local monitor = require("monitor")
+
+      assert.equal("", monitor.upstream_connect_failures())
+    end)
+
+    it("ignores the tries without upstream endpoint", function()
+      mock_ngx({ var = { upstream_addr = "upstream_balancer, unix:/tmp/sock", upstream_connect_time = "-, -" } })
+      local monitor = require("monitor")
+
+      assert.equal("", monitor.upstream_connect_failures())
+    end)
+
+    it("returns - without upstream", function()
+      mock_ngx({ var = {} })
+      local monitor = require("monitor")
+
+      assert.equal("-", monitor.upstream_connect_failures())
+    end)
+  end)
+
+  describe("upstream_times", function()
+    it("returns the times of the tries that established a connection", function()
+      mock_ngx({ var = {} })
+      local monitor = require("monitor")
+
+      assert.are.same({ 0.004, 0.001 }, monitor.upstream_times("-, 0.004 : 0.001"))
+    end)
+
+    it("returns nil without times", function()
+      mock_ngx({ var = {} })
+      local monitor = require("monitor")
+
+      assert.is_nil(monitor.upstream_times("-, -"))
+      assert.is_nil(monitor.upstream_times(nil))
+    end)
+  end)
+
+  describe("last_upstream_value", function()
+    it("returns the value of the last try", function()
+      mock_ngx({ var = {} })
+      local monitor = require("monitor")
+
+      assert.equal("10.10.0.2:8080", monitor.last_upstream_value("10.10.0.1:8080, 10.10.0.2:8080"))
+      assert.equal("502", monitor.last_upstream_value("504 : 502"))
+    end)
+
+    it("returns nil without value", function()
+      mock_ngx({ var = {} })
+      local monitor = require("monitor")
+
+      assert.is_nil(monitor.last_upstream_value(nil))
+    end)
+  end)
 end)
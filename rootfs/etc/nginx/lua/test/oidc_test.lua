@@ -0,0 +1,287 @@
+local cjson = require("cjson.safe")
+local http = require("resty.http")
+
+local clients = ngx.shared.oidc_clients
+
+local KEY = "default-ing-secret"
+
+local function encode(claims)
+  local segment = ngx.encode_base64(cjson.encode(claims))
+  segment = string.gsub(segment, "=", "")
+  segment = string.gsub(segment, "%+", "-")
+  segment = string.gsub(segment, "/", "_")
+  return segment
+end
+
+local function id_token(claims)
+  return encode({ alg = "RS256", typ = "JWT" }) .. "." .. encode(claims) .. ".signature"
+end
+
+describe("oidc", function()
+  local oidc = require_without_cache("oidc")
+  local client = { clientId = "dashboard", clientSecret = "client-secret" }
+  local discovery = {
+    issuer = "https://accounts.example.com",
+    authorization_endpoint = "https://accounts.example.com/authorize",
+    token_endpoint = "https://accounts.example.com/token",
+  }
+
+  describe("seal()", function()
+    it("returns a value unsealed with the same keys", function()
+      local keys = oidc.keys(KEY, client)
+      local sealed = oidc.seal(keys, { sub = "foo" })
+      assert.is_truthy(string.match(sealed, "^[%w%-_]+%.[%w%-_]+$"))
+      assert.are.same({ sub = "foo" }, oidc.unseal(keys, sealed))
+    end)
+
+    it("returns a value that cannot be unsealed with other keys", function()
+      local sealed = oidc.seal(oidc.keys(KEY, client), { sub = "foo" })
+      assert.is_nil(oidc.unseal(oidc.keys("default-other-secret", client), sealed))
+
+      local with_cookie_secret = { clientId = "dashboard", clientSecret = "client-secret", cookieSecret = "cookie" }
+      assert.is_nil(oidc.unseal(oidc.keys(KEY, with_cookie_secret), sealed))
+    end)
+
+    it("returns a value that cannot be modified", function()
+      local keys = oidc.keys(KEY, client)
+      local sealed = oidc.seal(keys, { sub = "foo" })
+      local encrypted, mac = string.match(sealed, "^(.-)%.(.*)$")
+      local other_encrypted = string.match(oidc.seal(keys, { sub = "bar" }), "^(.-)%.")
+
+      assert.is_nil(oidc.unseal(keys, other_encrypted .. "." .. mac))
+      assert.is_nil(oidc.unseal(keys, encrypted .. "." .. encrypted))
+      assert.is_nil(oidc.unseal(keys, "invalid"))
+      assert.is_nil(oidc.unseal(keys, nil))
+    end)
+  end)
+
+  describe("validate_id_token()", function()
+    local claims
+
+    before_each(function()
+      claims = {
+        iss = "https://accounts.example.com/",
+        aud = "dashboard",
+        sub = "foo",
+        exp = ngx.time() + 60,
+        nonce = "nonce",
+      }
+    end)
+
+    it("returns the claims of a valid token", function()
+      assert.are.same(claims, oidc.validate_id_token(id_token(claims), discovery, "dashboard", "nonce"))
+
+      claims.aud = { "dashboard", "other" }
+      claims.azp = "dashboard"
+      assert.are.same(claims, oidc.validate_id_token(id_token(claims), discovery, "dashboard", "nonce"))
+    end)
+
+    it("rejects the invalid tokens", function()
+      local invalid_claims = {
+        { iss = "https://evil.example.com" },
+        { aud = "other" },
+        { aud = { "dashboard", "other" } },
+        { exp = ngx.time() - 1 },
+        { nonce = "other" },
+        { sub = "" },
+      }
+
+      for _, invalid in ipairs(invalid_claims) do
+        local token_claims = {}
+        for name, value in pairs(claims) do
+          token_claims[name] = value
+        end
+        for name, value in pairs(invalid) do
+          token_claims[name] = value
+        end
+
+        local validated, err = oidc.validate_id_token(id_token(token_claims), discovery, "dashboard", "nonce")
+        assert.is_nil(validated)
+        assert.is_not_nil(err)
+      end
+
+      assert.is_nil(oidc.validate_id_token("invalid", discovery, "dashboard", "nonce"))
+    end)
+  end)
+
+  describe("rewrite()", function()
+    local unmocked_ngx = _G.ngx
+    local original_http_new = http.new
+    local exit_status, redirected_to, request_headers, request_method
+
+    before_each(function()
+      exit_status, redirected_to, request_method = nil, nil, "GET"
+      request_headers = { ["X-Auth-Request-User"] = "spoofed" }
+
+      _G.ngx = setmetatable({
+        var = {
+          oidc_key = KEY,
+          oidc_issuer = "https://accounts.example.com",
+          oidc_scopes = "openid email",
+          oidc_redirect_path = "/oauth2/callback",
+          oidc_cookie_name = "_oidc_session",
+          oidc_cookie_domain = "",
+          oidc_cookie_samesite = "Lax",
+          oidc_session_duration = "3600",
+          uri = "/dashboard",
+          request_uri = "/dashboard?tab=1",
+          host = "example.com",
+          scheme = "https",
+        },
+        header = {},
+        exit = function(status) exit_status = status end,
+        redirect = function(url) redirected_to = url end,
+        req = setmetatable({
+          get_method = function() return request_method end,
+          clear_header = function(name) request_headers[name] = nil end,
+          set_header = function(name, value) request_headers[name] = value end,
+        }, { __index = unmocked_ngx.req }),
+      }, { __index = unmocked_ngx })
+      oidc = require_without_cache("oidc")
+      oidc.discover = function() return discovery end
+
+      clients:set(KEY, cjson.encode(client))
+    end)
+
+    after_each(function()
+      _G.ngx = unmocked_ngx
+      http.new = original_http_new
+      clients:flush_all()
+    end)
+
+    it("does nothing in the locations without OpenID Connect authentication", function()
+      ngx.var = {}
+      oidc.rewrite()
+      assert.is_nil(exit_status)
+      assert.is_nil(redirected_to)
+      assert.are.equal("spoofed", request_headers["X-Auth-Request-User"])
+    end)
+
+    it("sets the headers of the users with a valid session", function()
+      ngx.var.cookie__oidc_session = oidc.seal(oidc.keys(KEY, client), {
+        user = "foo",
+        email = "foo@example.com",
+        exp = ngx.time() + 60,
+      })
+
+      oidc.rewrite()
+      assert.is_nil(exit_status)
+      assert.is_nil(redirected_to)
+      assert.are.equal("foo", request_headers["X-Auth-Request-User"])
+      assert.are.equal("foo@example.com", request_headers["X-Auth-Request-Email"])
+    end)
+
+    it("redirects the users without a valid session to the issuer", function()
+      ngx.var.cookie__oidc_session = oidc.seal(oidc.keys(KEY, client), { user = "foo", exp = ngx.time() - 1 })
+
+      oidc.rewrite()
+      assert.is_nil(request_headers["X-Auth-Request-User"])
+      assert.are.equal(discovery.authorization_endpoint .. "?",
+                       string.sub(redirected_to, 1, #discovery.authorization_endpoint + 1))
+
+      local args = ngx.decode_args(string.match(redirected_to, "%?(.*)$"))
+      assert.are.equal("code", args.response_type)
+      assert.are.equal("dashboard", args.client_id)
+      assert.are.equal("https://example.com/oauth2/callback", args.redirect_uri)
+      assert.are.equal("openid email", args.scope)
+      assert.are.equal("S256", args.code_challenge_method)
+
+      local state_cookie = string.match(ngx.header["Set-Cookie"], "^_oidc_session_state=([^;]+)")
+      local state = oidc.unseal(oidc.keys(KEY, client), state_cookie)
+      assert.are.equal(args.state, state.state)
+      assert.are.equal("/dashboard?tab=1", state.target)
+    end)
+
+    it("rejects the requests other than navigations without a valid session", function()
+      request_method = "POST"
+
+      oidc.rewrite()
+      assert.are.equal(ngx.HTTP_UNAUTHORIZED, exit_status)
+      assert.is_nil(redirected_to)
+      assert.is_nil(request_headers["X-Auth-Request-User"])
+    end)
+
+    it("returns a status code of 503 when the client is not configured", function()
+      clients:delete(KEY)
+
+      oidc.rewrite()
+      assert.are.equal(ngx.HTTP_SERVICE_UNAVAILABLE, exit_status)
+    end)
+
+    describe("callback", function()
+      local token_request
+
+      before_each(function()
+        token_request = nil
+        ngx.var.uri = "/oauth2/callback"
+        ngx.var.cookie__oidc_session_state = oidc.seal(oidc.keys(KEY, client), {
+          state = "state",
+          nonce = "nonce",
+          verifier = "verifier",
+          target = "/dashboard?tab=1",
+          exp = ngx.time() + 60,
+        })
+        ngx.req.get_uri_args = function() return { state = "state", code = "code" } end
+
+        http.new = function()
+          return {
+            set_timeout = function() end,
+            request_uri = function(_, url, params)
+              token_request = { url = url, params = params }
+              return {
+                status = 200,
+                body = cjson.encode({
+                  id_token = id_token({
+                    iss = "https://accounts.example.com",
+                    aud = "dashboard",
+                    sub = "foo",
+                    email = "foo@example.com",
+                    exp = ngx.time() + 60,
+                    nonce = "nonce",
+                  }),
+                }),
+              }
+            end,
+          }
+        end
+      end)
+
+      it("creates the session of the authenticated users", function()
+        oidc.rewrite()
+        assert.is_nil(exit_status)
+        assert.are.equal("/dashboard?tab=1", redirected_to)
+
+        assert.are.equal(discovery.token_endpoint, token_request.url)
+        local args = ngx.decode_args(token_request.params.body)
+        assert.are.equal("authorization_code", args.grant_type)
+        assert.are.equal("code", args.code)
+        assert.are.equal("verifier", args.code_verifier)
+        assert.are.equal("Basic " .. ngx.encode_base64("dashboard:client-secret"),
+                         token_request.params.headers["Authorization"])
+
+        local cookies = ngx.header["Set-Cookie"]
+        local session = oidc.unseal(oidc.keys(KEY, client), string.match(cookies[1], "^_oidc_session=([^;]+)"))
+        assert.are.equal("foo@example.com", session.user)
+        assert.are.equal("foo@example.com", session.email)
+        assert.is_truthy(string.find(cookies[1], "; Secure; HttpOnly; SameSite=Lax", 1, true))
+        assert.is_truthy(string.find(cookies[2], "^_oidc_session_state=; Path=/; Max%-Age=0"))
+      end)
+
+      it("rejects the callbacks with an invalid state", function()
+        ngx.req.get_uri_args = function() return { state = "other", code = "code" } end
+
+        oidc.rewrite()
+        assert.are.equal(ngx.HTTP_UNAUTHORIZED, exit_status)
+        assert.is_nil(token_request)
+      end)
+
+      it("rejects the callbacks without the state cookie", function()
+        ngx.var.cookie__oidc_session_state = nil
+
+        oidc.rewrite()
+        assert.are.equal(ngx.HTTP_UNAUTHORIZED, exit_status)
+        assert.is_nil(token_request)
+      end)
+    end)
+  end)
+end)
@@ -0,0 +1,68 @@
+describe("proxy_cache", function()
+  local unmocked_ngx = _G.ngx
+  local proxy_cache
+  local now, start_time
+
+  before_each(function()
+    now, start_time = 100, 99
+
+    _G.ngx = setmetatable({
+      var = {
+        response_cache_key = "httpsexample.com/app",
+        upstream_cache_status = "MISS",
+        proxy_cache_coalesced = "",
+      },
+      now = function() return now end,
+      req = setmetatable({
+        start_time = function() return start_time end,
+      }, { __index = unmocked_ngx.req }),
+    }, { __index = unmocked_ngx })
+
+    ngx.shared.proxy_cache_fills:flush_all()
+    proxy_cache = require_without_cache("proxy_cache")
+  end)
+
+  after_each(function()
+    _G.ngx = unmocked_ngx
+  end)
+
+  it("marks the hits started before the fill of their cache entry", function()
+    proxy_cache.header_filter()
+
+    ngx.var.upstream_cache_status = "HIT"
+    start_time = 99.5
+    proxy_cache.log()
+    assert.are.equal("true", ngx.var.proxy_cache_coalesced)
+  end)
+
+  it("does not mark the hits started after the fill of their cache entry", function()
+    proxy_cache.header_filter()
+
+    ngx.var.upstream_cache_status = "HIT"
+    start_time = 100.5
+    proxy_cache.log()
+    assert.are.equal("", ngx.var.proxy_cache_coalesced)
+  end)
+
+  it("does not mark the requests sent to the backend", function()
+    proxy_cache.header_filter()
+
+    ngx.var.upstream_cache_status = "EXPIRED"
+    proxy_cache.log()
+    assert.are.equal("", ngx.var.proxy_cache_coalesced)
+  end)
+
+  it("does not record the fills of the hits", function()
+    ngx.var.upstream_cache_status = "HIT"
+    proxy_cache.header_filter()
+
+    assert.is_nil(ngx.shared.proxy_cache_fills:get("httpsexample.com/app"))
+  end)
+
+  it("does nothing in the locations without cache", function()
+    ngx.var.response_cache_key = ""
+    proxy_cache.header_filter()
+
+    assert.is_nil(ngx.shared.proxy_cache_fills:get(""))
+  end)
+end)
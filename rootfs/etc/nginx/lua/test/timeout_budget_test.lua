@@ -0,0 +1,65 @@
+local timeout_budget = require("timeout_budget")
+
+describe("timeout_budget", function()
+  describe("parse()", function()
+    it("parses grpc-timeout values", function()
+      assert.are.equal(7200, timeout_budget.parse("2H"))
+      assert.are.equal(120, timeout_budget.parse("2M"))
+      assert.are.equal(2, timeout_budget.parse("2S"))
+      assert.are.equal(0.25, timeout_budget.parse("250m"))
+      assert.are.equal(0.5, timeout_budget.parse("500000u"))
+      assert.are.equal(0.5, timeout_budget.parse("500000000n"))
+    end)
+
+    it("parses seconds", function()
+      assert.are.equal(30, timeout_budget.parse("30"))
+      assert.are.equal(1.5, timeout_budget.parse("1.5"))
+    end)
+
+    it("ignores invalid values", function()
+      assert.is_nil(timeout_budget.parse(nil))
+      assert.is_nil(timeout_budget.parse(""))
+      assert.is_nil(timeout_budget.parse("0"))
+      assert.is_nil(timeout_budget.parse("-1"))
+      assert.is_nil(timeout_budget.parse("0x10"))
+      assert.is_nil(timeout_budget.parse("1h"))
+      assert.is_nil(timeout_budget.parse("123456789S"))
+      assert.is_nil(timeout_budget.parse({ "1S", "2S" }))
+    end)
+  end)
+
+  describe("apply()", function()
+    local ngx_balancer = require("ngx.balancer")
+
+    after_each(function()
+      ngx.ctx.timeout_budget_deadline = nil
+      ngx.ctx.timeout_budget_max = nil
+    end)
+
+    it("does nothing without a deadline", function()
+      local s = spy.on(ngx_balancer, "set_timeouts")
+      timeout_budget.apply()
+      assert.spy(s).was_not_called()
+    end)
+
+    it("caps the read timeout by the read timeout of the location", function()
+      local s = stub(ngx_balancer, "set_timeouts", true)
+      ngx.ctx.timeout_budget_deadline = ngx.now() + 3600
+      ngx.ctx.timeout_budget_max = 60
+
+      timeout_budget.apply()
+      assert.stub(s).was_called_with(nil, nil, 60)
+      s:revert()
+    end)
+
+    it("sets a minimum read timeout when the budget is exhausted", function()
+      local s = stub(ngx_balancer, "set_timeouts", true)
+      ngx.ctx.timeout_budget_deadline = ngx.now() - 1
+      ngx.ctx.timeout_budget_max = 60
+
+      timeout_budget.apply()
+      assert.stub(s).was_called_with(nil, nil, 0.001)
+      s:revert()
+    end)
+  end)
+end)
@@ -0,0 +1,71 @@
+describe("trusted_proxies", function()
+  local unmocked_ngx = _G.ngx
+  local trusted_proxies
+
+  before_each(function()
+    _G.ngx = setmetatable({
+      var = {
+        realip_remote_addr = "10.0.0.1",
+        remote_addr = "10.0.0.1",
+      },
+    }, { __index = unmocked_ngx })
+    trusted_proxies = require_without_cache("trusted_proxies")
+  end)
+
+  after_each(function()
+    _G.ngx = unmocked_ngx
+  end)
+
+  describe("is_trusted()", function()
+    it("uses the forwarded headers without trusted proxies", function()
+      assert.is_true(trusted_proxies.is_trusted(true, nil))
+      assert.is_false(trusted_proxies.is_trusted(false, ""))
+    end)
+
+    it("trusts the peers in the trusted proxies of the controller", function()
+      assert.is_true(trusted_proxies.is_trusted(false, "10.0.0.0/24"))
+      assert.is_false(trusted_proxies.is_trusted(true, "192.168.0.0/16"))
+    end)
+
+    it("trusts the peers in the trusted proxies of the server", function()
+      ngx.var.trusted_proxies = "192.168.0.0/16"
+      assert.is_false(trusted_proxies.is_trusted(true, "10.0.0.0/24"))
+
+      ngx.var.realip_remote_addr = "192.168.1.1"
+      assert.is_true(trusted_proxies.is_trusted(false, "10.0.0.0/24"))
+    end)
+  end)
+
+  describe("rewrite()", function()
+    before_each(function()
+      ngx.var.realip_remote_addr = "10.0.0.1"
+      ngx.var.remote_addr = "203.0.113.10"
+      ngx.var.trusted_x_forwarded_for = ngx.var.remote_addr
+      ngx.var.trusted_x_original_forwarded_for = "203.0.113.10, 10.0.1.1"
+      ngx.var.trusted_forwarded = "for=203.0.113.10"
+    end)
+
+    it("extends the forwarded headers of a trusted peer", function()
+      trusted_proxies.rewrite(true)
+      assert.are.equal("203.0.113.10, 10.0.1.1, 10.0.0.1", ngx.var.trusted_x_forwarded_for)
+      assert.are.equal("203.0.113.10, 10.0.1.1", ngx.var.trusted_x_original_forwarded_for)
+      assert.are.equal("for=203.0.113.10", ngx.var.trusted_forwarded)
+    end)
+
+    it("replaces the forwarded headers of an untrusted peer", function()
+      ngx.var.remote_addr = "10.0.0.1"
+
+      trusted_proxies.rewrite(false)
+      assert.are.equal("10.0.0.1", ngx.var.trusted_x_forwarded_for)
+      assert.are.equal("", ngx.var.trusted_x_original_forwarded_for)
+      assert.are.equal("", ngx.var.trusted_forwarded)
+    end)
+
+    it("does nothing in the locations without trusted proxies", function()
+      ngx.var.trusted_x_forwarded_for = nil
+
+      trusted_proxies.rewrite(false)
+      assert.are.equal("for=203.0.113.10", ngx.var.trusted_forwarded)
+    end)
+  end)
+end)
@@ -0,0 +1,48 @@
+local cidr = require("util.cidr")
+
+describe("cidr", function()
+
+  describe("parse", function()
+    it("parses the IPv4 and IPv6 networks and addresses", function()
+      assert.are.same({ bytes = { 10, 0, 0, 0 }, prefix = 8 }, cidr.parse("10.0.0.0/8"))
+      assert.are.same({ bytes = { 192, 168, 1, 1 }, prefix = 32 }, cidr.parse(" 192.168.1.1 "))
+      assert.are.equal(16, #cidr.parse("2001:db8::/32").bytes)
+      assert.are.equal(32, cidr.parse("2001:db8::/32").prefix)
+      assert.are.equal(128, cidr.parse("::1").prefix)
+      assert.are.same({ bytes = { 10, 0, 0, 0 }, prefix = 8 }, cidr.parse("::ffff:10.0.0.0/104"))
+    end)
+
+    it("returns nil for the invalid networks", function()
+      for _, value in ipairs({ "", "10.0.0.0/33", "256.0.0.1", "10.0.0/8", "2001:db8::/129",
+                               "1::2::3", "1:2:3:4:5:6:7", "fe80::g", "example.com" }) do
+        assert.is_nil(cidr.parse(value), value)
+      end
+    end)
+  end)
+
+  describe("contains", function()
+    it("returns true for the addresses in the networks", function()
+      local list = "10.0.0.0/8, 192.168.1.0/25,2001:db8::/32,203.0.113.7"
+
+      for _, address in ipairs({ "10.1.2.3", "192.168.1.127", "2001:db8:0:1::5", "203.0.113.7", "::ffff:10.0.0.1" }) do
+        assert.is_true(cidr.contains(list, address), address)
+      end
+    end)
+
+    it("returns false for the addresses out of the networks", function()
+      local list = "10.0.0.0/8,192.168.1.0/25,2001:db8::/32,invalid"
+
+      for _, address in ipairs({ "11.0.0.1", "192.168.1.128", "2001:db9::1", "::1", "invalid" }) do
+        assert.is_false(cidr.contains(list, address), address)
+      end
+      assert.is_false(cidr.contains(nil, "10.0.0.1"))
+      assert.is_false(cidr.contains(list, nil))
+    end)
+
+    it("matches all the addresses of the default networks", function()
+      assert.is_true(cidr.contains("0.0.0.0/0", "198.51.100.1"))
+      assert.is_true(cidr.contains("::/0", "fe80::1%eth0"))
+      assert.is_false(cidr.contains("0.0.0.0/0", "fe80::1"))
+    end)
+  end)
+end)
@@ -32,6 +32,14 @@ describe("dns.lookup", function()
     dns_lookup("example.com")
   end)
 
+  it("sets the nameservers of the options", function()
+    helpers.mock_resty_dns_new(function(self, options)
+      assert.are.same({ nameservers = { "10.0.0.53" }, retrans = 5, timeout = 2000 }, options)
+      return nil, ""
+    end)
+    dns_lookup("example.com", { nameservers = { "10.0.0.53" } })
+  end)
+
   describe("when there's an error", function()
     it("returns host when resolver can not be instantiated", function()
       helpers.mock_resty_dns_new(function(...) return nil, "an error" end)
@@ -133,4 +141,69 @@ describe("dns.lookup", function()
     assert.are.same({ "192.168.1.1", "1.2.3.4" }, dns_lookup("example.com."))
     assert.spy(spy_cache_set).was_called_with(match.is_table(), "example.com.", { "192.168.1.1", "1.2.3.4" }, 60)
   end)
+
+  it("caches with the ttl and per nameservers of the options", function()
+    helpers.mock_resty_dns_query("example.com.", {
+      {
+        name = "example.com.",
+        address = "192.168.1.1",
+        ttl = 3600,
+      }
+    })
+
+    local spy_cache_set = spy.on(dns._cache, "set")
+
+    local options = { nameservers = { "10.0.0.53", "10.0.0.54" }, ttl = 30 }
+    assert.are.same({ "192.168.1.1" }, dns_lookup("example.com.", options))
+    assert.spy(spy_cache_set).was_called_with(match.is_table(), "example.com.@10.0.0.53,10.0.0.54", { "192.168.1.1" }, 30)
+    assert.is_nil(dns._cache:get("example.com."))
+  end)
+
+  describe("stale addresses", function()
+    after_each(function()
+      dns.stale_ttl = 0
+    end)
+
+    it("returns the expired addresses when the resolution fails", function()
+      dns.stale_ttl = 60
+      helpers.mock_resty_dns_query("example.com.", { { name = "example.com.", address = "192.168.1.1", ttl = 1 } })
+      assert.are.same({ "192.168.1.1" }, dns_lookup("example.com."))
+
+      dns._cache:delete("example.com.")
+      helpers.mock_resty_dns_query("example.com.", nil, "oops!")
+      assert.are.same({ "192.168.1.1" }, dns_lookup("example.com."))
+    end)
+
+    it("returns host when the resolution fails without stale_ttl", function()
+      helpers.mock_resty_dns_query("example.com.", { { name = "example.com.", address = "192.168.1.1", ttl = 1 } })
+      assert.are.same({ "192.168.1.1" }, dns_lookup("example.com."))
+
+      dns._cache:delete("example.com.")
+      helpers.mock_resty_dns_query("example.com.", nil, "oops!")
+      assert.are.same({ "example.com." }, dns_lookup("example.com."))
+    end)
+  end)
+
+  describe("stats()", function()
+    before_each(function()
+      ngx.shared.dns_cache_stats:flush_all()
+    end)
+
+    it("counts the lookups and the resolutions", function()
+      helpers.mock_resty_dns_query("example.com.", { { name = "example.com.", address = "192.168.1.1", ttl = 60 } })
+      dns_lookup("example.com.")
+      dns_lookup("example.com.")
+
+      helpers.mock_resty_dns_query("other.example.com.", nil, "oops!")
+      dns_lookup("other.example.com.")
+
+      local stats = dns.stats()
+      assert.are.equal(1, stats.hits)
+      assert.are.equal(2, stats.misses)
+      assert.are.equal(1, stats.errors)
+      assert.are.equal(0, stats.stale)
+      assert.are.equal(2, stats.resolutions)
+      assert.are.equal(2, stats.resolution_buckets["10"])
+    end)
+  end)
 end)
@@ -0,0 +1,39 @@
+local jwt = require("util.jwt")
+
+local function encode(claims)
+  local segment = ngx.encode_base64(require("cjson").encode(claims))
+  segment = string.gsub(segment, "=", "")
+  segment = string.gsub(segment, "%+", "-")
+  segment = string.gsub(segment, "/", "_")
+  return segment
+end
+
+local header = encode({ alg = "HS256", typ = "JWT" })
+
+describe("jwt", function()
+  it("returns the value of a claim", function()
+    local token = header .. "." .. encode({ sub = "tenant-a", level = 3 }) .. ".signature"
+    assert.are.equal("tenant-a", jwt.claim("Bearer " .. token, "sub"))
+    assert.are.equal("3", jwt.claim("bearer " .. token, "level"))
+  end)
+
+  it("returns an empty string when the claim is not present", function()
+    local token = header .. "." .. encode({ sub = "tenant-a", groups = { "a" } }) .. ".signature"
+    assert.are.equal("", jwt.claim("Bearer " .. token, "tenant"))
+    assert.are.equal("", jwt.claim("Bearer " .. token, "groups"))
+  end)
+
+  it("returns the claims of a token", function()
+    local token = header .. "." .. encode({ sub = "tenant-a", aud = { "a", "b" } }) .. ".signature"
+    assert.same({ sub = "tenant-a", aud = { "a", "b" } }, jwt.claims(token))
+    assert.is_nil(jwt.claims("Bearer " .. token))
+    assert.is_nil(jwt.claims(nil))
+  end)
+
+  it("returns an empty string for invalid tokens", function()
+    assert.are.equal("", jwt.claim(nil, "sub"))
+    assert.are.equal("", jwt.claim("Basic dXNlcjpwYXNz", "sub"))
+    assert.are.equal("", jwt.claim("Bearer invalid", "sub"))
+    assert.are.equal("", jwt.claim("Bearer " .. header .. ".e30K$.signature", "sub"))
+  end)
+end)
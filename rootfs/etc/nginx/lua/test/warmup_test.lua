@@ -0,0 +1,74 @@
+describe("warmup", function()
+  local unmocked_ngx = _G.ngx
+  local warmup, sent, status_line
+
+  before_each(function()
+    sent, status_line = {}, "HTTP/1.1 200 OK"
+    ngx.shared.warmup_endpoints:flush_all()
+
+    _G.ngx = setmetatable({
+      log = function() end,
+      socket = {
+        tcp = function()
+          return {
+            settimeouts = function() end,
+            connect = function(_, host, port)
+              if host == "10.0.0.9" then
+                return nil, "connection refused"
+              end
+              return true
+            end,
+            send = function(_, data)
+              table.insert(sent, data)
+              return #data
+            end,
+            receive = function() return status_line end,
+            close = function() end,
+          }
+        end,
+      },
+    }, { __index = unmocked_ngx })
+    warmup = require_without_cache("warmup")
+  end)
+
+  after_each(function()
+    _G.ngx = unmocked_ngx
+  end)
+
+  describe("endpoints()", function()
+    it("returns the endpoints of the backends without warm-up", function()
+      local backend = { name = "app", endpoints = { { address = "10.0.0.1", port = "80" } } }
+
+      local endpoints, warming = warmup.endpoints(backend)
+      assert.equal(backend.endpoints, endpoints)
+      assert.is_false(warming)
+    end)
+  end)
+
+  describe("warm_up()", function()
+    local config = { path = "/warmup?level=full", requests = 3, timeout = 1 }
+
+    it("sends the warm-up requests and records the endpoint warmed up", function()
+      warmup.warm_up(false, "app", { address = "2001:db8::1", port = "8080" }, config)
+
+      assert.equal(3, #sent)
+      assert.equal("GET /warmup?level=full HTTP/1.1\r\nHost: [2001:db8::1]\r\n" ..
+                   "User-Agent: ingress-nginx-warmup\r\nConnection: close\r\n\r\n", sent[1])
+      assert.is_true(ngx.shared.warmup_endpoints:get("app 2001:db8::1:8080"))
+    end)
+
+    it("records the endpoint warmed up when the requests fail", function()
+      warmup.warm_up(false, "app", { address = "10.0.0.9", port = "8080" }, config)
+
+      assert.equal(0, #sent)
+      assert.is_true(ngx.shared.warmup_endpoints:get("app 10.0.0.9:8080"))
+    end)
+
+    it("does not warm up the endpoints when the worker exits", function()
+      warmup.warm_up(true, "app", { address = "10.0.0.1", port = "8080" }, config)
+
+      assert.equal(0, #sent)
+      assert.is_nil(ngx.shared.warmup_endpoints:get("app 10.0.0.1:8080"))
+    end)
+  end)
+end)
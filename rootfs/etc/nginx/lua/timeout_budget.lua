@@ -0,0 +1,103 @@
+local ngx_balancer = require("ngx.balancer")
+local ngx = ngx
+local tonumber = tonumber
+local type = type
+local math_min = math.min
+local string_gsub = string.gsub
+local string_lower = string.lower
+local string_match = string.match
+
+local _M = {}
+
+-- units of the grpc-timeout header, in seconds
+-- https://github.com/grpc/grpc/blob/master/doc/PROTOCOL-HTTP2.md
+local GRPC_TIMEOUT_UNITS = {
+  H = 3600,
+  M = 60,
+  S = 1,
+  m = 0.001,
+  u = 0.000001,
+  n = 0.000000001,
+}
+
+-- smallest read timeout set when the budget of the request is almost exhausted
+local MIN_TIMEOUT = 0.001
+
+-- parse returns the budget in seconds of a header value using the format
+-- of the grpc-timeout header (like "100m") or a number of seconds (like "1.5")
+function _M.parse(value)
+  if type(value) ~= "string" then
+    return nil
+  end
+
+  local amount, unit = string_match(value, "^(%d%d?%d?%d?%d?%d?%d?%d?)([HMSmun])$")
+  if amount then
+    return tonumber(amount) * GRPC_TIMEOUT_UNITS[unit]
+  end
+
+  if not string_match(value, "^%d+%.?%d*$") then
+    return nil
+  end
+
+  local seconds = tonumber(value)
+  if not seconds or seconds <= 0 then
+    return nil
+  end
+
+  return seconds
+end
+
+local function header_variable(name)
+  return "http_" .. string_gsub(string_lower(name), "-", "_")
+end
+
+-- rewrite calculates the deadline of the request using the header configured
+-- in the location. Requests whose budget is already exhausted are rejected.
+function _M.rewrite()
+  local header = ngx.var.timeout_budget_header
+  if not header or header == "" then
+    return
+  end
+
+  local budget = _M.parse(ngx.var[header_variable(header)])
+  if not budget then
+    return
+  end
+
+  local deadline = ngx.req.start_time() + budget
+  if deadline <= ngx.now() then
+    ngx.log(ngx.INFO, "timeout budget of the request is exhausted, header: ", header)
+    return ngx.exit(ngx.HTTP_GATEWAY_TIMEOUT)
+  end
+
+  ngx.ctx.timeout_budget_deadline = deadline
+  ngx.ctx.timeout_budget_max = tonumber(ngx.var.timeout_budget_max)
+end
+
+-- apply sets the read timeout of the current try to the remaining budget
+-- of the request, capped by the read timeout of the location
+function _M.apply()
+  local deadline = ngx.ctx.timeout_budget_deadline
+  if not deadline then
+    return
+  end
+
+  ngx.update_time()
+
+  local remaining = deadline - ngx.now()
+  local max = ngx.ctx.timeout_budget_max
+  if max and max > 0 then
+    remaining = math_min(remaining, max)
+  end
+
+  if remaining < MIN_TIMEOUT then
+    remaining = MIN_TIMEOUT
+  end
+
+  local ok, err = ngx_balancer.set_timeouts(nil, nil, remaining)
+  if not ok then
+    ngx.log(ngx.ERR, "error setting the read timeout of the request: ", err)
+  end
+end
+
+return _M
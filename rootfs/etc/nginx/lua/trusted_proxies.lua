@@ -0,0 +1,57 @@
+local ngx = ngx
+local cidr = require("util.cidr")
+
+local _M = {}
+
+-- trusted_proxies returns the comma separated list of the proxies trusted by
+-- the server of the request, or else by the controller, or nil
+local function trusted_proxies(global_trusted_proxies)
+  local server_trusted_proxies = ngx.var.trusted_proxies
+  if server_trusted_proxies and server_trusted_proxies ~= "" then
+    return server_trusted_proxies
+  end
+
+  if global_trusted_proxies and global_trusted_proxies ~= "" then
+    return global_trusted_proxies
+  end
+
+  return nil
+end
+
+-- is_trusted returns true when the forwarded headers of the request are
+-- honored: the peer is one of the trusted proxies when they are defined, or
+-- else the forwarded headers are used
+function _M.is_trusted(use_forwarded_headers, global_trusted_proxies)
+  local proxies = trusted_proxies(global_trusted_proxies)
+  if not proxies then
+    return use_forwarded_headers and true or false
+  end
+
+  return cidr.contains(proxies, ngx.var.realip_remote_addr)
+end
+
+-- rewrite sets the forwarded headers sent to the backend by a location
+-- trusting proxies: the headers of a trusted peer are extended, the headers
+-- of an untrusted peer are replaced or removed
+function _M.rewrite(trusted)
+  -- the variables are only defined by the locations trusting proxies
+  if ngx.var.trusted_x_forwarded_for == nil then
+    return
+  end
+
+  if not trusted then
+    ngx.var.trusted_x_forwarded_for = ngx.var.remote_addr
+    ngx.var.trusted_x_original_forwarded_for = ""
+    ngx.var.trusted_forwarded = ""
+    return
+  end
+
+  local forwarded_for = ngx.var.trusted_x_original_forwarded_for
+  if forwarded_for and forwarded_for ~= "" then
+    ngx.var.trusted_x_forwarded_for = forwarded_for .. ", " .. ngx.var.realip_remote_addr
+  else
+    ngx.var.trusted_x_forwarded_for = ngx.var.realip_remote_addr
+  end
+end
+
+return _M
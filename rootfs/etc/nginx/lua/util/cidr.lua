@@ -0,0 +1,227 @@
+local bit = require("bit")
+
+local ipairs = ipairs
+local tonumber = tonumber
+local math_floor = math.floor
+local string_find = string.find
+local string_gmatch = string.gmatch
+local string_match = string.match
+local string_sub = string.sub
+local band = bit.band
+
+local _M = {}
+
+-- maximum number of the parsed lists of networks kept in the cache
+local MAX_CACHED_LISTS = 1000
+
+local cached_lists = {}
+local cached_lists_count = 0
+
+-- parse_ipv4 returns the 4 bytes of an IPv4 address, or nil
+local function parse_ipv4(address)
+  local a, b, c, d = string_match(address, "^(%d+)%.(%d+)%.(%d+)%.(%d+)$")
+  if not a then
+    return nil
+  end
+
+  local bytes = { tonumber(a), tonumber(b), tonumber(c), tonumber(d) }
+  for _, byte in ipairs(bytes) do
+    if byte > 255 then
+      return nil
+    end
+  end
+  return bytes
+end
+
+-- ipv6_words returns the 16 bits words of a part of an IPv6 address, the
+-- last word possibly written as an IPv4 address, or nil
+local function ipv6_words(part)
+  local words = {}
+  if part == "" then
+    return words
+  end
+
+  for word in string_gmatch(part .. ":", "([^:]*):") do
+    if string_find(word, ".", 1, true) then
+      local ipv4 = parse_ipv4(word)
+      if not ipv4 then
+        return nil
+      end
+      words[#words + 1] = ipv4[1] * 256 + ipv4[2]
+      words[#words + 1] = ipv4[3] * 256 + ipv4[4]
+    elseif string_match(word, "^%x%x?%x?%x?$") then
+      words[#words + 1] = tonumber(word, 16)
+    else
+      return nil
+    end
+  end
+  return words
+end
+
+-- parse_ipv6 returns the 16 bytes of an IPv6 address, or nil
+local function parse_ipv6(address)
+  -- the zone of the link-local addresses is ignored
+  address = string_match(address, "^[^%%]*")
+
+  local head, tail = address, ""
+  local compressed = string_find(address, "::", 1, true)
+  if compressed then
+    head = string_sub(address, 1, compressed - 1)
+    tail = string_sub(address, compressed + 2)
+    if string_find(tail, "::", 1, true) then
+      return nil
+    end
+  end
+
+  local head_words = ipv6_words(head)
+  local tail_words = ipv6_words(tail)
+  if not head_words or not tail_words then
+    return nil
+  end
+
+  local count = #head_words + #tail_words
+  if (compressed and count > 7) or (not compressed and count ~= 8) then
+    return nil
+  end
+
+  local words = head_words
+  for _ = 1, 8 - count do
+    words[#words + 1] = 0
+  end
+  for _, word in ipairs(tail_words) do
+    words[#words + 1] = word
+  end
+
+  local bytes = {}
+  for _, word in ipairs(words) do
+    bytes[#bytes + 1] = math_floor(word / 256)
+    bytes[#bytes + 1] = word % 256
+  end
+  return bytes
+end
+
+-- parse_address returns the bytes of an IPv4 or IPv6 address, the IPv4
+-- addresses mapped to IPv6 being returned as IPv4 addresses, or nil
+local function parse_address(address)
+  if not string_find(address, ":", 1, true) then
+    return parse_ipv4(address)
+  end
+
+  local bytes = parse_ipv6(address)
+  if not bytes then
+    return nil
+  end
+
+  for i = 1, 10 do
+    if bytes[i] ~= 0 then
+      return bytes
+    end
+  end
+  if bytes[11] == 255 and bytes[12] == 255 then
+    return { bytes[13], bytes[14], bytes[15], bytes[16] }
+  end
+  return bytes
+end
+
+-- parse returns the network of a CIDR or of an address, or nil
+function _M.parse(cidr)
+  local address, prefix = string_match(cidr, "^%s*([^/%s]+)/(%d+)%s*$")
+  if not address then
+    address = string_match(cidr, "^%s*([^/%s]+)%s*$")
+    if not address then
+      return nil
+    end
+  end
+
+  local bytes = parse_address(address)
+  if not bytes then
+    return nil
+  end
+
+  local bits = #bytes * 8
+  if prefix then
+    prefix = tonumber(prefix)
+    -- the prefix of an IPv4 network mapped to IPv6 counts the first 96 bits
+    if #bytes == 4 and string_find(address, ":", 1, true) then
+      prefix = prefix - 96
+    end
+    if prefix < 0 or prefix > bits then
+      return nil
+    end
+  else
+    prefix = bits
+  end
+
+  return { bytes = bytes, prefix = prefix }
+end
+
+-- parse_list returns the networks of a comma separated list of CIDRs,
+-- ignoring the invalid CIDRs
+local function parse_list(list)
+  local networks = cached_lists[list]
+  if networks then
+    return networks
+  end
+
+  networks = {}
+  for cidr in string_gmatch(list, "[^,]+") do
+    local network = _M.parse(cidr)
+    if network then
+      networks[#networks + 1] = network
+    end
+  end
+
+  if cached_lists_count >= MAX_CACHED_LISTS then
+    cached_lists = {}
+    cached_lists_count = 0
+  end
+  cached_lists[list] = networks
+  cached_lists_count = cached_lists_count + 1
+
+  return networks
+end
+
+-- matches returns true when the bytes of an address are in a network
+local function matches(bytes, network)
+  if #bytes ~= #network.bytes then
+    return false
+  end
+
+  local bits = network.prefix
+  local i = 1
+  while bits >= 8 do
+    if bytes[i] ~= network.bytes[i] then
+      return false
+    end
+    i = i + 1
+    bits = bits - 8
+  end
+
+  if bits > 0 then
+    local mask = 256 - 2 ^ (8 - bits)
+    return band(bytes[i], mask) == band(network.bytes[i], mask)
+  end
+  return true
+end
+
+-- contains returns true when an address is in one of the networks of a
+-- comma separated list of CIDRs
+function _M.contains(list, address)
+  if not list or not address then
+    return false
+  end
+
+  local bytes = parse_address(address)
+  if not bytes then
+    return false
+  end
+
+  for _, network in ipairs(parse_list(list)) do
+    if matches(bytes, network) then
+      return true
+    end
+  end
+  return false
+end
+
+return _M
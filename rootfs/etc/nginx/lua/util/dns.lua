@@ -11,26 +11,82 @@ local table_insert = table.insert
 local ipairs = ipairs
 local tostring = tostring
 
-local _M = {}
+local stats = ngx.shared.dns_cache_stats
+
+local _M = {
+  -- number of seconds the expired addresses of a host are returned when its
+  -- resolution fails, 0 disables it
+  stale_ttl = 0,
+}
 local CACHE_SIZE = 10000
+-- upper bounds in seconds of the buckets of the histogram of the resolution durations
+local RESOLUTION_BUCKETS = { 0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10 }
 -- maximum value according to https://tools.ietf.org/html/rfc2181
 local MAXIMUM_TTL_VALUE = 2147483647
 -- for every host we will try two queries for the following types with the order set here
 local QTYPES_TO_CHECK = { resolver.TYPE_A, resolver.TYPE_AAAA }
 
-local cache
+local cache, stale_cache
 do
   local err
   cache, err = lrucache.new(CACHE_SIZE)
   if not cache then
     return error("failed to create the cache: " .. (err or "unknown"))
   end
+
+  stale_cache, err = lrucache.new(CACHE_SIZE)
+  if not stale_cache then
+    return error("failed to create the stale cache: " .. (err or "unknown"))
+  end
 end
 
 local function cache_set(host, addresses, ttl)
   cache:set(host, addresses, ttl)
   ngx_log(ngx_INFO, string_format("cache set for '%s' with value of [%s] and ttl of %s.",
     host, table_concat(addresses, ", "), ttl))
+
+  if _M.stale_ttl > 0 then
+    stale_cache:set(host, addresses, ttl + _M.stale_ttl)
+  end
+end
+
+-- the statistics are shared by the workers and returned by stats(), they are
+-- not recorded in the stream subsystem without the shared dictionary
+local function incr_stat(key, value)
+  if not stats then
+    return
+  end
+
+  local _, err = stats:incr(key, value, 0)
+  if err then
+    ngx_log(ngx_ERR, "failed to increment the DNS cache statistic ", key, ": ", err)
+  end
+end
+
+local function observe_resolution(started_at)
+  ngx.update_time()
+  local duration = ngx.now() - started_at
+
+  incr_stat("resolutions", 1)
+  incr_stat("resolution_seconds", duration)
+  for _, bound in ipairs(RESOLUTION_BUCKETS) do
+    if duration <= bound then
+      incr_stat("resolution_bucket:" .. bound, 1)
+    end
+  end
+end
+
+-- returns the expired addresses of a host when its resolution failed
+local function stale_addresses(cache_key, host)
+  local addresses = stale_cache:get(cache_key)
+  if not addresses then
+    return { host }
+  end
+
+  incr_stat("stale", 1)
+  ngx_log(ngx_INFO, string_format("returning the stale value [%s] of '%s'.",
+    table_concat(addresses, ", "), cache_key))
+  return addresses
 end
 
 local function is_fully_qualified(host)
@@ -88,24 +144,47 @@ local function resolve_host(r, host)
   return nil, nil, dns_errors
 end
 
-function _M.lookup(host)
-  local cached_addresses = cache:get(host)
+-- options.nameservers overrides the nameservers of /etc/resolv.conf
+-- options.ttl, when greater than 0, overrides the TTL of the DNS answers
+function _M.lookup(host, options)
+  options = options or {}
+
+  local nameservers = resolv_conf.nameservers
+  local cache_key = host
+  if options.nameservers and #options.nameservers > 0 then
+    nameservers = options.nameservers
+    cache_key = string_format("%s@%s", host, table_concat(nameservers, ","))
+  end
+
+  local cached_addresses = cache:get(cache_key)
   if cached_addresses then
+    incr_stat("hits", 1)
     return cached_addresses
   end
+  incr_stat("misses", 1)
 
   local r, err = resolver:new{
-    nameservers = resolv_conf.nameservers,
+    nameservers = nameservers,
     retrans = 5,
     timeout = 2000,  -- 2 sec
   }
 
   if not r then
     ngx_log(ngx_ERR, string_format("failed to instantiate the resolver: %s", err))
-    return { host }
+    incr_stat("errors", 1)
+    return stale_addresses(cache_key, host)
   end
 
+  ngx.update_time()
+  local started_at = ngx.now()
+
   local addresses, ttl, dns_errors
+  local function cache_addresses()
+    if options.ttl and options.ttl > 0 then
+      ttl = options.ttl
+    end
+    cache_set(cache_key, addresses, ttl)
+  end
 
   -- when the queried domain is fully qualified
   -- then we don't go through resolv_conf.search
@@ -113,15 +192,17 @@ function _M.lookup(host)
   -- with K8s 1.15: https://github.com/kubernetes/kubernetes/pull/78385
   if is_fully_qualified(host) then
     addresses, ttl, dns_errors = resolve_host(r, host)
+    observe_resolution(started_at)
     if addresses then
-      cache_set(host, addresses, ttl)
+      cache_addresses()
       return addresses
     end
 
     ngx_log(ngx_ERR, "failed to query the DNS server for ",
       host, ":\n", table_concat(dns_errors, "\n"))
 
-    return { host }
+    incr_stat("errors", 1)
+    return stale_addresses(cache_key, host)
   end
 
   -- for non fully qualified domains if number of dots in
@@ -143,19 +224,41 @@ function _M.lookup(host)
 
     addresses, ttl, dns_errors = resolve_host(r, new_host)
     if addresses then
-      cache_set(host, addresses, ttl)
+      observe_resolution(started_at)
+      cache_addresses()
       return addresses
     end
   end
+  observe_resolution(started_at)
 
   if #dns_errors > 0 then
     ngx_log(ngx_ERR, "failed to query the DNS server for ",
       host, ":\n", table_concat(dns_errors, "\n"))
   end
 
-  return { host }
+  incr_stat("errors", 1)
+  return stale_addresses(cache_key, host)
+end
+
+-- returns the cumulative number of lookups of the cache and the histogram of
+-- the durations of the resolutions
+function _M.stats()
+  local buckets = {}
+  for _, bound in ipairs(RESOLUTION_BUCKETS) do
+    buckets[tostring(bound)] = stats:get("resolution_bucket:" .. bound) or 0
+  end
+
+  return {
+    hits = stats:get("hits") or 0,
+    misses = stats:get("misses") or 0,
+    stale = stats:get("stale") or 0,
+    errors = stats:get("errors") or 0,
+    resolutions = stats:get("resolutions") or 0,
+    resolution_seconds = stats:get("resolution_seconds") or 0,
+    resolution_buckets = buckets,
+  }
 end
 
-setmetatable(_M, {__index = { _cache = cache }})
+setmetatable(_M, {__index = { _cache = cache, _stale_cache = stale_cache }})
 
 return _M
@@ -0,0 +1,67 @@
+local ngx = ngx
+local string = string
+local tostring = tostring
+local type = type
+local cjson = require("cjson.safe")
+
+local _M = {}
+
+-- decodes a base64url encoded segment of a JWT
+local function decode_segment(segment)
+  local padding = #segment % 4
+  if padding > 0 then
+    segment = segment .. string.rep("=", 4 - padding)
+  end
+
+  segment = string.gsub(segment, "%-", "+")
+  segment = string.gsub(segment, "_", "/")
+  return ngx.decode_base64(segment)
+end
+
+-- returns the claims of a JWT, or nil when the token is invalid.
+-- The signature of the token is not verified.
+function _M.claims(token)
+  if type(token) ~= "string" then
+    return nil
+  end
+
+  local payload = string.match(token, "^[%w%-_]+%.([%w%-_]+)%.[%w%-_]*$")
+  if not payload then
+    return nil
+  end
+
+  local decoded = decode_segment(payload)
+  if not decoded then
+    return nil
+  end
+
+  local claims = cjson.decode(decoded)
+  if type(claims) ~= "table" then
+    return nil
+  end
+
+  return claims
+end
+
+-- returns the value of a claim of the JWT sent as bearer token in the
+-- Authorization header, or an empty string when it is not present.
+-- The signature of the token is not verified.
+function _M.claim(authorization, name)
+  if type(authorization) ~= "string" then
+    return ""
+  end
+
+  local claims = _M.claims(string.match(authorization, "^[Bb]earer%s+(%S+)$"))
+  if not claims then
+    return ""
+  end
+
+  local value = claims[name]
+  if value == nil or type(value) == "table" then
+    return ""
+  end
+
+  return tostring(value)
+end
+
+return _M
@@ -0,0 +1,165 @@
+local ngx = ngx
+local ipairs = ipairs
+local setmetatable = setmetatable
+local tostring = tostring
+local string_find = string.find
+local string_match = string.match
+
+local _M = {}
+
+-- seconds an endpoint is known as warmed up by the workers, long enough for
+-- every worker to add it to the load balancing of its backend
+local WARMED_TTL = 600
+
+local USER_AGENT = "ingress-nginx-warmup"
+
+-- endpoints of the backends in the load balancing of the worker, by backend
+-- name and "address:port"
+local balanced_endpoints = {}
+
+local function endpoint_key(backend_name, endpoint)
+  return backend_name .. " " .. endpoint.address .. ":" .. tostring(endpoint.port)
+end
+
+-- host returns the address of an endpoint as written in a URL
+local function host(address)
+  if string_find(address, ":", 1, true) and not string_find(address, "[", 1, true) then
+    return "[" .. address .. "]"
+  end
+  return address
+end
+
+-- request sends a warm-up request to an endpoint and returns the status code
+-- of the response, or nil and an error
+local function request(endpoint, config)
+  local sock = ngx.socket.tcp()
+  local timeout = (config.timeout or 5) * 1000
+  sock:settimeouts(timeout, timeout, timeout)
+
+  local address = host(endpoint.address)
+  local ok, err = sock:connect(address, endpoint.port)
+  if not ok then
+    return nil, err
+  end
+
+  local _
+  _, err = sock:send("GET " .. config.path .. " HTTP/1.1\r\n" ..
+                     "Host: " .. address .. "\r\n" ..
+                     "User-Agent: " .. USER_AGENT .. "\r\n" ..
+                     "Connection: close\r\n\r\n")
+  if err then
+    sock:close()
+    return nil, err
+  end
+
+  local line
+  line, err = sock:receive("*l")
+  sock:close()
+  if not line then
+    return nil, err
+  end
+
+  local status = string_match(line, "^HTTP/[%d%.]+ (%d%d%d)")
+  if not status then
+    return nil, "invalid status line"
+  end
+  return status
+end
+
+-- warm_up sends the warm-up requests to an endpoint, then records it as
+-- warmed up. The endpoint is warmed up even when the requests fail, to be
+-- load balanced like the endpoints without warm-up.
+local function warm_up(premature, backend_name, endpoint, config)
+  if premature then
+    return
+  end
+
+  for i = 1, config.requests or 1 do
+    local status, err = request(endpoint, config)
+    if not status then
+      ngx.log(ngx.WARN, "warm-up request ", i, " to the endpoint ", endpoint.address, ":",
+              endpoint.port, " of the backend ", backend_name, " failed: ", err)
+    end
+  end
+
+  local ok, err = ngx.shared.warmup_endpoints:set(endpoint_key(backend_name, endpoint),
+                                                  true, WARMED_TTL)
+  if not ok then
+    ngx.log(ngx.ERR, "error recording the warm-up of the endpoint ", endpoint.address, ":",
+            endpoint.port, " of the backend ", backend_name, ": ", err)
+    return
+  end
+  ngx.log(ngx.INFO, "endpoint ", endpoint.address, ":", endpoint.port, " of the backend ",
+          backend_name, " warmed up")
+end
+
+-- start_warm_up starts the warm-up of an endpoint, unless a worker is
+-- already warming it up
+local function start_warm_up(backend_name, endpoint, config)
+  local key = endpoint_key(backend_name, endpoint)
+  local ttl = (config.requests or 1) * (config.timeout or 5) * 3 + 1
+  local ok = ngx.shared.warmup_endpoints:add("warming " .. key, true, ttl)
+  if not ok then
+    return
+  end
+
+  -- the balancer formats the addresses of the endpoints after their warm-up
+  -- is started
+  local err
+  ok, err = ngx.timer.at(0, warm_up, backend_name,
+                         { address = endpoint.address, port = endpoint.port }, config)
+  if not ok then
+    ngx.log(ngx.ERR, "failed to create the warm-up timer: ", err)
+  end
+end
+
+local function is_warmed_up(backend_name, endpoint)
+  return ngx.shared.warmup_endpoints:get(endpoint_key(backend_name, endpoint)) ~= nil
+end
+
+-- endpoints returns the endpoints of a backend load balanced by the worker,
+-- and true when the other endpoints are being warmed up. The endpoints of a
+-- backend synced for the first time are all load balanced, as are the new
+-- endpoints of a backend with no warmed up endpoint.
+function _M.endpoints(backend)
+  local config = backend.warmup
+  if not config or not config.path or config.path == "" then
+    balanced_endpoints[backend.name] = nil
+    return backend.endpoints, false
+  end
+
+  local previous = balanced_endpoints[backend.name]
+  local balanced, endpoints, warming = {}, {}, false
+  for _, endpoint in ipairs(backend.endpoints) do
+    local key = endpoint.address .. ":" .. tostring(endpoint.port)
+    if not previous or previous[key] or is_warmed_up(backend.name, endpoint) then
+      balanced[key] = true
+      endpoints[#endpoints + 1] = endpoint
+    else
+      start_warm_up(backend.name, endpoint, config)
+      warming = true
+    end
+  end
+
+  if #endpoints == 0 then
+    for _, endpoint in ipairs(backend.endpoints) do
+      balanced[endpoint.address .. ":" .. tostring(endpoint.port)] = true
+    end
+    balanced_endpoints[backend.name] = balanced
+    return backend.endpoints, false
+  end
+
+  balanced_endpoints[backend.name] = balanced
+  return endpoints, warming
+end
+
+-- forget forgets the endpoints of a backend removed from the worker
+function _M.forget(backend_name)
+  balanced_endpoints[backend_name] = nil
+end
+
+setmetatable(_M, {__index = {
+  warm_up = warm_up,
+}})
+
+return _M
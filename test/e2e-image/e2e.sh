@@ -21,6 +21,7 @@ BGREEN='\e[32m'
 
 E2E_NODES=${E2E_NODES:-5}
 E2E_CHECK_LEAKS=${E2E_CHECK_LEAKS:-""}
+E2E_STRESS=${E2E_STRESS:-""}
 
 reportFile="report-e2e-test-suite.xml"
 ginkgo_args=(
@@ -43,6 +44,10 @@ if [ -z "${E2E_CHECK_LEAKS}" ]; then
   ginkgo_args+=("--skip=\[Memory Leak\]")
 fi
 
+if [ -z "${E2E_STRESS}" ]; then
+  ginkgo_args+=("--skip=\[Stress\]")
+fi
+
 echo -e "${BGREEN}Running e2e test suite...${NC}"
 (set -x; ginkgo "${ginkgo_args[@]}" /e2e.test)
 
@@ -52,6 +52,7 @@ import (
 	_ "k8s.io/ingress-nginx/test/e2e/settings/validations"
 	_ "k8s.io/ingress-nginx/test/e2e/ssl"
 	_ "k8s.io/ingress-nginx/test/e2e/status"
+	_ "k8s.io/ingress-nginx/test/e2e/stress"
 	_ "k8s.io/ingress-nginx/test/e2e/tcpudp"
 )
 
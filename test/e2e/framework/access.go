@@ -0,0 +1,235 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package framework
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/onsi/ginkgo/v2"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// NginxAccessEnv is the environment variable selecting how the tests
+	// reach NGINX: cluster-ip (default), node-port or port-forward
+	NginxAccessEnv = "E2E_NGINX_ACCESS"
+	// NodeHostEnv is the environment variable containing the host of the
+	// nodes of the cluster, used with the node-port access
+	NodeHostEnv = "E2E_NODE_HOST"
+
+	// nginxServiceName is the name of the Service of the ingress controller
+	nginxServiceName = "nginx-ingress-controller"
+)
+
+const (
+	// accessClusterIP reaches NGINX using the cluster IP of its Service,
+	// when the tests run inside the cluster
+	accessClusterIP = "cluster-ip"
+	// accessNodePort reaches NGINX using the node ports of its Service on
+	// the host defined in E2E_NODE_HOST
+	accessNodePort = "node-port"
+	// accessPortForward reaches NGINX forwarding local ports to the pod
+	// with kubectl port-forward
+	accessPortForward = "port-forward"
+)
+
+var portForwardRegexp = regexp.MustCompile(`Forwarding from 127\.0\.0\.1:(\d+) ->`)
+
+// nginxAccess returns how the tests reach NGINX
+func nginxAccess() string {
+	access := os.Getenv(NginxAccessEnv)
+	if access == "" {
+		return accessClusterIP
+	}
+
+	return access
+}
+
+// GetNginxAddress returns the address (host:port) used to reach the port
+// of the Service of the ingress controller, according to E2E_NGINX_ACCESS
+func (f *Framework) GetNginxAddress(port int) string {
+	switch access := nginxAccess(); access {
+	case accessClusterIP:
+		return net.JoinHostPort(f.GetNginxIP(), strconv.Itoa(port))
+	case accessNodePort:
+		host := os.Getenv(NodeHostEnv)
+		assert.NotEmpty(ginkgo.GinkgoT(), host, "%v must be set to use the access %v", NodeHostEnv, access)
+
+		servicePort := f.getNginxServicePort(port)
+		assert.NotZero(ginkgo.GinkgoT(), servicePort.NodePort, "the port %v of the service %v has no node port", port, nginxServiceName)
+
+		return net.JoinHostPort(host, strconv.Itoa(int(servicePort.NodePort)))
+	case accessPortForward:
+		localPort, err := f.forwardPort(f.getNginxTargetPort(port))
+		assert.Nil(ginkgo.GinkgoT(), err, "forwarding port %v of the ingress controller pod", port)
+
+		return net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort))
+	default:
+		assert.Fail(ginkgo.GinkgoT(), fmt.Sprintf("unsupported value %q of %v", access, NginxAccessEnv))
+		return ""
+	}
+}
+
+// getNginxServicePort returns the port of the Service of the ingress controller
+func (f *Framework) getNginxServicePort(port int) v1.ServicePort {
+	s, err := f.KubeClientSet.
+		CoreV1().
+		Services(f.Namespace).
+		Get(context.TODO(), nginxServiceName, metav1.GetOptions{})
+	assert.Nil(ginkgo.GinkgoT(), err, "obtaining NGINX service")
+
+	for _, sp := range s.Spec.Ports {
+		if int(sp.Port) == port {
+			return sp
+		}
+	}
+
+	assert.Fail(ginkgo.GinkgoT(), fmt.Sprintf("the service %v has no port %v", nginxServiceName, port))
+	return v1.ServicePort{}
+}
+
+// getNginxTargetPort returns the port of the ingress controller pod
+// targeted by a port of its Service
+func (f *Framework) getNginxTargetPort(port int) int {
+	s, err := f.KubeClientSet.
+		CoreV1().
+		Services(f.Namespace).
+		Get(context.TODO(), nginxServiceName, metav1.GetOptions{})
+	if err != nil {
+		return port
+	}
+
+	for _, sp := range s.Spec.Ports {
+		if int(sp.Port) != port {
+			continue
+		}
+
+		if sp.TargetPort.IntValue() > 0 {
+			return sp.TargetPort.IntValue()
+		}
+
+		for i := range f.pod.Spec.Containers {
+			for _, cp := range f.pod.Spec.Containers[i].Ports {
+				if cp.Name == sp.TargetPort.String() {
+					return int(cp.ContainerPort)
+				}
+			}
+		}
+	}
+
+	return port
+}
+
+// portForward is a local port forwarded to a port of the ingress controller pod
+type portForward struct {
+	cmd       *exec.Cmd
+	localPort int
+	// done is closed when kubectl exits
+	done chan struct{}
+}
+
+func (pf *portForward) running() bool {
+	select {
+	case <-pf.done:
+		return false
+	default:
+		return true
+	}
+}
+
+// forwardPort forwards a local port to a port of the ingress controller pod
+// and returns the local port. The forwards are reused until the pod changes
+// and stopped after each test.
+func (f *Framework) forwardPort(port int) (int, error) {
+	key := fmt.Sprintf("%v/%v:%v", f.pod.Namespace, f.pod.Name, port)
+	if pf, ok := f.portForwards[key]; ok && pf.running() {
+		return pf.localPort, nil
+	}
+
+	//nolint:gosec // Ignore G204 error
+	cmd := exec.Command(KubectlPath, "port-forward", "--namespace", f.pod.Namespace, "pod/"+f.pod.Name, fmt.Sprintf(":%v", port))
+	cmd.Stderr = io.Discard
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return -1, err
+	}
+
+	Logf("Asynchronously running '%s'", strings.Join(cmd.Args, " "))
+	if err := cmd.Start(); err != nil {
+		return -1, err
+	}
+
+	pf := &portForward{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+
+	reader := bufio.NewReader(stdout)
+	for pf.localPort == 0 {
+		line, err := reader.ReadString('\n')
+		if match := portForwardRegexp.FindStringSubmatch(line); len(match) == 2 {
+			pf.localPort, _ = strconv.Atoi(match[1])
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	// kubectl writes a line for each connection, the output is drained to not block it
+	go func() {
+		//nolint:errcheck // the output is discarded
+		io.Copy(io.Discard, reader)
+		//nolint:errcheck // the exit is detected using done
+		cmd.Wait()
+		close(pf.done)
+	}()
+
+	if pf.localPort == 0 {
+		//nolint:errcheck // the command failed already
+		cmd.Process.Kill()
+		return -1, fmt.Errorf("failed to parse port from kubectl port-forward output")
+	}
+
+	if f.portForwards == nil {
+		f.portForwards = map[string]*portForward{}
+	}
+	f.portForwards[key] = pf
+
+	return pf.localPort, nil
+}
+
+// stopPortForwards stops the port forwards started by the test
+func (f *Framework) stopPortForwards() {
+	for key, pf := range f.portForwards {
+		if pf.running() {
+			//nolint:errcheck // the process may have exited already
+			pf.cmd.Process.Kill()
+		}
+		delete(f.portForwards, key)
+	}
+}
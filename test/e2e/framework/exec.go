@@ -28,6 +28,7 @@ import (
 	"strings"
 
 	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
 )
 
 // GetLbAlgorithm returns algorithm identifier for the given backend
@@ -117,7 +118,15 @@ func (f *Framework) newIngressController(namespace, namespaceOverlay string) err
 		isChroot = "false"
 	}
 
-	cmd := exec.Command("./wait-for-nginx.sh", namespace, namespaceOverlay, isChroot)
+	valuesFile, err := f.controllerValuesFile()
+	if err != nil {
+		return err
+	}
+	if valuesFile != "" {
+		defer os.Remove(valuesFile)
+	}
+
+	cmd := exec.Command("./wait-for-nginx.sh", namespace, namespaceOverlay, isChroot, valuesFile)
 	out, err := cmd.CombinedOutput()
 	if err != nil {
 		return fmt.Errorf("unexpected error waiting for ingress controller deployment: %v.\nLogs:\n%v", err, string(out))
@@ -126,6 +135,37 @@ func (f *Framework) newIngressController(namespace, namespaceOverlay string) err
 	return nil
 }
 
+// controllerValuesFile writes the values of the Helm chart setting the
+// additional flags of the ingress controller, and returns the path of the
+// file or an empty string when there are no additional flags
+func (f *Framework) controllerValuesFile() (string, error) {
+	if len(f.ControllerArgs) == 0 {
+		return "", nil
+	}
+
+	values, err := yaml.Marshal(map[string]interface{}{
+		"controller": map[string]interface{}{
+			"extraArgs": f.ControllerArgs,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	file, err := os.CreateTemp("", "ingress-nginx-values-*.yaml")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(values); err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
 var proxyRegexp = regexp.MustCompile(`Starting to serve on .*:(\d+)`)
 
 // KubectlProxy creates a proxy to kubernetes apiserver
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -72,6 +73,14 @@ type Framework struct {
 	// is used extensively
 	HTTPBunIP      string
 	HTTPBunEnabled bool
+
+	// ControllerArgs are the additional flags, without the leading dashes,
+	// of the ingress controller deployed for each spec
+	ControllerArgs map[string]string
+
+	// portForwards contains the local ports forwarded to the ingress
+	// controller pod when E2E_NGINX_ACCESS is port-forward
+	portForwards map[string]*portForward
 }
 
 // WithHTTPBunEnabled deploys an instance of HTTPBun for the specific test
@@ -81,6 +90,17 @@ func WithHTTPBunEnabled() func(*Framework) {
 	}
 }
 
+// WithControllerArgs deploys the ingress controller of each spec with
+// additional flags, like {"disable-catch-all": "true"}. Each controller
+// only watches the namespace of its spec and uses a leader election lock
+// in that namespace, so the specs using different flags run in parallel
+// without updating the deployment of the controller.
+func WithControllerArgs(args map[string]string) func(*Framework) {
+	return func(f *Framework) {
+		f.ControllerArgs = args
+	}
+}
+
 // NewDefaultFramework makes a new framework and sets up a BeforeEach/AfterEach for
 // you (you can write additional before/after each functions).
 func NewDefaultFramework(baseName string, opts ...func(*Framework)) *Framework {
@@ -169,6 +189,7 @@ func (f *Framework) BeforeEach() {
 // AfterEach deletes the namespace, after reading its events.
 func (f *Framework) AfterEach() {
 	defer f.DestroyEnvironment()
+	defer f.stopPortForwards()
 
 	defer func(kubeClient kubernetes.Interface, ingressclass string) {
 		defer ginkgo.GinkgoRecover()
@@ -250,8 +271,18 @@ func (f *Framework) GetNginxPodIP() string {
 
 // GetURL returns the URL should be used to make a request to NGINX
 func (f *Framework) GetURL(requestScheme RequestScheme) string {
-	ip := f.GetNginxIP()
-	return fmt.Sprintf("%v://%v", requestScheme, ip)
+	port := 80
+	if requestScheme == HTTPS {
+		port = 443
+	}
+
+	address := f.GetNginxAddress(port)
+	// the default port of the scheme is omitted
+	if host, p, err := net.SplitHostPort(address); err == nil && p == strconv.Itoa(port) {
+		address = host
+	}
+
+	return fmt.Sprintf("%v://%v", requestScheme, address)
 }
 
 // GetIngressNGINXPod returns the ingress controller running pod
@@ -267,10 +298,12 @@ func (f *Framework) updateIngressNGINXPod() error {
 }
 
 // WaitForNginxServer waits until the nginx configuration contains a particular server section.
+// The server section is only read once the server is in the running configuration returned
+// by the /configuration endpoint of the controller, and is empty when it is not.
 // `cfg` passed to matcher is normalized by replacing all tabs and spaces with single space.
 func (f *Framework) WaitForNginxServer(name string, matcher func(cfg string) bool) {
 	//nolint:staticcheck // TODO: will replace it since wait.Poll is deprecated
-	err := wait.Poll(Poll, DefaultTimeout, f.matchNginxConditions(name, matcher))
+	err := wait.Poll(Poll, DefaultTimeout, f.matchNginxServerConditions(name, matcher))
 	assert.Nil(ginkgo.GinkgoT(), err, "waiting for nginx server condition/s")
 	Sleep(1 * time.Second)
 }
@@ -279,7 +312,7 @@ func (f *Framework) WaitForNginxServer(name string, matcher func(cfg string) boo
 // `cfg` passed to matcher is normalized by replacing all tabs and spaces with single space.
 func (f *Framework) WaitForNginxConfiguration(matcher func(cfg string) bool) {
 	//nolint:staticcheck // TODO: will replace it since wait.Poll is deprecated
-	err := wait.Poll(Poll, DefaultTimeout, f.matchNginxConditions("", matcher))
+	err := wait.Poll(Poll, DefaultTimeout, f.matchNginxConditions(matcher))
 	assert.Nil(ginkgo.GinkgoT(), err, "waiting for nginx server condition/s")
 	Sleep(1 * time.Second)
 }
@@ -309,16 +342,73 @@ func (f *Framework) NginxLogs() (string, error) {
 	return "", fmt.Errorf("no nginx ingress controller pod is running (logs)")
 }
 
-func (f *Framework) matchNginxConditions(name string, matcher func(cfg string) bool) wait.ConditionFunc {
+func (f *Framework) matchNginxServerConditions(name string, matcher func(cfg string) bool) wait.ConditionFunc {
+	if name == "" {
+		return f.matchNginxConditions(matcher)
+	}
+
 	return func() (bool, error) {
-		var cmd string
-		if name == "" {
-			cmd = "cat /etc/nginx/nginx.conf"
-		} else {
-			cmd = fmt.Sprintf("cat /etc/nginx/nginx.conf | awk '/## start server %v/,/## end server %v/'", name, name)
+		found, err := f.runningServerExists(name)
+		if err != nil {
+			return false, nil
 		}
 
-		o, err := f.ExecCommand(f.pod, cmd)
+		var block string
+		if found {
+			o, err := f.ExecCommand(f.pod, "cat /etc/nginx/nginx.conf")
+			if err != nil {
+				return false, nil
+			}
+
+			block = serverBlock(o, name)
+			if klog.V(10).Enabled() && block != "" {
+				klog.InfoS("NGINX", "server", block)
+			}
+		}
+
+		// passes the nginx server section to the passed function
+		return matcher(strings.Join(strings.Fields(block), " ")), nil
+	}
+}
+
+// runningServerExists checks if the running configuration of the ingress
+// controller contains a server, using the /configuration endpoint through
+// the proxy of the API server
+func (f *Framework) runningServerExists(name string) (bool, error) {
+	_, err := f.KubeClientSet.
+		CoreV1().
+		Pods(f.pod.Namespace).
+		ProxyGet("http", f.pod.Name, "10254", "/configuration/servers/"+name, nil).
+		DoRaw(context.TODO())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// serverBlock returns the section of a server in nginx.conf, including the
+// comments delimiting it
+func serverBlock(conf, name string) string {
+	startMarker := fmt.Sprintf("## start server %v\n", name)
+	endMarker := fmt.Sprintf("## end server %v\n", name)
+
+	start := strings.Index(conf, startMarker)
+	if start < 0 {
+		return ""
+	}
+
+	end := strings.Index(conf[start:], endMarker)
+	if end < 0 {
+		return ""
+	}
+
+	return conf[start : start+end+len(endMarker)]
+}
+
+func (f *Framework) matchNginxConditions(matcher func(cfg string) bool) wait.ConditionFunc {
+	return func() (bool, error) {
+		o, err := f.ExecCommand(f.pod, "cat /etc/nginx/nginx.conf")
 		if err != nil {
 			return false, nil
 		}
@@ -561,10 +651,9 @@ func (f *Framework) WaitForNginxListening(port int) {
 	})
 	assert.Nil(ginkgo.GinkgoT(), err, "waiting for ingress pods to be ready")
 
-	podIP := f.GetNginxIP()
+	hostPort := f.GetNginxAddress(port)
 	//nolint:staticcheck // TODO: will replace it since wait.Poll is deprecated
 	err = wait.Poll(500*time.Millisecond, DefaultTimeout, func() (bool, error) {
-		hostPort := net.JoinHostPort(podIP, fmt.Sprintf("%v", port))
 		conn, err := net.Dial("tcp", hostPort)
 		if err != nil {
 			return false, nil
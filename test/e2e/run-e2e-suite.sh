@@ -77,9 +77,13 @@ kubectl run --rm \
   --restart=Never \
   --env="E2E_NODES=${E2E_NODES}" \
   --env="FOCUS=${FOCUS}" \
+  --env="E2E_NGINX_ACCESS=${E2E_NGINX_ACCESS:-}" \
+  --env="E2E_NODE_HOST=${E2E_NODE_HOST:-}" \
   --env="IS_CHROOT=${IS_CHROOT:-false}"\
   --env="SKIP_OPENTELEMETRY_TESTS=${SKIP_OPENTELEMETRY_TESTS:-false}"\
   --env="E2E_CHECK_LEAKS=${E2E_CHECK_LEAKS}" \
+  --env="E2E_STRESS=${E2E_STRESS:-}" \
+  --env="E2E_STRESS_DURATION=${E2E_STRESS_DURATION:-}" \
   --env="NGINX_BASE_IMAGE=${NGINX_BASE_IMAGE}" \
   --env="HTTPBUN_IMAGE=${HTTPBUN_IMAGE}" \
   --overrides='{ "apiVersion": "v1", "spec":{"serviceAccountName": "ingress-nginx-e2e"}}' \
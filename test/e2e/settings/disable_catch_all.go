@@ -17,34 +17,23 @@ limitations under the License.
 package settings
 
 import (
-	"context"
 	"net/http"
 	"strings"
 
 	"github.com/onsi/ginkgo/v2"
 	"github.com/stretchr/testify/assert"
-	appsv1 "k8s.io/api/apps/v1"
 	networking "k8s.io/api/networking/v1"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/ingress-nginx/test/e2e/framework"
 )
 
 var _ = framework.IngressNginxDescribe("[Flag] disable-catch-all", func() {
-	f := framework.NewDefaultFramework("disabled-catch-all")
+	f := framework.NewDefaultFramework("disabled-catch-all", framework.WithControllerArgs(map[string]string{
+		"disable-catch-all": "true",
+	}))
 
 	ginkgo.BeforeEach(func() {
 		f.NewEchoDeployment(framework.WithDeploymentReplicas(1))
-
-		err := f.UpdateIngressControllerDeployment(func(deployment *appsv1.Deployment) error {
-			args := deployment.Spec.Template.Spec.Containers[0].Args
-			args = append(args, "--disable-catch-all=true")
-			deployment.Spec.Template.Spec.Containers[0].Args = args
-			_, err := f.KubeClientSet.AppsV1().Deployments(f.Namespace).Update(context.TODO(), deployment, metav1.UpdateOptions{})
-
-			return err
-		})
-		assert.Nil(ginkgo.GinkgoT(), err, "updating ingress controller deployment flags")
 	})
 
 	ginkgo.It("should ignore catch all Ingress with backend", func() {
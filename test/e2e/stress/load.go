@@ -0,0 +1,184 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stress
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// loadResult contains the outcome of the requests sent by a load generator
+type loadResult struct {
+	// Requests is the number of requests sent
+	Requests int
+	// Errors is the number of requests failed without a response, like
+	// connections reset or closed while NGINX reloads
+	Errors int
+	// ServerErrors is the number of responses with a 5xx status code
+	ServerErrors int
+	// latencies of the requests with a response
+	latencies []time.Duration
+}
+
+// ErrorRatio returns the ratio of the requests failed or answered with a 5xx
+// status code
+func (r *loadResult) ErrorRatio() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+
+	return float64(r.Errors+r.ServerErrors) / float64(r.Requests)
+}
+
+// Percentile returns the latency below which the percentage p of the
+// responses were received
+func (r *loadResult) Percentile(p float64) time.Duration {
+	if len(r.latencies) == 0 {
+		return 0
+	}
+
+	sorted := make([]time.Duration, len(r.latencies))
+	copy(sorted, r.latencies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	index := int(float64(len(sorted))*p/100+0.5) - 1
+	if index < 0 {
+		index = 0
+	}
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+
+	return sorted[index]
+}
+
+func (r *loadResult) String() string {
+	return fmt.Sprintf("requests: %v, errors: %v, 5xx: %v, error ratio: %.5f, p50: %v, p99: %v",
+		r.Requests, r.Errors, r.ServerErrors, r.ErrorRatio(), r.Percentile(50), r.Percentile(99))
+}
+
+func (r *loadResult) merge(other *loadResult) {
+	r.Requests += other.Requests
+	r.Errors += other.Errors
+	r.ServerErrors += other.ServerErrors
+	r.latencies = append(r.latencies, other.latencies...)
+}
+
+// loadGenerator sends requests to a host of NGINX with long-lived keep-alive
+// connections, so the connections opened before a reload are used while the
+// old workers shut down
+type loadGenerator struct {
+	url     string
+	host    string
+	workers int
+	// interval between the requests of each worker
+	interval time.Duration
+
+	client *http.Client
+}
+
+func newLoadGenerator(url, host string, workers int, interval time.Duration) *loadGenerator {
+	return &loadGenerator{
+		url:      url,
+		host:     host,
+		workers:  workers,
+		interval: interval,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+			Transport: &http.Transport{
+				MaxIdleConns:        workers,
+				MaxIdleConnsPerHost: workers,
+				IdleConnTimeout:     5 * time.Minute,
+			},
+		},
+	}
+}
+
+// Run sends requests until the context is done and returns the result
+func (g *loadGenerator) Run(ctx context.Context) *loadResult {
+	results := make([]*loadResult, g.workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < g.workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = g.worker(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	g.client.CloseIdleConnections()
+
+	result := &loadResult{}
+	for _, r := range results {
+		result.merge(r)
+	}
+
+	return result
+}
+
+func (g *loadGenerator) worker(ctx context.Context) *loadResult {
+	result := &loadResult{}
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return result
+		case <-ticker.C:
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.url, http.NoBody)
+		if err != nil {
+			result.Requests++
+			result.Errors++
+			continue
+		}
+		req.Host = g.host
+
+		start := time.Now()
+		resp, err := g.client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				// the test finished while the request was in flight
+				return result
+			}
+
+			result.Requests++
+			result.Errors++
+			continue
+		}
+
+		//nolint:errcheck // the body is drained to reuse the connection
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		result.Requests++
+		result.latencies = append(result.latencies, time.Since(start))
+		if resp.StatusCode >= http.StatusInternalServerError {
+			result.ServerErrors++
+		}
+	}
+}
@@ -0,0 +1,258 @@
+/*
+Copyright 2024 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package stress
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/onsi/ginkgo/v2"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/ingress-nginx/test/e2e/framework"
+)
+
+const (
+	// DurationEnv is the environment variable containing the duration of the
+	// stress tests, like 30m. The default is defaultDuration.
+	DurationEnv = "E2E_STRESS_DURATION"
+
+	defaultDuration = 10 * time.Minute
+
+	// hostCount is the number of hosts churned by the mutators
+	hostCount = 300
+	// mutators is the number of concurrent mutators
+	mutators = 10
+	// mutationInterval is the interval between the mutations of each mutator
+	mutationInterval = 500 * time.Millisecond
+
+	// loadWorkers is the number of concurrent connections of the load generator
+	loadWorkers = 20
+	// loadInterval is the interval between the requests of each connection
+	loadInterval = 50 * time.Millisecond
+
+	// maxErrorRatio is the error budget: the maximum ratio of requests
+	// failed or answered with a 5xx status code
+	maxErrorRatio = 0.001
+	// maxP99Latency is the maximum 99th percentile of the latency
+	maxP99Latency = 1 * time.Second
+
+	stableHost = "stress.foo.com"
+)
+
+var _ = framework.IngressNginxDescribe("[Stress] Reload", func() {
+	f := framework.NewDefaultFramework("stress-reload")
+
+	ginkgo.BeforeEach(func() {
+		f.NewEchoDeployment(framework.WithDeploymentReplicas(2))
+	})
+
+	ginkgo.It("should not drop connections while hundreds of ingresses and secrets change", func() {
+		duration := stressDuration()
+
+		f.EnsureIngress(framework.NewSingleIngress(stableHost, "/", stableHost, f.Namespace, framework.EchoService, 80, nil))
+		f.WaitForNginxServer(stableHost,
+			func(server string) bool {
+				return strings.Contains(server, fmt.Sprintf("server_name %v", stableHost))
+			})
+
+		reloadsBefore := reloadCount(f)
+
+		ctx, cancel := context.WithTimeout(context.Background(), duration)
+		defer cancel()
+
+		ginkgo.By(fmt.Sprintf("churning %v hosts with %v mutators during %v", hostCount, mutators, duration))
+		churn := newChurn(f)
+
+		var wg sync.WaitGroup
+		for i := 0; i < mutators; i++ {
+			wg.Add(1)
+			go func() {
+				defer ginkgo.GinkgoRecover()
+				defer wg.Done()
+				churn.run(ctx)
+			}()
+		}
+
+		load := newLoadGenerator(f.GetURL(framework.HTTP), stableHost, loadWorkers, loadInterval)
+		result := load.Run(ctx)
+
+		wg.Wait()
+
+		reloads := reloadCount(f) - reloadsBefore
+		framework.Logf("load: %v", result)
+		framework.Logf("mutations: %v, failed mutations: %v, reloads: %v", churn.mutations, churn.failures, reloads)
+
+		assert.NotZero(ginkgo.GinkgoT(), result.Requests, "expected requests to NGINX")
+		assert.NotZero(ginkgo.GinkgoT(), reloads, "expected reloads of NGINX")
+		assert.LessOrEqual(ginkgo.GinkgoT(), result.ErrorRatio(), maxErrorRatio, "error ratio over the budget (%v)", result)
+		assert.LessOrEqual(ginkgo.GinkgoT(), result.Percentile(99), maxP99Latency, "p99 latency over the budget (%v)", result)
+	})
+})
+
+// stressDuration returns the duration of the stress tests
+func stressDuration() time.Duration {
+	value := os.Getenv(DurationEnv)
+	if value == "" {
+		return defaultDuration
+	}
+
+	duration, err := time.ParseDuration(value)
+	assert.Nil(ginkgo.GinkgoT(), err, "parsing %v", DurationEnv)
+
+	return duration
+}
+
+// reloadCount returns the number of successful reloads of NGINX
+func reloadCount(f *framework.Framework) int {
+	mf, err := f.GetMetric("nginx_ingress_controller_success", f.GetNginxPodIP())
+	assert.Nil(ginkgo.GinkgoT(), err, "obtaining the reload count")
+
+	var count float64
+	for _, m := range mf.GetMetric() {
+		count += counterValue(m)
+	}
+
+	return int(count)
+}
+
+func counterValue(m *dto.Metric) float64 {
+	if m.GetCounter() != nil {
+		return m.GetCounter().GetValue()
+	}
+
+	return m.GetUntyped().GetValue()
+}
+
+// churn creates, updates and deletes ingresses with TLS secrets. The updates
+// of the ingresses require reloads while the updates of the secrets do not.
+type churn struct {
+	f *framework.Framework
+
+	mu sync.Mutex
+	// exists contains the hosts with an ingress
+	exists map[string]bool
+	// busy contains the hosts being mutated
+	busy      map[string]bool
+	mutations int
+	failures  int
+}
+
+func newChurn(f *framework.Framework) *churn {
+	return &churn{
+		f:      f,
+		exists: map[string]bool{},
+		busy:   map[string]bool{},
+	}
+}
+
+// run mutates random hosts until the context is done
+func (c *churn) run(ctx context.Context) {
+	//nolint:gosec // the mutations do not require a secure random generator
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	ticker := time.NewTicker(mutationInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		host := fmt.Sprintf("stress-%v.foo.com", r.Intn(hostCount))
+
+		c.mu.Lock()
+		if c.busy[host] {
+			// the host is mutated by another mutator
+			c.mu.Unlock()
+			continue
+		}
+		c.busy[host] = true
+		exists := c.exists[host]
+		c.mu.Unlock()
+
+		exists, err := c.mutate(ctx, host, exists, r.Intn(3), r.Intn(60))
+
+		c.mu.Lock()
+		delete(c.busy, host)
+		c.exists[host] = exists
+		c.mutations++
+		if err != nil && ctx.Err() == nil {
+			c.failures++
+			framework.Logf("mutating %v: %v", host, err)
+		}
+		c.mu.Unlock()
+	}
+}
+
+// mutate creates the ingress of the host or applies the operation op to it:
+// update the ingress, rotate the certificate or delete both. It returns if
+// the ingress exists after the mutation.
+func (c *churn) mutate(ctx context.Context, host string, exists bool, op, timeout int) (bool, error) {
+	ingresses := c.f.KubeClientSet.NetworkingV1().Ingresses(c.f.Namespace)
+	secrets := c.f.KubeClientSet.CoreV1().Secrets(c.f.Namespace)
+
+	if !exists {
+		if _, err := framework.CreateIngressTLSSecret(c.f.KubeClientSet, []string{host}, host, c.f.Namespace); err != nil {
+			return false, err
+		}
+
+		ing := framework.NewSingleIngressWithTLS(host, "/", host, []string{host}, c.f.Namespace, framework.EchoService, 80, nil)
+		_, err := ingresses.Create(ctx, ing, metav1.CreateOptions{})
+		if err != nil && !k8sErrors.IsAlreadyExists(err) {
+			return false, err
+		}
+		return true, nil
+	}
+
+	switch op {
+	case 0:
+		ing, err := ingresses.Get(ctx, host, metav1.GetOptions{})
+		if err != nil {
+			return true, err
+		}
+
+		if ing.Annotations == nil {
+			ing.Annotations = map[string]string{}
+		}
+		ing.Annotations["nginx.ingress.kubernetes.io/proxy-connect-timeout"] = fmt.Sprintf("%v", 1+timeout)
+
+		_, err = ingresses.Update(ctx, ing, metav1.UpdateOptions{})
+		return true, err
+	case 1:
+		_, err := framework.CreateIngressTLSSecret(c.f.KubeClientSet, []string{host}, host, c.f.Namespace)
+		return true, err
+	default:
+		if err := ingresses.Delete(ctx, host, metav1.DeleteOptions{}); err != nil && !k8sErrors.IsNotFound(err) {
+			return true, err
+		}
+		if err := secrets.Delete(ctx, host, metav1.DeleteOptions{}); err != nil && !k8sErrors.IsNotFound(err) {
+			return false, err
+		}
+		return false, nil
+	}
+}
@@ -24,6 +24,8 @@ DIR="$( cd "$( dirname "${BASH_SOURCE[0]}" )" && pwd )"
 export NAMESPACE=$1
 export NAMESPACE_OVERLAY=$2
 export IS_CHROOT=$3
+# optional values of the chart, like the additional flags of the controller
+export EXTRA_VALUES=$4
 
 echo "deploying NGINX Ingress controller in namespace $NAMESPACE"
 
@@ -52,9 +54,10 @@ if [[ ! -z "$NAMESPACE_OVERLAY" && -d "$DIR/namespace-overlays/$NAMESPACE_OVERLA
     echo "Namespace overlay $NAMESPACE_OVERLAY is being used for namespace $NAMESPACE"
     helm install nginx-ingress ${DIR}/charts/ingress-nginx \
         --namespace=$NAMESPACE \
-        --values "$DIR/namespace-overlays/$NAMESPACE_OVERLAY/values.yaml"
+        --values "$DIR/namespace-overlays/$NAMESPACE_OVERLAY/values.yaml" \
+        ${EXTRA_VALUES:+--values "$EXTRA_VALUES"}
 else
-    cat << EOF | helm install nginx-ingress ${DIR}/charts/ingress-nginx --namespace=$NAMESPACE --values -
+    cat << EOF | helm install nginx-ingress ${DIR}/charts/ingress-nginx --namespace=$NAMESPACE --values - ${EXTRA_VALUES:+--values "$EXTRA_VALUES"}
 # TODO: remove the need to use fullnameOverride
 fullnameOverride: nginx-ingress
 controller:
@@ -36,6 +36,13 @@ SHDICT_ARGS=(
     "--shdict" "high_throughput_tracker 1M"
     "--shdict" "balancer_ewma_last_touched_at 1M"
     "--shdict" "balancer_ewma_locks 512k"
+    "--shdict" "basic_auth_credentials 1M"
+    "--shdict" "oidc_clients 1M"
+    "--shdict" "lua_kv 1M"
+    "--shdict" "dns_cache_stats 1M"
+    "--shdict" "proxy_cache_fills 1M"
+    "--shdict" "auth_failure_decisions 1M"
+    "--shdict" "warmup_endpoints 1M"
     "./rootfs/etc/nginx/lua/test/run.lua"
 )
 